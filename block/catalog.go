@@ -584,6 +584,7 @@ func UserUnit(uc *UseCases, infra *Infra) compose.Unit {
 			CreateWorkspaceUserRole:      uc.WorkspaceUserRole.Create,
 			DeleteWorkspaceUserRole:      uc.WorkspaceUserRole.Delete,
 			ListRoles:                    uc.Role.List,
+			GetRoleItemPageData:          uc.Role.GetItemPageData,
 			GetDashboardData:             infra.GetDashboardData,
 			HashPassword:                 infra.HashPassword,
 			UploadFile:                   infra.UploadFile,
@@ -622,6 +623,7 @@ func RoleUnit(uc *UseCases, infra *Infra) compose.Unit {
 			CreateRolePermission:    uc.RolePermission.Create,
 			DeleteRolePermission:    uc.RolePermission.Delete,
 			ListPermissions:         uc.Permission.List,
+			ListRoles:               uc.Role.List,
 			GetUsersByRoleID:        infra.GetUsersByRoleID,
 			ListWorkspaceUsers:      uc.WorkspaceUser.List,
 			CreateWorkspaceUserRole: uc.WorkspaceUserRole.Create,
@@ -733,6 +735,7 @@ func WorkspaceUserUnit(uc *UseCases, infra *Infra) compose.Unit {
 			CreateWorkspaceUser:          uc.WorkspaceUser.Create,
 			DeleteWorkspaceUser:          uc.WorkspaceUser.Delete,
 			SetWorkspaceUserActive:       setActiveClosure(uc, "workspace_user"),
+			GetRoleItemPageData:          uc.Role.GetItemPageData,
 			WorkspaceUserRoleAddURL:      entity.WorkspaceUserRoleAddURL,
 			WorkspaceUserRoleDeleteURL:   entity.WorkspaceUserRoleDeleteURL,
 			UploadFile:                   infra.UploadFile,
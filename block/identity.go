@@ -123,6 +123,8 @@ func wireIdentityModule(ctx *consumerapp.AppContext, w identityWiring) {
 			CreateWorkspaceUserRole:      uc.WorkspaceUserRole.Create,
 			DeleteWorkspaceUserRole:      uc.WorkspaceUserRole.Delete,
 			ListRoles:                    uc.Role.List,
+			GetRoleItemPageData:          uc.Role.GetItemPageData,
+			ListPermissions:              uc.Permission.List,
 			GetDashboardData:             getDashboardData,
 			HashPassword:                 hashPassword,
 			UploadFile:                   uploadFile,
@@ -153,6 +155,7 @@ func wireIdentityModule(ctx *consumerapp.AppContext, w identityWiring) {
 			CreateRolePermission:    uc.RolePermission.Create,
 			DeleteRolePermission:    uc.RolePermission.Delete,
 			ListPermissions:         uc.Permission.List,
+			ListRoles:               uc.Role.List,
 			GetUsersByRoleID:        getUsersByRoleID,
 			ListWorkspaceUsers:      uc.WorkspaceUser.List,
 			CreateWorkspaceUserRole: uc.WorkspaceUserRole.Create,
@@ -272,6 +275,8 @@ func wireIdentityModule(ctx *consumerapp.AppContext, w identityWiring) {
 				CreateWorkspaceUser:          uc.WorkspaceUser.Create,
 				DeleteWorkspaceUser:          uc.WorkspaceUser.Delete,
 				SetWorkspaceUserActive:       setActiveClosure(uc, "workspace_user"),
+				GetRoleItemPageData:          uc.Role.GetItemPageData,
+				ListPermissions:              uc.Permission.List,
 				// Phase 3 closeout: wire WorkspaceUserRole routes now that Phase 3 has registered them.
 				WorkspaceUserRoleAddURL:    entity.WorkspaceUserRoleAddURL,
 				WorkspaceUserRoleDeleteURL: entity.WorkspaceUserRoleDeleteURL,
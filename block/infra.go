@@ -80,6 +80,8 @@ type Infra struct {
 	UserDashboardLabels entityuser.DashboardLabels
 
 	// RolePermissionLabels holds the role→permission junction tab strings.
+	// Seed it with entityrole.DefaultPermissionLabels() before loading, so
+	// the permission matrix has its copy.
 	RolePermissionLabels entityrole.PermissionLabels
 
 	// RoleUserLabels holds the role→user junction tab strings.
@@ -96,11 +96,13 @@ func loadBlockLabels(t *lynguaV1.TranslationProvider, businessType string) block
 	_ = t.LoadPathIfExists("en", businessType, "supplier_tag.json", "", &l.SupplierTag)
 	_ = t.LoadPathIfExists("en", businessType, "payment_term.json", "paymentTerm", &l.PaymentTerm)
 
+	l.User = entity.DefaultUserLabels()
 	if err := t.LoadPath("en", businessType, "user.json", "", &l.User); err != nil {
 		log.Printf("entydad.Block: warning: failed to load user labels: %v", err)
 	}
 	_ = t.LoadPathIfExists("en", businessType, "user.json", "user.dashboard", &l.UserDashboard)
 
+	l.Role = entity.DefaultRoleLabels()
 	if err := t.LoadPath("en", businessType, "role.json", "", &l.Role); err != nil {
 		log.Printf("entydad.Block: warning: failed to load role labels: %v", err)
 	}
@@ -109,9 +111,11 @@ func loadBlockLabels(t *lynguaV1.TranslationProvider, businessType string) block
 	}
 	l.LocationArea = entity.DefaultLocationAreaLabels()
 	_ = t.LoadPathIfExists("en", businessType, "location_area.json", "", &l.LocationArea)
+	l.Permission = entity.DefaultPermissionLabels()
 	if err := t.LoadPath("en", businessType, "permission.json", "", &l.Permission); err != nil {
 		log.Printf("entydad.Block: warning: failed to load permission labels: %v", err)
 	}
+	l.RolePermission = entity.DefaultRolePermissionLabels()
 	if err := t.LoadPath("en", businessType, "role_permission.json", "", &l.RolePermission); err != nil {
 		log.Printf("entydad.Block: warning: failed to load role_permission labels: %v", err)
 	}
@@ -121,9 +125,11 @@ func loadBlockLabels(t *lynguaV1.TranslationProvider, businessType string) block
 	if err := t.LoadPath("en", businessType, "role_user.json", "", &l.RoleUser); err != nil {
 		log.Printf("entydad.Block: warning: failed to load role_user labels: %v", err)
 	}
+	l.Workspace = entity.DefaultWorkspaceLabels()
 	if err := t.LoadPath("en", businessType, "workspace.json", "", &l.Workspace); err != nil {
 		log.Printf("entydad.Block: warning: failed to load workspace labels: %v", err)
 	}
+	l.WorkspaceUser = entity.DefaultWorkspaceUserLabels()
 	_ = t.LoadPathIfExists("en", businessType, "workspace_user.json", "", &l.WorkspaceUser)
 	_ = t.LoadPathIfExists("en", businessType, "workspace_user_role.json", "workspace_user_role", &l.WorkspaceUserRole)
 	if err := t.LoadPath("en", businessType, "supplier.json", "supplier", &l.Supplier); err != nil {
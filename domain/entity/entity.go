@@ -197,6 +197,8 @@ type UserRoleEmptyLabels = user.RoleEmptyLabels
 type UserRoleFormLabels = user.RoleFormLabels
 type UserRoleActionLabels = user.RoleActionLabels
 
+func DefaultUserLabels() UserLabels { return user.DefaultLabels() }
+
 type UserRoutes = user.Routes
 
 func DefaultUserRoutes() UserRoutes { return user.DefaultRoutes() }
@@ -223,6 +225,8 @@ type RolePermissionEmptyLabels = role.PermissionEmptyLabels
 type RolePermissionFormLabels = role.PermissionFormLabels
 type RolePermissionActionLabels = role.PermissionActionLabels
 type RoleUserLabels = role.UserLabels
+
+func DefaultRolePermissionLabels() RolePermissionLabels { return role.DefaultPermissionLabels() }
 type RoleUserPageLabels = role.UserPageLabels
 type RoleUserButtonLabels = role.UserButtonLabels
 type RoleUserColumnLabels = role.UserColumnLabels
@@ -230,6 +234,8 @@ type RoleUserEmptyLabels = role.UserEmptyLabels
 type RoleUserFormLabels = role.UserFormLabels
 type RoleUserActionLabels = role.UserActionLabels
 
+func DefaultRoleLabels() RoleLabels { return role.DefaultLabels() }
+
 type RoleRoutes = role.Routes
 
 func DefaultRoleRoutes() RoleRoutes { return role.DefaultRoutes() }
@@ -246,6 +252,8 @@ type PermissionEmptyLabels = permission.EmptyLabels
 type PermissionFormLabels = permission.FormLabels
 type PermissionActionLabels = permission.ActionLabels
 
+func DefaultPermissionLabels() PermissionLabels { return permission.DefaultLabels() }
+
 type PermissionRoutes = permission.Routes
 
 func DefaultPermissionRoutes() PermissionRoutes { return permission.DefaultRoutes() }
@@ -267,6 +275,8 @@ type WorkspaceEmptyLabels = workspace.EmptyLabels
 type WorkspaceFormLabels = workspace.FormLabels
 type WorkspaceActionLabels = workspace.ActionLabels
 
+func DefaultWorkspaceLabels() WorkspaceLabels { return workspace.DefaultLabels() }
+
 type WorkspaceRoutes = workspace.Routes
 
 func DefaultWorkspaceRoutes() WorkspaceRoutes { return workspace.DefaultRoutes() }
@@ -289,6 +299,8 @@ type WorkspaceUserDetailRolesLabels = workspaceuser.DetailRolesLabels
 type WorkspaceUserFormLabels = workspaceuser.FormLabels
 type WorkspaceUserActionLabels = workspaceuser.ActionLabels
 
+func DefaultWorkspaceUserLabels() WorkspaceUserLabels { return workspaceuser.DefaultLabels() }
+
 type WorkspaceUserRoutes = workspaceuser.Routes
 
 func DefaultWorkspaceUserRoutes() WorkspaceUserRoutes { return workspaceuser.DefaultRoutes() }
@@ -28,3 +28,15 @@ func identityHandleFunc(r view.RouteRegistrar, method, path string, handler http
 	}
 	log.Printf("identity: RouteRegistrar does not support HandleFunc — skipping %s %s", method, path)
 }
+
+// postPatterns turns action URLs into "POST <url>" ServeMux patterns for
+// the modules' SensitiveActions, skipping routes that are not set.
+func postPatterns(urls ...string) []string {
+	patterns := make([]string, 0, len(urls))
+	for _, u := range urls {
+		if u != "" {
+			patterns = append(patterns, http.MethodPost+" "+u)
+		}
+	}
+	return patterns
+}
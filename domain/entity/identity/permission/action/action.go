@@ -11,6 +11,7 @@ import (
 	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
 
 	permission "github.com/erniealice/entydad-golang/domain/entity/identity/permission"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
 	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/form"
 )
 
@@ -22,6 +23,7 @@ type Deps struct {
 	DeletePermission    func(ctx context.Context, req *permissionpb.DeletePermissionRequest) (*permissionpb.DeletePermissionResponse, error)
 	SetPermissionActive func(ctx context.Context, id string, active bool) error
 	Routes              permission.Routes
+	Labels              permission.Labels
 }
 
 // NewAddAction creates the permission add action (GET = form, POST = create).
@@ -49,6 +51,9 @@ func NewAddAction(deps *Deps) view.View {
 
 		r := viewCtx.Request
 		active := r.FormValue("active") == "true"
+		if !effective.ValidCode(r.FormValue("permission_code")) {
+			return view.HTMXError(deps.Labels.Errors.InvalidCode)
+		}
 
 		_, err := deps.CreatePermission(ctx, &permissionpb.CreatePermissionRequest{
 			Data: &permissionpb.Permission{
@@ -110,6 +115,9 @@ func NewEditAction(deps *Deps) view.View {
 
 		r := viewCtx.Request
 		active := r.FormValue("active") == "true"
+		if !effective.ValidCode(r.FormValue("permission_code")) {
+			return view.HTMXError(deps.Labels.Errors.InvalidCode)
+		}
 
 		_, err := deps.UpdatePermission(ctx, &permissionpb.UpdatePermissionRequest{
 			Data: &permissionpb.Permission{
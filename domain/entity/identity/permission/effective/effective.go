@@ -0,0 +1,273 @@
+// Package effective resolves the roles a workspace user holds into
+// permission decisions. It is the one place that defines how several roles
+// combine:
+//
+//   - a grant is a role_permission row: its role, its permission's code
+//     ("entity:action") and its type — the row's own PermissionType when
+//     set, otherwise the permission's (unspecified reads as ALLOW, as on the
+//     permission pages);
+//   - codes may use a wildcard for either half: "client:*" covers every
+//     client action, "*:read" the read action of every entity, "*:*" all;
+//   - an inactive assignment, role, role_permission row or permission takes
+//     no part in decisions (it is kept, with the reason, for display);
+//   - a matching DENY beats any number of matching ALLOWs, whichever role
+//     they come from; nothing matching means not granted.
+package effective
+
+import (
+	"sort"
+	"strings"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
+)
+
+// Wildcard matches any entity or any action.
+const Wildcard = "*"
+
+// Reasons a grant is inactive, in the order they are checked.
+const (
+	InactiveAssignment     = "assignment_inactive"
+	InactiveRole           = "role_inactive"
+	InactiveRolePermission = "role_permission_inactive"
+	InactivePermission     = "permission_inactive"
+)
+
+// Effect is the outcome of a decision.
+type Effect int
+
+const (
+	NotGranted Effect = iota
+	Allowed
+	Denied
+)
+
+// String returns "not_granted", "allowed" or "denied".
+func (e Effect) String() string {
+	switch e {
+	case Allowed:
+		return "allowed"
+	case Denied:
+		return "denied"
+	default:
+		return "not_granted"
+	}
+}
+
+// Grant is one permission carried by one role.
+type Grant struct {
+	RoleID           string
+	RoleName         string
+	RolePermissionID string
+	PermissionID     string
+	PermissionName   string
+	Code             string // may contain wildcards
+	Deny             bool
+	Inactive         string // "" when the grant takes part in decisions
+}
+
+// Active reports whether g takes part in decisions.
+func (g Grant) Active() bool {
+	return g.Inactive == ""
+}
+
+// Decision is the verdict on one code and the active grants behind it.
+type Decision struct {
+	Code   string
+	Effect Effect
+	Allow  []Grant // matching active ALLOW grants
+	Deny   []Grant // matching active DENY grants
+}
+
+// Allowed reports whether the code is granted.
+func (d Decision) Allowed() bool {
+	return d.Effect == Allowed
+}
+
+// Set is the resolved grants of one or more roles.
+type Set struct {
+	grants []Grant
+}
+
+// New returns a Set over the given grants.
+func New(grants []Grant) *Set {
+	return &Set{grants: grants}
+}
+
+// FromRoles resolves roles loaded with their RolePermissions (and each
+// row's Permission), as GetRoleItemPageData returns them. Rows without a
+// Permission are skipped.
+func FromRoles(roles ...*rolepb.Role) *Set {
+	s := &Set{}
+	for _, r := range roles {
+		s.addRole(r, "")
+	}
+	return s
+}
+
+// FromAssignments resolves a workspace user's role assignments. Each
+// assignment's Role must be loaded with its RolePermissions; assignments
+// without one are skipped.
+func FromAssignments(assignments []*workspaceuserrolepb.WorkspaceUserRole) *Set {
+	s := &Set{}
+	for _, a := range assignments {
+		if a.GetRole() == nil {
+			continue
+		}
+		inactive := ""
+		if !a.GetActive() {
+			inactive = InactiveAssignment
+		}
+		s.addRole(a.GetRole(), inactive)
+	}
+	return s
+}
+
+func (s *Set) addRole(r *rolepb.Role, inactive string) {
+	if inactive == "" && !r.GetActive() {
+		inactive = InactiveRole
+	}
+	for _, rp := range r.GetRolePermissions() {
+		p := rp.GetPermission()
+		if p == nil {
+			continue
+		}
+		g := Grant{
+			RoleID:           r.GetId(),
+			RoleName:         r.GetName(),
+			RolePermissionID: rp.GetId(),
+			PermissionID:     p.GetId(),
+			PermissionName:   p.GetName(),
+			Code:             p.GetPermissionCode(),
+			Deny:             IsDeny(rp),
+			Inactive:         inactive,
+		}
+		switch {
+		case g.Inactive != "":
+		case !rp.GetActive():
+			g.Inactive = InactiveRolePermission
+		case !p.GetActive():
+			g.Inactive = InactivePermission
+		}
+		s.grants = append(s.grants, g)
+	}
+}
+
+// IsDeny reports whether a role_permission row denies: the row's own type
+// when set, otherwise its permission's.
+func IsDeny(rp *rolepermissionpb.RolePermission) bool {
+	if t := rp.GetPermissionType(); t != permissionpb.PermissionType_PERMISSION_TYPE_UNSPECIFIED {
+		return t == permissionpb.PermissionType_PERMISSION_TYPE_DENY
+	}
+	return rp.GetPermission().GetPermissionType() == permissionpb.PermissionType_PERMISSION_TYPE_DENY
+}
+
+// Grants returns every grant, active or not, in role then code order.
+func (s *Set) Grants() []Grant {
+	out := append([]Grant(nil), s.grants...)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].RoleName != out[j].RoleName {
+			return out[i].RoleName < out[j].RoleName
+		}
+		return out[i].Code < out[j].Code
+	})
+	return out
+}
+
+// Decide returns the decision on code ("entity:action").
+func (s *Set) Decide(code string) Decision {
+	d := Decision{Code: code}
+	for _, g := range s.grants {
+		if !g.Active() || !Match(g.Code, code) {
+			continue
+		}
+		if g.Deny {
+			d.Deny = append(d.Deny, g)
+		} else {
+			d.Allow = append(d.Allow, g)
+		}
+	}
+	switch {
+	case len(d.Deny) > 0:
+		d.Effect = Denied
+	case len(d.Allow) > 0:
+		d.Effect = Allowed
+	}
+	return d
+}
+
+// Overridden reports whether g is an active ALLOW grant that a DENY in the
+// set blocks for every code it covers.
+func (s *Set) Overridden(g Grant) bool {
+	return g.Active() && !g.Deny && s.Decide(g.Code).Effect == Denied
+}
+
+// Can is Decide for an entity and action as passed to perms.Can.
+func (s *Set) Can(entity, action string) bool {
+	return s.Decide(entity + ":" + action).Allowed()
+}
+
+// Codes returns the codes among catalog that are allowed, sorted, with
+// duplicates removed. The session's UserPermissions matches codes exactly,
+// so feed it the permission catalog expanded this way rather than the raw
+// (possibly wildcard) grant codes.
+func (s *Set) Codes(catalog []string) []string {
+	seen := make(map[string]bool, len(catalog))
+	var out []string
+	for _, code := range catalog {
+		if seen[code] || strings.Contains(code, Wildcard) {
+			continue
+		}
+		seen[code] = true
+		if s.Decide(code).Allowed() {
+			out = append(out, code)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Runtime returns the permission set a request holding s carries: the
+// codes among catalog and s's own wildcard-free codes that s allows,
+// sorted. It is what view.WithUserPermissions gets (see the auth module's
+// PermissionsMiddleware), so a DENY removes its codes and a wildcard
+// reaches every catalog code it covers — but not a code no permission
+// defines.
+func (s *Set) Runtime(catalog []string) []string {
+	codes := append([]string(nil), catalog...)
+	for _, g := range s.grants {
+		codes = append(codes, g.Code)
+	}
+	return s.Codes(codes)
+}
+
+// Match reports whether the grant code pattern covers code. Either half of
+// pattern may be Wildcard; code is compared literally.
+func Match(pattern, code string) bool {
+	pe, pa, ok := strings.Cut(pattern, ":")
+	if !ok {
+		return false
+	}
+	ce, ca, ok := strings.Cut(code, ":")
+	if !ok {
+		return false
+	}
+	return (pe == Wildcard || pe == ce) && (pa == Wildcard || pa == ca)
+}
+
+// ValidCode reports whether code is a well-formed permission code: an
+// entity and an action separated by one colon, each non-empty, without
+// spaces, and either a name or exactly Wildcard.
+func ValidCode(code string) bool {
+	entity, action, ok := strings.Cut(code, ":")
+	return ok && validHalf(entity) && validHalf(action)
+}
+
+func validHalf(s string) bool {
+	if s == "" || strings.ContainsAny(s, ": \t\r\n") {
+		return false
+	}
+	return s == Wildcard || !strings.Contains(s, Wildcard)
+}
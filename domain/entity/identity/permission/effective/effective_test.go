@@ -0,0 +1,389 @@
+package effective
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"testing"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
+)
+
+const (
+	allow = permissionpb.PermissionType_PERMISSION_TYPE_ALLOW
+	deny  = permissionpb.PermissionType_PERMISSION_TYPE_DENY
+	unset = permissionpb.PermissionType_PERMISSION_TYPE_UNSPECIFIED
+)
+
+// row is a role_permission row for a test role.
+type row struct {
+	code       string
+	permType   permissionpb.PermissionType // the permission's type
+	linkType   permissionpb.PermissionType // the row's own type
+	linkOff    bool
+	permOff    bool
+	permission bool // false ⇒ Permission not loaded
+}
+
+func grant(code string) row                          { return row{code: code, permType: allow, permission: true} }
+func denyGrant(code string) row                      { return row{code: code, permType: deny, permission: true} }
+func (r row) link(t permissionpb.PermissionType) row { r.linkType = t; return r }
+func (r row) rowInactive() row                       { r.linkOff = true; return r }
+func (r row) permInactive() row                      { r.permOff = true; return r }
+
+func testRole(id string, active bool, rows ...row) *rolepb.Role {
+	r := &rolepb.Role{Id: id, Name: id, Active: active}
+	for i, rw := range rows {
+		rp := &rolepermissionpb.RolePermission{
+			Id:             id + "-rp" + string(rune('0'+i)),
+			RoleId:         id,
+			PermissionType: rw.linkType,
+			Active:         !rw.linkOff,
+		}
+		if rw.permission || rw.code != "" {
+			rp.Permission = &permissionpb.Permission{
+				Id:             "perm-" + rw.code,
+				Name:           rw.code,
+				PermissionCode: rw.code,
+				PermissionType: rw.permType,
+				Active:         !rw.permOff,
+			}
+		}
+		r.RolePermissions = append(r.RolePermissions, rp)
+	}
+	return r
+}
+
+func TestDecide(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		roles     []*rolepb.Role
+		code      string
+		want      Effect
+		wantAllow []string // role ids of the matching ALLOW grants
+		wantDeny  []string // role ids of the matching DENY grants
+	}{
+		{name: "no roles", code: "client:list", want: NotGranted},
+		{name: "exact allow", roles: []*rolepb.Role{testRole("sales", true, grant("client:list"))}, code: "client:list", want: Allowed, wantAllow: []string{"sales"}},
+		{name: "other code", roles: []*rolepb.Role{testRole("sales", true, grant("client:read"))}, code: "client:list", want: NotGranted},
+		{name: "entity wildcard", roles: []*rolepb.Role{testRole("sales", true, grant("client:*"))}, code: "client:delete", want: Allowed, wantAllow: []string{"sales"}},
+		{name: "entity wildcard other entity", roles: []*rolepb.Role{testRole("sales", true, grant("client:*"))}, code: "supplier:list", want: NotGranted},
+		{name: "action wildcard", roles: []*rolepb.Role{testRole("auditor", true, grant("*:read"))}, code: "invoice:read", want: Allowed, wantAllow: []string{"auditor"}},
+		{name: "action wildcard other action", roles: []*rolepb.Role{testRole("auditor", true, grant("*:read"))}, code: "invoice:update", want: NotGranted},
+		{name: "full wildcard", roles: []*rolepb.Role{testRole("owner", true, grant("*:*"))}, code: "role:delete", want: Allowed, wantAllow: []string{"owner"}},
+		{name: "unspecified type reads as allow", roles: []*rolepb.Role{testRole("sales", true, row{code: "client:list", permType: unset, permission: true})}, code: "client:list", want: Allowed, wantAllow: []string{"sales"}},
+		{name: "exact deny", roles: []*rolepb.Role{testRole("intern", true, denyGrant("client:delete"))}, code: "client:delete", want: Denied, wantDeny: []string{"intern"}},
+		{
+			name:      "deny in the same role beats allow",
+			roles:     []*rolepb.Role{testRole("sales", true, grant("client:*"), denyGrant("client:delete"))},
+			code:      "client:delete",
+			want:      Denied,
+			wantAllow: []string{"sales"},
+			wantDeny:  []string{"sales"},
+		},
+		{
+			name:      "deny from another role beats allow",
+			roles:     []*rolepb.Role{testRole("owner", true, grant("*:*")), testRole("intern", true, denyGrant("client:delete"))},
+			code:      "client:delete",
+			want:      Denied,
+			wantAllow: []string{"owner"},
+			wantDeny:  []string{"intern"},
+		},
+		{
+			name:      "wildcard deny beats exact allow",
+			roles:     []*rolepb.Role{testRole("sales", true, grant("client:delete")), testRole("readonly", true, denyGrant("*:delete"))},
+			code:      "client:delete",
+			want:      Denied,
+			wantAllow: []string{"sales"},
+			wantDeny:  []string{"readonly"},
+		},
+		{
+			name:      "deny elsewhere leaves other codes allowed",
+			roles:     []*rolepb.Role{testRole("sales", true, grant("client:*"), denyGrant("client:delete"))},
+			code:      "client:list",
+			want:      Allowed,
+			wantAllow: []string{"sales"},
+		},
+		{
+			name:      "allows from several roles",
+			roles:     []*rolepb.Role{testRole("a", true, grant("client:list")), testRole("b", true, grant("client:*"))},
+			code:      "client:list",
+			want:      Allowed,
+			wantAllow: []string{"a", "b"},
+		},
+		{name: "row type deny overrides permission allow", roles: []*rolepb.Role{testRole("sales", true, grant("client:list").link(deny))}, code: "client:list", want: Denied, wantDeny: []string{"sales"}},
+		{name: "row type allow overrides permission deny", roles: []*rolepb.Role{testRole("sales", true, denyGrant("client:list").link(allow))}, code: "client:list", want: Allowed, wantAllow: []string{"sales"}},
+		{name: "inactive role ignored", roles: []*rolepb.Role{testRole("sales", false, grant("client:list"))}, code: "client:list", want: NotGranted},
+		{name: "inactive row ignored", roles: []*rolepb.Role{testRole("sales", true, grant("client:list").rowInactive())}, code: "client:list", want: NotGranted},
+		{name: "inactive permission ignored", roles: []*rolepb.Role{testRole("sales", true, grant("client:list").permInactive())}, code: "client:list", want: NotGranted},
+		{
+			name:  "inactive deny does not block",
+			roles: []*rolepb.Role{testRole("sales", true, grant("client:*"), denyGrant("client:delete").permInactive()), testRole("intern", false, denyGrant("*:*"))},
+			code:  "client:delete", want: Allowed, wantAllow: []string{"sales"},
+		},
+		{name: "row without permission skipped", roles: []*rolepb.Role{testRole("sales", true, row{})}, code: "client:list", want: NotGranted},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			d := FromRoles(tt.roles...).Decide(tt.code)
+			if d.Effect != tt.want {
+				t.Fatalf("Decide(%q) = %s, want %s", tt.code, d.Effect, tt.want)
+			}
+			if got := roleIDs(d.Allow); !slices.Equal(got, tt.wantAllow) {
+				t.Errorf("Allow = %v, want %v", got, tt.wantAllow)
+			}
+			if got := roleIDs(d.Deny); !slices.Equal(got, tt.wantDeny) {
+				t.Errorf("Deny = %v, want %v", got, tt.wantDeny)
+			}
+		})
+	}
+}
+
+func roleIDs(grants []Grant) []string {
+	var out []string
+	for _, g := range grants {
+		out = append(out, g.RoleID)
+	}
+	return out
+}
+
+func TestFromAssignments(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name         string
+		assignments  []*workspaceuserrolepb.WorkspaceUserRole
+		want         Effect
+		wantInactive []string
+	}{
+		{
+			name:        "active assignment",
+			assignments: []*workspaceuserrolepb.WorkspaceUserRole{{RoleId: "sales", Active: true, Role: testRole("sales", true, grant("client:list"))}},
+			want:        Allowed, wantInactive: []string{""},
+		},
+		{
+			name:        "inactive assignment ignored",
+			assignments: []*workspaceuserrolepb.WorkspaceUserRole{{RoleId: "sales", Active: false, Role: testRole("sales", true, grant("client:list"))}},
+			want:        NotGranted, wantInactive: []string{InactiveAssignment},
+		},
+		{
+			name:        "inactive assignment is reported before an inactive role",
+			assignments: []*workspaceuserrolepb.WorkspaceUserRole{{RoleId: "sales", Active: false, Role: testRole("sales", false, grant("client:list"))}},
+			want:        NotGranted, wantInactive: []string{InactiveAssignment},
+		},
+		{
+			name: "inactive deny assignment does not block",
+			assignments: []*workspaceuserrolepb.WorkspaceUserRole{
+				{RoleId: "sales", Active: true, Role: testRole("sales", true, grant("client:list"))},
+				{RoleId: "intern", Active: false, Role: testRole("intern", true, denyGrant("client:*"))},
+			},
+			want: Allowed, wantInactive: []string{InactiveAssignment, ""},
+		},
+		{
+			name:        "assignment without a loaded role skipped",
+			assignments: []*workspaceuserrolepb.WorkspaceUserRole{{RoleId: "sales", Active: true}},
+			want:        NotGranted,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := FromAssignments(tt.assignments)
+			if got := s.Decide("client:list").Effect; got != tt.want {
+				t.Fatalf("Decide = %s, want %s", got, tt.want)
+			}
+			var inactive []string
+			for _, g := range s.Grants() {
+				inactive = append(inactive, g.Inactive)
+			}
+			if !slices.Equal(inactive, tt.wantInactive) {
+				t.Fatalf("Inactive = %q, want %q", inactive, tt.wantInactive)
+			}
+		})
+	}
+}
+
+func TestCodes(t *testing.T) {
+	t.Parallel()
+
+	s := FromRoles(testRole("sales", true, grant("client:*"), denyGrant("client:delete"), grant("*:read")))
+	catalog := []string{"client:list", "client:delete", "client:*", "invoice:read", "invoice:update", "client:list"}
+	if got, want := s.Codes(catalog), []string{"client:list", "invoice:read"}; !slices.Equal(got, want) {
+		t.Fatalf("Codes = %v, want %v", got, want)
+	}
+	if !s.Can("client", "list") || s.Can("client", "delete") {
+		t.Fatal("Can disagrees with Decide")
+	}
+}
+
+func TestRuntime(t *testing.T) {
+	t.Parallel()
+
+	// A literal ALLOW outside the catalog still counts; the DENY removes
+	// client:delete although an ALLOW names it exactly, and the wildcard
+	// does not invent client:export, which no permission defines.
+	s := FromRoles(
+		testRole("sales", true, grant("client:*"), grant("client:delete"), grant("report:run")),
+		testRole("auditor", true, denyGrant("client:delete")),
+	)
+	if got, want := s.Runtime([]string{"client:list", "client:delete"}), []string{"client:list", "report:run"}; !slices.Equal(got, want) {
+		t.Fatalf("Runtime = %v, want %v", got, want)
+	}
+}
+
+func TestMatchAndValidCode(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		pattern, code string
+		match         bool
+	}{
+		{"client:list", "client:list", true},
+		{"client:list", "client:read", false},
+		{"client:*", "client:list", true},
+		{"*:list", "client:list", true},
+		{"*:*", "client:list", true},
+		{"client:*", "clients:list", false},
+		{"client", "client:list", false},
+		{"client:list", "client", false},
+		{"*", "client:list", false},
+	}
+	for _, tt := range tests {
+		if got := Match(tt.pattern, tt.code); got != tt.match {
+			t.Errorf("Match(%q, %q) = %v, want %v", tt.pattern, tt.code, got, tt.match)
+		}
+	}
+
+	valid := []string{"client:list", "workspace_user:read", "reports:view", "client:*", "*:read", "*:*"}
+	invalid := []string{"", "client", "client:", ":list", "client:list:all", "cli*:list", "client:li st", "*", "client:*list"}
+	for _, c := range valid {
+		if !ValidCode(c) {
+			t.Errorf("ValidCode(%q) = false, want true", c)
+		}
+	}
+	for _, c := range invalid {
+		if ValidCode(c) {
+			t.Errorf("ValidCode(%q) = true, want false", c)
+		}
+	}
+}
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+
+	s := FromAssignments([]*workspaceuserrolepb.WorkspaceUserRole{
+		{RoleId: "owner", Active: true, Role: testRole("owner", true, grant("*:*"))},
+		{RoleId: "intern", Active: true, Role: testRole("intern", true, denyGrant("client:delete"), grant("client:list"))},
+		{RoleId: "sales", Active: true, Role: testRole("sales", false, grant("client:*"))},
+		{RoleId: "auditor", Active: false, Role: testRole("auditor", true, denyGrant("*:delete"))},
+	})
+
+	e := s.Explain("client:delete")
+	if e.Effect != Denied {
+		t.Fatalf("Effect = %s, want denied", e.Effect)
+	}
+	if got := roleIDs(e.Deny); !slices.Equal(got, []string{"intern"}) {
+		t.Errorf("Deny = %v", got)
+	}
+	if got := roleIDs(e.Allow); !slices.Equal(got, []string{"owner"}) {
+		t.Errorf("Allow = %v", got)
+	}
+	if got := roleIDs(e.Inactive); !slices.Equal(got, []string{"auditor", "sales"}) {
+		t.Errorf("Inactive = %v", got)
+	}
+	if d := s.Decide("client:delete"); d.Effect != e.Effect || len(d.Allow) != len(e.Allow) || len(d.Deny) != len(e.Deny) {
+		t.Errorf("Explain disagrees with Decide: %+v", d)
+	}
+
+	text := e.Text("Rosa Diaz <rosa@example.com>")
+	for _, want := range []string{
+		"Permission: client:delete\n",
+		"User: Rosa Diaz <rosa@example.com>\n",
+		"Decision: denied\n",
+		"Denied by:\n  - role \"intern\": DENY client:delete",
+		"Granted by (overridden):\n  - role \"owner\": ALLOW *:*",
+		"- role \"auditor\": DENY *:delete (*:delete) [assignment_inactive]",
+		"- role \"sales\": ALLOW client:* (client:*) [role_inactive]",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("Text missing %q:\n%s", want, text)
+		}
+	}
+
+	if e := s.Explain("invoice:read"); e.Effect != Allowed || !strings.Contains(e.Text(""), "Inactive (ignored):\n  (none)") {
+		t.Errorf("invoice:read = %s\n%s", e.Effect, e.Text(""))
+	}
+	if !ExplainableCode("client:delete") || ExplainableCode("client:*") || ExplainableCode("client") {
+		t.Error("ExplainableCode accepts wildcards or malformed codes")
+	}
+}
+
+// ExplainRuntime agrees with the runtime set: a code only a wildcard
+// reaches, which no permission defines, is not granted.
+func TestExplainRuntime(t *testing.T) {
+	t.Parallel()
+
+	s := FromRoles(testRole("sales", true, grant("client:*"), denyGrant("client:delete"), grant("report:run")))
+	catalog := []string{"client:list", "client:delete"}
+	for _, tt := range []struct {
+		code      string
+		effect    Effect
+		undefined bool
+	}{
+		{"client:list", Allowed, false},
+		{"client:delete", Denied, false},
+		{"report:run", Allowed, false},
+		{"client:export", NotGranted, true},
+		{"invoice:read", NotGranted, false},
+	} {
+		e := s.ExplainRuntime(tt.code, catalog)
+		if e.Effect != tt.effect || e.Undefined != tt.undefined {
+			t.Errorf("%s: Effect = %s, Undefined = %v; want %s, %v", tt.code, e.Effect, e.Undefined, tt.effect, tt.undefined)
+		}
+		if held := slices.Contains(s.Runtime(catalog), tt.code); held != e.Allowed() {
+			t.Errorf("%s: allowed = %v, but in the runtime set = %v", tt.code, e.Allowed(), held)
+		}
+	}
+	if text := s.ExplainRuntime("client:export", catalog).Text(""); !strings.Contains(text, "no permission defines this code") {
+		t.Errorf("Text:\n%s", text)
+	}
+}
+
+func TestLoadAssignments(t *testing.T) {
+	t.Parallel()
+
+	full := testRole("sales", true, grant("client:list"))
+	get := func(_ context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error) {
+		if req.GetRoleId() != "sales" {
+			t.Fatalf("loaded role %q", req.GetRoleId())
+		}
+		return &rolepb.GetRoleItemPageDataResponse{Role: full}, nil
+	}
+	in := []*workspaceuserrolepb.WorkspaceUserRole{
+		{Id: "wur-1", RoleId: "sales", Active: true, Role: &rolepb.Role{Id: "sales", Name: "sales", Active: true}},
+	}
+
+	out, err := LoadAssignments(context.Background(), in, get)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !FromAssignments(out).Decide("client:list").Allowed() {
+		t.Fatal("loaded role does not grant client:list")
+	}
+	if len(in[0].GetRole().GetRolePermissions()) != 0 {
+		t.Fatal("LoadAssignments modified its input")
+	}
+	if same, _ := LoadAssignments(context.Background(), in, nil); !slices.Equal(same, in) {
+		t.Fatal("nil GetRole should return the assignments as given")
+	}
+}
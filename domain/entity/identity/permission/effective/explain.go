@@ -0,0 +1,155 @@
+package effective
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
+)
+
+// Explanation is a Decision together with the grants that match the code
+// but take no part because they are inactive — the full trace behind "why
+// can (or can't) this user do X".
+type Explanation struct {
+	Decision
+	Inactive []Grant // matching grants that are inactive, with the reason
+	// Undefined is set by ExplainRuntime when only a wildcard allows the
+	// code but no permission defines it, so the runtime set lacks it.
+	Undefined bool
+}
+
+// Explain returns the decision on code and its trace. Grants are in role
+// then code order.
+func (s *Set) Explain(code string) Explanation {
+	e := Explanation{Decision: Decision{Code: code}}
+	for _, g := range s.Grants() {
+		if !Match(g.Code, code) {
+			continue
+		}
+		switch {
+		case !g.Active():
+			e.Inactive = append(e.Inactive, g)
+		case g.Deny:
+			e.Deny = append(e.Deny, g)
+		default:
+			e.Allow = append(e.Allow, g)
+		}
+	}
+	switch {
+	case len(e.Deny) > 0:
+		e.Effect = Denied
+	case len(e.Allow) > 0:
+		e.Effect = Allowed
+	}
+	return e
+}
+
+// ExplainRuntime is Explain with the verdict taken from the runtime
+// permission set over catalog (see Runtime) — the set perms.Can checks —
+// rather than from the matching grants alone.
+func (s *Set) ExplainRuntime(code string, catalog []string) Explanation {
+	e := s.Explain(code)
+	if e.Effect == Allowed && !slices.Contains(s.Runtime(catalog), code) {
+		e.Effect, e.Undefined = NotGranted, true
+	}
+	return e
+}
+
+// Text renders e as plain text for pasting into a support ticket. subject
+// names whose access was checked (e.g. "Rosa Diaz <rosa@example.com>");
+// the wording is fixed English so tickets read the same whatever the
+// operator's language.
+func (e Explanation) Text(subject string) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Permission: %s\n", e.Code)
+	if subject != "" {
+		fmt.Fprintf(&b, "User: %s\n", subject)
+	}
+	fmt.Fprintf(&b, "Decision: %s\n", e.Effect)
+	switch e.Effect {
+	case Denied:
+		b.WriteString("Reason: a DENY matches; DENY wins over any ALLOW\n")
+	case NotGranted:
+		if e.Undefined {
+			b.WriteString("Reason: only a wildcard grants it and no permission defines this code\n")
+		} else {
+			b.WriteString("Reason: no active role grants it\n")
+		}
+	}
+	section := func(title string, grants []Grant) {
+		fmt.Fprintf(&b, "\n%s:\n", title)
+		if len(grants) == 0 {
+			b.WriteString("  (none)\n")
+			return
+		}
+		for _, g := range grants {
+			fmt.Fprintf(&b, "  - role %q: %s %s", g.RoleName, effectWord(g), g.Code)
+			if g.PermissionName != "" {
+				fmt.Fprintf(&b, " (%s)", g.PermissionName)
+			}
+			if g.Inactive != "" {
+				fmt.Fprintf(&b, " [%s]", g.Inactive)
+			}
+			fmt.Fprintf(&b, " role_id=%s role_permission_id=%s\n", g.RoleID, g.RolePermissionID)
+		}
+	}
+	section("Denied by", e.Deny)
+	if e.Effect == Denied {
+		section("Granted by (overridden)", e.Allow)
+	} else {
+		section("Granted by", e.Allow)
+	}
+	section("Inactive (ignored)", e.Inactive)
+	return b.String()
+}
+
+func effectWord(g Grant) string {
+	if g.Deny {
+		return "DENY"
+	}
+	return "ALLOW"
+}
+
+// ExplainableCode reports whether code can be explained: a valid code
+// naming one entity and one action, without wildcards.
+func ExplainableCode(code string) bool {
+	return ValidCode(code) && !strings.Contains(code, Wildcard)
+}
+
+// GetRole loads a role with its RolePermissions and each row's Permission —
+// the role use cases' GetRoleItemPageData.
+type GetRole func(ctx context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error)
+
+// LoadAssignments returns assignments with each Role replaced by the one
+// get loads, for callers whose assignments carry only the role's own row.
+// The inputs are not modified. A nil get returns assignments as given.
+func LoadAssignments(ctx context.Context, assignments []*workspaceuserrolepb.WorkspaceUserRole, get GetRole) ([]*workspaceuserrolepb.WorkspaceUserRole, error) {
+	if get == nil {
+		return assignments, nil
+	}
+	out := make([]*workspaceuserrolepb.WorkspaceUserRole, 0, len(assignments))
+	for _, a := range assignments {
+		roleID := a.GetRoleId()
+		if roleID == "" {
+			roleID = a.GetRole().GetId()
+		}
+		if roleID == "" {
+			continue
+		}
+		resp, err := get(ctx, &rolepb.GetRoleItemPageDataRequest{RoleId: roleID})
+		if err != nil {
+			return nil, fmt.Errorf("load role %s: %w", roleID, err)
+		}
+		out = append(out, &workspaceuserrolepb.WorkspaceUserRole{
+			Id:              a.GetId(),
+			WorkspaceUserId: a.GetWorkspaceUserId(),
+			RoleId:          roleID,
+			Role:            resp.GetRole(),
+			Active:          a.GetActive(),
+		})
+	}
+	return out, nil
+}
@@ -0,0 +1,271 @@
+// Package explain builds the permission explainer on the user and
+// workspace_user detail pages: the operator picks a permission code, or an
+// entity and action as passed to perms.Can, and sees the decision with the
+// grants behind it — the roles that grant it, the DENY that blocks it and
+// the inactive rows that take no part. Each detail page renders Data in its
+// own "access" tab and serves the same trace as plain text for tickets.
+package explain
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/erniealice/pyeza-golang/types"
+
+	permission "github.com/erniealice/entydad-golang/domain/entity/identity/permission"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
+)
+
+// Assignments loads the role assignments of the user being explained. It
+// is only called once a valid code has been asked about.
+type Assignments func(ctx context.Context) ([]*workspaceuserrolepb.WorkspaceUserRole, error)
+
+// Catalog returns the permission codes the workspace defines, the catalog
+// the runtime permission set is expanded against (effective.Set.Runtime).
+type Catalog func(ctx context.Context) ([]string, error)
+
+// ListCatalog returns the Catalog of every code list returns — the
+// permission use cases' List. Nil for a nil list.
+func ListCatalog(list func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)) Catalog {
+	if list == nil {
+		return nil
+	}
+	return func(ctx context.Context) ([]string, error) {
+		resp, err := list(ctx, &permissionpb.ListPermissionsRequest{})
+		if err != nil {
+			return nil, err
+		}
+		codes := make([]string, 0, len(resp.GetData()))
+		for _, p := range resp.GetData() {
+			codes = append(codes, p.GetPermissionCode())
+		}
+		return codes, nil
+	}
+}
+
+// Input is what a detail page passes to Build.
+type Input struct {
+	Labels      permission.ExplainLabels // see permission.DefaultExplainLabels
+	TableLabels types.TableLabels
+	Query       url.Values // the form: "code", or "entity" and "action"
+	Assignments Assignments
+	GetRole     effective.GetRole // optional, see effective.LoadAssignments
+	Catalog     Catalog           // optional: nil counts the code as defined
+	FormURL     string            // the tab action URL the form submits to
+	TextURL     string            // the plain-text export; the query is appended
+	Subject     string            // whose access is explained, for the text
+}
+
+// Data is the explainer state for the "access" tab templates.
+type Data struct {
+	Labels  permission.ExplainLabels
+	FormURL string
+	// Form values, echoed back.
+	Code   string
+	Entity string
+	Action string
+	// Set once a valid code has been checked.
+	Checked      bool
+	CheckedCode  string
+	Verdict      string
+	VerdictHelp  string
+	Variant      string // badge variant of the verdict
+	Table        *types.TableConfig
+	Text         string
+	TextURL      string
+	ErrorMessage string
+}
+
+// Code returns the code the form asks about: "code" when given, otherwise
+// "entity" and "action" joined as perms.Can joins them. asked is false when
+// the form is empty (the tab's first load).
+func Code(q url.Values) (code string, asked bool) {
+	if c := strings.TrimSpace(q.Get("code")); c != "" {
+		return c, true
+	}
+	entity, action := strings.TrimSpace(q.Get("entity")), strings.TrimSpace(q.Get("action"))
+	if entity == "" && action == "" {
+		return "", false
+	}
+	return entity + ":" + action, true
+}
+
+// Explain loads the assignments (and each role through get when set) and
+// explains code. The verdict is whether code is in the runtime permission
+// set over catalog — the set the auth module's PermissionsMiddleware puts
+// on the session — so it matches what perms.Can answers. A nil catalog
+// counts code as defined.
+func Explain(ctx context.Context, code string, assignments Assignments, get effective.GetRole, catalog Catalog) (effective.Explanation, error) {
+	list, err := assignments(ctx)
+	if err != nil {
+		return effective.Explanation{}, err
+	}
+	loaded, err := effective.LoadAssignments(ctx, list, get)
+	if err != nil {
+		return effective.Explanation{}, err
+	}
+	codes := []string{code}
+	if catalog != nil {
+		if codes, err = catalog(ctx); err != nil {
+			return effective.Explanation{}, err
+		}
+	}
+	return effective.FromAssignments(loaded).ExplainRuntime(code, codes), nil
+}
+
+// Build returns the explainer state for in.Query.
+func Build(ctx context.Context, in Input) *Data {
+	l := in.Labels
+	q := in.Query
+	d := &Data{
+		Labels:  l,
+		FormURL: in.FormURL,
+		Code:    strings.TrimSpace(q.Get("code")),
+		Entity:  strings.TrimSpace(q.Get("entity")),
+		Action:  strings.TrimSpace(q.Get("action")),
+	}
+	code, asked := Code(q)
+	if !asked {
+		return d
+	}
+	if !effective.ExplainableCode(code) {
+		d.ErrorMessage = l.ErrorCode
+		return d
+	}
+	e, err := Explain(ctx, code, in.Assignments, in.GetRole, in.Catalog)
+	if err != nil {
+		log.Printf("Failed to explain %s: %v", code, err)
+		d.ErrorMessage = l.ErrorLoad
+		return d
+	}
+
+	d.Checked = true
+	d.CheckedCode = code
+	switch e.Effect {
+	case effective.Allowed:
+		d.Verdict, d.Variant = l.Allowed, "success"
+	case effective.Denied:
+		d.Verdict, d.VerdictHelp, d.Variant = l.Denied, l.DeniedHelp, "danger"
+	default:
+		d.Verdict, d.VerdictHelp, d.Variant = l.NotGranted, l.NotGrantedHelp, "warning"
+		if e.Undefined {
+			d.VerdictHelp = l.UndefinedHelp
+		}
+	}
+	d.Table = buildTable(e, l, in.TableLabels)
+	d.Text = e.Text(in.Subject)
+	if in.TextURL != "" {
+		d.TextURL = in.TextURL + "?code=" + url.QueryEscape(code)
+	}
+	return d
+}
+
+// buildTable lists the trace: DENY grants first, then ALLOW grants, then
+// the inactive ones.
+func buildTable(e effective.Explanation, l permission.ExplainLabels, tl types.TableLabels) *types.TableConfig {
+	columns := []types.TableColumn{
+		{Key: "role", Label: l.ColumnRole},
+		{Key: "code", Label: l.ColumnCode},
+		{Key: "type", Label: l.ColumnType, WidthClass: "col-2xl"},
+		{Key: "outcome", Label: l.ColumnOutcome, WidthClass: "col-4xl"},
+	}
+
+	rows := []types.TableRow{}
+	add := func(g effective.Grant, outcome, variant string) {
+		permType, typeVariant := l.Allow, "success"
+		if g.Deny {
+			permType, typeVariant = l.Deny, "danger"
+		}
+		code := g.Code
+		if g.PermissionName != "" {
+			code += " — " + g.PermissionName
+		}
+		rows = append(rows, types.TableRow{
+			ID: g.RolePermissionID,
+			Cells: []types.TableCell{
+				{Type: "text", Value: g.RoleName},
+				{Type: "text", Value: code},
+				{Type: "badge", Value: permType, Variant: typeVariant},
+				{Type: "badge", Value: outcome, Variant: variant},
+			},
+			DataAttrs: map[string]string{
+				"testid": "permission-explain-row-" + g.RolePermissionID,
+			},
+		})
+	}
+	for _, g := range e.Deny {
+		add(g, l.Blocks, "danger")
+	}
+	for _, g := range e.Allow {
+		if e.Effect == effective.Denied {
+			add(g, l.Overridden, "warning")
+		} else {
+			add(g, l.Grants, "success")
+		}
+	}
+	for _, g := range e.Inactive {
+		add(g, inactiveLabel(g.Inactive, l), "default")
+	}
+
+	types.ApplyColumnStyles(columns, rows)
+
+	tc := &types.TableConfig{
+		ID:          "permission-explain-table",
+		Columns:     columns,
+		Rows:        rows,
+		Labels:      tl,
+		ShowSearch:  false,
+		ShowActions: false,
+		ShowSort:    false,
+		ShowColumns: false,
+		ShowDensity: false,
+		ShowEntries: false,
+		EmptyState: types.TableEmptyState{
+			Title:   l.Trace,
+			Message: l.NoTrace,
+		},
+	}
+	types.ApplyTableSettings(tc)
+	return tc
+}
+
+func inactiveLabel(reason string, l permission.ExplainLabels) string {
+	switch reason {
+	case effective.InactiveAssignment:
+		return l.AssignmentInactive
+	case effective.InactiveRole:
+		return l.RoleInactive
+	case effective.InactiveRolePermission:
+		return l.RolePermissionInactive
+	default:
+		return l.PermissionInactive
+	}
+}
+
+// WriteText writes the trace for the request's code as a plain-text
+// download. name identifies the user in the file name.
+func WriteText(w http.ResponseWriter, r *http.Request, name, subject string, assignments Assignments, get effective.GetRole, catalog Catalog) {
+	code, _ := Code(r.URL.Query())
+	if !effective.ExplainableCode(code) {
+		http.Error(w, "invalid permission code", http.StatusBadRequest)
+		return
+	}
+	e, err := Explain(r.Context(), code, assignments, get, catalog)
+	if err != nil {
+		log.Printf("permission explain export: %s for %s: %v", code, name, err)
+		http.Error(w, "failed to explain permission", http.StatusInternalServerError)
+		return
+	}
+	filename := fmt.Sprintf("permission-%s-%s-%s.txt", strings.ReplaceAll(code, ":", "-"), name, time.Now().Format("2006-01-02"))
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	_, _ = io.WriteString(w, e.Text(subject))
+}
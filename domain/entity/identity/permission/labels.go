@@ -15,6 +15,7 @@ type Labels struct {
 	Empty   EmptyLabels  `json:"empty"`
 	Form    FormLabels   `json:"form"`
 	Actions ActionLabels `json:"actions"`
+	Errors  ErrorLabels  `json:"errors"`
 }
 
 type PageLabels struct {
@@ -64,3 +65,49 @@ type ActionLabels struct {
 	Activate   string `json:"activate"`
 	Deactivate string `json:"deactivate"`
 }
+
+// ErrorLabels holds the permission drawer's validation messages.
+type ErrorLabels struct {
+	InvalidCode string `json:"invalidCode"`
+}
+
+// ExplainLabels holds labels for the permission explainer — the "Access"
+// tab of the user and workspace_user detail pages.
+type ExplainLabels struct {
+	Tab             string `json:"tab"`
+	Title           string `json:"title"`
+	Help            string `json:"help"`
+	Code            string `json:"code"`
+	CodePlaceholder string `json:"codePlaceholder"`
+	Entity          string `json:"entity"`
+	Action          string `json:"action"`
+	Or              string `json:"or"`
+	Check           string `json:"check"`
+	ErrorCode       string `json:"errorCode"`
+	ErrorLoad       string `json:"errorLoad"`
+	Allowed         string `json:"allowed"`
+	Denied          string `json:"denied"`
+	NotGranted      string `json:"notGranted"`
+	DeniedHelp      string `json:"deniedHelp"`
+	NotGrantedHelp  string `json:"notGrantedHelp"`
+	// UndefinedHelp explains a wildcard ALLOW that reaches no permission.
+	UndefinedHelp string `json:"undefinedHelp"`
+	Trace         string `json:"trace"`
+	NoTrace       string `json:"noTrace"`
+	ColumnRole    string `json:"columnRole"`
+	ColumnCode    string `json:"columnCode"`
+	ColumnType    string `json:"columnType"`
+	ColumnOutcome string `json:"columnOutcome"`
+	Allow         string `json:"allow"`
+	Deny          string `json:"deny"`
+	Grants        string `json:"grants"`
+	Blocks        string `json:"blocks"`
+	Overridden    string `json:"overridden"`
+	// Inactive reasons, one per effective.Inactive* constant.
+	AssignmentInactive     string `json:"assignmentInactive"`
+	RoleInactive           string `json:"roleInactive"`
+	RolePermissionInactive string `json:"rolePermissionInactive"`
+	PermissionInactive     string `json:"permissionInactive"`
+	TextTitle              string `json:"textTitle"`
+	Download               string `json:"download"`
+}
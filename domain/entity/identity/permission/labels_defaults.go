@@ -0,0 +1,55 @@
+package permission
+
+// DefaultLabels returns Labels seeded with the English defaults below, for
+// the host's lyngua files to overlay.
+func DefaultLabels() Labels {
+	return Labels{Errors: DefaultErrorLabels()}
+}
+
+// DefaultErrorLabels returns ErrorLabels populated with English defaults.
+func DefaultErrorLabels() ErrorLabels {
+	return ErrorLabels{
+		InvalidCode: "Enter a code like client:delete or client:* — an entity and an action separated by one colon, without spaces.",
+	}
+}
+
+// DefaultExplainLabels returns ExplainLabels populated with English
+// defaults.
+func DefaultExplainLabels() ExplainLabels {
+	return ExplainLabels{
+		Tab:                    "Access",
+		Title:                  "Explain a permission",
+		Help:                   "Check why this user can or can't do something: enter a permission code, or the entity and action the app checks.",
+		Code:                   "Permission code",
+		CodePlaceholder:        "client:delete",
+		Entity:                 "Entity",
+		Action:                 "Action",
+		Or:                     "or",
+		Check:                  "Check",
+		ErrorCode:              "Enter a code like client:delete — one entity and one action, no wildcards.",
+		ErrorLoad:              "This user's roles could not be loaded. Try again later.",
+		Allowed:                "Allowed",
+		Denied:                 "Denied",
+		NotGranted:             "Not granted",
+		DeniedHelp:             "A DENY matches this permission. A DENY wins over every ALLOW, whichever role it comes from.",
+		NotGrantedHelp:         "No active role of this user grants this permission.",
+		UndefinedHelp:          "A wildcard grant covers this code, but no permission defines it, so the app's check still fails.",
+		Trace:                  "Matching role permissions",
+		NoTrace:                "None of this user's roles mention this permission.",
+		ColumnRole:             "Role",
+		ColumnCode:             "Permission",
+		ColumnType:             "Type",
+		ColumnOutcome:          "Outcome",
+		Allow:                  "Allow",
+		Deny:                   "Deny",
+		Grants:                 "Grants",
+		Blocks:                 "Blocks",
+		Overridden:             "Overridden by a DENY",
+		AssignmentInactive:     "Role assignment inactive",
+		RoleInactive:           "Role inactive",
+		RolePermissionInactive: "Role permission inactive",
+		PermissionInactive:     "Permission inactive",
+		TextTitle:              "As text, for a ticket",
+		Download:               "Download .txt",
+	}
+}
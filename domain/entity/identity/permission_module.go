@@ -50,6 +50,7 @@ func NewPermissionModule(deps *PermissionModuleDeps) *PermissionModule {
 		DeletePermission:    deps.DeletePermission,
 		SetPermissionActive: deps.SetActive,
 		Routes:              deps.Routes,
+		Labels:              deps.Labels,
 	}
 	listDeps := &permissionlist.ListViewDeps{
 		GetListPageData: deps.GetListPageData,
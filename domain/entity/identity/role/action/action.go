@@ -1,14 +1,20 @@
 package action
 
 import (
+	"cmp"
 	"context"
+	"fmt"
 	"log"
 	"net/http"
+	"slices"
+	"strings"
 
 	"github.com/erniealice/pyeza-golang/route"
 	"github.com/erniealice/pyeza-golang/view"
 
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
 	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
 
 	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
 	"github.com/erniealice/entydad-golang/domain/entity/identity/role/form"
@@ -22,9 +28,19 @@ type Deps struct {
 	DeleteRole    func(ctx context.Context, req *rolepb.DeleteRoleRequest) (*rolepb.DeleteRoleResponse, error)
 	SetRoleActive func(ctx context.Context, id string, active bool) error
 	Routes        role.Routes
+
+	// Clone: load the source role with its permissions and copy them to
+	// the new role. Without both (and DeleteRole, which takes back a
+	// partial copy), a clone is refused.
+	GetRoleItemPageData  func(ctx context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error)
+	CreateRolePermission func(ctx context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error)
 }
 
 // NewAddAction creates the role add action (GET = form, POST = create).
+// A POST carrying clone_from (set by the edit action's ?clone=1 form) also
+// copies every RolePermission row of that role, which takes role:update
+// as well since it grants permissions. A copy that fails part-way deletes
+// the new role rather than leave it with only some of the rows.
 func NewAddAction(deps *Deps) view.View {
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
 		perms := view.GetUserPermissions(ctx)
@@ -48,28 +64,121 @@ func NewAddAction(deps *Deps) view.View {
 		r := viewCtx.Request
 		active := r.FormValue("active") == "true"
 
-		_, err := deps.CreateRole(ctx, &rolepb.CreateRoleRequest{
-			Data: &rolepb.Role{
-				Name:        r.FormValue("name"),
-				Description: r.FormValue("description"),
-				Color:       r.FormValue("color"),
-				Active:      active,
-			},
-		})
+		var source *rolepb.Role
+		if cloneFrom := r.FormValue("clone_from"); cloneFrom != "" {
+			if !perms.Can("role", "update") {
+				return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+			}
+			if deps.GetRoleItemPageData == nil || deps.CreateRolePermission == nil || deps.DeleteRole == nil {
+				return view.HTMXError(viewCtx.T("shared.errors.cloneUnavailable"))
+			}
+			resp, err := deps.GetRoleItemPageData(ctx, &rolepb.GetRoleItemPageDataRequest{RoleId: cloneFrom})
+			if err != nil || resp.GetRole() == nil {
+				log.Printf("Failed to load role %s to clone: %v", cloneFrom, err)
+				return view.HTMXError(viewCtx.T("shared.errors.notFound"))
+			}
+			source = resp.GetRole()
+		}
+
+		data := &rolepb.Role{
+			Name:        r.FormValue("name"),
+			Description: r.FormValue("description"),
+			Color:       r.FormValue("color"),
+			Active:      active,
+		}
+		if source != nil {
+			data.ApplicablePrincipalTypes = source.GetApplicablePrincipalTypes()
+		}
+		resp, err := deps.CreateRole(ctx, &rolepb.CreateRoleRequest{Data: data})
 		if err != nil {
 			log.Printf("Failed to create role: %v", err)
 			return view.HTMXError(err.Error())
 		}
 
+		if source != nil {
+			if err := copyPermissions(ctx, deps, source, resp.GetData()); err != nil {
+				log.Printf("Failed to clone role %s: %v", source.GetId(), err)
+				if created := resp.GetData(); len(created) > 0 && created[0].GetId() != "" {
+					DiscardRole(ctx, deps, created[0].GetId())
+				}
+				return view.HTMXError(viewCtx.T("shared.errors.cloneFailed"))
+			}
+		}
+
 		return view.HTMXSuccess("roles-table")
 	})
 }
 
+// copyPermissions gives the created role every RolePermission row of
+// source, keeping each row's type and active flag.
+func copyPermissions(ctx context.Context, deps *Deps, source *rolepb.Role, created []*rolepb.Role) error {
+	if len(created) == 0 || created[0].GetId() == "" {
+		return fmt.Errorf("the new role was created but its ID is unknown, so no permissions were copied")
+	}
+	return GrantPermissions(ctx, deps, created[0].GetId(), source.GetRolePermissions())
+}
+
+// GrantPermissions writes rows to the new role roleID through
+// CreateRolePermission, keeping each row's permission, type and active
+// flag. DENY rows go first, so a role cut short never holds a grant
+// without the denials that bound it; it stops at the first failed row,
+// after which the caller discards the role with DiscardRole.
+func GrantPermissions(ctx context.Context, deps *Deps, roleID string, rows []*rolepermissionpb.RolePermission) error {
+	rows = slices.Clone(rows)
+	slices.SortStableFunc(rows, func(a, b *rolepermissionpb.RolePermission) int {
+		return cmp.Compare(denyFirst(a), denyFirst(b))
+	})
+	for i, rp := range rows {
+		_, err := deps.CreateRolePermission(ctx, &rolepermissionpb.CreateRolePermissionRequest{
+			Data: &rolepermissionpb.RolePermission{
+				RoleId:         roleID,
+				PermissionId:   rp.GetPermissionId(),
+				PermissionType: rp.GetPermissionType(),
+				Active:         rp.GetActive(),
+			},
+		})
+		if err != nil {
+			return fmt.Errorf("granting permission %s (%d of %d): %w", rp.GetPermissionId(), i+1, len(rows), err)
+		}
+	}
+	return nil
+}
+
+// denyFirst orders DENY rows before the rest.
+func denyFirst(rp *rolepermissionpb.RolePermission) int {
+	if rp.GetPermissionType() == permissionpb.PermissionType_PERMISSION_TYPE_DENY {
+		return 0
+	}
+	return 1
+}
+
+// DiscardRole deletes a new role whose permissions could not all be
+// granted. If even that fails the role is deactivated, so the partial
+// role grants nothing until someone looks at it.
+func DiscardRole(ctx context.Context, deps *Deps, id string) {
+	_, err := deps.DeleteRole(ctx, &rolepb.DeleteRoleRequest{Data: &rolepb.Role{Id: id}})
+	if err == nil {
+		return
+	}
+	log.Printf("Failed to delete partially granted role %s: %v", id, err)
+	if deps.SetRoleActive == nil {
+		return
+	}
+	if err := deps.SetRoleActive(ctx, id, false); err != nil {
+		log.Printf("Failed to deactivate partially granted role %s: %v", id, err)
+	}
+}
+
 // NewEditAction creates the role edit action (GET = form, POST = update).
+// When the GET request includes ?clone=1, the handler returns the drawer form
+// pre-populated from the source role but wired to AddURL, with " (Copy)"
+// appended to the name and the source's ID in clone_from so the add handler
+// copies its permissions. Cloning takes role:create and role:update.
 func NewEditAction(deps *Deps) view.View {
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
 		perms := view.GetUserPermissions(ctx)
-		if !perms.Can("role", "update") {
+		isClone := viewCtx.Request.Method == http.MethodGet && viewCtx.Request.URL.Query().Get("clone") == "1"
+		if !perms.Can("role", "update") || (isClone && !perms.Can("role", "create")) {
 			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
 		}
 		id := viewCtx.Request.PathValue("id")
@@ -85,7 +194,7 @@ func NewEditAction(deps *Deps) view.View {
 
 			role := resp.GetData()[0]
 
-			return view.OK("role-drawer-form", &form.Data{
+			data := &form.Data{
 				FormAction:   route.ResolveURL(deps.Routes.EditURL, "id", id),
 				IsEdit:       true,
 				ID:           id,
@@ -95,7 +204,15 @@ func NewEditAction(deps *Deps) view.View {
 				Active:       role.GetActive(),
 				Labels:       form.BuildLabels(viewCtx.T),
 				CommonLabels: nil, // injected by ViewAdapter
-			})
+			}
+			if isClone {
+				data.FormAction = deps.Routes.AddURL
+				data.IsEdit = false
+				data.ID = ""
+				data.Name = strings.TrimSpace(data.Name) + viewCtx.T("actions.copySuffix")
+				data.CloneFromID = id
+			}
+			return view.OK("role-drawer-form", data)
 		}
 
 		// POST -- update role
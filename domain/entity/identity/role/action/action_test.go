@@ -12,9 +12,15 @@ import (
 	"strings"
 	"testing"
 
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	principaltypepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/principal_type"
 	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
 	"github.com/erniealice/pyeza-golang/types"
 	"github.com/erniealice/pyeza-golang/view"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/role/form"
 )
 
 const rolesTableTrigger = `{"formSuccess":true,"refreshTable":"roles-table"}`
@@ -25,6 +31,9 @@ var testMessages = map[string]string{
 	"shared.errors.noIdsProvided":       "no ids provided",
 	"shared.errors.invalidStatus":       "invalid status",
 	"shared.errors.invalidTargetStatus": "invalid target status",
+	"shared.errors.cloneUnavailable":    "clone unavailable",
+	"shared.errors.cloneFailed":         "clone failed",
+	"actions.copySuffix":                " (Copy)",
 }
 
 type setRoleActiveCall struct {
@@ -428,6 +437,170 @@ func TestNewBulkSetStatusAction(t *testing.T) {
 	}
 }
 
+func TestCloneRole(t *testing.T) {
+	t.Parallel()
+
+	source := &rolepb.Role{
+		Id:                       "role-1",
+		Name:                     "Sales",
+		Color:                    "#00aa00",
+		Active:                   true,
+		ApplicablePrincipalTypes: []principaltypepb.PrincipalType{principaltypepb.PrincipalType_PRINCIPAL_TYPE_OPERATOR_STAFF},
+		RolePermissions: []*rolepermissionpb.RolePermission{
+			{Id: "rp-1", RoleId: "role-1", PermissionId: "p-read", Active: true},
+			{Id: "rp-2", RoleId: "role-1", PermissionId: "p-delete", Active: false, PermissionType: permissionpb.PermissionType_PERMISSION_TYPE_DENY},
+		},
+	}
+	newDeps := func(created *[]*rolepb.Role, copied *[]*rolepermissionpb.RolePermission) *Deps {
+		return &Deps{
+			DeleteRole: func(context.Context, *rolepb.DeleteRoleRequest) (*rolepb.DeleteRoleResponse, error) {
+				return &rolepb.DeleteRoleResponse{}, nil
+			},
+			ReadRole: func(context.Context, *rolepb.ReadRoleRequest) (*rolepb.ReadRoleResponse, error) {
+				return &rolepb.ReadRoleResponse{Data: []*rolepb.Role{source}}, nil
+			},
+			CreateRole: func(_ context.Context, req *rolepb.CreateRoleRequest) (*rolepb.CreateRoleResponse, error) {
+				r := req.GetData()
+				r.Id = "role-2"
+				*created = append(*created, r)
+				return &rolepb.CreateRoleResponse{Data: []*rolepb.Role{r}}, nil
+			},
+			GetRoleItemPageData: func(_ context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error) {
+				if req.GetRoleId() != source.GetId() {
+					return nil, errors.New("not found")
+				}
+				return &rolepb.GetRoleItemPageDataResponse{Role: source}, nil
+			},
+			CreateRolePermission: func(_ context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error) {
+				*copied = append(*copied, req.GetData())
+				return &rolepermissionpb.CreateRolePermissionResponse{}, nil
+			},
+			Routes: role.DefaultRoutes(),
+		}
+	}
+
+	t.Run("edit with clone=1 returns an add form", func(t *testing.T) {
+		t.Parallel()
+		var created []*rolepb.Role
+		var copied []*rolepermissionpb.RolePermission
+		req := httptest.NewRequest(http.MethodGet, "/action/roles/edit/role-1?clone=1", nil)
+		req.SetPathValue("id", "role-1")
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"role:create", "role:update"}))
+
+		got := NewEditAction(newDeps(&created, &copied)).Handle(ctx, &view.ViewContext{Request: req, Messages: testMessages})
+		data, ok := got.Data.(*form.Data)
+		if !ok {
+			t.Fatalf("result = %+v", got)
+		}
+		if data.FormAction != role.AddURL || data.IsEdit || data.ID != "" || data.Name != "Sales (Copy)" || data.CloneFromID != "role-1" {
+			t.Fatalf("form = %+v", data)
+		}
+	})
+
+	t.Run("clone needs role:create", func(t *testing.T) {
+		t.Parallel()
+		var created []*rolepb.Role
+		var copied []*rolepermissionpb.RolePermission
+		req := httptest.NewRequest(http.MethodGet, "/action/roles/edit/role-1?clone=1", nil)
+		req.SetPathValue("id", "role-1")
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"role:update"}))
+
+		got := NewEditAction(newDeps(&created, &copied)).Handle(ctx, &view.ViewContext{Request: req, Messages: testMessages})
+		assertViewResult(t, got, http.StatusUnprocessableEntity, testMessages["shared.errors.permissionDenied"], "")
+	})
+
+	// DENY rows are copied first.
+	wantOrder := []*rolepermissionpb.RolePermission{source.GetRolePermissions()[1], source.GetRolePermissions()[0]}
+
+	t.Run("add with clone_from copies the permissions", func(t *testing.T) {
+		t.Parallel()
+		var created []*rolepb.Role
+		var copied []*rolepermissionpb.RolePermission
+		req := newFormRequest(t, http.MethodPost, role.AddURL, url.Values{
+			"name": {"Sales (Copy)"}, "active": {"true"}, "clone_from": {"role-1"},
+		})
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"role:create", "role:update"}))
+
+		got := NewAddAction(newDeps(&created, &copied)).Handle(ctx, &view.ViewContext{Request: req, Messages: testMessages})
+		assertViewResult(t, got, http.StatusOK, "", rolesTableTrigger)
+		if len(created) != 1 || !slices.Equal(created[0].GetApplicablePrincipalTypes(), source.GetApplicablePrincipalTypes()) {
+			t.Fatalf("created = %v", created)
+		}
+		if len(copied) != 2 {
+			t.Fatalf("copied %d rows, want 2", len(copied))
+		}
+		for i, rp := range copied {
+			want := wantOrder[i]
+			if rp.GetRoleId() != "role-2" || rp.GetPermissionId() != want.GetPermissionId() ||
+				rp.GetPermissionType() != want.GetPermissionType() || rp.GetActive() != want.GetActive() {
+				t.Errorf("copied row %d = %v, want a copy of %v", i, rp, want)
+			}
+		}
+	})
+
+	t.Run("a failed copy deletes the new role", func(t *testing.T) {
+		t.Parallel()
+		var created []*rolepb.Role
+		var copied []*rolepermissionpb.RolePermission
+		var deleted []string
+		deps := newDeps(&created, &copied)
+		deps.CreateRolePermission = func(_ context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error) {
+			if req.GetData().GetPermissionType() != permissionpb.PermissionType_PERMISSION_TYPE_DENY {
+				return nil, errors.New("write failed")
+			}
+			copied = append(copied, req.GetData())
+			return &rolepermissionpb.CreateRolePermissionResponse{}, nil
+		}
+		deps.DeleteRole = func(_ context.Context, req *rolepb.DeleteRoleRequest) (*rolepb.DeleteRoleResponse, error) {
+			deleted = append(deleted, req.GetData().GetId())
+			return &rolepb.DeleteRoleResponse{}, nil
+		}
+		req := newFormRequest(t, http.MethodPost, role.AddURL, url.Values{
+			"name": {"Sales (Copy)"}, "active": {"true"}, "clone_from": {"role-1"},
+		})
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"role:create", "role:update"}))
+
+		got := NewAddAction(deps).Handle(ctx, &view.ViewContext{Request: req, Messages: testMessages})
+		assertViewResult(t, got, http.StatusUnprocessableEntity, testMessages["shared.errors.cloneFailed"], "")
+		if len(copied) != 1 || copied[0].GetPermissionId() != "p-delete" {
+			t.Fatalf("copied = %v, want only the DENY row", copied)
+		}
+		if !slices.Equal(deleted, []string{"role-2"}) {
+			t.Fatalf("deleted = %v, want [role-2]", deleted)
+		}
+	})
+
+	t.Run("clone needs a way to take back a partial copy", func(t *testing.T) {
+		t.Parallel()
+		var created []*rolepb.Role
+		var copied []*rolepermissionpb.RolePermission
+		deps := newDeps(&created, &copied)
+		deps.DeleteRole = nil
+		req := newFormRequest(t, http.MethodPost, role.AddURL, url.Values{"name": {"Copy"}, "clone_from": {"role-1"}})
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"role:create", "role:update"}))
+
+		got := NewAddAction(deps).Handle(ctx, &view.ViewContext{Request: req, Messages: testMessages})
+		assertViewResult(t, got, http.StatusUnprocessableEntity, testMessages["shared.errors.cloneUnavailable"], "")
+		if len(created) != 0 {
+			t.Fatalf("created = %v, want none", created)
+		}
+	})
+
+	t.Run("copying permissions needs role:update", func(t *testing.T) {
+		t.Parallel()
+		var created []*rolepb.Role
+		var copied []*rolepermissionpb.RolePermission
+		req := newFormRequest(t, http.MethodPost, role.AddURL, url.Values{"name": {"Copy"}, "clone_from": {"role-1"}})
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"role:create"}))
+
+		got := NewAddAction(newDeps(&created, &copied)).Handle(ctx, &view.ViewContext{Request: req, Messages: testMessages})
+		assertViewResult(t, got, http.StatusUnprocessableEntity, testMessages["shared.errors.permissionDenied"], "")
+		if len(created) != 0 {
+			t.Fatalf("created = %v, want none", created)
+		}
+	})
+}
+
 func assertViewResult(t *testing.T, got view.ViewResult, wantStatus int, wantError, wantTrigger string) {
 	t.Helper()
 
@@ -0,0 +1,134 @@
+package detail
+
+import (
+	"context"
+	"log"
+	"strings"
+
+	"github.com/erniealice/pyeza-golang/route"
+	"github.com/erniealice/pyeza-golang/view"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/role/library"
+)
+
+// ---------------------------------------------------------------------------
+// Template tab: how the role differs from a library template
+// ---------------------------------------------------------------------------
+
+// DriftTab is the data for the template tab.
+type DriftTab struct {
+	Labels      role.TemplateLabels
+	CompareURL  string
+	Options     []DriftOption
+	Template    string // name of the template compared against, "" when none
+	Source      string // "recorded", "matched" or "" when chosen by the operator
+	Rows        []DriftRow
+	Unavailable string
+	Error       string
+}
+
+// DriftOption is one entry of the template picker.
+type DriftOption struct {
+	Key      string
+	Name     string
+	Selected bool
+}
+
+// DriftRow is one permission where the role and the template differ.
+type DriftRow struct {
+	Code            string
+	Name            string
+	Kind            string
+	KindVariant     string
+	TemplateValue   string
+	TemplateVariant string
+	RoleValue       string
+	RoleVariant     string
+}
+
+// buildDriftTab compares r, loaded with its RolePermissions, against a
+// template: the ?template= choice if given, else the one recorded when the
+// role was made, else the one named like the role.
+func buildDriftTab(ctx context.Context, deps *DetailViewDeps, r *rolepb.Role, viewCtx *view.ViewContext) *DriftTab {
+	l := deps.Labels.Templates
+	tab := &DriftTab{
+		Labels:     l,
+		CompareURL: route.ResolveURL(deps.Routes.TabActionURL, "id", r.GetId(), "tab", "") + "template",
+	}
+	lib, err := library.Builtin()
+	if err != nil {
+		log.Printf("Failed to load the role library: %v", err)
+		tab.Error = l.ErrorLoad
+		return tab
+	}
+
+	var (
+		t     library.Template
+		found bool
+	)
+	if key := viewCtx.Request.URL.Query().Get("template"); key != "" {
+		t, found = lib.Get(key)
+	}
+	if !found && deps.GetRoleTemplate != nil {
+		if key, err := deps.GetRoleTemplate(ctx, r.GetId()); err != nil {
+			log.Printf("Failed to get the template of role %s: %v", r.GetId(), err)
+		} else if t, found = lib.Get(key); found {
+			tab.Source = "recorded"
+		}
+	}
+	if !found {
+		if t, found = lib.Match(r.GetName()); found {
+			tab.Source = "matched"
+		}
+	}
+	for _, o := range lib.Templates {
+		tab.Options = append(tab.Options, DriftOption{Key: o.Key, Name: o.Name, Selected: found && o.Key == t.Key})
+	}
+	if !found {
+		return tab
+	}
+	tab.Template = t.Name
+
+	var catalog []*permissionpb.Permission
+	if deps.ListPermissions != nil {
+		resp, err := deps.ListPermissions(ctx, &permissionpb.ListPermissionsRequest{})
+		if err != nil {
+			log.Printf("Failed to list permissions for role %s drift: %v", r.GetId(), err)
+			tab.Error = l.ErrorLoad
+			return tab
+		}
+		catalog = resp.GetData()
+	}
+	items, missing := library.Drift(t, catalog, r)
+	tab.Unavailable = strings.Join(missing, ", ")
+
+	value := func(deny bool) (string, string) {
+		if deny {
+			return deps.SharedLabels.Badges.Deny, "danger"
+		}
+		return deps.SharedLabels.Badges.Allow, "success"
+	}
+	for _, it := range items {
+		row := DriftRow{Code: it.Code, Name: it.Name, TemplateValue: l.Absent, RoleValue: l.Absent}
+		switch it.Kind {
+		case library.DriftMissing:
+			row.Kind, row.KindVariant = l.Missing, "warning"
+		case library.DriftExtra:
+			row.Kind, row.KindVariant = l.Extra, "info"
+		default:
+			row.Kind, row.KindVariant = l.TypeDiffers, "danger"
+		}
+		if it.Kind != library.DriftExtra {
+			row.TemplateValue, row.TemplateVariant = value(it.TemplateDeny)
+		}
+		if it.Kind != library.DriftMissing {
+			row.RoleValue, row.RoleVariant = value(it.RoleDeny)
+		}
+		tab.Rows = append(tab.Rows, row)
+	}
+	return tab
+}
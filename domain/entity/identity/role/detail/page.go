@@ -13,13 +13,16 @@ import (
 	"github.com/erniealice/pyeza-golang/view"
 
 	"github.com/erniealice/entydad-golang"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
 	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+	rolepermissions "github.com/erniealice/entydad-golang/domain/entity/identity/role/permissions"
 	roleusers "github.com/erniealice/entydad-golang/domain/entity/identity/role/users"
 	lynguaV1 "github.com/erniealice/lyngua/golang/v1"
 
 	attachmentpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/document/attachment"
 	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
 	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
 )
 
 // DetailViewDeps holds view dependencies.
@@ -35,6 +38,12 @@ type DetailViewDeps struct {
 	CommonLabels         pyeza.CommonLabels
 	TableLabels          types.TableLabels
 
+	// Template tab: the permission catalog to resolve templates against,
+	// and the template a role was made from. GetRoleTemplate is optional;
+	// without it the tab compares against the template named like the role.
+	ListPermissions func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
+	GetRoleTemplate func(ctx context.Context, roleID string) (string, error)
+
 	// Attachment operations (embedded from hybra)
 	attachment.AttachmentOps
 
@@ -58,6 +67,7 @@ type PageData struct {
 	PermissionsTable *types.TableConfig
 	UsersTable       *types.TableConfig
 	AttachmentTable  *types.TableConfig
+	Drift            *DriftTab
 	// Audit history tab
 	AuditEntries    []auditlog.AuditEntryView
 	AuditHasNext    bool
@@ -162,6 +172,7 @@ func buildPageData(ctx context.Context, deps *DetailViewDeps, id, activeTab stri
 	// Get counts for tab badges
 	permCount := 0
 	userCount := 0
+	var rolePerms []*rolepermissionpb.RolePermission
 
 	if deps.RoleGetItemPageData != nil {
 		itemResp, err := deps.RoleGetItemPageData(ctx, &rolepb.GetRoleItemPageDataRequest{
@@ -170,7 +181,8 @@ func buildPageData(ctx context.Context, deps *DetailViewDeps, id, activeTab stri
 		if err != nil {
 			log.Printf("Failed to get role item page data for %s: %v", id, err)
 		} else {
-			permCount = len(itemResp.GetRole().GetRolePermissions())
+			rolePerms = itemResp.GetRole().GetRolePermissions()
+			permCount = len(rolePerms)
 		}
 	}
 
@@ -238,6 +250,8 @@ func buildPageData(ctx context.Context, deps *DetailViewDeps, id, activeTab stri
 			}
 			pageData.AttachmentTable = attachment.BuildTable(items, cfg, id)
 		}
+	case "template":
+		pageData.Drift = buildDriftTab(ctx, deps, &rolepb.Role{Id: id, Name: roleName, RolePermissions: rolePerms}, viewCtx)
 	case "audit-history":
 		if deps.ListAuditHistory != nil {
 			cursor := viewCtx.Request.URL.Query().Get("cursor")
@@ -270,6 +284,7 @@ func buildTabItems(id string, labels role.Labels, routes role.Routes, permCount,
 		{Key: "permissions", Label: labels.Detail.Tabs.Permissions, Href: base + "?tab=permissions", HxGet: action + "permissions", Icon: "icon-key", Count: permCount, Disabled: false},
 		{Key: "users", Label: labels.Detail.Tabs.Users, Href: base + "?tab=users", HxGet: action + "users", Icon: "icon-user", Count: userCount, Disabled: false},
 		{Key: "attachments", Label: labels.Detail.AttachmentsTab, Href: base + "?tab=attachments", HxGet: action + "attachments", Icon: "icon-paperclip", Count: 0, Disabled: false},
+		{Key: "template", Label: labels.Templates.Tab, Href: base + "?tab=template", HxGet: action + "template", Icon: "icon-layers"},
 		{Key: "audit-history", Label: func() string {
 			if labels.Detail.AuditHistoryTab != "" {
 				return labels.Detail.AuditHistoryTab
@@ -302,10 +317,13 @@ func buildPermissionsTable(ctx context.Context, deps *DetailViewDeps, roleID str
 		{Key: "permissionName", Label: l.Columns.PermissionName},
 		{Key: "code", Label: l.Columns.Code},
 		{Key: "type", Label: l.Columns.Type, WidthClass: "col-2xl"},
+		{Key: "status", Label: l.Columns.Status, WidthClass: "col-2xl"},
 		{Key: "dateAssigned", Label: l.Columns.DateAssigned, WidthClass: "col-6xl"},
 	}
 
 	rows := []types.TableRow{}
+	set := effective.FromRoles(role)
+	grants := rolepermissions.GrantsByRow(set)
 	for _, rp := range role.GetRolePermissions() {
 		perm := rp.GetPermission()
 		if perm == nil {
@@ -318,14 +336,12 @@ func buildPermissionsTable(ctx context.Context, deps *DetailViewDeps, roleID str
 		permType := deps.SharedLabels.Badges.Allow
 		dateAssigned := rp.GetDateCreatedString()
 
-		pt := perm.GetPermissionType()
-		if pt == permissionpb.PermissionType_PERMISSION_TYPE_DENY {
-			permType = deps.SharedLabels.Badges.Deny
-		}
 		typeVariant := "success"
-		if pt == permissionpb.PermissionType_PERMISSION_TYPE_DENY {
+		if effective.IsDeny(rp) {
+			permType = deps.SharedLabels.Badges.Deny
 			typeVariant = "danger"
 		}
+		status, statusVariant := rolepermissions.StatusBadge(set, grants[rpID], l)
 
 		actions := []types.TableAction{
 			{
@@ -344,12 +360,14 @@ func buildPermissionsTable(ctx context.Context, deps *DetailViewDeps, roleID str
 				{Type: "text", Value: permName},
 				{Type: "text", Value: permCode},
 				{Type: "badge", Value: permType, Variant: typeVariant},
+				{Type: "badge", Value: status, Variant: statusVariant},
 				{Type: "text", Value: dateAssigned},
 			},
 			DataAttrs: map[string]string{
 				"permissionName": permName,
 				"code":           permCode,
 				"type":           permType,
+				"status":         status,
 			},
 			Actions: actions,
 		})
@@ -21,6 +21,7 @@ type Data struct {
 	Description  string
 	Color        string
 	Active       bool
+	CloneFromID  string // set by ?clone=1: the role whose permissions the new role copies
 	Labels       Labels
 	CommonLabels any
 }
@@ -19,6 +19,12 @@ type Labels struct {
 	Form    FormLabels   `json:"form"`
 	Actions ActionLabels `json:"actions"`
 	Detail  DetailLabels `json:"detail"`
+
+	// Role template library and the detail page's drift tab
+	Templates TemplateLabels `json:"templates"`
+
+	// Role setup export/import between workspaces
+	Transfer TransferLabels `json:"transfer"`
 }
 
 type PageLabels struct {
@@ -31,7 +37,8 @@ type PageLabels struct {
 }
 
 type ButtonLabels struct {
-	AddRole string `json:"addRole"`
+	AddRole          string `json:"addRole"`
+	PermissionMatrix string `json:"permissionMatrix"`
 }
 
 type ColumnLabels struct {
@@ -60,6 +67,87 @@ type FormLabels struct {
 	Active                 string `json:"active"`
 }
 
+// TemplateLabels holds the strings of the role template library page and
+// of the role detail "Template" tab, which shows how a role drifted from
+// the template it was made from.
+type TemplateLabels struct {
+	Heading        string `json:"heading"`
+	Caption        string `json:"caption"`
+	Link           string `json:"link"`
+	Version        string `json:"version"`
+	Use            string `json:"use"`
+	Allow          string `json:"allow"`
+	Deny           string `json:"deny"`
+	PrincipalTypes string `json:"principalTypes"`
+	Unavailable    string `json:"unavailable"`
+	ErrorLoad      string `json:"errorLoad"`
+	ErrorUnknown   string `json:"errorUnknown"`
+	// Drift tab
+	Tab            string `json:"tab"`
+	DriftTitle     string `json:"driftTitle"`
+	Template       string `json:"template"`
+	Choose         string `json:"choose"`
+	Compare        string `json:"compare"`
+	Recorded       string `json:"recorded"`
+	Matched        string `json:"matched"`
+	InSync         string `json:"inSync"`
+	ColumnCode     string `json:"columnCode"`
+	ColumnDrift    string `json:"columnDrift"`
+	ColumnTemplate string `json:"columnTemplate"`
+	ColumnRole     string `json:"columnRole"`
+	Missing        string `json:"missing"`
+	Extra          string `json:"extra"`
+	TypeDiffers    string `json:"typeDiffers"`
+	Absent         string `json:"absent"`
+}
+
+// TransferLabels holds the strings of the role setup export/import page
+// and of the import's dry-run review.
+type TransferLabels struct {
+	Heading        string `json:"heading"`
+	Caption        string `json:"caption"`
+	Link           string `json:"link"`
+	ExportTitle    string `json:"exportTitle"`
+	ExportHelp     string `json:"exportHelp"`
+	ExportYAML     string `json:"exportYaml"`
+	ExportJSON     string `json:"exportJson"`
+	ImportTitle    string `json:"importTitle"`
+	ImportHelp     string `json:"importHelp"`
+	File           string `json:"file"`
+	Paste          string `json:"paste"`
+	UpdateExisting string `json:"updateExisting"`
+	RemoveMissing  string `json:"removeMissing"`
+	SkipUnknown    string `json:"skipUnknown"`
+	DryRun         string `json:"dryRun"`
+	ReadOnly       string `json:"readOnly"`
+	// Review
+	ReviewTitle     string `json:"reviewTitle"`
+	Summary         string `json:"summary"`
+	NoChanges       string `json:"noChanges"`
+	Conflicts       string `json:"conflicts"`
+	Blocked         string `json:"blocked"`
+	Create          string `json:"create"`
+	Update          string `json:"update"`
+	Remove          string `json:"remove"`
+	Grant           string `json:"grant"`
+	Revoke          string `json:"revoke"`
+	Fields          string `json:"fields"`
+	Apply           string `json:"apply"`
+	Imported        string `json:"imported"`
+	RolledBack      string `json:"rolledBack"`
+	RollbackFailed  string `json:"rollbackFailed"`
+	UnknownCode     string `json:"unknownCode"`
+	InactiveCode    string `json:"inactiveCode"`
+	DuplicateRole   string `json:"duplicateRole"`
+	ExistingDiffers string `json:"existingDiffers"`
+	RoleInUse       string `json:"roleInUse"`
+	UsageUnknown    string `json:"usageUnknown"`
+	Skipped         string `json:"skipped"`
+	ErrorEmpty      string `json:"errorEmpty"`
+	ErrorParse      string `json:"errorParse"`
+	ErrorLoad       string `json:"errorLoad"`
+}
+
 type ActionLabels struct {
 	View              string `json:"view"`
 	Edit              string `json:"edit"`
@@ -110,6 +198,8 @@ type PermissionLabels struct {
 	Empty   PermissionEmptyLabels  `json:"empty"`
 	Form    PermissionFormLabels   `json:"form"`
 	Actions PermissionActionLabels `json:"actions"`
+	Status  PermissionStatusLabels `json:"status"`
+	Matrix  PermissionMatrixLabels `json:"matrix"`
 }
 
 type PermissionPageLabels struct {
@@ -126,6 +216,7 @@ type PermissionColumnLabels struct {
 	Code           string `json:"code"`
 	Type           string `json:"type"`
 	DateAssigned   string `json:"dateAssigned"`
+	Status         string `json:"status"`
 }
 
 type PermissionEmptyLabels struct {
@@ -143,6 +234,48 @@ type PermissionActionLabels struct {
 	ManagePermissions string `json:"managePermissions"`
 }
 
+// PermissionStatusLabels name how a row takes part in the role's effective
+// permissions (see permission/effective): granting, blocked by a DENY in
+// the role, or ignored because the row or its permission is inactive.
+type PermissionStatusLabels struct {
+	Effective  string `json:"effective"`
+	Overridden string `json:"overridden"`
+	Inactive   string `json:"inactive"`
+}
+
+// PermissionMatrixLabels holds the strings of the role × permission matrix,
+// where permissions are granted to many roles at once and the change is
+// reviewed before it is applied.
+type PermissionMatrixLabels struct {
+	Heading      string `json:"heading"`
+	Caption      string `json:"caption"`
+	Permission   string `json:"permission"`
+	ToggleRow    string `json:"toggleRow"`
+	ToggleColumn string `json:"toggleColumn"`
+	ToggleGroup  string `json:"toggleGroup"`
+	Inactive     string `json:"inactive"`
+	Deny         string `json:"deny"`
+	DenyHelp     string `json:"denyHelp"`
+	ReadOnly     string `json:"readOnly"`
+	Review       string `json:"review"`
+	ReviewTitle  string `json:"reviewTitle"`
+	Summary      string `json:"summary"`
+	// SummaryRemoveDeny is appended to Summary when DENY rows are lifted.
+	SummaryRemoveDeny string `json:"summaryRemoveDeny"`
+	NoChanges         string `json:"noChanges"`
+	Grant             string `json:"grant"`
+	Revoke            string `json:"revoke"`
+	RemoveDeny        string `json:"removeDeny"`
+	Apply             string `json:"apply"`
+	Cancel            string `json:"cancel"`
+	Saved             string `json:"saved"`
+	// Failed and RollbackFailed take the failed step and its error.
+	Failed         string `json:"failed"`
+	RollbackFailed string `json:"rollbackFailed"`
+	ErrorLoad      string `json:"errorLoad"`
+	Empty          string `json:"empty"`
+}
+
 // ---------------------------------------------------------------------------
 // Role-User labels (reverse of User-Role: managing users on a role)
 // ---------------------------------------------------------------------------
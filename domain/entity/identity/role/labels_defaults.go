@@ -0,0 +1,127 @@
+package role
+
+// DefaultLabels returns Labels seeded with the template library and
+// transfer defaults below, for the host's lyngua files to overlay.
+func DefaultLabels() Labels {
+	return Labels{Templates: DefaultTemplateLabels(), Transfer: DefaultTransferLabels()}
+}
+
+// DefaultPermissionLabels returns PermissionLabels seeded with the matrix
+// defaults below, for the host's lyngua files to overlay.
+func DefaultPermissionLabels() PermissionLabels {
+	return PermissionLabels{Matrix: DefaultPermissionMatrixLabels()}
+}
+
+// DefaultPermissionMatrixLabels returns PermissionMatrixLabels populated
+// with English defaults.
+func DefaultPermissionMatrixLabels() PermissionMatrixLabels {
+	return PermissionMatrixLabels{
+		Heading:           "Permission matrix",
+		Caption:           "Grant permissions to many roles at once. Review the changes before they are applied.",
+		Permission:        "Permission",
+		ToggleRow:         "Toggle this permission for every role",
+		ToggleColumn:      "Toggle every permission for this role",
+		ToggleGroup:       "Toggle this group for the role",
+		Inactive:          "Inactive",
+		Deny:              "Deny",
+		DenyHelp:          "Cells marked Deny hold a DENY row. Clearing one removes the DENY.",
+		ReadOnly:          "You can view the matrix but not change it.",
+		Review:            "Review changes",
+		ReviewTitle:       "Confirm changes",
+		Summary:           "%d to grant and %d to revoke across %d roles.",
+		SummaryRemoveDeny: "%d DENY rows will be removed, widening access.",
+		NoChanges:         "Nothing to change: the matrix matches the saved permissions.",
+		Grant:             "Grant",
+		Revoke:            "Revoke",
+		RemoveDeny:        "Remove deny",
+		Apply:             "Apply changes",
+		Cancel:            "Cancel",
+		Saved:             "%d permission changes applied.",
+		Failed:            "Nothing was saved: %s failed (%v), so the changes already made were undone. Try again.",
+		RollbackFailed:    "The save stopped at %s (%v) and these changes could not be undone; check the roles below before trying again.",
+		ErrorLoad:         "The roles and permissions could not be loaded. Try again later.",
+		Empty:             "There are no roles or permissions yet.",
+	}
+}
+
+// DefaultTemplateLabels returns TemplateLabels populated with English
+// defaults.
+func DefaultTemplateLabels() TemplateLabels {
+	return TemplateLabels{
+		Heading:        "Role templates",
+		Caption:        "Start a role from a built-in template, then adjust it to fit.",
+		Link:           "Role templates",
+		Version:        "Template library v%d",
+		Use:            "Use template",
+		Allow:          "Allows",
+		Deny:           "Denies",
+		PrincipalTypes: "For",
+		Unavailable:    "Not in this workspace's permissions, skipped",
+		ErrorLoad:      "The role templates could not be loaded.",
+		ErrorUnknown:   "There is no such role template.",
+		Tab:            "Template",
+		DriftTitle:     "Compared with the template",
+		Template:       "Template",
+		Choose:         "Choose a template to compare this role with.",
+		Compare:        "Compare",
+		Recorded:       "This role was made from this template.",
+		Matched:        "Compared with the template of the same name.",
+		InSync:         "This role grants exactly what the template does.",
+		ColumnCode:     "Permission",
+		ColumnDrift:    "Difference",
+		ColumnTemplate: "Template",
+		ColumnRole:     "Role",
+		Missing:        "Missing from the role",
+		Extra:          "Added to the role",
+		TypeDiffers:    "Allow/deny differs",
+		Absent:         "—",
+	}
+}
+
+// DefaultTransferLabels returns TransferLabels populated with English
+// defaults.
+func DefaultTransferLabels() TransferLabels {
+	return TransferLabels{
+		Heading:         "Export and import roles",
+		Caption:         "Copy this workspace's roles and their permissions to another workspace.",
+		Link:            "Export / import",
+		ExportTitle:     "Export",
+		ExportHelp:      "Download every role with its permissions, named by permission code so the file applies to any workspace.",
+		ExportYAML:      "Download YAML",
+		ExportJSON:      "Download JSON",
+		ImportTitle:     "Import",
+		ImportHelp:      "Upload or paste an exported file. You will see what changes before anything is applied.",
+		File:            "File",
+		Paste:           "Or paste the YAML or JSON",
+		UpdateExisting:  "Update roles this workspace already has",
+		RemoveMissing:   "Delete roles the file does not list",
+		SkipUnknown:     "Skip permissions this workspace lacks instead of stopping",
+		DryRun:          "Preview changes",
+		ReadOnly:        "You can preview an import but not apply it.",
+		ReviewTitle:     "Changes to apply",
+		Summary:         "%d roles to create, %d to update and %d to delete.",
+		NoChanges:       "Nothing to change: this workspace already matches the file.",
+		Conflicts:       "Conflicts",
+		Blocked:         "Resolve the conflicts marked below, or change the options, before importing.",
+		Create:          "Create",
+		Update:          "Update",
+		Remove:          "Delete",
+		Grant:           "Grant",
+		Revoke:          "Revoke",
+		Fields:          "Changes",
+		Apply:           "Import",
+		Imported:        "Import applied: %d changes made.",
+		RolledBack:      "The import failed and nothing was changed: %s",
+		RollbackFailed:  "The import failed and could not be fully undone; check the roles listed: %s",
+		UnknownCode:     "This workspace has no %s permission",
+		InactiveCode:    "The %s permission is inactive in this workspace",
+		DuplicateRole:   "Several roles in this workspace have this name",
+		ExistingDiffers: "The role exists and differs from the file",
+		RoleInUse:       "The role has users, so it is not deleted",
+		UsageUnknown:    "Whether the role has users could not be checked, so it is not deleted",
+		Skipped:         "Skipped",
+		ErrorEmpty:      "Choose a file or paste its contents.",
+		ErrorParse:      "The file could not be read: %s",
+		ErrorLoad:       "The roles and permissions could not be loaded. Try again later.",
+	}
+}
@@ -0,0 +1,128 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/erniealice/pyeza-golang/route"
+	"github.com/erniealice/pyeza-golang/view"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+
+	roleaction "github.com/erniealice/entydad-golang/domain/entity/identity/role/action"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/role/form"
+)
+
+// NewUseAction creates the "use template" action (GET = drawer form
+// prefilled from the template, POST = create the role with the template's
+// permissions). Making a role this way grants permissions, so it takes
+// role:create and role:update. On success the operator lands on the new
+// role's detail page.
+func NewUseAction(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("role", "create") || !perms.Can("role", "update") {
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+
+		l := deps.Labels.Templates
+		lib, err := Builtin()
+		if err != nil {
+			log.Printf("Failed to load the role library: %v", err)
+			return view.HTMXError(l.ErrorLoad)
+		}
+		key := viewCtx.Request.PathValue("key")
+		t, ok := lib.Get(key)
+		if !ok {
+			return view.HTMXError(l.ErrorUnknown)
+		}
+
+		if viewCtx.Request.Method == http.MethodGet {
+			return view.OK("role-drawer-form", &form.Data{
+				FormAction:   route.ResolveURL(deps.Routes.TemplateUseURL, "key", t.Key),
+				Name:         t.Name,
+				Description:  t.Description,
+				Color:        t.Color,
+				Active:       true,
+				Labels:       form.BuildLabels(viewCtx.T),
+				CommonLabels: nil, // injected by ViewAdapter
+			})
+		}
+
+		// POST -- create the role from the template
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		r := viewCtx.Request
+		data := t.Role()
+		data.Name = r.FormValue("name")
+		data.Description = r.FormValue("description")
+		data.Color = r.FormValue("color")
+		data.Active = r.FormValue("active") == "true"
+
+		roleID, err := Instantiate(ctx, deps, t, data)
+		if err != nil {
+			log.Printf("Failed to create a role from template %s: %v", t.Key, err)
+			return view.HTMXError(err.Error())
+		}
+		if deps.SetRoleTemplate != nil {
+			if err := deps.SetRoleTemplate(ctx, roleID, t.Key); err != nil {
+				log.Printf("Failed to record template %s for role %s: %v", t.Key, roleID, err)
+			}
+		}
+
+		return view.Redirect(route.ResolveURL(deps.Routes.DetailURL, "id", roleID) + "?tab=template")
+	})
+}
+
+// Instantiate creates data as a role and gives it t's permissions, resolved
+// against the workspace's catalog, as explicit ALLOW or DENY rows. Template
+// codes the catalog lacks are skipped. It returns the new role's ID. The
+// rows are written as a clone writes them, DENY first; a failed row
+// discards the new role, so it never exists with only part of them.
+func Instantiate(ctx context.Context, deps *Deps, t Template, data *rolepb.Role) (string, error) {
+	if deps.DeleteRole == nil {
+		return "", fmt.Errorf("role templates need DeleteRole to take back a partial role")
+	}
+	permResp, err := deps.ListPermissions(ctx, &permissionpb.ListPermissionsRequest{})
+	if err != nil {
+		return "", fmt.Errorf("list permissions: %w", err)
+	}
+	grants, _ := t.Resolve(permResp.GetData())
+
+	resp, err := deps.CreateRole(ctx, &rolepb.CreateRoleRequest{Data: data})
+	if err != nil {
+		return "", err
+	}
+	if len(resp.GetData()) == 0 || resp.GetData()[0].GetId() == "" {
+		return "", fmt.Errorf("the new role was created but its ID is unknown, so no permissions were granted")
+	}
+	roleID := resp.GetData()[0].GetId()
+
+	rows := make([]*rolepermissionpb.RolePermission, 0, len(grants))
+	for _, g := range grants {
+		permType := permissionpb.PermissionType_PERMISSION_TYPE_ALLOW
+		if g.Deny {
+			permType = permissionpb.PermissionType_PERMISSION_TYPE_DENY
+		}
+		rows = append(rows, &rolepermissionpb.RolePermission{
+			PermissionId:   g.Permission.GetId(),
+			PermissionType: permType,
+			Active:         true,
+		})
+	}
+	writer := &roleaction.Deps{
+		DeleteRole:           deps.DeleteRole,
+		SetRoleActive:        deps.SetRoleActive,
+		CreateRolePermission: deps.CreateRolePermission,
+	}
+	if err := roleaction.GrantPermissions(ctx, writer, roleID, rows); err != nil {
+		roleaction.DiscardRole(ctx, writer, roleID)
+		return "", err
+	}
+	return roleID, nil
+}
@@ -0,0 +1,308 @@
+// Package library is the built-in role templates: starting points such as
+// Owner or Read-only auditor, defined in the versioned roles.json embedded
+// below. A template names its permissions by code, so it fits any
+// workspace's permission catalog; instantiating one creates a role with the
+// matching permissions, and Drift compares a role against its template.
+package library
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	principaltypepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/principal_type"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+)
+
+//go:embed roles.json
+var rolesJSON []byte
+
+// Library is a versioned set of role templates. Bump Version whenever a
+// template's permissions change, so a drift view can tell which edition a
+// role was made from.
+type Library struct {
+	Version   int        `json:"version"`
+	Templates []Template `json:"templates"`
+}
+
+// Template is one role template. Codes may use wildcards; see Resolve.
+type Template struct {
+	Key            string   `json:"key"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Color          string   `json:"color"`
+	PrincipalTypes []string `json:"principal_types"` // e.g. "operator_staff", "client"
+	Allow          []string `json:"allow"`
+	Deny           []string `json:"deny"`
+}
+
+var (
+	builtinOnce sync.Once
+	builtin     *Library
+	builtinErr  error
+)
+
+// Builtin returns the embedded library.
+func Builtin() (*Library, error) {
+	builtinOnce.Do(func() {
+		builtin, builtinErr = Parse(rolesJSON)
+	})
+	return builtin, builtinErr
+}
+
+// Parse reads and checks a library: every template has a unique key and a
+// name, every code is valid, and every principal type is known.
+func Parse(data []byte) (*Library, error) {
+	var l Library
+	if err := json.Unmarshal(data, &l); err != nil {
+		return nil, fmt.Errorf("role library: %w", err)
+	}
+	if l.Version < 1 {
+		return nil, fmt.Errorf("role library: version must be set")
+	}
+	seen := make(map[string]bool, len(l.Templates))
+	for _, t := range l.Templates {
+		if t.Key == "" || t.Name == "" {
+			return nil, fmt.Errorf("role library: template %q needs a key and a name", t.Key)
+		}
+		if seen[t.Key] {
+			return nil, fmt.Errorf("role library: duplicate template %q", t.Key)
+		}
+		seen[t.Key] = true
+		for _, code := range append(append([]string(nil), t.Allow...), t.Deny...) {
+			if !effective.ValidCode(code) {
+				return nil, fmt.Errorf("role library: template %q: invalid code %q", t.Key, code)
+			}
+		}
+		if _, err := t.principalTypes(); err != nil {
+			return nil, fmt.Errorf("role library: template %q: %w", t.Key, err)
+		}
+	}
+	return &l, nil
+}
+
+// Get returns the template with key.
+func (l *Library) Get(key string) (Template, bool) {
+	for _, t := range l.Templates {
+		if t.Key == key {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+// Match returns the template named like a role ("Sales" or "sales"), for
+// roles whose template was not recorded.
+func (l *Library) Match(roleName string) (Template, bool) {
+	for _, t := range l.Templates {
+		if strings.EqualFold(strings.TrimSpace(roleName), t.Name) {
+			return t, true
+		}
+	}
+	return Template{}, false
+}
+
+func (t Template) principalTypes() ([]principaltypepb.PrincipalType, error) {
+	return ParsePrincipalTypes(t.PrincipalTypes)
+}
+
+// ParsePrincipalTypes maps names such as "operator_staff" to principal
+// types.
+func ParsePrincipalTypes(names []string) ([]principaltypepb.PrincipalType, error) {
+	var out []principaltypepb.PrincipalType
+	for _, name := range names {
+		v, ok := principaltypepb.PrincipalType_value["PRINCIPAL_TYPE_"+strings.ToUpper(name)]
+		if !ok || v == 0 {
+			return nil, fmt.Errorf("unknown principal type %q", name)
+		}
+		out = append(out, principaltypepb.PrincipalType(v))
+	}
+	return out, nil
+}
+
+// PrincipalTypeNames is the inverse of ParsePrincipalTypes.
+func PrincipalTypeNames(types []principaltypepb.PrincipalType) []string {
+	var out []string
+	for _, pt := range types {
+		if pt == principaltypepb.PrincipalType_PRINCIPAL_TYPE_UNSPECIFIED {
+			continue
+		}
+		out = append(out, strings.ToLower(strings.TrimPrefix(pt.String(), "PRINCIPAL_TYPE_")))
+	}
+	return out
+}
+
+// Role returns the role a template creates, without its permissions.
+func (t Template) Role() *rolepb.Role {
+	types, _ := t.principalTypes() // checked by Parse
+	return &rolepb.Role{
+		Name:                     t.Name,
+		Description:              t.Description,
+		Color:                    t.Color,
+		Active:                   true,
+		ApplicablePrincipalTypes: types,
+	}
+}
+
+// Grant is a catalog permission a template grants or denies.
+type Grant struct {
+	Permission *permissionpb.Permission
+	Deny       bool
+}
+
+// Resolve maps the template's codes onto catalog. A code the catalog holds
+// as is (wildcards included) maps to that permission; otherwise a wildcard
+// code expands to every active catalog permission it covers. A permission
+// both allowed and denied is denied. Codes that match nothing are returned
+// as missing. Grants are in code order.
+func (t Template) Resolve(catalog []*permissionpb.Permission) (grants []Grant, missing []string) {
+	byCode := make(map[string]*permissionpb.Permission, len(catalog))
+	for _, p := range catalog {
+		if _, ok := byCode[p.GetPermissionCode()]; !ok {
+			byCode[p.GetPermissionCode()] = p
+		}
+	}
+	deny := make(map[string]bool)
+	picked := make(map[string]*permissionpb.Permission)
+	add := func(code string, isDeny bool) {
+		if p, ok := byCode[code]; ok {
+			picked[p.GetId()] = p
+			deny[p.GetId()] = deny[p.GetId()] || isDeny
+			return
+		}
+		found := false
+		if strings.Contains(code, effective.Wildcard) {
+			for _, p := range catalog {
+				c := p.GetPermissionCode()
+				if !p.GetActive() || strings.Contains(c, effective.Wildcard) || !effective.Match(code, c) {
+					continue
+				}
+				picked[p.GetId()] = p
+				deny[p.GetId()] = deny[p.GetId()] || isDeny
+				found = true
+			}
+		}
+		if !found {
+			missing = append(missing, code)
+		}
+	}
+	for _, code := range t.Allow {
+		add(code, false)
+	}
+	for _, code := range t.Deny {
+		add(code, true)
+	}
+	for id, p := range picked {
+		grants = append(grants, Grant{Permission: p, Deny: deny[id]})
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		return grants[i].Permission.GetPermissionCode() < grants[j].Permission.GetPermissionCode()
+	})
+	return grants, missing
+}
+
+// Drift kinds.
+const (
+	DriftMissing     = "missing"      // the template grants it, the role does not
+	DriftExtra       = "extra"        // the role grants it, the template does not
+	DriftTypeDiffers = "type_differs" // both have it, one as ALLOW and the other as DENY
+)
+
+// DriftItem is one permission where a role and its template differ.
+type DriftItem struct {
+	Code         string
+	Name         string
+	Kind         string
+	TemplateDeny bool // meaningful unless Kind is DriftExtra
+	RoleDeny     bool // meaningful unless Kind is DriftMissing
+}
+
+// Drift returns how r, loaded with its RolePermissions, differs from t
+// resolved against catalog, in code order, and the template codes the
+// catalog lacks. Inactive rows count: the drift view is about what was
+// configured, not what is in effect.
+func Drift(t Template, catalog []*permissionpb.Permission, r *rolepb.Role) (items []DriftItem, missing []string) {
+	grants, missing := t.Resolve(catalog)
+	want := make(map[string]Grant, len(grants))
+	for _, g := range grants {
+		want[g.Permission.GetId()] = g
+	}
+	have := make(map[string]bool) // permission ID -> deny
+	perm := make(map[string]*permissionpb.Permission)
+	for _, rp := range r.GetRolePermissions() {
+		id := rp.GetPermissionId()
+		have[id] = have[id] || effective.IsDeny(rp)
+		if rp.GetPermission() != nil {
+			perm[id] = rp.GetPermission()
+		}
+	}
+	for _, p := range catalog {
+		if _, ok := perm[p.GetId()]; !ok {
+			perm[p.GetId()] = p
+		}
+	}
+
+	for id, g := range want {
+		roleDeny, ok := have[id]
+		switch {
+		case !ok:
+			items = append(items, DriftItem{Code: g.Permission.GetPermissionCode(), Name: g.Permission.GetName(), Kind: DriftMissing, TemplateDeny: g.Deny})
+		case roleDeny != g.Deny:
+			items = append(items, DriftItem{Code: g.Permission.GetPermissionCode(), Name: g.Permission.GetName(), Kind: DriftTypeDiffers, TemplateDeny: g.Deny, RoleDeny: roleDeny})
+		}
+	}
+	for id, roleDeny := range have {
+		if _, ok := want[id]; ok {
+			continue
+		}
+		p := perm[id]
+		code := p.GetPermissionCode()
+		if code == "" {
+			code = id
+		}
+		items = append(items, DriftItem{Code: code, Name: p.GetName(), Kind: DriftExtra, RoleDeny: roleDeny})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Code != items[j].Code {
+			return items[i].Code < items[j].Code
+		}
+		return items[i].Kind < items[j].Kind
+	})
+	return items, missing
+}
+
+// MemorySources records which template each role came from, in memory. Its
+// methods fit RoleModuleDeps.GetRoleTemplate and SetRoleTemplate for tests
+// and single-process setups; a host that keeps the provenance across
+// restarts supplies its own closures.
+type MemorySources struct {
+	mu   sync.RWMutex
+	keys map[string]string
+}
+
+// NewMemorySources returns an empty MemorySources.
+func NewMemorySources() *MemorySources {
+	return &MemorySources{keys: make(map[string]string)}
+}
+
+// Get returns the template key recorded for roleID, or "".
+func (m *MemorySources) Get(_ context.Context, roleID string) (string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.keys[roleID], nil
+}
+
+// Set records that roleID came from the template key.
+func (m *MemorySources) Set(_ context.Context, roleID, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keys[roleID] = key
+	return nil
+}
@@ -0,0 +1,246 @@
+package library
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+)
+
+var catalog = []*permissionpb.Permission{
+	{Id: "p-client-read", PermissionCode: "client:read", Active: true},
+	{Id: "p-client-list", PermissionCode: "client:list", Active: true},
+	{Id: "p-client-delete", PermissionCode: "client:delete", Active: true},
+	{Id: "p-user-read", PermissionCode: "user:read", Active: true},
+	{Id: "p-user-impersonate", PermissionCode: "user:impersonate", Active: true},
+	{Id: "p-old-read", PermissionCode: "legacy:read", Active: false},
+}
+
+func TestBuiltin(t *testing.T) {
+	t.Parallel()
+
+	lib, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"owner", "accountant", "sales", "auditor", "client_portal"} {
+		tmpl, ok := lib.Get(key)
+		if !ok {
+			t.Errorf("template %q is missing", key)
+			continue
+		}
+		if r := tmpl.Role(); r.GetName() == "" || !r.GetActive() || len(r.GetApplicablePrincipalTypes()) == 0 {
+			t.Errorf("template %q role = %v", key, r)
+		}
+	}
+	if tmpl, ok := lib.Match(" read-only AUDITOR "); !ok || tmpl.Key != "auditor" {
+		t.Errorf("Match by name = %v, %v", tmpl.Key, ok)
+	}
+}
+
+func TestParse(t *testing.T) {
+	t.Parallel()
+
+	for name, data := range map[string]string{
+		"no version":     `{"templates":[]}`,
+		"duplicate key":  `{"version":1,"templates":[{"key":"a","name":"A"},{"key":"a","name":"B"}]}`,
+		"invalid code":   `{"version":1,"templates":[{"key":"a","name":"A","allow":["client"]}]}`,
+		"unknown type":   `{"version":1,"templates":[{"key":"a","name":"A","principal_types":["robot"]}]}`,
+		"missing a name": `{"version":1,"templates":[{"key":"a"}]}`,
+	} {
+		if _, err := Parse([]byte(data)); err == nil {
+			t.Errorf("%s: Parse accepted %s", name, data)
+		}
+	}
+}
+
+func codes(grants []Grant) []string {
+	var out []string
+	for _, g := range grants {
+		c := g.Permission.GetPermissionCode()
+		if g.Deny {
+			c = "!" + c
+		}
+		out = append(out, c)
+	}
+	return out
+}
+
+func TestResolve(t *testing.T) {
+	t.Parallel()
+
+	tmpl := Template{
+		Allow: []string{"client:read", "*:read", "invoice:read"},
+		Deny:  []string{"user:*"},
+	}
+	grants, missing := tmpl.Resolve(catalog)
+
+	// *:read skips the inactive legacy:read; user:read is both allowed and
+	// denied, so it is denied.
+	want := []string{"client:read", "!user:impersonate", "!user:read"}
+	if got := codes(grants); !slices.Equal(got, want) {
+		t.Errorf("grants = %v, want %v", got, want)
+	}
+	if !slices.Equal(missing, []string{"invoice:read"}) {
+		t.Errorf("missing = %v, want [invoice:read]", missing)
+	}
+
+	// A wildcard the catalog holds as a permission maps to it as is.
+	withStar := append(slices.Clone(catalog), &permissionpb.Permission{Id: "p-star", PermissionCode: "*:*", Active: true})
+	grants, _ = Template{Allow: []string{"*:*"}}.Resolve(withStar)
+	if got := codes(grants); !slices.Equal(got, []string{"*:*"}) {
+		t.Errorf("*:* grants = %v, want [*:*]", got)
+	}
+}
+
+func TestDrift(t *testing.T) {
+	t.Parallel()
+
+	tmpl := Template{Allow: []string{"client:read", "client:list"}, Deny: []string{"user:impersonate"}}
+	r := &rolepb.Role{RolePermissions: []*rolepermissionpb.RolePermission{
+		{PermissionId: "p-client-read", Active: true},
+		{PermissionId: "p-user-impersonate", Active: true}, // ALLOW where the template denies
+		{PermissionId: "p-client-delete", Active: true},
+	}}
+	items, _ := Drift(tmpl, catalog, r)
+
+	want := []DriftItem{
+		{Code: "client:delete", Kind: DriftExtra},
+		{Code: "client:list", Kind: DriftMissing},
+		{Code: "user:impersonate", Kind: DriftTypeDiffers, TemplateDeny: true},
+	}
+	if !slices.Equal(items, want) {
+		t.Errorf("drift = %+v, want %+v", items, want)
+	}
+
+	r.RolePermissions = r.RolePermissions[:1]
+	r.RolePermissions = append(r.RolePermissions,
+		&rolepermissionpb.RolePermission{PermissionId: "p-client-list", Active: true},
+		&rolepermissionpb.RolePermission{PermissionId: "p-user-impersonate", Active: true, PermissionType: permissionpb.PermissionType_PERMISSION_TYPE_DENY},
+	)
+	if items, _ := Drift(tmpl, catalog, r); len(items) != 0 {
+		t.Errorf("in-sync role drifts: %+v", items)
+	}
+}
+
+func TestNewUseAction(t *testing.T) {
+	t.Parallel()
+
+	var (
+		created *rolepb.Role
+		rows    []*rolepermissionpb.RolePermission
+	)
+	sources := NewMemorySources()
+	deps := &Deps{
+		CreateRole: func(_ context.Context, req *rolepb.CreateRoleRequest) (*rolepb.CreateRoleResponse, error) {
+			created = req.GetData()
+			created.Id = "role-new"
+			return &rolepb.CreateRoleResponse{Data: []*rolepb.Role{created}}, nil
+		},
+		ListPermissions: func(context.Context, *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error) {
+			return &permissionpb.ListPermissionsResponse{Data: catalog}, nil
+		},
+		CreateRolePermission: func(_ context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error) {
+			rows = append(rows, req.GetData())
+			return &rolepermissionpb.CreateRolePermissionResponse{}, nil
+		},
+		DeleteRole: func(context.Context, *rolepb.DeleteRoleRequest) (*rolepb.DeleteRoleResponse, error) {
+			t.Fatal("a complete role was deleted")
+			return nil, nil
+		},
+		SetRoleTemplate: sources.Set,
+		Routes:          role.DefaultRoutes(),
+	}
+	form := url.Values{"name": {"Auditors"}, "active": {"true"}}
+	req := httptest.NewRequest(http.MethodPost, "/action/role/templates/use/auditor", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetPathValue("key", "auditor")
+
+	denied := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"role:create"}))
+	if res := NewUseAction(deps).Handle(denied, &view.ViewContext{Request: req}); res.StatusCode != http.StatusUnprocessableEntity || created != nil {
+		t.Fatalf("without role:update: status %d, created %v", res.StatusCode, created)
+	}
+
+	ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"role:create", "role:update"}))
+	res := NewUseAction(deps).Handle(ctx, &view.ViewContext{Request: req})
+	if res.Redirect != "/roles/detail/role-new?tab=template" {
+		t.Fatalf("result = %+v", res)
+	}
+	if created.GetName() != "Auditors" || len(created.GetApplicablePrincipalTypes()) != 2 {
+		t.Errorf("created = %v", created)
+	}
+	var got []string
+	for _, rp := range rows {
+		c := rp.GetPermissionId()
+		if rp.GetPermissionType() == permissionpb.PermissionType_PERMISSION_TYPE_DENY {
+			c = "!" + c
+		}
+		if rp.GetRoleId() != "role-new" || !rp.GetActive() {
+			t.Errorf("row = %v", rp)
+		}
+		got = append(got, c)
+	}
+	want := []string{"!p-user-impersonate", "p-client-list", "p-client-read", "p-user-read"}
+	if !slices.Equal(got, want) {
+		t.Errorf("rows = %v, want %v", got, want)
+	}
+	if key, _ := sources.Get(ctx, "role-new"); key != "auditor" {
+		t.Errorf("recorded template = %q, want auditor", key)
+	}
+}
+
+func TestInstantiate_FailedRowDiscardsTheRole(t *testing.T) {
+	t.Parallel()
+
+	var (
+		rows    []*rolepermissionpb.RolePermission
+		deleted []string
+	)
+	deps := &Deps{
+		CreateRole: func(context.Context, *rolepb.CreateRoleRequest) (*rolepb.CreateRoleResponse, error) {
+			return &rolepb.CreateRoleResponse{Data: []*rolepb.Role{{Id: "role-new"}}}, nil
+		},
+		ListPermissions: func(context.Context, *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error) {
+			return &permissionpb.ListPermissionsResponse{Data: catalog}, nil
+		},
+		CreateRolePermission: func(_ context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error) {
+			if req.GetData().GetPermissionType() != permissionpb.PermissionType_PERMISSION_TYPE_DENY {
+				return nil, errors.New("write failed")
+			}
+			rows = append(rows, req.GetData())
+			return &rolepermissionpb.CreateRolePermissionResponse{}, nil
+		},
+		DeleteRole: func(_ context.Context, req *rolepb.DeleteRoleRequest) (*rolepb.DeleteRoleResponse, error) {
+			deleted = append(deleted, req.GetData().GetId())
+			return &rolepb.DeleteRoleResponse{}, nil
+		},
+	}
+	lib, err := Builtin()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl, _ := lib.Get("auditor")
+
+	id, err := Instantiate(context.Background(), deps, tmpl, tmpl.Role())
+	if err == nil || id != "" {
+		t.Fatalf("Instantiate = %q, %v; want an error and no role", id, err)
+	}
+	if len(rows) != 1 || rows[0].GetPermissionId() != "p-user-impersonate" {
+		t.Fatalf("rows = %v, want only the DENY row", rows)
+	}
+	if !slices.Equal(deleted, []string{"role-new"}) {
+		t.Fatalf("deleted = %v, want [role-new]", deleted)
+	}
+}
@@ -0,0 +1,125 @@
+package library
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/route"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+)
+
+// Deps holds dependencies for the template library page and the drawer
+// that makes a role from a template.
+type Deps struct {
+	CreateRole           func(ctx context.Context, req *rolepb.CreateRoleRequest) (*rolepb.CreateRoleResponse, error)
+	ListPermissions      func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
+	CreateRolePermission func(ctx context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error)
+	// DeleteRole takes back a role whose permissions could not all be
+	// written, SetRoleActive deactivates it when even that fails. Without
+	// DeleteRole, Instantiate refuses.
+	DeleteRole    func(ctx context.Context, req *rolepb.DeleteRoleRequest) (*rolepb.DeleteRoleResponse, error)
+	SetRoleActive func(ctx context.Context, id string, active bool) error
+	// SetRoleTemplate records the template a new role came from, for the
+	// detail page's drift tab. Optional.
+	SetRoleTemplate func(ctx context.Context, roleID, key string) error
+	Routes          role.Routes
+	Labels          role.Labels
+	CommonLabels    pyeza.CommonLabels
+}
+
+// PageData holds the data for the template library page.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Labels          role.TemplateLabels
+	Version         string
+	Cards           []Card
+	CanUse          bool
+	ErrorMessage    string
+}
+
+// Card is one template on the library page.
+type Card struct {
+	Key            string
+	Name           string
+	Description    string
+	Color          string
+	PrincipalTypes string
+	Allow          []string
+	Deny           []string
+	Unavailable    string // template codes this workspace's catalog lacks
+	UseURL         string
+}
+
+// NewView creates the template library view (full page).
+func NewView(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("role", "list") {
+			return view.Forbidden("role:list")
+		}
+
+		l := deps.Labels.Templates
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion:   viewCtx.CacheVersion,
+				Title:          l.Heading,
+				CurrentPath:    viewCtx.CurrentPath,
+				ActiveNav:      "user",
+				ActiveSubNav:   "role",
+				HeaderTitle:    l.Heading,
+				HeaderSubtitle: l.Caption,
+				HeaderIcon:     "icon-shield",
+				CommonLabels:   deps.CommonLabels,
+			},
+			ContentTemplate: "role-template-list-content",
+			Labels:          l,
+			CanUse:          perms.Can("role", "create") && perms.Can("role", "update"),
+		}
+
+		lib, err := Builtin()
+		if err != nil {
+			log.Printf("Failed to load the role library: %v", err)
+			pageData.ErrorMessage = l.ErrorLoad
+			return view.OK("role-template-list", pageData)
+		}
+		pageData.Version = fmt.Sprintf(l.Version, lib.Version)
+
+		var catalog []*permissionpb.Permission
+		if resp, err := deps.ListPermissions(ctx, &permissionpb.ListPermissionsRequest{}); err != nil {
+			log.Printf("Failed to list permissions for the role library: %v", err)
+		} else {
+			catalog = resp.GetData()
+		}
+		for _, t := range lib.Templates {
+			card := Card{
+				Key:            t.Key,
+				Name:           t.Name,
+				Description:    t.Description,
+				Color:          t.Color,
+				PrincipalTypes: strings.ReplaceAll(strings.Join(t.PrincipalTypes, ", "), "_", " "),
+				Allow:          t.Allow,
+				Deny:           t.Deny,
+				UseURL:         route.ResolveURL(deps.Routes.TemplateUseURL, "key", t.Key),
+			}
+			if catalog != nil {
+				if _, missing := t.Resolve(catalog); len(missing) > 0 {
+					card.Unavailable = strings.Join(missing, ", ")
+				}
+			}
+			pageData.Cards = append(pageData.Cards, card)
+		}
+
+		return view.OK("role-template-list", pageData)
+	})
+}
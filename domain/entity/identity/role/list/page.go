@@ -37,6 +37,12 @@ type PageData struct {
 	types.PageData
 	ContentTemplate string
 	Table           *types.TableConfig
+	MatrixURL       string // link to the role × permission matrix; empty hides it
+	MatrixLabel     string
+	TemplatesURL    string // link to the role template library; empty hides it
+	TemplatesLabel  string
+	TransferURL     string // link to the role setup export/import; empty hides it
+	TransferLabel   string
 }
 
 var roleSearchFields = []string{"name", "description"}
@@ -73,6 +79,21 @@ func NewView(deps *ListViewDeps) view.View {
 			},
 			ContentTemplate: "role-list-content",
 			Table:           tableConfig,
+			MatrixURL:       deps.Routes.MatrixURL,
+			MatrixLabel:     deps.Labels.Buttons.PermissionMatrix,
+			TemplatesURL:    deps.Routes.TemplatesURL,
+			TemplatesLabel:  deps.Labels.Templates.Link,
+			TransferURL:     deps.Routes.TransferURL,
+			TransferLabel:   deps.Labels.Transfer.Link,
+		}
+		if pageData.MatrixLabel == "" {
+			pageData.MatrixLabel = "Permission matrix"
+		}
+		if pageData.TemplatesLabel == "" {
+			pageData.TemplatesLabel = "Role templates"
+		}
+		if pageData.TransferLabel == "" {
+			pageData.TransferLabel = "Export / import"
 		}
 
 		// KB help content
@@ -140,7 +161,7 @@ func buildTableConfig(ctx context.Context, deps *ListViewDeps, columns []types.T
 	}
 
 	l := deps.Labels
-	rows := buildTableRows(resp.GetRoleList(), l, deps.SharedLabels, deps.Routes, inUseIDs, perms)
+	rows := buildTableRows(resp.GetRoleList(), l, deps.SharedLabels, deps.CommonLabels, deps.Routes, inUseIDs, perms)
 	types.ApplyColumnStyles(columns, rows)
 
 	bulkCfg := pyeza.MapBulkConfig(deps.CommonLabels)
@@ -212,7 +233,7 @@ func roleColumns(l role.Labels) []types.TableColumn {
 	}
 }
 
-func buildTableRows(roles []*rolepb.Role, l role.Labels, sl entydad.SharedLabels, routes role.Routes, inUseIDs map[string]bool, perms *types.UserPermissions) []types.TableRow {
+func buildTableRows(roles []*rolepb.Role, l role.Labels, sl entydad.SharedLabels, cl pyeza.CommonLabels, routes role.Routes, inUseIDs map[string]bool, perms *types.UserPermissions) []types.TableRow {
 	rows := []types.TableRow{}
 	for _, r := range roles {
 		active := r.GetActive()
@@ -228,6 +249,12 @@ func buildTableRows(roles []*rolepb.Role, l role.Labels, sl entydad.SharedLabels
 
 		actions := []types.TableAction{
 			{Type: "view", Label: l.Actions.View, Action: "view", Href: route.ResolveURL(routes.DetailURL, "id", id)},
+			// Clone copies the role's permissions, so it takes create and update.
+			{
+				Type: "clone", Label: cl.Actions.Clone, Action: "clone",
+				URL: route.ResolveURL(routes.EditURL, "id", id), DrawerTitle: cl.Actions.Clone,
+				Disabled: !perms.Can("role", "create") || !perms.Can("role", "update"), DisabledTooltip: sl.Badges.NoPermission,
+			},
 		}
 		if active {
 			actions = append(actions, types.TableAction{
@@ -0,0 +1,273 @@
+package matrix
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/erniealice/pyeza-golang/view"
+
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+)
+
+// ReviewData is the confirmation summary of a save. It carries the
+// submitted matrix as hidden inputs so Apply posts the reviewed state, not
+// whatever the page shows by then.
+type ReviewData struct {
+	Labels        role.PermissionMatrixLabels
+	SaveURL       string
+	WorkspaceID   string // injected by the ViewAdapter for actionForm
+	Summary       string
+	Changes       []ChangeData
+	RoleIDs       []string
+	PermissionIDs []string
+	Grants        []string
+	Failed        string
+	Failures      []string // steps a failed save could not undo
+}
+
+// ChangeData is one role's grants, revokes and lifted denies, as
+// permission codes.
+type ChangeData struct {
+	RoleName   string
+	Grant      []string
+	Revoke     []string
+	RemoveDeny []string
+}
+
+// NewSaveAction creates the matrix save action (POST only). Without
+// confirm=1 it renders the review of the changes; with it, it applies them
+// and sends the browser back to the matrix. When some changes fail, the
+// review is rendered again with the failures and what is left to do.
+func NewSaveAction(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("role", "update") {
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+		if viewCtx.Request.Method != http.MethodPost {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+
+		l := deps.Labels.Matrix
+		form := viewCtx.Request.PostForm
+		desired := make(map[Cell]bool)
+		for _, v := range form["grant"] {
+			if c, ok := ParseCell(v); ok {
+				desired[c] = true
+			}
+		}
+
+		s, err := Load(ctx, deps)
+		if err != nil {
+			log.Printf("Failed to load role permission matrix: %v", err)
+			return view.HTMXError(l.ErrorLoad)
+		}
+		changes := plan(s, form["role"], form["permission"], desired)
+
+		if form.Get("confirm") != "1" || len(changes) == 0 {
+			return view.OK("role-permission-matrix-review", reviewData(deps, l, s, changes, form["role"], form["permission"], desired))
+		}
+
+		applied, err := Apply(ctx, deps, s, changes)
+		if err == nil {
+			return view.Redirect(deps.Routes.MatrixURL + "?saved=" + strconv.Itoa(applied))
+		}
+
+		// Show what is still to do against the state the batch left behind:
+		// everything, unless some steps could not be undone.
+		var applyErr *ApplyError
+		if !errors.As(err, &applyErr) {
+			applyErr = &ApplyError{Err: err}
+		}
+		if s, err = Load(ctx, deps); err != nil {
+			log.Printf("Failed to reload role permission matrix: %v", err)
+			return view.HTMXError(l.ErrorLoad)
+		}
+		changes = plan(s, form["role"], form["permission"], desired)
+		data := reviewData(deps, l, s, changes, form["role"], form["permission"], desired)
+		data.Failed = fmt.Sprintf(l.Failed, applyErr.Step, applyErr.Err)
+		if len(applyErr.RollbackErrs) > 0 {
+			data.Failed = fmt.Sprintf(l.RollbackFailed, applyErr.Step, applyErr.Err)
+			for _, e := range applyErr.RollbackErrs {
+				data.Failures = append(data.Failures, e.Error())
+			}
+		}
+		return view.OK("role-permission-matrix-review", data)
+	})
+}
+
+func plan(s *State, roleIDs, permissionIDs []string, desired map[Cell]bool) []Change {
+	grant, revoke := Diff(s.Current(), desired, s.Scope(roleIDs, permissionIDs))
+	return s.Plan(grant, revoke)
+}
+
+func reviewData(deps *Deps, l role.PermissionMatrixLabels, s *State, changes []Change, roleIDs, permissionIDs []string, desired map[Cell]bool) *ReviewData {
+	data := &ReviewData{
+		Labels:        l,
+		SaveURL:       deps.Routes.MatrixSaveURL,
+		RoleIDs:       roleIDs,
+		PermissionIDs: permissionIDs,
+	}
+	for _, c := range s.Scope(roleIDs, permissionIDs) {
+		if desired[c] {
+			data.Grants = append(data.Grants, c.Key())
+		}
+	}
+	grants, revokes, denies := 0, 0, 0
+	for _, ch := range changes {
+		cd := ChangeData{RoleName: ch.RoleName}
+		for _, c := range ch.Grant {
+			cd.Grant = append(cd.Grant, s.permission(c.PermissionID).GetPermissionCode())
+		}
+		for _, c := range ch.Revoke {
+			cd.Revoke = append(cd.Revoke, s.permission(c.PermissionID).GetPermissionCode())
+		}
+		for _, c := range ch.RemoveDeny {
+			cd.RemoveDeny = append(cd.RemoveDeny, s.permission(c.PermissionID).GetPermissionCode())
+		}
+		grants += len(ch.Grant)
+		revokes += len(ch.Revoke)
+		denies += len(ch.RemoveDeny)
+		data.Changes = append(data.Changes, cd)
+	}
+	if len(changes) > 0 {
+		data.Summary = fmt.Sprintf(l.Summary, grants, revokes, len(changes))
+		if denies > 0 {
+			data.Summary += " " + fmt.Sprintf(l.SummaryRemoveDeny, denies)
+		}
+	}
+	return data
+}
+
+// ApplyError is a failed save. Every step made before Step was undone,
+// except those listed in RollbackErrs.
+type ApplyError struct {
+	Step         string
+	Err          error
+	RollbackErrs []error
+}
+
+func (e *ApplyError) Error() string {
+	if len(e.RollbackErrs) > 0 {
+		return fmt.Sprintf("%s: %v (and %d steps could not be undone: %v)", e.Step, e.Err, len(e.RollbackErrs), errors.Join(e.RollbackErrs...))
+	}
+	return fmt.Sprintf("%s: %v", e.Step, e.Err)
+}
+
+func (e *ApplyError) Unwrap() error { return e.Err }
+
+// step is one role_permission call and the call that reverses it.
+type step struct {
+	name string
+	do   func(ctx context.Context) error
+	undo func(ctx context.Context) error
+}
+
+// Apply makes the changes: every role_permission row of a revoked or
+// lifted-deny cell is deleted, and a granted cell gets one active row, as
+// the assign drawer creates it. Each role's deletions go before its grants.
+// The use cases share no transaction, so Apply is atomic by compensation,
+// like the transfer import: when a step fails, the steps already made are
+// undone in reverse order and no role is left half-changed. It returns how
+// many cells changed.
+func Apply(ctx context.Context, deps *Deps, s *State, changes []Change) (int, error) {
+	var steps []step
+	cells := 0
+	for _, ch := range changes {
+		for _, c := range ch.Revoke {
+			steps = append(steps, removeSteps(deps, s, ch, c, "revoke")...)
+		}
+		for _, c := range ch.RemoveDeny {
+			steps = append(steps, removeSteps(deps, s, ch, c, "remove deny")...)
+		}
+		for _, c := range ch.Grant {
+			steps = append(steps, grantStep(deps, s, ch, c))
+		}
+		cells += len(ch.Revoke) + len(ch.RemoveDeny) + len(ch.Grant)
+	}
+	for i, st := range steps {
+		if err := st.do(ctx); err != nil {
+			log.Printf("Failed to save role permission matrix: %s: %v", st.name, err)
+			applyErr := &ApplyError{Step: st.name, Err: err}
+			for j := i - 1; j >= 0; j-- {
+				if err := steps[j].undo(ctx); err != nil {
+					log.Printf("Failed to undo %s: %v", steps[j].name, err)
+					applyErr.RollbackErrs = append(applyErr.RollbackErrs, fmt.Errorf("undo %s: %w", steps[j].name, err))
+				}
+			}
+			return 0, applyErr
+		}
+	}
+	return cells, nil
+}
+
+// removeSteps deletes every row of cell c, one step per row, so a cell
+// whose second row fails to delete gets its first one back.
+func removeSteps(deps *Deps, s *State, ch Change, c Cell, verb string) []step {
+	name := fmt.Sprintf("%s: %s %s", ch.RoleName, verb, s.permission(c.PermissionID).GetPermissionCode())
+	var steps []step
+	for _, rp := range s.Rows[c] {
+		steps = append(steps, step{
+			name: name,
+			do: func(ctx context.Context) error {
+				_, err := deps.DeleteRolePermission(ctx, &rolepermissionpb.DeleteRolePermissionRequest{
+					Data: &rolepermissionpb.RolePermission{Id: rp.GetId()},
+				})
+				return err
+			},
+			undo: func(ctx context.Context) error {
+				_, err := deps.CreateRolePermission(ctx, &rolepermissionpb.CreateRolePermissionRequest{
+					Data: &rolepermissionpb.RolePermission{
+						RoleId:         c.RoleID,
+						PermissionId:   c.PermissionID,
+						PermissionType: rp.GetPermissionType(),
+						Active:         rp.GetActive(),
+					},
+				})
+				return err
+			},
+		})
+	}
+	return steps
+}
+
+func grantStep(deps *Deps, s *State, ch Change, c Cell) step {
+	var rowID string
+	return step{
+		name: fmt.Sprintf("%s: grant %s", ch.RoleName, s.permission(c.PermissionID).GetPermissionCode()),
+		do: func(ctx context.Context) error {
+			resp, err := deps.CreateRolePermission(ctx, &rolepermissionpb.CreateRolePermissionRequest{
+				Data: &rolepermissionpb.RolePermission{
+					RoleId:       c.RoleID,
+					PermissionId: c.PermissionID,
+					Active:       true,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if len(resp.GetData()) > 0 {
+				rowID = resp.GetData()[0].GetId()
+			}
+			return nil
+		},
+		undo: func(ctx context.Context) error {
+			if rowID == "" {
+				return fmt.Errorf("the created row's ID is unknown")
+			}
+			_, err := deps.DeleteRolePermission(ctx, &rolepermissionpb.DeleteRolePermissionRequest{
+				Data: &rolepermissionpb.RolePermission{Id: rowID},
+			})
+			return err
+		},
+	}
+}
@@ -0,0 +1,218 @@
+// Package matrix is the role × permission matrix: every role as a column,
+// every permission as a row grouped by the entity half of its code, and a
+// checkbox where they meet. A save is reviewed first — the grants and
+// revokes it amounts to, per role — and then applied as one batch of
+// RolePermission creates and deletes.
+package matrix
+
+import (
+	"sort"
+	"strings"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+)
+
+// Cell is one role and one permission.
+type Cell struct {
+	RoleID       string
+	PermissionID string
+}
+
+// Key is the cell's form value, "roleID|permissionID".
+func (c Cell) Key() string {
+	return c.RoleID + "|" + c.PermissionID
+}
+
+// ParseCell parses a form value written by Key.
+func ParseCell(s string) (Cell, bool) {
+	roleID, permissionID, ok := strings.Cut(s, "|")
+	if !ok || roleID == "" || permissionID == "" {
+		return Cell{}, false
+	}
+	return Cell{RoleID: roleID, PermissionID: permissionID}, true
+}
+
+// State is the loaded matrix: the roles in name order, the permissions in
+// code order, and the role_permission rows of each cell. A cell may hold
+// more than one row (an ALLOW and a DENY, say); it is granted when it holds
+// any.
+type State struct {
+	Roles       []*rolepb.Role
+	Permissions []*permissionpb.Permission
+	Rows        map[Cell][]*rolepermissionpb.RolePermission
+}
+
+// NewState indexes roles, each loaded with its RolePermissions, against the
+// permission catalog.
+func NewState(roles []*rolepb.Role, permissions []*permissionpb.Permission) *State {
+	s := &State{
+		Roles:       append([]*rolepb.Role(nil), roles...),
+		Permissions: append([]*permissionpb.Permission(nil), permissions...),
+		Rows:        make(map[Cell][]*rolepermissionpb.RolePermission),
+	}
+	sort.SliceStable(s.Roles, func(i, j int) bool {
+		return strings.ToLower(s.Roles[i].GetName()) < strings.ToLower(s.Roles[j].GetName())
+	})
+	sort.SliceStable(s.Permissions, func(i, j int) bool {
+		return s.Permissions[i].GetPermissionCode() < s.Permissions[j].GetPermissionCode()
+	})
+	for _, r := range s.Roles {
+		for _, rp := range r.GetRolePermissions() {
+			c := Cell{RoleID: r.GetId(), PermissionID: rp.GetPermissionId()}
+			s.Rows[c] = append(s.Rows[c], rp)
+		}
+	}
+	return s
+}
+
+// Granted reports whether the cell holds any role_permission row.
+func (s *State) Granted(c Cell) bool {
+	return len(s.Rows[c]) > 0
+}
+
+// Deny reports whether any row of the cell denies.
+func (s *State) Deny(c Cell) bool {
+	for _, rp := range s.Rows[c] {
+		if rp.GetPermission() == nil {
+			rp = &rolepermissionpb.RolePermission{PermissionType: rp.GetPermissionType(), Permission: s.permission(c.PermissionID)}
+		}
+		if effective.IsDeny(rp) {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *State) role(id string) *rolepb.Role {
+	for _, r := range s.Roles {
+		if r.GetId() == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (s *State) permission(id string) *permissionpb.Permission {
+	for _, p := range s.Permissions {
+		if p.GetId() == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// Entity returns the entity half of a permission code, the matrix's row
+// group.
+func Entity(code string) string {
+	entity, _, _ := strings.Cut(code, ":")
+	return entity
+}
+
+// Diff returns the cells of scope whose grant changes from current to
+// desired: granted in desired but not current, and the reverse. Cells
+// outside scope are left alone, so a form only changes what it showed.
+// Both lists follow scope's order.
+func Diff(current, desired map[Cell]bool, scope []Cell) (grant, revoke []Cell) {
+	seen := make(map[Cell]bool, len(scope))
+	for _, c := range scope {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		switch {
+		case desired[c] && !current[c]:
+			grant = append(grant, c)
+		case !desired[c] && current[c]:
+			revoke = append(revoke, c)
+		}
+	}
+	return grant, revoke
+}
+
+// Scope returns the cells of roleIDs × permissionIDs that exist in s, in
+// the matrix's order. Unknown IDs are dropped.
+func (s *State) Scope(roleIDs, permissionIDs []string) []Cell {
+	roles := make(map[string]bool, len(roleIDs))
+	for _, id := range roleIDs {
+		roles[id] = true
+	}
+	perms := make(map[string]bool, len(permissionIDs))
+	for _, id := range permissionIDs {
+		perms[id] = true
+	}
+	var out []Cell
+	for _, p := range s.Permissions {
+		if !perms[p.GetId()] {
+			continue
+		}
+		for _, r := range s.Roles {
+			if roles[r.GetId()] {
+				out = append(out, Cell{RoleID: r.GetId(), PermissionID: p.GetId()})
+			}
+		}
+	}
+	return out
+}
+
+// Current returns the granted cells of s.
+func (s *State) Current() map[Cell]bool {
+	out := make(map[Cell]bool, len(s.Rows))
+	for c, rows := range s.Rows {
+		if len(rows) > 0 {
+			out[c] = true
+		}
+	}
+	return out
+}
+
+// Change is one role's part of a save. A cleared cell holding a DENY row
+// is listed under RemoveDeny rather than Revoke: deleting its rows lifts
+// the deny, so it widens the role's access instead of narrowing it.
+type Change struct {
+	RoleID     string
+	RoleName   string
+	Grant      []Cell
+	Revoke     []Cell
+	RemoveDeny []Cell
+}
+
+// Plan groups the cells of a diff by role, in the matrix's role order.
+// Grants of an inactive permission are dropped — as on the assign drawer,
+// only active permissions can be granted — and revokes of a cell holding a
+// DENY row become RemoveDeny.
+func (s *State) Plan(grant, revoke []Cell) []Change {
+	byRole := make(map[string]*Change)
+	at := func(roleID string) *Change {
+		if c, ok := byRole[roleID]; ok {
+			return c
+		}
+		c := &Change{RoleID: roleID, RoleName: s.role(roleID).GetName()}
+		byRole[roleID] = c
+		return c
+	}
+	for _, c := range grant {
+		if !s.permission(c.PermissionID).GetActive() {
+			continue
+		}
+		at(c.RoleID).Grant = append(at(c.RoleID).Grant, c)
+	}
+	for _, c := range revoke {
+		ch := at(c.RoleID)
+		if s.Deny(c) {
+			ch.RemoveDeny = append(ch.RemoveDeny, c)
+			continue
+		}
+		ch.Revoke = append(ch.Revoke, c)
+	}
+	var out []Change
+	for _, r := range s.Roles {
+		if c, ok := byRole[r.GetId()]; ok {
+			out = append(out, *c)
+		}
+	}
+	return out
+}
@@ -0,0 +1,281 @@
+package matrix
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+)
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+
+	a := Cell{RoleID: "r1", PermissionID: "p1"}
+	b := Cell{RoleID: "r1", PermissionID: "p2"}
+	c := Cell{RoleID: "r2", PermissionID: "p1"}
+	outside := Cell{RoleID: "r3", PermissionID: "p1"}
+
+	current := map[Cell]bool{a: true, c: true, outside: true}
+	desired := map[Cell]bool{b: true, c: true}
+	grant, revoke := Diff(current, desired, []Cell{a, b, c, a})
+
+	if !slices.Equal(grant, []Cell{b}) {
+		t.Errorf("grant = %v, want [%v]", grant, b)
+	}
+	if !slices.Equal(revoke, []Cell{a}) {
+		t.Errorf("revoke = %v, want [%v] (cells outside the scope are left alone)", revoke, a)
+	}
+}
+
+func TestParseCell(t *testing.T) {
+	t.Parallel()
+
+	c := Cell{RoleID: "r1", PermissionID: "p1"}
+	if got, ok := ParseCell(c.Key()); !ok || got != c {
+		t.Errorf("ParseCell(%q) = %v, %v", c.Key(), got, ok)
+	}
+	for _, s := range []string{"", "r1", "|p1", "r1|"} {
+		if _, ok := ParseCell(s); ok {
+			t.Errorf("ParseCell(%q) accepted", s)
+		}
+	}
+}
+
+// fakeStore is the role_permission table behind the deps closures.
+type fakeStore struct {
+	roles      []*rolepb.Role
+	perms      []*permissionpb.Permission
+	rows       []*rolepermissionpb.RolePermission
+	nextID     int
+	failGrant  string // permission ID whose create fails
+	failDelete string // row ID whose delete fails
+}
+
+func (f *fakeStore) deps() *Deps {
+	return &Deps{
+		ListRoles: func(context.Context, *rolepb.ListRolesRequest) (*rolepb.ListRolesResponse, error) {
+			return &rolepb.ListRolesResponse{Data: f.roles}, nil
+		},
+		GetRoleItemPageData: func(_ context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error) {
+			for _, r := range f.roles {
+				if r.GetId() != req.GetRoleId() {
+					continue
+				}
+				loaded := &rolepb.Role{Id: r.GetId(), Name: r.GetName(), Active: r.GetActive()}
+				for _, rp := range f.rows {
+					if rp.GetRoleId() == r.GetId() {
+						loaded.RolePermissions = append(loaded.RolePermissions, rp)
+					}
+				}
+				return &rolepb.GetRoleItemPageDataResponse{Role: loaded}, nil
+			}
+			return nil, errors.New("role not found")
+		},
+		ListPermissions: func(context.Context, *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error) {
+			return &permissionpb.ListPermissionsResponse{Data: f.perms}, nil
+		},
+		CreateRolePermission: func(_ context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error) {
+			if req.GetData().GetPermissionId() == f.failGrant {
+				return nil, errors.New("create failed")
+			}
+			f.nextID++
+			rp := req.GetData()
+			rp.Id = "new-" + strconv.Itoa(f.nextID)
+			f.rows = append(f.rows, rp)
+			return &rolepermissionpb.CreateRolePermissionResponse{Data: []*rolepermissionpb.RolePermission{rp}}, nil
+		},
+		DeleteRolePermission: func(_ context.Context, req *rolepermissionpb.DeleteRolePermissionRequest) (*rolepermissionpb.DeleteRolePermissionResponse, error) {
+			if req.GetData().GetId() == f.failDelete {
+				return nil, errors.New("delete failed")
+			}
+			f.rows = slices.DeleteFunc(f.rows, func(rp *rolepermissionpb.RolePermission) bool {
+				return rp.GetId() == req.GetData().GetId()
+			})
+			return &rolepermissionpb.DeleteRolePermissionResponse{}, nil
+		},
+		Routes: role.DefaultRoutes(),
+		Labels: role.PermissionLabels{Matrix: role.DefaultPermissionMatrixLabels()},
+	}
+}
+
+func (f *fakeStore) granted() []string {
+	var out []string
+	for _, rp := range f.rows {
+		out = append(out, rp.GetRoleId()+"|"+rp.GetPermissionId())
+	}
+	slices.Sort(out)
+	return out
+}
+
+func newStore() *fakeStore {
+	read := &permissionpb.Permission{Id: "p-read", PermissionCode: "client:read", Active: true}
+	del := &permissionpb.Permission{Id: "p-delete", PermissionCode: "client:delete", Active: true}
+	old := &permissionpb.Permission{Id: "p-old", PermissionCode: "legacy:run", Active: false}
+	return &fakeStore{
+		roles: []*rolepb.Role{
+			{Id: "sales", Name: "Sales", Active: true},
+			{Id: "auditor", Name: "Auditor", Active: true},
+		},
+		perms: []*permissionpb.Permission{read, del, old},
+		rows: []*rolepermissionpb.RolePermission{
+			{Id: "rp-1", RoleId: "sales", PermissionId: "p-read", Permission: read, Active: true},
+			// An ALLOW and a DENY on the same cell: clearing it removes
+			// both, and the review lists it as a lifted deny.
+			{Id: "rp-2", RoleId: "sales", PermissionId: "p-delete", Permission: del, Active: true},
+			{Id: "rp-3", RoleId: "sales", PermissionId: "p-delete", Permission: del, Active: true, PermissionType: permissionpb.PermissionType_PERMISSION_TYPE_DENY},
+		},
+	}
+}
+
+func TestBuildGrid(t *testing.T) {
+	t.Parallel()
+
+	f := newStore()
+	s, err := Load(context.Background(), f.deps())
+	if err != nil {
+		t.Fatal(err)
+	}
+	columns, groups := buildGrid(s, true)
+
+	if len(columns) != 2 || columns[0].Name != "Auditor" || columns[1].Name != "Sales" {
+		t.Fatalf("columns = %+v, want Auditor then Sales", columns)
+	}
+	if len(groups) != 2 || groups[0].Entity != "client" || groups[1].Entity != "legacy" {
+		t.Fatalf("groups = %+v, want client then legacy", groups)
+	}
+	del := groups[0].Rows[0] // client:delete sorts before client:read
+	if del.Code != "client:delete" || !del.Cells[1].Checked || !del.Cells[1].Deny || del.Cells[0].Checked {
+		t.Errorf("client:delete row = %+v", del)
+	}
+	old := groups[1].Rows[0]
+	if !old.Inactive || !old.Cells[0].Disabled {
+		t.Errorf("an inactive, ungranted permission must not be grantable: %+v", old)
+	}
+	if _, groups := buildGrid(s, false); !groups[0].Rows[0].Cells[1].Disabled {
+		t.Error("read-only matrix has an enabled cell")
+	}
+}
+
+func TestNewSaveAction(t *testing.T) {
+	t.Parallel()
+
+	post := func(d *Deps, perms []string, form url.Values) view.ViewResult {
+		req := httptest.NewRequest(http.MethodPost, role.MatrixSaveURL, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions(perms))
+		return NewSaveAction(d).Handle(ctx, &view.ViewContext{Request: req})
+	}
+	// The submitted matrix: Sales keeps client:read and loses client:delete;
+	// Auditor gains client:read and the inactive legacy:run (dropped).
+	form := url.Values{
+		"role":       {"sales", "auditor"},
+		"permission": {"p-read", "p-delete", "p-old"},
+		"grant":      {"sales|p-read", "auditor|p-read", "auditor|p-old"},
+	}
+
+	t.Run("permission denied", func(t *testing.T) {
+		t.Parallel()
+		f := newStore()
+		res := post(f.deps(), []string{"role:list"}, form)
+		if res.StatusCode != http.StatusUnprocessableEntity || len(f.rows) != 3 {
+			t.Fatalf("status = %d, rows = %v", res.StatusCode, f.granted())
+		}
+	})
+
+	t.Run("review changes nothing", func(t *testing.T) {
+		t.Parallel()
+		f := newStore()
+		res := post(f.deps(), []string{"role:update"}, form)
+		data, ok := res.Data.(*ReviewData)
+		if res.Template != "role-permission-matrix-review" || !ok {
+			t.Fatalf("result = %+v", res)
+		}
+		if len(f.rows) != 3 {
+			t.Fatalf("review wrote rows: %v", f.granted())
+		}
+		want := []ChangeData{
+			{RoleName: "Auditor", Grant: []string{"client:read"}},
+			{RoleName: "Sales", RemoveDeny: []string{"client:delete"}},
+		}
+		if len(data.Changes) != len(want) {
+			t.Fatalf("changes = %+v, want %+v", data.Changes, want)
+		}
+		for i := range want {
+			got := data.Changes[i]
+			if got.RoleName != want[i].RoleName || !slices.Equal(got.Grant, want[i].Grant) || !slices.Equal(got.Revoke, want[i].Revoke) || !slices.Equal(got.RemoveDeny, want[i].RemoveDeny) {
+				t.Errorf("change %d = %+v, want %+v", i, got, want[i])
+			}
+		}
+		if !strings.Contains(data.Summary, "1 to grant and 0 to revoke across 2 roles. 1 DENY rows will be removed") {
+			t.Errorf("summary = %q", data.Summary)
+		}
+	})
+
+	t.Run("confirm applies the batch", func(t *testing.T) {
+		t.Parallel()
+		f := newStore()
+		confirmed := url.Values{"confirm": {"1"}}
+		for k, v := range form {
+			confirmed[k] = v
+		}
+		res := post(f.deps(), []string{"role:update"}, confirmed)
+		if res.Redirect != role.MatrixURL+"?saved=2" {
+			t.Fatalf("redirect = %q (result %+v)", res.Redirect, res)
+		}
+		if got, want := f.granted(), []string{"auditor|p-read", "sales|p-read"}; !slices.Equal(got, want) {
+			t.Errorf("rows = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("a failed step undoes the batch", func(t *testing.T) {
+		t.Parallel()
+		confirmed := url.Values{"confirm": {"1"}}
+		for k, v := range form {
+			confirmed[k] = v
+		}
+		for name, fail := range map[string]func(*fakeStore){
+			// The first step: nothing to undo.
+			"grant": func(f *fakeStore) { f.failGrant = "p-read" },
+			// Auditor's grant and the first row of Sales' cleared cell
+			// are already made when the DENY row fails to delete.
+			"second row of a cell": func(f *fakeStore) { f.failDelete = "rp-3" },
+		} {
+			f := newStore()
+			fail(f)
+			before := f.granted()
+			res := post(f.deps(), []string{"role:update"}, confirmed)
+			data, ok := res.Data.(*ReviewData)
+			if !ok || data.Failed == "" || len(data.Failures) != 0 {
+				t.Fatalf("%s: result = %+v", name, res)
+			}
+			if len(data.Changes) != 2 {
+				t.Errorf("%s: remaining changes = %+v, want the whole batch", name, data.Changes)
+			}
+			if got := f.granted(); !slices.Equal(got, before) {
+				t.Errorf("%s: rows = %v, want %v", name, got, before)
+			}
+			deny := 0
+			for _, rp := range f.rows {
+				if rp.GetPermissionType() == permissionpb.PermissionType_PERMISSION_TYPE_DENY {
+					deny++
+				}
+			}
+			if deny != 1 {
+				t.Errorf("%s: %d DENY rows after the undo, want 1", name, deny)
+			}
+		}
+	})
+}
@@ -0,0 +1,184 @@
+package matrix
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+)
+
+// Deps holds dependencies for the matrix page and its save action.
+type Deps struct {
+	ListRoles            func(ctx context.Context, req *rolepb.ListRolesRequest) (*rolepb.ListRolesResponse, error)
+	GetRoleItemPageData  func(ctx context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error)
+	ListPermissions      func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
+	CreateRolePermission func(ctx context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error)
+	DeleteRolePermission func(ctx context.Context, req *rolepermissionpb.DeleteRolePermissionRequest) (*rolepermissionpb.DeleteRolePermissionResponse, error)
+	Routes               role.Routes
+	Labels               role.PermissionLabels
+	CommonLabels         pyeza.CommonLabels
+}
+
+// PageData holds the data for the matrix page.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Labels          role.PermissionMatrixLabels
+	SaveURL         string
+	CanEdit         bool
+	Roles           []Column
+	Groups          []Group
+	Saved           string
+	ErrorMessage    string
+}
+
+// Column is a role heading.
+type Column struct {
+	ID       string
+	Name     string
+	Color    string
+	Inactive bool
+}
+
+// Group is the permissions sharing an entity.
+type Group struct {
+	Entity string
+	Rows   []Row
+}
+
+// Row is a permission and its cell for each role.
+type Row struct {
+	ID       string
+	Code     string
+	Name     string
+	Inactive bool
+	Cells    []CellData
+}
+
+// CellData is one checkbox.
+type CellData struct {
+	Key      string
+	RoleID   string
+	Checked  bool
+	Deny     bool
+	Disabled bool
+}
+
+// NewView creates the matrix view (full page).
+func NewView(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("role", "list") {
+			return view.Forbidden("role:list")
+		}
+
+		l := deps.Labels.Matrix
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion:   viewCtx.CacheVersion,
+				Title:          l.Heading,
+				CurrentPath:    viewCtx.CurrentPath,
+				ActiveNav:      "user",
+				ActiveSubNav:   "role",
+				HeaderTitle:    l.Heading,
+				HeaderSubtitle: l.Caption,
+				HeaderIcon:     "icon-shield",
+				CommonLabels:   deps.CommonLabels,
+			},
+			ContentTemplate: "role-permission-matrix-content",
+			Labels:          l,
+			SaveURL:         deps.Routes.MatrixSaveURL,
+			CanEdit:         perms.Can("role", "update"),
+		}
+		if n, err := strconv.Atoi(viewCtx.Request.URL.Query().Get("saved")); err == nil && n > 0 {
+			pageData.Saved = fmt.Sprintf(l.Saved, n)
+		}
+
+		s, err := Load(ctx, deps)
+		if err != nil {
+			log.Printf("Failed to load role permission matrix: %v", err)
+			pageData.ErrorMessage = l.ErrorLoad
+			return view.OK("role-permission-matrix", pageData)
+		}
+		pageData.Roles, pageData.Groups = buildGrid(s, pageData.CanEdit)
+
+		return view.OK("role-permission-matrix", pageData)
+	})
+}
+
+// Load reads every role with its RolePermissions, and the permission
+// catalog.
+func Load(ctx context.Context, deps *Deps) (*State, error) {
+	rolesResp, err := deps.ListRoles(ctx, &rolepb.ListRolesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	roles := make([]*rolepb.Role, 0, len(rolesResp.GetData()))
+	for _, r := range rolesResp.GetData() {
+		resp, err := deps.GetRoleItemPageData(ctx, &rolepb.GetRoleItemPageDataRequest{RoleId: r.GetId()})
+		if err != nil {
+			return nil, fmt.Errorf("load role %s: %w", r.GetId(), err)
+		}
+		if resp.GetRole() != nil {
+			roles = append(roles, resp.GetRole())
+		}
+	}
+	permResp, err := deps.ListPermissions(ctx, &permissionpb.ListPermissionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list permissions: %w", err)
+	}
+	return NewState(roles, permResp.GetData()), nil
+}
+
+// buildGrid lays s out for the template. A cell is disabled when the
+// operator cannot edit, or when its permission is inactive and not yet
+// granted — an inactive permission can be revoked but not granted.
+func buildGrid(s *State, canEdit bool) ([]Column, []Group) {
+	columns := make([]Column, 0, len(s.Roles))
+	for _, r := range s.Roles {
+		columns = append(columns, Column{
+			ID:       r.GetId(),
+			Name:     r.GetName(),
+			Color:    r.GetColor(),
+			Inactive: !r.GetActive(),
+		})
+	}
+
+	var groups []Group
+	for _, p := range s.Permissions {
+		entity := Entity(p.GetPermissionCode())
+		if len(groups) == 0 || groups[len(groups)-1].Entity != entity {
+			groups = append(groups, Group{Entity: entity})
+		}
+		row := Row{
+			ID:       p.GetId(),
+			Code:     p.GetPermissionCode(),
+			Name:     p.GetName(),
+			Inactive: !p.GetActive(),
+		}
+		for _, r := range s.Roles {
+			c := Cell{RoleID: r.GetId(), PermissionID: p.GetId()}
+			checked := s.Granted(c)
+			row.Cells = append(row.Cells, CellData{
+				Key:      c.Key(),
+				RoleID:   r.GetId(),
+				Checked:  checked,
+				Deny:     s.Deny(c),
+				Disabled: !canEdit || (row.Inactive && !checked),
+			})
+		}
+		g := &groups[len(groups)-1]
+		g.Rows = append(g.Rows, row)
+	}
+	return columns, groups
+}
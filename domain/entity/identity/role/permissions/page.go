@@ -12,9 +12,8 @@ import (
 
 	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
 
-	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
-
 	"github.com/erniealice/entydad-golang"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
 	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
 )
 
@@ -145,12 +144,15 @@ func permissionColumns(l role.PermissionLabels) []types.TableColumn {
 		{Key: "permissionName", Label: l.Columns.PermissionName},
 		{Key: "code", Label: l.Columns.Code},
 		{Key: "type", Label: l.Columns.Type, WidthClass: "col-2xl"},
+		{Key: "status", Label: l.Columns.Status, WidthClass: "col-2xl"},
 		{Key: "dateAssigned", Label: l.Columns.DateAssigned, WidthClass: "col-6xl"},
 	}
 }
 
 func buildTableRows(role *rolepb.Role, l role.PermissionLabels, sl entydad.SharedLabels, routes role.Routes) []types.TableRow {
 	rows := []types.TableRow{}
+	set := effective.FromRoles(role)
+	grants := GrantsByRow(set)
 
 	for _, rp := range role.GetRolePermissions() {
 		perm := rp.GetPermission()
@@ -164,15 +166,13 @@ func buildTableRows(role *rolepb.Role, l role.PermissionLabels, sl entydad.Share
 		permType := sl.Badges.Allow
 		dateAssigned := rp.GetDateCreatedString()
 
-		// Permission type badge — from the embedded Permission object
-		pt := perm.GetPermissionType()
-		if pt == permissionpb.PermissionType_PERMISSION_TYPE_DENY {
-			permType = sl.Badges.Deny
-		}
+		// Permission type badge — the row's own type, else the permission's
 		typeVariant := "success"
-		if pt == permissionpb.PermissionType_PERMISSION_TYPE_DENY {
+		if effective.IsDeny(rp) {
+			permType = sl.Badges.Deny
 			typeVariant = "danger"
 		}
+		status, statusVariant := StatusBadge(set, grants[rpID], l)
 
 		actions := []types.TableAction{
 			{
@@ -190,15 +190,39 @@ func buildTableRows(role *rolepb.Role, l role.PermissionLabels, sl entydad.Share
 				{Type: "text", Value: permName},
 				{Type: "text", Value: permCode},
 				{Type: "badge", Value: permType, Variant: typeVariant},
+				{Type: "badge", Value: status, Variant: statusVariant},
 				{Type: "text", Value: dateAssigned},
 			},
 			DataAttrs: map[string]string{
 				"permissionName": permName,
 				"code":           permCode,
 				"type":           permType,
+				"status":         status,
 			},
 			Actions: actions,
 		})
 	}
 	return rows
 }
+
+// GrantsByRow indexes the grants of set by role_permission ID.
+func GrantsByRow(set *effective.Set) map[string]effective.Grant {
+	out := make(map[string]effective.Grant)
+	for _, g := range set.Grants() {
+		out[g.RolePermissionID] = g
+	}
+	return out
+}
+
+// StatusBadge returns the status cell of a role's permission row: granting,
+// blocked by a DENY elsewhere in the role, or ignored as inactive.
+func StatusBadge(set *effective.Set, g effective.Grant, l role.PermissionLabels) (label, variant string) {
+	switch {
+	case !g.Active():
+		return l.Status.Inactive, "default"
+	case set.Overridden(g):
+		return l.Status.Overridden, "warning"
+	default:
+		return l.Status.Effective, "success"
+	}
+}
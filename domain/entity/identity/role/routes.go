@@ -41,6 +41,19 @@ const (
 	DetailPermissionsTableURL  = "/action/role/detail/{id}/permissions/table"
 	DetailPermissionsAssignURL = "/action/role/detail/{id}/permissions/assign"
 	DetailPermissionsRemoveURL = "/action/role/detail/{id}/permissions/remove"
+
+	// Role × permission matrix
+	MatrixURL     = "/roles/permissions-matrix"
+	MatrixSaveURL = "/action/role/permissions-matrix/save"
+
+	// Role template library
+	TemplatesURL   = "/roles/templates"
+	TemplateUseURL = "/action/role/templates/use/{key}"
+
+	// Role setup export/import
+	TransferURL = "/roles/transfer"
+	ExportURL   = "/action/role/export"
+	ImportURL   = "/action/role/import"
 )
 
 // Routes holds all route paths for role management, including
@@ -79,6 +92,21 @@ type Routes struct {
 	DetailPermissionsTableURL  string `json:"detail_permissions_table_url"`
 	DetailPermissionsAssignURL string `json:"detail_permissions_assign_url"`
 	DetailPermissionsRemoveURL string `json:"detail_permissions_remove_url"`
+
+	// Role × permission matrix: the page and its review/apply action
+	MatrixURL     string `json:"matrix_url"`
+	MatrixSaveURL string `json:"matrix_save_url"`
+
+	// Role template library: the page and the drawer that makes a role
+	// from a template
+	TemplatesURL   string `json:"templates_url"`
+	TemplateUseURL string `json:"template_use_url"`
+
+	// Role setup export/import: the page, the document download, and the
+	// import's dry run/apply action
+	TransferURL string `json:"transfer_url"`
+	ExportURL   string `json:"export_url"`
+	ImportURL   string `json:"import_url"`
 }
 
 // DefaultRoutes returns a Routes populated from the package-level
@@ -117,6 +145,19 @@ func DefaultRoutes() Routes {
 		DetailPermissionsTableURL:  DetailPermissionsTableURL,
 		DetailPermissionsAssignURL: DetailPermissionsAssignURL,
 		DetailPermissionsRemoveURL: DetailPermissionsRemoveURL,
+
+		// Role × permission matrix
+		MatrixURL:     MatrixURL,
+		MatrixSaveURL: MatrixSaveURL,
+
+		// Role template library
+		TemplatesURL:   TemplatesURL,
+		TemplateUseURL: TemplateUseURL,
+
+		// Role setup export/import
+		TransferURL: TransferURL,
+		ExportURL:   ExportURL,
+		ImportURL:   ImportURL,
 	}
 }
 
@@ -155,5 +196,18 @@ func (r Routes) RouteMap() map[string]string {
 		"role.detail_permission.table":  r.DetailPermissionsTableURL,
 		"role.detail_permission.assign": r.DetailPermissionsAssignURL,
 		"role.detail_permission.remove": r.DetailPermissionsRemoveURL,
+
+		// Role × permission matrix
+		"role.matrix":      r.MatrixURL,
+		"role.matrix.save": r.MatrixSaveURL,
+
+		// Role template library
+		"role.template.list": r.TemplatesURL,
+		"role.template.use":  r.TemplateUseURL,
+
+		// Role setup export/import
+		"role.transfer":        r.TransferURL,
+		"role.transfer.export": r.ExportURL,
+		"role.transfer.import": r.ImportURL,
 	}
 }
@@ -0,0 +1,260 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/erniealice/pyeza-golang/view"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+)
+
+// maxDocumentSize caps an uploaded or pasted document.
+const maxDocumentSize = 1 << 20
+
+// NewExportHandler serves the workspace's role setup as a YAML or JSON
+// download. Handles GET /action/role/export?format=yaml|json
+func NewExportHandler(deps *Deps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if !view.GetUserPermissions(ctx).Can("role", "list") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = FormatYAML
+		}
+		ws, err := Load(ctx, deps)
+		if err != nil {
+			log.Printf("role export: %v", err)
+			http.Error(w, "failed to load roles", http.StatusInternalServerError)
+			return
+		}
+		data, err := Encode(Export(ws, time.Now()), format)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		contentType := "application/yaml"
+		if format == FormatJSON {
+			contentType = "application/json"
+		}
+		filename := fmt.Sprintf("roles-%s.%s", time.Now().Format("2006-01-02"), format)
+		w.Header().Set("Content-Type", contentType+"; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		_, _ = w.Write(data)
+	}
+}
+
+// ReviewData is the import's dry run, rendered into the page's review area.
+type ReviewData struct {
+	Labels      role.TransferLabels
+	ImportURL   string
+	WorkspaceID string // injected by ViewAdapter
+	Document    string // the document as submitted, posted back on confirm
+	Options     Options
+	Summary     string
+	Changes     []ChangeData
+	Conflicts   []ConflictData
+	Blocked     bool
+	CanApply    bool
+	Error       string
+}
+
+// ChangeData is one role's change, ready for the template.
+type ChangeData struct {
+	Action string
+	Label  string
+	Name   string
+	Fields string
+	Grant  []string
+	Revoke []string
+}
+
+// ConflictData is one conflict, ready for the template.
+type ConflictData struct {
+	Role     string
+	Message  string
+	Resolved bool
+}
+
+// NewImportAction creates the import action (POST only). Without confirm=1
+// it renders the dry run; with it, it re-plans against the current state
+// and applies the plan all or nothing, then reloads the page. Previewing
+// takes role:list; applying takes role:create and role:update, and
+// role:delete when roles are deleted.
+func NewImportAction(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("role", "list") {
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+		l := deps.Labels.Transfer
+		review := &ReviewData{Labels: l, ImportURL: deps.Routes.ImportURL}
+
+		r := viewCtx.Request
+		if err := r.ParseMultipartForm(maxDocumentSize); err != nil && !errors.Is(err, http.ErrNotMultipart) {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		text, err := readDocument(r)
+		if err != nil {
+			review.Error = fmt.Sprintf(l.ErrorParse, err)
+			return view.OK("role-transfer-review", review)
+		}
+		if strings.TrimSpace(text) == "" {
+			review.Error = l.ErrorEmpty
+			return view.OK("role-transfer-review", review)
+		}
+		doc, err := Decode([]byte(text))
+		if err != nil {
+			review.Error = fmt.Sprintf(l.ErrorParse, err)
+			return view.OK("role-transfer-review", review)
+		}
+		review.Document = text
+		review.Options = Options{
+			UpdateExisting: r.FormValue("update_existing") == "true",
+			RemoveMissing:  r.FormValue("remove_missing") == "true",
+			SkipUnknown:    r.FormValue("skip_unknown") == "true",
+		}
+
+		ws, err := Load(ctx, deps)
+		if err != nil {
+			log.Printf("role import: %v", err)
+			review.Error = l.ErrorLoad
+			return view.OK("role-transfer-review", review)
+		}
+		inUse := rolesInUse(ctx, deps, ws)
+		plan := NewPlan(doc, ws, review.Options, inUse)
+		fillReview(review, plan)
+		_, _, removes := plan.Counts()
+		review.CanApply = !review.Blocked && len(plan.Changes) > 0 &&
+			perms.Can("role", "create") && perms.Can("role", "update") &&
+			(removes == 0 || perms.Can("role", "delete"))
+
+		if r.FormValue("confirm") != "1" {
+			return view.OK("role-transfer-review", review)
+		}
+		if !review.CanApply {
+			if review.Blocked || len(plan.Changes) == 0 {
+				return view.OK("role-transfer-review", review)
+			}
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+
+		n, err := Apply(ctx, deps, plan)
+		if err != nil {
+			log.Printf("role import: %v", err)
+			var applyErr *ApplyError
+			if errors.As(err, &applyErr) && len(applyErr.RollbackErrs) > 0 {
+				review.Error = fmt.Sprintf(l.RollbackFailed, err)
+			} else {
+				review.Error = fmt.Sprintf(l.RolledBack, err)
+			}
+			review.CanApply = false
+			return view.OK("role-transfer-review", review)
+		}
+		return view.Redirect(fmt.Sprintf("%s?imported=%d", deps.Routes.TransferURL, n))
+	})
+}
+
+// readDocument returns the uploaded file's contents, or else the pasted
+// text.
+func readDocument(r *http.Request) (string, error) {
+	if r.MultipartForm != nil {
+		if files := r.MultipartForm.File["file"]; len(files) > 0 {
+			f, err := files[0].Open()
+			if err != nil {
+				return "", err
+			}
+			defer f.Close()
+			data, err := io.ReadAll(io.LimitReader(f, maxDocumentSize+1))
+			if err != nil {
+				return "", err
+			}
+			if len(data) > maxDocumentSize {
+				return "", fmt.Errorf("the file is larger than %d bytes", maxDocumentSize)
+			}
+			return string(data), nil
+		}
+	}
+	return r.FormValue("document"), nil
+}
+
+// rolesInUse asks which roles have users; nil when that is unknown, which
+// keeps every role the import would remove.
+func rolesInUse(ctx context.Context, deps *Deps, ws *Workspace) map[string]bool {
+	if deps.GetInUseIDs == nil {
+		return nil
+	}
+	ids := make([]string, 0, len(ws.Roles))
+	for _, r := range ws.Roles {
+		ids = append(ids, r.GetId())
+	}
+	inUse, err := deps.GetInUseIDs(ctx, ids)
+	if err != nil {
+		log.Printf("role import: failed to check roles in use: %v", err)
+		return nil
+	}
+	return inUse
+}
+
+// fillReview lays plan out for the template.
+func fillReview(review *ReviewData, plan *Plan) {
+	l := review.Labels
+	create, update, remove := plan.Counts()
+	review.Summary = fmt.Sprintf(l.Summary, create, update, remove)
+	review.Blocked = plan.Blocked()
+	for _, c := range plan.Changes {
+		cd := ChangeData{Action: c.Action, Name: c.Name, Fields: strings.ReplaceAll(strings.Join(c.Fields, ", "), "_", " ")}
+		switch c.Action {
+		case ActionCreate:
+			cd.Label = l.Create
+		case ActionUpdate:
+			cd.Label = l.Update
+		default:
+			cd.Label = l.Remove
+		}
+		for _, g := range c.Grant {
+			code := g.Permission.GetPermissionCode()
+			if g.Deny {
+				code += " (DENY)"
+			}
+			cd.Grant = append(cd.Grant, code)
+		}
+		if c.Action != ActionRemove {
+			for _, rp := range c.Revoke {
+				code := rp.GetPermission().GetPermissionCode()
+				if code == "" {
+					code = rp.GetPermissionId()
+				}
+				cd.Revoke = append(cd.Revoke, code)
+			}
+		}
+		review.Changes = append(review.Changes, cd)
+	}
+	for _, c := range plan.Conflicts {
+		var msg string
+		switch c.Kind {
+		case ConflictUnknownCode:
+			msg = fmt.Sprintf(l.UnknownCode, c.Code)
+		case ConflictInactiveCode:
+			msg = fmt.Sprintf(l.InactiveCode, c.Code)
+		case ConflictDuplicateRole:
+			msg = l.DuplicateRole
+		case ConflictExistingDiffers:
+			msg = l.ExistingDiffers
+		case ConflictRoleInUse:
+			msg = l.RoleInUse
+		case ConflictUsageUnknown:
+			msg = l.UsageUnknown
+		}
+		review.Conflicts = append(review.Conflicts, ConflictData{Role: c.Role, Message: msg, Resolved: c.Resolved})
+	}
+}
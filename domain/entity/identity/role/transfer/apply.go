@@ -0,0 +1,242 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+)
+
+// ApplyError is a failed import. Every step made before Step was undone,
+// except those listed in RollbackErrs.
+type ApplyError struct {
+	Step         string
+	Err          error
+	RollbackErrs []error
+}
+
+func (e *ApplyError) Error() string {
+	if len(e.RollbackErrs) > 0 {
+		return fmt.Sprintf("%s: %v (and %d steps could not be undone: %v)", e.Step, e.Err, len(e.RollbackErrs), errors.Join(e.RollbackErrs...))
+	}
+	return fmt.Sprintf("%s: %v", e.Step, e.Err)
+}
+
+func (e *ApplyError) Unwrap() error { return e.Err }
+
+// step is one use case call and the call that reverses it.
+type step struct {
+	name string
+	do   func(ctx context.Context) error
+	undo func(ctx context.Context) error
+}
+
+// Apply makes plan's changes through the role and role_permission use
+// cases. The use cases have no shared transaction, so Apply is atomic by
+// compensation: when a step fails, the steps already made are undone in
+// reverse order. A removed role that is restored comes back with a new ID.
+// It returns the number of steps made.
+func Apply(ctx context.Context, deps *Deps, plan *Plan) (int, error) {
+	var steps []step
+	for _, c := range plan.Changes {
+		steps = append(steps, changeSteps(deps, c)...)
+	}
+	for i, s := range steps {
+		if err := s.do(ctx); err != nil {
+			applyErr := &ApplyError{Step: s.name, Err: err}
+			for j := i - 1; j >= 0; j-- {
+				if err := steps[j].undo(ctx); err != nil {
+					applyErr.RollbackErrs = append(applyErr.RollbackErrs, fmt.Errorf("undo %s: %w", steps[j].name, err))
+				}
+			}
+			return 0, applyErr
+		}
+	}
+	return len(steps), nil
+}
+
+// changeSteps turns a change into steps. Revokes come before grants so a
+// link that changes from ALLOW to DENY never holds both.
+func changeSteps(deps *Deps, c Change) []step {
+	roleID := c.Current.GetId()
+	id := func() string { return roleID }
+	var steps []step
+
+	switch c.Action {
+	case ActionCreate:
+		steps = append(steps, step{
+			name: "create role " + c.Name,
+			do: func(ctx context.Context) error {
+				resp, err := deps.CreateRole(ctx, &rolepb.CreateRoleRequest{Data: cloneRole(c.Desired)})
+				if err != nil {
+					return err
+				}
+				if len(resp.GetData()) == 0 || resp.GetData()[0].GetId() == "" {
+					return fmt.Errorf("the role was created but its ID is unknown")
+				}
+				roleID = resp.GetData()[0].GetId()
+				return nil
+			},
+			undo: func(ctx context.Context) error {
+				_, err := deps.DeleteRole(ctx, &rolepb.DeleteRoleRequest{Data: &rolepb.Role{Id: roleID}})
+				return err
+			},
+		})
+	case ActionUpdate:
+		if hasField(c.Fields, "name", "description", "color", "principal_types") {
+			steps = append(steps, step{
+				name: "update role " + c.Name,
+				do: func(ctx context.Context) error {
+					_, err := deps.UpdateRole(ctx, &rolepb.UpdateRoleRequest{Data: cloneRole(c.Desired)})
+					return err
+				},
+				undo: func(ctx context.Context) error {
+					_, err := deps.UpdateRole(ctx, &rolepb.UpdateRoleRequest{Data: cloneRole(c.Current)})
+					return err
+				},
+			})
+		}
+		if hasField(c.Fields, "active") {
+			steps = append(steps, setActiveStep(deps, c.Name, roleID, c.Desired.GetActive()))
+		}
+	}
+
+	for _, rp := range c.Revoke {
+		steps = append(steps, revokeStep(deps, c.Name, rp, id))
+	}
+	for _, l := range c.Grant {
+		steps = append(steps, grantStep(deps, c.Name, l, id))
+	}
+
+	if c.Action == ActionRemove {
+		steps = append(steps, step{
+			name: "delete role " + c.Name,
+			do: func(ctx context.Context) error {
+				_, err := deps.DeleteRole(ctx, &rolepb.DeleteRoleRequest{Data: &rolepb.Role{Id: roleID}})
+				return err
+			},
+			undo: func(ctx context.Context) error {
+				restored := cloneRole(c.Current)
+				restored.Id = ""
+				resp, err := deps.CreateRole(ctx, &rolepb.CreateRoleRequest{Data: restored})
+				if err != nil {
+					return err
+				}
+				if len(resp.GetData()) == 0 || resp.GetData()[0].GetId() == "" {
+					return fmt.Errorf("the role was restored but its ID is unknown")
+				}
+				// The revoke steps undone next restore the links under the new ID.
+				roleID = resp.GetData()[0].GetId()
+				return nil
+			},
+		})
+	}
+	return steps
+}
+
+// setActiveStep flips a role's active flag. SetRoleActive is used when
+// wired because UpdateRole drops a false active flag.
+func setActiveStep(deps *Deps, name, roleID string, active bool) step {
+	set := func(ctx context.Context, v bool) error {
+		if deps.SetRoleActive != nil {
+			return deps.SetRoleActive(ctx, roleID, v)
+		}
+		_, err := deps.UpdateRole(ctx, &rolepb.UpdateRoleRequest{Data: &rolepb.Role{Id: roleID, Active: v}})
+		return err
+	}
+	return step{
+		name: "set role " + name + " active",
+		do:   func(ctx context.Context) error { return set(ctx, active) },
+		undo: func(ctx context.Context) error { return set(ctx, !active) },
+	}
+}
+
+func grantStep(deps *Deps, name string, l Link, roleID func() string) step {
+	var rowID string
+	permType := permissionpb.PermissionType_PERMISSION_TYPE_ALLOW
+	if l.Deny {
+		permType = permissionpb.PermissionType_PERMISSION_TYPE_DENY
+	}
+	return step{
+		name: "grant " + l.Permission.GetPermissionCode() + " to " + name,
+		do: func(ctx context.Context) error {
+			resp, err := deps.CreateRolePermission(ctx, &rolepermissionpb.CreateRolePermissionRequest{
+				Data: &rolepermissionpb.RolePermission{
+					RoleId:         roleID(),
+					PermissionId:   l.Permission.GetId(),
+					PermissionType: permType,
+					Active:         true,
+				},
+			})
+			if err != nil {
+				return err
+			}
+			if len(resp.GetData()) > 0 {
+				rowID = resp.GetData()[0].GetId()
+			}
+			return nil
+		},
+		undo: func(ctx context.Context) error {
+			if rowID == "" {
+				return fmt.Errorf("the created row's ID is unknown")
+			}
+			_, err := deps.DeleteRolePermission(ctx, &rolepermissionpb.DeleteRolePermissionRequest{
+				Data: &rolepermissionpb.RolePermission{Id: rowID},
+			})
+			return err
+		},
+	}
+}
+
+func revokeStep(deps *Deps, name string, rp *rolepermissionpb.RolePermission, roleID func() string) step {
+	code := rp.GetPermission().GetPermissionCode()
+	if code == "" {
+		code = rp.GetPermissionId()
+	}
+	return step{
+		name: "revoke " + code + " from " + name,
+		do: func(ctx context.Context) error {
+			_, err := deps.DeleteRolePermission(ctx, &rolepermissionpb.DeleteRolePermissionRequest{
+				Data: &rolepermissionpb.RolePermission{Id: rp.GetId()},
+			})
+			return err
+		},
+		undo: func(ctx context.Context) error {
+			_, err := deps.CreateRolePermission(ctx, &rolepermissionpb.CreateRolePermissionRequest{
+				Data: &rolepermissionpb.RolePermission{
+					RoleId:         roleID(),
+					PermissionId:   rp.GetPermissionId(),
+					PermissionType: rp.GetPermissionType(),
+					Active:         rp.GetActive(),
+				},
+			})
+			return err
+		},
+	}
+}
+
+// cloneRole copies the role fields an import writes.
+func cloneRole(r *rolepb.Role) *rolepb.Role {
+	return &rolepb.Role{
+		Id:                       r.GetId(),
+		Name:                     r.GetName(),
+		Description:              r.GetDescription(),
+		Color:                    r.GetColor(),
+		Active:                   r.GetActive(),
+		ApplicablePrincipalTypes: r.GetApplicablePrincipalTypes(),
+	}
+}
+
+func hasField(fields []string, names ...string) bool {
+	for _, f := range fields {
+		for _, n := range names {
+			if f == n {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,85 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+)
+
+// Deps holds dependencies for the export/import page, the export download
+// and the import action: the role and role_permission use cases.
+type Deps struct {
+	ListRoles            func(ctx context.Context, req *rolepb.ListRolesRequest) (*rolepb.ListRolesResponse, error)
+	GetRoleItemPageData  func(ctx context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error)
+	ListPermissions      func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
+	CreateRole           func(ctx context.Context, req *rolepb.CreateRoleRequest) (*rolepb.CreateRoleResponse, error)
+	UpdateRole           func(ctx context.Context, req *rolepb.UpdateRoleRequest) (*rolepb.UpdateRoleResponse, error)
+	DeleteRole           func(ctx context.Context, req *rolepb.DeleteRoleRequest) (*rolepb.DeleteRoleResponse, error)
+	SetRoleActive        func(ctx context.Context, id string, active bool) error
+	CreateRolePermission func(ctx context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error)
+	DeleteRolePermission func(ctx context.Context, req *rolepermissionpb.DeleteRolePermissionRequest) (*rolepermissionpb.DeleteRolePermissionResponse, error)
+	// GetInUseIDs reports which roles have users, so an import does not
+	// delete them. Optional.
+	GetInUseIDs  func(ctx context.Context, ids []string) (map[string]bool, error)
+	Routes       role.Routes
+	Labels       role.Labels
+	CommonLabels pyeza.CommonLabels
+}
+
+// PageData holds the data for the export/import page.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Labels          role.TransferLabels
+	ExportYAMLURL   string
+	ExportJSONURL   string
+	ImportURL       string
+	CanImport       bool
+	Imported        string
+}
+
+// NewView creates the export/import view (full page).
+func NewView(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("role", "list") {
+			return view.Forbidden("role:list")
+		}
+
+		l := deps.Labels.Transfer
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion:   viewCtx.CacheVersion,
+				Title:          l.Heading,
+				CurrentPath:    viewCtx.CurrentPath,
+				ActiveNav:      "user",
+				ActiveSubNav:   "role",
+				HeaderTitle:    l.Heading,
+				HeaderSubtitle: l.Caption,
+				HeaderIcon:     "icon-shield",
+				CommonLabels:   deps.CommonLabels,
+			},
+			ContentTemplate: "role-transfer-content",
+			Labels:          l,
+			ExportYAMLURL:   deps.Routes.ExportURL + "?format=" + FormatYAML,
+			ExportJSONURL:   deps.Routes.ExportURL + "?format=" + FormatJSON,
+			ImportURL:       deps.Routes.ImportURL,
+			CanImport:       perms.Can("role", "create") && perms.Can("role", "update"),
+		}
+		if n, err := strconv.Atoi(viewCtx.Request.URL.Query().Get("imported")); err == nil && n > 0 {
+			pageData.Imported = fmt.Sprintf(l.Imported, n)
+		}
+
+		return view.OK("role-transfer", pageData)
+	})
+}
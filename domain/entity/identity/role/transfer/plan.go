@@ -0,0 +1,266 @@
+package transfer
+
+import (
+	"slices"
+	"strings"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/role/library"
+)
+
+// Options settle how an import treats what the workspace already has.
+type Options struct {
+	UpdateExisting bool // bring roles the workspace already has in line with the document
+	RemoveMissing  bool // delete roles the document does not list
+	SkipUnknown    bool // drop links to codes the workspace lacks or has inactive, instead of refusing
+}
+
+// Change actions.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionRemove = "remove"
+)
+
+// Conflict kinds.
+const (
+	ConflictUnknownCode     = "unknown_code"     // the workspace has no permission with the code
+	ConflictInactiveCode    = "inactive_code"    // the workspace's permission is inactive
+	ConflictDuplicateRole   = "duplicate_role"   // several workspace roles have the name
+	ConflictExistingDiffers = "existing_differs" // the role exists and differs, and UpdateExisting is off
+	ConflictRoleInUse       = "role_in_use"      // a role to remove still has users, so it is kept
+	ConflictUsageUnknown    = "usage_unknown"    // whether a role to remove has users is unknown, so it is kept
+)
+
+// Conflict is something the import cannot do as the document says. A
+// resolved conflict is skipped under the chosen Options; an unresolved one
+// blocks the import.
+type Conflict struct {
+	Role     string
+	Code     string
+	Kind     string
+	Resolved bool
+}
+
+// Link is a permission a role is to grant or deny.
+type Link struct {
+	Permission *permissionpb.Permission
+	Deny       bool
+}
+
+// Change is what the import does to one role. Current is the workspace's
+// role (nil for a create) and Desired the role fields to write (nil for a
+// remove). Fields lists the fields an update changes.
+type Change struct {
+	Action  string
+	Name    string
+	Current *rolepb.Role
+	Desired *rolepb.Role
+	Fields  []string
+	Grant   []Link
+	Revoke  []*rolepermissionpb.RolePermission
+}
+
+// Plan is an import's dry run: the changes in the order Apply makes them
+// (creates, updates, removes), and the conflicts.
+type Plan struct {
+	Changes   []Change
+	Conflicts []Conflict
+}
+
+// Blocked reports whether an unresolved conflict stops the import.
+func (p *Plan) Blocked() bool {
+	return slices.ContainsFunc(p.Conflicts, func(c Conflict) bool { return !c.Resolved })
+}
+
+// Counts returns the number of roles to create, update and remove.
+func (p *Plan) Counts() (create, update, remove int) {
+	for _, c := range p.Changes {
+		switch c.Action {
+		case ActionCreate:
+			create++
+		case ActionUpdate:
+			update++
+		case ActionRemove:
+			remove++
+		}
+	}
+	return create, update, remove
+}
+
+// linkKey identifies a link regardless of the row holding it.
+type linkKey struct {
+	permissionID string
+	deny         bool
+}
+
+// NewPlan compares doc with ws. Roles are matched by name, ignoring case,
+// and permissions by code. inUse holds the IDs of roles that have users;
+// nil means unknown, and then no role is removed.
+func NewPlan(doc *Document, ws *Workspace, opts Options, inUse map[string]bool) *Plan {
+	plan := &Plan{}
+	byCode := make(map[string]*permissionpb.Permission, len(ws.Permissions))
+	for _, p := range ws.Permissions {
+		if cur, ok := byCode[p.GetPermissionCode()]; !ok || (!cur.GetActive() && p.GetActive()) {
+			byCode[p.GetPermissionCode()] = p
+		}
+	}
+	byName := make(map[string][]*rolepb.Role, len(ws.Roles))
+	for _, r := range ws.Roles {
+		key := strings.ToLower(strings.TrimSpace(r.GetName()))
+		byName[key] = append(byName[key], r)
+	}
+
+	var creates, updates []Change
+	listed := make(map[string]bool, len(doc.Roles))
+	for _, dr := range doc.Roles {
+		key := strings.ToLower(dr.Name)
+		listed[key] = true
+		matches := byName[key]
+		if len(matches) > 1 {
+			plan.Conflicts = append(plan.Conflicts, Conflict{Role: dr.Name, Kind: ConflictDuplicateRole, Resolved: !opts.UpdateExisting})
+			continue
+		}
+		var cur *rolepb.Role
+		if len(matches) == 1 {
+			cur = matches[0]
+		}
+
+		held := make(map[linkKey]bool)
+		for _, rp := range cur.GetRolePermissions() {
+			if rp.GetActive() {
+				held[linkKey{rp.GetPermissionId(), effective.IsDeny(rp)}] = true
+			}
+		}
+		var want []Link
+		var conflicts []Conflict
+		addLinks := func(codes []string, deny bool) {
+			for _, code := range codes {
+				p, ok := byCode[code]
+				switch {
+				case !ok:
+					conflicts = append(conflicts, Conflict{Role: dr.Name, Code: code, Kind: ConflictUnknownCode, Resolved: opts.SkipUnknown})
+				case !p.GetActive() && !held[linkKey{p.GetId(), deny}]:
+					// An inactive permission can stay granted but not be newly granted.
+					conflicts = append(conflicts, Conflict{Role: dr.Name, Code: code, Kind: ConflictInactiveCode, Resolved: opts.SkipUnknown})
+				default:
+					want = append(want, Link{Permission: p, Deny: deny})
+				}
+			}
+		}
+		addLinks(dr.Allow, false)
+		addLinks(dr.Deny, true)
+
+		types, _ := library.ParsePrincipalTypes(dr.PrincipalTypes) // checked by Decode
+		desired := &rolepb.Role{
+			Name:                     dr.Name,
+			Description:              dr.Description,
+			Color:                    dr.Color,
+			Active:                   dr.IsActive(),
+			ApplicablePrincipalTypes: types,
+		}
+		if cur == nil {
+			plan.Conflicts = append(plan.Conflicts, conflicts...)
+			grant, _ := diffLinks(nil, want)
+			creates = append(creates, Change{Action: ActionCreate, Name: dr.Name, Desired: desired, Grant: grant})
+			continue
+		}
+
+		grant, revoke := diffLinks(cur.GetRolePermissions(), want)
+		fields := diffFields(cur, desired)
+		if len(fields) == 0 && len(grant) == 0 && len(revoke) == 0 && len(conflicts) == 0 {
+			continue
+		}
+		if !opts.UpdateExisting {
+			// The role is left as it is, so its link conflicts do not arise.
+			if len(fields) > 0 || len(grant) > 0 || len(revoke) > 0 {
+				plan.Conflicts = append(plan.Conflicts, Conflict{Role: dr.Name, Kind: ConflictExistingDiffers, Resolved: true})
+			}
+			continue
+		}
+		plan.Conflicts = append(plan.Conflicts, conflicts...)
+		if len(fields) == 0 && len(grant) == 0 && len(revoke) == 0 {
+			continue
+		}
+		desired.Id = cur.GetId()
+		updates = append(updates, Change{Action: ActionUpdate, Name: dr.Name, Current: cur, Desired: desired, Fields: fields, Grant: grant, Revoke: revoke})
+	}
+
+	var removes []Change
+	if opts.RemoveMissing {
+		for _, r := range ws.Roles {
+			if listed[strings.ToLower(strings.TrimSpace(r.GetName()))] {
+				continue
+			}
+			if inUse == nil {
+				plan.Conflicts = append(plan.Conflicts, Conflict{Role: r.GetName(), Kind: ConflictUsageUnknown, Resolved: true})
+				continue
+			}
+			if inUse[r.GetId()] {
+				plan.Conflicts = append(plan.Conflicts, Conflict{Role: r.GetName(), Kind: ConflictRoleInUse, Resolved: true})
+				continue
+			}
+			removes = append(removes, Change{Action: ActionRemove, Name: r.GetName(), Current: r, Revoke: r.GetRolePermissions()})
+		}
+	}
+
+	plan.Changes = slices.Concat(creates, updates, removes)
+	return plan
+}
+
+// diffLinks returns the links of want the rows lack, and the rows to
+// delete: inactive rows, duplicates, and links want does not hold.
+func diffLinks(rows []*rolepermissionpb.RolePermission, want []Link) ([]Link, []*rolepermissionpb.RolePermission) {
+	wanted := make(map[linkKey]bool, len(want))
+	for _, l := range want {
+		wanted[linkKey{l.Permission.GetId(), l.Deny}] = true
+	}
+	kept := make(map[linkKey]bool, len(rows))
+	var revoke []*rolepermissionpb.RolePermission
+	for _, rp := range rows {
+		k := linkKey{rp.GetPermissionId(), effective.IsDeny(rp)}
+		if rp.GetActive() && wanted[k] && !kept[k] {
+			kept[k] = true
+			continue
+		}
+		revoke = append(revoke, rp)
+	}
+	var grant []Link
+	for _, l := range want {
+		k := linkKey{l.Permission.GetId(), l.Deny}
+		if !kept[k] {
+			kept[k] = true
+			grant = append(grant, l)
+		}
+	}
+	return grant, revoke
+}
+
+// diffFields names the role fields that differ between cur and desired.
+func diffFields(cur, desired *rolepb.Role) []string {
+	var fields []string
+	if cur.GetName() != desired.GetName() {
+		fields = append(fields, "name")
+	}
+	if cur.GetDescription() != desired.GetDescription() {
+		fields = append(fields, "description")
+	}
+	if cur.GetColor() != desired.GetColor() {
+		fields = append(fields, "color")
+	}
+	if cur.GetActive() != desired.GetActive() {
+		fields = append(fields, "active")
+	}
+	a := slices.Clone(cur.GetApplicablePrincipalTypes())
+	b := slices.Clone(desired.GetApplicablePrincipalTypes())
+	slices.Sort(a)
+	slices.Sort(b)
+	if !slices.Equal(a, b) {
+		fields = append(fields, "principal_types")
+	}
+	return fields
+}
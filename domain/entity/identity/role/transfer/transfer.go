@@ -0,0 +1,225 @@
+// Package transfer moves a workspace's role setup — its roles, the
+// permission catalog and the role_permission links — to another workspace
+// as a portable YAML or JSON document. The document names permissions by
+// code and roles by name, never by ID, so it applies to any workspace with
+// the same permission codes. Importing plans a diff first (NewPlan) and
+// applies it all or nothing (Apply).
+package transfer
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/goccy/go-yaml"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/role/library"
+)
+
+// Version is the document version this package writes and reads.
+const Version = 1
+
+// Document formats.
+const (
+	FormatYAML = "yaml"
+	FormatJSON = "json"
+)
+
+// Document is a portable role setup. The json tags serve both formats.
+type Document struct {
+	Version     int          `json:"version"`
+	ExportedAt  string       `json:"exported_at,omitempty"`
+	Permissions []Permission `json:"permissions,omitempty"`
+	Roles       []Role       `json:"roles"`
+}
+
+// Permission is a catalog entry, for reference: an import matches codes
+// against the target workspace's catalog and never creates permissions.
+type Permission struct {
+	Code        string `json:"code"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Role is a role and its active permission links, by code.
+type Role struct {
+	Name           string   `json:"name"`
+	Description    string   `json:"description,omitempty"`
+	Color          string   `json:"color,omitempty"`
+	Active         *bool    `json:"active,omitempty"` // omitted means active
+	PrincipalTypes []string `json:"principal_types,omitempty"`
+	Allow          []string `json:"allow,omitempty"`
+	Deny           []string `json:"deny,omitempty"`
+}
+
+// IsActive reports whether the role is to be active.
+func (r Role) IsActive() bool {
+	return r.Active == nil || *r.Active
+}
+
+// Workspace is a workspace's role setup as loaded through the use cases.
+type Workspace struct {
+	Roles       []*rolepb.Role // with their RolePermissions
+	Permissions []*permissionpb.Permission
+}
+
+// Load reads every role with its RolePermissions, and the permission
+// catalog.
+func Load(ctx context.Context, deps *Deps) (*Workspace, error) {
+	rolesResp, err := deps.ListRoles(ctx, &rolepb.ListRolesRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list roles: %w", err)
+	}
+	ws := &Workspace{}
+	for _, r := range rolesResp.GetData() {
+		resp, err := deps.GetRoleItemPageData(ctx, &rolepb.GetRoleItemPageDataRequest{RoleId: r.GetId()})
+		if err != nil {
+			return nil, fmt.Errorf("load role %s: %w", r.GetId(), err)
+		}
+		loaded := resp.GetRole()
+		if loaded == nil {
+			continue
+		}
+		// The item page data carries the links; the list carries the fields.
+		full := &rolepb.Role{
+			Id:                       r.GetId(),
+			Name:                     r.GetName(),
+			Description:              r.GetDescription(),
+			Color:                    r.GetColor(),
+			Active:                   r.GetActive(),
+			ApplicablePrincipalTypes: r.GetApplicablePrincipalTypes(),
+			RolePermissions:          loaded.GetRolePermissions(),
+		}
+		ws.Roles = append(ws.Roles, full)
+	}
+	permResp, err := deps.ListPermissions(ctx, &permissionpb.ListPermissionsRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("list permissions: %w", err)
+	}
+	ws.Permissions = permResp.GetData()
+	return ws, nil
+}
+
+// Export builds the document for ws. Only active role_permission rows are
+// exported; roles and permissions are in name and code order.
+func Export(ws *Workspace, now time.Time) *Document {
+	doc := &Document{Version: Version, ExportedAt: now.UTC().Format(time.RFC3339)}
+	byID := make(map[string]*permissionpb.Permission, len(ws.Permissions))
+	for _, p := range ws.Permissions {
+		byID[p.GetId()] = p
+		doc.Permissions = append(doc.Permissions, Permission{
+			Code:        p.GetPermissionCode(),
+			Name:        p.GetName(),
+			Description: p.GetDescription(),
+		})
+	}
+	slices.SortFunc(doc.Permissions, func(a, b Permission) int { return strings.Compare(a.Code, b.Code) })
+	doc.Permissions = slices.CompactFunc(doc.Permissions, func(a, b Permission) bool { return a.Code == b.Code })
+
+	for _, r := range ws.Roles {
+		active := r.GetActive()
+		out := Role{
+			Name:           r.GetName(),
+			Description:    r.GetDescription(),
+			Color:          r.GetColor(),
+			Active:         &active,
+			PrincipalTypes: library.PrincipalTypeNames(r.GetApplicablePrincipalTypes()),
+		}
+		for _, rp := range r.GetRolePermissions() {
+			if !rp.GetActive() {
+				continue
+			}
+			code := rp.GetPermission().GetPermissionCode()
+			if code == "" {
+				code = byID[rp.GetPermissionId()].GetPermissionCode()
+			}
+			if code == "" {
+				continue
+			}
+			if effective.IsDeny(rp) {
+				out.Deny = append(out.Deny, code)
+			} else {
+				out.Allow = append(out.Allow, code)
+			}
+		}
+		slices.Sort(out.Allow)
+		slices.Sort(out.Deny)
+		out.Allow = slices.Compact(out.Allow)
+		out.Deny = slices.Compact(out.Deny)
+		doc.Roles = append(doc.Roles, out)
+	}
+	slices.SortFunc(doc.Roles, func(a, b Role) int { return strings.Compare(a.Name, b.Name) })
+	return doc
+}
+
+// Encode writes doc in format (FormatYAML or FormatJSON).
+func Encode(doc *Document, format string) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(doc, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(doc)
+	default:
+		return nil, fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// Decode reads a YAML or JSON document (JSON is read as YAML) and checks
+// it: a supported version, unique non-empty role names, valid codes and
+// principal types, and no code both allowed and denied by one role.
+func Decode(data []byte) (*Document, error) {
+	var doc Document
+	if err := yaml.UnmarshalWithOptions(data, &doc, yaml.Strict()); err != nil {
+		return nil, err
+	}
+	if doc.Version == 0 {
+		return nil, fmt.Errorf("version must be set")
+	}
+	if doc.Version > Version {
+		return nil, fmt.Errorf("version %d is newer than this app reads (%d)", doc.Version, Version)
+	}
+	for _, p := range doc.Permissions {
+		if !effective.ValidCode(p.Code) {
+			return nil, fmt.Errorf("permission: invalid code %q", p.Code)
+		}
+	}
+	seen := make(map[string]bool, len(doc.Roles))
+	for i := range doc.Roles {
+		r := &doc.Roles[i]
+		r.Name = strings.TrimSpace(r.Name)
+		if r.Name == "" {
+			return nil, fmt.Errorf("role %d: name is required", i+1)
+		}
+		key := strings.ToLower(r.Name)
+		if seen[key] {
+			return nil, fmt.Errorf("role %q is listed twice", r.Name)
+		}
+		seen[key] = true
+		if _, err := library.ParsePrincipalTypes(r.PrincipalTypes); err != nil {
+			return nil, fmt.Errorf("role %q: %w", r.Name, err)
+		}
+		allowed := make(map[string]bool, len(r.Allow))
+		for _, code := range r.Allow {
+			if !effective.ValidCode(code) {
+				return nil, fmt.Errorf("role %q: invalid code %q", r.Name, code)
+			}
+			allowed[code] = true
+		}
+		for _, code := range r.Deny {
+			if !effective.ValidCode(code) {
+				return nil, fmt.Errorf("role %q: invalid code %q", r.Name, code)
+			}
+			if allowed[code] {
+				return nil, fmt.Errorf("role %q: %s is both allowed and denied", r.Name, code)
+			}
+		}
+	}
+	return &doc, nil
+}
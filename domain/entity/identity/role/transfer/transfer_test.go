@@ -0,0 +1,602 @@
+package transfer
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	principaltypepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/principal_type"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+	rolepermissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role_permission"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
+)
+
+// fakeStore is the role and role_permission tables behind the deps
+// closures.
+type fakeStore struct {
+	roles      []*rolepb.Role
+	perms      []*permissionpb.Permission
+	rows       []*rolepermissionpb.RolePermission
+	nextID     int
+	failDelete string // role ID whose delete fails
+}
+
+func (f *fakeStore) newID(prefix string) string {
+	f.nextID++
+	return prefix + strconv.Itoa(f.nextID)
+}
+
+func (f *fakeStore) role(id string) *rolepb.Role {
+	for _, r := range f.roles {
+		if r.GetId() == id {
+			return r
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) deps() *Deps {
+	return &Deps{
+		ListRoles: func(context.Context, *rolepb.ListRolesRequest) (*rolepb.ListRolesResponse, error) {
+			return &rolepb.ListRolesResponse{Data: f.roles}, nil
+		},
+		GetRoleItemPageData: func(_ context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error) {
+			r := f.role(req.GetRoleId())
+			if r == nil {
+				return nil, errors.New("role not found")
+			}
+			loaded := &rolepb.Role{Id: r.GetId(), Name: r.GetName()}
+			for _, rp := range f.rows {
+				if rp.GetRoleId() == r.GetId() {
+					loaded.RolePermissions = append(loaded.RolePermissions, rp)
+				}
+			}
+			return &rolepb.GetRoleItemPageDataResponse{Role: loaded}, nil
+		},
+		ListPermissions: func(context.Context, *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error) {
+			return &permissionpb.ListPermissionsResponse{Data: f.perms}, nil
+		},
+		CreateRole: func(_ context.Context, req *rolepb.CreateRoleRequest) (*rolepb.CreateRoleResponse, error) {
+			r := req.GetData()
+			r.Id = f.newID("role-")
+			f.roles = append(f.roles, r)
+			return &rolepb.CreateRoleResponse{Data: []*rolepb.Role{r}, Success: true}, nil
+		},
+		UpdateRole: func(_ context.Context, req *rolepb.UpdateRoleRequest) (*rolepb.UpdateRoleResponse, error) {
+			r := f.role(req.GetData().GetId())
+			if r == nil {
+				return nil, errors.New("role not found")
+			}
+			r.Name = req.GetData().GetName()
+			r.Description = req.GetData().GetDescription()
+			r.Color = req.GetData().GetColor()
+			r.ApplicablePrincipalTypes = req.GetData().GetApplicablePrincipalTypes()
+			return &rolepb.UpdateRoleResponse{Success: true}, nil
+		},
+		DeleteRole: func(_ context.Context, req *rolepb.DeleteRoleRequest) (*rolepb.DeleteRoleResponse, error) {
+			if req.GetData().GetId() == f.failDelete {
+				return nil, errors.New("delete failed")
+			}
+			f.roles = slices.DeleteFunc(f.roles, func(r *rolepb.Role) bool { return r.GetId() == req.GetData().GetId() })
+			return &rolepb.DeleteRoleResponse{Success: true}, nil
+		},
+		SetRoleActive: func(_ context.Context, id string, active bool) error {
+			r := f.role(id)
+			if r == nil {
+				return errors.New("role not found")
+			}
+			r.Active = active
+			return nil
+		},
+		CreateRolePermission: func(_ context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error) {
+			rp := req.GetData()
+			rp.Id = f.newID("rp-")
+			f.rows = append(f.rows, rp)
+			return &rolepermissionpb.CreateRolePermissionResponse{Data: []*rolepermissionpb.RolePermission{rp}, Success: true}, nil
+		},
+		DeleteRolePermission: func(_ context.Context, req *rolepermissionpb.DeleteRolePermissionRequest) (*rolepermissionpb.DeleteRolePermissionResponse, error) {
+			f.rows = slices.DeleteFunc(f.rows, func(rp *rolepermissionpb.RolePermission) bool {
+				return rp.GetId() == req.GetData().GetId()
+			})
+			return &rolepermissionpb.DeleteRolePermissionResponse{Success: true}, nil
+		},
+		GetInUseIDs: func(context.Context, []string) (map[string]bool, error) {
+			return map[string]bool{"auditor": true}, nil
+		},
+		Routes: role.DefaultRoutes(),
+		Labels: role.Labels{Transfer: role.DefaultTransferLabels()},
+	}
+}
+
+// state describes the store by role name and permission ID, so it compares
+// equal across a role that was deleted and restored under a new ID.
+func (f *fakeStore) state() []string {
+	var out []string
+	for _, r := range f.roles {
+		out = append(out, r.GetName()+" active="+strconv.FormatBool(r.GetActive()))
+		for _, rp := range f.rows {
+			if rp.GetRoleId() == r.GetId() {
+				out = append(out, r.GetName()+"|"+rp.GetPermissionId()+"|"+rp.GetPermissionType().String())
+			}
+		}
+	}
+	slices.Sort(out)
+	return out
+}
+
+// catalog returns the permissions every test workspace has, under IDs
+// prefixed with prefix.
+func catalog(prefix string) []*permissionpb.Permission {
+	return []*permissionpb.Permission{
+		{Id: prefix + "read", PermissionCode: "client:read", Name: "Read clients", Active: true},
+		{Id: prefix + "delete", PermissionCode: "client:delete", Active: true},
+		{Id: prefix + "impersonate", PermissionCode: "user:impersonate", Active: true},
+		{Id: prefix + "old", PermissionCode: "legacy:run", Active: false},
+	}
+}
+
+func newStore() *fakeStore {
+	perms := catalog("p-")
+	deny := permissionpb.PermissionType_PERMISSION_TYPE_DENY
+	return &fakeStore{
+		roles: []*rolepb.Role{
+			{Id: "sales", Name: "Sales", Color: "blue", Active: true, ApplicablePrincipalTypes: []principaltypepb.PrincipalType{principaltypepb.PrincipalType_PRINCIPAL_TYPE_OPERATOR_STAFF}},
+			{Id: "auditor", Name: "Auditor", Active: true},
+			{Id: "legacy", Name: "Legacy", Active: false},
+		},
+		perms: perms,
+		rows: []*rolepermissionpb.RolePermission{
+			{Id: "rp-a", RoleId: "sales", PermissionId: "p-read", Permission: perms[0], Active: true},
+			{Id: "rp-b", RoleId: "sales", PermissionId: "p-impersonate", Permission: perms[2], Active: true, PermissionType: deny},
+			// An inactive row is not part of the setup.
+			{Id: "rp-c", RoleId: "sales", PermissionId: "p-delete", Permission: perms[1], Active: false},
+			{Id: "rp-d", RoleId: "auditor", PermissionId: "p-read", Permission: perms[0], Active: true},
+			{Id: "rp-e", RoleId: "legacy", PermissionId: "p-read", Permission: perms[0], Active: true},
+		},
+	}
+}
+
+func TestExportDecode(t *testing.T) {
+	t.Parallel()
+
+	f := newStore()
+	ws, err := Load(context.Background(), f.deps())
+	if err != nil {
+		t.Fatal(err)
+	}
+	doc := Export(ws, time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC))
+
+	if got := []string{doc.Roles[0].Name, doc.Roles[1].Name, doc.Roles[2].Name}; !slices.Equal(got, []string{"Auditor", "Legacy", "Sales"}) {
+		t.Fatalf("roles = %v, want name order", got)
+	}
+	sales := doc.Roles[2]
+	if !slices.Equal(sales.Allow, []string{"client:read"}) || !slices.Equal(sales.Deny, []string{"user:impersonate"}) {
+		t.Errorf("Sales allow = %v, deny = %v (inactive rows are not exported)", sales.Allow, sales.Deny)
+	}
+	if !slices.Equal(sales.PrincipalTypes, []string{"operator_staff"}) || sales.Color != "blue" {
+		t.Errorf("Sales = %+v", sales)
+	}
+	if doc.Roles[1].IsActive() {
+		t.Error("Legacy exported as active")
+	}
+
+	for _, format := range []string{FormatYAML, FormatJSON} {
+		data, err := Encode(doc, format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if strings.Contains(string(data), "p-read") {
+			t.Errorf("%s document holds a permission ID:\n%s", format, data)
+		}
+		got, err := Decode(data)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(got, doc) {
+			t.Errorf("%s round trip = %+v, want %+v", format, got, doc)
+		}
+	}
+	if _, err := Encode(doc, "xml"); err == nil {
+		t.Error("Encode accepted an unknown format")
+	}
+}
+
+func TestDecode(t *testing.T) {
+	t.Parallel()
+
+	if doc, err := Decode([]byte(`{"version": 1, "roles": [{"name": " Sales ", "allow": ["client:read"]}]}`)); err != nil || doc.Roles[0].Name != "Sales" {
+		t.Errorf("JSON document = %+v, %v", doc, err)
+	}
+	for name, data := range map[string]string{
+		"no version":         "roles: []",
+		"newer version":      "version: 2\nroles: []",
+		"unknown field":      "version: 1\nrole: []",
+		"no name":            "version: 1\nroles:\n  - allow: [client:read]",
+		"duplicate name":     "version: 1\nroles:\n  - name: Sales\n  - name: sales",
+		"invalid code":       "version: 1\nroles:\n  - name: Sales\n    allow: [client]",
+		"allowed and denied": "version: 1\nroles:\n  - name: Sales\n    allow: [client:read]\n    deny: [client:read]",
+		"principal type":     "version: 1\nroles:\n  - name: Sales\n    principal_types: [robot]",
+		"catalog code":       "version: 1\npermissions:\n  - code: nope\nroles: []",
+	} {
+		if _, err := Decode([]byte(data)); err == nil {
+			t.Errorf("%s: Decode accepted %q", name, data)
+		}
+	}
+}
+
+// planDoc grants Sales client:delete instead of its DENY on
+// user:impersonate, and adds Support with a code the workspace lacks.
+const planDoc = `version: 1
+roles:
+  - name: Sales
+    color: blue
+    principal_types: [operator_staff]
+    allow: [client:read, client:delete]
+  - name: Support
+    allow: [client:read, ticket:close]
+`
+
+func changes(p *Plan) []string {
+	var out []string
+	for _, c := range p.Changes {
+		out = append(out, c.Action+" "+c.Name)
+	}
+	return out
+}
+
+func conflicts(p *Plan) []string {
+	var out []string
+	for _, c := range p.Conflicts {
+		out = append(out, c.Kind+" "+c.Role+" "+strconv.FormatBool(c.Resolved))
+	}
+	return out
+}
+
+func TestNewPlan(t *testing.T) {
+	t.Parallel()
+
+	doc, err := Decode([]byte(planDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	load := func(f *fakeStore) *Workspace {
+		ws, err := Load(context.Background(), f.deps())
+		if err != nil {
+			t.Fatal(err)
+		}
+		return ws
+	}
+	inUse := map[string]bool{"auditor": true}
+
+	t.Run("unknown code blocks", func(t *testing.T) {
+		t.Parallel()
+		p := NewPlan(doc, load(newStore()), Options{UpdateExisting: true}, inUse)
+		if !p.Blocked() {
+			t.Errorf("plan not blocked: %v", conflicts(p))
+		}
+		if got, want := changes(p), []string{"create Support", "update Sales"}; !slices.Equal(got, want) {
+			t.Errorf("changes = %v, want %v", got, want)
+		}
+		sales := p.Changes[1]
+		if len(sales.Grant) != 1 || sales.Grant[0].Permission.GetId() != "p-delete" {
+			t.Errorf("Sales grant = %+v", sales.Grant)
+		}
+		var revoked []string
+		for _, rp := range sales.Revoke {
+			revoked = append(revoked, rp.GetId())
+		}
+		if !slices.Equal(revoked, []string{"rp-b", "rp-c"}) {
+			t.Errorf("Sales revoke = %v, want the DENY and the inactive row", revoked)
+		}
+		if len(sales.Fields) != 0 {
+			t.Errorf("Sales fields = %v, want none", sales.Fields)
+		}
+	})
+
+	t.Run("skip unknown and remove missing", func(t *testing.T) {
+		t.Parallel()
+		p := NewPlan(doc, load(newStore()), Options{UpdateExisting: true, RemoveMissing: true, SkipUnknown: true}, inUse)
+		if p.Blocked() {
+			t.Errorf("plan blocked: %v", conflicts(p))
+		}
+		if got, want := changes(p), []string{"create Support", "update Sales", "remove Legacy"}; !slices.Equal(got, want) {
+			t.Errorf("changes = %v, want %v", got, want)
+		}
+		if got, want := conflicts(p), []string{"unknown_code Support true", "role_in_use Auditor true"}; !slices.Equal(got, want) {
+			t.Errorf("conflicts = %v, want %v", got, want)
+		}
+		if c, u, r := p.Counts(); c != 1 || u != 1 || r != 1 {
+			t.Errorf("counts = %d, %d, %d", c, u, r)
+		}
+	})
+
+	t.Run("unknown usage keeps every role", func(t *testing.T) {
+		t.Parallel()
+		p := NewPlan(doc, load(newStore()), Options{UpdateExisting: true, RemoveMissing: true, SkipUnknown: true}, nil)
+		if got, want := changes(p), []string{"create Support", "update Sales"}; !slices.Equal(got, want) {
+			t.Errorf("changes = %v, want %v", got, want)
+		}
+		if got, want := conflicts(p), []string{"unknown_code Support true", "usage_unknown Auditor true", "usage_unknown Legacy true"}; !slices.Equal(got, want) {
+			t.Errorf("conflicts = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("existing roles kept", func(t *testing.T) {
+		t.Parallel()
+		p := NewPlan(doc, load(newStore()), Options{SkipUnknown: true}, inUse)
+		if got, want := changes(p), []string{"create Support"}; !slices.Equal(got, want) {
+			t.Errorf("changes = %v, want %v", got, want)
+		}
+		if got, want := conflicts(p), []string{"existing_differs Sales true", "unknown_code Support true"}; !slices.Equal(got, want) {
+			t.Errorf("conflicts = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("duplicate role", func(t *testing.T) {
+		t.Parallel()
+		f := newStore()
+		f.roles = append(f.roles, &rolepb.Role{Id: "sales-2", Name: "sales", Active: true})
+		p := NewPlan(doc, load(f), Options{UpdateExisting: true, SkipUnknown: true}, inUse)
+		if got, want := conflicts(p), []string{"duplicate_role Sales false", "unknown_code Support true"}; !slices.Equal(got, want) {
+			t.Errorf("conflicts = %v, want %v", got, want)
+		}
+		if !p.Blocked() {
+			t.Error("an ambiguous update is not blocked")
+		}
+	})
+
+	t.Run("inactive code", func(t *testing.T) {
+		t.Parallel()
+		doc, err := Decode([]byte("version: 1\nroles:\n  - name: Auditor\n    allow: [client:read, legacy:run]"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewPlan(doc, load(newStore()), Options{UpdateExisting: true}, inUse)
+		if got, want := conflicts(p), []string{"inactive_code Auditor false"}; !slices.Equal(got, want) {
+			t.Errorf("conflicts = %v, want %v", got, want)
+		}
+	})
+}
+
+func TestApply(t *testing.T) {
+	t.Parallel()
+
+	t.Run("copies a setup to another workspace", func(t *testing.T) {
+		t.Parallel()
+		src := newStore()
+		ws, err := Load(context.Background(), src.deps())
+		if err != nil {
+			t.Fatal(err)
+		}
+		doc := Export(ws, time.Now())
+
+		dst := &fakeStore{perms: catalog("other-")}
+		ws, err = Load(context.Background(), dst.deps())
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan := NewPlan(doc, ws, Options{UpdateExisting: true}, nil)
+		if plan.Blocked() {
+			t.Fatalf("plan blocked: %v", conflicts(plan))
+		}
+		// Legacy is created inactive, so no step sets it inactive.
+		n, err := Apply(context.Background(), dst.deps(), plan)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != 7 {
+			t.Errorf("steps = %d, want 3 creates and 4 grants", n)
+		}
+
+		ws, err = Load(context.Background(), dst.deps())
+		if err != nil {
+			t.Fatal(err)
+		}
+		copied := Export(ws, time.Now())
+		copied.ExportedAt = doc.ExportedAt
+		if !reflect.DeepEqual(copied, doc) {
+			t.Errorf("copied setup = %+v, want %+v", copied.Roles, doc.Roles)
+		}
+		if p := NewPlan(doc, ws, Options{UpdateExisting: true, RemoveMissing: true}, nil); len(p.Changes) != 0 || len(p.Conflicts) != 0 {
+			t.Errorf("re-import plans %v, %v", changes(p), conflicts(p))
+		}
+	})
+
+	t.Run("updates roles in place", func(t *testing.T) {
+		t.Parallel()
+		f := newStore()
+		doc, err := Decode([]byte("version: 1\nroles:\n  - name: legacy\n    description: Old tools\n    allow: [client:read]"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ws, err := Load(context.Background(), f.deps())
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan := NewPlan(doc, ws, Options{UpdateExisting: true}, nil)
+		if got := plan.Changes[0].Fields; !slices.Equal(got, []string{"name", "description", "active"}) {
+			t.Errorf("fields = %v", got)
+		}
+		if _, err := Apply(context.Background(), f.deps(), plan); err != nil {
+			t.Fatal(err)
+		}
+		if r := f.role("legacy"); r.GetName() != "legacy" || r.GetDescription() != "Old tools" || !r.GetActive() {
+			t.Errorf("role = %+v", r)
+		}
+	})
+
+	t.Run("a failure undoes every step", func(t *testing.T) {
+		t.Parallel()
+		doc, err := Decode([]byte(planDoc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := newStore()
+		f.failDelete = "legacy" // removes come last; Auditor is deleted first
+		before := f.state()
+		ws, err := Load(context.Background(), f.deps())
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan := NewPlan(doc, ws, Options{UpdateExisting: true, RemoveMissing: true, SkipUnknown: true}, map[string]bool{})
+		if got, want := changes(plan), []string{"create Support", "update Sales", "remove Auditor", "remove Legacy"}; !slices.Equal(got, want) {
+			t.Fatalf("changes = %v, want %v", got, want)
+		}
+
+		n, err := Apply(context.Background(), f.deps(), plan)
+		var applyErr *ApplyError
+		if n != 0 || !errors.As(err, &applyErr) || applyErr.Step != "delete role Legacy" || len(applyErr.RollbackErrs) != 0 {
+			t.Fatalf("Apply = %d, %v", n, err)
+		}
+		if got := f.state(); !slices.Equal(got, before) {
+			t.Errorf("state after rollback = %v, want %v", got, before)
+		}
+	})
+
+	t.Run("a failed undo is reported", func(t *testing.T) {
+		t.Parallel()
+		doc, err := Decode([]byte(planDoc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		f := newStore()
+		ws, err := Load(context.Background(), f.deps())
+		if err != nil {
+			t.Fatal(err)
+		}
+		plan := NewPlan(doc, ws, Options{UpdateExisting: true, SkipUnknown: true}, nil)
+		// Sales' revokes run, then its grant of client:delete fails;
+		// re-creating the revoked DENY row fails too.
+		d := f.deps()
+		create := d.CreateRolePermission
+		d.CreateRolePermission = func(ctx context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error) {
+			switch rp := req.GetData(); {
+			case rp.GetPermissionId() == "p-delete" && rp.GetActive():
+				return nil, errors.New("create failed")
+			case rp.GetPermissionId() == "p-impersonate":
+				return nil, errors.New("restore failed")
+			}
+			return create(ctx, req)
+		}
+		_, err = Apply(context.Background(), d, plan)
+		var applyErr *ApplyError
+		if !errors.As(err, &applyErr) || len(applyErr.RollbackErrs) != 1 || !strings.Contains(err.Error(), "undo revoke user:impersonate from Sales") {
+			t.Fatalf("Apply error = %v", err)
+		}
+	})
+}
+
+func TestNewImportAction(t *testing.T) {
+	t.Parallel()
+
+	post := func(d *Deps, perms []string, form url.Values) view.ViewResult {
+		req := httptest.NewRequest(http.MethodPost, role.ImportURL, strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions(perms))
+		return NewImportAction(d).Handle(ctx, &view.ViewContext{Request: req})
+	}
+	editor := []string{"role:list", "role:create", "role:update", "role:delete"}
+	form := url.Values{"document": {planDoc}, "update_existing": {"true"}, "skip_unknown": {"true"}}
+
+	t.Run("dry run changes nothing", func(t *testing.T) {
+		t.Parallel()
+		f := newStore()
+		before := f.state()
+		res := post(f.deps(), editor, form)
+		data, ok := res.Data.(*ReviewData)
+		if res.Template != "role-transfer-review" || !ok {
+			t.Fatalf("result = %+v", res)
+		}
+		if !data.CanApply || data.Blocked || data.Error != "" {
+			t.Errorf("review = %+v", data)
+		}
+		if data.Summary != "1 roles to create, 1 to update and 0 to delete." {
+			t.Errorf("summary = %q", data.Summary)
+		}
+		if got := data.Changes[1].Revoke; !slices.Equal(got, []string{"user:impersonate", "client:delete"}) {
+			t.Errorf("Sales revoke = %v", got)
+		}
+		if got := f.state(); !slices.Equal(got, before) {
+			t.Errorf("dry run wrote %v", got)
+		}
+	})
+
+	t.Run("confirm applies", func(t *testing.T) {
+		t.Parallel()
+		f := newStore()
+		confirmed := url.Values{"confirm": {"1"}}
+		for k, v := range form {
+			confirmed[k] = v
+		}
+		res := post(f.deps(), editor, confirmed)
+		if res.Redirect != role.TransferURL+"?imported=5" {
+			t.Fatalf("redirect = %q (result %+v)", res.Redirect, res)
+		}
+		if f.role("role-1").GetName() != "Support" {
+			t.Errorf("Support not created: %v", f.state())
+		}
+	})
+
+	t.Run("uploaded file", func(t *testing.T) {
+		t.Parallel()
+		var body bytes.Buffer
+		w := multipart.NewWriter(&body)
+		part, _ := w.CreateFormFile("file", "roles.yaml")
+		_, _ = part.Write([]byte(planDoc))
+		_ = w.WriteField("update_existing", "true")
+		_ = w.Close()
+		req := httptest.NewRequest(http.MethodPost, role.ImportURL, &body)
+		req.Header.Set("Content-Type", w.FormDataContentType())
+		ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions(editor))
+
+		res := NewImportAction(newStore().deps()).Handle(ctx, &view.ViewContext{Request: req})
+		data, ok := res.Data.(*ReviewData)
+		if !ok || data.Document != planDoc || !data.Blocked || data.CanApply {
+			t.Fatalf("result = %+v", res)
+		}
+	})
+
+	t.Run("unreadable document", func(t *testing.T) {
+		t.Parallel()
+		res := post(newStore().deps(), editor, url.Values{"document": {"version: 1\nroles: [{name: Sales, allow: [client]}]"}})
+		data, ok := res.Data.(*ReviewData)
+		if !ok || !strings.HasPrefix(data.Error, "The file could not be read") || data.CanApply {
+			t.Fatalf("result = %+v", res)
+		}
+	})
+
+	t.Run("deleting roles takes role:delete", func(t *testing.T) {
+		t.Parallel()
+		f := newStore()
+		removing := url.Values{"confirm": {"1"}, "remove_missing": {"true"}}
+		for k, v := range form {
+			removing[k] = v
+		}
+		res := post(f.deps(), []string{"role:list", "role:create", "role:update"}, removing)
+		if res.StatusCode != http.StatusUnprocessableEntity || f.role("legacy") == nil {
+			t.Fatalf("status = %d, state = %v", res.StatusCode, f.state())
+		}
+	})
+
+	t.Run("permission denied", func(t *testing.T) {
+		t.Parallel()
+		res := post(newStore().deps(), nil, form)
+		if res.StatusCode != http.StatusUnprocessableEntity {
+			t.Fatalf("status = %d", res.StatusCode)
+		}
+	})
+}
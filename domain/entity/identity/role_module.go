@@ -2,6 +2,7 @@ package identity
 
 import (
 	"context"
+	"net/http"
 
 	pyeza "github.com/erniealice/pyeza-golang"
 	"github.com/erniealice/pyeza-golang/types"
@@ -11,8 +12,11 @@ import (
 	role "github.com/erniealice/entydad-golang/domain/entity/identity/role"
 	roleaction "github.com/erniealice/entydad-golang/domain/entity/identity/role/action"
 	roledetail "github.com/erniealice/entydad-golang/domain/entity/identity/role/detail"
+	rolelibrary "github.com/erniealice/entydad-golang/domain/entity/identity/role/library"
 	rolelist "github.com/erniealice/entydad-golang/domain/entity/identity/role/list"
+	rolematrix "github.com/erniealice/entydad-golang/domain/entity/identity/role/matrix"
 	rolepermissions "github.com/erniealice/entydad-golang/domain/entity/identity/role/permissions"
+	roletransfer "github.com/erniealice/entydad-golang/domain/entity/identity/role/transfer"
 	roleusers "github.com/erniealice/entydad-golang/domain/entity/identity/role/users"
 	attachmentpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/document/attachment"
 	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
@@ -48,6 +52,12 @@ type RoleModuleDeps struct {
 	CreateRolePermission func(ctx context.Context, req *rolepermissionpb.CreateRolePermissionRequest) (*rolepermissionpb.CreateRolePermissionResponse, error)
 	DeleteRolePermission func(ctx context.Context, req *rolepermissionpb.DeleteRolePermissionRequest) (*rolepermissionpb.DeleteRolePermissionResponse, error)
 	ListPermissions      func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
+	// Role × permission matrix (all roles at once; optional)
+	ListRoles func(ctx context.Context, req *rolepb.ListRolesRequest) (*rolepb.ListRolesResponse, error)
+	// Role template library: which template a role was made from, shown by
+	// the detail page's drift tab (optional; library.MemorySources fits)
+	GetRoleTemplate func(ctx context.Context, roleID string) (string, error)
+	SetRoleTemplate func(ctx context.Context, roleID, key string) error
 	// Role-User management
 	GetUsersByRoleID        func(ctx context.Context, roleID string) ([]roleusers.UserByRole, error)
 	ListWorkspaceUsers      func(ctx context.Context, req *workspaceuserpb.ListWorkspaceUsersRequest) (*workspaceuserpb.ListWorkspaceUsersResponse, error)
@@ -83,6 +93,16 @@ type RoleModule struct {
 	PermissionTable  view.View
 	PermissionAssign view.View
 	PermissionRemove view.View
+	// Role × permission matrix views
+	Matrix     view.View
+	MatrixSave view.View
+	// Role template library views
+	Templates   view.View
+	TemplateUse view.View
+	// Role setup export/import
+	Transfer view.View
+	Import   view.View
+	Export   http.HandlerFunc
 	// Role-User assignment views
 	UserList         view.View
 	UserTable        view.View
@@ -100,11 +120,23 @@ func NewRoleModule(deps *RoleModuleDeps) *RoleModule {
 		DeleteRole:    deps.DeleteRole,
 		SetRoleActive: deps.SetActive,
 		Routes:        deps.Routes,
+
+		GetRoleItemPageData:  deps.GetItemPageData,
+		CreateRolePermission: deps.CreateRolePermission,
+	}
+	// The list links to the matrix only when the matrix is served.
+	listRoutes := deps.Routes
+	if deps.ListRoles == nil {
+		listRoutes.MatrixURL = ""
+		listRoutes.TransferURL = ""
+	}
+	if deps.ListPermissions == nil || deps.CreateRolePermission == nil {
+		listRoutes.TemplatesURL = ""
 	}
 	listDeps := &rolelist.ListViewDeps{
 		GetListPageData: deps.GetListPageData,
 		GetInUseIDs:     deps.GetInUseIDs,
-		Routes:          deps.Routes,
+		Routes:          listRoutes,
 		Labels:          deps.Labels,
 		SharedLabels:    deps.SharedLabels,
 		CommonLabels:    deps.CommonLabels,
@@ -121,6 +153,8 @@ func NewRoleModule(deps *RoleModuleDeps) *RoleModule {
 		RoleUserLabels:       deps.RoleUserLabels,
 		CommonLabels:         deps.CommonLabels,
 		TableLabels:          deps.TableLabels,
+		ListPermissions:      deps.ListPermissions,
+		GetRoleTemplate:      deps.GetRoleTemplate,
 		AttachmentOps: attachment.AttachmentOps{
 			UploadFile:       deps.UploadFile,
 			ListAttachments:  deps.ListAttachments,
@@ -148,6 +182,42 @@ func NewRoleModule(deps *RoleModuleDeps) *RoleModule {
 		Routes:               deps.Routes,
 		Labels:               deps.RolePermissionLabels,
 	}
+	matrixDeps := &rolematrix.Deps{
+		ListRoles:            deps.ListRoles,
+		GetRoleItemPageData:  deps.GetItemPageData,
+		ListPermissions:      deps.ListPermissions,
+		CreateRolePermission: deps.CreateRolePermission,
+		DeleteRolePermission: deps.DeleteRolePermission,
+		Routes:               deps.Routes,
+		Labels:               deps.RolePermissionLabels,
+		CommonLabels:         deps.CommonLabels,
+	}
+	libraryDeps := &rolelibrary.Deps{
+		CreateRole:           deps.CreateRole,
+		ListPermissions:      deps.ListPermissions,
+		CreateRolePermission: deps.CreateRolePermission,
+		DeleteRole:           deps.DeleteRole,
+		SetRoleActive:        deps.SetActive,
+		SetRoleTemplate:      deps.SetRoleTemplate,
+		Routes:               deps.Routes,
+		Labels:               deps.Labels,
+		CommonLabels:         deps.CommonLabels,
+	}
+	transferDeps := &roletransfer.Deps{
+		ListRoles:            deps.ListRoles,
+		GetRoleItemPageData:  deps.GetItemPageData,
+		ListPermissions:      deps.ListPermissions,
+		CreateRole:           deps.CreateRole,
+		UpdateRole:           deps.UpdateRole,
+		DeleteRole:           deps.DeleteRole,
+		SetRoleActive:        deps.SetActive,
+		CreateRolePermission: deps.CreateRolePermission,
+		DeleteRolePermission: deps.DeleteRolePermission,
+		GetInUseIDs:          deps.GetInUseIDs,
+		Routes:               deps.Routes,
+		Labels:               deps.Labels,
+		CommonLabels:         deps.CommonLabels,
+	}
 	userListDeps := &roleusers.Deps{
 		GetUsersByRoleID: deps.GetUsersByRoleID,
 		ReadRole:         deps.ReadRole,
@@ -166,7 +236,7 @@ func NewRoleModule(deps *RoleModuleDeps) *RoleModule {
 		Labels:                  deps.RoleUserLabels,
 	}
 
-	return &RoleModule{
+	m := &RoleModule{
 		routes:           deps.Routes,
 		List:             rolelist.NewView(listDeps),
 		Table:            rolelist.NewTableView(listDeps),
@@ -189,6 +259,18 @@ func NewRoleModule(deps *RoleModuleDeps) *RoleModule {
 		AttachmentUpload: roledetail.NewAttachmentUploadAction(detailDeps),
 		AttachmentDelete: roledetail.NewAttachmentDeleteAction(detailDeps),
 	}
+	if deps.ListRoles != nil {
+		m.Matrix = rolematrix.NewView(matrixDeps)
+		m.MatrixSave = rolematrix.NewSaveAction(matrixDeps)
+		m.Transfer = roletransfer.NewView(transferDeps)
+		m.Import = roletransfer.NewImportAction(transferDeps)
+		m.Export = roletransfer.NewExportHandler(transferDeps)
+	}
+	if deps.ListPermissions != nil && deps.CreateRolePermission != nil && deps.DeleteRole != nil {
+		m.Templates = rolelibrary.NewView(libraryDeps)
+		m.TemplateUse = rolelibrary.NewUseAction(libraryDeps)
+	}
+	return m
 }
 
 func (m *RoleModule) RegisterRoutes(r view.RouteRegistrar) {
@@ -216,6 +298,23 @@ func (m *RoleModule) RegisterRoutes(r view.RouteRegistrar) {
 	r.GET(m.routes.PermissionsAssignURL, m.PermissionAssign)
 	r.POST(m.routes.PermissionsAssignURL, m.PermissionAssign)
 	r.POST(m.routes.PermissionsRemoveURL, m.PermissionRemove)
+	// Role × permission matrix
+	if m.Matrix != nil && m.routes.MatrixURL != "" {
+		r.GET(m.routes.MatrixURL, m.Matrix)
+		r.POST(m.routes.MatrixSaveURL, m.MatrixSave)
+	}
+	// Role template library
+	if m.Templates != nil && m.routes.TemplatesURL != "" {
+		r.GET(m.routes.TemplatesURL, m.Templates)
+		r.GET(m.routes.TemplateUseURL, m.TemplateUse)
+		r.POST(m.routes.TemplateUseURL, m.TemplateUse)
+	}
+	// Role setup export/import
+	if m.Transfer != nil && m.routes.TransferURL != "" {
+		r.GET(m.routes.TransferURL, m.Transfer)
+		r.POST(m.routes.ImportURL, m.Import)
+		identityHandleFunc(r, "GET", m.routes.ExportURL, m.Export)
+	}
 	// Role-User assignment
 	r.GET(m.routes.UsersURL, m.UserList)
 	r.GET(m.routes.UsersTableURL, m.UserTable)
@@ -229,3 +328,19 @@ func (m *RoleModule) RegisterRoutes(r view.RouteRegistrar) {
 		r.POST(m.routes.AttachmentDeleteURL, m.AttachmentDelete)
 	}
 }
+
+// SensitiveActions returns the ServeMux patterns of the actions that grant
+// permissions: assigning permissions to a role (one at a time or through
+// the matrix), making a role from a template, importing a role setup, and
+// assigning users to a role. Pass them to the auth module's
+// Deps.StepUpActions to require a recent sign-in.
+func (m *RoleModule) SensitiveActions() []string {
+	return postPatterns(
+		m.routes.DetailPermissionsAssignURL,
+		m.routes.PermissionsAssignURL,
+		m.routes.MatrixSaveURL,
+		m.routes.TemplateUseURL,
+		m.routes.ImportURL,
+		m.routes.UsersAssignURL,
+	)
+}
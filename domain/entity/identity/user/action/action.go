@@ -42,6 +42,15 @@ type Deps struct {
 	// GetUserAuthCapability reports the user's sign-in methods (WS-4). Optional/
 	// nil-safe: nil => treat as local-managed (guard allows reset).
 	GetUserAuthCapability func(ctx context.Context, userID string) (bool, []string, error)
+
+	// Password policy (auth.PasswordPolicy Check / Remember, wired by the
+	// composition). Optional/nil-safe: nil => no checks beyond the provider's.
+	// CheckPassword returns a violation code ("" = acceptable); userID is
+	// empty on Add and email may be empty on reset-password (the composition
+	// resolves it from userID when it wants the must-not-contain-email rule).
+	// RememberPassword records an applied password for the no-reuse rule.
+	CheckPassword    func(ctx context.Context, userID, email, password string) string
+	RememberPassword func(ctx context.Context, userID, password string)
 }
 
 // hashPassword hashes the password using the deps.HashPassword func, or returns it as-is.
@@ -52,6 +61,37 @@ func hashPassword(deps *Deps, password string) (string, error) {
 	return password, nil
 }
 
+// checkPassword runs the optional password policy and returns the
+// localized error key for a violation ("" when acceptable).
+func checkPassword(ctx context.Context, deps *Deps, userID, email, password string) string {
+	if deps.CheckPassword == nil {
+		return ""
+	}
+	switch deps.CheckPassword(ctx, userID, email, password) {
+	case "":
+		return ""
+	case "too_short":
+		return "shared.errors.passwordTooShort"
+	case "password_classes":
+		return "shared.errors.passwordClasses"
+	case "password_breached":
+		return "shared.errors.passwordBreached"
+	case "password_reused":
+		return "shared.errors.passwordReused"
+	case "password_contains_email":
+		return "shared.errors.passwordContainsEmail"
+	default:
+		return "shared.errors.passwordFailed"
+	}
+}
+
+// rememberPassword records an applied password in the policy history.
+func rememberPassword(ctx context.Context, deps *Deps, userID, password string) {
+	if deps.RememberPassword != nil && userID != "" {
+		deps.RememberPassword(ctx, userID, password)
+	}
+}
+
 // NewAddAction creates the user add action (GET = form, POST = create).
 func NewAddAction(deps *Deps) view.View {
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
@@ -78,7 +118,11 @@ func NewAddAction(deps *Deps) view.View {
 		active := r.FormValue("active") == "true"
 
 		var pwHash string
-		if pw := r.FormValue("password"); pw != "" {
+		pw := r.FormValue("password")
+		if pw != "" {
+			if key := checkPassword(ctx, deps, "", r.FormValue("email_address"), pw); key != "" {
+				return view.HTMXError(viewCtx.T(key))
+			}
 			h, hashErr := hashPassword(deps, pw)
 			if hashErr != nil {
 				log.Printf("Failed to hash password: %v", hashErr)
@@ -114,12 +158,16 @@ func NewAddAction(deps *Deps) view.View {
 			return view.HTMXError(err.Error())
 		}
 
+		newUserID := ""
+		if data := createResp.GetData(); len(data) > 0 {
+			newUserID = data[0].GetId()
+		}
+		if pw != "" {
+			rememberPassword(ctx, deps, newUserID, pw)
+		}
+
 		// Auto-create WorkspaceUser for the default workspace
 		if deps.CreateWorkspaceUser != nil && deps.DefaultWorkspaceID != "" {
-			newUserID := ""
-			if data := createResp.GetData(); len(data) > 0 {
-				newUserID = data[0].GetId()
-			}
 			if newUserID != "" {
 				_, err := deps.CreateWorkspaceUser(ctx, &workspaceuserpb.CreateWorkspaceUserRequest{
 					Data: &workspaceuserpb.WorkspaceUser{
@@ -194,6 +242,15 @@ func NewEditAction(deps *Deps) view.View {
 			userData.Timezone = &tz
 		}
 
+		// Policy check before any write so a rejected password does not
+		// leave the profile half-updated.
+		pw := r.FormValue("password")
+		if pw != "" && deps.AdminResetPassword != nil {
+			if key := checkPassword(ctx, deps, id, userData.EmailAddress, pw); key != "" {
+				return view.HTMXError(viewCtx.T(key))
+			}
+		}
+
 		// UpdateUser is the provider-syncing use case (design §6): on a detected
 		// email change it also calls AuthService.UpdateEmailAtProvider so the IdP
 		// account email matches the DB (closes the firebase change-email
@@ -210,7 +267,7 @@ func NewEditAction(deps *Deps) view.View {
 		// provider-abstracted AdminResetPassword use case (NOT a raw hash write),
 		// so it lands at the active IdP. Nil-safe: if the use case is unwired the
 		// password field is ignored rather than silently stored as plaintext.
-		if pw := r.FormValue("password"); pw != "" && deps.AdminResetPassword != nil {
+		if pw != "" && deps.AdminResetPassword != nil {
 			_, resetErr := deps.AdminResetPassword(ctx, &userpb.AdminResetPasswordRequest{
 				UserId: id,
 				Method: &userpb.AdminResetPasswordRequest_NewPassword{NewPassword: pw},
@@ -219,6 +276,7 @@ func NewEditAction(deps *Deps) view.View {
 				log.Printf("Failed to set password for user %s during edit: %v", id, resetErr)
 				return view.HTMXError(resetErr.Error())
 			}
+			rememberPassword(ctx, deps, id, pw)
 		}
 
 		return view.HTMXSuccess("users-table")
@@ -391,6 +449,9 @@ func NewResetPasswordAction(deps *Deps) view.View {
 		if deps.AdminResetPassword == nil {
 			return view.HTMXError(viewCtx.T("shared.errors.passwordFailed"))
 		}
+		if key := checkPassword(ctx, deps, id, "", password); key != "" {
+			return view.HTMXError(viewCtx.T(key))
+		}
 
 		_, resetErr := deps.AdminResetPassword(ctx, &userpb.AdminResetPasswordRequest{
 			UserId: id,
@@ -400,6 +461,7 @@ func NewResetPasswordAction(deps *Deps) view.View {
 			log.Printf("Failed to reset password for user %s: %v", id, resetErr)
 			return view.HTMXError(resetErr.Error())
 		}
+		rememberPassword(ctx, deps, id, password)
 
 		return view.HTMXSuccess("")
 	})
@@ -177,6 +177,8 @@ func testMessages() map[string]string {
 		"shared.errors.passwordFailed":      "password failed",
 		"shared.errors.passwordRequired":    "password required",
 		"shared.errors.notFound":            "not found",
+		"shared.errors.passwordBreached":    "password breached",
+		"shared.errors.passwordReused":      "password reused",
 	}
 }
 
@@ -1212,6 +1214,63 @@ func TestNewResetPasswordAction_Negative(t *testing.T) {
 	}
 }
 
+func TestNewResetPasswordAction_PasswordPolicy(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		code            string
+		wantStatus      int
+		wantErrorHeader string
+		wantResetCount  int
+		wantRemembered  bool
+	}{
+		{name: "accepted password is applied and remembered", wantStatus: http.StatusOK, wantResetCount: 1, wantRemembered: true},
+		{name: "breached password rejected", code: "password_breached", wantStatus: http.StatusUnprocessableEntity, wantErrorHeader: "password breached"},
+		{name: "reused password rejected", code: "password_reused", wantStatus: http.StatusUnprocessableEntity, wantErrorHeader: "password reused"},
+		{name: "unknown code falls back to password failed", code: "future_rule", wantStatus: http.StatusUnprocessableEntity, wantErrorHeader: "password failed"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rec := &userActionRecorder{}
+			var checkedUser, remembered string
+			deps := &Deps{
+				AdminResetPassword: rec.adminResetPassword,
+				CheckPassword: func(_ context.Context, userID, _, _ string) string {
+					checkedUser = userID
+					return tt.code
+				},
+				RememberPassword: func(_ context.Context, userID, _ string) {
+					remembered = userID
+				},
+			}
+
+			req := makePostRequestWithPathValue("/action/users/reset-password", url.Values{"password": {"n3w-Passw0rd"}}, "id", "u-1")
+			res := runHandler(t, NewResetPasswordAction(deps), withPerms("user:update"), req)
+
+			if res.StatusCode != tt.wantStatus {
+				t.Fatalf("StatusCode = %d, want %d", res.StatusCode, tt.wantStatus)
+			}
+			if tt.wantErrorHeader != "" {
+				assertErrorHeader(t, res, tt.wantErrorHeader)
+			}
+			if checkedUser != "u-1" {
+				t.Fatalf("CheckPassword userID = %q, want u-1", checkedUser)
+			}
+			if len(rec.resetPasswordCalls) != tt.wantResetCount {
+				t.Fatalf("AdminResetPassword call count = %d, want %d", len(rec.resetPasswordCalls), tt.wantResetCount)
+			}
+			if (remembered == "u-1") != tt.wantRemembered {
+				t.Fatalf("RememberPassword userID = %q, wantRemembered %v", remembered, tt.wantRemembered)
+			}
+		})
+	}
+}
+
 // ---------------------------------------------------------------------------
 // Delete — additional edge cases
 // ---------------------------------------------------------------------------
@@ -0,0 +1,60 @@
+package detail
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/erniealice/pyeza-golang/view"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/explain"
+	userpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/user"
+	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
+)
+
+// NewExplainTextHandler serves the access tab's permission trace as a
+// plain-text download, for pasting into a support ticket.
+// Handles GET /action/user/{id}/permissions/explain?code=...
+func NewExplainTextHandler(deps *DetailViewDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if !view.GetUserPermissions(ctx).Can("user", "read") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		id := r.PathValue("id")
+		resp, err := deps.ReadUser(ctx, &userpb.ReadUserRequest{
+			Data: &userpb.User{Id: id},
+		})
+		if err != nil {
+			log.Printf("permission explain export: failed to read user %s: %v", id, err)
+			http.Error(w, "failed to load user", http.StatusInternalServerError)
+			return
+		}
+		if len(resp.GetData()) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+		explain.WriteText(w, r, id, userSubject(resp.GetData()[0]), explainAssignments(deps, id), deps.GetRoleItemPageData, explain.ListCatalog(deps.ListPermissions))
+	}
+}
+
+// explainAssignments loads the user's role assignments for the explainer.
+func explainAssignments(deps *DetailViewDeps, userID string) explain.Assignments {
+	return func(ctx context.Context) ([]*workspaceuserrolepb.WorkspaceUserRole, error) {
+		return getUserRoleAssignments(ctx, deps, userID)
+	}
+}
+
+// userSubject names u in the explainer's text: "First Last <email>".
+func userSubject(u *userpb.User) string {
+	name := strings.TrimSpace(u.GetFirstName() + " " + u.GetLastName())
+	switch {
+	case name == "":
+		return u.GetEmailAddress()
+	case u.GetEmailAddress() == "":
+		return name
+	}
+	return name + " <" + u.GetEmailAddress() + ">"
+}
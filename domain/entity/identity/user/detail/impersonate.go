@@ -0,0 +1,141 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/route"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	user "github.com/erniealice/entydad-golang/domain/entity/identity/user"
+	userpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/user"
+)
+
+// ImpersonationInput is what the "view as this user" drawer submits. The
+// actor and workspace come from the request identity; ReturnURL is the page
+// the administrator lands on when the impersonation ends.
+type ImpersonationInput struct {
+	ActorUserID  string
+	TargetUserID string
+	TargetName   string
+	WorkspaceID  string
+	Reason       string
+	ReturnURL    string
+}
+
+// Errors StartImpersonation returns (or wraps) so the drawer can show a
+// specific message instead of the generic one.
+var (
+	ErrImpersonationReason = errors.New("user: impersonation requires a reason")
+	ErrImpersonationTarget = errors.New("user: this user cannot be impersonated")
+)
+
+// StartImpersonation begins an audited "view as user" session and returns
+// the URL the browser navigates to. The session switch and its audit trail
+// live in the auth service (auth.AuthModule.ImpersonationURL); the
+// composition layer adapts it so this package does not depend on it. When
+// nil the security tab offers no impersonation.
+type StartImpersonation func(ctx context.Context, in ImpersonationInput) (redirectURL string, err error)
+
+// ImpersonateFormData is the data for the "user-impersonate-form" drawer.
+type ImpersonateFormData struct {
+	FormAction  string
+	WorkspaceID string
+	Labels      user.DetailImpersonateLabels
+}
+
+// canImpersonate reports whether the security tab offers "view as this
+// user" for id: the permission, a wired closure, and never yourself.
+func canImpersonate(ctx context.Context, deps *DetailViewDeps, perms *types.UserPermissions, id string) bool {
+	if deps.StartImpersonation == nil || !perms.Can("user", "impersonate") {
+		return false
+	}
+	actor, ok := identity.FromContext(ctx)
+	return ok && actor != nil && actor.UserID != "" && actor.UserID != id
+}
+
+// NewImpersonateAction creates the "view as this user" action.
+// GET renders the reason drawer; POST starts the impersonation and
+// redirects the browser into it. Requires user:impersonate.
+func NewImpersonateAction(deps *DetailViewDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("user", "impersonate") {
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+		id := viewCtx.Request.PathValue("id")
+		if id == "" {
+			return view.HTMXError(viewCtx.T("shared.errors.idRequired"))
+		}
+		l := deps.Labels.Detail.Security.Impersonate
+		if !canImpersonate(ctx, deps, perms, id) {
+			return view.HTMXError(l.ErrorTarget)
+		}
+		actor, _ := identity.FromContext(ctx)
+
+		name, err := userDisplayName(ctx, deps, id)
+		if err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.notFound"))
+		}
+
+		if viewCtx.Request.Method == http.MethodGet {
+			return view.OK("user-impersonate-form", &ImpersonateFormData{
+				FormAction:  route.ResolveURL(deps.Routes.ImpersonateURL, "id", id),
+				WorkspaceID: actor.WorkspaceID,
+				Labels:      l,
+			})
+		}
+
+		// POST — start the impersonation
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		reason := strings.TrimSpace(viewCtx.Request.FormValue("reason"))
+		if reason == "" {
+			return view.HTMXError(l.ErrorReason)
+		}
+
+		target, err := deps.StartImpersonation(ctx, ImpersonationInput{
+			ActorUserID:  actor.UserID,
+			TargetUserID: id,
+			TargetName:   name,
+			WorkspaceID:  actor.WorkspaceID,
+			Reason:       reason,
+			ReturnURL:    viewCtx.Request.Header.Get("HX-Current-URL"),
+		})
+		switch {
+		case errors.Is(err, ErrImpersonationReason):
+			return view.HTMXError(l.ErrorReason)
+		case errors.Is(err, ErrImpersonationTarget):
+			return view.HTMXError(l.ErrorTarget)
+		case err != nil:
+			log.Printf("Failed to start impersonation of user %s by %s: %v", id, actor.UserID, err)
+			return view.HTMXError(l.ErrorUnavailable)
+		}
+		return view.Redirect(target)
+	})
+}
+
+// userDisplayName returns the user's full name, or their email when both
+// name parts are empty.
+func userDisplayName(ctx context.Context, deps *DetailViewDeps, id string) (string, error) {
+	resp, err := deps.ReadUser(ctx, &userpb.ReadUserRequest{Data: &userpb.User{Id: id}})
+	if err != nil {
+		log.Printf("Failed to read user %s: %v", id, err)
+		return "", err
+	}
+	if len(resp.GetData()) == 0 {
+		return "", errors.New("user not found")
+	}
+	u := resp.GetData()[0]
+	name := strings.TrimSpace(u.GetFirstName() + " " + u.GetLastName())
+	if name == "" {
+		name = u.GetEmailAddress()
+	}
+	return name, nil
+}
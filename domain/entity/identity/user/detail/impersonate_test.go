@@ -0,0 +1,154 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	user "github.com/erniealice/entydad-golang/domain/entity/identity/user"
+	"github.com/erniealice/espyna-golang/shared/identity"
+	userpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/user"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+func TestNewImpersonateAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		perms           []string
+		pathID          string
+		method          string
+		reason          string
+		startErr        error
+		wantTemplate    string
+		wantRedirect    string
+		wantErrorHeader string
+		wantCalls       int
+	}{
+		{
+			name:            "missing user:impersonate",
+			perms:           []string{"user:update"},
+			pathID:          "user-2",
+			method:          http.MethodPost,
+			reason:          "ticket 42",
+			wantErrorHeader: "permission denied",
+		},
+		{
+			name:            "cannot impersonate yourself",
+			perms:           []string{"user:impersonate"},
+			pathID:          "admin",
+			method:          http.MethodGet,
+			wantErrorHeader: "You can't view the app as this user in this workspace.",
+		},
+		{
+			name:         "GET renders the reason drawer",
+			perms:        []string{"user:impersonate"},
+			pathID:       "user-2",
+			method:       http.MethodGet,
+			wantTemplate: "user-impersonate-form",
+		},
+		{
+			name:            "reason is mandatory",
+			perms:           []string{"user:impersonate"},
+			pathID:          "user-2",
+			method:          http.MethodPost,
+			reason:          "   ",
+			wantErrorHeader: "Enter the reason for viewing as this user.",
+		},
+		{
+			name:            "target refused by the auth service",
+			perms:           []string{"user:impersonate"},
+			pathID:          "user-2",
+			method:          http.MethodPost,
+			reason:          "ticket 42",
+			startErr:        ErrImpersonationTarget,
+			wantErrorHeader: "You can't view the app as this user in this workspace.",
+			wantCalls:       1,
+		},
+		{
+			name:            "auth service failure",
+			perms:           []string{"user:impersonate"},
+			pathID:          "user-2",
+			method:          http.MethodPost,
+			reason:          "ticket 42",
+			startErr:        errors.New("store down"),
+			wantErrorHeader: "Viewing as this user is not available right now. Try again later.",
+			wantCalls:       1,
+		},
+		{
+			name:         "POST redirects into the impersonated session",
+			perms:        []string{"user:impersonate"},
+			pathID:       "user-2",
+			method:       http.MethodPost,
+			reason:       " ticket 42 ",
+			wantRedirect: "/auth/impersonate?grant=g",
+			wantCalls:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []ImpersonationInput
+			deps := &DetailViewDeps{
+				Routes: user.DefaultRoutes(),
+				Labels: user.Labels{Detail: user.DetailLabels{Security: user.DetailSecurityLabels{Impersonate: user.DefaultImpersonateLabels()}}},
+				ReadUser: func(_ context.Context, req *userpb.ReadUserRequest) (*userpb.ReadUserResponse, error) {
+					return &userpb.ReadUserResponse{Data: []*userpb.User{{Id: req.GetData().GetId(), FirstName: "Rosa", LastName: "Diaz"}}}, nil
+				},
+				StartImpersonation: func(_ context.Context, in ImpersonationInput) (string, error) {
+					calls = append(calls, in)
+					if tt.startErr != nil {
+						return "", tt.startErr
+					}
+					return "/auth/impersonate?grant=g", nil
+				},
+			}
+
+			req := httptest.NewRequest(tt.method, "/action/user/impersonate/"+tt.pathID, strings.NewReader(url.Values{"reason": {tt.reason}}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.Header.Set("HX-Current-URL", "https://app.example.com/app/users/detail/user-2?tab=security")
+			req.SetPathValue("id", tt.pathID)
+			ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions(tt.perms))
+			ctx = identity.WithRequestIdentity(ctx, &identity.RequestIdentity{UserID: "admin", WorkspaceID: "ws-1"})
+
+			res := NewImpersonateAction(deps).Handle(ctx, &view.ViewContext{
+				Request: req,
+				Messages: map[string]string{
+					"shared.errors.permissionDenied": "permission denied",
+				},
+			})
+
+			if got := res.Headers["HX-Error-Message"]; got != tt.wantErrorHeader {
+				t.Fatalf("HX-Error-Message = %q, want %q", got, tt.wantErrorHeader)
+			}
+			if res.Template != tt.wantTemplate || res.Redirect != tt.wantRedirect {
+				t.Fatalf("Template = %q, Redirect = %q", res.Template, res.Redirect)
+			}
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("StartImpersonation calls = %d, want %d", len(calls), tt.wantCalls)
+			}
+			if tt.wantRedirect != "" {
+				want := ImpersonationInput{
+					ActorUserID:  "admin",
+					TargetUserID: "user-2",
+					TargetName:   "Rosa Diaz",
+					WorkspaceID:  "ws-1",
+					Reason:       "ticket 42",
+					ReturnURL:    "https://app.example.com/app/users/detail/user-2?tab=security",
+				}
+				if calls[0] != want {
+					t.Fatalf("StartImpersonation input = %+v, want %+v", calls[0], want)
+				}
+			}
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 
 	"github.com/erniealice/hybra-golang/views/attachment"
 	"github.com/erniealice/hybra-golang/views/auditlog"
@@ -13,12 +14,16 @@ import (
 	"github.com/erniealice/pyeza-golang/view"
 
 	"github.com/erniealice/entydad-golang"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/explain"
 	user "github.com/erniealice/entydad-golang/domain/entity/identity/user"
 	lynguaV1 "github.com/erniealice/lyngua/golang/v1"
 
 	attachmentpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/document/attachment"
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
 	userpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/user"
 	workspaceuserpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user"
+	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
 )
 
 // DetailViewDeps holds view dependencies.
@@ -39,6 +44,19 @@ type DetailViewDeps struct {
 
 	// Audit log operations (embedded from hybra)
 	auditlog.AuditOps
+
+	// StartImpersonation backs the security tab's "view as this user"
+	// action (optional).
+	StartImpersonation StartImpersonation
+
+	// GetRoleItemPageData loads each assigned role with its permissions
+	// for the access tab (optional: without it the tab explains from the
+	// roles as the workspace user's page data carries them).
+	GetRoleItemPageData effective.GetRole
+	// ListPermissions is the catalog the access tab's verdict expands
+	// wildcard grants against, as the runtime permission set does
+	// (optional: without it the explained code counts as defined).
+	ListPermissions func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
 }
 
 // PageData holds the data for the user detail page.
@@ -63,11 +81,17 @@ type PageData struct {
 	ManageAccountURL     string
 	EditURL              string
 	AttachmentTable      *types.TableConfig
+	// "View as this user" (security tab), shown with user:impersonate.
+	ImpersonateURL    string
+	CanImpersonate    bool
+	ImpersonateLabels user.DetailImpersonateLabels
 	// Audit history tab
 	AuditEntries    []auditlog.AuditEntryView
 	AuditHasNext    bool
 	AuditNextCursor string
 	AuditHistoryURL string
+	// Access tab
+	Explain *explain.Data
 }
 
 // NewView creates the user detail view (full page).
@@ -213,6 +237,13 @@ func buildPageData(ctx context.Context, deps *DetailViewDeps, id, activeTab stri
 		}
 	}
 
+	if activeTab == "security" && canImpersonate(ctx, deps, perms, id) {
+		pageData.CanImpersonate = true
+		pageData.ImpersonateURL = route.ResolveURL(deps.Routes.ImpersonateURL, "id", id)
+		pageData.ImpersonateLabels = deps.Labels.Detail.Security.Impersonate
+		pageData.ImpersonateLabels.FormTitle = strings.ReplaceAll(pageData.ImpersonateLabels.FormTitle, "{user}", displayName)
+	}
+
 	// Load tab-specific data
 	switch activeTab {
 	case "roles":
@@ -222,6 +253,18 @@ func buildPageData(ctx context.Context, deps *DetailViewDeps, id, activeTab stri
 		} else {
 			pageData.RolesTable = tableConfig
 		}
+	case "access":
+		pageData.Explain = explain.Build(ctx, explain.Input{
+			Labels:      deps.Labels.Detail.Explain,
+			TableLabels: deps.TableLabels,
+			Query:       viewCtx.Request.URL.Query(),
+			Assignments: explainAssignments(deps, id),
+			GetRole:     deps.GetRoleItemPageData,
+			Catalog:     explain.ListCatalog(deps.ListPermissions),
+			FormURL:     route.ResolveURL(deps.Routes.TabActionURL, "id", id, "tab", "access"),
+			TextURL:     route.ResolveURL(deps.Routes.ExplainURL, "id", id),
+			Subject:     userSubject(user),
+		})
 	case "attachments":
 		if deps.ListAttachments != nil {
 			cfg := attachmentConfig(deps)
@@ -281,6 +324,7 @@ func buildTabItems(id string, labels user.Labels, roleCount int, routes user.Rou
 	return []pyeza.TabItem{
 		{Key: "info", Label: labels.Detail.Tabs.Info, Href: base + "?tab=info", HxGet: action + "info", Icon: "icon-info", Count: 0, Disabled: false},
 		{Key: "roles", Label: labels.Detail.Tabs.Roles, Href: base + "?tab=roles", HxGet: action + "roles", Icon: "icon-shield", Count: roleCount, Disabled: false},
+		{Key: "access", Label: labels.Detail.Explain.Tab, Href: base + "?tab=access", HxGet: action + "access", Icon: "icon-lock", Count: 0, Disabled: false},
 		{Key: "security", Label: labels.Detail.Tabs.Security, Href: base + "?tab=security", HxGet: action + "security", Icon: "icon-shield-check", Count: 0, Disabled: false},
 		{Key: "attachments", Label: labels.Detail.AttachmentsTab, Href: base + "?tab=attachments", HxGet: action + "attachments", Icon: "icon-paperclip", Count: 0, Disabled: false},
 		{Key: "audit-history", Label: func() string {
@@ -293,15 +337,31 @@ func buildTabItems(id string, labels user.Labels, roleCount int, routes user.Rou
 }
 
 func getUserRoles(ctx context.Context, deps *DetailViewDeps, userID string) (int, []string) {
-	if deps.ListWorkspaceUsers == nil || deps.GetWorkspaceUserItemPageData == nil {
+	roles, err := getUserRoleAssignments(ctx, deps, userID)
+	if err != nil {
+		log.Printf("Failed to load role assignments for user %s: %v", userID, err)
 		return 0, nil
 	}
+	names := make([]string, 0, len(roles))
+	for _, wur := range roles {
+		if r := wur.GetRole(); r != nil {
+			names = append(names, r.GetName())
+		}
+	}
+	return len(names), names
+}
+
+// getUserRoleAssignments returns the role assignments of the user's
+// workspace user, none when the user has no workspace user.
+func getUserRoleAssignments(ctx context.Context, deps *DetailViewDeps, userID string) ([]*workspaceuserrolepb.WorkspaceUserRole, error) {
+	if deps.ListWorkspaceUsers == nil || deps.GetWorkspaceUserItemPageData == nil {
+		return nil, nil
+	}
 
 	// Find workspace user for this user ID
 	wuResp, err := deps.ListWorkspaceUsers(ctx, &workspaceuserpb.ListWorkspaceUsersRequest{})
 	if err != nil {
-		log.Printf("Failed to list workspace users: %v", err)
-		return 0, nil
+		return nil, fmt.Errorf("failed to list workspace users: %w", err)
 	}
 
 	var wuID string
@@ -312,25 +372,16 @@ func getUserRoles(ctx context.Context, deps *DetailViewDeps, userID string) (int
 		}
 	}
 	if wuID == "" {
-		return 0, nil
+		return nil, nil
 	}
 
 	itemResp, err := deps.GetWorkspaceUserItemPageData(ctx, &workspaceuserpb.GetWorkspaceUserItemPageDataRequest{
 		WorkspaceUserId: wuID,
 	})
 	if err != nil {
-		log.Printf("Failed to get workspace user item page data: %v", err)
-		return 0, nil
+		return nil, fmt.Errorf("failed to get workspace user item page data: %w", err)
 	}
-
-	roles := itemResp.GetWorkspaceUser().GetWorkspaceUserRoles()
-	names := make([]string, 0, len(roles))
-	for _, wur := range roles {
-		if r := wur.GetRole(); r != nil {
-			names = append(names, r.GetName())
-		}
-	}
-	return len(names), names
+	return itemResp.GetWorkspaceUser().GetWorkspaceUserRoles(), nil
 }
 
 // ---------------------------------------------------------------------------
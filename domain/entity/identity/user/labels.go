@@ -9,6 +9,10 @@ package user
 // rename: UserLabels -> Labels, User<Xxx>Labels -> <Xxx>Labels,
 // UserDashboardLabels -> DashboardLabels, UserRoleLabels -> RoleLabels.
 
+import (
+	permission "github.com/erniealice/entydad-golang/domain/entity/identity/permission"
+)
+
 // Labels holds all translatable strings for the user module.
 // JSON tags match retail/user.json (no wrapper key).
 type Labels struct {
@@ -19,6 +23,9 @@ type Labels struct {
 	Form    FormLabels   `json:"form"`
 	Actions ActionLabels `json:"actions"`
 	Detail  DetailLabels `json:"detail"`
+	// ServiceAccounts is the service accounts page, its add drawer and
+	// the access tokens drawer.
+	ServiceAccounts ServiceAccountLabels `json:"serviceAccounts"`
 }
 
 type PageLabels struct {
@@ -86,6 +93,8 @@ type DetailLabels struct {
 	AttachmentsTab string `json:"attachmentsTab"`
 	// Tab label for audit history
 	AuditHistoryTab string `json:"auditHistoryTab"`
+	// Explain is the access tab's permission explainer.
+	Explain permission.ExplainLabels `json:"explain"`
 }
 
 // DetailSecurityLabels holds labels for the security tab.
@@ -100,6 +109,64 @@ type DetailSecurityLabels struct {
 	AuthMethod        string `json:"authMethod"`
 	ManagedByProvider string `json:"managedByProvider"`
 	ManageAccountLink string `json:"manageAccountLink"`
+	// Impersonate is the "view as this user" section and its reason drawer.
+	Impersonate DetailImpersonateLabels `json:"impersonate"`
+}
+
+// DetailImpersonateLabels holds labels for the security tab's "view as this
+// user" section and the drawer asking for the reason.
+type DetailImpersonateLabels struct {
+	Section          string `json:"section"`
+	Help             string `json:"help"`
+	Button           string `json:"button"`
+	FormTitle        string `json:"formTitle"`
+	FormReason       string `json:"formReason"`
+	FormReasonHint   string `json:"formReasonHint"`
+	FormSubmit       string `json:"formSubmit"`
+	ErrorReason      string `json:"errorReason"`
+	ErrorTarget      string `json:"errorTarget"`
+	ErrorUnavailable string `json:"errorUnavailable"`
+}
+
+// ServiceAccountLabels holds labels for the service accounts page and its
+// drawers.
+type ServiceAccountLabels struct {
+	Heading           string `json:"heading"`
+	Caption           string `json:"caption"`
+	AddButton         string `json:"addButton"`
+	ColumnName        string `json:"columnName"`
+	ColumnDescription string `json:"columnDescription"`
+	ColumnCreated     string `json:"columnCreated"`
+	ColumnStatus      string `json:"columnStatus"`
+	EmptyTitle        string `json:"emptyTitle"`
+	EmptyMessage      string `json:"emptyMessage"`
+	ManageTokens      string `json:"manageTokens"`
+	ManageRoles       string `json:"manageRoles"`
+	FormName          string `json:"formName"`
+	FormDescription   string `json:"formDescription"`
+	FormHint          string `json:"formHint"`
+	FormSubmit        string `json:"formSubmit"`
+	TokensTitle       string `json:"tokensTitle"`
+	TokensHelp        string `json:"tokensHelp"`
+	TokensEmpty       string `json:"tokensEmpty"`
+	TokenName         string `json:"tokenName"`
+	TokenExpiry       string `json:"tokenExpiry"`
+	TokenDays         string `json:"tokenDays"`
+	TokenPermissions  string `json:"tokenPermissions"`
+	TokenIssue        string `json:"tokenIssue"`
+	TokenRevoke       string `json:"tokenRevoke"`
+	TokenSecretHelp   string `json:"tokenSecretHelp"`
+	TokenCreated      string `json:"tokenCreated"`
+	TokenExpires      string `json:"tokenExpires"`
+	TokenLastUsed     string `json:"tokenLastUsed"`
+	TokenRevoked      string `json:"tokenRevoked"`
+	TokenExpired      string `json:"tokenExpired"`
+	NoScopes          string `json:"noScopes"`
+	ErrorName         string `json:"errorName"`
+	ErrorTokenRequest string `json:"errorTokenRequest"`
+	ErrorTokenScope   string `json:"errorTokenScope"`
+	ErrorNotFound     string `json:"errorNotFound"`
+	ErrorUnavailable  string `json:"errorUnavailable"`
 }
 
 // DetailEmptyStateLabels holds empty-state labels for user detail tabs.
@@ -0,0 +1,73 @@
+package user
+
+import "github.com/erniealice/entydad-golang/domain/entity/identity/permission"
+
+// DefaultLabels returns Labels seeded with the English defaults below, for
+// the host's lyngua files to overlay.
+func DefaultLabels() Labels {
+	var l Labels
+	l.Detail.Security.Impersonate = DefaultImpersonateLabels()
+	l.ServiceAccounts = DefaultServiceAccountLabels()
+	l.Detail.Explain = permission.DefaultExplainLabels()
+	return l
+}
+
+// DefaultImpersonateLabels returns DetailImpersonateLabels populated with
+// English defaults.
+func DefaultImpersonateLabels() DetailImpersonateLabels {
+	return DetailImpersonateLabels{
+		Section:          "View as this user",
+		Help:             "See the app exactly as this user does. The session is recorded in the audit log and ends automatically.",
+		Button:           "View as user",
+		FormTitle:        "View as {user}",
+		FormReason:       "Reason",
+		FormReasonHint:   "Recorded in the audit log, e.g. the support ticket you are working on.",
+		FormSubmit:       "Start viewing as user",
+		ErrorReason:      "Enter the reason for viewing as this user.",
+		ErrorTarget:      "You can't view the app as this user in this workspace.",
+		ErrorUnavailable: "Viewing as this user is not available right now. Try again later.",
+	}
+}
+
+// DefaultServiceAccountLabels returns ServiceAccountLabels populated with
+// English defaults.
+func DefaultServiceAccountLabels() ServiceAccountLabels {
+	return ServiceAccountLabels{
+		Heading:           "Service accounts",
+		Caption:           "Non-human users for integrations. They sign in with access tokens, never with a password.",
+		AddButton:         "Add service account",
+		ColumnName:        "Name",
+		ColumnDescription: "Description",
+		ColumnCreated:     "Created",
+		ColumnStatus:      "Status",
+		EmptyTitle:        "No service accounts",
+		EmptyMessage:      "Add one for each integration that calls the app.",
+		ManageTokens:      "Access tokens",
+		ManageRoles:       "Roles",
+		FormName:          "Name",
+		FormDescription:   "Description",
+		FormHint:          "What the integration does, and who owns it.",
+		FormSubmit:        "Add service account",
+		TokensTitle:       "Access tokens",
+		TokensHelp:        "Tokens authenticate as this service account with an Authorization: Bearer header, in this workspace and with the permissions ticked.",
+		TokensEmpty:       "No tokens yet.",
+		TokenName:         "Token name",
+		TokenExpiry:       "Expires in",
+		TokenDays:         "days",
+		TokenPermissions:  "Permissions",
+		TokenIssue:        "Create token",
+		TokenRevoke:       "Revoke",
+		TokenSecretHelp:   "Copy this token now. It is not stored and won't be shown again.",
+		TokenCreated:      "Created",
+		TokenExpires:      "expires",
+		TokenLastUsed:     "last used",
+		TokenRevoked:      "Revoked",
+		TokenExpired:      "Expired",
+		NoScopes:          "Assign roles to this service account before creating a token.",
+		ErrorName:         "Enter a name for the service account.",
+		ErrorTokenRequest: "Enter a token name and tick at least one permission.",
+		ErrorTokenScope:   "You can only grant permissions that both you and the service account hold.",
+		ErrorNotFound:     "That service account or token no longer exists.",
+		ErrorUnavailable:  "Service accounts are not available right now. Try again later.",
+	}
+}
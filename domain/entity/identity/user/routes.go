@@ -32,6 +32,15 @@ const (
 	AttachmentUploadURL = "/action/user/{id}/attachments/upload"
 	AttachmentDeleteURL = "/action/user/{id}/attachments/delete"
 	ResetPasswordURL    = "/action/user/reset-password/{id}"
+	ImpersonateURL      = "/action/user/impersonate/{id}"
+	ExplainURL          = "/action/user/{id}/permissions/explain"
+
+	// Service accounts and their access tokens
+	ServiceAccountsURL           = "/users/service-accounts"
+	ServiceAccountsTableURL      = "/action/user/service-accounts/table"
+	ServiceAccountAddURL         = "/action/user/service-accounts/add"
+	ServiceAccountTokensURL      = "/action/user/service-accounts/tokens/{id}"
+	ServiceAccountTokenRevokeURL = "/action/user/service-accounts/revoke-token/{id}"
 
 	// Legacy /manage/ user-roles routes
 	RolesURL       = "/manage/users/{id}/roles"
@@ -60,6 +69,16 @@ type Routes struct {
 	DetailURL        string `json:"detail_url"`
 	TabActionURL     string `json:"tab_action_url"`
 	ResetPasswordURL string `json:"reset_password_url"`
+	ImpersonateURL   string `json:"impersonate_url"`
+	// ExplainURL serves the access tab's permission trace as plain text.
+	ExplainURL string `json:"explain_url"`
+
+	// Service account routes
+	ServiceAccountsURL           string `json:"service_accounts_url"`
+	ServiceAccountsTableURL      string `json:"service_accounts_table_url"`
+	ServiceAccountAddURL         string `json:"service_account_add_url"`
+	ServiceAccountTokensURL      string `json:"service_account_tokens_url"`
+	ServiceAccountTokenRevokeURL string `json:"service_account_token_revoke_url"`
 
 	// Timezone autocomplete search endpoint (returns JSON [{value,label}, ...])
 	SearchTimezonesURL string `json:"search_timezones_url"`
@@ -101,6 +120,14 @@ func DefaultRoutes() Routes {
 		DetailURL:        DetailURL,
 		TabActionURL:     TabActionURL,
 		ResetPasswordURL: ResetPasswordURL,
+		ImpersonateURL:   ImpersonateURL,
+		ExplainURL:       ExplainURL,
+
+		ServiceAccountsURL:           ServiceAccountsURL,
+		ServiceAccountsTableURL:      ServiceAccountsTableURL,
+		ServiceAccountAddURL:         ServiceAccountAddURL,
+		ServiceAccountTokensURL:      ServiceAccountTokensURL,
+		ServiceAccountTokenRevokeURL: ServiceAccountTokenRevokeURL,
 
 		SearchTimezonesURL: SearchTimezonesURL,
 
@@ -139,6 +166,14 @@ func (r Routes) RouteMap() map[string]string {
 		"user.bulk_set_status": r.BulkSetStatusURL,
 		"user.detail":          r.DetailURL,
 		"user.tab_action":      r.TabActionURL,
+		"user.impersonate":     r.ImpersonateURL,
+		"user.explain":         r.ExplainURL,
+
+		"user.service_accounts":              r.ServiceAccountsURL,
+		"user.service_accounts.table":        r.ServiceAccountsTableURL,
+		"user.service_account.add":           r.ServiceAccountAddURL,
+		"user.service_account.tokens":        r.ServiceAccountTokensURL,
+		"user.service_account.tokens.revoke": r.ServiceAccountTokenRevokeURL,
 
 		"user.search_timezones": r.SearchTimezonesURL,
 
@@ -0,0 +1,264 @@
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/route"
+	"github.com/erniealice/pyeza-golang/view"
+
+	user "github.com/erniealice/entydad-golang/domain/entity/identity/user"
+)
+
+// tokenExpiryDays are the lifetimes the issue form offers; the first is the
+// default.
+var tokenExpiryDays = []int{90, 30, 365}
+
+// FormData is the data for the "service-account-drawer-form" drawer.
+type FormData struct {
+	FormAction  string
+	WorkspaceID string
+	Labels      user.ServiceAccountLabels
+}
+
+// TokensData is the data for the "service-account-tokens" drawer.
+type TokensData struct {
+	FormAction   string
+	RevokeAction string
+	WorkspaceID  string
+	Account      ServiceAccount
+	Tokens       []TokenItem
+	Scopes       []string
+	ExpiryDays   []int
+	Secret       string // the just-issued token, shown once
+	Error        string
+	Labels       user.ServiceAccountLabels
+}
+
+// TokenItem is one row of the tokens drawer.
+type TokenItem struct {
+	Token
+	Created  string
+	Expires  string
+	LastUsed string
+	Status   string // revoked or expired label; empty while active
+}
+
+// NewAddAction creates the add service account action (GET = form,
+// POST = create). Requires user:create.
+func NewAddAction(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		if !view.GetUserPermissions(ctx).Can("user", "create") {
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+		l := deps.Labels
+		actor, ok := identity.FromContext(ctx)
+		if !ok || actor == nil || actor.WorkspaceID == "" {
+			return view.HTMXError(l.ErrorUnavailable)
+		}
+
+		if viewCtx.Request.Method == http.MethodGet {
+			return view.OK("service-account-drawer-form", &FormData{
+				FormAction:  deps.Routes.ServiceAccountAddURL,
+				WorkspaceID: actor.WorkspaceID,
+				Labels:      l,
+			})
+		}
+
+		// POST — create service account
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		name := strings.TrimSpace(viewCtx.Request.FormValue("name"))
+		if name == "" {
+			return view.HTMXError(l.ErrorName)
+		}
+		if _, err := deps.CreateServiceAccount(ctx, CreateInput{
+			Name:        name,
+			Description: strings.TrimSpace(viewCtx.Request.FormValue("description")),
+			WorkspaceID: actor.WorkspaceID,
+			CreatedBy:   actor.UserID,
+		}); err != nil {
+			log.Printf("Failed to create service account %q: %v", name, err)
+			return view.HTMXError(l.ErrorUnavailable)
+		}
+		return view.HTMXSuccess(tableID)
+	})
+}
+
+// NewTokensAction creates the service account tokens action (GET = list
+// and issue form, POST = issue a token and show its secret once). The id
+// must be one of the workspace's service accounts, so the drawer can never
+// mint tokens for a person. Requires user:update.
+func NewTokensAction(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		account, actor, errMsg := tokensPreamble(ctx, viewCtx, deps)
+		if errMsg != "" {
+			return view.HTMXError(errMsg)
+		}
+		l := deps.Labels
+
+		if viewCtx.Request.Method == http.MethodGet {
+			return renderTokens(ctx, deps, account, actor.WorkspaceID, "", "")
+		}
+
+		// POST — issue a token
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		days := tokenExpiryDays[0]
+		if v, err := strconv.Atoi(viewCtx.Request.FormValue("expires_in_days")); err == nil && validExpiryDays(v) {
+			days = v
+		}
+		secret, err := deps.IssueToken(ctx, TokenInput{
+			ServiceAccountID: account.ID,
+			WorkspaceID:      actor.WorkspaceID,
+			Name:             strings.TrimSpace(viewCtx.Request.FormValue("name")),
+			Permissions:      viewCtx.Request.Form["permissions"],
+			ExpiresAt:        time.Now().AddDate(0, 0, days),
+			IssuedBy:         actor.UserID,
+		})
+		if err != nil {
+			return renderTokens(ctx, deps, account, actor.WorkspaceID, "", tokenErrorLabel(l, err, account.ID))
+		}
+		return renderTokens(ctx, deps, account, actor.WorkspaceID, secret, "")
+	})
+}
+
+// NewTokenRevokeAction creates the revoke token action (POST only). It
+// re-renders the tokens drawer. Requires user:update.
+func NewTokenRevokeAction(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		account, actor, errMsg := tokensPreamble(ctx, viewCtx, deps)
+		if errMsg != "" {
+			return view.HTMXError(errMsg)
+		}
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		tokenID := viewCtx.Request.FormValue("token_id")
+		if tokenID == "" {
+			return view.HTMXError(viewCtx.T("shared.errors.idRequired"))
+		}
+		if err := deps.RevokeToken(ctx, account.ID, tokenID); err != nil {
+			return renderTokens(ctx, deps, account, actor.WorkspaceID, "", tokenErrorLabel(deps.Labels, err, account.ID))
+		}
+		return renderTokens(ctx, deps, account, actor.WorkspaceID, "", "")
+	})
+}
+
+// tokensPreamble gates the token actions and resolves the path id to one of
+// the current workspace's service accounts. A non-empty message is the
+// error to answer with.
+func tokensPreamble(ctx context.Context, viewCtx *view.ViewContext, deps *Deps) (ServiceAccount, *identity.RequestIdentity, string) {
+	fail := func(msg string) (ServiceAccount, *identity.RequestIdentity, string) {
+		return ServiceAccount{}, nil, msg
+	}
+	if !view.GetUserPermissions(ctx).Can("user", "update") {
+		return fail(viewCtx.T("shared.errors.permissionDenied"))
+	}
+	id := viewCtx.Request.PathValue("id")
+	if id == "" {
+		return fail(viewCtx.T("shared.errors.idRequired"))
+	}
+	l := deps.Labels
+	actor, ok := identity.FromContext(ctx)
+	if !ok || actor == nil || actor.WorkspaceID == "" {
+		return fail(l.ErrorUnavailable)
+	}
+	accounts, err := deps.ListServiceAccounts(ctx, actor.WorkspaceID)
+	if err != nil {
+		log.Printf("Failed to list service accounts: %v", err)
+		return fail(l.ErrorUnavailable)
+	}
+	for _, a := range accounts {
+		if a.ID == id {
+			return a, actor, ""
+		}
+	}
+	return fail(l.ErrorNotFound)
+}
+
+// renderTokens renders the tokens drawer. The permission list offers only
+// codes both the service account and the administrator hold, since
+// issuing refuses anything wider.
+func renderTokens(ctx context.Context, deps *Deps, account ServiceAccount, workspaceID, secret, errMsg string) view.ViewResult {
+	l := deps.Labels
+	data := &TokensData{
+		FormAction:   route.ResolveURL(deps.Routes.ServiceAccountTokensURL, "id", account.ID),
+		RevokeAction: route.ResolveURL(deps.Routes.ServiceAccountTokenRevokeURL, "id", account.ID),
+		WorkspaceID:  workspaceID,
+		Account:      account,
+		ExpiryDays:   tokenExpiryDays,
+		Secret:       secret,
+		Error:        errMsg,
+		Labels:       l,
+	}
+
+	tokens, err := deps.ListTokens(ctx, account.ID)
+	if err != nil {
+		log.Printf("Failed to list tokens of service account %s: %v", account.ID, err)
+		data.Error = l.ErrorUnavailable
+	}
+	now := time.Now()
+	for _, t := range tokens {
+		item := TokenItem{Token: t, Created: formatDate(t.CreatedAt), Expires: formatDate(t.ExpiresAt), LastUsed: formatDate(t.LastUsedAt)}
+		switch {
+		case t.Revoked:
+			item.Status = l.TokenRevoked
+		case !t.ExpiresAt.IsZero() && now.After(t.ExpiresAt):
+			item.Status = l.TokenExpired
+		}
+		data.Tokens = append(data.Tokens, item)
+	}
+
+	if deps.TokenScopes != nil {
+		codes, err := deps.TokenScopes(ctx, account.ID, workspaceID)
+		if err != nil {
+			log.Printf("Failed to read token scopes of service account %s: %v", account.ID, err)
+		}
+		perms := view.GetUserPermissions(ctx)
+		for _, code := range codes {
+			if perms.HasCode(code) {
+				data.Scopes = append(data.Scopes, code)
+			}
+		}
+	}
+	return view.OK("service-account-tokens", data)
+}
+
+// tokenErrorLabel maps a token closure error to the drawer message.
+func tokenErrorLabel(l user.ServiceAccountLabels, err error, id string) string {
+	switch {
+	case errors.Is(err, ErrTokenRequest):
+		return l.ErrorTokenRequest
+	case errors.Is(err, ErrTokenScope):
+		return l.ErrorTokenScope
+	case errors.Is(err, ErrTokenNotFound):
+		return l.ErrorNotFound
+	}
+	log.Printf("Failed to update tokens of service account %s: %v", id, err)
+	return l.ErrorUnavailable
+}
+
+func validExpiryDays(days int) bool {
+	for _, d := range tokenExpiryDays {
+		if d == days {
+			return true
+		}
+	}
+	return false
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02")
+}
@@ -0,0 +1,168 @@
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	user "github.com/erniealice/entydad-golang/domain/entity/identity/user"
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+func TestNewTokensAction(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name            string
+		perms           []string
+		pathID          string
+		method          string
+		form            url.Values
+		issueErr        error
+		wantTemplate    string
+		wantErrorHeader string
+		wantSecret      string
+		wantError       string
+		wantScopes      []string
+		wantCalls       int
+	}{
+		{
+			name:            "missing user:update",
+			perms:           []string{"user:list"},
+			pathID:          "sa-1",
+			method:          http.MethodGet,
+			wantErrorHeader: "permission denied",
+		},
+		{
+			name:            "a person is not a service account",
+			perms:           []string{"user:update"},
+			pathID:          "user-2",
+			method:          http.MethodPost,
+			form:            url.Values{"name": {"ci"}, "permissions": {"user:list"}},
+			wantErrorHeader: "That service account or token no longer exists.",
+		},
+		{
+			name:         "GET offers only codes the administrator also holds",
+			perms:        []string{"user:update", "user:list"},
+			pathID:       "sa-1",
+			method:       http.MethodGet,
+			wantTemplate: "service-account-tokens",
+			wantScopes:   []string{"user:list"},
+		},
+		{
+			name:         "POST shows the secret once",
+			perms:        []string{"user:update", "user:list"},
+			pathID:       "sa-1",
+			method:       http.MethodPost,
+			form:         url.Values{"name": {" ci "}, "permissions": {"user:list"}, "expires_in_days": {"30"}},
+			wantTemplate: "service-account-tokens",
+			wantSecret:   "pat_secret",
+			wantScopes:   []string{"user:list"},
+			wantCalls:    1,
+		},
+		{
+			name:         "scope wider than held",
+			perms:        []string{"user:update", "user:list"},
+			pathID:       "sa-1",
+			method:       http.MethodPost,
+			form:         url.Values{"name": {"ci"}, "permissions": {"user:delete"}},
+			issueErr:     fmt.Errorf("issue: %w", ErrTokenScope),
+			wantTemplate: "service-account-tokens",
+			wantError:    "You can only grant permissions that both you and the service account hold.",
+			wantScopes:   []string{"user:list"},
+			wantCalls:    1,
+		},
+		{
+			name:         "token service failure",
+			perms:        []string{"user:update", "user:list"},
+			pathID:       "sa-1",
+			method:       http.MethodPost,
+			form:         url.Values{"name": {"ci"}, "permissions": {"user:list"}},
+			issueErr:     errors.New("store down"),
+			wantTemplate: "service-account-tokens",
+			wantError:    "Service accounts are not available right now. Try again later.",
+			wantScopes:   []string{"user:list"},
+			wantCalls:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			var calls []TokenInput
+			deps := &Deps{
+				Routes: user.DefaultRoutes(),
+				Labels: user.DefaultServiceAccountLabels(),
+				ListServiceAccounts: func(_ context.Context, workspaceID string) ([]ServiceAccount, error) {
+					if workspaceID != "ws-1" {
+						t.Errorf("ListServiceAccounts workspace = %q, want ws-1", workspaceID)
+					}
+					return []ServiceAccount{{ID: "sa-1", Name: "CI", Active: true}}, nil
+				},
+				ListTokens: func(context.Context, string) ([]Token, error) { return nil, nil },
+				IssueToken: func(_ context.Context, in TokenInput) (string, error) {
+					calls = append(calls, in)
+					if tt.issueErr != nil {
+						return "", tt.issueErr
+					}
+					return "pat_secret", nil
+				},
+				RevokeToken: func(context.Context, string, string) error { return nil },
+				TokenScopes: func(context.Context, string, string) ([]string, error) {
+					return []string{"user:delete", "user:list"}, nil
+				},
+			}
+
+			req := httptest.NewRequest(tt.method, "/action/user/service-accounts/tokens/"+tt.pathID, strings.NewReader(tt.form.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req.SetPathValue("id", tt.pathID)
+			ctx := view.WithUserPermissions(context.Background(), types.NewUserPermissions(tt.perms))
+			ctx = identity.WithRequestIdentity(ctx, &identity.RequestIdentity{UserID: "admin", WorkspaceID: "ws-1"})
+
+			res := NewTokensAction(deps).Handle(ctx, &view.ViewContext{
+				Request: req,
+				Messages: map[string]string{
+					"shared.errors.permissionDenied": "permission denied",
+				},
+			})
+
+			if got := res.Headers["HX-Error-Message"]; got != tt.wantErrorHeader {
+				t.Fatalf("HX-Error-Message = %q, want %q", got, tt.wantErrorHeader)
+			}
+			if res.Template != tt.wantTemplate {
+				t.Fatalf("Template = %q, want %q", res.Template, tt.wantTemplate)
+			}
+			if len(calls) != tt.wantCalls {
+				t.Fatalf("IssueToken calls = %d, want %d", len(calls), tt.wantCalls)
+			}
+			if tt.wantSecret != "" {
+				in := calls[0]
+				if in.ServiceAccountID != "sa-1" || in.WorkspaceID != "ws-1" || in.IssuedBy != "admin" || in.Name != "ci" {
+					t.Fatalf("IssueToken input = %+v", in)
+				}
+			}
+			if tt.wantTemplate == "" {
+				return
+			}
+			data, ok := res.Data.(*TokensData)
+			if !ok {
+				t.Fatalf("Data = %T, want *TokensData", res.Data)
+			}
+			if data.Secret != tt.wantSecret || data.Error != tt.wantError {
+				t.Fatalf("Secret = %q, Error = %q", data.Secret, data.Error)
+			}
+			if strings.Join(data.Scopes, ",") != strings.Join(tt.wantScopes, ",") {
+				t.Fatalf("Scopes = %v, want %v", data.Scopes, tt.wantScopes)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package serviceaccount
+
+import (
+	"context"
+	"log"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/route"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	user "github.com/erniealice/entydad-golang/domain/entity/identity/user"
+)
+
+// tableID is the service accounts table, refreshed after an add.
+const tableID = "service-accounts-table"
+
+// PageData holds the data for the service accounts page.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Table           *types.TableConfig
+}
+
+// NewView creates the service accounts view (full page). Requires
+// user:list.
+func NewView(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		if !view.GetUserPermissions(ctx).Can("user", "list") {
+			return view.Forbidden("user:list")
+		}
+		l := deps.Labels
+		tableConfig, err := buildTableConfig(ctx, deps, l)
+		if err != nil {
+			return view.Error(err)
+		}
+
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion:   viewCtx.CacheVersion,
+				Title:          l.Heading,
+				CurrentPath:    viewCtx.CurrentPath,
+				ActiveNav:      "user",
+				ActiveSubNav:   "users-service-accounts",
+				HeaderTitle:    l.Heading,
+				HeaderSubtitle: l.Caption,
+				HeaderIcon:     "icon-users",
+				CommonLabels:   deps.CommonLabels,
+			},
+			ContentTemplate: "service-accounts-content",
+			Table:           tableConfig,
+		}
+		return view.OK("service-accounts", pageData)
+	})
+}
+
+// NewTableView creates a view that returns only the table-card HTML.
+func NewTableView(deps *Deps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		if !view.GetUserPermissions(ctx).Can("user", "list") {
+			return view.Forbidden("user:list")
+		}
+		tableConfig, err := buildTableConfig(ctx, deps, deps.Labels)
+		if err != nil {
+			return view.Error(err)
+		}
+		return view.OK("table-card", tableConfig)
+	})
+}
+
+func buildTableConfig(ctx context.Context, deps *Deps, l user.ServiceAccountLabels) (*types.TableConfig, error) {
+	perms := view.GetUserPermissions(ctx)
+	accounts, err := deps.ListServiceAccounts(ctx, currentWorkspace(ctx))
+	if err != nil {
+		log.Printf("Failed to list service accounts: %v", err)
+		return nil, err
+	}
+
+	columns := []types.TableColumn{
+		{Key: "name", Label: l.ColumnName},
+		{Key: "description", Label: l.ColumnDescription},
+		{Key: "dateCreated", Label: l.ColumnCreated, WidthClass: "col-6xl"},
+		{Key: "status", Label: l.ColumnStatus, WidthClass: "col-2xl"},
+	}
+	rows := make([]types.TableRow, 0, len(accounts))
+	for _, a := range accounts {
+		status, variant := "active", "success"
+		if !a.Active {
+			status, variant = "inactive", "warning"
+		}
+		created := ""
+		if !a.CreatedAt.IsZero() {
+			created = a.CreatedAt.Format("2006-01-02 15:04")
+		}
+		rows = append(rows, types.TableRow{
+			ID: a.ID,
+			Cells: []types.TableCell{
+				{Type: "text", Value: a.Name},
+				{Type: "text", Value: a.Description},
+				{Type: "text", Value: created},
+				{Type: "badge", Value: status, Variant: variant},
+			},
+			DataAttrs: map[string]string{
+				"testid":      "service-account-row-" + a.ID,
+				"name":        a.Name,
+				"description": a.Description,
+				"status":      status,
+			},
+			Actions: []types.TableAction{
+				{Type: "edit", Label: l.ManageTokens, Action: "edit", TestID: "service-account-tokens-" + a.ID,
+					URL: route.ResolveURL(deps.Routes.ServiceAccountTokensURL, "id", a.ID), DrawerTitle: l.TokensTitle,
+					Disabled: !perms.Can("user", "update"), DisabledTooltip: deps.SharedLabels.Badges.NoPermission},
+				{Type: "manage", Label: l.ManageRoles, Action: "manage",
+					Href: route.ResolveURL(deps.Routes.DetailRolesURL, "id", a.ID)},
+			},
+		})
+	}
+	types.ApplyColumnStyles(columns, rows)
+
+	tableConfig := &types.TableConfig{
+		ID:                   tableID,
+		RefreshURL:           deps.Routes.ServiceAccountsTableURL,
+		Columns:              columns,
+		Rows:                 rows,
+		ShowSearch:           true,
+		ShowActions:          true,
+		ShowSort:             true,
+		ShowColumns:          true,
+		ShowDensity:          true,
+		ShowEntries:          true,
+		DefaultSortColumn:    "name",
+		DefaultSortDirection: "asc",
+		Labels:               deps.TableLabels,
+		EmptyState: types.TableEmptyState{
+			Title:   l.EmptyTitle,
+			Message: l.EmptyMessage,
+		},
+	}
+	if deps.CreateServiceAccount != nil {
+		tableConfig.PrimaryAction = &types.PrimaryAction{
+			Label:     l.AddButton,
+			ActionURL: deps.Routes.ServiceAccountAddURL,
+			Icon:      "icon-plus",
+			Disabled:  !perms.Can("user", "create"), DisabledTooltip: deps.SharedLabels.Badges.NoPermission,
+		}
+	}
+	types.ApplyTableSettings(tableConfig)
+	return tableConfig, nil
+}
+
+func currentWorkspace(ctx context.Context) string {
+	if id, ok := identity.FromContext(ctx); ok && id != nil {
+		return id.WorkspaceID
+	}
+	return ""
+}
@@ -0,0 +1,108 @@
+// Package serviceaccount manages service accounts: non-human users that
+// integrations run as instead of borrowing a person's session. A service
+// account is a user with no password and no interactive sign-in; it holds
+// roles like any other user (assigned on its roles page) and authenticates
+// with access tokens that an administrator issues here.
+//
+// Storage and token minting are injected closures — typically the host's
+// user use cases and the auth service's access token methods
+// (auth.AuthModule.IssueAccessToken, ...), adapted by the composition layer
+// so this package does not depend on the auth service.
+package serviceaccount
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/types"
+
+	"github.com/erniealice/entydad-golang"
+	user "github.com/erniealice/entydad-golang/domain/entity/identity/user"
+)
+
+// ServiceAccount is one service account as the list shows it.
+type ServiceAccount struct {
+	ID          string // the service account's user ID
+	Name        string
+	Description string
+	Active      bool
+	CreatedAt   time.Time
+}
+
+// CreateInput is what the add drawer submits. The workspace and the
+// creating administrator come from the request identity.
+type CreateInput struct {
+	Name        string
+	Description string
+	WorkspaceID string
+	CreatedBy   string
+}
+
+// Token is one of a service account's access tokens. Hint is the first
+// characters of the secret, which itself is never stored.
+type Token struct {
+	ID          string
+	Name        string
+	Hint        string
+	Permissions []string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	LastUsedAt  time.Time
+	Revoked     bool
+}
+
+// TokenInput is what the issue form submits. IssuedBy is the administrator;
+// the token's permissions must be held by both the service account and the
+// administrator in WorkspaceID.
+type TokenInput struct {
+	ServiceAccountID string
+	WorkspaceID      string
+	Name             string
+	Permissions      []string
+	ExpiresAt        time.Time
+	IssuedBy         string
+}
+
+// Service account closures.
+type (
+	// ListServiceAccounts returns the service accounts of workspaceID.
+	ListServiceAccounts func(ctx context.Context, workspaceID string) ([]ServiceAccount, error)
+	// CreateServiceAccount creates the user (no password, a non-deliverable
+	// email address) and binds it to the workspace.
+	CreateServiceAccount func(ctx context.Context, in CreateInput) (ServiceAccount, error)
+	// ListTokens returns a service account's tokens, newest first.
+	ListTokens func(ctx context.Context, serviceAccountID string) ([]Token, error)
+	// IssueToken mints a token and returns its secret, shown once.
+	IssueToken func(ctx context.Context, in TokenInput) (secret string, err error)
+	// RevokeToken revokes one of a service account's tokens.
+	RevokeToken func(ctx context.Context, serviceAccountID, tokenID string) error
+	// TokenScopes returns the permission codes a service account holds in
+	// workspaceID — what its tokens may carry.
+	TokenScopes func(ctx context.Context, serviceAccountID, workspaceID string) ([]string, error)
+)
+
+// Errors the token closures return (or wrap) so the drawer can show a
+// specific message instead of the generic one.
+var (
+	ErrTokenRequest  = errors.New("serviceaccount: a token needs a name and at least one permission")
+	ErrTokenScope    = errors.New("serviceaccount: token permissions exceed what is held")
+	ErrTokenNotFound = errors.New("serviceaccount: token not found")
+)
+
+// Deps holds view dependencies for the service account pages.
+type Deps struct {
+	Routes       user.Routes
+	Labels       user.ServiceAccountLabels
+	SharedLabels entydad.SharedLabels
+	CommonLabels pyeza.CommonLabels
+	TableLabels  types.TableLabels
+
+	ListServiceAccounts  ListServiceAccounts
+	CreateServiceAccount CreateServiceAccount
+	ListTokens           ListTokens
+	IssueToken           IssueToken
+	RevokeToken          RevokeToken
+	TokenScopes          TokenScopes
+}
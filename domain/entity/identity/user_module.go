@@ -15,7 +15,9 @@ import (
 	userdetail "github.com/erniealice/entydad-golang/domain/entity/identity/user/detail"
 	userlist "github.com/erniealice/entydad-golang/domain/entity/identity/user/list"
 	userroles "github.com/erniealice/entydad-golang/domain/entity/identity/user/roles"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/user/serviceaccount"
 	attachmentpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/document/attachment"
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
 	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
 	userpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/user"
 	workspaceuserpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user"
@@ -51,6 +53,28 @@ type UserModuleDeps struct {
 	// GetUserAuthCapability reports the user's sign-in methods (WS-4). Optional/
 	// nil-safe: nil => treat as local-managed (reset form stays visible).
 	GetUserAuthCapability func(ctx context.Context, userID string) (bool, []string, error)
+	// Password policy for the add / edit / reset-password actions — typically
+	// auth.PasswordPolicy.Check and .Remember from the host's auth Deps, so the
+	// admin paths enforce the same rules as signup and change-password.
+	// Optional/nil-safe.
+	CheckPassword    func(ctx context.Context, userID, email, password string) string
+	RememberPassword func(ctx context.Context, userID, password string)
+	// StartImpersonation begins an audited "view as this user" session from
+	// the detail page's security tab — typically an adapter over
+	// auth.AuthModule.ImpersonationURL. Optional/nil-safe: nil => no
+	// impersonation action or route.
+	StartImpersonation userdetail.StartImpersonation
+	// Service accounts — non-human users with access tokens. Storage is
+	// typically the host's user use cases; the token closures adapt
+	// auth.AuthModule.IssueAccessToken, ListAccessTokens, RevokeAccessToken
+	// and AccessTokenScopes. Optional/nil-safe: ListServiceAccounts nil =>
+	// no service accounts page or routes.
+	ListServiceAccounts       serviceaccount.ListServiceAccounts
+	CreateServiceAccount      serviceaccount.CreateServiceAccount
+	ListServiceAccountTokens  serviceaccount.ListTokens
+	IssueServiceAccountToken  serviceaccount.IssueToken
+	RevokeServiceAccountToken serviceaccount.RevokeToken
+	ServiceAccountTokenScopes serviceaccount.TokenScopes
 	// Workspace user (for user creation + detail)
 	CreateWorkspaceUser          func(ctx context.Context, req *workspaceuserpb.CreateWorkspaceUserRequest) (*workspaceuserpb.CreateWorkspaceUserResponse, error)
 	ListWorkspaceUsers           func(ctx context.Context, req *workspaceuserpb.ListWorkspaceUsersRequest) (*workspaceuserpb.ListWorkspaceUsersResponse, error)
@@ -60,6 +84,14 @@ type UserModuleDeps struct {
 	CreateWorkspaceUserRole func(ctx context.Context, req *workspaceuserrolepb.CreateWorkspaceUserRoleRequest) (*workspaceuserrolepb.CreateWorkspaceUserRoleResponse, error)
 	DeleteWorkspaceUserRole func(ctx context.Context, req *workspaceuserrolepb.DeleteWorkspaceUserRoleRequest) (*workspaceuserrolepb.DeleteWorkspaceUserRoleResponse, error)
 	ListRoles               func(ctx context.Context, req *rolepb.ListRolesRequest) (*rolepb.ListRolesResponse, error)
+	// GetRoleItemPageData loads each assigned role with its permissions for
+	// the detail page's access tab (permission explainer) — the role use
+	// cases' GetRoleItemPageData. Optional/nil-safe: nil => the tab explains
+	// from the roles as GetWorkspaceUserItemPageData returns them.
+	GetRoleItemPageData func(ctx context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error)
+	// ListPermissions gives the access tab the permission catalog, so its
+	// verdict matches the runtime permission set. Optional/nil-safe.
+	ListPermissions func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
 	// Dashboard
 	GetDashboardData func(ctx context.Context) (*userdashboard.DashboardData, error)
 	// Password hashing (optional)
@@ -91,6 +123,15 @@ type UserModule struct {
 	SetStatus     view.View
 	BulkSetStatus view.View
 	ResetPassword view.View
+	// Impersonate is nil unless deps.StartImpersonation is wired.
+	Impersonate view.View
+	// Service account views are nil unless deps.ListServiceAccounts is
+	// wired; the add and token views also need their closures.
+	ServiceAccounts           view.View
+	ServiceAccountsTable      view.View
+	ServiceAccountAdd         view.View
+	ServiceAccountTokens      view.View
+	ServiceAccountTokenRevoke view.View
 	// User-Role assignment views (detail + legacy paths)
 	RoleList         view.View
 	RoleTable        view.View
@@ -100,6 +141,8 @@ type UserModule struct {
 	AttachmentDelete view.View
 	// SearchTimezones is a JSON endpoint backing the timezone autocomplete in the user drawer form.
 	SearchTimezones http.HandlerFunc
+	// ExplainText serves the access tab's permission trace as plain text.
+	ExplainText http.HandlerFunc
 }
 
 func NewUserModule(deps *UserModuleDeps) *UserModule {
@@ -117,6 +160,8 @@ func NewUserModule(deps *UserModuleDeps) *UserModule {
 		EnableUser:            deps.EnableUser,
 		AdminResetPassword:    deps.AdminResetPassword,
 		GetUserAuthCapability: deps.GetUserAuthCapability,
+		CheckPassword:         deps.CheckPassword,
+		RememberPassword:      deps.RememberPassword,
 	}
 	listDeps := &userlist.ListViewDeps{
 		Routes:               deps.Routes,
@@ -139,6 +184,9 @@ func NewUserModule(deps *UserModuleDeps) *UserModule {
 		CommonLabels:                 deps.CommonLabels,
 		TableLabels:                  deps.TableLabels,
 		GetUserAuthCapability:        deps.GetUserAuthCapability,
+		StartImpersonation:           deps.StartImpersonation,
+		GetRoleItemPageData:          deps.GetRoleItemPageData,
+		ListPermissions:              deps.ListPermissions,
 		AttachmentOps: attachment.AttachmentOps{
 			UploadFile:       deps.UploadFile,
 			ListAttachments:  deps.ListAttachments,
@@ -172,7 +220,12 @@ func NewUserModule(deps *UserModuleDeps) *UserModule {
 		Labels:                       deps.UserRoleLabels,
 	}
 
-	return &UserModule{
+	var impersonate view.View
+	if deps.StartImpersonation != nil {
+		impersonate = userdetail.NewImpersonateAction(detailDeps)
+	}
+
+	m := &UserModule{
 		routes: deps.Routes,
 		Dashboard: userdashboard.NewView(&userdashboard.Deps{
 			DashboardLabels:  deps.DashboardTitleLabels,
@@ -192,6 +245,7 @@ func NewUserModule(deps *UserModuleDeps) *UserModule {
 		SetStatus:        useraction.NewSetStatusAction(actionDeps),
 		BulkSetStatus:    useraction.NewBulkSetStatusAction(actionDeps),
 		ResetPassword:    useraction.NewResetPasswordAction(actionDeps),
+		Impersonate:      impersonate,
 		RoleList:         userroles.NewView(roleListDeps),
 		RoleTable:        userroles.NewTableView(roleListDeps),
 		RoleAssign:       userroles.NewAssignAction(roleActionDeps),
@@ -199,7 +253,34 @@ func NewUserModule(deps *UserModuleDeps) *UserModule {
 		AttachmentUpload: userdetail.NewAttachmentUploadAction(detailDeps),
 		AttachmentDelete: userdetail.NewAttachmentDeleteAction(detailDeps),
 		SearchTimezones:  useraction.NewSearchTimezonesAction(),
+		ExplainText:      userdetail.NewExplainTextHandler(detailDeps),
+	}
+
+	if deps.ListServiceAccounts != nil {
+		saDeps := &serviceaccount.Deps{
+			Routes:               deps.Routes,
+			Labels:               deps.Labels.ServiceAccounts,
+			SharedLabels:         deps.SharedLabels,
+			CommonLabels:         deps.CommonLabels,
+			TableLabels:          deps.TableLabels,
+			ListServiceAccounts:  deps.ListServiceAccounts,
+			CreateServiceAccount: deps.CreateServiceAccount,
+			ListTokens:           deps.ListServiceAccountTokens,
+			IssueToken:           deps.IssueServiceAccountToken,
+			RevokeToken:          deps.RevokeServiceAccountToken,
+			TokenScopes:          deps.ServiceAccountTokenScopes,
+		}
+		m.ServiceAccounts = serviceaccount.NewView(saDeps)
+		m.ServiceAccountsTable = serviceaccount.NewTableView(saDeps)
+		if deps.CreateServiceAccount != nil {
+			m.ServiceAccountAdd = serviceaccount.NewAddAction(saDeps)
+		}
+		if deps.ListServiceAccountTokens != nil && deps.IssueServiceAccountToken != nil && deps.RevokeServiceAccountToken != nil {
+			m.ServiceAccountTokens = serviceaccount.NewTokensAction(saDeps)
+			m.ServiceAccountTokenRevoke = serviceaccount.NewTokenRevokeAction(saDeps)
+		}
 	}
+	return m
 }
 
 func (m *UserModule) RegisterRoutes(r view.RouteRegistrar) {
@@ -217,6 +298,24 @@ func (m *UserModule) RegisterRoutes(r view.RouteRegistrar) {
 	r.POST(m.routes.SetStatusURL, m.SetStatus)
 	r.POST(m.routes.BulkSetStatusURL, m.BulkSetStatus)
 	r.POST(m.routes.ResetPasswordURL, m.ResetPassword)
+	if m.Impersonate != nil {
+		r.GET(m.routes.ImpersonateURL, m.Impersonate)
+		r.POST(m.routes.ImpersonateURL, m.Impersonate)
+	}
+	// Service accounts
+	if m.ServiceAccounts != nil {
+		r.GET(m.routes.ServiceAccountsURL, m.ServiceAccounts)
+		r.GET(m.routes.ServiceAccountsTableURL, m.ServiceAccountsTable)
+	}
+	if m.ServiceAccountAdd != nil {
+		r.GET(m.routes.ServiceAccountAddURL, m.ServiceAccountAdd)
+		r.POST(m.routes.ServiceAccountAddURL, m.ServiceAccountAdd)
+	}
+	if m.ServiceAccountTokens != nil {
+		r.GET(m.routes.ServiceAccountTokensURL, m.ServiceAccountTokens)
+		r.POST(m.routes.ServiceAccountTokensURL, m.ServiceAccountTokens)
+		r.POST(m.routes.ServiceAccountTokenRevokeURL, m.ServiceAccountTokenRevoke)
+	}
 	// User-Role assignment (/detail/ path)
 	r.GET(m.routes.DetailRolesURL, m.RoleList)
 	r.GET(m.routes.DetailRolesTableURL, m.RoleTable)
@@ -237,4 +336,23 @@ func (m *UserModule) RegisterRoutes(r view.RouteRegistrar) {
 	}
 	// Timezone autocomplete JSON endpoint
 	identityHandleFunc(r, "GET", m.routes.SearchTimezonesURL, m.SearchTimezones)
+	// Permission explainer text export
+	if m.routes.ExplainURL != "" {
+		identityHandleFunc(r, "GET", m.routes.ExplainURL, m.ExplainText)
+	}
+}
+
+// SensitiveActions returns the ServeMux patterns of the actions that hand
+// over an account: password resets, role grants, impersonation and service
+// account tokens. Pass
+// them to the auth module's Deps.StepUpActions to require a recent sign-in.
+func (m *UserModule) SensitiveActions() []string {
+	urls := []string{m.routes.ResetPasswordURL, m.routes.DetailRolesAssignURL, m.routes.RolesAssignURL}
+	if m.Impersonate != nil {
+		urls = append(urls, m.routes.ImpersonateURL)
+	}
+	if m.ServiceAccountTokens != nil {
+		urls = append(urls, m.routes.ServiceAccountTokensURL)
+	}
+	return postPatterns(urls...)
 }
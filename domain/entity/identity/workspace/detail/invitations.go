@@ -0,0 +1,388 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/erniealice/pyeza-golang/route"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+	workspace "github.com/erniealice/entydad-golang/domain/entity/identity/workspace"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
+)
+
+// PendingInvitation is one row on the Invitations tab.
+type PendingInvitation struct {
+	ID          string
+	Email       string
+	RoleIDs     []string
+	InviterName string
+	SentAt      time.Time
+	ExpiresAt   time.Time
+}
+
+// InviteInput is what the invite drawer submits. WorkspaceName is resolved
+// server-side for the invitation email.
+type InviteInput struct {
+	WorkspaceID   string
+	WorkspaceName string
+	Email         string
+	RoleIDs       []string
+}
+
+// Errors the invitation closures return (or wrap) so the drawer can show a
+// specific message instead of the generic one.
+var (
+	ErrInvitationEmail   = errors.New("workspace: invalid invitation email")
+	ErrInvitationPending = errors.New("workspace: invitation already pending")
+)
+
+// InvitationOps holds the pending-invitation closures. The invitation store
+// and the mailer live in the auth service; the composition layer adapts them
+// so this package does not depend on it. When ListInvitations is nil the
+// Invitations tab is hidden.
+type InvitationOps struct {
+	ListInvitations func(ctx context.Context, workspaceID string) ([]PendingInvitation, error)
+	InviteUser      func(ctx context.Context, in InviteInput) error
+	// ResendInvitation and RevokeInvitation must only touch invitations of
+	// the given workspace.
+	ResendInvitation func(ctx context.Context, workspaceID, id string) error
+	RevokeInvitation func(ctx context.Context, workspaceID, id string) error
+	// ListRoles supplies role names for the table and the role checkboxes
+	// on the invite drawer.
+	ListRoles func(ctx context.Context, req *rolepb.ListRolesRequest) (*rolepb.ListRolesResponse, error)
+	// GetRoleItemPageData loads a role with its permissions so the drawer
+	// offers only roles the inviter holds. Without it no role is offered.
+	GetRoleItemPageData func(ctx context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error)
+}
+
+// InviteRoleOption is one role checkbox on the invite drawer.
+type InviteRoleOption struct {
+	ID          string
+	Name        string
+	Description string
+}
+
+// InviteFormData is the template data for the invite drawer.
+type InviteFormData struct {
+	FormAction  string
+	WorkspaceID string
+	Labels      workspace.DetailInvitationLabels
+	Roles       []InviteRoleOption
+}
+
+// workspaceRoles returns the active roles assignable in the workspace —
+// its own plus the global ones — sorted by name. With perms set, only the
+// roles within those permissions (see grantableBy) are returned; a role
+// whose permissions cannot be loaded is left out.
+func workspaceRoles(ctx context.Context, deps *DetailViewDeps, workspaceID string, perms *types.UserPermissions) []InviteRoleOption {
+	if deps.ListRoles == nil {
+		return nil
+	}
+	resp, err := deps.ListRoles(ctx, &rolepb.ListRolesRequest{})
+	if err != nil {
+		log.Printf("Failed to list roles for workspace %s: %v", workspaceID, err)
+		return nil
+	}
+	var roles []InviteRoleOption
+	for _, r := range resp.GetData() {
+		if !r.GetActive() || (r.GetWorkspaceId() != "" && r.GetWorkspaceId() != workspaceID) {
+			continue
+		}
+		if perms != nil && !grantableBy(ctx, deps, r, perms) {
+			continue
+		}
+		roles = append(roles, InviteRoleOption{ID: r.GetId(), Name: r.GetName(), Description: r.GetDescription()})
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles
+}
+
+// grantableBy reports whether every permission role allows is one perms
+// holds, so inviting someone with it grants nothing the inviter lacks. A
+// wildcard grant counts only when perms holds the wildcard code itself.
+func grantableBy(ctx context.Context, deps *DetailViewDeps, role *rolepb.Role, perms *types.UserPermissions) bool {
+	if deps.GetRoleItemPageData == nil {
+		return false
+	}
+	resp, err := deps.GetRoleItemPageData(ctx, &rolepb.GetRoleItemPageDataRequest{RoleId: role.GetId()})
+	if err != nil || resp.GetRole() == nil {
+		log.Printf("Failed to load role %s for the invite drawer: %v", role.GetId(), err)
+		return false
+	}
+	for _, g := range effective.FromRoles(resp.GetRole()).Grants() {
+		if g.Active() && !g.Deny && !perms.HasCode(g.Code) {
+			return false
+		}
+	}
+	return true
+}
+
+// buildInvitationsTable lists the workspace's pending invitations.
+func buildInvitationsTable(ctx context.Context, deps *DetailViewDeps, workspaceID string, perms *types.UserPermissions) *types.TableConfig {
+	l := deps.Labels.Detail.Invitations
+	columns := []types.TableColumn{
+		{Key: "email", Label: l.ColumnEmail},
+		{Key: "roles", Label: l.ColumnRoles},
+		{Key: "sent", Label: l.ColumnSent, WidthClass: "col-3xl"},
+		{Key: "expires", Label: l.ColumnExpires, WidthClass: "col-3xl"},
+	}
+
+	roleNames := map[string]string{}
+	for _, r := range workspaceRoles(ctx, deps, workspaceID, nil) {
+		roleNames[r.ID] = r.Name
+	}
+
+	canCreate := perms.Can("workspace_user", "create")
+	canDelete := perms.Can("workspace_user", "delete")
+	resendURL := route.ResolveURL(deps.Routes.InvitationResendURL, "id", workspaceID)
+	revokeURL := route.ResolveURL(deps.Routes.InvitationRevokeURL, "id", workspaceID)
+
+	invitations, err := deps.ListInvitations(ctx, workspaceID)
+	if err != nil {
+		log.Printf("Failed to list invitations for workspace %s: %v", workspaceID, err)
+	}
+	now := time.Now()
+	rows := []types.TableRow{}
+	for _, inv := range invitations {
+		names := make([]string, 0, len(inv.RoleIDs))
+		for _, id := range inv.RoleIDs {
+			if name := roleNames[id]; name != "" {
+				names = append(names, name)
+			} else {
+				names = append(names, id)
+			}
+		}
+		roles := strings.Join(names, ", ")
+		if roles == "" {
+			roles = "—"
+		}
+		expires := types.TableCell{Type: "text", Value: inv.ExpiresAt.Format("2006-01-02 15:04")}
+		if now.After(inv.ExpiresAt) {
+			expires = types.TableCell{Type: "badge", Value: l.Expired, Variant: "warning"}
+		}
+
+		rows = append(rows, types.TableRow{
+			ID: inv.ID,
+			Cells: []types.TableCell{
+				{Type: "text", Value: inv.Email},
+				{Type: "text", Value: roles},
+				{Type: "text", Value: inv.SentAt.Format("2006-01-02 15:04")},
+				expires,
+			},
+			DataAttrs: map[string]string{
+				"email":  inv.Email,
+				"testid": "workspace-invitation-row-" + inv.ID,
+			},
+			Actions: []types.TableAction{
+				{
+					Type:            "mail",
+					Label:           l.Resend,
+					Action:          "send-email",
+					URL:             resendURL,
+					ItemName:        inv.Email,
+					ConfirmTitle:    l.Resend,
+					ConfirmMessage:  l.ResendConfirm,
+					Disabled:        !canCreate,
+					DisabledTooltip: fmt.Sprintf(deps.CommonLabels.Errors.MissingPermission, "workspace_user:create"),
+				},
+				{
+					Type:            "delete",
+					Label:           l.Revoke,
+					Action:          "delete",
+					URL:             revokeURL,
+					ItemName:        inv.Email,
+					ConfirmTitle:    l.Revoke,
+					ConfirmMessage:  l.RevokeConfirm,
+					Disabled:        !canDelete,
+					DisabledTooltip: fmt.Sprintf(deps.CommonLabels.Errors.MissingPermission, "workspace_user:delete"),
+				},
+			},
+		})
+	}
+
+	types.ApplyColumnStyles(columns, rows)
+
+	tc := &types.TableConfig{
+		ID:                   "workspace-invitations-table",
+		RefreshURL:           route.ResolveURL(deps.Routes.InvitationTableURL, "id", workspaceID),
+		Columns:              columns,
+		Rows:                 rows,
+		Labels:               deps.TableLabels,
+		ShowSearch:           false,
+		ShowActions:          true,
+		ShowSort:             false,
+		ShowColumns:          false,
+		ShowDensity:          false,
+		ShowEntries:          false,
+		DefaultSortColumn:    "email",
+		DefaultSortDirection: "asc",
+		EmptyState: types.TableEmptyState{
+			Title:   l.EmptyTitle,
+			Message: l.EmptyMessage,
+		},
+	}
+	if deps.InviteUser != nil {
+		tc.PrimaryAction = &types.PrimaryAction{
+			Label:           l.InviteButton,
+			ActionURL:       route.ResolveURL(deps.Routes.InvitationInviteURL, "id", workspaceID),
+			Icon:            "icon-plus",
+			TestID:          "workspace-invite-btn",
+			Disabled:        !canCreate,
+			DisabledTooltip: fmt.Sprintf(deps.CommonLabels.Errors.MissingPermission, "workspace_user:create"),
+		}
+	}
+	types.ApplyTableSettings(tc)
+	return tc
+}
+
+// NewInvitationTableAction returns the invitations table partial, used to
+// refresh it after an invite, resend or revoke.
+// Route: GET /action/workspace/{id}/invitations/table
+func NewInvitationTableAction(deps *DetailViewDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("workspace", "read") {
+			return view.Forbidden("workspace:read")
+		}
+		id := viewCtx.Request.PathValue("id")
+		return view.OK("table-card", buildInvitationsTable(ctx, deps, id, perms))
+	})
+}
+
+// NewInviteAction creates the invite action (GET = drawer form, POST = send).
+// Route: GET/POST /action/workspace/{id}/invitations/invite
+func NewInviteAction(deps *DetailViewDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("workspace_user", "create") {
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+
+		id := viewCtx.Request.PathValue("id")
+		l := deps.Labels.Detail.Invitations
+		// The inviter can only hand out what they hold themselves.
+		roles := workspaceRoles(ctx, deps, id, perms)
+
+		if viewCtx.Request.Method == http.MethodGet {
+			return view.OK("workspace-invite-form", &InviteFormData{
+				FormAction:  route.ResolveURL(deps.Routes.InvitationInviteURL, "id", id),
+				WorkspaceID: id,
+				Labels:      l,
+				Roles:       roles,
+			})
+		}
+
+		// POST — create and send the invitation
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		email := strings.TrimSpace(viewCtx.Request.FormValue("email"))
+		if email == "" {
+			return view.HTMXError(l.ErrorEmail)
+		}
+
+		// Only roles offered on the form can be granted.
+		offered := make(map[string]bool, len(roles))
+		for _, r := range roles {
+			offered[r.ID] = true
+		}
+		var roleIDs []string
+		for _, roleID := range viewCtx.Request.Form["role_id"] {
+			if !offered[roleID] {
+				return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+			}
+			roleIDs = append(roleIDs, roleID)
+		}
+
+		ws, err := loadWorkspace(ctx, deps, id)
+		if err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.notFound"))
+		}
+
+		err = deps.InviteUser(ctx, InviteInput{
+			WorkspaceID:   id,
+			WorkspaceName: ws.GetName(),
+			Email:         email,
+			RoleIDs:       roleIDs,
+		})
+		switch {
+		case errors.Is(err, ErrInvitationEmail):
+			return view.HTMXError(l.ErrorEmail)
+		case errors.Is(err, ErrInvitationPending):
+			return view.HTMXError(l.ErrorPending)
+		case err != nil:
+			log.Printf("Failed to invite %s to workspace %s: %v", email, id, err)
+			return view.HTMXError(l.ErrorUnavailable)
+		}
+
+		return view.HTMXSuccess("workspace-invitations-table")
+	})
+}
+
+// NewInvitationResendAction re-sends a pending invitation with a fresh link
+// and expiry (POST only).
+// Route: POST /action/workspace/{id}/invitations/resend?id={invitationID}
+func NewInvitationResendAction(deps *DetailViewDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("workspace_user", "create") {
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+
+		workspaceID := viewCtx.Request.PathValue("id")
+		id := invitationIDParam(viewCtx)
+		if id == "" {
+			return view.HTMXError(viewCtx.T("shared.errors.idRequired"))
+		}
+
+		if err := deps.ResendInvitation(ctx, workspaceID, id); err != nil {
+			log.Printf("Failed to resend invitation %s: %v", id, err)
+			return view.HTMXError(deps.Labels.Detail.Invitations.ErrorUnavailable)
+		}
+
+		return view.HTMXSuccess("workspace-invitations-table")
+	})
+}
+
+// NewInvitationRevokeAction deletes a pending invitation; its link stops
+// working (POST only).
+// Route: POST /action/workspace/{id}/invitations/revoke?id={invitationID}
+func NewInvitationRevokeAction(deps *DetailViewDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("workspace_user", "delete") {
+			return view.HTMXError(viewCtx.T("shared.errors.permissionDenied"))
+		}
+
+		workspaceID := viewCtx.Request.PathValue("id")
+		id := invitationIDParam(viewCtx)
+		if id == "" {
+			return view.HTMXError(viewCtx.T("shared.errors.idRequired"))
+		}
+
+		if err := deps.RevokeInvitation(ctx, workspaceID, id); err != nil {
+			log.Printf("Failed to revoke invitation %s: %v", id, err)
+			return view.HTMXError(err.Error())
+		}
+
+		return view.HTMXSuccess("workspace-invitations-table")
+	})
+}
+
+// invitationIDParam reads the invitation id the row action appends to the
+// URL, falling back to a form field.
+func invitationIDParam(viewCtx *view.ViewContext) string {
+	if id := viewCtx.Request.URL.Query().Get("id"); id != "" {
+		return id
+	}
+	return viewCtx.Request.FormValue("id")
+}
@@ -36,6 +36,10 @@ type DetailViewDeps struct {
 	// Attachment operations (embedded from hybra)
 	attachment.AttachmentOps
 
+	// Invitation operations. The Invitations tab is shown only when
+	// ListInvitations is set.
+	InvitationOps
+
 	// TaxRegistrationListURL is the URL of the workspace-scoped tax registrations list
 	// (WorkspaceTaxRegistrationListURL). When set, the Tax Registrations tab is shown
 	// on the workspace detail page. Nil-safe: tab is hidden when empty.
@@ -74,6 +78,8 @@ type PageData struct {
 	AttachmentTable *types.TableConfig
 	// Tax Registrations tab (Phase 2 H1)
 	TaxRegistrationListURL string
+	// Invitations tab
+	InvitationsTable *types.TableConfig
 }
 
 // tabLabels holds the resolved tab display strings, sourced from the lyngua
@@ -83,6 +89,7 @@ type tabLabels struct {
 	Users            string
 	Attachments      string
 	TaxRegistrations string
+	Invitations      string
 }
 
 // resolveTabLabels returns display strings for the tabs.
@@ -105,7 +112,11 @@ func resolveTabLabels(l workspace.Labels) tabLabels {
 	if taxReg == "" {
 		taxReg = "Tax Registrations"
 	}
-	return tabLabels{Info: info, Users: users, Attachments: attachments, TaxRegistrations: taxReg}
+	invitations := l.Detail.Tabs.Invitations
+	if invitations == "" {
+		invitations = "Invitations"
+	}
+	return tabLabels{Info: info, Users: users, Attachments: attachments, TaxRegistrations: taxReg, Invitations: invitations}
 }
 
 // NewView creates the workspace detail view (full page load).
@@ -142,6 +153,10 @@ func NewView(deps *DetailViewDeps) view.View {
 			if deps.TaxRegistrationListURL != "" {
 				pageData.TaxRegistrationListURL = deps.TaxRegistrationListURL
 			}
+		case "invitations":
+			if deps.ListInvitations != nil {
+				pageData.InvitationsTable = buildInvitationsTable(ctx, deps, id, perms)
+			}
 		}
 
 		return view.OK("workspace-detail", pageData)
@@ -187,6 +202,11 @@ func NewTabAction(deps *DetailViewDeps) view.View {
 				pageData.TaxRegistrationListURL = deps.TaxRegistrationListURL
 			}
 			return view.OK("workspace-tab-tax-registrations", pageData)
+		case "invitations":
+			if deps.ListInvitations != nil {
+				pageData.InvitationsTable = buildInvitationsTable(ctx, deps, id, perms)
+			}
+			return view.OK("workspace-tab-invitations", pageData)
 		default:
 			return view.OK("workspace-tab-info", pageData)
 		}
@@ -262,6 +282,16 @@ func buildTabItems(id string, deps *DetailViewDeps, tl tabLabels) []pyeza.TabIte
 			Icon:  "icon-file-text",
 		})
 	}
+	// Pending invitations (shown only when the invitation closures are wired)
+	if deps.ListInvitations != nil {
+		tabs = append(tabs, pyeza.TabItem{
+			Key:   "invitations",
+			Label: tl.Invitations,
+			Href:  base + "?tab=invitations",
+			HxGet: action + "invitations",
+			Icon:  "icon-mail",
+		})
+	}
 	return tabs
 }
 
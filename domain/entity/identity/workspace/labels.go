@@ -29,6 +29,35 @@ type DetailLabels struct {
 	// TaxReg holds the Tax Registrations tab panel copy (W4.5 label
 	// remediation — previously hardcoded in detail.html).
 	TaxReg DetailTaxRegLabels `json:"taxReg"`
+	// Invitations holds the pending-invitations tab and invite drawer copy.
+	Invitations DetailInvitationLabels `json:"invitations"`
+}
+
+// DetailInvitationLabels holds i18n strings for the Invitations tab on the
+// workspace detail page and its invite drawer. When the host carries none
+// of them the module uses DefaultInvitationLabels().
+type DetailInvitationLabels struct {
+	InviteButton     string `json:"inviteButton"`
+	EmptyTitle       string `json:"emptyTitle"`
+	EmptyMessage     string `json:"emptyMessage"`
+	ColumnEmail      string `json:"columnEmail"`
+	ColumnRoles      string `json:"columnRoles"`
+	ColumnSent       string `json:"columnSent"`
+	ColumnExpires    string `json:"columnExpires"`
+	Expired          string `json:"expired"`
+	Resend           string `json:"resend"`
+	ResendConfirm    string `json:"resendConfirm"`
+	Revoke           string `json:"revoke"`
+	RevokeConfirm    string `json:"revokeConfirm"`
+	FormTitle        string `json:"formTitle"`
+	FormEmail        string `json:"formEmail"`
+	FormEmailHint    string `json:"formEmailHint"`
+	FormRoles        string `json:"formRoles"`
+	FormRolesHint    string `json:"formRolesHint"`
+	FormSubmit       string `json:"formSubmit"`
+	ErrorEmail       string `json:"errorEmail"`
+	ErrorPending     string `json:"errorPending"`
+	ErrorUnavailable string `json:"errorUnavailable"`
 }
 
 // DetailTaxRegLabels holds the Tax Registrations tab panel copy
@@ -55,6 +84,8 @@ type DetailTabLabels struct {
 	Attachments string `json:"attachments"`
 	// Phase 2 — polymorphic tax registrations tab
 	TaxRegistrations string `json:"taxRegistrations"`
+	// Pending workspace invitations
+	Invitations string `json:"invitations"`
 }
 
 // DetailUserLabels holds i18n strings for the Users tab on the workspace detail page.
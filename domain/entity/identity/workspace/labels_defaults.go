@@ -0,0 +1,37 @@
+package workspace
+
+// DefaultLabels returns Labels seeded with the English defaults below, for
+// the host's lyngua files to overlay.
+func DefaultLabels() Labels {
+	var l Labels
+	l.Detail.Invitations = DefaultInvitationLabels()
+	return l
+}
+
+// DefaultInvitationLabels returns DetailInvitationLabels populated with
+// English defaults.
+func DefaultInvitationLabels() DetailInvitationLabels {
+	return DetailInvitationLabels{
+		InviteButton:     "Invite by email",
+		EmptyTitle:       "No pending invitations",
+		EmptyMessage:     "Invite people by email; they appear here until they accept.",
+		ColumnEmail:      "Email",
+		ColumnRoles:      "Roles",
+		ColumnSent:       "Sent",
+		ColumnExpires:    "Expires",
+		Expired:          "Expired",
+		Resend:           "Resend invitation",
+		ResendConfirm:    "Send a new invitation email? Earlier links stop working.",
+		Revoke:           "Revoke invitation",
+		RevokeConfirm:    "Revoke this invitation? Its link stops working.",
+		FormTitle:        "Invite to workspace",
+		FormEmail:        "Email address",
+		FormEmailHint:    "They can accept with an existing account or create one.",
+		FormRoles:        "Roles",
+		FormRolesHint:    "Granted when the invitation is accepted.",
+		FormSubmit:       "Send invitation",
+		ErrorEmail:       "Enter a valid email address.",
+		ErrorPending:     "That address already has a pending invitation. Resend it instead.",
+		ErrorUnavailable: "The invitation could not be sent. Try again later.",
+	}
+}
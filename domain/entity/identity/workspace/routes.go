@@ -22,6 +22,10 @@ const (
 	TabActionURL        = "/action/workspace/{id}/tab/{tab}"
 	AttachmentUploadURL = "/action/workspace/{id}/attachments/upload"
 	AttachmentDeleteURL = "/action/workspace/{id}/attachments/delete"
+	InvitationTableURL  = "/action/workspace/{id}/invitations/table"
+	InvitationInviteURL = "/action/workspace/{id}/invitations/invite"
+	InvitationResendURL = "/action/workspace/{id}/invitations/resend"
+	InvitationRevokeURL = "/action/workspace/{id}/invitations/revoke"
 )
 
 // Routes holds all route paths for workspace management.
@@ -41,6 +45,12 @@ type Routes struct {
 	// Attachment routes
 	AttachmentUploadURL string `json:"attachment_upload_url"`
 	AttachmentDeleteURL string `json:"attachment_delete_url"`
+
+	// Invitation routes
+	InvitationTableURL  string `json:"invitation_table_url"`
+	InvitationInviteURL string `json:"invitation_invite_url"`
+	InvitationResendURL string `json:"invitation_resend_url"`
+	InvitationRevokeURL string `json:"invitation_revoke_url"`
 }
 
 // DefaultRoutes returns a Routes populated from the
@@ -61,6 +71,11 @@ func DefaultRoutes() Routes {
 
 		AttachmentUploadURL: AttachmentUploadURL,
 		AttachmentDeleteURL: AttachmentDeleteURL,
+
+		InvitationTableURL:  InvitationTableURL,
+		InvitationInviteURL: InvitationInviteURL,
+		InvitationResendURL: InvitationResendURL,
+		InvitationRevokeURL: InvitationRevokeURL,
 	}
 }
 
@@ -81,5 +96,10 @@ func (r Routes) RouteMap() map[string]string {
 
 		"workspace.attachment.upload": r.AttachmentUploadURL,
 		"workspace.attachment.delete": r.AttachmentDeleteURL,
+
+		"workspace.invitation.table":  r.InvitationTableURL,
+		"workspace.invitation.invite": r.InvitationInviteURL,
+		"workspace.invitation.resend": r.InvitationResendURL,
+		"workspace.invitation.revoke": r.InvitationRevokeURL,
 	}
 }
@@ -13,6 +13,7 @@ import (
 	workspacedetail "github.com/erniealice/entydad-golang/domain/entity/identity/workspace/detail"
 	workspacelist "github.com/erniealice/entydad-golang/domain/entity/identity/workspace/list"
 	attachmentpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/document/attachment"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
 	workspacepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace"
 	workspaceuserpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user"
 	"github.com/erniealice/hybra-golang/views/attachment"
@@ -48,6 +49,18 @@ type WorkspaceModuleDeps struct {
 	CreateAttachment func(ctx context.Context, req *attachmentpb.CreateAttachmentRequest) (*attachmentpb.CreateAttachmentResponse, error)
 	DeleteAttachment func(ctx context.Context, req *attachmentpb.DeleteAttachmentRequest) (*attachmentpb.DeleteAttachmentResponse, error)
 	NewID            func() string
+
+	// Invitation operations. Optional: when ListInvitations is nil the
+	// Invitations tab and its routes are not registered. The composition
+	// layer adapts the auth service's invitation methods.
+	ListInvitations  func(ctx context.Context, workspaceID string) ([]workspacedetail.PendingInvitation, error)
+	InviteUser       func(ctx context.Context, in workspacedetail.InviteInput) error
+	ResendInvitation func(ctx context.Context, workspaceID, id string) error
+	RevokeInvitation func(ctx context.Context, workspaceID, id string) error
+	ListRoles        func(ctx context.Context, req *rolepb.ListRolesRequest) (*rolepb.ListRolesResponse, error)
+	// GetRoleItemPageData lets the invite drawer offer only the roles whose
+	// permissions the inviter holds; without it no role is offered.
+	GetRoleItemPageData func(ctx context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error)
 }
 
 // WorkspaceModule holds all constructed workspace views.
@@ -65,6 +78,10 @@ type WorkspaceModule struct {
 	TabAction        view.View
 	AttachmentUpload view.View
 	AttachmentDelete view.View
+	InvitationTable  view.View
+	Invite           view.View
+	InvitationResend view.View
+	InvitationRevoke view.View
 }
 
 func NewWorkspaceModule(deps *WorkspaceModuleDeps) *WorkspaceModule {
@@ -101,6 +118,14 @@ func NewWorkspaceModule(deps *WorkspaceModuleDeps) *WorkspaceModule {
 			DeleteAttachment: deps.DeleteAttachment,
 			NewAttachmentID:  deps.NewID,
 		},
+		InvitationOps: workspacedetail.InvitationOps{
+			ListInvitations:     deps.ListInvitations,
+			InviteUser:          deps.InviteUser,
+			ResendInvitation:    deps.ResendInvitation,
+			RevokeInvitation:    deps.RevokeInvitation,
+			ListRoles:           deps.ListRoles,
+			GetRoleItemPageData: deps.GetRoleItemPageData,
+		},
 	}
 
 	m := &WorkspaceModule{
@@ -120,6 +145,18 @@ func NewWorkspaceModule(deps *WorkspaceModuleDeps) *WorkspaceModule {
 		m.AttachmentUpload = workspacedetail.NewAttachmentUploadAction(detailDeps)
 		m.AttachmentDelete = workspacedetail.NewAttachmentDeleteAction(detailDeps)
 	}
+	if deps.ListInvitations != nil {
+		m.InvitationTable = workspacedetail.NewInvitationTableAction(detailDeps)
+		if deps.InviteUser != nil {
+			m.Invite = workspacedetail.NewInviteAction(detailDeps)
+		}
+		if deps.ResendInvitation != nil {
+			m.InvitationResend = workspacedetail.NewInvitationResendAction(detailDeps)
+		}
+		if deps.RevokeInvitation != nil {
+			m.InvitationRevoke = workspacedetail.NewInvitationRevokeAction(detailDeps)
+		}
+	}
 	return m
 }
 
@@ -146,4 +183,25 @@ func (m *WorkspaceModule) RegisterRoutes(r view.RouteRegistrar) {
 		r.POST(m.routes.AttachmentUploadURL, m.AttachmentUpload)
 		r.POST(m.routes.AttachmentDeleteURL, m.AttachmentDelete)
 	}
+	// Invitations
+	if m.InvitationTable != nil {
+		r.GET(m.routes.InvitationTableURL, m.InvitationTable)
+	}
+	if m.Invite != nil {
+		r.GET(m.routes.InvitationInviteURL, m.Invite)
+		r.POST(m.routes.InvitationInviteURL, m.Invite)
+	}
+	if m.InvitationResend != nil {
+		r.POST(m.routes.InvitationResendURL, m.InvitationResend)
+	}
+	if m.InvitationRevoke != nil {
+		r.POST(m.routes.InvitationRevokeURL, m.InvitationRevoke)
+	}
+}
+
+// SensitiveActions returns the ServeMux patterns of the workspace delete
+// actions. Pass them to the auth module's Deps.StepUpActions to require a
+// recent sign-in.
+func (m *WorkspaceModule) SensitiveActions() []string {
+	return postPatterns(m.routes.DeleteURL, m.routes.BulkDeleteURL)
 }
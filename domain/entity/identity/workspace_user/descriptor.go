@@ -4,7 +4,7 @@ import "github.com/erniealice/espyna-golang/consumer/compose"
 
 func Describe() compose.Unit {
 	r := DefaultRoutes()
-	l := Labels{}
+	l := DefaultLabels()
 	return compose.Unit{
 		Key:       "entity.workspace_user",
 		Routes:    &r,
@@ -0,0 +1,76 @@
+package detail
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	"github.com/erniealice/pyeza-golang/route"
+	"github.com/erniealice/pyeza-golang/view"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/explain"
+	workspaceuserpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user"
+	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
+)
+
+// buildExplain builds the access tab's permission explainer for wu.
+func buildExplain(ctx context.Context, viewCtx *view.ViewContext, deps *DetailViewDeps, wu *workspaceuserpb.WorkspaceUser) *explain.Data {
+	return explain.Build(ctx, explain.Input{
+		Labels:      deps.Labels.Detail.Explain,
+		TableLabels: deps.TableLabels,
+		Query:       viewCtx.Request.URL.Query(),
+		Assignments: explainAssignments(wu),
+		GetRole:     deps.GetRoleItemPageData,
+		Catalog:     explain.ListCatalog(deps.ListPermissions),
+		FormURL:     route.ResolveURL(deps.Routes.TabActionURL, "id", wu.GetId(), "tab", "access"),
+		TextURL:     route.ResolveURL(deps.Routes.ExplainURL, "id", wu.GetId()),
+		Subject:     workspaceUserSubject(wu),
+	})
+}
+
+// NewExplainTextHandler serves the access tab's permission trace as a
+// plain-text download, for pasting into a support ticket.
+// Handles GET /action/workspace_user/{id}/permissions/explain?code=...
+func NewExplainTextHandler(deps *DetailViewDeps) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		if !view.GetUserPermissions(ctx).Can("workspace_user", "read") {
+			http.Error(w, "forbidden", http.StatusForbidden)
+			return
+		}
+		id := r.PathValue("id")
+		wu, err := loadWorkspaceUser(ctx, deps, id)
+		if err != nil {
+			http.Error(w, "failed to load workspace user", http.StatusInternalServerError)
+			return
+		}
+		explain.WriteText(w, r, id, workspaceUserSubject(wu), explainAssignments(wu), deps.GetRoleItemPageData, explain.ListCatalog(deps.ListPermissions))
+	}
+}
+
+// explainAssignments returns the already loaded role assignments of wu.
+func explainAssignments(wu *workspaceuserpb.WorkspaceUser) explain.Assignments {
+	return func(context.Context) ([]*workspaceuserrolepb.WorkspaceUserRole, error) {
+		return wu.GetWorkspaceUserRoles(), nil
+	}
+}
+
+// workspaceUserSubject names wu in the explainer's text:
+// "First Last <email> in Workspace".
+func workspaceUserSubject(wu *workspaceuserpb.WorkspaceUser) string {
+	u := wu.GetUser()
+	subject := strings.TrimSpace(u.GetFirstName() + " " + u.GetLastName())
+	switch {
+	case subject == "":
+		subject = u.GetEmailAddress()
+	case u.GetEmailAddress() != "":
+		subject += " <" + u.GetEmailAddress() + ">"
+	}
+	if subject == "" {
+		subject = wu.GetId()
+	}
+	if name := wu.GetWorkspace().GetName(); name != "" {
+		subject += " in " + name
+	}
+	return subject
+}
@@ -1,6 +1,6 @@
 // Package detail provides the workspace_user nested detail page view.
 // This is the page operators land on after clicking a row in workspace detail's Users tab.
-// It renders Info and Roles tabs with a "Back to workspace" breadcrumb link.
+// It renders Info, Roles, Access and Attachments tabs with a "Back to workspace" breadcrumb link.
 package detail
 
 import (
@@ -14,7 +14,10 @@ import (
 	"github.com/erniealice/pyeza-golang/types"
 	"github.com/erniealice/pyeza-golang/view"
 
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/explain"
 	workspace_user "github.com/erniealice/entydad-golang/domain/entity/identity/workspace_user"
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
 	workspaceuserpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user"
 	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
 	"github.com/erniealice/hybra-golang/views/attachment"
@@ -32,6 +35,12 @@ type DetailViewDeps struct {
 	GetWorkspaceUserRoleListPageData func(ctx context.Context, req *workspaceuserrolepb.GetWorkspaceUserRoleListPageDataRequest) (*workspaceuserrolepb.GetWorkspaceUserRoleListPageDataResponse, error)
 	WorkspaceUserRoleAddURL          string
 	WorkspaceUserRoleDeleteURL       string
+	// GetRoleItemPageData loads each assigned role with its permissions
+	// for the access tab (optional).
+	GetRoleItemPageData effective.GetRole
+	// ListPermissions is the catalog the access tab's verdict expands
+	// wildcard grants against (optional).
+	ListPermissions func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
 
 	// Attachment operations (embedded from hybra)
 	attachment.AttachmentOps
@@ -59,6 +68,8 @@ type PageData struct {
 	// Roles tab
 	RolesTable              *types.TableConfig
 	WorkspaceUserRoleAddURL string
+	// Access tab
+	Explain *explain.Data
 	// Attachments tab
 	AttachmentTable *types.TableConfig
 }
@@ -67,6 +78,7 @@ type PageData struct {
 type tabLabels struct {
 	Info        string
 	Roles       string
+	Access      string
 	Attachments string
 }
 
@@ -84,7 +96,7 @@ func resolveTabLabels(l workspace_user.Labels) tabLabels {
 	if attachments == "" {
 		attachments = "Attachments"
 	}
-	return tabLabels{Info: info, Roles: roles, Attachments: attachments}
+	return tabLabels{Info: info, Roles: roles, Access: l.Detail.Explain.Tab, Attachments: attachments}
 }
 
 // NewView creates the workspace_user detail view (full page load).
@@ -113,6 +125,8 @@ func NewView(deps *DetailViewDeps) view.View {
 		switch activeTab {
 		case "roles":
 			pageData.RolesTable = buildRolesTable(ctx, deps, wu, tl)
+		case "access":
+			pageData.Explain = buildExplain(ctx, viewCtx, deps, wu)
 		case "attachments":
 			loadAttachments(ctx, deps, id, pageData)
 		}
@@ -148,6 +162,9 @@ func NewTabAction(deps *DetailViewDeps) view.View {
 		case "roles":
 			pageData.RolesTable = buildRolesTable(ctx, deps, wu, tl)
 			return view.OK("workspace-user-tab-roles", pageData)
+		case "access":
+			pageData.Explain = buildExplain(ctx, viewCtx, deps, wu)
+			return view.OK("workspace-user-tab-access", pageData)
 		case "attachments":
 			loadAttachments(ctx, deps, id, pageData)
 			return view.OK("attachment-tab", pageData)
@@ -255,6 +272,7 @@ func buildTabItems(id string, deps *DetailViewDeps, tl tabLabels) []pyeza.TabIte
 	return []pyeza.TabItem{
 		{Key: "info", Label: tl.Info, Href: base + "?tab=info", HxGet: action + "info", Icon: "icon-info"},
 		{Key: "roles", Label: tl.Roles, Href: base + "?tab=roles", HxGet: action + "roles", Icon: "icon-shield"},
+		{Key: "access", Label: tl.Access, Href: base + "?tab=access", HxGet: action + "access", Icon: "icon-lock"},
 		{Key: "attachments", Label: tl.Attachments, Href: base + "?tab=attachments", HxGet: action + "attachments", Icon: "icon-paperclip"},
 	}
 }
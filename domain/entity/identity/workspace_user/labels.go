@@ -7,6 +7,10 @@ package workspace_user
 // names, json tags, and string literals are byte-identical. Entity-local
 // rename: WorkspaceUserLabels -> Labels, WorkspaceUser<Xxx>Labels -> <Xxx>Labels.
 
+import (
+	permission "github.com/erniealice/entydad-golang/domain/entity/identity/permission"
+)
+
 // Labels holds all translatable strings for the workspace_user module.
 type Labels struct {
 	Page    PageLabels   `json:"page"`
@@ -39,6 +43,8 @@ type DetailLabels struct {
 	// Info holds the Info-tab section title + field labels (W4.5 label
 	// remediation — previously hardcoded in info-tab.html).
 	Info DetailInfoLabels `json:"info"`
+	// Explain is the access tab's permission explainer.
+	Explain permission.ExplainLabels `json:"explain"`
 }
 
 // DetailInfoLabels holds the Info-tab labels on the
@@ -80,3 +86,11 @@ type ActionLabels struct {
 	Activate   string `json:"activate"`
 	Deactivate string `json:"deactivate"`
 }
+
+// DefaultLabels returns Labels seeded with English defaults for the access
+// tab's permission explainer, for the host's lyngua files to overlay.
+func DefaultLabels() Labels {
+	var l Labels
+	l.Detail.Explain = permission.DefaultExplainLabels()
+	return l
+}
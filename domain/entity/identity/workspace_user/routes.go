@@ -17,6 +17,7 @@ const (
 	DeleteURL    = "/action/workspace_user/delete/{id}"
 	SetStatusURL = "/action/workspace_user/set-status/{id}"
 	SearchURL    = "/action/workspace_user/search"
+	ExplainURL   = "/action/workspace_user/{id}/permissions/explain"
 
 	AttachmentUploadURL = "/action/workspace_user/{id}/attachments/upload"
 	AttachmentDeleteURL = "/action/workspace_user/{id}/attachments/delete"
@@ -31,6 +32,8 @@ type Routes struct {
 	DeleteURL    string `json:"delete_url"`
 	SetStatusURL string `json:"set_status_url"`
 	SearchURL    string `json:"search_url"`
+	// ExplainURL serves the access tab's permission trace as plain text.
+	ExplainURL string `json:"explain_url"`
 
 	// Attachment routes
 	AttachmentUploadURL string `json:"attachment_upload_url"`
@@ -48,6 +51,7 @@ func DefaultRoutes() Routes {
 		DeleteURL:    DeleteURL,
 		SetStatusURL: SetStatusURL,
 		SearchURL:    SearchURL,
+		ExplainURL:   ExplainURL,
 
 		AttachmentUploadURL: AttachmentUploadURL,
 		AttachmentDeleteURL: AttachmentDeleteURL,
@@ -64,6 +68,7 @@ func (r Routes) RouteMap() map[string]string {
 		"workspace_user.delete":     r.DeleteURL,
 		"workspace_user.set_status": r.SetStatusURL,
 		"workspace_user.search":     r.SearchURL,
+		"workspace_user.explain":    r.ExplainURL,
 
 		"workspace_user.attachment.upload": r.AttachmentUploadURL,
 		"workspace_user.attachment.delete": r.AttachmentDeleteURL,
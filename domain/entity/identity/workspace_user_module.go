@@ -16,6 +16,8 @@ import (
 	workspaceuserdetail "github.com/erniealice/entydad-golang/domain/entity/identity/workspace_user/detail"
 	workspaceuserlist "github.com/erniealice/entydad-golang/domain/entity/identity/workspace_user/list"
 	attachmentpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/document/attachment"
+	permissionpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/permission"
+	rolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/role"
 	userpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/user"
 	workspaceuserpb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user"
 	workspaceuserrolepb "github.com/erniealice/esqyma/pkg/schema/v1/domain/entity/workspace_user_role"
@@ -42,6 +44,12 @@ type WorkspaceUserModuleDeps struct {
 	GetWorkspaceUserRoleListPageData func(ctx context.Context, req *workspaceuserrolepb.GetWorkspaceUserRoleListPageDataRequest) (*workspaceuserrolepb.GetWorkspaceUserRoleListPageDataResponse, error)
 	WorkspaceUserRoleAddURL          string
 	WorkspaceUserRoleDeleteURL       string
+	// GetRoleItemPageData loads each assigned role with its permissions for
+	// the detail page's access tab (permission explainer). Optional/nil-safe.
+	GetRoleItemPageData func(ctx context.Context, req *rolepb.GetRoleItemPageDataRequest) (*rolepb.GetRoleItemPageDataResponse, error)
+	// ListPermissions gives the access tab the permission catalog, so its
+	// verdict matches the runtime permission set. Optional/nil-safe.
+	ListPermissions func(ctx context.Context, req *permissionpb.ListPermissionsRequest) (*permissionpb.ListPermissionsResponse, error)
 
 	// Attachment operations
 	UploadFile       func(ctx context.Context, bucket, key string, content []byte, contentType string) error
@@ -64,6 +72,8 @@ type WorkspaceUserModule struct {
 	UserSearch       http.HandlerFunc
 	AttachmentUpload view.View
 	AttachmentDelete view.View
+	// ExplainText serves the access tab's permission trace as plain text.
+	ExplainText http.HandlerFunc
 }
 
 // NewWorkspaceUserModule constructs all workspace_user views from deps.
@@ -92,6 +102,8 @@ func NewWorkspaceUserModule(deps *WorkspaceUserModuleDeps) *WorkspaceUserModule
 		GetWorkspaceUserRoleListPageData: deps.GetWorkspaceUserRoleListPageData,
 		WorkspaceUserRoleAddURL:          deps.WorkspaceUserRoleAddURL,
 		WorkspaceUserRoleDeleteURL:       deps.WorkspaceUserRoleDeleteURL,
+		GetRoleItemPageData:              deps.GetRoleItemPageData,
+		ListPermissions:                  deps.ListPermissions,
 		AttachmentOps: attachment.AttachmentOps{
 			UploadFile:       deps.UploadFile,
 			ListAttachments:  deps.ListAttachments,
@@ -111,6 +123,7 @@ func NewWorkspaceUserModule(deps *WorkspaceUserModuleDeps) *WorkspaceUserModule
 		SetStatus:  workspaceuseraction.NewSetStatusAction(actionDeps),
 		UserSearch: workspaceuseraction.NewUserSearchAction(actionDeps),
 	}
+	m.ExplainText = workspaceuserdetail.NewExplainTextHandler(detailDeps)
 	if deps.UploadFile != nil {
 		m.AttachmentUpload = workspaceuserdetail.NewAttachmentUploadAction(detailDeps)
 		m.AttachmentDelete = workspaceuserdetail.NewAttachmentDeleteAction(detailDeps)
@@ -145,6 +158,10 @@ func (m *WorkspaceUserModule) RegisterRoutes(r view.RouteRegistrar) {
 			full.HandleFunc("GET", m.routes.SearchURL, m.UserSearch)
 		}
 	}
+	// Permission explainer text export
+	if m.routes.ExplainURL != "" {
+		identityHandleFunc(r, "GET", m.routes.ExplainURL, m.ExplainText)
+	}
 	// Attachments
 	if m.AttachmentUpload != nil {
 		r.GET(m.routes.AttachmentUploadURL, m.AttachmentUpload)
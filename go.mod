@@ -10,6 +10,7 @@ require (
 	github.com/erniealice/esqyma v0.1.0-alpha
 	github.com/erniealice/hybra-golang v0.1.0-alpha
 	github.com/erniealice/lyngua v0.1.0-alpha
+	github.com/goccy/go-yaml v1.18.0
 	google.golang.org/protobuf v1.36.11
 )
 
@@ -89,7 +90,6 @@ require (
 	github.com/go-playground/universal-translator v0.18.1 // indirect
 	github.com/go-playground/validator/v10 v10.28.0 // indirect
 	github.com/goccy/go-json v0.10.5 // indirect
-	github.com/goccy/go-yaml v1.18.0 // indirect
 	github.com/gofiber/fiber/v2 v2.52.9 // indirect
 	github.com/gofiber/fiber/v3 v3.0.0-rc.2 // indirect
 	github.com/gofiber/schema v1.6.0 // indirect
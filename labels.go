@@ -100,7 +100,54 @@ type Login02Labels struct {
 	NoAccount           string `json:"noAccount"`
 	SignUpLink          string `json:"signUpLink"`
 	SocialDivider       string `json:"socialDivider"`
-	Error               string `json:"error"`
+	// Error is the generic failure (?error=invalid and anything
+	// unrecognized); the limiter codes have their own messages:
+	//   ?error=locked      → ErrorLocked
+	//   ?error=throttled   → ErrorThrottled
+	//   ?error=mfa_expired → ErrorMFAExpired
+	//   ?error=oidc        → ErrorOIDC
+	//   ?error=no_account  → ErrorNoAccount
+	//   ?error=verify_link → ErrorVerifyLink
+	//   ?error=magic_link  → ErrorMagicLink
+	//   ?error=sso_required → ErrorSSORequired
+	//   ?error=consent_declined → ErrorConsentDeclined
+	//   ?error=session_expired → ErrorSessionExpired
+	Error          string `json:"error"`
+	ErrorLocked    string `json:"errorLocked"`
+	ErrorThrottled string `json:"errorThrottled"`
+	// ErrorMFAExpired: ?error=mfa_expired — the second-factor step timed out.
+	ErrorMFAExpired string `json:"errorMfaExpired"`
+	// Passkey sign-in button and its failure message (shown inline by the
+	// passkey script; the JSON error codes "passkey" / "expired" map here).
+	PasskeyButton string `json:"passkeyButton"`
+	ErrorPasskey  string `json:"errorPasskey"`
+	// ErrorOIDC: ?error=oidc — the identity-provider round trip failed.
+	// ErrorNoAccount: ?error=no_account — the provider verified an email no
+	// user in this app has.
+	ErrorOIDC      string `json:"errorOidc"`
+	ErrorNoAccount string `json:"errorNoAccount"`
+	// ErrorVerifyLink: ?error=verify_link — an email-verification link was
+	// invalid or expired. EmailVerified is the ?verified=1 success notice.
+	ErrorVerifyLink string `json:"errorVerifyLink"`
+	EmailVerified   string `json:"emailVerified"`
+	// MagicLinkButton opens the passwordless sign-in page. ErrorMagicLink:
+	// ?error=magic_link — the emailed sign-in link was invalid, expired or
+	// already used.
+	MagicLinkButton string `json:"magicLinkButton"`
+	ErrorMagicLink  string `json:"errorMagicLink"`
+	// Identifier-first sign-in (home-realm discovery): ContinueButton
+	// submits the email step, ChangeEmail returns to it. ErrorSSORequired:
+	// ?error=sso_required — the email's workspace only allows sign-in
+	// through its identity provider.
+	ContinueButton   string `json:"continueButton"`
+	ChangeEmail      string `json:"changeEmail"`
+	ErrorSSORequired string `json:"errorSsoRequired"`
+	// ErrorConsentDeclined: ?error=consent_declined — the user declined the
+	// current terms at /auth/consent and was signed out.
+	ErrorConsentDeclined string `json:"errorConsentDeclined"`
+	// ErrorSessionExpired: ?error=session_expired — the session passed its
+	// idle or absolute lifetime and was signed out.
+	ErrorSessionExpired string `json:"errorSessionExpired"`
 	// Carousel navigation
 	PreviousSlide string `json:"previousSlide"`
 	NextSlide     string `json:"nextSlide"`
@@ -154,7 +201,29 @@ type Signup02Labels struct {
 	SignInLink                 string `json:"signInLink"`
 	SocialDivider              string `json:"socialDivider"`
 	TermsText                  string `json:"termsText"`
+	// Generic + code-specific error messages, addressed by the `?error=`
+	// code the signup handler emits (see classifySignupError):
+	//   ?error=mismatch       → ErrorMismatch
+	//   ?error=email_taken    → ErrorEmailTaken
+	//   ?error=weak_password  → ErrorWeakPassword
+	//   ?error=invalid_email  → ErrorInvalidEmail
+	//   ?error=throttled      → ErrorThrottled
+	//   ?error=sso_required   → ErrorSSORequired
+	// plus the auth.PasswordPolicy codes:
+	//   ?error=too_short               → ErrorWeakPassword
+	//   ?error=password_classes        → ErrorPasswordClasses
+	//   ?error=password_breached       → ErrorPasswordBreached
+	//   ?error=password_contains_email → ErrorPasswordContainsEmail
+	//   ?error=generic (and anything unrecognized) → Error
 	Error                      string `json:"error"`
+	ErrorMismatch              string `json:"errorMismatch"`
+	ErrorEmailTaken            string `json:"errorEmailTaken"`
+	ErrorWeakPassword          string `json:"errorWeakPassword"`
+	ErrorInvalidEmail          string `json:"errorInvalidEmail"`
+	ErrorThrottled             string `json:"errorThrottled"`
+	ErrorPasswordClasses       string `json:"errorPasswordClasses"`
+	ErrorPasswordBreached      string `json:"errorPasswordBreached"`
+	ErrorPasswordContainsEmail string `json:"errorPasswordContainsEmail"`
 	// Carousel navigation + accessibility
 	PreviousSlide    string `json:"previousSlide"`
 	NextSlide        string `json:"nextSlide"`
@@ -211,12 +280,25 @@ type ResetPassword02Labels struct {
 	//   ?error=invalid_token  → ErrorInvalidToken
 	//   ?error=expired_token  → ErrorExpiredToken
 	//   ?error=weak_password  → ErrorWeakPassword
+	//   ?error=throttled      → ErrorThrottled
+	// plus the auth.PasswordPolicy codes:
+	//   ?error=too_short               → ErrorWeakPassword
+	//   ?error=password_classes        → ErrorPasswordClasses
+	//   ?error=password_breached       → ErrorPasswordBreached
+	//   ?error=password_reused         → ErrorPasswordReused
+	//   ?error=password_contains_email → ErrorPasswordContainsEmail
 	//   ?error=generic (and anything unrecognized) → Error
-	Error             string `json:"error"`
-	ErrorMismatch     string `json:"errorMismatch"`
-	ErrorInvalidToken string `json:"errorInvalidToken"`
-	ErrorExpiredToken string `json:"errorExpiredToken"`
-	ErrorWeakPassword string `json:"errorWeakPassword"`
+	Error                      string `json:"error"`
+	ErrorMismatch              string `json:"errorMismatch"`
+	ErrorInvalidToken          string `json:"errorInvalidToken"`
+	ErrorExpiredToken          string `json:"errorExpiredToken"`
+	ErrorWeakPassword          string `json:"errorWeakPassword"`
+	ErrorThrottled             string `json:"errorThrottled"`
+	ErrorPasswordClasses       string `json:"errorPasswordClasses"`
+	ErrorPasswordBreached      string `json:"errorPasswordBreached"`
+	ErrorPasswordReused        string `json:"errorPasswordReused"`
+	ErrorPasswordContainsEmail string `json:"errorPasswordContainsEmail"`
+	ErrorSSORequired           string `json:"errorSsoRequired"`
 	// Carousel navigation
 	PreviousSlide string `json:"previousSlide"`
 	NextSlide     string `json:"nextSlide"`
@@ -231,6 +313,11 @@ type ResetPassword02Labels struct {
 //	?error=mismatch  → ErrorMismatch
 //	?error=incorrect → ErrorCurrentIncorrect
 //	?error=too_short → ErrorTooShort
+//	?error=password_classes        → ErrorPasswordClasses
+//	?error=password_breached       → ErrorPasswordBreached
+//	?error=password_reused         → ErrorPasswordReused
+//	?error=password_contains_email → ErrorPasswordContainsEmail
+//	?error=impersonating           → ErrorImpersonating
 //	?error=generic (and anything unrecognized) → Error
 type ChangePasswordLabels struct {
 	Title                      string `json:"title"`
@@ -245,11 +332,239 @@ type ChangePasswordLabels struct {
 	SubmitButton               string `json:"submitButton"`
 	SuccessMessage             string `json:"successMessage"`
 	// Generic fallback + code-specific error messages.
-	Error                 string `json:"error"`
-	ErrorMismatch         string `json:"errorMismatch"`
-	ErrorCurrentIncorrect string `json:"errorCurrentIncorrect"`
-	ErrorTooShort         string `json:"errorTooShort"`
-	BackToApp             string `json:"backToApp"`
+	Error                      string `json:"error"`
+	ErrorMismatch              string `json:"errorMismatch"`
+	ErrorCurrentIncorrect      string `json:"errorCurrentIncorrect"`
+	ErrorTooShort              string `json:"errorTooShort"`
+	ErrorPasswordClasses       string `json:"errorPasswordClasses"`
+	ErrorPasswordBreached      string `json:"errorPasswordBreached"`
+	ErrorPasswordReused        string `json:"errorPasswordReused"`
+	ErrorPasswordContainsEmail string `json:"errorPasswordContainsEmail"`
+	ErrorImpersonating         string `json:"errorImpersonating"`
+	BackToApp                  string `json:"backToApp"`
+}
+
+// ---------------------------------------------------------------------------
+// MFA labels
+// ---------------------------------------------------------------------------
+
+// MFALabels holds i18n strings for the /auth/mfa second-factor page (TOTP
+// challenge, recovery-code challenge, first-time setup, and the one-time
+// recovery-code listing). Error fields are addressed by code:
+//
+//	invalid   → ErrorInvalidCode
+//	throttled → ErrorThrottled
+//	(anything else) → Error
+type MFALabels struct {
+	Title                string `json:"title"`
+	Heading              string `json:"heading"`
+	Subheading           string `json:"subheading"`
+	CodeLabel            string `json:"codeLabel"`
+	CodePlaceholder      string `json:"codePlaceholder"`
+	VerifyButton         string `json:"verifyButton"`
+	UseRecoveryCode      string `json:"useRecoveryCode"`
+	RecoveryHeading      string `json:"recoveryHeading"`
+	RecoverySubheading   string `json:"recoverySubheading"`
+	RecoveryCodeLabel    string `json:"recoveryCodeLabel"`
+	UseAuthenticator     string `json:"useAuthenticator"`
+	SetupHeading         string `json:"setupHeading"`
+	SetupSubheading      string `json:"setupSubheading"`
+	SecretLabel          string `json:"secretLabel"`
+	OpenInApp            string `json:"openInApp"`
+	RecoveryCodesHeading string `json:"recoveryCodesHeading"`
+	RecoveryCodesHelp    string `json:"recoveryCodesHelp"`
+	ContinueButton       string `json:"continueButton"`
+	CancelLink           string `json:"cancelLink"`
+	Error                string `json:"error"`
+	ErrorInvalidCode     string `json:"errorInvalidCode"`
+	ErrorThrottled       string `json:"errorThrottled"`
+}
+
+// ---------------------------------------------------------------------------
+// Verify email labels
+// ---------------------------------------------------------------------------
+
+// VerifyEmailLabels holds i18n strings for the /auth/verify-email page shown
+// after self-signup and whenever an unverified account tries to sign in.
+// Error fields are addressed by code:
+//
+//	throttled → ErrorThrottled
+//	mail      → ErrorSendFailed
+//	(anything else) → Error
+type VerifyEmailLabels struct {
+	Title           string `json:"title"`
+	Heading         string `json:"heading"`
+	Subheading      string `json:"subheading"`
+	Help            string `json:"help"`
+	ResendButton    string `json:"resendButton"`
+	ResentMessage   string `json:"resentMessage"`
+	BackToLogin     string `json:"backToLogin"`
+	Error           string `json:"error"`
+	ErrorThrottled  string `json:"errorThrottled"`
+	ErrorSendFailed string `json:"errorSendFailed"`
+}
+
+// ---------------------------------------------------------------------------
+// Magic link labels
+// ---------------------------------------------------------------------------
+
+// MagicLinkLabels holds i18n strings for the /auth/magic-link pages: the
+// request form, the "link sent" confirmation and the confirm step the
+// emailed link opens. Error fields are addressed by code:
+//
+//	throttled → ErrorThrottled
+//	(anything else) → Error
+type MagicLinkLabels struct {
+	Title            string `json:"title"`
+	Heading          string `json:"heading"`
+	Subheading       string `json:"subheading"`
+	EmailLabel       string `json:"emailLabel"`
+	EmailPlaceholder string `json:"emailPlaceholder"`
+	SendButton       string `json:"sendButton"`
+	SentHeading      string `json:"sentHeading"`
+	SentMessage      string `json:"sentMessage"`
+	ConfirmHeading   string `json:"confirmHeading"`
+	ConfirmMessage   string `json:"confirmMessage"`
+	ConfirmButton    string `json:"confirmButton"`
+	BackToLogin      string `json:"backToLogin"`
+	Error            string `json:"error"`
+	ErrorThrottled   string `json:"errorThrottled"`
+}
+
+// ---------------------------------------------------------------------------
+// Accept invitation labels
+// ---------------------------------------------------------------------------
+
+// AcceptInviteLabels holds i18n strings for the /auth/accept-invite page the
+// emailed workspace invitation opens. "{workspace}", "{inviter}" and
+// "{email}" are filled from the invitation. Invitees with an account sign
+// in (SignInMessage); everyone else creates one (SignUpMessage). Error
+// fields are addressed by code:
+//
+//	invalid  → ErrorInvalid (wrong password)
+//	locked   → ErrorLocked
+//	throttled → ErrorThrottled
+//	mismatch → ErrorMismatch
+//	too_short / weak_password → ErrorWeakPassword
+//	password_classes / password_breached / password_contains_email →
+//	  ErrorPasswordClasses / ErrorPasswordBreached / ErrorPasswordContainsEmail
+//	(anything else) → Error
+type AcceptInviteLabels struct {
+	Title                      string `json:"title"`
+	Heading                    string `json:"heading"`
+	Subheading                 string `json:"subheading"`
+	SignInMessage              string `json:"signInMessage"`
+	SignUpMessage              string `json:"signUpMessage"`
+	EmailLabel                 string `json:"emailLabel"`
+	FirstNameLabel             string `json:"firstNameLabel"`
+	LastNameLabel              string `json:"lastNameLabel"`
+	PasswordLabel              string `json:"passwordLabel"`
+	PasswordPlaceholder        string `json:"passwordPlaceholder"`
+	ConfirmPasswordLabel       string `json:"confirmPasswordLabel"`
+	ConfirmPasswordPlaceholder string `json:"confirmPasswordPlaceholder"`
+	SignInButton               string `json:"signInButton"`
+	SignUpButton               string `json:"signUpButton"`
+	InvalidHeading             string `json:"invalidHeading"`
+	InvalidMessage             string `json:"invalidMessage"`
+	BackToLogin                string `json:"backToLogin"`
+	Error                      string `json:"error"`
+	ErrorInvalid               string `json:"errorInvalid"`
+	ErrorLocked                string `json:"errorLocked"`
+	ErrorThrottled             string `json:"errorThrottled"`
+	ErrorMismatch              string `json:"errorMismatch"`
+	ErrorWeakPassword          string `json:"errorWeakPassword"`
+	ErrorPasswordClasses       string `json:"errorPasswordClasses"`
+	ErrorPasswordBreached      string `json:"errorPasswordBreached"`
+	ErrorPasswordContainsEmail string `json:"errorPasswordContainsEmail"`
+	SSOMessage                 string `json:"ssoMessage"`
+	SSOButton                  string `json:"ssoButton"`
+	ErrorSSOEmail              string `json:"errorSsoEmail"`
+}
+
+// ---------------------------------------------------------------------------
+// Impersonation labels
+// ---------------------------------------------------------------------------
+
+// ImpersonationLabels holds i18n strings for the banner shown on every page
+// while an administrator is viewing the app as another user. "{user}" is the
+// impersonated user's name and "{time}" the automatic end time.
+type ImpersonationLabels struct {
+	Title      string `json:"title"`
+	Message    string `json:"message"`
+	StopButton string `json:"stopButton"`
+}
+
+// ---------------------------------------------------------------------------
+// Step-up labels
+// ---------------------------------------------------------------------------
+
+// StepUpLabels holds i18n strings for the "confirm it's you" dialog shown
+// before a sensitive action when the last sign-in or re-authentication is too
+// old.
+type StepUpLabels struct {
+	Title          string `json:"title"`
+	Message        string `json:"message"`
+	Password       string `json:"password"`
+	Code           string `json:"code"`
+	CodeHint       string `json:"codeHint"`
+	Submit         string `json:"submit"`
+	Cancel         string `json:"cancel"`
+	ErrorInvalid   string `json:"errorInvalid"`
+	ErrorThrottled string `json:"errorThrottled"`
+	ErrorExpired   string `json:"errorExpired"`
+}
+
+// ---------------------------------------------------------------------------
+// Session expiry labels
+// ---------------------------------------------------------------------------
+
+// SessionExpiryLabels holds i18n strings for the re-login dialog shown when
+// an in-page request finds the session past its idle or absolute lifetime.
+type SessionExpiryLabels struct {
+	Title          string `json:"title"`
+	Message        string `json:"message"`
+	Password       string `json:"password"`
+	Code           string `json:"code"`
+	CodeHint       string `json:"codeHint"`
+	Submit         string `json:"submit"`
+	Cancel         string `json:"cancel"`
+	ErrorInvalid   string `json:"errorInvalid"`
+	ErrorThrottled string `json:"errorThrottled"`
+	ErrorExpired   string `json:"errorExpired"`
+}
+
+// ---------------------------------------------------------------------------
+// Consent labels
+// ---------------------------------------------------------------------------
+
+// ConsentLabels holds i18n strings for the /auth/consent step that asks a
+// user to accept the current terms of service and privacy notice. The
+// documents themselves (title + markdown body) come from the host, not from
+// these labels. Error fields are addressed by code:
+//
+//	required → ErrorRequired
+//	(anything else) → Error
+type ConsentLabels struct {
+	Title         string `json:"title"`
+	Heading       string `json:"heading"`
+	Subheading    string `json:"subheading"`
+	VersionLabel  string `json:"versionLabel"`
+	AcceptLabel   string `json:"acceptLabel"`
+	AcceptButton  string `json:"acceptButton"`
+	DeclineButton string `json:"declineButton"`
+	Error         string `json:"error"`
+	ErrorRequired string `json:"errorRequired"`
+}
+
+// ---------------------------------------------------------------------------
+// Acting-as switcher labels
+// ---------------------------------------------------------------------------
+
+// ActingAsLabels holds i18n strings for the portal header dropdown a
+// delegate uses to change which client or supplier they act for.
+type ActingAsLabels struct {
+	Label  string `json:"label"`
+	Switch string `json:"switch"`
 }
 
 // ---------------------------------------------------------------------------
@@ -270,6 +585,24 @@ type AuthEmailLabels struct {
 	PasswordChangedSubject string `json:"passwordChangedSubject"`
 	PasswordChangedHeading string `json:"passwordChangedHeading"`
 	PasswordChangedBody    string `json:"passwordChangedBody"`
+	VerifySubject          string `json:"verifySubject"`
+	VerifyHeading          string `json:"verifyHeading"`
+	VerifyBody             string `json:"verifyBody"`
+	VerifyButtonText       string `json:"verifyButtonText"`
+	VerifyExpiry           string `json:"verifyExpiry"`
+	MagicLinkSubject       string `json:"magicLinkSubject"`
+	MagicLinkHeading       string `json:"magicLinkHeading"`
+	MagicLinkBody          string `json:"magicLinkBody"`
+	MagicLinkButtonText    string `json:"magicLinkButtonText"`
+	MagicLinkExpiry        string `json:"magicLinkExpiry"`
+	InvitationSubject      string `json:"invitationSubject"`
+	InvitationHeading      string `json:"invitationHeading"`
+	InvitationBody         string `json:"invitationBody"`
+	InvitationButtonText   string `json:"invitationButtonText"`
+	InvitationExpiry       string `json:"invitationExpiry"`
+	AlertTimeLabel         string `json:"alertTimeLabel"`
+	AlertIPLabel           string `json:"alertIpLabel"`
+	AlertHelp              string `json:"alertHelp"`
 	SecurityNotice         string `json:"securityNotice"`
 }
 
@@ -312,6 +645,11 @@ type SharedErrorLabels struct {
 	PasswordRequired          string `json:"passwordRequired"`
 	PasswordFailed            string `json:"passwordFailed"`
 	PasswordManagedByProvider string `json:"passwordManagedByProvider"`
+	PasswordTooShort          string `json:"passwordTooShort"`
+	PasswordClasses           string `json:"passwordClasses"`
+	PasswordBreached          string `json:"passwordBreached"`
+	PasswordReused            string `json:"passwordReused"`
+	PasswordContainsEmail     string `json:"passwordContainsEmail"`
 	RoleRequired              string `json:"roleRequired"`
 	PermissionRequired        string `json:"permissionRequired"`
 	UserRequired              string `json:"userRequired"`
@@ -11,23 +11,39 @@ package entydad
 // DefaultLogin02Labels returns Login02Labels populated with English defaults.
 func DefaultLogin02Labels() Login02Labels {
 	return Login02Labels{
-		Title:               "Sign In",
-		Heading:             "Welcome back",
-		Subheading:          "Sign in to your account",
-		EmailLabel:          "Email",
-		EmailPlaceholder:    "Enter your email",
-		PasswordLabel:       "Password",
-		PasswordPlaceholder: "Enter your password",
-		RememberMe:          "Remember me",
-		ForgotPassword:      "Forgot password?",
-		SignInButton:        "Sign In",
-		NoAccount:           "Don't have an account?",
-		SignUpLink:          "Sign up",
-		SocialDivider:       "or continue with",
-		Error:               "Invalid email or password. Please try again.",
-		PreviousSlide:       "Previous slide",
-		NextSlide:           "Next slide",
-		ContinueWith:        "Continue with",
+		Title:                "Sign In",
+		Heading:              "Welcome back",
+		Subheading:           "Sign in to your account",
+		EmailLabel:           "Email",
+		EmailPlaceholder:     "Enter your email",
+		PasswordLabel:        "Password",
+		PasswordPlaceholder:  "Enter your password",
+		RememberMe:           "Remember me",
+		ForgotPassword:       "Forgot password?",
+		SignInButton:         "Sign In",
+		NoAccount:            "Don't have an account?",
+		SignUpLink:           "Sign up",
+		SocialDivider:        "or continue with",
+		Error:                "Invalid email or password. Please try again.",
+		ErrorLocked:          "Too many failed sign-in attempts. This account is temporarily locked — try again later or reset your password.",
+		ErrorThrottled:       "Too many sign-in attempts. Please wait a few minutes and try again.",
+		ErrorMFAExpired:      "Your verification step timed out. Please sign in again.",
+		PasskeyButton:        "Sign in with a passkey",
+		ErrorPasskey:         "We couldn't sign you in with that passkey. Please try again.",
+		ErrorOIDC:            "We couldn't sign you in with your organization account. Please try again.",
+		ErrorNoAccount:       "There is no account for that email. Ask your administrator for an invitation.",
+		ErrorVerifyLink:      "That verification link is invalid or has expired. Sign in to get a new one.",
+		EmailVerified:        "Your email is verified. You can sign in now.",
+		MagicLinkButton:      "Email me a sign-in link",
+		ErrorMagicLink:       "That sign-in link is invalid, has expired or was already used. Request a new one.",
+		ContinueButton:       "Continue",
+		ChangeEmail:          "Use a different email",
+		ErrorSSORequired:     "Your organization requires you to sign in with its single sign-on provider.",
+		ErrorConsentDeclined: "You need to accept the terms to use your account.",
+		ErrorSessionExpired:  "Your session expired. Sign in again to continue.",
+		PreviousSlide:        "Previous slide",
+		NextSlide:            "Next slide",
+		ContinueWith:         "Continue with",
 	}
 }
 
@@ -53,6 +69,14 @@ func DefaultSignup02Labels() Signup02Labels {
 		SocialDivider:              "or sign up with",
 		TermsText:                  "By signing up, you agree to our Terms and Privacy Policy.",
 		Error:                      "Registration failed. Please try again.",
+		ErrorMismatch:              "The two passwords don't match. Please enter the same value in both fields.",
+		ErrorEmailTaken:            "An account with this email already exists. Sign in instead.",
+		ErrorWeakPassword:          "Your password is too short. Choose at least 8 characters.",
+		ErrorInvalidEmail:          "Please enter a valid email address.",
+		ErrorThrottled:             "Too many sign-up attempts. Please wait a while and try again.",
+		ErrorPasswordClasses:       "Mix at least two of lowercase, uppercase, digits and symbols.",
+		ErrorPasswordBreached:      "This password has appeared in a data breach. Choose a different one.",
+		ErrorPasswordContainsEmail: "Your password must not contain your email address.",
 		PreviousSlide:              "Previous slide",
 		NextSlide:                  "Next slide",
 		ContinueWith:               "Continue with",
@@ -85,11 +109,154 @@ func DefaultResetPassword02Labels() ResetPassword02Labels {
 		ErrorInvalidToken:          "This reset link is no longer valid. Request a new one to continue.",
 		ErrorExpiredToken:          "This reset link has expired. Request a new one to continue.",
 		ErrorWeakPassword:          "Your new password is too short. Choose at least 8 characters.",
+		ErrorThrottled:             "Too many reset requests. Please wait a while and try again.",
+		ErrorPasswordClasses:       "Mix at least two of lowercase, uppercase, digits and symbols.",
+		ErrorPasswordBreached:      "This password has appeared in a data breach. Choose a different one.",
+		ErrorPasswordReused:        "You used this password recently. Choose one you haven't used before.",
+		ErrorPasswordContainsEmail: "Your password must not contain your email address.",
+		ErrorSSORequired:           "Your organization manages sign-in through its single sign-on provider, so the password can't be reset here.",
 		PreviousSlide:              "Previous slide",
 		NextSlide:                  "Next slide",
 	}
 }
 
+// DefaultMFALabels returns MFALabels populated with English defaults.
+func DefaultMFALabels() MFALabels {
+	return MFALabels{
+		Title:                "Two-step verification",
+		Heading:              "Two-step verification",
+		Subheading:           "Enter the 6-digit code from your authenticator app.",
+		CodeLabel:            "Verification code",
+		CodePlaceholder:      "123456",
+		VerifyButton:         "Verify",
+		UseRecoveryCode:      "Use a recovery code instead",
+		RecoveryHeading:      "Use a recovery code",
+		RecoverySubheading:   "Enter one of the recovery codes you saved when you turned on two-step verification. Each code works once.",
+		RecoveryCodeLabel:    "Recovery code",
+		UseAuthenticator:     "Use your authenticator app instead",
+		SetupHeading:         "Set up two-step verification",
+		SetupSubheading:      "This workspace requires a second sign-in step. Add this key to your authenticator app, then enter the code it shows.",
+		SecretLabel:          "Setup key",
+		OpenInApp:            "Open in authenticator app",
+		RecoveryCodesHeading: "Save your recovery codes",
+		RecoveryCodesHelp:    "Store these codes somewhere safe. Each one signs you in once if you lose access to your authenticator app. They won't be shown again.",
+		ContinueButton:       "Continue",
+		CancelLink:           "Cancel and sign out",
+		Error:                "Verification failed. Please try again.",
+		ErrorInvalidCode:     "That code isn't valid. Check your authenticator app and try again.",
+		ErrorThrottled:       "Too many attempts. Please wait a few minutes and try again.",
+	}
+}
+
+// DefaultVerifyEmailLabels returns VerifyEmailLabels populated with English defaults.
+func DefaultVerifyEmailLabels() VerifyEmailLabels {
+	return VerifyEmailLabels{
+		Title:           "Verify your email",
+		Heading:         "Check your email",
+		Subheading:      "We sent a verification link to",
+		Help:            "Open the link to activate your account, then sign in. Didn't get it? Check your spam folder or send it again.",
+		ResendButton:    "Resend verification email",
+		ResentMessage:   "We sent you a new verification link.",
+		BackToLogin:     "Back to sign in",
+		Error:           "Something went wrong. Please try again.",
+		ErrorThrottled:  "Too many requests. Please wait a while before asking for another link.",
+		ErrorSendFailed: "We couldn't send the email right now. Please try again in a few minutes.",
+	}
+}
+
+// DefaultMagicLinkLabels returns MagicLinkLabels populated with English defaults.
+func DefaultMagicLinkLabels() MagicLinkLabels {
+	return MagicLinkLabels{
+		Title:            "Sign in with email",
+		Heading:          "Sign in with a link",
+		Subheading:       "Enter your email and we'll send you a link that signs you in — no password needed.",
+		EmailLabel:       "Email",
+		EmailPlaceholder: "Enter your email",
+		SendButton:       "Send sign-in link",
+		SentHeading:      "Check your email",
+		SentMessage:      "If that email has an account here, a sign-in link is on its way. It expires in 15 minutes.",
+		ConfirmHeading:   "Finish signing in",
+		ConfirmMessage:   "Continue to sign in as",
+		ConfirmButton:    "Sign in",
+		BackToLogin:      "Back to sign in",
+		Error:            "Something went wrong. Please try again.",
+		ErrorThrottled:   "Too many requests. Please wait a while before asking for another link.",
+	}
+}
+
+// DefaultAcceptInviteLabels returns AcceptInviteLabels populated with English
+// defaults.
+func DefaultAcceptInviteLabels() AcceptInviteLabels {
+	return AcceptInviteLabels{
+		Title:                      "Accept invitation",
+		Heading:                    "Join {workspace}",
+		Subheading:                 "{inviter} invited {email} to join the {workspace} workspace.",
+		SignInMessage:              "You already have an account. Enter your password to accept.",
+		SignUpMessage:              "Create your account to accept.",
+		EmailLabel:                 "Email",
+		FirstNameLabel:             "First name",
+		LastNameLabel:              "Last name",
+		PasswordLabel:              "Password",
+		PasswordPlaceholder:        "Enter your password",
+		ConfirmPasswordLabel:       "Confirm password",
+		ConfirmPasswordPlaceholder: "Re-enter your password",
+		SignInButton:               "Sign in and join",
+		SignUpButton:               "Create account and join",
+		InvalidHeading:             "Invitation unavailable",
+		InvalidMessage:             "This invitation is invalid, has expired or was withdrawn. Ask the person who invited you for a new one.",
+		BackToLogin:                "Back to sign in",
+		Error:                      "Something went wrong. Please try again.",
+		ErrorInvalid:               "Incorrect password.",
+		ErrorLocked:                "Too many failed attempts. Your account is temporarily locked — try again later.",
+		ErrorThrottled:             "Too many attempts. Please wait a moment and try again.",
+		ErrorMismatch:              "The two passwords don't match. Please enter the same value in both fields.",
+		ErrorWeakPassword:          "Your password is too short. Choose at least 8 characters.",
+		ErrorPasswordClasses:       "Mix at least two of lowercase, uppercase, digits and symbols.",
+		ErrorPasswordBreached:      "This password has appeared in a data breach. Choose a different one.",
+		ErrorPasswordContainsEmail: "Your password must not contain your email address.",
+		SSOMessage:                 "{workspace} signs in through your organization's single sign-on.",
+		SSOButton:                  "Continue with single sign-on",
+		ErrorSSOEmail:              "Sign in as {email} to accept this invitation.",
+	}
+}
+
+// DefaultAuthEmailLabels returns AuthEmailLabels populated with English defaults.
+func DefaultAuthEmailLabels() AuthEmailLabels {
+	return AuthEmailLabels{
+		ResetSubject:           "Reset your password",
+		ResetHeading:           "Reset your password",
+		ResetBody:              "We received a request to reset the password for your account. Use the button below to choose a new one.",
+		ResetButtonText:        "Reset password",
+		ResetExpiry:            "This link expires in 1 hour.",
+		WelcomeSubject:         "Welcome",
+		WelcomeHeading:         "Welcome aboard",
+		WelcomeBody:            "Your account is ready.",
+		WelcomeButtonText:      "Sign in",
+		PasswordChangedSubject: "Your password was changed",
+		PasswordChangedHeading: "Your password was changed",
+		PasswordChangedBody:    "The password for your account was just changed.",
+		VerifySubject:          "Verify your email address",
+		VerifyHeading:          "Confirm your email",
+		VerifyBody:             "Thanks for signing up. Confirm this is your email address to activate your account.",
+		VerifyButtonText:       "Verify email",
+		VerifyExpiry:           "This link expires in 24 hours.",
+		MagicLinkSubject:       "Your sign-in link",
+		MagicLinkHeading:       "Sign in to your account",
+		MagicLinkBody:          "Use the button below to sign in. The link works once.",
+		MagicLinkButtonText:    "Sign in",
+		MagicLinkExpiry:        "This link expires in 15 minutes.",
+		InvitationSubject:      "You're invited to join {workspace}",
+		InvitationHeading:      "Join {workspace}",
+		InvitationBody:         "{inviter} invited you to join the {workspace} workspace. Accept the invitation to get started.",
+		InvitationButtonText:   "Accept invitation",
+		InvitationExpiry:       "This invitation expires in 7 days.",
+		AlertTimeLabel:         "Time",
+		AlertIPLabel:           "IP address",
+		AlertHelp:              "If this wasn't you, reset your password right away and contact your administrator.",
+		SecurityNotice:         "If you didn't request this, you can ignore this email.",
+	}
+}
+
 // DefaultChangePasswordLabels returns ChangePasswordLabels populated with English defaults.
 func DefaultChangePasswordLabels() ChangePasswordLabels {
 	return ChangePasswordLabels{
@@ -108,6 +275,78 @@ func DefaultChangePasswordLabels() ChangePasswordLabels {
 		ErrorMismatch:              "The two new-password fields don't match. Please retype to confirm.",
 		ErrorCurrentIncorrect:      "Current password is incorrect",
 		ErrorTooShort:              "New password must be at least 8 characters",
+		ErrorPasswordClasses:       "Mix at least two of lowercase, uppercase, digits and symbols.",
+		ErrorPasswordBreached:      "This password has appeared in a data breach. Choose a different one.",
+		ErrorPasswordReused:        "You used this password recently. Choose one you haven't used before.",
+		ErrorPasswordContainsEmail: "Your password must not contain your email address.",
+		ErrorImpersonating:         "Passwords can't be changed while viewing as another user.",
 		BackToApp:                  "Back to dashboard",
 	}
 }
+
+// DefaultImpersonationLabels returns ImpersonationLabels populated with
+// English defaults.
+func DefaultImpersonationLabels() ImpersonationLabels {
+	return ImpersonationLabels{
+		Title:      "Viewing as {user}",
+		Message:    "Everything you do is recorded in the audit log. This session ends automatically at {time}.",
+		StopButton: "Stop impersonating",
+	}
+}
+
+// DefaultStepUpLabels returns English defaults for the step-up dialog.
+func DefaultStepUpLabels() StepUpLabels {
+	return StepUpLabels{
+		Title:          "Confirm it's you",
+		Message:        "This action needs a recent sign-in. Enter your password or a code from your authenticator app to continue.",
+		Password:       "Password",
+		Code:           "Verification code",
+		CodeHint:       "Or a recovery code.",
+		Submit:         "Confirm and continue",
+		Cancel:         "Cancel",
+		ErrorInvalid:   "That password or code is not right. Try again.",
+		ErrorThrottled: "Too many attempts. Wait a moment and try again.",
+		ErrorExpired:   "This confirmation expired. Close this dialog and try the action again.",
+	}
+}
+
+// DefaultSessionExpiryLabels returns English defaults for the re-login
+// dialog.
+func DefaultSessionExpiryLabels() SessionExpiryLabels {
+	return SessionExpiryLabels{
+		Title:          "Your session expired",
+		Message:        "Sign in again to pick up where you left off. Nothing you entered has been lost.",
+		Password:       "Password",
+		Code:           "Verification code",
+		CodeHint:       "Or a recovery code.",
+		Submit:         "Sign in and continue",
+		Cancel:         "Cancel",
+		ErrorInvalid:   "That password or code is not right. Try again.",
+		ErrorThrottled: "Too many attempts. Wait a moment and try again.",
+		ErrorExpired:   "This sign-in prompt expired. Reload the page to sign in again.",
+	}
+}
+
+// DefaultConsentLabels returns ConsentLabels populated with English defaults.
+func DefaultConsentLabels() ConsentLabels {
+	return ConsentLabels{
+		Title:         "Review our terms",
+		Heading:       "Before you continue",
+		Subheading:    "We've updated our terms. Please review and accept them to continue.",
+		VersionLabel:  "Version",
+		AcceptLabel:   "I have read and accept this document",
+		AcceptButton:  "Accept and continue",
+		DeclineButton: "Decline and sign out",
+		Error:         "We couldn't save your answer. Please sign in again.",
+		ErrorRequired: "Accept each document to continue.",
+	}
+}
+
+// DefaultActingAsLabels returns ActingAsLabels populated with English
+// defaults.
+func DefaultActingAsLabels() ActingAsLabels {
+	return ActingAsLabels{
+		Label:  "Acting for",
+		Switch: "Switch",
+	}
+}
@@ -31,9 +31,70 @@ const (
 	AuthResetConfirmPostURL  = "/auth/reset-password/confirm"
 	AuthChangePasswordURL    = "/auth/change-password"
 	AuthLogoutURL            = "/auth/logout"
+	// AuthLoginDiscoverURL is the identifier-first step of login02: the
+	// email alone is POSTed here and the home-realm lookup decides between
+	// the password step and the workspace's identity provider.
+	AuthLoginDiscoverURL = "/auth/login/discover"
 	// AuthFirebaseLoginURL is the Firebase ID-token login POST. Under /auth/ so
 	// it shares the session-exclude + CSRF-exempt posture of /auth/login.
 	AuthFirebaseLoginURL = "/auth/firebase"
+	// AuthMFAURL is the second-factor step between the credential check and
+	// principal routing (GET challenge/setup page, POST code).
+	AuthMFAURL     = "/auth/mfa"
+	AuthMFAPostURL = "/auth/mfa"
+	// Passkey (WebAuthn) endpoints, all JSON. The options/verify pairs are
+	// sign-in (login02) and registration (account page, session cookie
+	// re-validated by the handler).
+	AuthPasskeyOptionsURL         = "/auth/passkey/options"
+	AuthPasskeyLoginURL           = "/auth/passkey"
+	AuthPasskeyRegisterOptionsURL = "/auth/passkey/register/options"
+	AuthPasskeyRegisterURL        = "/auth/passkey/register"
+	// Generic OIDC sign-in (authorization code + PKCE). Start takes
+	// ?provider=<id>; the callback is the redirect URI registered with
+	// every configured IdP.
+	AuthOIDCStartURL    = "/auth/oidc/start"
+	AuthOIDCCallbackURL = "/auth/oidc/callback"
+	// Email verification after self-signup. GET without ?token= renders the
+	// "check your email" page; with ?token= it confirms the address.
+	AuthVerifyEmailURL       = "/auth/verify-email"
+	AuthVerifyEmailResendURL = "/auth/verify-email/resend"
+	// Passwordless magic-link sign-in. GET renders the request form (POST
+	// mails the link); the emailed link opens the verify page, whose POST
+	// consumes it — a link prefetched by a mail scanner stays unused.
+	AuthMagicLinkURL       = "/auth/magic-link"
+	AuthMagicLinkPostURL   = "/auth/magic-link"
+	AuthMagicLinkVerifyURL = "/auth/magic-link/verify"
+	// Workspace invitations. The emailed link opens the accept page
+	// (?token=…), whose POST signs the invitee in — or signs them up — and
+	// joins them to the workspace.
+	AuthAcceptInviteURL     = "/auth/accept-invite"
+	AuthAcceptInvitePostURL = "/auth/accept-invite"
+	// Audited impersonation ("view as user"). The admin action hands out a
+	// short-lived signed start link (?grant=…); stop is the banner's POST.
+	AuthImpersonateURL     = "/auth/impersonate"
+	AuthImpersonateStopURL = "/auth/impersonate/stop"
+	// Terms-of-service / privacy consent step between the credential check
+	// and principal routing, shown while the user has not accepted the
+	// current version of every consent document.
+	AuthConsentURL     = "/auth/consent"
+	AuthConsentPostURL = "/auth/consent"
+
+	// Step-up re-authentication dialog (GET) and its submit (POST). Served
+	// by auth.StepUpMiddleware inside the session middleware, hence under
+	// /action/ rather than /auth/.
+	AuthStepUpURL = "/action/auth/step-up"
+	// Re-login dialog (GET) and its submit (POST) for a session past its
+	// idle or absolute lifetime. Served by auth.SessionLifetimeMiddleware,
+	// like the step-up dialog.
+	AuthSessionReloginURL = "/action/auth/session/relogin"
+	// Acting-as switcher for multi-target delegates: the portal header loads
+	// the dropdown fragment (GET) and posts the chosen target to the switch
+	// endpoint, which rotates the session like the principal chooser.
+	AuthActingAsURL       = "/action/auth/acting-as"
+	AuthActingAsSwitchURL = "/action/auth/acting-as/switch"
+	// Download of a ready self-service data export (ZIP), linked from the
+	// portal account page.
+	AuthDataExportURL = "/action/auth/data-export/{id}"
 
 	// Legacy login routes (redirect to /auth/login)
 	LoginURL     = "/login"
@@ -136,44 +136,83 @@ func (r LoginRoutes) RouteMap() map[string]string {
 
 // AuthRoutes holds all route paths for authentication views (signup, reset, logout).
 type AuthRoutes struct {
-	LoginURL             string `json:"login_url"`
-	LoginPostURL         string `json:"login_post_url"`
-	SignupURL            string `json:"signup_url"`
-	SignupPostURL        string `json:"signup_post_url"`
-	ResetPasswordURL     string `json:"reset_password_url"`
-	ResetPasswordPostURL string `json:"reset_password_post_url"`
-	ResetConfirmURL      string `json:"reset_confirm_url"`
-	ResetConfirmPostURL  string `json:"reset_confirm_post_url"`
-	LogoutURL            string `json:"logout_url"`
+	LoginURL                  string `json:"login_url"`
+	LoginPostURL              string `json:"login_post_url"`
+	SignupURL                 string `json:"signup_url"`
+	SignupPostURL             string `json:"signup_post_url"`
+	ResetPasswordURL          string `json:"reset_password_url"`
+	ResetPasswordPostURL      string `json:"reset_password_post_url"`
+	ResetConfirmURL           string `json:"reset_confirm_url"`
+	ResetConfirmPostURL       string `json:"reset_confirm_post_url"`
+	LogoutURL                 string `json:"logout_url"`
+	MFAURL                    string `json:"mfa_url"`
+	MFAPostURL                string `json:"mfa_post_url"`
+	PasskeyOptionsURL         string `json:"passkey_options_url"`
+	PasskeyLoginURL           string `json:"passkey_login_url"`
+	PasskeyRegisterOptionsURL string `json:"passkey_register_options_url"`
+	PasskeyRegisterURL        string `json:"passkey_register_url"`
+	OIDCStartURL              string `json:"oidc_start_url"`
+	OIDCCallbackURL           string `json:"oidc_callback_url"`
+	VerifyEmailURL            string `json:"verify_email_url"`
+	VerifyEmailResendURL      string `json:"verify_email_resend_url"`
+	MagicLinkURL              string `json:"magic_link_url"`
+	MagicLinkPostURL          string `json:"magic_link_post_url"`
+	MagicLinkVerifyURL        string `json:"magic_link_verify_url"`
 }
 
 // DefaultAuthRoutes returns an AuthRoutes populated from the package-level
 // URL constants defined in routes.go.
 func DefaultAuthRoutes() AuthRoutes {
 	return AuthRoutes{
-		LoginURL:             AuthLoginURL,
-		LoginPostURL:         AuthLoginPostURL,
-		SignupURL:            AuthSignupURL,
-		SignupPostURL:        AuthSignupPostURL,
-		ResetPasswordURL:     AuthResetPasswordURL,
-		ResetPasswordPostURL: AuthResetPasswordPostURL,
-		ResetConfirmURL:      AuthResetConfirmURL,
-		ResetConfirmPostURL:  AuthResetConfirmPostURL,
-		LogoutURL:            AuthLogoutURL,
+		LoginURL:                  AuthLoginURL,
+		LoginPostURL:              AuthLoginPostURL,
+		SignupURL:                 AuthSignupURL,
+		SignupPostURL:             AuthSignupPostURL,
+		ResetPasswordURL:          AuthResetPasswordURL,
+		ResetPasswordPostURL:      AuthResetPasswordPostURL,
+		ResetConfirmURL:           AuthResetConfirmURL,
+		ResetConfirmPostURL:       AuthResetConfirmPostURL,
+		LogoutURL:                 AuthLogoutURL,
+		MFAURL:                    AuthMFAURL,
+		MFAPostURL:                AuthMFAPostURL,
+		PasskeyOptionsURL:         AuthPasskeyOptionsURL,
+		PasskeyLoginURL:           AuthPasskeyLoginURL,
+		PasskeyRegisterOptionsURL: AuthPasskeyRegisterOptionsURL,
+		PasskeyRegisterURL:        AuthPasskeyRegisterURL,
+		OIDCStartURL:              AuthOIDCStartURL,
+		OIDCCallbackURL:           AuthOIDCCallbackURL,
+		VerifyEmailURL:            AuthVerifyEmailURL,
+		VerifyEmailResendURL:      AuthVerifyEmailResendURL,
+		MagicLinkURL:              AuthMagicLinkURL,
+		MagicLinkPostURL:          AuthMagicLinkPostURL,
+		MagicLinkVerifyURL:        AuthMagicLinkVerifyURL,
 	}
 }
 
 // RouteMap returns a map of route keys to URL paths for AuthRoutes.
 func (r AuthRoutes) RouteMap() map[string]string {
 	return map[string]string{
-		"auth.login.page":          r.LoginURL,
-		"auth.login.post":          r.LoginPostURL,
-		"auth.signup.page":         r.SignupURL,
-		"auth.signup.post":         r.SignupPostURL,
-		"auth.reset-password.page": r.ResetPasswordURL,
-		"auth.reset-password.post": r.ResetPasswordPostURL,
-		"auth.reset-confirm.page":  r.ResetConfirmURL,
-		"auth.reset-confirm.post":  r.ResetConfirmPostURL,
-		"auth.logout":              r.LogoutURL,
+		"auth.login.page":               r.LoginURL,
+		"auth.login.post":               r.LoginPostURL,
+		"auth.signup.page":              r.SignupURL,
+		"auth.signup.post":              r.SignupPostURL,
+		"auth.reset-password.page":      r.ResetPasswordURL,
+		"auth.reset-password.post":      r.ResetPasswordPostURL,
+		"auth.reset-confirm.page":       r.ResetConfirmURL,
+		"auth.reset-confirm.post":       r.ResetConfirmPostURL,
+		"auth.logout":                   r.LogoutURL,
+		"auth.mfa.page":                 r.MFAURL,
+		"auth.mfa.post":                 r.MFAPostURL,
+		"auth.passkey.options":          r.PasskeyOptionsURL,
+		"auth.passkey.login":            r.PasskeyLoginURL,
+		"auth.passkey.register_options": r.PasskeyRegisterOptionsURL,
+		"auth.passkey.register":         r.PasskeyRegisterURL,
+		"auth.oidc.start":               r.OIDCStartURL,
+		"auth.oidc.callback":            r.OIDCCallbackURL,
+		"auth.verify-email.page":        r.VerifyEmailURL,
+		"auth.verify-email.resend":      r.VerifyEmailResendURL,
+		"auth.magic-link.page":          r.MagicLinkURL,
+		"auth.magic-link.post":          r.MagicLinkPostURL,
+		"auth.magic-link.verify":        r.MagicLinkVerifyURL,
 	}
 }
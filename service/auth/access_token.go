@@ -0,0 +1,468 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// Personal access tokens let integrations — usually service-account users —
+// call the app with "Authorization: Bearer <token>" instead of a session
+// cookie. A token belongs to one user, works in one workspace and carries a
+// subset of that user's permission codes there. Only its SHA-256 is stored;
+// the secret is shown once, when it is issued.
+
+// Access token errors. Callers map them to labels.
+var (
+	ErrAccessTokensDisabled = errors.New("auth: access tokens are not configured")
+	ErrAccessTokenNotFound  = errors.New("auth: access token not found")
+	ErrAccessTokenInvalid   = errors.New("auth: access token is invalid, expired or revoked")
+	ErrAccessTokenRequest   = errors.New("auth: an access token needs a name, a workspace and at least one permission")
+	ErrAccessTokenScope     = errors.New("auth: access token permissions exceed what the owner holds")
+)
+
+const (
+	// accessTokenPrefix marks the secrets so leaked ones are easy to spot
+	// (secret scanners, logs).
+	accessTokenPrefix = "pat_"
+
+	// accessTokenNameMaxLen caps the user-chosen token name (runes).
+	accessTokenNameMaxLen = 100
+
+	// accessTokenTouchInterval throttles the last-used write: a busy
+	// integration does not update the store on every request.
+	accessTokenTouchInterval = time.Minute
+)
+
+// PermissionCodes returns the permission codes ("entity:action") userID
+// holds in workspaceID — the same role → permission lookup the session
+// permission chain feeds into view.WithUserPermissions. Injected as a
+// closure; an empty result is not an error. PermissionGrants and
+// PermissionCatalog take its place when set (see EffectivePermissionCodes).
+type PermissionCodes func(ctx context.Context, userID, workspaceID string) ([]string, error)
+
+type ctxKeyAccessTokenType struct{}
+
+// ctxKeyAccessToken marks a request BearerTokenMiddleware authenticated;
+// the value is the token ID.
+var ctxKeyAccessToken = ctxKeyAccessTokenType{}
+
+// AccessToken is a stored personal access token. Hash is the hex SHA-256 of
+// the secret; Hint is the secret's first characters, to tell tokens apart.
+// IssuedBy is the administrator who issued a service account's token, empty
+// when the owner issued it.
+type AccessToken struct {
+	ID          string
+	UserID      string
+	Name        string
+	WorkspaceID string
+	Permissions []string
+	Hash        string
+	Hint        string
+	IssuedBy    string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	LastUsedAt  time.Time
+	RevokedAt   time.Time
+}
+
+// AccessTokenStore persists access tokens. GetAccessTokenByHash returns
+// (nil, nil) for an unknown hash; RevokeAccessToken returns
+// ErrAccessTokenNotFound when id is not one of userID's tokens. Satisfied by
+// the host's token repository; NewMemoryAccessTokenStore is the per-process
+// default for tests.
+type AccessTokenStore interface {
+	SaveAccessToken(ctx context.Context, token AccessToken) error
+	GetAccessTokenByHash(ctx context.Context, hash string) (*AccessToken, error)
+	ListAccessTokens(ctx context.Context, userID string) ([]AccessToken, error)
+	UpdateAccessTokenUsage(ctx context.Context, id string, usedAt time.Time) error
+	RevokeAccessToken(ctx context.Context, userID, id string, revokedAt time.Time) error
+}
+
+// AccessTokenRequest is what IssueAccessToken needs. ExpiresAt zero means
+// the longest lifetime allowed (Deps.AccessTokenMaxAge). IssuedBy is set
+// when an administrator issues a token for a service account; the token's
+// permissions must then be held by both.
+type AccessTokenRequest struct {
+	UserID      string
+	Name        string
+	WorkspaceID string
+	Permissions []string
+	ExpiresAt   time.Time
+	IssuedBy    string
+}
+
+// AccessTokenSummary is the account-page view of a token.
+type AccessTokenSummary struct {
+	ID          string
+	Name        string
+	WorkspaceID string
+	Permissions []string
+	Hint        string
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+	LastUsedAt  time.Time
+	Revoked     bool
+}
+
+// Expired reports whether the token has passed its expiry.
+func (s AccessTokenSummary) Expired() bool {
+	return !s.ExpiresAt.IsZero() && !time.Now().Before(s.ExpiresAt)
+}
+
+// AccessTokenGrant is a validated bearer token: who it acts for, where, and
+// the permission codes it carries right now — the token's scope narrowed to
+// what the owner still holds.
+type AccessTokenGrant struct {
+	TokenID     string
+	UserID      string
+	WorkspaceID string
+	Permissions []string
+}
+
+func (m *AuthModule) accessTokensEnabled() bool {
+	return m.deps.AccessTokenStore != nil && (m.deps.PermissionCodes != nil || m.effectivePermissionsEnabled())
+}
+
+// IssueAccessToken mints a token for req.UserID and returns its secret,
+// which is not stored and cannot be shown again. Every requested permission
+// must be held by the owner (and the issuing administrator) in the
+// workspace. Refused inside an impersonated session.
+func (m *AuthModule) IssueAccessToken(ctx context.Context, req AccessTokenRequest) (secret string, token AccessTokenSummary, err error) {
+	if !m.accessTokensEnabled() {
+		return "", AccessTokenSummary{}, ErrAccessTokensDisabled
+	}
+	if m.impersonating(ctx, nil) {
+		return "", AccessTokenSummary{}, ErrImpersonating
+	}
+	name := strings.TrimSpace(req.Name)
+	if runes := []rune(name); len(runes) > accessTokenNameMaxLen {
+		name = string(runes[:accessTokenNameMaxLen])
+	}
+	perms := uniqueCodes(req.Permissions)
+	if req.UserID == "" || name == "" || req.WorkspaceID == "" || len(perms) == 0 {
+		return "", AccessTokenSummary{}, ErrAccessTokenRequest
+	}
+	now := time.Now().UTC()
+	latest := now.Add(m.deps.AccessTokenMaxAge)
+	expires := req.ExpiresAt.UTC()
+	if expires.IsZero() || expires.After(latest) {
+		expires = latest
+	}
+	if !expires.After(now) {
+		return "", AccessTokenSummary{}, ErrAccessTokenRequest
+	}
+	holders := []string{req.UserID}
+	if req.IssuedBy != "" && req.IssuedBy != req.UserID {
+		holders = append(holders, req.IssuedBy)
+	}
+	for _, holder := range holders {
+		held, err := m.EffectivePermissionCodes(ctx, holder, req.WorkspaceID)
+		if err != nil {
+			return "", AccessTokenSummary{}, err
+		}
+		if len(intersectCodes(perms, held)) != len(perms) {
+			return "", AccessTokenSummary{}, ErrAccessTokenScope
+		}
+	}
+
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", AccessTokenSummary{}, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", AccessTokenSummary{}, err
+	}
+	secret = accessTokenPrefix + base64.RawURLEncoding.EncodeToString(raw)
+	t := AccessToken{
+		ID:          hex.EncodeToString(id),
+		UserID:      req.UserID,
+		Name:        name,
+		WorkspaceID: req.WorkspaceID,
+		Permissions: perms,
+		Hash:        hashAccessToken(secret),
+		Hint:        secret[:len(accessTokenPrefix)+6],
+		IssuedBy:    req.IssuedBy,
+		CreatedAt:   now,
+		ExpiresAt:   expires,
+	}
+	if err := m.deps.AccessTokenStore.SaveAccessToken(ctx, t); err != nil {
+		return "", AccessTokenSummary{}, err
+	}
+	log.Printf("[AUTH] access token issued: user=%s workspace=%s token=%s by=%s permissions=%d",
+		t.UserID, t.WorkspaceID, t.ID, t.IssuedBy, len(t.Permissions))
+	return secret, summarizeAccessToken(t), nil
+}
+
+// ListAccessTokens returns userID's tokens, revoked and expired ones
+// included, newest first.
+func (m *AuthModule) ListAccessTokens(ctx context.Context, userID string) ([]AccessTokenSummary, error) {
+	if m.deps.AccessTokenStore == nil {
+		return nil, nil
+	}
+	tokens, err := m.deps.AccessTokenStore.ListAccessTokens(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]AccessTokenSummary, 0, len(tokens))
+	for _, t := range tokens {
+		out = append(out, summarizeAccessToken(t))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// RevokeAccessToken revokes one of userID's tokens; it stops working at
+// once.
+func (m *AuthModule) RevokeAccessToken(ctx context.Context, userID, tokenID string) error {
+	if m.deps.AccessTokenStore == nil {
+		return ErrAccessTokenNotFound
+	}
+	if m.impersonating(ctx, nil) {
+		return ErrImpersonating
+	}
+	if err := m.deps.AccessTokenStore.RevokeAccessToken(ctx, userID, strings.TrimSpace(tokenID), time.Now().UTC()); err != nil {
+		return err
+	}
+	log.Printf("[AUTH] access token revoked: user=%s token=%s", userID, tokenID)
+	return nil
+}
+
+// AccessTokenScopes returns the permission codes userID could put on a
+// token for workspaceID, sorted — the choices for the issue form.
+func (m *AuthModule) AccessTokenScopes(ctx context.Context, userID, workspaceID string) ([]string, error) {
+	if !m.accessTokensEnabled() {
+		return nil, ErrAccessTokensDisabled
+	}
+	held, err := m.EffectivePermissionCodes(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	codes := uniqueCodes(held)
+	sort.Strings(codes)
+	return codes, nil
+}
+
+// ValidateAccessToken checks a bearer secret and returns what it grants. A
+// revoked or expired token, or one whose owner no longer holds any of its
+// permissions, is ErrAccessTokenInvalid.
+func (m *AuthModule) ValidateAccessToken(ctx context.Context, secret string) (*AccessTokenGrant, error) {
+	if !m.accessTokensEnabled() {
+		return nil, ErrAccessTokensDisabled
+	}
+	if !strings.HasPrefix(secret, accessTokenPrefix) {
+		return nil, ErrAccessTokenInvalid
+	}
+	t, err := m.deps.AccessTokenStore.GetAccessTokenByHash(ctx, hashAccessToken(secret))
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if t == nil || !t.RevokedAt.IsZero() || !now.Before(t.ExpiresAt) {
+		return nil, ErrAccessTokenInvalid
+	}
+	// Narrow to what the owner holds now, so a role removed since the
+	// token was issued is removed from the token too.
+	held, err := m.EffectivePermissionCodes(ctx, t.UserID, t.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	perms := intersectCodes(t.Permissions, held)
+	if len(perms) == 0 {
+		return nil, ErrAccessTokenInvalid
+	}
+	if now.Sub(t.LastUsedAt) >= accessTokenTouchInterval {
+		if err := m.deps.AccessTokenStore.UpdateAccessTokenUsage(ctx, t.ID, now.UTC()); err != nil {
+			log.Printf("[AUTH] access token: usage update failed for token %s: %v", t.ID, err)
+		}
+	}
+	return &AccessTokenGrant{TokenID: t.ID, UserID: t.UserID, WorkspaceID: t.WorkspaceID, Permissions: perms}, nil
+}
+
+// BearerTokenMiddleware authenticates requests that carry
+// "Authorization: Bearer <token>": the request identity (user and
+// workspace) and the permissions view.GetUserPermissions reads are set from
+// the token, and its cookies are dropped so no browser session can mix in.
+// A bad token is answered 401, and a token used under another workspace's
+// /w/{slug}/ pages (or where WorkspaceSlugResolver cannot tell the
+// token's slug) 403. Requests without the header pass through
+// untouched. Wrap the app handler with it outside the session middleware,
+// and let the session middleware pass requests that already carry a
+// RequestIdentity. Returns next unchanged when tokens are not configured.
+func (m *AuthModule) BearerTokenMiddleware(next http.Handler) http.Handler {
+	if !m.accessTokensEnabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, secret, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !ok || !strings.EqualFold(scheme, "Bearer") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		grant, err := m.ValidateAccessToken(r.Context(), strings.TrimSpace(secret))
+		if err != nil {
+			if !errors.Is(err, ErrAccessTokenInvalid) {
+				log.Printf("[AUTH] access token: validation failed: %v", err)
+			}
+			w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if strings.HasPrefix(r.URL.Path, "/w/") && !m.inTokenWorkspace(r, grant.WorkspaceID) {
+			log.Printf("[AUTH] access token %s: refused outside its workspace: %s", grant.TokenID, r.URL.Path)
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+		ctx := identity.WithRequestIdentity(r.Context(), &identity.RequestIdentity{
+			UserID:      grant.UserID,
+			WorkspaceID: grant.WorkspaceID,
+		})
+		ctx = view.WithUserPermissions(ctx, types.NewUserPermissions(grant.Permissions))
+		ctx = context.WithValue(ctx, ctxKeyAccessToken, grant.TokenID)
+		r = r.WithContext(ctx)
+		r.Header.Del("Cookie")
+		next.ServeHTTP(w, r)
+	})
+}
+
+// inTokenWorkspace reports whether a /w/{slug}/ request is under the
+// token's own workspace.
+func (m *AuthModule) inTokenWorkspace(r *http.Request, workspaceID string) bool {
+	if m.deps.WorkspaceSlugResolver == nil {
+		return false
+	}
+	slug := m.deps.WorkspaceSlugResolver(r.Context(), workspaceID)
+	return slug != "" && strings.HasPrefix(r.URL.Path+"/", "/w/"+slug+"/")
+}
+
+func hashAccessToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func summarizeAccessToken(t AccessToken) AccessTokenSummary {
+	return AccessTokenSummary{
+		ID:          t.ID,
+		Name:        t.Name,
+		WorkspaceID: t.WorkspaceID,
+		Permissions: append([]string(nil), t.Permissions...),
+		Hint:        t.Hint,
+		CreatedAt:   t.CreatedAt,
+		ExpiresAt:   t.ExpiresAt,
+		LastUsedAt:  t.LastUsedAt,
+		Revoked:     !t.RevokedAt.IsZero(),
+	}
+}
+
+// uniqueCodes trims codes and drops empty and repeated ones, keeping order.
+func uniqueCodes(codes []string) []string {
+	seen := make(map[string]bool, len(codes))
+	out := make([]string, 0, len(codes))
+	for _, c := range codes {
+		c = strings.TrimSpace(c)
+		if c != "" && !seen[c] {
+			seen[c] = true
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// intersectCodes returns the codes of want that held also contains.
+func intersectCodes(want, held []string) []string {
+	has := make(map[string]bool, len(held))
+	for _, c := range held {
+		has[c] = true
+	}
+	var out []string
+	for _, c := range want {
+		if has[c] {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// MemoryAccessTokenStore is an in-process AccessTokenStore for tests and
+// single-instance development hosts.
+type MemoryAccessTokenStore struct {
+	mu     sync.Mutex
+	tokens map[string]AccessToken // keyed by id
+}
+
+// NewMemoryAccessTokenStore returns an empty in-memory token store.
+func NewMemoryAccessTokenStore() *MemoryAccessTokenStore {
+	return &MemoryAccessTokenStore{tokens: make(map[string]AccessToken)}
+}
+
+func (s *MemoryAccessTokenStore) SaveAccessToken(_ context.Context, t AccessToken) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t.Permissions = append([]string(nil), t.Permissions...)
+	s.tokens[t.ID] = t
+	return nil
+}
+
+func (s *MemoryAccessTokenStore) GetAccessTokenByHash(_ context.Context, hash string) (*AccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, t := range s.tokens {
+		if t.Hash == hash {
+			return &t, nil
+		}
+	}
+	return nil, nil
+}
+
+func (s *MemoryAccessTokenStore) ListAccessTokens(_ context.Context, userID string) ([]AccessToken, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []AccessToken
+	for _, t := range s.tokens {
+		if t.UserID == userID {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryAccessTokenStore) UpdateAccessTokenUsage(_ context.Context, id string, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[id]
+	if !ok {
+		return ErrAccessTokenNotFound
+	}
+	t.LastUsedAt = usedAt
+	s.tokens[id] = t
+	return nil
+}
+
+func (s *MemoryAccessTokenStore) RevokeAccessToken(_ context.Context, userID, id string, revokedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	t, ok := s.tokens[id]
+	if !ok || t.UserID != userID {
+		return ErrAccessTokenNotFound
+	}
+	if t.RevokedAt.IsZero() {
+		t.RevokedAt = revokedAt
+		s.tokens[id] = t
+	}
+	return nil
+}
@@ -0,0 +1,166 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+func TestAccessToken_BearerCarriesScopedPermissions(t *testing.T) {
+	t.Parallel()
+	var mu sync.Mutex
+	held := map[string][]string{
+		"user-ana": {"client:list", "client:read", "invoice:list", "user:update"},
+		"svc-sync": {"client:list", "client:read"},
+	}
+	store := NewMemoryAccessTokenStore()
+	m := NewAuthModule(&Deps{
+		AccessTokenStore: store,
+		WorkspaceSlugResolver: func(_ context.Context, workspaceID string) string {
+			return map[string]string{"ws-1": "acme", "ws-2": "globex"}[workspaceID]
+		},
+		PermissionCodes: func(_ context.Context, userID, workspaceID string) ([]string, error) {
+			mu.Lock()
+			defer mu.Unlock()
+			if workspaceID != "ws-1" {
+				return nil, nil
+			}
+			return held[userID], nil
+		},
+	})
+	ctx := context.Background()
+
+	secret, tok, err := m.IssueAccessToken(ctx, AccessTokenRequest{
+		UserID: "user-ana", Name: "CI export", WorkspaceID: "ws-1",
+		Permissions: []string{"client:list", "invoice:list", "client:list"},
+	})
+	if err != nil {
+		t.Fatalf("issue: %v", err)
+	}
+	if !strings.HasPrefix(secret, accessTokenPrefix) || !strings.HasPrefix(secret, tok.Hint) || len(tok.Permissions) != 2 {
+		t.Fatalf("issued %q %+v", secret, tok)
+	}
+	if want := time.Now().Add(m.deps.AccessTokenMaxAge); tok.ExpiresAt.After(want) {
+		t.Errorf("ExpiresAt = %v, want at most %v", tok.ExpiresAt, want)
+	}
+	stored, _ := store.ListAccessTokens(ctx, "user-ana")
+	if len(stored) != 1 || stored[0].Hash == "" || strings.Contains(stored[0].Hash, secret) {
+		t.Fatalf("stored %+v", stored)
+	}
+
+	// A permission the owner lacks, or the issuing administrator lacks.
+	for _, req := range []AccessTokenRequest{
+		{UserID: "user-ana", Name: "too wide", WorkspaceID: "ws-1", Permissions: []string{"role:update"}},
+		{UserID: "svc-sync", Name: "sync", WorkspaceID: "ws-1", Permissions: []string{"client:read"}, IssuedBy: "user-bo"},
+		{UserID: "user-ana", Name: "  ", WorkspaceID: "ws-1", Permissions: []string{"client:list"}},
+	} {
+		if _, _, err := m.IssueAccessToken(ctx, req); !errors.Is(err, ErrAccessTokenScope) && !errors.Is(err, ErrAccessTokenRequest) {
+			t.Errorf("issue %+v: err = %v", req, err)
+		}
+	}
+
+	var gotID *identity.RequestIdentity
+	var canList, canUpdate bool
+	var gotCookie string
+	h := m.BearerTokenMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotID, _ = identity.FromContext(r.Context())
+		perms := view.GetUserPermissions(r.Context())
+		canList, canUpdate = perms.Can("client", "list"), perms.Can("user", "update")
+		gotCookie = r.Header.Get("Cookie")
+	}))
+	callPath := func(path, auth string) int {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		req.AddCookie(&http.Cookie{Name: "ichizen_session", Value: "browser-session"})
+		rec := httptest.NewRecorder()
+		gotID = nil
+		h.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	call := func(auth string) int { return callPath("/api/clients", auth) }
+
+	if code := call("Bearer " + secret); code != http.StatusOK || gotID == nil || gotID.UserID != "user-ana" || gotID.WorkspaceID != "ws-1" {
+		t.Fatalf("bearer: %d %+v", code, gotID)
+	}
+	if !canList || canUpdate || gotCookie != "" {
+		t.Fatalf("bearer permissions: list=%v update=%v cookie=%q", canList, canUpdate, gotCookie)
+	}
+	if list, _ := m.ListAccessTokens(ctx, "user-ana"); len(list) != 1 || list[0].LastUsedAt.IsZero() {
+		t.Fatalf("last used not recorded: %+v", list)
+	}
+	// Workspace pages: only the token's own.
+	if code := callPath("/w/acme/clients", "Bearer "+secret); code != http.StatusOK {
+		t.Fatalf("own workspace: %d", code)
+	}
+	for _, path := range []string{"/w/globex/clients", "/w/acme-old/clients", "/w/"} {
+		if code := callPath(path, "Bearer "+secret); code != http.StatusForbidden {
+			t.Fatalf("%s: %d, want 403", path, code)
+		}
+	}
+	// No header: untouched, cookie and all.
+	if code := call(""); code != http.StatusOK || gotID != nil || gotCookie == "" {
+		t.Fatalf("no bearer: %d %+v %q", code, gotID, gotCookie)
+	}
+	if code := call("Bearer pat_forged"); code != http.StatusUnauthorized {
+		t.Fatalf("forged token: %d", code)
+	}
+
+	// Losing a role narrows the token; losing all of its permissions kills it.
+	mu.Lock()
+	held["user-ana"] = []string{"invoice:list"}
+	mu.Unlock()
+	if code := call("Bearer " + secret); code != http.StatusOK || canList {
+		t.Fatalf("narrowed: %d list=%v", code, canList)
+	}
+	mu.Lock()
+	held["user-ana"] = nil
+	mu.Unlock()
+	if code := call("Bearer " + secret); code != http.StatusUnauthorized {
+		t.Fatalf("no permissions left: %d", code)
+	}
+
+	// Revocation is owner-only and immediate.
+	mu.Lock()
+	held["user-ana"] = []string{"client:list", "invoice:list"}
+	mu.Unlock()
+	if err := m.RevokeAccessToken(ctx, "user-bo", tok.ID); !errors.Is(err, ErrAccessTokenNotFound) {
+		t.Fatalf("revoke by another user: %v", err)
+	}
+	if err := m.RevokeAccessToken(ctx, "user-ana", tok.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	if code := call("Bearer " + secret); code != http.StatusUnauthorized {
+		t.Fatalf("revoked token: %d", code)
+	}
+	if list, _ := m.ListAccessTokens(ctx, "user-ana"); len(list) != 1 || !list[0].Revoked {
+		t.Fatalf("list after revoke: %+v", list)
+	}
+
+	// Expired tokens are refused.
+	expiring, _, err := m.IssueAccessToken(ctx, AccessTokenRequest{
+		UserID: "user-ana", Name: "short", WorkspaceID: "ws-1",
+		Permissions: []string{"client:list"}, ExpiresAt: time.Now().Add(time.Hour),
+	})
+	if err != nil {
+		t.Fatalf("issue expiring: %v", err)
+	}
+	for _, st := range store.tokens {
+		if st.Name == "short" {
+			st.ExpiresAt = time.Now().Add(-time.Second)
+			store.tokens[st.ID] = st
+		}
+	}
+	if _, err := m.ValidateAccessToken(ctx, expiring); !errors.Is(err, ErrAccessTokenInvalid) {
+		t.Fatalf("expired token: %v", err)
+	}
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Self-service account deletion: ScheduleAccountDeletion books the user's
+// account for deletion once AccountDeletionGrace has passed. Until then the
+// user keeps signing in as usual and can cancel from the portal account
+// page. RunAccountDeletions, called from the host's scheduler, deletes the
+// data exports of each account whose grace period has ended and hands it
+// to Deps.AnonymizeUser.
+
+// Account deletion errors. ErrImpersonating is also returned: an
+// impersonator cannot schedule or cancel the deletion.
+var (
+	ErrAccountDeletionDisabled = errors.New("auth: account deletion is not configured")
+	ErrAccountDeletionNotFound = errors.New("auth: no account deletion is scheduled")
+)
+
+// AccountDeletion is a scheduled deletion.
+type AccountDeletion struct {
+	UserID       string
+	RequestedAt  time.Time
+	ScheduledFor time.Time
+}
+
+// AccountDeletionStore keeps scheduled deletions, one per user.
+// GetAccountDeletion returns nil, nil when none is scheduled;
+// DueAccountDeletions lists those scheduled at or before now.
+type AccountDeletionStore interface {
+	GetAccountDeletion(ctx context.Context, userID string) (*AccountDeletion, error)
+	PutAccountDeletion(ctx context.Context, d AccountDeletion) error
+	DeleteAccountDeletion(ctx context.Context, userID string) error
+	DueAccountDeletions(ctx context.Context, now time.Time) ([]AccountDeletion, error)
+}
+
+// AnonymizeUser strips the personal data from userID's user row (name,
+// email, phone, credentials), deactivates it and ends its sessions, leaving
+// the id for the records that reference it. Injected as a closure over the
+// host's user repository.
+type AnonymizeUser func(ctx context.Context, userID string) error
+
+func (m *AuthModule) accountDeletionEnabled() bool {
+	return m.deps.AccountDeletions != nil && m.deps.AnonymizeUser != nil
+}
+
+// AccountDeletionStatus returns the user's scheduled deletion, nil when
+// none is scheduled.
+func (m *AuthModule) AccountDeletionStatus(ctx context.Context, userID string) (*AccountDeletion, error) {
+	if !m.accountDeletionEnabled() {
+		return nil, ErrAccountDeletionDisabled
+	}
+	return m.deps.AccountDeletions.GetAccountDeletion(ctx, userID)
+}
+
+// ScheduleAccountDeletion books the user's account for deletion after the
+// grace period. Scheduling again keeps the original date.
+func (m *AuthModule) ScheduleAccountDeletion(ctx context.Context, userID string) (AccountDeletion, error) {
+	if !m.accountDeletionEnabled() {
+		return AccountDeletion{}, ErrAccountDeletionDisabled
+	}
+	if m.impersonating(ctx, nil) {
+		return AccountDeletion{}, ErrImpersonating
+	}
+	store := m.deps.AccountDeletions
+	existing, err := store.GetAccountDeletion(ctx, userID)
+	if err != nil {
+		return AccountDeletion{}, err
+	}
+	if existing != nil {
+		return *existing, nil
+	}
+	now := time.Now()
+	d := AccountDeletion{UserID: userID, RequestedAt: now, ScheduledFor: now.Add(m.deps.AccountDeletionGrace)}
+	if err := store.PutAccountDeletion(ctx, d); err != nil {
+		return AccountDeletion{}, err
+	}
+	log.Printf("[AUTH] account deletion scheduled: user=%s, for %s", userID, d.ScheduledFor.Format(time.RFC3339))
+	m.recordAuthEvent(ctx, nil, AuthEvent{Type: AuthEventAccountDeletionScheduled, UserID: userID})
+	return d, nil
+}
+
+// CancelAccountDeletion withdraws the user's scheduled deletion.
+func (m *AuthModule) CancelAccountDeletion(ctx context.Context, userID string) error {
+	if !m.accountDeletionEnabled() {
+		return ErrAccountDeletionDisabled
+	}
+	if m.impersonating(ctx, nil) {
+		return ErrImpersonating
+	}
+	store := m.deps.AccountDeletions
+	existing, err := store.GetAccountDeletion(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if existing == nil {
+		return ErrAccountDeletionNotFound
+	}
+	if err := store.DeleteAccountDeletion(ctx, userID); err != nil {
+		return err
+	}
+	log.Printf("[AUTH] account deletion cancelled: user=%s", userID)
+	m.recordAuthEvent(ctx, nil, AuthEvent{Type: AuthEventAccountDeletionCancelled, UserID: userID})
+	return nil
+}
+
+// RunAccountDeletions deletes the data exports of every account whose
+// grace period has ended, anonymizes it, and returns how many it did. A failed account is logged, left
+// scheduled for the next run, and its error returned after the others
+// have been tried. Call it from the host's scheduler, e.g. hourly.
+func (m *AuthModule) RunAccountDeletions(ctx context.Context) (int, error) {
+	if !m.accountDeletionEnabled() {
+		return 0, ErrAccountDeletionDisabled
+	}
+	store := m.deps.AccountDeletions
+	due, err := store.DueAccountDeletions(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	var (
+		done     int
+		firstErr error
+	)
+	for _, d := range due {
+		// The exports are copies of the personal data anonymizing
+		// removes; they go first so a failure leaves both for the retry.
+		if err := m.deleteDataExports(ctx, d.UserID); err != nil {
+			log.Printf("[AUTH] account deletion failed: user=%s: data exports: %v", d.UserID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if err := m.deps.AnonymizeUser(ctx, d.UserID); err != nil {
+			log.Printf("[AUTH] account deletion failed: user=%s: %v", d.UserID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		// Keyed by the user id, which anonymizing keeps, so the event
+		// still says whose account went.
+		m.recordAuthEvent(ctx, nil, AuthEvent{Type: AuthEventAccountDeleted, UserID: d.UserID})
+		if err := store.DeleteAccountDeletion(ctx, d.UserID); err != nil {
+			log.Printf("[AUTH] account deletion: user=%s anonymized but still scheduled: %v", d.UserID, err)
+		}
+		log.Printf("[AUTH] account deleted: user=%s anonymized", d.UserID)
+		done++
+	}
+	return done, firstErr
+}
+
+// MemoryAccountDeletionStore is an in-process AccountDeletionStore for
+// single-instance deployments and tests.
+type MemoryAccountDeletionStore struct {
+	mu        sync.Mutex
+	deletions map[string]AccountDeletion
+}
+
+// NewMemoryAccountDeletionStore returns an empty in-memory store.
+func NewMemoryAccountDeletionStore() *MemoryAccountDeletionStore {
+	return &MemoryAccountDeletionStore{deletions: make(map[string]AccountDeletion)}
+}
+
+// GetAccountDeletion implements AccountDeletionStore.
+func (s *MemoryAccountDeletionStore) GetAccountDeletion(_ context.Context, userID string) (*AccountDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.deletions[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// PutAccountDeletion implements AccountDeletionStore.
+func (s *MemoryAccountDeletionStore) PutAccountDeletion(_ context.Context, d AccountDeletion) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.deletions[d.UserID] = d
+	return nil
+}
+
+// DeleteAccountDeletion implements AccountDeletionStore.
+func (s *MemoryAccountDeletionStore) DeleteAccountDeletion(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.deletions, userID)
+	return nil
+}
+
+// DueAccountDeletions implements AccountDeletionStore.
+func (s *MemoryAccountDeletionStore) DueAccountDeletions(_ context.Context, now time.Time) ([]AccountDeletion, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []AccountDeletion
+	for _, d := range s.deletions {
+		if !d.ScheduledFor.After(now) {
+			out = append(out, d)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ScheduledFor.Before(out[j].ScheduledFor) })
+	return out, nil
+}
@@ -0,0 +1,210 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"html/template"
+	"log"
+	"net/http"
+	"strings"
+
+	entydad "github.com/erniealice/entydad-golang"
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+// ActingAsOption is one client or supplier the signed-in delegate can act
+// for, as the portal header's acting-as dropdown lists it.
+type ActingAsOption struct {
+	ID          string
+	DisplayName string
+	Current     bool
+}
+
+// actingAsEnabled reports whether the acting-as switcher can rotate
+// sessions: it needs the same deps as the principal chooser.
+func (m *AuthModule) actingAsEnabled() bool {
+	d := m.deps
+	return d.SessionManager != nil && d.PrincipalResolver != nil && d.PrincipalSwitcher != nil
+}
+
+// ActingAsOptions returns the targets the request's delegate can switch
+// between, in the loader's order, with the one the session acts for marked
+// Current. Nil when the session is not a delegate holding more than one
+// target — there is nothing to switch to.
+func (m *AuthModule) ActingAsOptions(ctx context.Context) ([]ActingAsOption, error) {
+	id, ok := identity.FromContext(ctx)
+	if !ok || id == nil || id.UserID == "" || m.deps.PrincipalResolver == nil {
+		return nil, nil
+	}
+	principals, err := m.deps.PrincipalResolver.Resolve(ctx, id.UserID)
+	if err != nil {
+		return nil, err
+	}
+	p, ok := currentDelegate(principals, id)
+	if !ok || len(p.ActingAsTargets) < 2 {
+		return nil, nil
+	}
+	current := currentActingAsID(p, id)
+	options := make([]ActingAsOption, 0, len(p.ActingAsTargets))
+	for _, t := range p.ActingAsTargets {
+		name := t.DisplayName
+		if name == "" {
+			name = t.ID
+		}
+		options = append(options, ActingAsOption{ID: t.ID, DisplayName: name, Current: t.ID == current})
+	}
+	return options, nil
+}
+
+// currentDelegate finds the delegate principal the session behind id runs
+// as: the delegate in id's workspace whose targets include the client or
+// supplier the session acts for.
+func currentDelegate(principals []Principal, id *identity.RequestIdentity) (Principal, bool) {
+	for _, p := range principals {
+		if p.WorkspaceID != id.WorkspaceID {
+			continue
+		}
+		current := currentActingAsID(p, id)
+		if current == "" {
+			continue
+		}
+		for _, t := range p.ActingAsTargets {
+			if t.ID == current {
+				return p, true
+			}
+		}
+	}
+	return Principal{}, false
+}
+
+// currentActingAsID returns the acting-as id id carries for p's kind of
+// delegate, "" when p is not a delegate.
+func currentActingAsID(p Principal, id *identity.RequestIdentity) string {
+	switch p.Type {
+	case PrincipalTypeClientDelegate:
+		return id.ActingAsClientID
+	case PrincipalTypeSupplierDelegate:
+		return id.ActingAsSupplierID
+	}
+	return ""
+}
+
+// handleActingAs returns the GET /action/auth/acting-as handler: the
+// dropdown fragment the portal header loads with hx-get. It answers 204
+// (nothing to swap in) when the session has no other target to switch to.
+func (m *AuthModule) handleActingAs() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		options, err := m.ActingAsOptions(r.Context())
+		if err != nil {
+			log.Printf("[AUTH] acting-as: resolve failed: %v", err)
+		}
+		if len(options) == 0 || m.impersonating(r.Context(), r) {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		labels := m.deps.Labels.ActingAs
+		if labels.Label == "" {
+			labels = entydad.DefaultActingAsLabels()
+		}
+		var buf bytes.Buffer
+		if err := actingAsSwitcherTemplate.Execute(&buf, map[string]any{
+			"Action":  entydad.AuthActingAsSwitchURL,
+			"Labels":  labels,
+			"Options": options,
+		}); err != nil {
+			log.Printf("[AUTH] acting-as: render failed: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(buf.Bytes())
+	}
+}
+
+// actingAsSwitcherTemplate renders actingAsSwitcherHTML.
+var actingAsSwitcherTemplate = template.Must(template.New("acting-as-switcher").Parse(actingAsSwitcherHTML))
+
+// handleActingAsSwitch returns the POST /action/auth/acting-as/switch
+// handler. It re-resolves the user's principals, accepts acting_as_id only
+// when DelegateActingAsResolved confirms it is one of the current
+// delegate's own targets, and hands the switch to PrincipalSwitcher so the
+// session rotates exactly as a chooser switch does.
+func (m *AuthModule) handleActingAsSwitch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id, ok := identity.FromContext(ctx)
+		if !ok || id == nil || id.UserID == "" {
+			redirectTopLevel(w, r, entydad.AuthLoginURL)
+			return
+		}
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+		// An impersonated session stays in the principal it was started in.
+		if m.impersonating(ctx, r) {
+			log.Printf("[AUTH] acting-as: refused for impersonated session of user %s", id.UserID)
+			redirectTopLevel(w, r, "/auth/select-workspace-role?error=forbidden")
+			return
+		}
+
+		principals, err := m.deps.PrincipalResolver.Resolve(ctx, id.UserID)
+		if err != nil {
+			log.Printf("[AUTH] acting-as: resolve failed for user %s: %v", id.UserID, err)
+			redirectTopLevel(w, r, "/auth/select-workspace-role?error=resolve")
+			return
+		}
+		target, ok := currentDelegate(principals, id)
+		targetID := strings.TrimSpace(r.FormValue("acting_as_id"))
+		var actingAsClientID, actingAsSupplierID string
+		if target.Type == PrincipalTypeSupplierDelegate {
+			actingAsSupplierID = targetID
+		} else {
+			actingAsClientID = targetID
+		}
+		// With two or more targets DelegateActingAsResolved accepts only an
+		// id the delegate itself holds; a free-form id never gets through.
+		if !ok || targetID == "" || len(target.ActingAsTargets) < 2 ||
+			!DelegateActingAsResolved(target, actingAsClientID, actingAsSupplierID) {
+			log.Printf("[AUTH] acting-as: rejected — user %s does not act for %q", id.UserID, targetID)
+			redirectTopLevel(w, r, "/auth/select-workspace-role?error=forbidden")
+			return
+		}
+
+		currentToken := id.SessionToken
+		if cookie, err := r.Cookie(m.deps.SessionCookieName); err == nil && cookie.Value != "" {
+			currentToken = cookie.Value
+		}
+		result, err := m.deps.PrincipalSwitcher(ctx, PrincipalSwitchInput{
+			UserID:             id.UserID,
+			Token:              currentToken,
+			TargetPrincipal:    target,
+			ActingAsClientID:   actingAsClientID,
+			ActingAsSupplierID: actingAsSupplierID,
+			// Explicit-form caller, like POST /action/auth/switch-principal.
+			UseCase:      "switch_explicit_acting_as",
+			RequestURL:   r.URL.Path,
+			Referer:      r.Header.Get("Referer"),
+			SecFetchSite: r.Header.Get("Sec-Fetch-Site"),
+			UserAgent:    r.Header.Get("User-Agent"),
+			RequireAudit: false,
+		})
+		if err != nil || result == nil {
+			log.Printf("[AUTH] acting-as: switch failed for user %s: %v", id.UserID, err)
+			redirectTopLevel(w, r, "/auth/select-workspace-role?error=switch")
+			return
+		}
+		effectiveToken := currentToken
+		if result.NewToken != "" {
+			m.deps.SessionManager.SetSessionCookie(w, result.NewToken)
+			m.carrySessionActivity(ctx, currentToken, result.NewToken)
+			m.carryCredentialCheck(w, r, currentToken, result.NewToken)
+			effectiveToken = result.NewToken
+		}
+		if m.deps.CSRFIssuer != nil {
+			m.deps.CSRFIssuer(w, m.deps.CSRFSecret, effectiveToken, target.WorkspaceID)
+		}
+		redirectTopLevel(w, r, m.homeURLForWorkspaceID(ctx, target.WorkspaceID))
+	}
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+func TestActingAsSwitch_AcceptsOnlyTheDelegatesOwnTargets(t *testing.T) {
+	t.Parallel()
+
+	delegate := Principal{
+		Type:        PrincipalTypeClientDelegate,
+		PrincipalID: "del-1",
+		WorkspaceID: "ws-1",
+		ActingAsTargets: []ActingAsTarget{
+			{ID: "client-A", WorkspaceID: "ws-1", DisplayName: "Client A"},
+			{ID: "client-B", WorkspaceID: "ws-1", DisplayName: "Client B"},
+		},
+	}
+	principals := staticPrincipals{
+		"rosa":  {delegate},
+		"staff": {{Type: PrincipalTypeStaff, PrincipalID: "staff-1", WorkspaceID: "ws-1"}},
+	}
+
+	tests := []struct {
+		name         string
+		userID       string
+		actingAs     string
+		target       string
+		wantLocation string
+		wantSwitch   bool
+	}{
+		{name: "another of the delegate's targets", userID: "rosa", actingAs: "client-A", target: "client-B", wantLocation: "/w/acme/home", wantSwitch: true},
+		{name: "a client the delegate does not act for", userID: "rosa", actingAs: "client-A", target: "client-Z", wantLocation: "/auth/select-workspace-role?error=forbidden"},
+		{name: "no target", userID: "rosa", actingAs: "client-A", wantLocation: "/auth/select-workspace-role?error=forbidden"},
+		{name: "not a delegate", userID: "staff", target: "client-B", wantLocation: "/auth/select-workspace-role?error=forbidden"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			sessions := &recordingSessionManager{}
+			switches := &switchRecorder{}
+			m := NewAuthModule(&Deps{
+				SessionManager:        sessions,
+				Renderer:              nopRenderer{},
+				PrincipalResolver:     principals,
+				PrincipalSwitcher:     switches.switchPrincipal,
+				WorkspaceSlugResolver: func(context.Context, string) string { return "acme" },
+				CSRFIssuer:            func(http.ResponseWriter, []byte, string, string) string { return "" },
+				CSRFSecret:            []byte("test-secret"),
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/action/auth/acting-as/switch", strings.NewReader(url.Values{"acting_as_id": {tt.target}}.Encode()))
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			req = req.WithContext(identity.WithRequestIdentity(req.Context(), &identity.RequestIdentity{
+				UserID: tt.userID, WorkspaceID: "ws-1", SessionToken: "tok", ActingAsClientID: tt.actingAs,
+			}))
+			rec := httptest.NewRecorder()
+			m.handleActingAsSwitch()(rec, req)
+
+			if got := rec.Header().Get("Location"); got != tt.wantLocation {
+				t.Fatalf("Location = %q, want %q", got, tt.wantLocation)
+			}
+			if got := len(switches.calls) == 1; got != tt.wantSwitch {
+				t.Fatalf("switch calls = %d, want switch %v", len(switches.calls), tt.wantSwitch)
+			}
+			if !tt.wantSwitch {
+				return
+			}
+			in := switches.last()
+			if in.TargetPrincipal.PrincipalID != "del-1" || in.ActingAsClientID != "client-B" || in.Token != "tok" || in.UseCase != "switch_explicit_acting_as" {
+				t.Fatalf("switch input = %+v", in)
+			}
+		})
+	}
+
+	t.Run("the dropdown lists the targets with the current one selected", func(t *testing.T) {
+		t.Parallel()
+
+		m := NewAuthModule(&Deps{
+			SessionManager:    &recordingSessionManager{},
+			Renderer:          nopRenderer{},
+			PrincipalResolver: principals,
+			PrincipalSwitcher: (&switchRecorder{}).switchPrincipal,
+		})
+		get := func(userID, actingAs string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest(http.MethodGet, "/action/auth/acting-as", nil)
+			req = req.WithContext(identity.WithRequestIdentity(req.Context(), &identity.RequestIdentity{
+				UserID: userID, WorkspaceID: "ws-1", ActingAsClientID: actingAs,
+			}))
+			rec := httptest.NewRecorder()
+			m.handleActingAs()(rec, req)
+			return rec
+		}
+
+		rec := get("rosa", "client-B")
+		body := rec.Body.String()
+		if rec.Code != http.StatusOK || !strings.Contains(body, `<option value="client-A">Client A</option>`) ||
+			!strings.Contains(body, `<option value="client-B" selected>Client B</option>`) {
+			t.Fatalf("GET = %d %q", rec.Code, body)
+		}
+		if rec := get("staff", ""); rec.Code != http.StatusNoContent {
+			t.Fatalf("GET for a non-delegate = %d, want 204", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+)
+
+// AuthEventType names a security event. The values double as the audit
+// use_case the host stores them under, next to the switch_* and
+// impersonate_* rows the PrincipalSwitcher writes.
+type AuthEventType string
+
+const (
+	AuthEventLoginSucceeded              AuthEventType = "login_success"
+	AuthEventLoginFailed                 AuthEventType = "login_failure"
+	AuthEventLogout                      AuthEventType = "logout"
+	AuthEventPasswordResetRequested      AuthEventType = "password_reset_requested"
+	AuthEventPasswordResetCompleted      AuthEventType = "password_reset_completed"
+	AuthEventPasswordChanged             AuthEventType = "password_changed"
+	AuthEventMFAChallengeSucceeded       AuthEventType = "mfa_challenge_success"
+	AuthEventMFAChallengeFailed          AuthEventType = "mfa_challenge_failure"
+	AuthEventMFAEnabled                  AuthEventType = "mfa_enabled"
+	AuthEventMFADisabled                 AuthEventType = "mfa_disabled"
+	AuthEventMFARecoveryCodesRegenerated AuthEventType = "mfa_recovery_codes_regenerated"
+	AuthEventSessionExpired              AuthEventType = "session_expired"
+	AuthEventDataExportRequested         AuthEventType = "data_export_requested"
+	AuthEventAccountDeletionScheduled    AuthEventType = "account_deletion_scheduled"
+	AuthEventAccountDeletionCancelled    AuthEventType = "account_deletion_cancelled"
+	AuthEventAccountDeleted              AuthEventType = "account_deleted"
+)
+
+// Failure reason classes carried in AuthEvent.Reason. Deliberately coarse:
+// they say why a sign-in was refused, never what was typed.
+const (
+	AuthFailureInvalidCredentials = "invalid_credentials"
+	AuthFailureInvalidCode        = "invalid_code"
+	AuthFailureThrottled          = "throttled"
+	AuthFailureLocked             = "locked"
+	AuthFailureUnverifiedEmail    = "unverified_email"
+	AuthFailureNoAccount          = "no_account"
+	AuthFailureMethodNotAllowed   = "method_not_allowed"
+	AuthFailureSSORequired        = "sso_required"
+)
+
+// Session expiry reasons carried in AuthEvent.Reason of
+// AuthEventSessionExpired.
+const (
+	SessionExpiredIdle     = "idle_timeout"
+	SessionExpiredAbsolute = "absolute_timeout"
+)
+
+// AuthEvent is one security event on an account. UserID is empty when a
+// failed sign-in names an email no account maps to. Method is the sign-in
+// method of login events ("password", "firebase", "magic_link", "passkey",
+// "oidc", "invitation", "signup"). IP and UserAgent are empty for events
+// raised outside a request, such as the portal's two-step verification
+// closures.
+type AuthEvent struct {
+	Type       AuthEventType
+	UserID     string
+	Email      string
+	Method     string
+	Reason     string
+	IP         string
+	UserAgent  string
+	OccurredAt time.Time
+}
+
+// AuthEventSink records security events. Satisfied by the host's audit
+// repository (one audit_entry row per event, use_case = Type), which is
+// what /me/recent-activity reads back. A failing sink is logged and never
+// blocks the sign-in.
+type AuthEventSink interface {
+	RecordAuthEvent(ctx context.Context, event AuthEvent) error
+}
+
+// MemoryAuthEventSink is an in-process AuthEventSink for tests and
+// single-instance development hosts.
+type MemoryAuthEventSink struct {
+	mu     sync.Mutex
+	events []AuthEvent
+}
+
+// NewMemoryAuthEventSink returns an empty sink.
+func NewMemoryAuthEventSink() *MemoryAuthEventSink {
+	return &MemoryAuthEventSink{}
+}
+
+func (s *MemoryAuthEventSink) RecordAuthEvent(_ context.Context, event AuthEvent) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.events = append(s.events, event)
+	return nil
+}
+
+// Events returns userID's events, newest first, at most limit of them
+// (all when limit <= 0).
+func (s *MemoryAuthEventSink) Events(userID string, limit int) []AuthEvent {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []AuthEvent
+	for i := len(s.events) - 1; i >= 0; i-- {
+		if s.events[i].UserID != userID {
+			continue
+		}
+		out = append(out, s.events[i])
+		if limit > 0 && len(out) == limit {
+			break
+		}
+	}
+	return out
+}
+
+// recordAuthEvent fills in the request details and hands e to the sink.
+// r may be nil. A failed sign-in that only names an email is attributed to
+// the account behind it, so it shows in that user's activity.
+func (m *AuthModule) recordAuthEvent(ctx context.Context, r *http.Request, e AuthEvent) {
+	sink := m.deps.AuthEventSink
+	if sink == nil {
+		return
+	}
+	if r != nil {
+		e.IP, e.UserAgent = m.clientIP(r), r.UserAgent()
+	}
+	if e.UserID == "" && e.Email != "" {
+		e.UserID = m.userIDForEmail(ctx, e.Email)
+	}
+	if e.OccurredAt.IsZero() {
+		e.OccurredAt = time.Now().UTC()
+	}
+	if err := sink.RecordAuthEvent(ctx, e); err != nil {
+		log.Printf("[AUTH] auth event %s for user %s not recorded: %v", e.Type, e.UserID, err)
+	}
+}
+
+// signedIn is the common ending of every sign-in that issued a session:
+// the credential check step-up relies on, the start of the session's
+// lifetime clock, and the login_success event.
+func (m *AuthModule) signedIn(w http.ResponseWriter, r *http.Request, token, userID string) {
+	m.recordCredentialCheck(w, token, userID)
+	m.startSessionClock(r.Context(), token, userID)
+	m.recordAuthEvent(r.Context(), r, AuthEvent{Type: AuthEventLoginSucceeded, UserID: userID, Method: m.signInMethod(r)})
+}
+
+// signInMethod names the sign-in method from the endpoint completing it.
+// The two-step challenge completes a login another method started, which
+// the pending login remembers.
+func (m *AuthModule) signInMethod(r *http.Request) string {
+	switch r.URL.Path {
+	case entydad.AuthLoginPostURL:
+		return "password"
+	case entydad.AuthFirebaseLoginURL:
+		return "firebase"
+	case entydad.AuthMagicLinkVerifyURL:
+		return "magic_link"
+	case entydad.AuthPasskeyLoginURL:
+		return "passkey"
+	case entydad.AuthOIDCCallbackURL:
+		return "oidc"
+	case entydad.AuthAcceptInvitePostURL:
+		return "invitation"
+	case entydad.AuthSignupPostURL:
+		return "signup"
+	case entydad.AuthSessionReloginURL:
+		return "relogin"
+	case entydad.AuthMFAPostURL:
+		if p, ok := m.readMFAPending(r); ok {
+			return p.Method
+		}
+	case entydad.AuthConsentPostURL:
+		if p, ok := m.readConsentPending(r); ok {
+			return p.Method
+		}
+	}
+	return ""
+}
+
+// loginFailed records a refused sign-in.
+func (m *AuthModule) loginFailed(r *http.Request, userID, email, reason string) {
+	m.recordAuthEvent(r.Context(), r, AuthEvent{Type: AuthEventLoginFailed, UserID: userID, Email: email, Method: m.signInMethod(r), Reason: reason})
+}
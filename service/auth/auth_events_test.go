@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"testing"
+
+	entydad "github.com/erniealice/entydad-golang"
+	mfamod "github.com/erniealice/entydad-golang/service/auth/views/login02/mfa"
+)
+
+func cookieNamed(rec interface{ Result() *http.Response }, name string) []*http.Cookie {
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == name {
+			return []*http.Cookie{c}
+		}
+	}
+	return nil
+}
+
+func TestAuthEvents_LoginMFALogout(t *testing.T) {
+	t.Parallel()
+	const userID = "user-rosa@example.com"
+	events := NewMemoryAuthEventSink()
+	adapter := &sessionAdapter{
+		passwordAdapter: &passwordAdapter{users: map[string]string{"rosa@example.com": "s3cret-pass"}},
+		sessions:        map[string]string{"session-rosa@example.com": userID},
+	}
+	m := NewAuthModule(&Deps{
+		AuthAdapter:    adapter,
+		SessionManager: &recordingSessionManager{},
+		Renderer:       nopRenderer{},
+		UserIDByEmail:  func(_ context.Context, email string) string { return "user-" + email },
+		CSRFIssuer:     func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:     []byte("test-secret"),
+		MFAStore:       NewMemoryMFAStore(),
+		AuthEventSink:  events,
+		SecureCookies:  func() bool { return false },
+	})
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.deps.MFAStore.SaveEnrollment(context.Background(), userID, MFAEnrollment{Secret: rfc6238Secret, RecoveryCodeHashes: hashes}); err != nil {
+		t.Fatal(err)
+	}
+
+	postForm(m.handleLogin(), entydad.AuthLoginPostURL, url.Values{"email": {"rosa@example.com"}, "password": {"wrong"}}, nil)
+	rec := postForm(m.handleLogin(), entydad.AuthLoginPostURL, url.Values{"email": {"rosa@example.com"}, "password": {"s3cret-pass"}}, nil)
+	pending := cookieNamed(rec, mfaPendingCookieName)
+	if rec.Header().Get("Location") != entydad.AuthMFAURL || pending == nil {
+		t.Fatalf("login: Location = %q", rec.Header().Get("Location"))
+	}
+	verify := m.handleMFAVerify(&mfamod.Deps{})
+	postForm(verify, entydad.AuthMFAPostURL, url.Values{"code": {"000000"}}, pending)
+	postForm(verify, entydad.AuthMFAPostURL, url.Values{"recovery_code": {codes[0]}}, pending)
+	postForm(m.handleLogout(), entydad.AuthLogoutURL, nil, sessionCookie(m, "session-rosa@example.com"))
+	if err := m.MFADisable(context.Background(), userID, codes[1]); err != nil {
+		t.Fatalf("MFADisable: %v", err)
+	}
+
+	want := []AuthEvent{
+		{Type: AuthEventLoginFailed, Method: "password", Reason: AuthFailureInvalidCredentials},
+		{Type: AuthEventMFAChallengeFailed, Method: "password", Reason: AuthFailureInvalidCode},
+		{Type: AuthEventMFAChallengeSucceeded, Method: "password"},
+		{Type: AuthEventLoginSucceeded, Method: "password"},
+		{Type: AuthEventLogout},
+		{Type: AuthEventMFADisabled},
+	}
+	got := events.Events(userID, 0)
+	if len(got) != len(want) {
+		t.Fatalf("got %d events, want %d: %+v", len(got), len(want), got)
+	}
+	for i, w := range want {
+		e := got[len(got)-1-i] // Events is newest first
+		if e.Type != w.Type || e.Method != w.Method || e.Reason != w.Reason || e.UserID != userID || e.OccurredAt.IsZero() {
+			t.Errorf("event %d = %+v, want %s method=%q reason=%q", i, e, w.Type, w.Method, w.Reason)
+		}
+		if w.Type != AuthEventMFADisabled && e.IP == "" {
+			t.Errorf("event %d (%s) has no IP", i, e.Type)
+		}
+	}
+}
+
+// A host without a principal resolver still gets the sign-in recorded.
+func TestAuthEvents_LoginWithoutPrincipalResolver(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	m := newMagicLinkTestModule(outbox, &recordingSessionManager{})
+	events := NewMemoryAuthEventSink()
+	m.deps.AuthEventSink = events
+
+	postForm(m.handleMagicLinkRequest(), entydad.AuthMagicLinkPostURL, url.Values{"email": {"ana@example.com"}}, nil)
+	msg, _ := outbox.Last("ana@example.com")
+	link, err := url.Parse(msg.Link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := postForm(m.handleMagicLinkVerify(), entydad.AuthMagicLinkVerifyURL, url.Values{"token": {link.Query().Get("token")}}, nil)
+	if got := rec.Header().Get("Location"); got != entydad.DefaultAppRedirectURL {
+		t.Fatalf("verify → %q", got)
+	}
+	got := events.Events("user-ana", 0)
+	if len(got) != 1 || got[0].Type != AuthEventLoginSucceeded || got[0].Method != "magic_link" {
+		t.Fatalf("events = %+v, want one magic_link login", got)
+	}
+}
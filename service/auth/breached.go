@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"strings"
+	"sync"
+)
+
+//go:generate go run breached_gen.go -in data/breached-passwords.txt -out data/breached-passwords.bloom
+
+// BreachedPasswordList answers whether a password appears in a breach
+// corpus. Implementations must work offline — the check runs on every
+// signup, reset and password change.
+type BreachedPasswordList interface {
+	Contains(password string) bool
+}
+
+// BreachedBloom is a Bloom filter over SHA-1(password), the same digest the
+// HIBP "Pwned Passwords" downloads use, so a host can build a large filter
+// straight from a hash dump without ever holding plaintexts. False
+// positives (a safe password refused) happen at the configured rate; false
+// negatives never do.
+//
+// File format (big endian): "ENTYBLM1" | k uint32 | m uint64 | ceil(m/8)
+// bytes of bits.
+type BreachedBloom struct {
+	k    uint32
+	m    uint64
+	bits []byte
+}
+
+var (
+	breachedBloomMagic = []byte("ENTYBLM1")
+	errBreachedBloom   = errors.New("auth: malformed breached-password bloom file")
+)
+
+//go:embed data/breached-passwords.bloom
+var bundledBreachedBloom []byte
+
+var (
+	defaultBreachedOnce sync.Once
+	defaultBreached     *BreachedBloom
+)
+
+// DefaultBreachedPasswords returns the bundled filter of common and
+// breached passwords (data/breached-passwords.txt). Hosts wanting a larger
+// corpus load their own file with LoadBreachedBloom.
+func DefaultBreachedPasswords() BreachedPasswordList {
+	defaultBreachedOnce.Do(func() {
+		b, err := LoadBreachedBloom(bytes.NewReader(bundledBreachedBloom))
+		if err != nil {
+			log.Printf("[AUTH] bundled breached-password filter unreadable: %v", err)
+			b = NewBreachedBloom(1, 0.01)
+		}
+		defaultBreached = b
+	})
+	return defaultBreached
+}
+
+// NewBreachedBloom sizes an empty filter for n entries at false-positive
+// rate fp.
+func NewBreachedBloom(n int, fp float64) *BreachedBloom {
+	if n < 1 {
+		n = 1
+	}
+	if fp <= 0 || fp >= 1 {
+		fp = 0.001
+	}
+	m := uint64(math.Ceil(-float64(n) * math.Log(fp) / (math.Ln2 * math.Ln2)))
+	k := uint32(math.Max(1, math.Round(float64(m)/float64(n)*math.Ln2)))
+	return &BreachedBloom{k: k, m: m, bits: make([]byte, (m+7)/8)}
+}
+
+// LoadBreachedBloom reads a filter written by WriteTo.
+func LoadBreachedBloom(r io.Reader) (*BreachedBloom, error) {
+	head := make([]byte, len(breachedBloomMagic)+12)
+	if _, err := io.ReadFull(r, head); err != nil || !bytes.Equal(head[:8], breachedBloomMagic) {
+		return nil, errBreachedBloom
+	}
+	k := binary.BigEndian.Uint32(head[8:12])
+	m := binary.BigEndian.Uint64(head[12:20])
+	if k == 0 || k > 64 || m == 0 || m > 1<<36 {
+		return nil, errBreachedBloom
+	}
+	bits := make([]byte, (m+7)/8)
+	if _, err := io.ReadFull(r, bits); err != nil {
+		return nil, errBreachedBloom
+	}
+	return &BreachedBloom{k: k, m: m, bits: bits}, nil
+}
+
+// Add inserts a plaintext password.
+func (b *BreachedBloom) Add(password string) {
+	sum := sha1.Sum([]byte(password))
+	b.AddSHA1(sum)
+}
+
+// AddSHA1 inserts a password by its SHA-1 digest (HIBP dump lines).
+func (b *BreachedBloom) AddSHA1(sum [sha1.Size]byte) {
+	h1, h2 := bloomHashes(sum)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		idx := (h1 + i*h2) % b.m
+		b.bits[idx/8] |= 1 << (idx % 8)
+	}
+}
+
+// Contains reports whether password may be in the corpus.
+func (b *BreachedBloom) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	h1, h2 := bloomHashes(sum)
+	for i := uint64(0); i < uint64(b.k); i++ {
+		idx := (h1 + i*h2) % b.m
+		if b.bits[idx/8]&(1<<(idx%8)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteTo writes the filter in the LoadBreachedBloom format.
+func (b *BreachedBloom) WriteTo(w io.Writer) (int64, error) {
+	head := append([]byte(nil), breachedBloomMagic...)
+	head = binary.BigEndian.AppendUint32(head, b.k)
+	head = binary.BigEndian.AppendUint64(head, b.m)
+	n, err := w.Write(head)
+	if err != nil {
+		return int64(n), err
+	}
+	n2, err := w.Write(b.bits)
+	return int64(n + n2), err
+}
+
+// AddLines reads one entry per line: a plaintext password, or a 40-char
+// SHA-1 hex digest optionally followed by ":count" (HIBP format) when
+// hashed is true. Blank lines and "#" comments are skipped.
+func (b *BreachedBloom) AddLines(r io.Reader, hashed bool) (int, error) {
+	sc := bufio.NewScanner(r)
+	n := 0
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), "\r")
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if !hashed {
+			b.Add(line)
+			n++
+			continue
+		}
+		digest, _, _ := strings.Cut(line, ":")
+		raw, err := hex.DecodeString(strings.TrimSpace(digest))
+		if err != nil || len(raw) != sha1.Size {
+			return n, errBreachedBloom
+		}
+		b.AddSHA1([sha1.Size]byte(raw))
+		n++
+	}
+	return n, sc.Err()
+}
+
+// bloomHashes derives the double-hashing pair from the SHA-1 digest; h2 is
+// forced odd so the probe sequence never degenerates.
+func bloomHashes(sum [sha1.Size]byte) (uint64, uint64) {
+	return binary.BigEndian.Uint64(sum[0:8]), binary.BigEndian.Uint64(sum[8:16]) | 1
+}
@@ -0,0 +1,67 @@
+//go:build ignore
+
+// breached_gen builds a breached-password Bloom filter for
+// auth.LoadBreachedBloom. The bundled filter is regenerated with
+// `go generate ./service/auth`; a host building a larger one from an HIBP
+// SHA-1 dump runs:
+//
+//	go run breached_gen.go -sha1 -n 1000000 -in pwned-sha1.txt -out pwned.bloom
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/erniealice/entydad-golang/service/auth"
+)
+
+func main() {
+	in := flag.String("in", "", "input file, one entry per line")
+	out := flag.String("out", "", "output bloom file")
+	hashed := flag.Bool("sha1", false, "input lines are SHA-1 hex digests (HASH[:count])")
+	n := flag.Int("n", 0, "expected entries (default: line count of -in)")
+	fp := flag.Float64("fp", 0.001, "false-positive rate")
+	flag.Parse()
+	if *in == "" || *out == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	entries := *n
+	if entries == 0 {
+		f, err := os.Open(*in)
+		if err != nil {
+			log.Fatal(err)
+		}
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			entries++
+		}
+		f.Close()
+	}
+
+	f, err := os.Open(*in)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	bloom := auth.NewBreachedBloom(entries, *fp)
+	added, err := bloom.AddLines(f, *hashed)
+	if err != nil {
+		log.Fatalf("line %d: %v", added+1, err)
+	}
+
+	w, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if _, err := bloom.WriteTo(w); err != nil {
+		log.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("wrote %s: %d entries", *out, added)
+}
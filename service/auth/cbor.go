@@ -0,0 +1,123 @@
+package auth
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+)
+
+// Minimal CBOR (RFC 8949) decoder for the WebAuthn structures the passkey
+// flow reads: the attestation object and the COSE public key. Only
+// definite-length items are supported — authenticators emit CTAP2 canonical
+// CBOR, which forbids indefinite lengths. Values decode to int64, []byte,
+// string, []any, map[any]any (keys int64 or string), bool or nil.
+
+var errCBOR = errors.New("auth: malformed CBOR")
+
+// cborMaxDepth bounds nesting so hostile input can't recurse unboundedly.
+const cborMaxDepth = 16
+
+// decodeCBOR decodes one item from b and returns it with the unread rest.
+func decodeCBOR(b []byte) (any, []byte, error) {
+	return decodeCBORItem(b, 0)
+}
+
+func decodeCBORItem(b []byte, depth int) (any, []byte, error) {
+	if depth > cborMaxDepth || len(b) == 0 {
+		return nil, nil, errCBOR
+	}
+	major, info := b[0]>>5, b[0]&0x1f
+	b = b[1:]
+
+	if major == 7 {
+		switch info {
+		case 20:
+			return false, b, nil
+		case 21:
+			return true, b, nil
+		case 22, 23:
+			return nil, b, nil
+		}
+		return nil, nil, errCBOR
+	}
+
+	n, b, err := cborArgument(info, b)
+	if err != nil {
+		return nil, nil, err
+	}
+	switch major {
+	case 0:
+		if n > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return int64(n), b, nil
+	case 1:
+		if n > math.MaxInt64 {
+			return nil, nil, errCBOR
+		}
+		return -1 - int64(n), b, nil
+	case 2, 3:
+		if uint64(len(b)) < n {
+			return nil, nil, errCBOR
+		}
+		if major == 2 {
+			return append([]byte(nil), b[:n]...), b[n:], nil
+		}
+		return string(b[:n]), b[n:], nil
+	case 4:
+		if n > uint64(len(b)) {
+			return nil, nil, errCBOR
+		}
+		out := make([]any, 0, n)
+		for i := uint64(0); i < n; i++ {
+			var v any
+			if v, b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+			out = append(out, v)
+		}
+		return out, b, nil
+	case 5:
+		if n > uint64(len(b)) {
+			return nil, nil, errCBOR
+		}
+		out := make(map[any]any, n)
+		for i := uint64(0); i < n; i++ {
+			var k, v any
+			if k, b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+			switch k.(type) {
+			case int64, string:
+			default:
+				return nil, nil, errCBOR
+			}
+			if v, b, err = decodeCBORItem(b, depth+1); err != nil {
+				return nil, nil, err
+			}
+			out[k] = v
+		}
+		return out, b, nil
+	case 6:
+		// Tags carry no meaning for WebAuthn; decode the tagged item.
+		return decodeCBORItem(b, depth+1)
+	}
+	return nil, nil, errCBOR
+}
+
+// cborArgument reads the head argument for additional-info value info.
+func cborArgument(info byte, b []byte) (uint64, []byte, error) {
+	switch {
+	case info < 24:
+		return uint64(info), b, nil
+	case info == 24 && len(b) >= 1:
+		return uint64(b[0]), b[1:], nil
+	case info == 25 && len(b) >= 2:
+		return uint64(binary.BigEndian.Uint16(b)), b[2:], nil
+	case info == 26 && len(b) >= 4:
+		return uint64(binary.BigEndian.Uint32(b)), b[4:], nil
+	case info == 27 && len(b) >= 8:
+		return binary.BigEndian.Uint64(b), b[8:], nil
+	}
+	return 0, nil, errCBOR
+}
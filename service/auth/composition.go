@@ -1,15 +1,22 @@
 package auth
 
 import (
+	"crypto/rand"
 	"log"
 	"net/http"
+	"net/netip"
 	"strings"
-	"sync"
+	"time"
 
 	entydad "github.com/erniealice/entydad-golang"
 	changepasswordmod "github.com/erniealice/entydad-golang/service/auth/views/change-password"
 	login02mod "github.com/erniealice/entydad-golang/service/auth/views/login02"
+	acceptinvitemod "github.com/erniealice/entydad-golang/service/auth/views/login02/accept-invite"
+	consentmod "github.com/erniealice/entydad-golang/service/auth/views/login02/consent"
+	magiclinkmod "github.com/erniealice/entydad-golang/service/auth/views/login02/magic-link"
+	mfamod "github.com/erniealice/entydad-golang/service/auth/views/login02/mfa"
 	selectWorkspaceRole "github.com/erniealice/entydad-golang/service/auth/views/login02/select-workspace-role"
+	verifyemailmod "github.com/erniealice/entydad-golang/service/auth/views/login02/verify-email"
 	resetpassword02mod "github.com/erniealice/entydad-golang/service/auth/views/reset-password02"
 	signup02mod "github.com/erniealice/entydad-golang/service/auth/views/signup02"
 	pyeza "github.com/erniealice/pyeza-golang"
@@ -21,6 +28,16 @@ type AuthLabels struct {
 	Signup02        entydad.Signup02Labels
 	ResetPassword02 entydad.ResetPassword02Labels
 	ChangePassword  entydad.ChangePasswordLabels
+	MFA             entydad.MFALabels
+	VerifyEmail     entydad.VerifyEmailLabels
+	MagicLink       entydad.MagicLinkLabels
+	AcceptInvite    entydad.AcceptInviteLabels
+	Impersonation   entydad.ImpersonationLabels
+	StepUp          entydad.StepUpLabels
+	SessionExpiry   entydad.SessionExpiryLabels
+	Consent         entydad.ConsentLabels
+	ActingAs        entydad.ActingAsLabels
+	Email           entydad.AuthEmailLabels
 	Common          pyeza.CommonLabels
 	Messages        map[string]string
 	// SelectWorkspaceRole holds per-kind role labels for the principal
@@ -64,6 +81,7 @@ type Deps struct {
 
 	// Data lookups (injected closures replace raw *sql.DB access)
 	UserIDByEmail         UserIDByEmail
+	EmailByUserID         EmailByUserID
 	WorkspaceSlugResolver WorkspaceSlugResolver
 
 	// Labels
@@ -101,6 +119,205 @@ type Deps struct {
 	// link is gated app-side via ChangePasswordURL). From PasswordChangeEnabled().
 	AllowPasswordChange bool
 
+	// LoginAttemptLimiter throttles /auth/login, /auth/firebase,
+	// /auth/reset-password and /auth/signup (per-email + per-IP sliding
+	// windows, progressive delay, temporary account lock). Defaults to the
+	// in-memory limiter with DefaultLoginAttemptPolicy when nil.
+	LoginAttemptLimiter LoginAttemptLimiter
+
+	// TrustedProxies lists the reverse proxies (IPs or CIDR prefixes, e.g.
+	// "10.0.0.0/8") whose X-Forwarded-For and X-Real-Ip headers name the
+	// client. Requests from any other address are keyed on RemoteAddr, so a
+	// client cannot pick its own IP to slip past the per-IP limits. Empty =
+	// forwarded headers are ignored.
+	TrustedProxies []string
+
+	// Two-step verification (TOTP, RFC 6238). MFAStore nil ⇒ MFA disabled and
+	// /auth/mfa not mounted. When set, an enrolled user is parked at the
+	// /auth/mfa challenge after the first factor and only reaches
+	// routePrincipals once it passes. MFARequired (optional) forces enrolment
+	// at next login for operator principals of workspaces that require it.
+	// MFAIssuer names the account in authenticator apps (default LogoText).
+	MFAStore    MFAStore
+	MFARequired MFARequired
+	MFAIssuer   string
+
+	// Passkey (WebAuthn) sign-in. Mounted only when PasskeyStore,
+	// SessionMinter and PasskeyRPID are all set: login02 then offers "Sign in
+	// with a passkey" and the account page can register credentials.
+	// PasskeyRPID is the registrable domain (e.g. "app.example.com");
+	// PasskeyOrigins the exact browser origins allowed in client data
+	// (default "https://" + PasskeyRPID). PasskeyRPName defaults to LogoText.
+	PasskeyStore   PasskeyStore
+	PasskeyRPID    string
+	PasskeyRPName  string
+	PasskeyOrigins []string
+
+	// OIDCProviders are generic OpenID Connect IdPs (Keycloak, Okta, Entra
+	// ID, ...) offered as login02 buttons. Each signs in via authorization
+	// code + PKCE at /auth/oidc/start → /auth/oidc/callback, the ID token is
+	// verified against the provider's JWKS and its email claim is joined to
+	// user.email (UserIDByEmail). Requires SessionMinter; independent of
+	// the Firebase wiring.
+	OIDCProviders []OIDCProvider
+
+	// PasswordPolicy gates every new password (signup, reset confirm,
+	// change-password): length, character classes, the breached-password
+	// list, no reuse of the last N and no email inside. nil = no checks
+	// beyond the adapter's own; DefaultPasswordPolicy() is the recommended
+	// set. PasswordResetTokenUser (optional) lets the reset step apply the
+	// per-user rules too.
+	PasswordPolicy         *PasswordPolicy
+	PasswordResetTokenUser PasswordResetTokenUser
+
+	// Mailer delivers the transactional email — password reset links,
+	// email verification, invitations and security alerts — rendered from
+	// MailTemplates (nil = the built-in set) with Labels.Email.
+	// PublicBaseURL (e.g. "https://app.example.com") is the origin of every
+	// emailed link; both are required before anything is mailed. Without a
+	// Mailer, reset tokens are only logged (development).
+	Mailer        Mailer
+	MailTemplates *MailTemplates
+	PublicBaseURL string
+
+	// EmailVerification turns on verification after self-signup (requires
+	// Mailer and PublicBaseURL): signup parks the new user on
+	// /auth/verify-email (no auto-login) and mails a signed link, and a
+	// password sign-in by a still-unverified user is refused with the same
+	// page plus a resend action.
+	EmailVerification EmailVerificationStore
+
+	// DeleteUser undoes a registration whose signup could not be completed:
+	// the new user could not be marked unverified, or an invitee could not
+	// be joined to the workspace. Without it AnonymizeUser deactivates the
+	// account instead; with neither the account is left behind (logged).
+	DeleteUser DeleteUser
+
+	// Passwordless magic-link sign-in. AllowMagicLink switches it on (like
+	// AllowSignups) — login02 gains "Email me a sign-in link" — once
+	// Mailer, PublicBaseURL and SessionMinter are set. Links are signed,
+	// expire after 15 minutes and work once (MagicLinkStore, in-memory by
+	// default; inject a shared store when running several instances).
+	// MagicLinkAllowed (optional) is the workspace-level toggle: only users
+	// with a principal in a workspace that allows it get a link.
+	AllowMagicLink   bool
+	MagicLinkStore   MagicLinkStore
+	MagicLinkAllowed MagicLinkAllowed
+
+	// Invitations stores pending workspace invitations. With it (plus
+	// Mailer and PublicBaseURL) the module mails invitations issued through
+	// InviteToWorkspace and mounts /auth/accept-invite, where the invitee
+	// signs in — or signs up, even with AllowSignups off — and is joined to
+	// the workspace with the invited roles.
+	Invitations InvitationStore
+
+	// Audited impersonation ("view as user"). With ImpersonationStore (plus
+	// SessionMinter, PrincipalResolver, PrincipalSwitcher, PermissionGrants
+	// and PermissionCatalog) the module mounts /auth/impersonate[/stop] and
+	// ImpersonationURL hands the admin user page a start link. Workspace
+	// owners, other holders of user:impersonate and users allowed any
+	// permission the actor is not cannot be impersonated: both users'
+	// grants are decided by the effective resolver over the catalog, so
+	// wildcards and DENY rows count. Starting and stopping both go through
+	// PrincipalSwitcher with an impersonate_* UseCase and RequireAudit. The
+	// session ends after ImpersonationTimeout (default 30 minutes); wrap the
+	// app handler in ImpersonationMiddleware for the banner and the timeout.
+	// PermissionGrants and PermissionCatalog also drive PermissionsMiddleware,
+	// which sets a session's runtime permissions by the same resolver so
+	// wildcard and DENY rows are enforced as the permission pages show them.
+	ImpersonationStore   ImpersonationStore
+	ImpersonationTimeout time.Duration
+	PermissionGrants     PermissionGrants
+	PermissionCatalog    PermissionCatalog
+
+	// Session lifetimes. With SessionActivity and SessionLifetimes (see
+	// SessionLifetimeRules for static settings) SessionLifetimeMiddleware
+	// signs out sessions past their idle or absolute lifetime; an htmx
+	// request on one opens a re-login dialog instead and is replayed after
+	// the user signs in again. Wrap the app handler in the middleware,
+	// inside the session middleware and outside StepUpMiddleware.
+	SessionActivity  SessionActivityStore
+	SessionLifetimes SessionLifetimePolicy
+
+	// Remembered default principal. With DefaultPrincipals the chooser
+	// offers "make this my default" on each card, and a user holding
+	// principals of different kinds is switched into their default at
+	// sign-in (delegate guard permitting) instead of seeing the chooser.
+	// The portal preferences page can clear it.
+	DefaultPrincipals DefaultPrincipalStore
+
+	// Step-up re-authentication. Requests matching StepUpActions (ServeMux
+	// patterns such as "POST /action/user/reset-password/{id}" — see the
+	// identity modules' SensitiveActions) need a password or two-step code
+	// entered within StepUpMaxAge (default 15 minutes); every sign-in
+	// counts. Wrap the app handler in StepUpMiddleware, inside the session
+	// middleware, to enforce it.
+	StepUpActions []string
+	StepUpMaxAge  time.Duration
+
+	// Security event log. When set, sign-ins (and refusals, with a reason
+	// class), sign-outs, password resets and changes and two-step
+	// verification events are recorded with the IP and user agent — the
+	// portal's /me/recent-activity reads them back. See AuthEventSink.
+	AuthEventSink AuthEventSink
+
+	// Home-realm discovery. With HomeRealm set, login02 turns
+	// identifier-first: the email is asked for alone and its domain's realm
+	// sends the user to the workspace's OIDC provider (or Firebase button)
+	// or on to the password step. Realms with SSORequired refuse every
+	// sign-in of their domains but the realm's provider, and password
+	// reset. Passkey sign-ins are checked by the user's address, so wire
+	// EmailByUserID too or they are refused.
+	HomeRealm HomeRealmLookup
+
+	// Terms-of-service / privacy consent. With ConsentDocuments,
+	// RecordConsent and ListConsents set, a sign-in whose user has not
+	// accepted the current Version of every document is held at
+	// /auth/consent — after two-step verification, before principal
+	// routing — and each acceptance is recorded with its timestamp and IP.
+	// Load the documents (title + markdown body) from lyngua per locale;
+	// ConsentHistory feeds the portal account page.
+	ConsentDocuments []ConsentDocument
+	RecordConsent    RecordConsent
+	ListConsents     ListConsents
+
+	// Self-service data export. With DataExports and PersonalData (one
+	// collector per section: profile, workspace memberships, role
+	// assignments, conversation posts, security events — consents come
+	// from ListConsents) the portal account page offers "Download my
+	// data": the ZIP is built in the background and downloads from
+	// /action/auth/data-export/{id} for DataExportMaxAge (default 7 days).
+	// The host calls PurgeExpiredDataExports from its scheduler to drop
+	// the archives past that window.
+	DataExports      DataExportStore
+	PersonalData     map[string]PersonalDataCollector
+	DataExportMaxAge time.Duration
+
+	// Self-service account deletion. With AccountDeletions and
+	// AnonymizeUser the portal account page offers "Delete my account":
+	// the deletion is scheduled AccountDeletionGrace ahead (default 30
+	// days) and can be cancelled until then. The host calls
+	// RunAccountDeletions from its scheduler to anonymize the due ones,
+	// which also deletes their DataExports.
+	AccountDeletions     AccountDeletionStore
+	AnonymizeUser        AnonymizeUser
+	AccountDeletionGrace time.Duration
+
+	// Personal access tokens. With AccessTokenStore and PermissionCodes set,
+	// IssueAccessToken mints bearer tokens scoped to one workspace and a
+	// subset of the owner's permission codes, and BearerTokenMiddleware
+	// accepts them in place of a session cookie. PermissionCodes is the
+	// role → permission lookup behind the session's UserPermissions, so a
+	// token never carries more than its owner holds. Under /w/{slug}/ a
+	// token is only accepted in its own workspace, which takes
+	// WorkspaceSlugResolver. AccessTokenMaxAge caps a token's lifetime
+	// (default 365 days). With PermissionGrants and PermissionCatalog set,
+	// tokens are narrowed to EffectivePermissionCodes instead, and
+	// PermissionCodes may be left nil.
+	AccessTokenStore  AccessTokenStore
+	PermissionCodes   PermissionCodes
+	AccessTokenMaxAge time.Duration
+
 	// Cookie policy
 	SecureCookies func() bool
 
@@ -119,12 +336,15 @@ type Deps struct {
 // AuthModule is the assembled auth service ready to register routes.
 type AuthModule struct {
 	deps *Deps
-	// lastResetTokens stores raw HMAC reset tokens keyed by user_id for test
-	// environments where AUTH_PASSWORD_TEST_MODE=true. This map is populated by
-	// the POST /auth/reset-password handler and consumed by the test-only
-	// GET /test/last-reset-token endpoint so E2E specs can obtain the raw token
-	// without a real email delivery pipeline.
-	lastResetTokens sync.Map
+	// cookieKey seeds the keys sealing the pre-session cookies (MFA
+	// pending login, WebAuthn challenges): CSRFSecret, or random per
+	// process when the host configured none.
+	cookieKey []byte
+	// oidc holds the runtime client (discovery + JWKS cache) per
+	// configured OIDCProviders ID.
+	oidc map[string]*oidcClient
+	// trustedProxies is Deps.TrustedProxies, parsed.
+	trustedProxies []netip.Prefix
 }
 
 // NewAuthModule validates deps and returns a ready-to-register module.
@@ -138,7 +358,43 @@ func NewAuthModule(deps *Deps) *AuthModule {
 	if deps.WorkspaceCSRFCookieName == "" {
 		deps.WorkspaceCSRFCookieName = "ws_csrf"
 	}
-	return &AuthModule{deps: deps}
+	if deps.MailTemplates == nil {
+		deps.MailTemplates = DefaultMailTemplates()
+	}
+	if deps.MagicLinkStore == nil {
+		deps.MagicLinkStore = NewMemoryMagicLinkStore()
+	}
+	if deps.ImpersonationTimeout <= 0 {
+		deps.ImpersonationTimeout = 30 * time.Minute
+	}
+	if deps.StepUpMaxAge <= 0 {
+		deps.StepUpMaxAge = 15 * time.Minute
+	}
+	if deps.AccessTokenMaxAge <= 0 {
+		deps.AccessTokenMaxAge = 365 * 24 * time.Hour
+	}
+	if deps.DataExportMaxAge <= 0 {
+		deps.DataExportMaxAge = 7 * 24 * time.Hour
+	}
+	if deps.AccountDeletionGrace <= 0 {
+		deps.AccountDeletionGrace = 30 * 24 * time.Hour
+	}
+	if deps.LoginAttemptLimiter == nil {
+		deps.LoginAttemptLimiter = NewLoginAttemptLimiter(NewMemoryLoginAttemptStore(), DefaultLoginAttemptPolicy())
+	}
+	cookieKey := deps.CSRFSecret
+	if len(cookieKey) == 0 {
+		cookieKey = make([]byte, 32)
+		if _, err := rand.Read(cookieKey); err != nil {
+			log.Printf("[AUTH] random cookie key generation failed: %v", err)
+		}
+	}
+	return &AuthModule{
+		deps:           deps,
+		cookieKey:      cookieKey,
+		oidc:           newOIDCClients(deps.OIDCProviders),
+		trustedProxies: parseTrustedProxies(deps.TrustedProxies),
+	}
 }
 
 // RegisterRoutes registers all auth GET/POST handlers on the given registrar.
@@ -171,6 +427,28 @@ func (m *AuthModule) RegisterRoutes(routes RouteRegistrar) {
 		socialProviders = firebaseSocialProviders(deps.AllowedSignInMethods)
 		showPasswordForm = passwordMethodEnabled(deps.AllowedSignInMethods)
 	}
+	// Generic OIDC sign-in: buttons only when the callback can mint a
+	// session.
+	var oidcButtons []login02mod.OIDCProvider
+	oidcEnabled := m.oidcEnabled()
+	if oidcEnabled {
+		oidcButtons = m.oidcLoginButtons()
+	}
+	var magicLinkURL string
+	if m.magicLinkEnabled() {
+		magicLinkURL = entydad.AuthMagicLinkURL
+	}
+	var discoverURL string
+	if deps.HomeRealm != nil {
+		discoverURL = entydad.AuthLoginDiscoverURL
+	}
+	var passkeyConfig *login02mod.PasskeyConfig
+	if m.passkeysEnabled() {
+		passkeyConfig = &login02mod.PasskeyConfig{
+			OptionsURL: entydad.AuthPasskeyOptionsURL,
+			PostURL:    entydad.AuthPasskeyLoginURL,
+		}
+	}
 
 	// Login (GET + POST)
 	routes.GET(entydad.AuthLoginURL, login02mod.NewView(&login02mod.Deps{
@@ -186,10 +464,18 @@ func (m *AuthModule) RegisterRoutes(routes RouteRegistrar) {
 		FirebaseConfig:   fbConfig,
 		ShowPasswordForm: showPasswordForm,
 		AllowSignups:     deps.AllowSignups,
+		PasskeyConfig:    passkeyConfig,
+		OIDCProviders:    oidcButtons,
+		MagicLinkURL:     magicLinkURL,
+		DiscoverURL:      discoverURL,
 	}))
 
 	// POST /auth/login
 	routes.HandleFunc("POST", entydad.AuthLoginPostURL, m.handleLogin())
+	if discoverURL != "" {
+		routes.HandleFunc("POST", entydad.AuthLoginDiscoverURL, m.handleLoginDiscover())
+		log.Println("  ✓ Home-realm discovery mounted: POST /auth/login/discover (identifier-first login)")
+	}
 
 	// POST /auth/firebase — Firebase ID-token login (only when wired). Lives
 	// under /auth/ so it shares the session-exclude + CSRF-exempt posture of
@@ -199,6 +485,183 @@ func (m *AuthModule) RegisterRoutes(routes RouteRegistrar) {
 		log.Println("  ✓ Firebase ID-token login mounted: POST /auth/firebase")
 	}
 
+	// Two-step verification challenge (GET + POST), only when a store is
+	// wired. Lives under /auth/ like login: the pending login has no session
+	// cookie yet.
+	if deps.MFAStore != nil {
+		mfaDeps := &mfamod.Deps{
+			Labels:       deps.Labels.MFA,
+			CommonLabels: deps.Labels.Common,
+			LogoText:     logoText,
+			LogoIcon:     deps.LogoIcon,
+			PostURL:      entydad.AuthMFAPostURL,
+			PageURL:      entydad.AuthMFAURL,
+		}
+		routes.HandleFunc("GET", entydad.AuthMFAURL, m.handleMFAPage(mfaDeps))
+		routes.HandleFunc("POST", entydad.AuthMFAPostURL, m.handleMFAVerify(mfaDeps))
+		log.Println("  ✓ Two-step verification mounted: GET/POST /auth/mfa")
+	}
+
+	// Consent step (GET + POST) between the credential check and principal
+	// routing; same posture as /auth/mfa.
+	if m.consentEnabled() {
+		consentDeps := &consentmod.Deps{
+			Labels:       deps.Labels.Consent,
+			CommonLabels: deps.Labels.Common,
+			LogoText:     logoText,
+			LogoIcon:     deps.LogoIcon,
+			PostURL:      entydad.AuthConsentPostURL,
+		}
+		routes.HandleFunc("GET", entydad.AuthConsentURL, m.handleConsentPage(consentDeps))
+		routes.HandleFunc("POST", entydad.AuthConsentPostURL, m.handleConsentAccept(consentDeps))
+		log.Printf("  ✓ Consent step mounted: GET/POST /auth/consent (%d document(s))", len(deps.ConsentDocuments))
+	} else if len(deps.ConsentDocuments) > 0 {
+		log.Println("  ✗ Consent step NOT mounted: RecordConsent and ListConsents are required")
+	}
+
+	// Generic OIDC (authorization code + PKCE). Top-level GET navigations,
+	// so they live under /auth/ with the other pre-session handlers.
+	if oidcEnabled {
+		routes.HandleFunc("GET", entydad.AuthOIDCStartURL, m.handleOIDCStart())
+		routes.HandleFunc("GET", entydad.AuthOIDCCallbackURL, m.handleOIDCCallback())
+		log.Printf("  ✓ OIDC sign-in mounted: GET /auth/oidc/start, /auth/oidc/callback (%d provider(s))", len(m.oidc))
+	}
+
+	// Passkeys: sign-in ceremony plus account-page registration. All four
+	// live under /auth/ (CSRF-exempt); registration is bound to the session
+	// cookie, an Origin allow-list and a user-bound sealed challenge instead,
+	// and needs a recent credential check when StepUpActions lists it (the
+	// account module's SensitiveActions does).
+	if m.passkeysEnabled() {
+		routes.HandleFunc("POST", entydad.AuthPasskeyOptionsURL, m.handlePasskeyOptions())
+		routes.HandleFunc("POST", entydad.AuthPasskeyLoginURL, m.handlePasskeyLogin())
+		routes.HandleFunc("POST", entydad.AuthPasskeyRegisterOptionsURL, m.StepUpMiddleware(m.handlePasskeyRegisterOptions()).ServeHTTP)
+		routes.HandleFunc("POST", entydad.AuthPasskeyRegisterURL, m.StepUpMiddleware(m.handlePasskeyRegister()).ServeHTTP)
+		log.Println("  ✓ Passkey sign-in mounted: POST /auth/passkey[/options|/register]")
+	}
+
+	// Email verification page + resend, only when signup verification is
+	// configured. Pre-session like /auth/mfa.
+	if m.emailVerificationEnabled() {
+		verifyDeps := &verifyemailmod.Deps{
+			Labels:       deps.Labels.VerifyEmail,
+			CommonLabels: deps.Labels.Common,
+			LogoText:     logoText,
+			LogoIcon:     deps.LogoIcon,
+			ResendURL:    entydad.AuthVerifyEmailResendURL,
+			LoginURL:     entydad.AuthLoginURL,
+		}
+		routes.HandleFunc("GET", entydad.AuthVerifyEmailURL, m.handleVerifyEmail(verifyDeps))
+		routes.HandleFunc("POST", entydad.AuthVerifyEmailResendURL, m.handleVerifyEmailResend())
+		log.Println("  ✓ Email verification mounted: GET /auth/verify-email, POST /auth/verify-email/resend")
+	} else if deps.EmailVerification != nil {
+		log.Println("  ✗ Email verification NOT mounted: Mailer and PublicBaseURL are required")
+	}
+
+	// Passwordless magic-link sign-in. Pre-session like /auth/mfa; the
+	// verify POST ends in routePrincipals like every other sign-in.
+	if m.magicLinkEnabled() {
+		magicDeps := &magiclinkmod.Deps{
+			Labels:       deps.Labels.MagicLink,
+			CommonLabels: deps.Labels.Common,
+			LogoText:     logoText,
+			LogoIcon:     deps.LogoIcon,
+			PostURL:      entydad.AuthMagicLinkPostURL,
+			VerifyURL:    entydad.AuthMagicLinkVerifyURL,
+			LoginURL:     entydad.AuthLoginURL,
+		}
+		routes.HandleFunc("GET", entydad.AuthMagicLinkURL, m.handleMagicLinkPage(magicDeps))
+		routes.HandleFunc("POST", entydad.AuthMagicLinkPostURL, m.handleMagicLinkRequest())
+		routes.HandleFunc("GET", entydad.AuthMagicLinkVerifyURL, m.handleMagicLinkConfirm(magicDeps))
+		routes.HandleFunc("POST", entydad.AuthMagicLinkVerifyURL, m.handleMagicLinkVerify())
+		log.Println("  ✓ Magic-link sign-in mounted: GET/POST /auth/magic-link[/verify]")
+	} else if deps.AllowMagicLink {
+		log.Println("  ✗ Magic-link sign-in NOT mounted: Mailer, PublicBaseURL, SessionMinter and SessionManager are required")
+	}
+
+	// Workspace invitations: the emailed link's page. Pre-session like
+	// /auth/mfa; the POST ends in routePrincipals.
+	if m.invitationsEnabled() {
+		inviteDeps := &acceptinvitemod.Deps{
+			Labels:       deps.Labels.AcceptInvite,
+			CommonLabels: deps.Labels.Common,
+			LogoText:     logoText,
+			LogoIcon:     deps.LogoIcon,
+			PostURL:      entydad.AuthAcceptInvitePostURL,
+			LoginURL:     entydad.AuthLoginURL,
+		}
+		routes.HandleFunc("GET", entydad.AuthAcceptInviteURL, m.handleAcceptInvitePage(inviteDeps))
+		routes.HandleFunc("POST", entydad.AuthAcceptInvitePostURL, m.handleAcceptInvite())
+		log.Println("  ✓ Workspace invitations mounted: GET/POST /auth/accept-invite")
+	} else if deps.Invitations != nil {
+		log.Println("  ✗ Workspace invitations NOT mounted: Mailer, PublicBaseURL, AuthAdapter and SessionManager are required")
+	}
+
+	// Audited impersonation. The start link is a top-level GET bound to
+	// the actor's session cookie; stop is the banner's form POST.
+	if m.impersonationEnabled() {
+		routes.HandleFunc("GET", entydad.AuthImpersonateURL, m.handleImpersonateStart())
+		routes.HandleFunc("POST", entydad.AuthImpersonateStopURL, m.handleImpersonateStop())
+		log.Println("  ✓ Impersonation mounted: GET /auth/impersonate, POST /auth/impersonate/stop")
+	} else if deps.ImpersonationStore != nil {
+		log.Println("  ✗ Impersonation NOT mounted: AuthAdapter, SessionManager, SessionMinter, PrincipalResolver, PrincipalSwitcher, PermissionGrants and PermissionCatalog are required")
+	}
+
+	// Acting-as switcher for multi-target delegates. The portal header
+	// loads the dropdown with hx-get; the switch rotates the session
+	// through PrincipalSwitcher like the chooser.
+	if m.actingAsEnabled() {
+		routes.HandleFunc("GET", entydad.AuthActingAsURL, m.handleActingAs())
+		routes.HandleFunc("POST", entydad.AuthActingAsSwitchURL, m.handleActingAsSwitch())
+		log.Println("  ✓ Acting-as switcher mounted: GET /action/auth/acting-as, POST /action/auth/acting-as/switch")
+	}
+
+	// Data export download; the portal account page requests and lists
+	// the exports through the module's methods.
+	if m.dataExportEnabled() {
+		routes.HandleFunc("GET", entydad.AuthDataExportURL, m.handleDataExportDownload())
+		log.Printf("  ✓ Data export mounted: GET /action/auth/data-export/{id}, %d sections", len(deps.PersonalData))
+	} else if deps.DataExports != nil {
+		log.Println("  ✗ Data export NOT mounted: PersonalData or ListConsents is required")
+	}
+
+	if m.accountDeletionEnabled() {
+		log.Printf("  ✓ Account deletion: grace period %s", deps.AccountDeletionGrace)
+	} else if deps.AccountDeletions != nil {
+		log.Println("  ✗ Account deletion disabled: AnonymizeUser is required")
+	}
+
+	if deps.DefaultPrincipals != nil {
+		log.Println("  ✓ Default principal: the chooser remembers a default per user")
+	}
+
+	// Session lifetimes are enforced by SessionLifetimeMiddleware, which
+	// also serves its re-login dialog; nothing to mount here.
+	if m.sessionLifetimesEnabled() {
+		log.Println("  ✓ Session lifetimes: idle and absolute timeouts with re-login dialog")
+	}
+
+	// Step-up re-authentication is enforced by StepUpMiddleware, which also
+	// serves its dialog; nothing to mount here.
+	if m.stepUpEnabled() {
+		log.Printf("  ✓ Step-up re-authentication: %d sensitive actions, max age %s", len(deps.StepUpActions), deps.StepUpMaxAge)
+	}
+
+	// Runtime permissions are set by PermissionsMiddleware; nothing to
+	// mount here.
+	if m.effectivePermissionsEnabled() {
+		log.Println("  ✓ Effective permissions: wildcard and DENY rows enforced by PermissionsMiddleware")
+	}
+
+	if deps.AuthEventSink != nil {
+		log.Println("  ✓ Security event log: sign-in, password and two-step verification events")
+	}
+
+	// Access tokens are checked by BearerTokenMiddleware; nothing to mount.
+	if m.accessTokensEnabled() {
+		log.Printf("  ✓ Personal access tokens: Authorization: Bearer, max age %s", deps.AccessTokenMaxAge)
+	}
+
 	// Signup (GET + POST)
 	routes.GET(entydad.AuthSignupURL, signup02mod.NewView(&signup02mod.Deps{
 		Labels:       deps.Labels.Signup02,
@@ -256,10 +719,10 @@ func (m *AuthModule) RegisterRoutes(routes RouteRegistrar) {
 		_, _ = w.Write([]byte(logoutLoadingHTML))
 	})
 
-	// Test-only endpoint: GET /test/last-reset-token?user_id=...
-	if deps.AuthProvider == "password" && deps.TestMode {
-		routes.HandleFunc("GET", "/test/last-reset-token", m.handleTestLastResetToken())
-		log.Println("  ✓ Test-only endpoint mounted: GET /test/last-reset-token (live — reads in-process sync.Map)")
+	// Test-only endpoint: GET /test/outbox?to=... (latest mail per address)
+	if outbox, ok := deps.Mailer.(MailOutbox); ok && deps.TestMode {
+		routes.HandleFunc("GET", "/test/outbox", m.handleTestOutbox(outbox))
+		log.Println("  ✓ Test-only endpoint mounted: GET /test/outbox (reads the Mailer outbox)")
 	}
 
 	log.Println("  ✓ Auth screens initialized (login, signup, reset-password, change-password, logout)")
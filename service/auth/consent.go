@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	consentmod "github.com/erniealice/entydad-golang/service/auth/views/login02/consent"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+const (
+	consentPendingCookieName = "consent_pending"
+	// consentPendingTTL bounds how long a verified login may sit at the
+	// consent step; longer than the MFA window, the documents take reading.
+	consentPendingTTL = 15 * time.Minute
+)
+
+// ConsentDocument is one versioned document every user must accept — the
+// terms of service, the privacy notice. Title and Body (markdown) are
+// usually loaded from lyngua in the user's locale; Version is what an
+// acceptance is recorded against, so bumping it asks everyone again.
+type ConsentDocument struct {
+	Kind    string `json:"kind"` // stable key, e.g. "terms", "privacy"
+	Version string `json:"version"`
+	Title   string `json:"title"`
+	Body    string `json:"body"`
+}
+
+// ConsentAcceptance is one recorded acceptance of a consent document. Title
+// is the document title as shown when it was accepted.
+type ConsentAcceptance struct {
+	UserID     string
+	Kind       string
+	Version    string
+	Title      string
+	AcceptedAt time.Time
+	IP         string
+	UserAgent  string
+}
+
+// RecordConsent stores an acceptance. Injected as a closure over the host's
+// consent table.
+type RecordConsent func(ctx context.Context, acceptance ConsentAcceptance) error
+
+// ListConsents returns every acceptance recorded for userID, in any order.
+type ListConsents func(ctx context.Context, userID string) ([]ConsentAcceptance, error)
+
+// consentPending is the login parked at the consent step. Like mfaPending it
+// carries the withheld session token in a sealed cookie scoped to /auth/.
+type consentPending struct {
+	Token   string `json:"t"`
+	UserID  string `json:"u"`
+	Email   string `json:"e"`
+	Method  string `json:"m,omitempty"` // sign-in method, for the security event log
+	Expires int64  `json:"x"`
+}
+
+// consentEnabled reports whether the consent step is switched on and fully
+// wired.
+func (m *AuthModule) consentEnabled() bool {
+	return len(m.deps.ConsentDocuments) > 0 && m.deps.RecordConsent != nil && m.deps.ListConsents != nil
+}
+
+// consentDue returns the documents userID has not accepted at their current
+// version.
+func (m *AuthModule) consentDue(ctx context.Context, userID string) ([]ConsentDocument, error) {
+	accepted, err := m.deps.ListConsents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var due []ConsentDocument
+	for _, d := range m.deps.ConsentDocuments {
+		found := false
+		for _, a := range accepted {
+			if a.Kind == d.Kind && a.Version == d.Version {
+				found = true
+				break
+			}
+		}
+		if !found {
+			due = append(due, d)
+		}
+	}
+	return due, nil
+}
+
+// parkLogin holds a freshly authenticated login back from its session
+// cookie while an interstitial is due: the two-step challenge first, then
+// the consent step. A sign-in its home realm refuses goes no further.
+// Passkeys skip the challenge and run the other two themselves.
+// parked=false means carry on with SetSessionCookie + routePrincipals.
+func (m *AuthModule) parkLogin(w http.ResponseWriter, r *http.Request, token, userID, email string) (target string, parked bool) {
+	if target, refused := m.homeRealmRefused(r, token, userID, email); refused {
+		return target, true
+	}
+	if target, pending := m.beginMFA(w, r, token, userID, email); pending {
+		return target, true
+	}
+	return m.beginConsent(w, r, token, userID, email)
+}
+
+// beginConsent parks the login at /auth/consent when the user has not
+// accepted the current version of every consent document. A lookup failure
+// fails CLOSED, like beginMFA.
+func (m *AuthModule) beginConsent(w http.ResponseWriter, r *http.Request, token, userID, email string) (target string, pending bool) {
+	if !m.consentEnabled() || userID == "" {
+		return "", false
+	}
+	due, err := m.consentDue(r.Context(), userID)
+	if err != nil {
+		log.Printf("[AUTH] consent: lookup failed for user %s: %v", userID, err)
+		m.abandonSession(r.Context(), token)
+		return entydad.AuthLoginURL + "?error=consent", true
+	}
+	if len(due) == 0 {
+		return "", false
+	}
+	p := consentPending{
+		Token:   token,
+		UserID:  userID,
+		Email:   email,
+		Method:  m.signInMethod(r),
+		Expires: time.Now().Add(consentPendingTTL).Unix(),
+	}
+	if err := m.setSealedCookie(w, consentPendingCookieName, p, consentPendingTTL); err != nil {
+		log.Printf("[AUTH] consent: pending cookie failed for user %s: %v", userID, err)
+		m.abandonSession(r.Context(), token)
+		return entydad.AuthLoginURL + "?error=consent", true
+	}
+	return entydad.AuthConsentURL, true
+}
+
+// handleConsentPage returns the GET /auth/consent handler: the documents
+// still to accept. A user who accepted them meanwhile (another tab) is let
+// through.
+func (m *AuthModule) handleConsentPage(page *consentmod.Deps) http.HandlerFunc {
+	v := consentmod.NewView(page)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		p, ok := m.readConsentPending(r)
+		if !ok {
+			http.Redirect(w, r, entydad.AuthLoginURL, http.StatusSeeOther)
+			return
+		}
+		due, err := m.consentDue(r.Context(), p.UserID)
+		if err != nil {
+			log.Printf("[AUTH] consent: lookup failed for user %s: %v", p.UserID, err)
+			m.abandonConsent(w, r, p)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=consent", http.StatusSeeOther)
+			return
+		}
+		if len(due) == 0 {
+			http.Redirect(w, r, m.completeConsent(w, r, p), http.StatusSeeOther)
+			return
+		}
+		m.renderConsent(w, r, v, consentmod.State{Documents: consentViewDocuments(due)})
+	}
+}
+
+// handleConsentAccept returns the POST /auth/consent handler. Every due
+// document must be ticked at the version shown; each acceptance is recorded
+// with its timestamp and the client IP before the held session is issued.
+// Declining drops the parked login.
+func (m *AuthModule) handleConsentAccept(page *consentmod.Deps) http.HandlerFunc {
+	v := consentmod.NewView(page)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+		p, ok := m.readConsentPending(r)
+		if !ok {
+			http.Redirect(w, r, entydad.AuthLoginURL, http.StatusSeeOther)
+			return
+		}
+		if r.FormValue("action") == "decline" {
+			log.Printf("[AUTH] consent declined by user %s", p.UserID)
+			m.abandonConsent(w, r, p)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=consent_declined", http.StatusSeeOther)
+			return
+		}
+		due, err := m.consentDue(r.Context(), p.UserID)
+		if err != nil {
+			log.Printf("[AUTH] consent: lookup failed for user %s: %v", p.UserID, err)
+			m.abandonConsent(w, r, p)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=consent", http.StatusSeeOther)
+			return
+		}
+		ticked := make(map[string]bool, len(r.Form["accept"]))
+		for _, a := range r.Form["accept"] {
+			ticked[a] = true
+		}
+		for _, d := range due {
+			if !ticked[d.Kind+":"+d.Version] {
+				m.renderConsent(w, r, v, consentmod.State{Documents: consentViewDocuments(due), ErrorCode: "required"})
+				return
+			}
+		}
+		now := time.Now().UTC()
+		for _, d := range due {
+			err := m.deps.RecordConsent(r.Context(), ConsentAcceptance{
+				UserID:     p.UserID,
+				Kind:       d.Kind,
+				Version:    d.Version,
+				Title:      d.Title,
+				AcceptedAt: now,
+				IP:         m.clientIP(r),
+				UserAgent:  r.UserAgent(),
+			})
+			if err != nil {
+				// Unrecorded consent is no consent: fail closed.
+				log.Printf("[AUTH] consent: recording %s %s for user %s failed: %v", d.Kind, d.Version, p.UserID, err)
+				m.abandonConsent(w, r, p)
+				http.Redirect(w, r, entydad.AuthLoginURL+"?error=consent", http.StatusSeeOther)
+				return
+			}
+		}
+		log.Printf("[AUTH] consent OK: user=%s documents=%d", p.UserID, len(due))
+		http.Redirect(w, r, m.completeConsent(w, r, p), http.StatusSeeOther)
+	}
+}
+
+// completeConsent releases the parked login, like completeMFA.
+func (m *AuthModule) completeConsent(w http.ResponseWriter, r *http.Request, p consentPending) string {
+	m.clearSealedCookie(w, consentPendingCookieName)
+	if _, err := m.deps.AuthAdapter.ValidateSession(r.Context(), p.Token); err != nil {
+		// Same message as a timed-out two-step verification.
+		log.Printf("[AUTH] consent: parked session no longer valid for user %s: %v", p.UserID, err)
+		return entydad.AuthLoginURL + "?error=mfa_expired"
+	}
+	return m.releaseLogin(w, r, p.Token, p.UserID)
+}
+
+// abandonConsent drops a parked login (decline, store failure).
+func (m *AuthModule) abandonConsent(w http.ResponseWriter, r *http.Request, p consentPending) {
+	m.clearSealedCookie(w, consentPendingCookieName)
+	m.abandonSession(r.Context(), p.Token)
+}
+
+func (m *AuthModule) readConsentPending(r *http.Request) (consentPending, bool) {
+	var p consentPending
+	if !m.openSealedCookie(r, consentPendingCookieName, &p) {
+		return consentPending{}, false
+	}
+	if p.Token == "" || p.UserID == "" || time.Now().Unix() > p.Expires {
+		return consentPending{}, false
+	}
+	return p, true
+}
+
+func (m *AuthModule) renderConsent(w http.ResponseWriter, r *http.Request, v view.View, state consentmod.State) {
+	result := v.Handle(consentmod.WithState(r.Context(), state), &view.ViewContext{
+		Request:     r,
+		CurrentPath: r.URL.Path,
+	})
+	m.renderAuthView(w, r, result)
+}
+
+func consentViewDocuments(docs []ConsentDocument) []consentmod.Document {
+	out := make([]consentmod.Document, 0, len(docs))
+	for _, d := range docs {
+		out = append(out, consentmod.Document{Kind: d.Kind, Version: d.Version, Title: d.Title, Body: d.Body})
+	}
+	return out
+}
+
+// ConsentHistory returns userID's consent acceptances, newest first, for
+// the portal account page.
+func (m *AuthModule) ConsentHistory(ctx context.Context, userID string) ([]ConsentAcceptance, error) {
+	if m.deps.ListConsents == nil {
+		return nil, nil
+	}
+	out, err := m.deps.ListConsents(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].AcceptedAt.After(out[j].AcceptedAt) })
+	return out, nil
+}
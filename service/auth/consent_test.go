@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+
+	entydad "github.com/erniealice/entydad-golang"
+	consentmod "github.com/erniealice/entydad-golang/service/auth/views/login02/consent"
+)
+
+type memoryConsents struct {
+	mu       sync.Mutex
+	accepted []ConsentAcceptance
+}
+
+func (c *memoryConsents) record(_ context.Context, a ConsentAcceptance) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.accepted = append(c.accepted, a)
+	return nil
+}
+
+func (c *memoryConsents) list(_ context.Context, userID string) ([]ConsentAcceptance, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var out []ConsentAcceptance
+	for _, a := range c.accepted {
+		if a.UserID == userID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func TestConsent_HeldUntilCurrentVersionsAccepted(t *testing.T) {
+	t.Parallel()
+	sessions := &recordingSessionManager{}
+	adapter := &passwordAdapter{users: map[string]string{"rosa@example.com": "s3cret-pass"}}
+	consents := &memoryConsents{}
+	m := NewAuthModule(&Deps{
+		AuthAdapter:    adapter,
+		SessionManager: sessions,
+		Renderer:       nopRenderer{},
+		UserIDByEmail:  func(_ context.Context, email string) string { return "user-" + email },
+		CSRFIssuer:     func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:     []byte("test-secret"),
+		ConsentDocuments: []ConsentDocument{
+			{Kind: "terms", Version: "2026-10", Title: "Terms of Service", Body: "# Terms"},
+			{Kind: "privacy", Version: "2026-01", Title: "Privacy Notice", Body: "# Privacy"},
+		},
+		RecordConsent: consents.record,
+		ListConsents:  consents.list,
+	})
+	const userID = "user-rosa@example.com"
+	page := &consentmod.Deps{}
+	login := func() *httptest.ResponseRecorder {
+		return postForm(m.handleLogin(), entydad.AuthLoginPostURL, url.Values{"email": {"rosa@example.com"}, "password": {"s3cret-pass"}}, nil)
+	}
+
+	rec := login()
+	pending := cookieNamed(rec, consentPendingCookieName)
+	if rec.Header().Get("Location") != entydad.AuthConsentURL || pending == nil || sessions.token != "" {
+		t.Fatalf("login: Location = %q, session = %q", rec.Header().Get("Location"), sessions.token)
+	}
+	req := httptest.NewRequest(http.MethodGet, entydad.AuthConsentURL, nil)
+	req.AddCookie(pending[0])
+	rec = httptest.NewRecorder()
+	m.handleConsentPage(page)(rec, req)
+	if rec.Code != http.StatusOK || rec.Body.String() != "consent" {
+		t.Fatalf("page: %d %q", rec.Code, rec.Body.String())
+	}
+
+	// One document unticked, or ticked at a stale version: not accepted.
+	for _, accept := range [][]string{{"terms:2026-10"}, {"terms:2025-01", "privacy:2026-01"}} {
+		rec = postForm(m.handleConsentAccept(page), entydad.AuthConsentPostURL, url.Values{"accept": accept}, pending)
+		if rec.Body.String() != "consent" || sessions.token != "" || len(consents.accepted) != 0 {
+			t.Fatalf("accept %v: body %q, session %q, recorded %d", accept, rec.Body.String(), sessions.token, len(consents.accepted))
+		}
+	}
+
+	rec = postForm(m.handleConsentAccept(page), entydad.AuthConsentPostURL, url.Values{"accept": {"terms:2026-10", "privacy:2026-01"}}, pending)
+	if rec.Header().Get("Location") != entydad.DefaultAppRedirectURL || sessions.token != "session-rosa@example.com" {
+		t.Fatalf("accepted: Location = %q, session = %q", rec.Header().Get("Location"), sessions.token)
+	}
+	if len(consents.accepted) != 2 {
+		t.Fatalf("recorded %+v", consents.accepted)
+	}
+	for _, a := range consents.accepted {
+		if a.UserID != userID || a.IP == "" || a.AcceptedAt.IsZero() || a.Title == "" {
+			t.Errorf("acceptance %+v", a)
+		}
+	}
+
+	// Accepted: straight through on the next sign-in.
+	sessions.token = ""
+	if rec = login(); rec.Header().Get("Location") != entydad.DefaultAppRedirectURL {
+		t.Fatalf("second login: Location = %q", rec.Header().Get("Location"))
+	}
+
+	// A new terms version asks again; declining drops the held session.
+	m.deps.ConsentDocuments[0].Version = "2026-11"
+	sessions.token = ""
+	rec = login()
+	pending = cookieNamed(rec, consentPendingCookieName)
+	if rec.Header().Get("Location") != entydad.AuthConsentURL || pending == nil {
+		t.Fatalf("new version: Location = %q", rec.Header().Get("Location"))
+	}
+	rec = postForm(m.handleConsentAccept(page), entydad.AuthConsentPostURL, url.Values{"action": {"decline"}}, pending)
+	if rec.Header().Get("Location") != entydad.AuthLoginURL+"?error=consent_declined" || sessions.token != "" {
+		t.Fatalf("declined: Location = %q, session = %q", rec.Header().Get("Location"), sessions.token)
+	}
+	if n := len(adapter.invalidated); n != 1 || adapter.invalidated[0] != "session-rosa@example.com" {
+		t.Fatalf("declined session not invalidated: %v", adapter.invalidated)
+	}
+
+	history, err := m.ConsentHistory(context.Background(), userID)
+	if err != nil || len(history) != 2 {
+		t.Fatalf("history = %+v, %v", history, err)
+	}
+}
+
+func TestConsent_SignupAutoLoginHeld(t *testing.T) {
+	t.Parallel()
+	sessions := &recordingSessionManager{}
+	consents := &memoryConsents{}
+	m := NewAuthModule(&Deps{
+		AuthAdapter:      &passwordAdapter{users: make(map[string]string)},
+		SessionManager:   sessions,
+		Renderer:         nopRenderer{},
+		UserIDByEmail:    func(_ context.Context, email string) string { return "user-" + email },
+		CSRFIssuer:       func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:       []byte("test-secret"),
+		AllowSignups:     true,
+		ConsentDocuments: []ConsentDocument{{Kind: "terms", Version: "2026-10", Title: "Terms of Service", Body: "# Terms"}},
+		RecordConsent:    consents.record,
+		ListConsents:     consents.list,
+	})
+
+	signup := url.Values{"email": {"new@example.com"}, "password": {"s3cret-pass"}, "confirm_password": {"s3cret-pass"}}
+	rec := postForm(m.handleSignup(), entydad.AuthSignupPostURL, signup, nil)
+	pending := cookieNamed(rec, consentPendingCookieName)
+	if rec.Header().Get("Location") != entydad.AuthConsentURL || pending == nil || sessions.token != "" {
+		t.Fatalf("signup: Location = %q, session = %q", rec.Header().Get("Location"), sessions.token)
+	}
+	rec = postForm(m.handleConsentAccept(&consentmod.Deps{}), entydad.AuthConsentPostURL, url.Values{"accept": {"terms:2026-10"}}, pending)
+	if rec.Header().Get("Location") != entydad.DefaultAppRedirectURL || sessions.token != "session-new@example.com" {
+		t.Fatalf("accepted: Location = %q, session = %q", rec.Header().Get("Location"), sessions.token)
+	}
+	if len(consents.accepted) != 1 || consents.accepted[0].UserID != "user-new@example.com" {
+		t.Fatalf("recorded %+v", consents.accepted)
+	}
+}
@@ -0,0 +1,462 @@
+package auth
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+// Self-service data export: RequestDataExport records a pending export and
+// builds its ZIP in the background from Deps.PersonalData (plus the consent
+// history when ListConsents is set). The portal account page lists the
+// user's exports; a ready one downloads from entydad.AuthDataExportURL
+// until DataExportMaxAge has passed. PurgeExpiredDataExports, called from
+// the host's scheduler, drops the archives past that window.
+
+// Data export errors. ErrImpersonating is also returned: an impersonator
+// cannot take out the user's data.
+var (
+	ErrDataExportDisabled = errors.New("auth: data export is not configured")
+	ErrDataExportPending  = errors.New("auth: a data export is already being prepared")
+	ErrDataExportNotFound = errors.New("auth: data export not found")
+)
+
+// dataExportBuildTimeout bounds building one export. A pending export
+// older than this was lost with its goroutine (a restart, a crash) and
+// counts as failed, so it no longer blocks a new request.
+const dataExportBuildTimeout = time.Hour
+
+// Data export states.
+const (
+	DataExportPending = "pending"
+	DataExportReady   = "ready"
+	DataExportFailed  = "failed"
+	DataExportExpired = "expired" // reported by ListDataExports, never stored
+)
+
+// DataExport is one requested export. Archive holds the ZIP once Status is
+// DataExportReady.
+type DataExport struct {
+	ID          string
+	UserID      string
+	Status      string
+	RequestedAt time.Time
+	CompletedAt time.Time
+	ExpiresAt   time.Time
+	Archive     []byte
+}
+
+// DataExportStore keeps data exports. GetDataExport returns nil, nil for an
+// unknown id; ListDataExports lists a user's exports newest first;
+// ExpiredDataExports lists the exports, of every user, that still hold an
+// archive and expired at or before now.
+type DataExportStore interface {
+	CreateDataExport(ctx context.Context, e DataExport) error
+	UpdateDataExport(ctx context.Context, e DataExport) error
+	DeleteDataExport(ctx context.Context, id string) error
+	GetDataExport(ctx context.Context, id string) (*DataExport, error)
+	ListDataExports(ctx context.Context, userID string) ([]DataExport, error)
+	ExpiredDataExports(ctx context.Context, now time.Time) ([]DataExport, error)
+}
+
+// PersonalData is one section of a data export. JSON, when set, is written
+// to <name>.json; Columns and Rows to <name>.csv. A section may carry both.
+type PersonalData struct {
+	JSON    any
+	Columns []string
+	Rows    [][]string
+}
+
+// PersonalDataCollector gathers one section of a user's data. The host
+// injects one per section, keyed by file name: typically "profile",
+// "workspace_memberships", "role_assignments", "conversation_posts" and
+// "security_events". "consents" is built in from ListConsents unless the
+// host supplies its own.
+type PersonalDataCollector func(ctx context.Context, userID string) (PersonalData, error)
+
+// DataExportSummary is what the portal account page shows about an export.
+type DataExportSummary struct {
+	ID          string
+	Status      string
+	RequestedAt time.Time
+	ExpiresAt   time.Time
+	DownloadURL string // set while the export is ready
+}
+
+func (m *AuthModule) dataExportEnabled() bool {
+	return m.deps.DataExports != nil && (len(m.deps.PersonalData) > 0 || m.deps.ListConsents != nil)
+}
+
+// RequestDataExport starts building an export of the user's data and
+// returns it in the pending state. Refused while another export is pending
+// and inside an impersonated session; a stale pending export is marked
+// failed instead.
+func (m *AuthModule) RequestDataExport(ctx context.Context, userID string) (DataExportSummary, error) {
+	if !m.dataExportEnabled() {
+		return DataExportSummary{}, ErrDataExportDisabled
+	}
+	if m.impersonating(ctx, nil) {
+		return DataExportSummary{}, ErrImpersonating
+	}
+	store := m.deps.DataExports
+	existing, err := store.ListDataExports(ctx, userID)
+	if err != nil {
+		return DataExportSummary{}, err
+	}
+	for _, e := range existing {
+		if e.Status != DataExportPending {
+			continue
+		}
+		if !dataExportStale(e) {
+			return DataExportSummary{}, ErrDataExportPending
+		}
+		log.Printf("[AUTH] data export %s for user %s never finished, marking it failed", e.ID, userID)
+		e.Status = DataExportFailed
+		e.CompletedAt = time.Now()
+		if err := store.UpdateDataExport(ctx, e); err != nil {
+			return DataExportSummary{}, err
+		}
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return DataExportSummary{}, fmt.Errorf("auth: data export id: %w", err)
+	}
+	export := DataExport{
+		ID:          hex.EncodeToString(b),
+		UserID:      userID,
+		Status:      DataExportPending,
+		RequestedAt: time.Now(),
+	}
+	if err := store.CreateDataExport(ctx, export); err != nil {
+		return DataExportSummary{}, err
+	}
+	m.recordAuthEvent(ctx, nil, AuthEvent{Type: AuthEventDataExportRequested, UserID: userID})
+	// The request that asked for it returns at once; the archive is built
+	// outside its lifetime.
+	go m.buildDataExport(context.WithoutCancel(ctx), export)
+	return m.dataExportSummary(export), nil
+}
+
+// buildDataExport collects every section and stores the archive, or marks
+// the export failed when a section cannot be read.
+func (m *AuthModule) buildDataExport(ctx context.Context, export DataExport) {
+	archive, err := m.collectDataExport(ctx, export.UserID)
+	export.CompletedAt = time.Now()
+	if err != nil {
+		log.Printf("[AUTH] data export %s for user %s failed: %v", export.ID, export.UserID, err)
+		export.Status = DataExportFailed
+	} else {
+		export.Status = DataExportReady
+		export.Archive = archive
+		export.ExpiresAt = export.CompletedAt.Add(m.deps.DataExportMaxAge)
+	}
+	if err := m.deps.DataExports.UpdateDataExport(ctx, export); err != nil {
+		log.Printf("[AUTH] data export %s: failed to store: %v", export.ID, err)
+	}
+}
+
+// collectDataExport runs personalDataArchive within dataExportBuildTimeout.
+// A panicking collector fails the export rather than leaving it pending.
+func (m *AuthModule) collectDataExport(ctx context.Context, userID string) (archive []byte, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("panic: %v", p)
+		}
+	}()
+	ctx, cancel := context.WithTimeout(ctx, dataExportBuildTimeout)
+	defer cancel()
+	return m.personalDataArchive(ctx, userID)
+}
+
+// dataExportStale reports whether e has been pending past
+// dataExportBuildTimeout.
+func dataExportStale(e DataExport) bool {
+	return e.Status == DataExportPending && time.Since(e.RequestedAt) > dataExportBuildTimeout
+}
+
+// personalDataArchive writes the ZIP: one file per section, sorted by name.
+func (m *AuthModule) personalDataArchive(ctx context.Context, userID string) ([]byte, error) {
+	collectors := make(map[string]PersonalDataCollector, len(m.deps.PersonalData)+1)
+	if m.deps.ListConsents != nil {
+		collectors["consents"] = m.consentData
+	}
+	for name, c := range m.deps.PersonalData {
+		collectors[name] = c
+	}
+	names := make([]string, 0, len(collectors))
+	for name := range collectors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, name := range names {
+		data, err := collectors[name](ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		if data.JSON != nil {
+			f, err := zw.Create(name + ".json")
+			if err != nil {
+				return nil, err
+			}
+			enc := json.NewEncoder(f)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(data.JSON); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if len(data.Columns) > 0 {
+			f, err := zw.Create(name + ".csv")
+			if err != nil {
+				return nil, err
+			}
+			cw := csv.NewWriter(f)
+			_ = cw.Write(data.Columns)
+			_ = cw.WriteAll(data.Rows)
+			if err := cw.Error(); err != nil {
+				return nil, fmt.Errorf("%s: %w", name, err)
+			}
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// consentData is the built-in "consents" section.
+func (m *AuthModule) consentData(ctx context.Context, userID string) (PersonalData, error) {
+	history, err := m.ConsentHistory(ctx, userID)
+	if err != nil {
+		return PersonalData{}, err
+	}
+	data := PersonalData{Columns: []string{"kind", "version", "title", "accepted_at", "ip", "user_agent"}}
+	for _, a := range history {
+		data.Rows = append(data.Rows, []string{a.Kind, a.Version, a.Title, a.AcceptedAt.UTC().Format(time.RFC3339), a.IP, a.UserAgent})
+	}
+	return data, nil
+}
+
+// ListDataExports returns the user's exports, newest first. Ready exports
+// past their download window are reported as DataExportExpired, stale
+// pending ones as DataExportFailed.
+func (m *AuthModule) ListDataExports(ctx context.Context, userID string) ([]DataExportSummary, error) {
+	if !m.dataExportEnabled() {
+		return nil, ErrDataExportDisabled
+	}
+	exports, err := m.deps.DataExports.ListDataExports(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DataExportSummary, 0, len(exports))
+	for _, e := range exports {
+		out = append(out, m.dataExportSummary(e))
+	}
+	return out, nil
+}
+
+func (m *AuthModule) dataExportSummary(e DataExport) DataExportSummary {
+	s := DataExportSummary{ID: e.ID, Status: e.Status, RequestedAt: e.RequestedAt, ExpiresAt: e.ExpiresAt}
+	if dataExportStale(e) {
+		s.Status = DataExportFailed
+	}
+	if e.Status == DataExportReady {
+		if time.Now().After(e.ExpiresAt) {
+			s.Status = DataExportExpired
+		} else {
+			s.DownloadURL = strings.Replace(entydad.AuthDataExportURL, "{id}", e.ID, 1)
+		}
+	}
+	return s
+}
+
+// DataExportArchive returns the ZIP of one of the user's ready exports.
+func (m *AuthModule) DataExportArchive(ctx context.Context, userID, id string) ([]byte, error) {
+	if !m.dataExportEnabled() {
+		return nil, ErrDataExportDisabled
+	}
+	if m.impersonating(ctx, nil) {
+		return nil, ErrImpersonating
+	}
+	e, err := m.deps.DataExports.GetDataExport(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil || e.UserID != userID || e.Status != DataExportReady || time.Now().After(e.ExpiresAt) {
+		return nil, ErrDataExportNotFound
+	}
+	return e.Archive, nil
+}
+
+// PurgeExpiredDataExports drops the archive of every export past its
+// download window and returns how many it dropped. The export itself is
+// kept, so the account page still lists it as expired. Call it from the
+// host's scheduler, e.g. hourly.
+func (m *AuthModule) PurgeExpiredDataExports(ctx context.Context) (int, error) {
+	if m.deps.DataExports == nil {
+		return 0, ErrDataExportDisabled
+	}
+	store := m.deps.DataExports
+	expired, err := store.ExpiredDataExports(ctx, time.Now())
+	if err != nil {
+		return 0, err
+	}
+	var (
+		done     int
+		firstErr error
+	)
+	for _, e := range expired {
+		e.Archive = nil
+		if err := store.UpdateDataExport(ctx, e); err != nil {
+			log.Printf("[AUTH] data export %s: failed to purge the archive: %v", e.ID, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		done++
+	}
+	return done, firstErr
+}
+
+// deleteDataExports deletes all of the user's exports, archives included.
+func (m *AuthModule) deleteDataExports(ctx context.Context, userID string) error {
+	if m.deps.DataExports == nil {
+		return nil
+	}
+	exports, err := m.deps.DataExports.ListDataExports(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, e := range exports {
+		if err := m.deps.DataExports.DeleteDataExport(ctx, e.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleDataExportDownload returns the GET /action/auth/data-export/{id}
+// handler, which sends the signed-in user one of their ready exports.
+func (m *AuthModule) handleDataExportDownload() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := identity.FromContext(r.Context())
+		if !ok || id == nil || id.UserID == "" {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		archive, err := m.DataExportArchive(r.Context(), id.UserID, r.PathValue("id"))
+		switch {
+		case errors.Is(err, ErrDataExportNotFound):
+			http.Error(w, "Not Found", http.StatusNotFound)
+			return
+		case errors.Is(err, ErrImpersonating):
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		case err != nil:
+			log.Printf("[AUTH] data export download failed for user %s: %v", id.UserID, err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		filename := fmt.Sprintf("my-data-%s.zip", time.Now().Format("2006-01-02"))
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.Header().Set("Cache-Control", "no-store")
+		_, _ = w.Write(archive)
+	}
+}
+
+// MemoryDataExportStore is an in-process DataExportStore for
+// single-instance deployments and tests.
+type MemoryDataExportStore struct {
+	mu      sync.Mutex
+	exports map[string]DataExport
+}
+
+// NewMemoryDataExportStore returns an empty in-memory store.
+func NewMemoryDataExportStore() *MemoryDataExportStore {
+	return &MemoryDataExportStore{exports: make(map[string]DataExport)}
+}
+
+// CreateDataExport implements DataExportStore.
+func (s *MemoryDataExportStore) CreateDataExport(_ context.Context, e DataExport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.exports[e.ID] = e
+	return nil
+}
+
+// UpdateDataExport implements DataExportStore.
+func (s *MemoryDataExportStore) UpdateDataExport(_ context.Context, e DataExport) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.exports[e.ID]; !ok {
+		return ErrDataExportNotFound
+	}
+	s.exports[e.ID] = e
+	return nil
+}
+
+// DeleteDataExport implements DataExportStore.
+func (s *MemoryDataExportStore) DeleteDataExport(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.exports, id)
+	return nil
+}
+
+// GetDataExport implements DataExportStore.
+func (s *MemoryDataExportStore) GetDataExport(_ context.Context, id string) (*DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.exports[id]
+	if !ok {
+		return nil, nil
+	}
+	return &e, nil
+}
+
+// ListDataExports implements DataExportStore.
+func (s *MemoryDataExportStore) ListDataExports(_ context.Context, userID string) ([]DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []DataExport
+	for _, e := range s.exports {
+		if e.UserID == userID {
+			out = append(out, e)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].RequestedAt.After(out[j].RequestedAt) })
+	return out, nil
+}
+
+// ExpiredDataExports implements DataExportStore.
+func (s *MemoryDataExportStore) ExpiredDataExports(_ context.Context, now time.Time) ([]DataExport, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []DataExport
+	for _, e := range s.exports {
+		if e.Archive != nil && !e.ExpiresAt.After(now) {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
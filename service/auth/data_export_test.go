@@ -0,0 +1,249 @@
+package auth
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+func TestDataExport_BuildsArchiveAndServesItToItsOwner(t *testing.T) {
+	t.Parallel()
+
+	consents := &memoryConsents{}
+	_ = consents.record(context.Background(), ConsentAcceptance{UserID: "user-1", Kind: "terms", Version: "2", AcceptedAt: time.Now()})
+	m := NewAuthModule(&Deps{
+		Renderer:     nopRenderer{},
+		DataExports:  NewMemoryDataExportStore(),
+		ListConsents: consents.list,
+		PersonalData: map[string]PersonalDataCollector{
+			"profile": func(_ context.Context, userID string) (PersonalData, error) {
+				return PersonalData{JSON: map[string]string{"id": userID, "email": "rosa@example.com"}}, nil
+			},
+			"workspace_memberships": func(context.Context, string) (PersonalData, error) {
+				return PersonalData{Columns: []string{"workspace", "joined_at"}, Rows: [][]string{{"acme", "2026-01-02"}}}, nil
+			},
+		},
+	})
+	ctx := context.Background()
+
+	export, err := m.RequestDataExport(ctx, "user-1")
+	if err != nil || export.Status != DataExportPending {
+		t.Fatalf("RequestDataExport = %+v, %v", export, err)
+	}
+	var ready DataExportSummary
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		list, err := m.ListDataExports(ctx, "user-1")
+		if err != nil || len(list) != 1 {
+			t.Fatalf("ListDataExports = %+v, %v", list, err)
+		}
+		if ready = list[0]; ready.Status != DataExportPending {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("export still pending")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	if ready.Status != DataExportReady || ready.DownloadURL != "/action/auth/data-export/"+export.ID {
+		t.Fatalf("export = %+v", ready)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /action/auth/data-export/{id}", m.handleDataExportDownload())
+	download := func(userID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, ready.DownloadURL, nil)
+		req = req.WithContext(identity.WithRequestIdentity(req.Context(), &identity.RequestIdentity{UserID: userID}))
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	if rec := download("user-2"); rec.Code != http.StatusNotFound {
+		t.Fatalf("someone else's download: %d", rec.Code)
+	}
+	rec := download("user-1")
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Disposition"), "attachment;") {
+		t.Fatalf("download: %d %v", rec.Code, rec.Header())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	files := map[string]string{}
+	for _, f := range zr.File {
+		rc, _ := f.Open()
+		b, _ := io.ReadAll(rc)
+		rc.Close()
+		files[f.Name] = string(b)
+	}
+	if len(files) != 3 ||
+		!strings.Contains(files["profile.json"], `"email": "rosa@example.com"`) ||
+		!strings.Contains(files["workspace_memberships.csv"], "acme,2026-01-02") ||
+		!strings.Contains(files["consents.csv"], "terms,2,") {
+		t.Fatalf("archive = %v", files)
+	}
+}
+
+// A pending export whose build died with the process no longer blocks the
+// user, and a panicking collector fails its export.
+func TestDataExport_StaleAndPanickedExportsFail(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := NewMemoryDataExportStore()
+	lost := DataExport{ID: "lost", UserID: "user-1", Status: DataExportPending, RequestedAt: time.Now().Add(-2 * time.Hour)}
+	if err := store.CreateDataExport(ctx, lost); err != nil {
+		t.Fatal(err)
+	}
+	m := NewAuthModule(&Deps{
+		Renderer:    nopRenderer{},
+		DataExports: store,
+		PersonalData: map[string]PersonalDataCollector{
+			"profile": func(context.Context, string) (PersonalData, error) { panic("collector bug") },
+		},
+	})
+
+	export, err := m.RequestDataExport(ctx, "user-1")
+	if err != nil {
+		t.Fatalf("RequestDataExport with a stale pending export: %v", err)
+	}
+	if e, _ := store.GetDataExport(ctx, "lost"); e == nil || e.Status != DataExportFailed {
+		t.Fatalf("stale export = %+v, want failed", e)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; {
+		e, _ := store.GetDataExport(ctx, export.ID)
+		if e != nil && e.Status == DataExportFailed {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("export after a collector panic = %+v, want failed", e)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestDataExport_PurgeExpiredArchives(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	store := NewMemoryDataExportStore()
+	now := time.Now()
+	for _, e := range []DataExport{
+		{ID: "old", UserID: "user-1", Status: DataExportReady, ExpiresAt: now.Add(-time.Minute), Archive: []byte("zip")},
+		{ID: "fresh", UserID: "user-1", Status: DataExportReady, ExpiresAt: now.Add(time.Hour), Archive: []byte("zip")},
+		{ID: "other", UserID: "user-2", Status: DataExportReady, ExpiresAt: now.Add(-time.Hour), Archive: []byte("zip")},
+	} {
+		if err := store.CreateDataExport(ctx, e); err != nil {
+			t.Fatal(err)
+		}
+	}
+	m := NewAuthModule(&Deps{Renderer: nopRenderer{}, DataExports: store})
+
+	if n, err := m.PurgeExpiredDataExports(ctx); n != 2 || err != nil {
+		t.Fatalf("PurgeExpiredDataExports = %d, %v", n, err)
+	}
+	for id, want := range map[string]bool{"old": false, "fresh": true, "other": false} {
+		e, _ := store.GetDataExport(ctx, id)
+		if e == nil || (e.Archive != nil) != want {
+			t.Errorf("%s after the sweep = %+v, archive kept %v", id, e, want)
+		}
+	}
+	// The export stays listed as expired; a second sweep finds nothing.
+	if list, _ := store.ListDataExports(ctx, "user-2"); len(list) != 1 || m.dataExportSummary(list[0]).Status != DataExportExpired {
+		t.Fatalf("purged export = %+v", list)
+	}
+	if n, err := m.PurgeExpiredDataExports(ctx); n != 0 || err != nil {
+		t.Fatalf("second sweep = %d, %v", n, err)
+	}
+}
+
+func TestAccountDeletion_GracePeriodCancelAndAnonymize(t *testing.T) {
+	t.Parallel()
+
+	store := NewMemoryAccountDeletionStore()
+	exports := NewMemoryDataExportStore()
+	events := NewMemoryAuthEventSink()
+	var anonymized []string
+	m := NewAuthModule(&Deps{
+		Renderer:         nopRenderer{},
+		DataExports:      exports,
+		AccountDeletions: store,
+		AuthEventSink:    events,
+		AnonymizeUser: func(_ context.Context, userID string) error {
+			if userID == "user-4" {
+				return errors.New("row locked")
+			}
+			anonymized = append(anonymized, userID)
+			return nil
+		},
+	})
+	ctx := context.Background()
+
+	scheduled, err := m.ScheduleAccountDeletion(ctx, "user-1")
+	if err != nil || time.Until(scheduled.ScheduledFor) < 29*24*time.Hour {
+		t.Fatalf("ScheduleAccountDeletion = %+v, %v", scheduled, err)
+	}
+	if again, _ := m.ScheduleAccountDeletion(ctx, "user-1"); !again.ScheduledFor.Equal(scheduled.ScheduledFor) {
+		t.Fatalf("rescheduling moved the date: %v -> %v", scheduled.ScheduledFor, again.ScheduledFor)
+	}
+	if n, err := m.RunAccountDeletions(ctx); n != 0 || err != nil || len(anonymized) != 0 {
+		t.Fatalf("within the grace period: n=%d err=%v anonymized=%v", n, err, anonymized)
+	}
+
+	// user-2 cancels; user-3's grace period has ended.
+	_, _ = m.ScheduleAccountDeletion(ctx, "user-2")
+	if err := m.CancelAccountDeletion(ctx, "user-2"); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.CancelAccountDeletion(ctx, "user-2"); !errors.Is(err, ErrAccountDeletionNotFound) {
+		t.Fatalf("cancel twice: %v", err)
+	}
+	_ = store.PutAccountDeletion(ctx, AccountDeletion{UserID: "user-3", ScheduledFor: time.Now().Add(-time.Minute)})
+	for _, e := range []DataExport{
+		{ID: "e3", UserID: "user-3", Status: DataExportReady, ExpiresAt: time.Now().Add(time.Hour), Archive: []byte("zip")},
+		{ID: "e1", UserID: "user-1", Status: DataExportReady, ExpiresAt: time.Now().Add(time.Hour), Archive: []byte("zip")},
+	} {
+		_ = exports.CreateDataExport(ctx, e)
+	}
+
+	_ = store.PutAccountDeletion(ctx, AccountDeletion{UserID: "user-4", ScheduledFor: time.Now().Add(-time.Minute)})
+
+	if n, err := m.RunAccountDeletions(ctx); n != 1 || err == nil || len(anonymized) != 1 || anonymized[0] != "user-3" {
+		t.Fatalf("after the grace period: n=%d err=%v anonymized=%v", n, err, anonymized)
+	}
+	// Only an anonymized account is recorded as deleted.
+	deleted := func(userID string) bool {
+		for _, e := range events.Events(userID, 0) {
+			if e.Type == AuthEventAccountDeleted {
+				return true
+			}
+		}
+		return false
+	}
+	if !deleted("user-3") || deleted("user-4") {
+		t.Fatalf("account_deleted events: user-3 %v, user-4 %v", deleted("user-3"), deleted("user-4"))
+	}
+	// The deleted account's exports go with it; the others stay.
+	if e, _ := exports.GetDataExport(ctx, "e3"); e != nil {
+		t.Fatalf("user-3's export survived the deletion: %+v", e)
+	}
+	if e, _ := exports.GetDataExport(ctx, "e1"); e == nil {
+		t.Fatal("user-1's export deleted")
+	}
+	if d, _ := m.AccountDeletionStatus(ctx, "user-4"); d == nil {
+		t.Fatal("user-4 no longer scheduled after a failed run")
+	}
+	if d, _ := m.AccountDeletionStatus(ctx, "user-3"); d != nil {
+		t.Fatalf("user-3 still scheduled: %+v", d)
+	}
+	if d, _ := m.AccountDeletionStatus(ctx, "user-1"); d == nil {
+		t.Fatal("user-1 no longer scheduled")
+	}
+}
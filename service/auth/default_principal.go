@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrDefaultPrincipalDisabled is returned by the default principal methods
+// when Deps.DefaultPrincipals is not configured.
+var ErrDefaultPrincipalDisabled = errors.New("auth: default principal is not configured")
+
+// DefaultPrincipal is the principal a user ticked "make this my default"
+// for on the chooser. routePrincipals switches into it after sign-in,
+// skipping the chooser, while the user still holds it.
+type DefaultPrincipal struct {
+	UserID             string
+	PrincipalID        string
+	Type               PrincipalType
+	ActingAsClientID   string
+	ActingAsSupplierID string
+	SetAt              time.Time
+}
+
+// DefaultPrincipalStore keeps each user's default principal.
+// GetDefaultPrincipal returns nil, nil when the user has none.
+type DefaultPrincipalStore interface {
+	GetDefaultPrincipal(ctx context.Context, userID string) (*DefaultPrincipal, error)
+	SetDefaultPrincipal(ctx context.Context, d DefaultPrincipal) error
+	ClearDefaultPrincipal(ctx context.Context, userID string) error
+}
+
+// DefaultPrincipalSummary is what the portal preferences page shows about
+// the user's default. Active is false when the user no longer holds the
+// principal; sign-in then shows the chooser again.
+type DefaultPrincipalSummary struct {
+	PrincipalID string
+	DisplayName string
+	Kind        string // PrincipalTypeString token
+	SetAt       time.Time
+	Active      bool
+}
+
+// DefaultPrincipal returns the user's default principal, or nil when they
+// have none.
+func (m *AuthModule) DefaultPrincipal(ctx context.Context, userID string) (*DefaultPrincipalSummary, error) {
+	store := m.deps.DefaultPrincipals
+	if store == nil {
+		return nil, ErrDefaultPrincipalDisabled
+	}
+	d, err := store.GetDefaultPrincipal(ctx, userID)
+	if err != nil || d == nil {
+		return nil, err
+	}
+	summary := &DefaultPrincipalSummary{
+		PrincipalID: d.PrincipalID,
+		DisplayName: d.PrincipalID,
+		Kind:        PrincipalTypeString(d.Type),
+		SetAt:       d.SetAt,
+	}
+	if m.deps.PrincipalResolver != nil {
+		principals, err := m.deps.PrincipalResolver.Resolve(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		if p, ok := findDefaultPrincipal(principals, d); ok {
+			summary.Active = true
+			if p.DisplayName != "" {
+				summary.DisplayName = p.DisplayName
+			}
+		}
+	}
+	return summary, nil
+}
+
+// ClearDefaultPrincipal forgets the user's default principal, so the next
+// sign-in shows the chooser again. Refused inside an impersonated session.
+func (m *AuthModule) ClearDefaultPrincipal(ctx context.Context, userID string) error {
+	store := m.deps.DefaultPrincipals
+	if store == nil {
+		return ErrDefaultPrincipalDisabled
+	}
+	if m.impersonating(ctx, nil) {
+		return ErrImpersonating
+	}
+	return store.ClearDefaultPrincipal(ctx, userID)
+}
+
+// defaultChoiceField marks a switch-principal form that carries the
+// "make this my default" checkbox. An unticked checkbox is not posted, so
+// without the marker an unticked answer and no question look the same.
+const defaultChoiceField = "default_choice"
+
+// rememberDefaultPrincipal records the chooser's "make this my default"
+// answer for a successful switch into p: ticked sets p as the default,
+// unticked on the card that is the default clears it. A form that did not
+// ask (no defaultChoiceField) leaves the default alone.
+func (m *AuthModule) rememberDefaultPrincipal(ctx context.Context, userID string, p Principal, actingAsClientID, actingAsSupplierID string, form url.Values) {
+	store := m.deps.DefaultPrincipals
+	if store == nil || form.Get(defaultChoiceField) != "1" {
+		return
+	}
+	var err error
+	if form.Get("make_default") == "1" {
+		err = store.SetDefaultPrincipal(ctx, DefaultPrincipal{
+			UserID:             userID,
+			PrincipalID:        p.PrincipalID,
+			Type:               p.Type,
+			ActingAsClientID:   actingAsClientID,
+			ActingAsSupplierID: actingAsSupplierID,
+			SetAt:              time.Now(),
+		})
+	} else if d, gerr := store.GetDefaultPrincipal(ctx, userID); gerr != nil {
+		err = gerr
+	} else if d != nil && d.PrincipalID == p.PrincipalID && d.Type == p.Type {
+		err = store.ClearDefaultPrincipal(ctx, userID)
+	}
+	if err != nil {
+		log.Printf("[AUTH] default principal: update failed for user %s: %v", userID, err)
+	}
+}
+
+// defaultPrincipalFor returns the user's default principal when it is
+// still among principals and, for a delegate, its remembered acting-as
+// target passes DelegateActingAsResolved — a default never bypasses the
+// delegate guard.
+func (m *AuthModule) defaultPrincipalFor(ctx context.Context, userID string, principals []Principal) (*DefaultPrincipal, Principal, bool) {
+	store := m.deps.DefaultPrincipals
+	if store == nil {
+		return nil, Principal{}, false
+	}
+	d, err := store.GetDefaultPrincipal(ctx, userID)
+	if err != nil {
+		log.Printf("[AUTH] default principal: lookup failed for user %s: %v", userID, err)
+		return nil, Principal{}, false
+	}
+	if d == nil {
+		return nil, Principal{}, false
+	}
+	p, ok := findDefaultPrincipal(principals, d)
+	if !ok || !DelegateActingAsResolved(p, d.ActingAsClientID, d.ActingAsSupplierID) {
+		return nil, Principal{}, false
+	}
+	return d, p, true
+}
+
+// findDefaultPrincipal finds d among principals by id and kind.
+func findDefaultPrincipal(principals []Principal, d *DefaultPrincipal) (Principal, bool) {
+	for _, p := range principals {
+		if p.PrincipalID == d.PrincipalID && p.Type == d.Type {
+			return p, true
+		}
+	}
+	return Principal{}, false
+}
+
+// routeToDefaultPrincipal switches the freshly signed-in session into the
+// user's default principal and returns the landing URL. A failed switch
+// falls back to the chooser.
+func (m *AuthModule) routeToDefaultPrincipal(w http.ResponseWriter, r *http.Request, token, userID string, d *DefaultPrincipal, p Principal) string {
+	result, err := m.deps.PrincipalSwitcher(r.Context(), PrincipalSwitchInput{
+		UserID:             userID,
+		Token:              token,
+		TargetPrincipal:    p,
+		ActingAsClientID:   d.ActingAsClientID,
+		ActingAsSupplierID: d.ActingAsSupplierID,
+		// Post-login auto-route into the remembered default.
+		// Explicit-form caller; RequireAudit=false.
+		UseCase:      "switch_explicit_rotate",
+		RequestURL:   r.URL.Path,
+		Referer:      r.Header.Get("Referer"),
+		SecFetchSite: r.Header.Get("Sec-Fetch-Site"),
+		UserAgent:    r.Header.Get("User-Agent"),
+		RequireAudit: false,
+	})
+	if err != nil || result == nil {
+		log.Printf("[AUTH] default principal switch failed for user %s: %v — routing to chooser", userID, err)
+		return "/auth/select-workspace-role"
+	}
+	effectiveToken := token
+	if result.NewToken != "" {
+		m.deps.SessionManager.SetSessionCookie(w, result.NewToken)
+		m.carrySessionActivity(r.Context(), token, result.NewToken)
+		m.recordCredentialCheck(w, result.NewToken, userID)
+		effectiveToken = result.NewToken
+	}
+	// C2: always refresh the CSRF cookie (see routePrincipals).
+	m.deps.CSRFIssuer(w, m.deps.CSRFSecret, effectiveToken, p.WorkspaceID)
+	return m.homeURLForWorkspaceID(r.Context(), p.WorkspaceID)
+}
+
+// isDefaultPrincipal reports whether p is userID's default principal (the
+// chooser pre-ticks that card's checkbox).
+func (m *AuthModule) isDefaultPrincipal(ctx context.Context, userID string, p Principal) bool {
+	store := m.deps.DefaultPrincipals
+	if store == nil || userID == "" {
+		return false
+	}
+	d, err := store.GetDefaultPrincipal(ctx, userID)
+	return err == nil && d != nil && d.PrincipalID == p.PrincipalID && d.Type == p.Type
+}
+
+// MemoryDefaultPrincipalStore is an in-process DefaultPrincipalStore for
+// single-instance deployments and tests.
+type MemoryDefaultPrincipalStore struct {
+	mu       sync.Mutex
+	defaults map[string]DefaultPrincipal
+}
+
+// NewMemoryDefaultPrincipalStore returns an empty in-memory store.
+func NewMemoryDefaultPrincipalStore() *MemoryDefaultPrincipalStore {
+	return &MemoryDefaultPrincipalStore{defaults: make(map[string]DefaultPrincipal)}
+}
+
+// GetDefaultPrincipal implements DefaultPrincipalStore.
+func (s *MemoryDefaultPrincipalStore) GetDefaultPrincipal(_ context.Context, userID string) (*DefaultPrincipal, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.defaults[userID]
+	if !ok {
+		return nil, nil
+	}
+	return &d, nil
+}
+
+// SetDefaultPrincipal implements DefaultPrincipalStore.
+func (s *MemoryDefaultPrincipalStore) SetDefaultPrincipal(_ context.Context, d DefaultPrincipal) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.defaults[d.UserID] = d
+	return nil
+}
+
+// ClearDefaultPrincipal implements DefaultPrincipalStore.
+func (s *MemoryDefaultPrincipalStore) ClearDefaultPrincipal(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.defaults, userID)
+	return nil
+}
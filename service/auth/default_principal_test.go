@@ -0,0 +1,143 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+)
+
+func TestRoutePrincipals_HonoursTheRememberedDefault(t *testing.T) {
+	t.Parallel()
+
+	staff := Principal{Type: PrincipalTypeStaff, PrincipalID: "staff-1", WorkspaceID: "ws-1"}
+	delegate := Principal{
+		Type:        PrincipalTypeClientDelegate,
+		PrincipalID: "del-1",
+		WorkspaceID: "ws-2",
+		ActingAsTargets: []ActingAsTarget{
+			{ID: "client-A", WorkspaceID: "ws-2"},
+			{ID: "client-B", WorkspaceID: "ws-2"},
+		},
+	}
+
+	tests := []struct {
+		name         string
+		stored       *DefaultPrincipal
+		wantLocation string
+		wantTarget   string
+		wantActingAs string
+	}{
+		{name: "no default shows the chooser", wantLocation: "/auth/select-workspace-role"},
+		{
+			name:         "staff default",
+			stored:       &DefaultPrincipal{UserID: "u", PrincipalID: "staff-1", Type: PrincipalTypeStaff},
+			wantLocation: "/w/acme/home",
+			wantTarget:   "staff-1",
+		},
+		{
+			name:         "delegate default with its acting-as target",
+			stored:       &DefaultPrincipal{UserID: "u", PrincipalID: "del-1", Type: PrincipalTypeClientDelegate, ActingAsClientID: "client-B"},
+			wantLocation: "/w/acme/home",
+			wantTarget:   "del-1",
+			wantActingAs: "client-B",
+		},
+		{
+			name:         "delegate default without an acting-as target stays behind the guard",
+			stored:       &DefaultPrincipal{UserID: "u", PrincipalID: "del-1", Type: PrincipalTypeClientDelegate},
+			wantLocation: "/auth/select-workspace-role",
+		},
+		{
+			name:         "acting-as target the delegate no longer holds",
+			stored:       &DefaultPrincipal{UserID: "u", PrincipalID: "del-1", Type: PrincipalTypeClientDelegate, ActingAsClientID: "client-Z"},
+			wantLocation: "/auth/select-workspace-role",
+		},
+		{
+			name:         "principal no longer held",
+			stored:       &DefaultPrincipal{UserID: "u", PrincipalID: "staff-9", Type: PrincipalTypeStaff},
+			wantLocation: "/auth/select-workspace-role",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			store := NewMemoryDefaultPrincipalStore()
+			if tt.stored != nil {
+				if err := store.SetDefaultPrincipal(context.Background(), *tt.stored); err != nil {
+					t.Fatal(err)
+				}
+			}
+			switches := &switchRecorder{}
+			m := NewAuthModule(&Deps{
+				SessionManager:        &recordingSessionManager{},
+				Renderer:              nopRenderer{},
+				PrincipalResolver:     staticPrincipals{"u": {staff, delegate}},
+				PrincipalSwitcher:     switches.switchPrincipal,
+				DefaultPrincipals:     store,
+				WorkspaceSlugResolver: func(context.Context, string) string { return "acme" },
+				CSRFIssuer:            func(http.ResponseWriter, []byte, string, string) string { return "" },
+				CSRFSecret:            []byte("test-secret"),
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/auth/login", nil)
+			got := m.routePrincipals(httptest.NewRecorder(), req, "tok", "u")
+
+			if got != tt.wantLocation {
+				t.Fatalf("routePrincipals = %q, want %q", got, tt.wantLocation)
+			}
+			if tt.wantTarget == "" {
+				if len(switches.calls) != 0 {
+					t.Fatalf("switch calls = %d, want none", len(switches.calls))
+				}
+				return
+			}
+			in := switches.last()
+			if in.TargetPrincipal.PrincipalID != tt.wantTarget || in.ActingAsClientID != tt.wantActingAs || in.Token != "tok" {
+				t.Fatalf("switch input = %+v", in)
+			}
+		})
+	}
+}
+
+func TestRememberDefaultPrincipal_OnlyWhenTheFormAsks(t *testing.T) {
+	t.Parallel()
+	staff := Principal{Type: PrincipalTypeStaff, PrincipalID: "staff-1", WorkspaceID: "ws-1"}
+	current := DefaultPrincipal{UserID: "u", PrincipalID: "staff-1", Type: PrincipalTypeStaff}
+
+	tests := []struct {
+		name     string
+		form     url.Values
+		stored   bool // the default is set beforehand
+		wantKept bool
+	}{
+		{name: "switch without the chooser checkbox keeps the default", stored: true, wantKept: true},
+		{name: "unticked on the default card clears it", form: url.Values{"default_choice": {"1"}}, stored: true},
+		{name: "ticked sets it", form: url.Values{"default_choice": {"1"}, "make_default": {"1"}}, wantKept: true},
+		{name: "checkbox value without the marker is ignored", form: url.Values{"make_default": {"1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			store := NewMemoryDefaultPrincipalStore()
+			if tt.stored {
+				if err := store.SetDefaultPrincipal(ctx, current); err != nil {
+					t.Fatal(err)
+				}
+			}
+			m := NewAuthModule(&Deps{DefaultPrincipals: store})
+			m.rememberDefaultPrincipal(ctx, "u", staff, "", "", tt.form)
+			d, err := store.GetDefaultPrincipal(ctx, "u")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if kept := d != nil && d.PrincipalID == "staff-1"; kept != tt.wantKept {
+				t.Errorf("default = %+v, want kept %v", d, tt.wantKept)
+			}
+		})
+	}
+}
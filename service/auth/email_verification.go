@@ -0,0 +1,255 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	verifyemailmod "github.com/erniealice/entydad-golang/service/auth/views/login02/verify-email"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// EmailVerificationStore records which users still have to confirm their
+// email address. A user with no record counts as verified, so accounts that
+// predate the feature (and admin-created users) keep signing in; only
+// self-signup marks a user unverified.
+type EmailVerificationStore interface {
+	IsEmailVerified(ctx context.Context, userID string) (bool, error)
+	SetEmailVerified(ctx context.Context, userID string, verified bool) error
+}
+
+const (
+	verifyPendingCookieName = "verify_pending"
+	// verifyPendingTTL bounds how long the "check your email" page can
+	// resend without signing in again.
+	verifyPendingTTL = time.Hour
+	// emailVerificationTTL is the lifetime of an emailed link.
+	emailVerificationTTL = 24 * time.Hour
+
+	linkPurposeVerifyEmail = "verify_email"
+)
+
+// verifyPending names the address the verify-email page may resend to. It
+// is not a session: nothing but a fresh link can be obtained with it.
+type verifyPending struct {
+	UserID  string `json:"u"`
+	Email   string `json:"e"`
+	Expires int64  `json:"x"`
+}
+
+// emailVerificationClaims is the payload of the emailed link. The email is
+// re-checked against the user on confirm, so a link for a since-changed
+// address stops working.
+type emailVerificationClaims struct {
+	UserID string `json:"u"`
+	Email  string `json:"e"`
+}
+
+// emailVerificationEnabled reports whether signup must confirm the address
+// before the first sign-in.
+func (m *AuthModule) emailVerificationEnabled() bool {
+	return m.deps.EmailVerification != nil && m.mailEnabled()
+}
+
+// requireVerifiedEmail refuses a password sign-in for a user whose address
+// is still unconfirmed: the freshly minted session is invalidated, the
+// address is parked in the verify_pending cookie and the caller redirects to
+// the returned target (the verify-email page with its resend action).
+// blocked=false means carry on with the normal login tail.
+//
+// A store failure fails CLOSED, like the MFA lookup.
+func (m *AuthModule) requireVerifiedEmail(w http.ResponseWriter, r *http.Request, token, userID, email string) (target string, blocked bool) {
+	if !m.emailVerificationEnabled() || userID == "" {
+		return "", false
+	}
+	verified, err := m.deps.EmailVerification.IsEmailVerified(r.Context(), userID)
+	if err != nil {
+		log.Printf("[AUTH] verify-email: lookup failed for user %s: %v", userID, err)
+		m.abandonSession(r.Context(), token)
+		return entydad.AuthLoginURL + "?error=verify", true
+	}
+	if verified {
+		return "", false
+	}
+	log.Printf("[AUTH] login refused: email not verified for user %s", userID)
+	m.abandonSession(r.Context(), token)
+	if err := m.setVerifyPending(w, userID, email); err != nil {
+		log.Printf("[AUTH] verify-email: pending cookie failed for user %s: %v", userID, err)
+		return entydad.AuthLoginURL + "?error=verify", true
+	}
+	return entydad.AuthVerifyEmailURL, true
+}
+
+// sendVerificationEmail mails userID a signed link to GET
+// /auth/verify-email?token=….
+func (m *AuthModule) sendVerificationEmail(ctx context.Context, userID, email string) error {
+	token, err := m.signLinkToken(linkPurposeVerifyEmail, emailVerificationClaims{UserID: userID, Email: email}, emailVerificationTTL)
+	if err != nil {
+		return err
+	}
+	link := m.publicURL(entydad.AuthVerifyEmailURL + "?token=" + url.QueryEscape(token))
+	return m.sendMail(ctx, MailVerifyEmail, MailData{To: email, Link: link})
+}
+
+// handleVerifyEmail returns the GET /auth/verify-email handler. With
+// ?token= it confirms the address and sends the user to login02
+// (?verified=1); without, it renders the "check your email" page for the
+// address in the verify_pending cookie.
+func (m *AuthModule) handleVerifyEmail(page *verifyemailmod.Deps) http.HandlerFunc {
+	v := verifyemailmod.NewView(page)
+	store := m.deps.EmailVerification
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		q := r.URL.Query()
+		if token := q.Get("token"); token != "" {
+			var c emailVerificationClaims
+			if !m.openLinkToken(linkPurposeVerifyEmail, token, &c) || c.UserID == "" {
+				http.Redirect(w, r, entydad.AuthLoginURL+"?error=verify_link", http.StatusSeeOther)
+				return
+			}
+			if m.deps.UserIDByEmail != nil && m.userIDForEmail(r.Context(), c.Email) != c.UserID {
+				log.Printf("[AUTH] verify-email: link for user %s no longer matches %s", c.UserID, c.Email)
+				http.Redirect(w, r, entydad.AuthLoginURL+"?error=verify_link", http.StatusSeeOther)
+				return
+			}
+			if err := store.SetEmailVerified(r.Context(), c.UserID, true); err != nil {
+				log.Printf("[AUTH] verify-email: store failed for user %s: %v", c.UserID, err)
+				http.Redirect(w, r, entydad.AuthLoginURL+"?error=verify", http.StatusSeeOther)
+				return
+			}
+			m.clearSealedCookie(w, verifyPendingCookieName)
+			log.Printf("[AUTH] email verified: user=%s", c.UserID)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?verified=1", http.StatusSeeOther)
+			return
+		}
+
+		p, ok := m.readVerifyPending(r)
+		if !ok {
+			http.Redirect(w, r, entydad.AuthLoginURL, http.StatusSeeOther)
+			return
+		}
+		state := verifyemailmod.State{
+			Email:     p.Email,
+			Resent:    q.Get("resent") == "1",
+			ErrorCode: q.Get("error"),
+		}
+		result := v.Handle(verifyemailmod.WithState(r.Context(), state), &view.ViewContext{
+			Request:     r,
+			CurrentPath: r.URL.Path,
+		})
+		m.renderAuthView(w, r, result)
+	}
+}
+
+// handleVerifyEmailResend returns the POST /auth/verify-email/resend
+// handler: a fresh link to the pending address, throttled per email and IP.
+// Answers with a redirect back to the page (?resent=1 or ?error=…).
+func (m *AuthModule) handleVerifyEmailResend() http.HandlerFunc {
+	store := m.deps.EmailVerification
+	limiter := m.deps.LoginAttemptLimiter
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		p, ok := m.readVerifyPending(r)
+		if !ok {
+			http.Redirect(w, r, entydad.AuthLoginURL, http.StatusSeeOther)
+			return
+		}
+		attempt := LoginAttempt{Scope: LoginScopeVerifyEmail, Email: p.Email, IP: m.clientIP(r)}
+		if limitedErrorCode(limiter.Check(r.Context(), attempt)) != "" {
+			log.Printf("[AUTH] verify-email resend throttled for %s from %s", p.Email, attempt.IP)
+			http.Redirect(w, r, entydad.AuthVerifyEmailURL+"?error=throttled", http.StatusSeeOther)
+			return
+		}
+		limiter.RecordSuccess(r.Context(), attempt)
+		if verified, err := store.IsEmailVerified(r.Context(), p.UserID); err == nil && verified {
+			m.clearSealedCookie(w, verifyPendingCookieName)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?verified=1", http.StatusSeeOther)
+			return
+		}
+		if err := m.sendVerificationEmail(r.Context(), p.UserID, p.Email); err != nil {
+			log.Printf("[AUTH] verify-email: send failed for user %s: %v", p.UserID, err)
+			http.Redirect(w, r, entydad.AuthVerifyEmailURL+"?error=mail", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, entydad.AuthVerifyEmailURL+"?resent=1", http.StatusSeeOther)
+	}
+}
+
+// startEmailVerification is the signup tail when verification is on: the
+// new user is marked unverified, mailed a link and parked on the
+// verify-email page instead of being signed in. Returns the redirect target.
+func (m *AuthModule) startEmailVerification(w http.ResponseWriter, r *http.Request, userID, email string) string {
+	if userID == "" {
+		userID = m.userIDForEmail(r.Context(), email)
+	}
+	if userID == "" {
+		log.Printf("[AUTH] verify-email: no user_id resolvable for %s after signup", email)
+		return entydad.AuthLoginURL + "?error=verify"
+	}
+	// No record counts as verified, so an account that cannot be marked
+	// must not survive the signup.
+	if err := m.deps.EmailVerification.SetEmailVerified(r.Context(), userID, false); err != nil {
+		log.Printf("[AUTH] verify-email: could not mark user %s unverified: %v", userID, err)
+		m.discardRegistration(r.Context(), userID)
+		return entydad.AuthSignupURL + "?error=generic"
+	}
+	if err := m.setVerifyPending(w, userID, email); err != nil {
+		log.Printf("[AUTH] verify-email: pending cookie failed for user %s: %v", userID, err)
+		return entydad.AuthLoginURL
+	}
+	if err := m.sendVerificationEmail(r.Context(), userID, email); err != nil {
+		log.Printf("[AUTH] verify-email: send failed for user %s: %v", userID, err)
+		return entydad.AuthVerifyEmailURL + "?error=mail"
+	}
+	return entydad.AuthVerifyEmailURL
+}
+
+func (m *AuthModule) setVerifyPending(w http.ResponseWriter, userID, email string) error {
+	p := verifyPending{UserID: userID, Email: email, Expires: time.Now().Add(verifyPendingTTL).Unix()}
+	return m.setSealedCookie(w, verifyPendingCookieName, p, verifyPendingTTL)
+}
+
+func (m *AuthModule) readVerifyPending(r *http.Request) (verifyPending, bool) {
+	var p verifyPending
+	if !m.openSealedCookie(r, verifyPendingCookieName, &p) {
+		return verifyPending{}, false
+	}
+	if p.UserID == "" || p.Email == "" || time.Now().Unix() > p.Expires {
+		return verifyPending{}, false
+	}
+	return p, true
+}
+
+// MemoryEmailVerificationStore is an in-process EmailVerificationStore for
+// tests and single-instance development hosts.
+type MemoryEmailVerificationStore struct {
+	mu         sync.Mutex
+	unverified map[string]bool
+}
+
+// NewMemoryEmailVerificationStore returns an empty store (everyone verified).
+func NewMemoryEmailVerificationStore() *MemoryEmailVerificationStore {
+	return &MemoryEmailVerificationStore{unverified: make(map[string]bool)}
+}
+
+func (s *MemoryEmailVerificationStore) IsEmailVerified(_ context.Context, userID string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return !s.unverified[userID], nil
+}
+
+func (s *MemoryEmailVerificationStore) SetEmailVerified(_ context.Context, userID string, verified bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if verified {
+		delete(s.unverified, userID)
+	} else {
+		s.unverified[userID] = true
+	}
+	return nil
+}
@@ -0,0 +1,236 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	verifyemailmod "github.com/erniealice/entydad-golang/service/auth/views/login02/verify-email"
+)
+
+// passwordAdapter is a minimal AuthAdapter over an in-memory user table.
+type passwordAdapter struct {
+	mu          sync.Mutex
+	users       map[string]string // email → password
+	invalidated []string
+}
+
+func (a *passwordAdapter) Login(_ context.Context, email, password string) (string, AuthIdentity, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if pw, ok := a.users[email]; !ok || pw != password {
+		return "", nil, errors.New("invalid credentials")
+	}
+	return "session-" + email, nil, nil
+}
+
+func (a *passwordAdapter) Register(_ context.Context, email, password, _, _, _ string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.users[email] = password
+	return "user-" + email, nil
+}
+
+func (a *passwordAdapter) RequestPasswordReset(_ context.Context, email string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if _, ok := a.users[email]; !ok {
+		return "", nil
+	}
+	return "reset/" + email, nil
+}
+func (a *passwordAdapter) ExecutePasswordReset(context.Context, string, string) error { return nil }
+func (a *passwordAdapter) ChangePassword(context.Context, string, string, string) error {
+	return nil
+}
+func (a *passwordAdapter) ValidateSession(context.Context, string) (string, error) { return "", nil }
+func (a *passwordAdapter) InvalidateSession(_ context.Context, token string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.invalidated = append(a.invalidated, token)
+	return nil
+}
+
+type nopRenderer struct{}
+
+func (nopRenderer) Render(w http.ResponseWriter, name string, _ interface{}) error {
+	_, err := w.Write([]byte(name))
+	return err
+}
+
+func newVerificationTestModule(mailer Mailer, sessions *recordingSessionManager) (*AuthModule, *passwordAdapter) {
+	adapter := &passwordAdapter{users: make(map[string]string)}
+	return NewAuthModule(&Deps{
+		AuthAdapter:    adapter,
+		SessionManager: sessions,
+		Renderer:       nopRenderer{},
+		UserIDByEmail: func(_ context.Context, email string) string {
+			return "user-" + email
+		},
+		CSRFIssuer:        func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:        []byte("test-secret"),
+		AllowSignups:      true,
+		EmailVerification: NewMemoryEmailVerificationStore(),
+		Mailer:            mailer,
+		PublicBaseURL:     "https://app.example.com/",
+	}), adapter
+}
+
+func postForm(h http.HandlerFunc, target string, form url.Values, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	h(rec, req)
+	return rec
+}
+
+var verifyLinkRE = regexp.MustCompile(`https://app\.example\.com/auth/verify-email\?token=\S+`)
+
+func TestEmailVerification_SignupLoginGate(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	sessions := &recordingSessionManager{}
+	m, adapter := newVerificationTestModule(outbox, sessions)
+	creds := url.Values{"email": {"new@example.com"}, "password": {"s3cret-pass"}}
+
+	// Signup: parked on the verify page, mailed a link, no session.
+	signup := url.Values{"email": creds["email"], "password": creds["password"], "confirm_password": creds["password"]}
+	rec := postForm(m.handleSignup(), entydad.AuthSignupPostURL, signup, nil)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != entydad.AuthVerifyEmailURL {
+		t.Fatalf("signup: %d → %q, want 303 → verify page", rec.Code, rec.Header().Get("Location"))
+	}
+	if sessions.token != "" {
+		t.Fatalf("signup set a session: %q", sessions.token)
+	}
+	msg, _ := outbox.Last("new@example.com")
+	link := verifyLinkRE.FindString(msg.Text)
+	if msg.Kind != MailVerifyEmail || link == "" || msg.Link != link || !strings.Contains(msg.HTML, "token=") {
+		t.Fatalf("verification mail: %+v", msg)
+	}
+
+	// The verify page renders for the pending address; resend mails again.
+	pending := rec.Result().Cookies()
+	page := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, entydad.AuthVerifyEmailURL, nil)
+	for _, c := range pending {
+		req.AddCookie(c)
+	}
+	m.handleVerifyEmail(&verifyemailmod.Deps{})(page, req)
+	if page.Code != http.StatusOK || page.Body.String() != "verify-email" {
+		t.Fatalf("verify page: %d %q", page.Code, page.Body.String())
+	}
+	resend := postForm(m.handleVerifyEmailResend(), entydad.AuthVerifyEmailResendURL, nil, pending)
+	if got := resend.Header().Get("Location"); got != entydad.AuthVerifyEmailURL+"?resent=1" || len(outbox.Messages()) != 2 {
+		t.Fatalf("resend: → %q with %d mails", got, len(outbox.Messages()))
+	}
+
+	// Password login before verifying: refused, session invalidated.
+	rec = postForm(m.handleLogin(), entydad.AuthLoginPostURL, creds, nil)
+	if rec.Header().Get("Location") != entydad.AuthVerifyEmailURL || sessions.token != "" {
+		t.Fatalf("unverified login: → %q session %q", rec.Header().Get("Location"), sessions.token)
+	}
+	if len(adapter.invalidated) != 1 {
+		t.Fatalf("unverified login: %d sessions invalidated, want 1", len(adapter.invalidated))
+	}
+
+	// A tampered link is rejected; the real one verifies.
+	for _, tc := range []struct{ target, want string }{
+		{link[:len(link)-2] + "xx", entydad.AuthLoginURL + "?error=verify_link"},
+		{link, entydad.AuthLoginURL + "?verified=1"},
+	} {
+		u, _ := url.Parse(tc.target)
+		rec := httptest.NewRecorder()
+		m.handleVerifyEmail(&verifyemailmod.Deps{})(rec, httptest.NewRequest(http.MethodGet, u.RequestURI(), nil))
+		if got := rec.Header().Get("Location"); got != tc.want {
+			t.Fatalf("GET %s → %q, want %q", u.RequestURI(), got, tc.want)
+		}
+	}
+
+	// Verified: the same login now gets its session.
+	rec = postForm(m.handleLogin(), entydad.AuthLoginPostURL, creds, nil)
+	if rec.Header().Get("Location") != entydad.DefaultAppRedirectURL || sessions.token != "session-new@example.com" {
+		t.Fatalf("verified login: → %q session %q", rec.Header().Get("Location"), sessions.token)
+	}
+}
+
+func TestEmailVerification_ExistingUsersUnaffected(t *testing.T) {
+	t.Parallel()
+	sessions := &recordingSessionManager{}
+	m, adapter := newVerificationTestModule(NewMemoryOutbox(), sessions)
+	adapter.users["old@example.com"] = "pw"
+
+	rec := postForm(m.handleLogin(), entydad.AuthLoginPostURL, url.Values{"email": {"old@example.com"}, "password": {"pw"}}, nil)
+	if rec.Header().Get("Location") != entydad.DefaultAppRedirectURL || sessions.token == "" {
+		t.Fatalf("pre-existing user: → %q session %q", rec.Header().Get("Location"), sessions.token)
+	}
+}
+
+// failingVerificationStore cannot record anything.
+type failingVerificationStore struct{}
+
+func (failingVerificationStore) IsEmailVerified(context.Context, string) (bool, error) {
+	return false, errors.New("store down")
+}
+func (failingVerificationStore) SetEmailVerified(context.Context, string, bool) error {
+	return errors.New("store down")
+}
+
+func TestEmailVerification_SignupStoreFailureDiscardsUser(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	sessions := &recordingSessionManager{}
+	m, _ := newVerificationTestModule(outbox, sessions)
+	m.deps.EmailVerification = failingVerificationStore{}
+	var deleted []string
+	m.deps.DeleteUser = func(_ context.Context, userID string) error {
+		deleted = append(deleted, userID)
+		return nil
+	}
+
+	signup := url.Values{"email": {"new@example.com"}, "password": {"s3cret-pass"}, "confirm_password": {"s3cret-pass"}}
+	rec := postForm(m.handleSignup(), entydad.AuthSignupPostURL, signup, nil)
+	if got := rec.Header().Get("Location"); got != entydad.AuthSignupURL+"?error=generic" {
+		t.Fatalf("signup → %q, want the signup error", got)
+	}
+	if len(deleted) != 1 || deleted[0] != "user-new@example.com" {
+		t.Fatalf("deleted %v, want the new user", deleted)
+	}
+	if sessions.token != "" || len(rec.Result().Cookies()) != 0 || len(outbox.Messages()) != 0 {
+		t.Fatalf("signup carried on: session %q, %d cookies, %d mails", sessions.token, len(rec.Result().Cookies()), len(outbox.Messages()))
+	}
+}
+
+func TestLinkToken_PurposeBound(t *testing.T) {
+	t.Parallel()
+	m := NewAuthModule(&Deps{CSRFSecret: []byte("k")})
+	tok, err := m.signLinkToken("a", emailVerificationClaims{UserID: "u"}, emailVerificationTTL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var c emailVerificationClaims
+	if !m.openLinkToken("a", tok, &c) || c.UserID != "u" {
+		t.Fatalf("open same purpose: %+v", c)
+	}
+	if m.openLinkToken("b", tok, &c) {
+		t.Fatal("token opened under another purpose")
+	}
+	other := NewAuthModule(&Deps{CSRFSecret: []byte("other")})
+	if other.openLinkToken("a", tok, &c) {
+		t.Fatal("token opened under another key")
+	}
+	expired, _ := m.signLinkToken("a", emailVerificationClaims{UserID: "u"}, -time.Minute)
+	if m.openLinkToken("a", expired, &c) {
+		t.Fatal("expired token opened")
+	}
+}
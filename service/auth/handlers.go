@@ -27,6 +27,9 @@ var ctxKeyAuthUserID = ctxKeyAuthUserIDType{}
 // Sequence (the auth-cycle UX flow):
 //
 //	 1. Validate credentials via authAdapter.Login (existing path).
+//	    When two-step verification applies (beginMFA), the login is
+//	    parked at /auth/mfa without a session cookie and steps 2+ run
+//	    from the challenge handler once the code checks out.
 //	 2. Resolve all active principal bindings for the user via
 //	    principalLoader.Resolve. Three branches follow:
 //	      a. 0 principals    → /auth/no-access (signed-in but no access)
@@ -46,6 +49,7 @@ func (m *AuthModule) handleLogin() http.HandlerFunc {
 	authAdapter := m.deps.AuthAdapter
 	sessionMw := m.deps.SessionManager
 	principalLoader := m.deps.PrincipalResolver
+	limiter := m.deps.LoginAttemptLimiter
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		if authAdapter == nil || sessionMw == nil {
@@ -59,12 +63,68 @@ func (m *AuthModule) handleLogin() http.HandlerFunc {
 		}
 		email := r.FormValue("email")
 		password := r.FormValue("password")
+		// Home realm: a workspace requiring SSO refuses its domain's
+		// passwords before they are checked.
+		if m.ssoRequired(r.Context(), email) {
+			m.loginFailed(r, "", email, AuthFailureSSORequired)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=sso_required", http.StatusSeeOther)
+			return
+		}
+		attempt := LoginAttempt{Scope: LoginScopePassword, Email: email, IP: m.clientIP(r)}
+		decision := limiter.Check(r.Context(), attempt)
+		if code := limitedErrorCode(decision); code != "" {
+			log.Printf("[AUTH] login %s for %s from %s", code, email, attempt.IP)
+			m.loginFailed(r, "", email, code)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error="+code, http.StatusSeeOther)
+			return
+		}
+		if !waitLoginDelay(r.Context(), decision.Delay) {
+			return
+		}
 		token, identity, err := authAdapter.Login(r.Context(), email, password)
 		if err != nil {
 			log.Printf("[AUTH] login failed for %s: %v", email, err)
-			http.Redirect(w, r, entydad.AuthLoginURL+"?error=invalid", http.StatusSeeOther)
+			code, reason := "invalid", AuthFailureInvalidCredentials
+			if limiter.RecordFailure(r.Context(), attempt).Locked {
+				code, reason = "locked", AuthFailureLocked
+			}
+			m.loginFailed(r, "", email, reason)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error="+code, http.StatusSeeOther)
+			return
+		}
+		limiter.RecordSuccess(r.Context(), attempt)
+
+		userID := ""
+		if identity != nil {
+			userID = identity.GetId()
+		}
+		if userID == "" {
+			// Fall back to a lookup by email — the identity proto's
+			// Subject field is normally the user_id but mock providers
+			// occasionally leave it blank.
+			if m.deps.UserIDByEmail != nil {
+				userID = m.deps.UserIDByEmail(r.Context(), email)
+			}
+		}
+
+		// Unverified self-signup: refuse the session and offer a new link.
+		if target, blocked := m.requireVerifiedEmail(w, r, token, userID, email); blocked {
+			m.loginFailed(r, userID, email, AuthFailureUnverifiedEmail)
+			http.Redirect(w, r, target, http.StatusSeeOther)
 			return
 		}
+
+		// Two-step verification: an enrolled user (or an operator of a
+		// workspace that requires MFA) is parked at /auth/mfa WITHOUT the
+		// session cookie; the challenge handler sets it and runs
+		// routePrincipals once the code checks out. A user who has not
+		// accepted the current terms is parked at /auth/consent the same
+		// way (parkLogin).
+		if target, pending := m.parkLogin(w, r, token, userID, email); pending {
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
+
 		sessionMw.SetSessionCookie(w, token)
 		// C2: issue CSRF cookie carrying the session token claim.
 		// workspace_id is empty at login (no workspace chosen yet) — the
@@ -77,22 +137,11 @@ func (m *AuthModule) handleLogin() http.HandlerFunc {
 		// in view_adapter.go is the safety net for unauthenticated
 		// reads — it just means the chooser/portal flow is skipped.
 		if principalLoader == nil || !principalLoader.IsEnabled() {
+			m.signedIn(w, r, token, userID)
 			http.Redirect(w, r, entydad.DefaultAppRedirectURL, http.StatusSeeOther)
 			return
 		}
 
-		userID := ""
-		if identity != nil {
-			userID = identity.GetId()
-		}
-		if userID == "" {
-			// Fall back to a lookup by email — the identity proto's
-			// Subject field is normally the user_id but mock providers
-			// occasionally leave it blank.
-			if m.deps.UserIDByEmail != nil {
-				userID = m.deps.UserIDByEmail(r.Context(), email)
-			}
-		}
 		if userID == "" {
 			log.Printf("[AUTH] login succeeded but no user_id resolvable for %s", email)
 			http.Redirect(w, r, "/auth/no-access", http.StatusSeeOther)
@@ -110,7 +159,8 @@ func (m *AuthModule) handleLogin() http.HandlerFunc {
 }
 
 // routePrincipals is the SHARED post-authentication tail for every login path
-// (password form + Firebase ID token). The caller has already minted a session
+// (password form, Firebase ID token, passkey and OIDC callback — directly or
+// via the /auth/mfa challenge). The caller has already minted a session
 // `token` for `userID` and set the session cookie + CSRF. This resolves the
 // user's active principal bindings and decides where to send them, performing
 // any session rotation / CSRF refresh as a SIDE EFFECT (on w). It RETURNS the
@@ -124,6 +174,11 @@ func (m *AuthModule) routePrincipals(w http.ResponseWriter, r *http.Request, tok
 	sessionMw := m.deps.SessionManager
 	principalLoader := m.deps.PrincipalResolver
 
+	// Every sign-in path ends here: the credential was just checked, so
+	// step-up-protected actions need no prompt for a while, and the
+	// sign-in goes into the security event log.
+	m.signedIn(w, r, token, userID)
+
 	principals, presolveErr := principalLoader.Resolve(r.Context(), userID)
 	if presolveErr != nil {
 		log.Printf("[AUTH] principal resolve failed for user %s: %v", userID, presolveErr)
@@ -178,6 +233,9 @@ func (m *AuthModule) routePrincipals(w http.ResponseWriter, r *http.Request, tok
 		}
 		if result.NewToken != "" {
 			sessionMw.SetSessionCookie(w, result.NewToken)
+			m.carrySessionActivity(r.Context(), token, result.NewToken)
+			// The sign-in's credential check belongs to the new row.
+			m.recordCredentialCheck(w, result.NewToken, userID)
 		}
 		// C2: always refresh the CSRF cookie after a successful
 		// principal switch — even when NewToken is empty (in-place
@@ -194,7 +252,13 @@ func (m *AuthModule) routePrincipals(w http.ResponseWriter, r *http.Request, tok
 		return m.homeURLForWorkspaceID(r.Context(), principals[0].WorkspaceID)
 
 	default:
-		// 2+ principals. If all are the same kind (e.g. one user
+		// 2+ principals. A default the user picked on the chooser wins
+		// while they still hold it; defaultPrincipalFor applies the same
+		// delegate guard as the auto-routes below.
+		if d, p, ok := m.defaultPrincipalFor(r.Context(), userID, principals); ok {
+			return m.routeToDefaultPrincipal(w, r, token, userID, d, p)
+		}
+		// If all are the same kind (e.g. one user
 		// has OPERATOR_STAFF in multiple workspaces), auto-route to
 		// the first one — the workspace switcher in the sidebar
 		// handles same-kind workspace switching. Only show the
@@ -242,6 +306,8 @@ func (m *AuthModule) routePrincipals(w http.ResponseWriter, r *http.Request, tok
 			}
 			if result.NewToken != "" {
 				sessionMw.SetSessionCookie(w, result.NewToken)
+				m.carrySessionActivity(r.Context(), token, result.NewToken)
+				m.recordCredentialCheck(w, result.NewToken, userID)
 			}
 			// C2: always refresh CSRF cookie (see case-1 comment).
 			effectiveToken := result.NewToken
@@ -284,6 +350,7 @@ func (m *AuthModule) handleFirebaseLogin() http.HandlerFunc {
 	sessionMw := m.deps.SessionManager
 	principalLoader := m.deps.PrincipalResolver
 	allowed := m.deps.AllowedSignInMethods
+	limiter := m.deps.LoginAttemptLimiter
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		if verifier == nil || minter == nil || sessionMw == nil {
@@ -299,30 +366,48 @@ func (m *AuthModule) handleFirebaseLogin() http.HandlerFunc {
 			writeFirebaseJSON(w, http.StatusBadRequest, "missing_token")
 			return
 		}
+		// The email is only known once the token verifies, so the first
+		// check is per-IP; the per-email check (and account lock) follows.
+		attempt := LoginAttempt{Scope: LoginScopeFirebase, IP: m.clientIP(r)}
+		if d := limiter.Check(r.Context(), attempt); !writeFirebaseLimited(w, d) {
+			m.loginFailed(r, "", "", limitedErrorCode(d))
+			return
+		}
 		email, signInProvider, err := verifier(r.Context(), idToken)
 		if err != nil || strings.TrimSpace(email) == "" {
 			log.Printf("[AUTH] firebase verify failed (provider=%s): %v", signInProvider, err)
+			limiter.RecordFailure(r.Context(), attempt)
+			m.loginFailed(r, "", "", AuthFailureInvalidCredentials)
 			writeFirebaseError(w, http.StatusUnauthorized, "invalid")
 			return
 		}
+		// The IP already holds its reservation from the first check.
+		attempt.Email = email
+		if d := limiter.Check(r.Context(), LoginAttempt{Scope: attempt.Scope, Email: email}); !writeFirebaseLimited(w, d) {
+			m.loginFailed(r, "", email, limitedErrorCode(d))
+			return
+		}
 		// Layer 5: enforce the configured sign-in-method allow-list. Empty list
 		// = allow any verified method. The token's sign_in_provider claim is the
 		// source of truth (e.g. "microsoft.com", "google.com", "password").
 		if len(allowed) > 0 && !signInMethodAllowed(allowed, signInProvider) {
 			log.Printf("[AUTH] firebase sign-in method %q not in allow-list for %s", signInProvider, email)
+			m.loginFailed(r, "", email, AuthFailureMethodNotAllowed)
 			writeFirebaseError(w, http.StatusForbidden, "method_not_allowed")
 			return
 		}
-		// Resolve the DB user by email (case-tolerant).
-		userID := ""
-		if m.deps.UserIDByEmail != nil {
-			userID = m.deps.UserIDByEmail(r.Context(), email)
-			if userID == "" {
-				userID = m.deps.UserIDByEmail(r.Context(), strings.ToLower(email))
-			}
+		// Home realm: Firebase email/password is the password path too.
+		if signInProvider == "password" && m.ssoRequired(r.Context(), email) {
+			m.loginFailed(r, "", email, AuthFailureSSORequired)
+			writeFirebaseError(w, http.StatusForbidden, "sso_required")
+			return
 		}
+		// Resolve the DB user by email (case-tolerant).
+		userID := m.userIDForEmail(r.Context(), email)
 		if userID == "" {
 			log.Printf("[AUTH] firebase: no DB user maps to email %s", email)
+			limiter.RecordFailure(r.Context(), attempt)
+			m.loginFailed(r, "", email, AuthFailureNoAccount)
 			writeFirebaseError(w, http.StatusForbidden, "no_account")
 			return
 		}
@@ -334,13 +419,22 @@ func (m *AuthModule) handleFirebaseLogin() http.HandlerFunc {
 			writeFirebaseError(w, http.StatusInternalServerError, "session")
 			return
 		}
-		sessionMw.SetSessionCookie(w, token)
-		m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, "")
+		limiter.RecordSuccess(r.Context(), attempt)
 		// Observability: a success line for the firebase login (failures are
 		// already logged above). userID + method, never the token.
 		log.Printf("[AUTH] firebase login OK: user=%s method=%s", userID, signInProvider)
+		r = withSSOProvider(r, signInProvider)
+		// Two-step verification and the consent step park the login before
+		// the session cookie is set (see handleLogin).
+		if target, pending := m.parkLogin(w, r, token, userID, email); pending {
+			writeFirebaseRedirect(w, target)
+			return
+		}
+		sessionMw.SetSessionCookie(w, token)
+		m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, "")
 
 		if principalLoader == nil || !principalLoader.IsEnabled() {
+			m.signedIn(w, r, token, userID)
 			writeFirebaseRedirect(w, entydad.DefaultAppRedirectURL)
 			return
 		}
@@ -371,6 +465,19 @@ func writeFirebaseError(w http.ResponseWriter, status int, code string) {
 	writeFirebaseBody(w, status, map[string]string{"error": code})
 }
 
+// writeFirebaseLimited answers a throttled or locked Firebase attempt with a
+// 429 + Retry-After and the same short codes login02 uses ("locked",
+// "throttled"). Returns true when the attempt may proceed.
+func writeFirebaseLimited(w http.ResponseWriter, d LoginAttemptDecision) bool {
+	code := limitedErrorCode(d)
+	if code == "" {
+		return true
+	}
+	setRetryAfter(w, d.RetryAfter)
+	writeFirebaseError(w, http.StatusTooManyRequests, code)
+	return false
+}
+
 // writeFirebaseJSON is the bare-message form used before a code is resolved.
 func writeFirebaseJSON(w http.ResponseWriter, status int, msg string) {
 	writeFirebaseBody(w, status, map[string]string{"error": msg})
@@ -387,6 +494,7 @@ func writeFirebaseBody(w http.ResponseWriter, status int, body map[string]string
 func (m *AuthModule) handleSignup() http.HandlerFunc {
 	authAdapter := m.deps.AuthAdapter
 	sessionMw := m.deps.SessionManager
+	limiter := m.deps.LoginAttemptLimiter
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		// AUTH_FIREBASE_ALLOW_SIGNUPS=false → self-signup disabled: reject the
@@ -413,12 +521,31 @@ func (m *AuthModule) handleSignup() http.HandlerFunc {
 			http.Redirect(w, r, entydad.AuthSignupURL+"?error=mismatch", http.StatusSeeOther)
 			return
 		}
-		if _, err := authAdapter.Register(r.Context(), email, password, firstName, lastName, ""); err != nil {
+		if code := m.deps.PasswordPolicy.Check(r.Context(), "", email, password); code != "" {
+			http.Redirect(w, r, entydad.AuthSignupURL+"?error="+code, http.StatusSeeOther)
+			return
+		}
+		attempt := LoginAttempt{Scope: LoginScopeSignup, Email: email, IP: m.clientIP(r)}
+		if limitedErrorCode(limiter.Check(r.Context(), attempt)) != "" {
+			log.Printf("[AUTH] signup throttled for %s from %s", email, attempt.IP)
+			http.Redirect(w, r, entydad.AuthSignupURL+"?error=throttled", http.StatusSeeOther)
+			return
+		}
+		userID, err := authAdapter.Register(r.Context(), email, password, firstName, lastName, "")
+		if err != nil {
 			log.Printf("[AUTH] register failed for %s: %v", email, err)
+			limiter.RecordFailure(r.Context(), attempt)
 			code := classifySignupError(err)
 			http.Redirect(w, r, entydad.AuthSignupURL+"?error="+code, http.StatusSeeOther)
 			return
 		}
+		limiter.RecordSuccess(r.Context(), attempt)
+		m.deps.PasswordPolicy.Remember(r.Context(), userID, password)
+		// Email verification: no session until the emailed link is opened.
+		if m.emailVerificationEnabled() {
+			http.Redirect(w, r, m.startEmailVerification(w, r, userID, email), http.StatusSeeOther)
+			return
+		}
 		// Auto-login after successful registration
 		token, _, err := authAdapter.Login(r.Context(), email, password)
 		if err != nil {
@@ -426,10 +553,20 @@ func (m *AuthModule) handleSignup() http.HandlerFunc {
 			http.Redirect(w, r, entydad.AuthLoginURL+"?registered=true", http.StatusSeeOther)
 			return
 		}
+		if userID == "" {
+			userID = m.userIDForEmail(r.Context(), email)
+		}
+		// The same tail as every other sign-in: home realm, two-step
+		// verification and the consent step come before the cookie.
+		if target, pending := m.parkLogin(w, r, token, userID, email); pending {
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
 		sessionMw.SetSessionCookie(w, token)
 		// C2: signup has no workspace_id yet; workspace claim filled on
 		// first GET after principal resolution.
 		m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, "")
+		m.signedIn(w, r, token, userID)
 		http.Redirect(w, r, entydad.DefaultAppRedirectURL, http.StatusSeeOther)
 	}
 }
@@ -438,6 +575,7 @@ func (m *AuthModule) handleSignup() http.HandlerFunc {
 // (request step — sends the reset email).
 func (m *AuthModule) handleResetPasswordRequest() http.HandlerFunc {
 	authAdapter := m.deps.AuthAdapter
+	limiter := m.deps.LoginAttemptLimiter
 
 	return func(w http.ResponseWriter, r *http.Request) {
 		if authAdapter == nil {
@@ -450,20 +588,34 @@ func (m *AuthModule) handleResetPasswordRequest() http.HandlerFunc {
 			return
 		}
 		email := r.FormValue("email")
+		// Throttled regardless of whether the email exists, so the error
+		// does not enumerate accounts. Every request counts (each one would
+		// send an email); an account lock does not apply here.
+		attempt := LoginAttempt{Scope: LoginScopeResetPassword, Email: email, IP: m.clientIP(r)}
+		if limitedErrorCode(limiter.Check(r.Context(), attempt)) != "" {
+			log.Printf("[AUTH] reset-password throttled for %s from %s", email, attempt.IP)
+			http.Redirect(w, r, entydad.AuthResetPasswordURL+"?error=throttled", http.StatusSeeOther)
+			return
+		}
+		limiter.RecordSuccess(r.Context(), attempt)
+		// The workspace's IdP owns the password of an SSO-required domain.
+		if m.ssoRequired(r.Context(), email) {
+			http.Redirect(w, r, entydad.AuthResetPasswordURL+"?error=sso_required", http.StatusSeeOther)
+			return
+		}
+		m.recordAuthEvent(r.Context(), r, AuthEvent{Type: AuthEventPasswordResetRequested, Email: email})
 		// Ignore errors to prevent email enumeration
 		resetToken, err := authAdapter.RequestPasswordReset(r.Context(), email)
 		if err == nil && resetToken != "" {
-			log.Printf("[AUTH] Password reset token for %s: %s", email, resetToken)
-			log.Printf("[AUTH] Reset URL: %s?token=%s", entydad.AuthResetConfirmURL, resetToken)
-
-			// In test mode, store the raw token keyed by user_id so the
-			// test-only GET /test/last-reset-token endpoint can return it.
-			if m.deps.TestMode && m.deps.UserIDByEmail != nil {
-				userID := m.deps.UserIDByEmail(r.Context(), email)
-				if userID != "" {
-					m.lastResetTokens.Store(userID, resetToken)
-					log.Printf("[AUTH] [TEST] stored reset token for user %s", userID)
+			link := entydad.AuthResetConfirmURL + "?token=" + url.QueryEscape(resetToken)
+			if m.mailEnabled() {
+				if err := m.sendMail(r.Context(), MailPasswordReset, MailData{To: email, Link: m.publicURL(link)}); err != nil {
+					log.Printf("[AUTH] password reset mail to %s failed: %v", email, err)
 				}
+			} else {
+				// No Mailer (development): the log is the only delivery.
+				log.Printf("[AUTH] Password reset token for %s: %s", email, resetToken)
+				log.Printf("[AUTH] Reset URL: %s", link)
 			}
 		}
 		// Always show success regardless of outcome
@@ -493,6 +645,16 @@ func (m *AuthModule) handleResetPasswordConfirm() http.HandlerFunc {
 			http.Redirect(w, r, entydad.AuthResetConfirmURL+"?token="+url.QueryEscape(token)+"&error=mismatch", http.StatusSeeOther)
 			return
 		}
+		// Without a token resolver only the user-independent rules apply
+		// and no security alert is mailed.
+		var userID, email string
+		if m.deps.PasswordResetTokenUser != nil {
+			userID, email = m.deps.PasswordResetTokenUser(r.Context(), token)
+		}
+		if code := m.deps.PasswordPolicy.Check(r.Context(), userID, email, newPassword); code != "" {
+			http.Redirect(w, r, entydad.AuthResetConfirmURL+"?token="+url.QueryEscape(token)+"&error="+code, http.StatusSeeOther)
+			return
+		}
 		if err := authAdapter.ExecutePasswordReset(r.Context(), token, newPassword); err != nil {
 			log.Printf("[AUTH] password reset confirm failed: %v", err)
 			// Map adapter error to a short code so the page handler can pick
@@ -502,6 +664,9 @@ func (m *AuthModule) handleResetPasswordConfirm() http.HandlerFunc {
 			http.Redirect(w, r, entydad.AuthResetConfirmURL+"?token="+url.QueryEscape(token)+"&error="+code, http.StatusSeeOther)
 			return
 		}
+		m.deps.PasswordPolicy.Remember(r.Context(), userID, newPassword)
+		m.recordAuthEvent(r.Context(), r, AuthEvent{Type: AuthEventPasswordResetCompleted, UserID: userID, Email: email})
+		m.sendSecurityAlert(r, email, SecurityAlertPasswordChanged)
 		http.Redirect(w, r, entydad.AuthLoginURL+"?reset=true", http.StatusSeeOther)
 	}
 }
@@ -523,6 +688,11 @@ func (m *AuthModule) handleChangePassword() http.HandlerFunc {
 			http.Redirect(w, r, entydad.DefaultAppRedirectURL, http.StatusSeeOther)
 			return
 		}
+		// An administrator viewing as this user must not change their password.
+		if m.impersonating(r.Context(), r) {
+			http.Redirect(w, r, entydad.AuthChangePasswordURL+"?error=impersonating", http.StatusSeeOther)
+			return
+		}
 		if err := r.ParseForm(); err != nil {
 			http.Error(w, "Invalid form data", http.StatusBadRequest)
 			return
@@ -540,6 +710,10 @@ func (m *AuthModule) handleChangePassword() http.HandlerFunc {
 			return
 		}
 		userID := id.UserID
+		if code := m.deps.PasswordPolicy.Check(r.Context(), userID, id.Email, newPassword); code != "" {
+			http.Redirect(w, r, entydad.AuthChangePasswordURL+"?error="+code, http.StatusSeeOther)
+			return
+		}
 		if err := authAdapter.ChangePassword(r.Context(), userID, oldPassword, newPassword); err != nil {
 			log.Printf("[AUTH] change password failed for user %s: %v", userID, err)
 			// Map adapter error to a short code so the page handler can pick
@@ -549,6 +723,9 @@ func (m *AuthModule) handleChangePassword() http.HandlerFunc {
 			http.Redirect(w, r, entydad.AuthChangePasswordURL+"?error="+code, http.StatusSeeOther)
 			return
 		}
+		m.deps.PasswordPolicy.Remember(r.Context(), userID, newPassword)
+		m.recordAuthEvent(r.Context(), r, AuthEvent{Type: AuthEventPasswordChanged, UserID: userID, Email: id.Email})
+		m.sendSecurityAlert(r, id.Email, SecurityAlertPasswordChanged)
 		http.Redirect(w, r, entydad.AuthChangePasswordURL+"?success=1", http.StatusSeeOther)
 	}
 }
@@ -591,13 +768,14 @@ func (m *AuthModule) handleSelectWorkspaceRole() http.HandlerFunc {
 	}
 
 	chooseDeps := &selectWorkspaceRole.Deps{
-		Labels:        selectWRLabels,
-		Login02:       deps.Labels.Login02,
-		CommonLabels:  deps.Labels.Common,
-		LogoText:      deps.LogoText,
-		LogoIcon:      deps.LogoIcon,
-		SwitchPostURL: "/action/auth/switch-principal",
-		LogoutURL:     entydad.AuthLogoutURL,
+		Labels:          selectWRLabels,
+		Login02:         deps.Labels.Login02,
+		CommonLabels:    deps.Labels.Common,
+		LogoText:        deps.LogoText,
+		LogoIcon:        deps.LogoIcon,
+		SwitchPostURL:   "/action/auth/switch-principal",
+		LogoutURL:       entydad.AuthLogoutURL,
+		RememberDefault: deps.DefaultPrincipals != nil,
 		ResolveCards: func(ctx context.Context) []selectWorkspaceRole.PrincipalCard {
 			if principalLoader == nil || !principalLoader.IsEnabled() {
 				return nil
@@ -628,6 +806,7 @@ func (m *AuthModule) handleSelectWorkspaceRole() http.HandlerFunc {
 					PrincipalID: p.PrincipalID,
 					DisplayName: p.DisplayName,
 					IconName:    iconForPrincipalKind(p.Type),
+					IsDefault:   m.isDefaultPrincipal(ctx, userID, p),
 				})
 			}
 			return cards
@@ -713,6 +892,12 @@ func (m *AuthModule) handleSwitchPrincipal() http.HandlerFunc {
 			http.Redirect(w, r, entydad.AuthLoginURL, http.StatusSeeOther)
 			return
 		}
+		// An impersonated session stays in the principal it was started in.
+		if m.impersonating(ctx, r) {
+			log.Printf("[AUTH] switch-principal: refused for impersonated session of user %s", userID)
+			http.Redirect(w, r, "/auth/select-workspace-role?error=forbidden", http.StatusSeeOther)
+			return
+		}
 
 		principalID := strings.TrimSpace(r.FormValue("principal_id"))
 		kindHint := strings.TrimSpace(r.FormValue("principal_kind"))
@@ -789,6 +974,8 @@ func (m *AuthModule) handleSwitchPrincipal() http.HandlerFunc {
 		}
 		if result.NewToken != "" {
 			sessionMw.SetSessionCookie(w, result.NewToken)
+			m.carrySessionActivity(ctx, currentToken, result.NewToken)
+			m.carryCredentialCheck(w, r, currentToken, result.NewToken)
 		}
 		// C2: always refresh CSRF cookie after principal switch — even
 		// when NewToken is empty (in-place, same workspace). An in-place
@@ -802,6 +989,7 @@ func (m *AuthModule) handleSwitchPrincipal() http.HandlerFunc {
 		}
 		m.deps.CSRFIssuer(w, m.deps.CSRFSecret,
 			effectiveToken, target.WorkspaceID)
+		m.rememberDefaultPrincipal(ctx, userID, target, actingAsClientID, actingAsSupplierID, r.Form)
 		http.Redirect(w, r, m.homeURLForWorkspaceID(r.Context(), target.WorkspaceID), http.StatusSeeOther)
 	}
 }
@@ -814,11 +1002,20 @@ func (m *AuthModule) handleLogout() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		if authAdapter != nil {
 			if cookie, err := r.Cookie(deps.SessionCookieName); err == nil && cookie.Value != "" {
+				// Resolve the user while the session is still valid.
+				if deps.AuthEventSink != nil {
+					if userID, err := authAdapter.ValidateSession(r.Context(), cookie.Value); err == nil && userID != "" {
+						m.recordAuthEvent(r.Context(), r, AuthEvent{Type: AuthEventLogout, UserID: userID})
+					}
+				}
 				if invalidErr := authAdapter.InvalidateSession(r.Context(), cookie.Value); invalidErr != nil {
 					log.Printf("[AUTH] logout: failed to invalidate session: %v", invalidErr)
 				}
+				m.logoutImpersonation(r.Context(), cookie.Value)
+				m.forgetSessionActivity(r.Context(), cookie.Value)
 			}
 		}
+		m.clearCredentialCheck(w)
 		// Q-SEC-3 (2026-05-31): clear the session cookie with SameSite=Strict
 		// + Secure — the locked "pin logout SameSite=Strict" posture. The
 		// espyna ClearSessionCookie default is SameSite=Lax; logout is always
@@ -867,24 +1064,24 @@ func (m *AuthModule) handleLogout() http.HandlerFunc {
 	}
 }
 
-// handleTestLastResetToken returns the GET /test/last-reset-token handler.
-// Returns the raw HMAC reset token for the given user so E2E specs can
-// construct the confirm URL without a real email pipeline.
-func (m *AuthModule) handleTestLastResetToken() http.HandlerFunc {
+// handleTestOutbox returns the GET /test/outbox handler. Returns the latest
+// message mailed to ?to= as JSON (MailMessage fields, including the action
+// link) so E2E specs can follow reset / verification / invitation links
+// without a real email pipeline.
+func (m *AuthModule) handleTestOutbox(outbox MailOutbox) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			http.Error(w, "missing user_id query param", http.StatusBadRequest)
+		to := r.URL.Query().Get("to")
+		if to == "" {
+			http.Error(w, "missing to query param", http.StatusBadRequest)
 			return
 		}
-		val, ok := m.lastResetTokens.Load(userID)
+		msg, ok := outbox.Last(to)
 		if !ok {
-			http.Error(w, "no reset token found for user", http.StatusNotFound)
+			http.Error(w, "no mail found for address", http.StatusNotFound)
 			return
 		}
-		token, _ := val.(string)
-		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
-		w.WriteHeader(http.StatusOK)
-		_, _ = w.Write([]byte(token))
+		w.Header().Set("Content-Type", "application/json; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(msg)
 	}
 }
@@ -205,3 +205,60 @@ func findPrincipalByID(principals []Principal, id, kindHint string) (Principal,
 	}
 	return Principal{}, false
 }
+
+// userIDFromSessionCookie re-validates the session cookie for handlers under
+// the session-excluded /auth/ prefix, where the session middleware has not
+// put the user into context. Empty when absent or invalid.
+func (m *AuthModule) userIDFromSessionCookie(r *http.Request) string {
+	if m.deps.AuthAdapter == nil {
+		return ""
+	}
+	cookie, err := r.Cookie(m.deps.SessionCookieName)
+	if err != nil || cookie.Value == "" {
+		return ""
+	}
+	userID, err := m.deps.AuthAdapter.ValidateSession(r.Context(), cookie.Value)
+	if err != nil {
+		return ""
+	}
+	return userID
+}
+
+// userIDForEmail resolves the DB user for a federated sign-in email
+// (case-tolerant). Empty when UserIDByEmail is not wired or nothing matches.
+func (m *AuthModule) userIDForEmail(ctx context.Context, email string) string {
+	if m.deps.UserIDByEmail == nil {
+		return ""
+	}
+	if userID := m.deps.UserIDByEmail(ctx, email); userID != "" {
+		return userID
+	}
+	return m.deps.UserIDByEmail(ctx, strings.ToLower(email))
+}
+
+func (m *AuthModule) emailForUserID(ctx context.Context, userID string) string {
+	if m.deps.EmailByUserID == nil || userID == "" {
+		return ""
+	}
+	return m.deps.EmailByUserID(ctx, userID)
+}
+
+// discardRegistration undoes a registration whose signup failed half-way:
+// DeleteUser removes the user, or failing that AnonymizeUser deactivates it.
+func (m *AuthModule) discardRegistration(ctx context.Context, userID string) {
+	var err error
+	switch {
+	case m.deps.DeleteUser != nil:
+		err = m.deps.DeleteUser(ctx, userID)
+	case m.deps.AnonymizeUser != nil:
+		err = m.deps.AnonymizeUser(ctx, userID)
+	default:
+		log.Printf("[AUTH] signup: user %s left behind: neither DeleteUser nor AnonymizeUser is configured", userID)
+		return
+	}
+	if err != nil {
+		log.Printf("[AUTH] signup: could not discard user %s: %v", userID, err)
+		return
+	}
+	log.Printf("[AUTH] signup: user %s discarded", userID)
+}
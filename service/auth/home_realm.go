@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	entydad "github.com/erniealice/entydad-golang"
+)
+
+// HomeRealm is the sign-in rule a workspace configured for an email domain.
+// Provider names where its users sign in: the ID of one of the
+// OIDCProviders, or (firebase mode) a Firebase sign-in method such as
+// "microsoft.com". SSORequired refuses every other way in for the domain —
+// passwords, invitations, magic links, passkeys, other providers — and
+// /auth/reset-password.
+type HomeRealm struct {
+	WorkspaceID string
+	Provider    string
+	SSORequired bool
+}
+
+// HomeRealmLookup returns the home realm of an email domain (lower-case, no
+// "@"); ok is false when no workspace claims the domain. Injected as a
+// closure over the workspace settings lookup, like MFARequired.
+type HomeRealmLookup func(ctx context.Context, domain string) (realm HomeRealm, ok bool)
+
+// homeRealmFor looks up the realm of email's domain.
+func (m *AuthModule) homeRealmFor(ctx context.Context, email string) (HomeRealm, bool) {
+	lookup := m.deps.HomeRealm
+	if lookup == nil {
+		return HomeRealm{}, false
+	}
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return HomeRealm{}, false
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+	if domain == "" {
+		return HomeRealm{}, false
+	}
+	return lookup(ctx, domain)
+}
+
+// ssoRequired reports whether email's workspace only allows sign-in through
+// its identity provider. The answer depends on the domain alone, so refusing
+// on it does not tell whether an account exists.
+func (m *AuthModule) ssoRequired(ctx context.Context, email string) bool {
+	realm, ok := m.homeRealmFor(ctx, email)
+	return ok && realm.SSORequired
+}
+
+// ssoProviderKey carries the identity provider a sign-in request came
+// through (see withSSOProvider).
+type ssoProviderKey struct{}
+
+// withSSOProvider marks r as authenticated by provider: an OIDC provider ID
+// or a Firebase sign-in method. Only the OIDC callback and the Firebase
+// login set it; the home-realm check accepts nothing else.
+func withSSOProvider(r *http.Request, provider string) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), ssoProviderKey{}, provider))
+}
+
+// realmProviderSignIn reports whether r signed in through realm's provider,
+// or through any identity provider when the realm names none.
+func realmProviderSignIn(r *http.Request, realm HomeRealm) bool {
+	provider, _ := r.Context().Value(ssoProviderKey{}).(string)
+	if provider == "" || provider == "password" {
+		return false
+	}
+	return realm.Provider == "" || provider == realm.Provider
+}
+
+// homeRealmRefused is the home-realm check of the shared sign-in tail
+// (parkLogin): a user of an SSO-required domain who got in any other way
+// has the fresh session invalidated and is sent back to the login page.
+// An address that cannot be found fails CLOSED.
+func (m *AuthModule) homeRealmRefused(r *http.Request, token, userID, email string) (target string, refused bool) {
+	if m.deps.HomeRealm == nil {
+		return "", false
+	}
+	if email == "" {
+		email = m.emailForUserID(r.Context(), userID)
+	}
+	if email == "" {
+		log.Printf("[AUTH] home realm: no email for user %s, refusing sign-in", userID)
+	} else if realm, ok := m.homeRealmFor(r.Context(), email); !ok || !realm.SSORequired || realmProviderSignIn(r, realm) {
+		return "", false
+	}
+	m.loginFailed(r, userID, email, AuthFailureSSORequired)
+	m.abandonSession(r.Context(), token)
+	return entydad.AuthLoginURL + "?error=sso_required", true
+}
+
+// handleLoginDiscover returns the POST /auth/login/discover handler, the
+// identifier-first step of login02. A domain whose realm names a configured
+// OIDC provider goes straight to /auth/oidc/start with the email as
+// login_hint; a Firebase method brings login02 back with only that button.
+// Anything else continues to the password step, unless the realm requires
+// SSO it cannot offer.
+func (m *AuthModule) handleLoginDiscover() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+		email := strings.TrimSpace(r.FormValue("email"))
+		if email == "" {
+			http.Redirect(w, r, entydad.AuthLoginURL, http.StatusSeeOther)
+			return
+		}
+		q := url.Values{"email": {email}}
+		realm, ok := m.homeRealmFor(r.Context(), email)
+		if ok && realm.Provider != "" {
+			if _, found := m.oidc[realm.Provider]; found && m.oidcEnabled() {
+				start := url.Values{"provider": {realm.Provider}, "login_hint": {email}}
+				http.Redirect(w, r, entydad.AuthOIDCStartURL+"?"+start.Encode(), http.StatusSeeOther)
+				return
+			}
+			if m.deps.FirebaseWebConfig != nil && m.firebaseMethodOffered(realm.Provider) {
+				q.Set("provider", realm.Provider)
+				http.Redirect(w, r, entydad.AuthLoginURL+"?"+q.Encode(), http.StatusSeeOther)
+				return
+			}
+			log.Printf("[AUTH] home realm of %s names provider %q, which is not configured", email, realm.Provider)
+		}
+		if ok && realm.SSORequired {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=sso_required", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, entydad.AuthLoginURL+"?"+q.Encode(), http.StatusSeeOther)
+	}
+}
+
+// oidcEnabled reports whether the OIDC callback can mint a session.
+func (m *AuthModule) oidcEnabled() bool {
+	return len(m.oidc) > 0 && m.deps.SessionMinter != nil && m.deps.SessionManager != nil
+}
+
+// firebaseMethodOffered reports whether login02 renders a button for the
+// Firebase sign-in method.
+func (m *AuthModule) firebaseMethodOffered(method string) bool {
+	for _, p := range firebaseSocialProviders(m.deps.AllowedSignInMethods) {
+		if p.Method == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	entydad "github.com/erniealice/entydad-golang"
+)
+
+func TestHomeRealm_DiscoveryAndSSOEnforcement(t *testing.T) {
+	t.Parallel()
+	idp := newStubIdP(t)
+	m := newOIDCTestModule(idp, &recordingSessionManager{})
+	m.deps.AuthAdapter = &passwordAdapter{users: map[string]string{
+		"ana@example.com":  "s3cret-pass",
+		"ben@partner.test": "s3cret-pass",
+	}}
+	m.deps.HomeRealm = func(_ context.Context, domain string) (HomeRealm, bool) {
+		switch domain {
+		case "example.com":
+			return HomeRealm{WorkspaceID: "ws-1", Provider: "stub", SSORequired: true}, true
+		case "legacy.test":
+			return HomeRealm{WorkspaceID: "ws-2", Provider: "saml-not-configured", SSORequired: true}, true
+		}
+		return HomeRealm{}, false
+	}
+	events := NewMemoryAuthEventSink()
+	m.deps.AuthEventSink = events
+
+	discover := func(email string) string {
+		rec := postForm(m.handleLoginDiscover(), entydad.AuthLoginDiscoverURL, url.Values{"email": {email}}, nil)
+		if rec.Code != http.StatusSeeOther {
+			t.Fatalf("discover %s: status = %d", email, rec.Code)
+		}
+		return rec.Header().Get("Location")
+	}
+
+	// The realm's OIDC provider, with the email as login_hint all the way
+	// to the IdP.
+	loc := discover("Ana@Example.com")
+	if !strings.HasPrefix(loc, entydad.AuthOIDCStartURL+"?") {
+		t.Fatalf("federated domain: Location = %q", loc)
+	}
+	start := httptest.NewRecorder()
+	m.handleOIDCStart()(start, httptest.NewRequest(http.MethodGet, loc, nil))
+	authz, err := url.Parse(start.Header().Get("Location"))
+	if err != nil || authz.Query().Get("login_hint") != "Ana@Example.com" {
+		t.Fatalf("authorization request = %q", start.Header().Get("Location"))
+	}
+
+	// No realm: the password step for that address.
+	if loc := discover("ben@partner.test"); loc != entydad.AuthLoginURL+"?email=ben%40partner.test" {
+		t.Fatalf("unclaimed domain: Location = %q", loc)
+	}
+	// SSO required but the provider is not configured here.
+	if loc := discover("cy@legacy.test"); loc != entydad.AuthLoginURL+"?error=sso_required" {
+		t.Fatalf("unavailable provider: Location = %q", loc)
+	}
+
+	// The password path is closed for SSO-required domains, even with the
+	// right password, and open elsewhere.
+	login := func(email string) string {
+		rec := postForm(m.handleLogin(), entydad.AuthLoginPostURL, url.Values{"email": {email}, "password": {"s3cret-pass"}}, nil)
+		return rec.Header().Get("Location")
+	}
+	if loc := login("ana@example.com"); loc != entydad.AuthLoginURL+"?error=sso_required" {
+		t.Fatalf("SSO-required login: Location = %q", loc)
+	}
+	if loc := login("ben@partner.test"); loc != entydad.DefaultAppRedirectURL {
+		t.Fatalf("password login: Location = %q", loc)
+	}
+	if got := events.Events("user-ana", 0); len(got) != 1 || got[0].Reason != AuthFailureSSORequired {
+		t.Fatalf("events = %+v", got)
+	}
+
+	reset := func(email string) string {
+		rec := postForm(m.handleResetPasswordRequest(), entydad.AuthResetPasswordPostURL, url.Values{"email": {email}}, nil)
+		return rec.Header().Get("Location")
+	}
+	if loc := reset("ana@example.com"); loc != entydad.AuthResetPasswordURL+"?error=sso_required" {
+		t.Fatalf("SSO-required reset: Location = %q", loc)
+	}
+	if loc := reset("ben@partner.test"); loc != entydad.AuthResetPasswordURL+"?sent=true" {
+		t.Fatalf("password reset: Location = %q", loc)
+	}
+}
+
+// ssoRealm claims example.com for provider with SSO required.
+func ssoRealm(provider string) HomeRealmLookup {
+	return func(_ context.Context, domain string) (HomeRealm, bool) {
+		if domain == "example.com" {
+			return HomeRealm{WorkspaceID: "ws-1", Provider: provider, SSORequired: true}, true
+		}
+		return HomeRealm{}, false
+	}
+}
+
+func TestHomeRealm_SSORequiredOnEveryEntryPoint(t *testing.T) {
+	t.Parallel()
+	refused := entydad.AuthLoginURL + "?error=sso_required"
+
+	t.Run("oidc", func(t *testing.T) {
+		t.Parallel()
+		for provider, want := range map[string]string{"stub": entydad.DefaultAppRedirectURL, "corp-idp": refused} {
+			idp := newStubIdP(t)
+			sessions := &recordingSessionManager{}
+			m := newOIDCTestModule(idp, sessions)
+			m.deps.HomeRealm = ssoRealm(provider)
+			rec := runOIDCFlow(t, m, idp, nil)
+			if got := rec.Header().Get("Location"); got != want || (want == refused) != (sessions.token == "") {
+				t.Errorf("realm provider %s: → %q session %q, want → %q", provider, got, sessions.token, want)
+			}
+		}
+	})
+
+	t.Run("firebase", func(t *testing.T) {
+		t.Parallel()
+		for method, want := range map[string]string{"microsoft.com": entydad.DefaultAppRedirectURL, "google.com": refused} {
+			sessions := &recordingSessionManager{}
+			m := NewAuthModule(&Deps{
+				SessionManager: sessions,
+				SessionMinter: func(_ context.Context, userID string) (string, error) {
+					return "session-for-" + userID, nil
+				},
+				FirebaseVerifier: func(context.Context, string) (string, string, error) {
+					return "ana@example.com", method, nil
+				},
+				UserIDByEmail: func(context.Context, string) string { return "user-ana" },
+				CSRFIssuer:    func(http.ResponseWriter, []byte, string, string) string { return "" },
+				CSRFSecret:    []byte("test-secret"),
+				HomeRealm:     ssoRealm("microsoft.com"),
+			})
+			rec := postForm(m.handleFirebaseLogin(), entydad.AuthFirebaseLoginURL, url.Values{"id_token": {"tok"}}, nil)
+			if body := rec.Body.String(); !strings.Contains(body, `"redirect":"`+want+`"`) || (want == refused) != (sessions.token == "") {
+				t.Errorf("method %s: %s session %q, want → %q", method, body, sessions.token, want)
+			}
+		}
+	})
+
+	t.Run("invitation", func(t *testing.T) {
+		t.Parallel()
+		outbox := NewMemoryOutbox()
+		sessions := &recordingSessionManager{}
+		joins := &joinRecorder{}
+		m, _ := newInvitationTestModule(outbox, sessions, joins.join)
+		in := InvitationInput{WorkspaceID: "ws-1", Workspace: "Acme", Email: "old@example.com", InviterName: "Rosa"}
+		if _, err := m.InviteToWorkspace(context.Background(), in); err != nil {
+			t.Fatal(err)
+		}
+		m.deps.HomeRealm = ssoRealm("stub")
+		form := url.Values{"token": {inviteToken(t, outbox, "old@example.com")}, "password": {"old-pass"}}
+		rec := postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, form, nil)
+		if got := rec.Header().Get("Location"); got != refused || sessions.token != "" || len(joins.joined) != 0 {
+			t.Fatalf("accept: → %q session %q joined %v", got, sessions.token, joins.joined)
+		}
+	})
+
+	t.Run("magic link", func(t *testing.T) {
+		t.Parallel()
+		outbox := NewMemoryOutbox()
+		sessions := &recordingSessionManager{}
+		m := newMagicLinkTestModule(outbox, sessions)
+
+		// A link mailed before the realm required SSO no longer signs in.
+		postForm(m.handleMagicLinkRequest(), entydad.AuthMagicLinkPostURL, url.Values{"email": {"ana@example.com"}}, nil)
+		msg, _ := outbox.Last("ana@example.com")
+		link, err := url.Parse(msg.Link)
+		if err != nil {
+			t.Fatal(err)
+		}
+		m.deps.HomeRealm = ssoRealm("stub")
+		rec := postForm(m.handleMagicLinkVerify(), entydad.AuthMagicLinkVerifyURL, url.Values{"token": {link.Query().Get("token")}}, nil)
+		if got := rec.Header().Get("Location"); got != refused || sessions.token != "" {
+			t.Fatalf("verify: → %q session %q", got, sessions.token)
+		}
+
+		// And no new link is mailed.
+		rec = postForm(m.handleMagicLinkRequest(), entydad.AuthMagicLinkPostURL, url.Values{"email": {"ana@example.com"}}, nil)
+		if got := rec.Header().Get("Location"); got != refused || len(outbox.Messages()) != 1 {
+			t.Fatalf("request: → %q, %d mails", got, len(outbox.Messages()))
+		}
+	})
+
+	t.Run("passkey", func(t *testing.T) {
+		t.Parallel()
+		a := newTestAuthenticator(t)
+		sessions := &recordingSessionManager{}
+		m := newPasskeyTestModule(t, a, sessions)
+		m.deps.HomeRealm = ssoRealm("stub")
+		login := func(signCount uint32) string { return passkeyLogin(t, m, a, signCount).Body.String() }
+
+		// Checked by the owner's address; without one it fails closed.
+		if body := login(1); !strings.Contains(body, refused) || sessions.token != "" {
+			t.Fatalf("no email lookup: %s session %q", body, sessions.token)
+		}
+		m.deps.EmailByUserID = func(context.Context, string) string { return "ana@example.com" }
+		if body := login(2); !strings.Contains(body, refused) || sessions.token != "" {
+			t.Fatalf("SSO-required passkey: %s session %q", body, sessions.token)
+		}
+	})
+}
@@ -142,3 +142,108 @@ const logoutLoadingHTML = `<!DOCTYPE html>
     </script>
 </body>
 </html>`
+
+// impersonationBannerHTML is the "viewing as" banner ImpersonationMiddleware
+// injects right after <body> on every full page of an impersonated session.
+// An html/template (the labels and the user's name are escaped); it reuses
+// the app's alert classes rather than inline styles, which the CSP blocks.
+const impersonationBannerHTML = `<div class="alert alert-warning alert-filled impersonation-banner" role="alert" data-testid="impersonation-banner">
+    <div class="alert-body">
+        <p class="alert-title">{{.Title}}</p>
+        <p class="alert-message">{{.Message}}</p>
+    </div>
+    <form method="POST" action="{{.StopURL}}" hx-boost="false">
+        <button type="submit" class="btn btn-sm btn-outline" data-testid="impersonation-stop-btn">{{.StopButton}}</button>
+    </form>
+</div>`
+
+// stepUpPromptHTML is the step-up prompt StepUpMiddleware loads into the
+// app's dialog. An html/template; the form posts with hx-swap="none" so the
+// replayed action's response headers (toast, redirect, table refresh) apply
+// as if the original form had sent it.
+const stepUpPromptHTML = `<div class="dialog-header">
+    <h3 class="dialog-title" id="dialog-title">{{.Labels.Title}}</h3>
+</div>
+{{if .Valid}}
+<form hx-post="{{.Action}}" hx-swap="none" data-testid="step-up-form">
+    <div class="dialog-body">
+        <p class="dialog-message">{{.Labels.Message}}</p>
+        {{if .Error}}<div class="alert alert-error" role="alert" data-testid="step-up-error">{{.Error}}</div>{{end}}
+        <input type="hidden" name="p" value="{{.Token}}">
+        <div class="form-group">
+            <label class="form-label" for="step-up-password">{{.Labels.Password}}</label>
+            <input type="password" id="step-up-password" name="password" class="form-input" autocomplete="current-password" autofocus data-testid="step-up-password">
+        </div>
+        {{if .MFA}}
+        <div class="form-group">
+            <label class="form-label" for="step-up-code">{{.Labels.Code}}</label>
+            <input type="text" id="step-up-code" name="code" class="form-input" inputmode="numeric" autocomplete="one-time-code" data-testid="step-up-code">
+            <p class="form-hint">{{.Labels.CodeHint}}</p>
+        </div>
+        {{end}}
+    </div>
+    <div class="dialog-footer">
+        <button type="button" class="dialog-btn dialog-btn-cancel" data-dialog-close data-testid="step-up-cancel">{{.Labels.Cancel}}</button>
+        <button type="submit" class="dialog-btn dialog-btn-confirm dialog-btn-primary" data-testid="step-up-submit">{{.Labels.Submit}}</button>
+    </div>
+</form>
+{{else}}
+<div class="dialog-body">
+    <p class="dialog-message" data-testid="step-up-expired">{{.Labels.ErrorExpired}}</p>
+</div>
+<div class="dialog-footer">
+    <button type="button" class="dialog-btn dialog-btn-cancel" data-dialog-close>{{.Labels.Cancel}}</button>
+</div>
+{{end}}`
+
+// sessionReloginHTML is the re-login dialog SessionLifetimeMiddleware loads
+// into the app's dialog when a session has expired. Like the step-up prompt
+// the form posts with hx-swap="none", so the replayed request's response
+// headers apply as if the original form had sent it; Cancel leaves the page
+// (and any half-filled drawer) as it was.
+const sessionReloginHTML = `<div class="dialog-header">
+    <h3 class="dialog-title" id="dialog-title">{{.Labels.Title}}</h3>
+</div>
+{{if .Valid}}
+<form hx-post="{{.Action}}" hx-swap="none" data-testid="session-relogin-form">
+    <div class="dialog-body">
+        <p class="dialog-message">{{.Labels.Message}}</p>
+        {{if .Error}}<div class="alert alert-error" role="alert" data-testid="session-relogin-error">{{.Error}}</div>{{end}}
+        <input type="hidden" name="p" value="{{.Token}}">
+        <div class="form-group">
+            <label class="form-label" for="session-relogin-password">{{.Labels.Password}}</label>
+            <input type="password" id="session-relogin-password" name="password" class="form-input" autocomplete="current-password" autofocus data-testid="session-relogin-password">
+        </div>
+        {{if .MFA}}
+        <div class="form-group">
+            <label class="form-label" for="session-relogin-code">{{.Labels.Code}}</label>
+            <input type="text" id="session-relogin-code" name="code" class="form-input" inputmode="numeric" autocomplete="one-time-code" data-testid="session-relogin-code">
+            <p class="form-hint">{{.Labels.CodeHint}}</p>
+        </div>
+        {{end}}
+    </div>
+    <div class="dialog-footer">
+        <button type="button" class="dialog-btn dialog-btn-cancel" data-dialog-close data-testid="session-relogin-cancel">{{.Labels.Cancel}}</button>
+        <button type="submit" class="dialog-btn dialog-btn-confirm dialog-btn-primary" data-testid="session-relogin-submit">{{.Labels.Submit}}</button>
+    </div>
+</form>
+{{else}}
+<div class="dialog-body">
+    <p class="dialog-message" data-testid="session-relogin-expired">{{.Labels.ErrorExpired}}</p>
+</div>
+<div class="dialog-footer">
+    <button type="button" class="dialog-btn dialog-btn-cancel" data-dialog-close>{{.Labels.Cancel}}</button>
+</div>
+{{end}}`
+
+// actingAsSwitcherHTML is the acting-as dropdown the portal header loads
+// from GET /action/auth/acting-as. A plain form with a submit button (the
+// CSP blocks an inline onchange); hx-boost is off so the switch is a full
+// navigation that picks up the rotated session cookie.
+const actingAsSwitcherHTML = `<form method="POST" action="{{.Action}}" hx-boost="false" class="acting-as-switcher" data-testid="acting-as-switcher">
+    <label class="form-label" for="acting-as-select">{{.Labels.Label}}</label>
+    <select id="acting-as-select" name="acting_as_id" class="form-input" data-testid="acting-as-select">
+        {{range .Options}}<option value="{{.ID}}"{{if .Current}} selected{{end}}>{{.DisplayName}}</option>{{end}}
+    </select>
+    <button type="submit" class="btn btn-sm btn-outline" data-testid="acting-as-switch-btn">{{.Labels.Switch}}</button>
+</form>`
@@ -0,0 +1,669 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+// Impersonation errors. ErrImpersonating is also returned by the account
+// methods (MFA, passkeys) that refuse to run inside an impersonated session;
+// callers map it to a label.
+var (
+	ErrImpersonationDisabled = errors.New("auth: impersonation is not configured")
+	ErrImpersonationReason   = errors.New("auth: impersonation requires a reason")
+	ErrImpersonationTarget   = errors.New("auth: this user cannot be impersonated")
+	ErrImpersonating         = errors.New("auth: not allowed while impersonating another user")
+)
+
+// Impersonation is an active "view as user" session. Token is the
+// impersonated session (the store key); ActorToken is the administrator's
+// own session, left valid so stopping can restore it.
+type Impersonation struct {
+	Token        string
+	ActorUserID  string
+	ActorToken   string
+	TargetUserID string
+	TargetName   string
+	WorkspaceID  string
+	Reason       string
+	ReturnURL    string // local path the administrator lands on when it ends
+	StartedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// ImpersonationStore tracks active impersonations by session token.
+// ImpersonationMiddleware looks every request's token up, so the store
+// should be cheap to query. NewMemoryImpersonationStore is the per-process
+// default for tests and single-instance hosts.
+type ImpersonationStore interface {
+	SaveImpersonation(ctx context.Context, imp Impersonation) error
+	// GetImpersonation returns (nil, nil) when token is not impersonating.
+	GetImpersonation(ctx context.Context, token string) (*Impersonation, error)
+	DeleteImpersonation(ctx context.Context, token string) error
+	// ConsumeImpersonationGrant marks a start link used and reports whether
+	// this was its first use, like MagicLinkStore.ConsumeMagicLink.
+	ConsumeImpersonationGrant(ctx context.Context, id string, expires time.Time) (first bool, err error)
+}
+
+// ImpersonationRequest is what the admin user page passes to
+// ImpersonationURL. The caller has already checked the actor's permission;
+// WorkspaceID is the workspace the actor is working in, and the
+// impersonated session is confined to it.
+type ImpersonationRequest struct {
+	ActorUserID  string
+	TargetUserID string
+	TargetName   string
+	WorkspaceID  string
+	Reason       string
+	ReturnURL    string
+}
+
+const (
+	// impersonationGrantTTL is the lifetime of the start link handed to
+	// the admin page; it is followed straight away.
+	impersonationGrantTTL = 2 * time.Minute
+
+	// impersonationReasonMaxLen caps the recorded reason (runes).
+	impersonationReasonMaxLen = 500
+
+	linkPurposeImpersonation = "impersonation"
+
+	// permissionImpersonate is the permission that lets a user impersonate
+	// others; its holders are administrators and are not impersonated.
+	permissionImpersonate = "user:impersonate"
+
+	// PrincipalSwitchInput.UseCase values for the impersonation audit rows.
+	useCaseImpersonateStart   = "impersonate_start"
+	useCaseImpersonateStop    = "impersonate_stop"
+	useCaseImpersonateTimeout = "impersonate_timeout"
+)
+
+// impersonationClaims is the payload of the start link. It is bound to the
+// actor's session on use, so a leaked link is useless to anyone else.
+type impersonationClaims struct {
+	ID        string `json:"i"`
+	Actor     string `json:"a"`
+	Target    string `json:"t"`
+	Name      string `json:"n"`
+	Workspace string `json:"w"`
+	Reason    string `json:"r"`
+	Return    string `json:"u,omitempty"`
+}
+
+type ctxKeyImpersonationType struct{}
+
+var ctxKeyImpersonation = ctxKeyImpersonationType{}
+
+// impersonationEnabled reports whether impersonation is configured and
+// fully wired.
+func (m *AuthModule) impersonationEnabled() bool {
+	d := m.deps
+	return d.ImpersonationStore != nil && d.SessionMinter != nil && d.SessionManager != nil &&
+		d.AuthAdapter != nil && d.PrincipalResolver != nil && d.PrincipalSwitcher != nil &&
+		d.PermissionGrants != nil && d.PermissionCatalog != nil
+}
+
+// PermissionGrants returns the role → permission grants userID holds in
+// workspaceID — DENY rows, wildcard codes and inactive rows included — as
+// effective.FromAssignments resolves the user's role assignments. Injected
+// as a closure; an empty result is not an error.
+type PermissionGrants func(ctx context.Context, userID, workspaceID string) ([]effective.Grant, error)
+
+// PermissionCatalog returns the permission codes defined for workspaceID,
+// the list wildcard grants are expanded against. Injected as a closure.
+type PermissionCatalog func(ctx context.Context, workspaceID string) ([]string, error)
+
+// impersonationTarget returns target's principal in workspaceID when actor
+// may impersonate them, ErrImpersonationTarget when not: an impersonated
+// session must never reach further than the actor's own. Refused are
+// workspace owners, other administrators (holders of user:impersonate) and
+// anyone allowed a permission the actor is not.
+func (m *AuthModule) impersonationTarget(ctx context.Context, actor, target, workspaceID string) (Principal, error) {
+	principals, err := m.deps.PrincipalResolver.Resolve(ctx, target)
+	if err != nil {
+		return Principal{}, fmt.Errorf("resolve user %s: %w", target, err)
+	}
+	p, ok := workspacePrincipal(principals, workspaceID)
+	if !ok || p.Type == PrincipalTypeOperatorOwner {
+		return Principal{}, ErrImpersonationTarget
+	}
+	actorGrants, err := m.deps.PermissionGrants(ctx, actor, workspaceID)
+	if err != nil {
+		return Principal{}, fmt.Errorf("permissions of user %s: %w", actor, err)
+	}
+	targetGrants, err := m.deps.PermissionGrants(ctx, target, workspaceID)
+	if err != nil {
+		return Principal{}, fmt.Errorf("permissions of user %s: %w", target, err)
+	}
+	catalog, err := m.deps.PermissionCatalog(ctx, workspaceID)
+	if err != nil {
+		return Principal{}, fmt.Errorf("permission catalog of workspace %s: %w", workspaceID, err)
+	}
+	targetSet := effective.New(targetGrants)
+	if targetSet.Decide(permissionImpersonate).Allowed() {
+		return Principal{}, ErrImpersonationTarget
+	}
+	if beyond := permissionsBeyond(targetSet, effective.New(actorGrants), catalog); len(beyond) > 0 {
+		log.Printf("[AUTH] impersonation: user %s is allowed %v, which actor %s is not", target, beyond, actor)
+		return Principal{}, ErrImpersonationTarget
+	}
+	return p, nil
+}
+
+// permissionsBeyond returns the codes target is allowed and actor is not.
+// Both sides are decided by the effective resolver over catalog plus the
+// target's own concrete codes, so wildcards on either side expand and a
+// DENY beats any ALLOW.
+func permissionsBeyond(target, actor *effective.Set, catalog []string) []string {
+	var out []string
+	for _, code := range target.Runtime(catalog) {
+		if !actor.Decide(code).Allowed() {
+			out = append(out, code)
+		}
+	}
+	return out
+}
+
+// ImpersonationURL validates req and returns the single-use start link the
+// admin page redirects to. The link only works in the actor's own browser
+// session and expires after two minutes.
+func (m *AuthModule) ImpersonationURL(ctx context.Context, req ImpersonationRequest) (string, error) {
+	if !m.impersonationEnabled() {
+		return "", ErrImpersonationDisabled
+	}
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return "", ErrImpersonationReason
+	}
+	if runes := []rune(reason); len(runes) > impersonationReasonMaxLen {
+		reason = string(runes[:impersonationReasonMaxLen])
+	}
+	if req.ActorUserID == "" || req.TargetUserID == "" || req.WorkspaceID == "" || req.ActorUserID == req.TargetUserID {
+		return "", ErrImpersonationTarget
+	}
+	if m.impersonating(ctx, nil) {
+		return "", ErrImpersonating
+	}
+	if _, err := m.impersonationTarget(ctx, req.ActorUserID, req.TargetUserID, req.WorkspaceID); err != nil {
+		return "", err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	token, err := m.signLinkToken(linkPurposeImpersonation, impersonationClaims{
+		ID:        hex.EncodeToString(id),
+		Actor:     req.ActorUserID,
+		Target:    req.TargetUserID,
+		Name:      req.TargetName,
+		Workspace: req.WorkspaceID,
+		Reason:    reason,
+		Return:    localPath(req.ReturnURL),
+	}, impersonationGrantTTL)
+	if err != nil {
+		return "", err
+	}
+	return entydad.AuthImpersonateURL + "?grant=" + url.QueryEscape(token), nil
+}
+
+// handleImpersonateStart returns the GET /auth/impersonate handler. It
+// consumes the start link, checks the target again (see
+// impersonationTarget), mints a session for the target user and switches
+// it into the target's principal in the actor's workspace through
+// PrincipalSwitcher (use case impersonate_start, audit required) before the
+// cookie is swapped.
+func (m *AuthModule) handleImpersonateStart() http.HandlerFunc {
+	store := m.deps.ImpersonationStore
+	minter := m.deps.SessionMinter
+	sessionMw := m.deps.SessionManager
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		var c impersonationClaims
+		if !m.openLinkToken(linkPurposeImpersonation, r.URL.Query().Get("grant"), &c) || c.ID == "" || c.Target == "" {
+			http.Redirect(w, r, entydad.DefaultAppRedirectURL, http.StatusSeeOther)
+			return
+		}
+		fail := func() {
+			http.Redirect(w, r, m.homeURLForWorkspaceID(ctx, c.Workspace), http.StatusSeeOther)
+		}
+		actorToken := ""
+		if cookie, err := r.Cookie(m.deps.SessionCookieName); err == nil {
+			actorToken = cookie.Value
+		}
+		if actorToken == "" || m.userIDFromSessionCookie(r) != c.Actor {
+			log.Printf("[AUTH] impersonation: start link for actor %s opened outside their session", c.Actor)
+			http.Redirect(w, r, entydad.AuthLoginURL, http.StatusSeeOther)
+			return
+		}
+		if current, err := store.GetImpersonation(ctx, actorToken); err != nil || current != nil {
+			log.Printf("[AUTH] impersonation: actor %s is already impersonating or lookup failed: %v", c.Actor, err)
+			fail()
+			return
+		}
+		first, err := store.ConsumeImpersonationGrant(ctx, c.ID, time.Now().Add(impersonationGrantTTL))
+		if err != nil || !first {
+			log.Printf("[AUTH] impersonation: replay or store failure for actor %s: %v", c.Actor, err)
+			fail()
+			return
+		}
+		target, err := m.impersonationTarget(ctx, c.Actor, c.Target, c.Workspace)
+		if err != nil {
+			log.Printf("[AUTH] impersonation: actor %s may not impersonate user %s in workspace %s: %v", c.Actor, c.Target, c.Workspace, err)
+			fail()
+			return
+		}
+		token, err := minter(ctx, c.Target)
+		if err != nil || token == "" {
+			log.Printf("[AUTH] impersonation: mint session failed for user %s: %v", c.Target, err)
+			fail()
+			return
+		}
+		result, err := m.deps.PrincipalSwitcher(ctx, PrincipalSwitchInput{
+			UserID:             c.Target,
+			Token:              token,
+			TargetPrincipal:    target,
+			UseCase:            useCaseImpersonateStart,
+			RequestURL:         r.URL.Path,
+			Referer:            r.Header.Get("Referer"),
+			SecFetchSite:       r.Header.Get("Sec-Fetch-Site"),
+			UserAgent:          r.Header.Get("User-Agent"),
+			RequireAudit:       true,
+			ImpersonatorUserID: c.Actor,
+			Reason:             c.Reason,
+		})
+		if err != nil {
+			log.Printf("[AUTH] impersonation: audited switch failed for actor %s → user %s: %v", c.Actor, c.Target, err)
+			m.abandonSession(ctx, token)
+			fail()
+			return
+		}
+		if result.NewToken != "" {
+			token = result.NewToken
+		}
+		now := time.Now()
+		if err := store.SaveImpersonation(ctx, Impersonation{
+			Token:        token,
+			ActorUserID:  c.Actor,
+			ActorToken:   actorToken,
+			TargetUserID: c.Target,
+			TargetName:   c.Name,
+			WorkspaceID:  target.WorkspaceID,
+			Reason:       c.Reason,
+			ReturnURL:    c.Return,
+			StartedAt:    now,
+			ExpiresAt:    now.Add(m.deps.ImpersonationTimeout),
+		}); err != nil {
+			log.Printf("[AUTH] impersonation: save failed for actor %s: %v", c.Actor, err)
+			m.abandonSession(ctx, token)
+			fail()
+			return
+		}
+		log.Printf("[AUTH] impersonation started: actor=%s target=%s workspace=%s reason=%q", c.Actor, c.Target, target.WorkspaceID, c.Reason)
+		sessionMw.SetSessionCookie(w, token)
+		m.startSessionClock(ctx, token, c.Target)
+		m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, target.WorkspaceID)
+		http.Redirect(w, r, m.homeURLForWorkspaceID(ctx, target.WorkspaceID), http.StatusSeeOther)
+	}
+}
+
+// handleImpersonateStop returns the POST /auth/impersonate/stop handler (the
+// banner's button).
+func (m *AuthModule) handleImpersonateStop() http.HandlerFunc {
+	store := m.deps.ImpersonationStore
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(m.deps.SessionCookieName)
+		if err != nil || cookie.Value == "" {
+			redirectTopLevel(w, r, entydad.AuthLoginURL)
+			return
+		}
+		imp, err := store.GetImpersonation(r.Context(), cookie.Value)
+		if err != nil || imp == nil {
+			redirectTopLevel(w, r, entydad.DefaultAppRedirectURL)
+			return
+		}
+		m.endImpersonation(w, r, imp, useCaseImpersonateStop)
+	}
+}
+
+// endImpersonation ends imp — the impersonated session is invalidated — and
+// switches the browser back to the administrator's own session, recording
+// useCase through PrincipalSwitcher. When the administrator's session ended
+// in the meantime the browser is signed out instead.
+func (m *AuthModule) endImpersonation(w http.ResponseWriter, r *http.Request, imp *Impersonation, useCase string) {
+	ctx := r.Context()
+	if err := m.deps.ImpersonationStore.DeleteImpersonation(ctx, imp.Token); err != nil {
+		log.Printf("[AUTH] impersonation: delete failed for actor %s: %v", imp.ActorUserID, err)
+	}
+	m.abandonSession(ctx, imp.Token)
+	log.Printf("[AUTH] impersonation ended (%s): actor=%s target=%s", useCase, imp.ActorUserID, imp.TargetUserID)
+
+	if userID, err := m.deps.AuthAdapter.ValidateSession(ctx, imp.ActorToken); err != nil || userID != imp.ActorUserID {
+		m.deps.SessionManager.ClearSessionCookie(w)
+		redirectTopLevel(w, r, entydad.AuthLoginURL)
+		return
+	}
+	token := imp.ActorToken
+	if principals, err := m.deps.PrincipalResolver.Resolve(ctx, imp.ActorUserID); err != nil {
+		log.Printf("[AUTH] impersonation: resolve failed for actor %s: %v", imp.ActorUserID, err)
+	} else if p, ok := workspacePrincipal(principals, imp.WorkspaceID); ok {
+		result, err := m.deps.PrincipalSwitcher(ctx, PrincipalSwitchInput{
+			UserID:             imp.ActorUserID,
+			Token:              token,
+			TargetPrincipal:    p,
+			UseCase:            useCase,
+			RequestURL:         r.URL.Path,
+			Referer:            r.Header.Get("Referer"),
+			SecFetchSite:       r.Header.Get("Sec-Fetch-Site"),
+			UserAgent:          r.Header.Get("User-Agent"),
+			RequireAudit:       true,
+			ImpersonatorUserID: imp.ActorUserID,
+			Reason:             imp.Reason,
+		})
+		if err != nil {
+			// The impersonated session is already gone; returning the
+			// administrator to their own session matters more.
+			log.Printf("[AUTH] impersonation: audited switch back failed for actor %s: %v", imp.ActorUserID, err)
+		} else if result.NewToken != "" {
+			token = result.NewToken
+		}
+	}
+	m.deps.SessionManager.SetSessionCookie(w, token)
+	m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, imp.WorkspaceID)
+	target := imp.ReturnURL
+	if target == "" {
+		target = m.homeURLForWorkspaceID(ctx, imp.WorkspaceID)
+	}
+	redirectTopLevel(w, r, target)
+}
+
+// logoutImpersonation handles a logout from an impersonated session: the
+// administrator's parked session is signed out too, since no browser holds
+// it any more.
+func (m *AuthModule) logoutImpersonation(ctx context.Context, token string) {
+	store := m.deps.ImpersonationStore
+	if store == nil {
+		return
+	}
+	imp, err := store.GetImpersonation(ctx, token)
+	if err != nil || imp == nil {
+		return
+	}
+	if err := store.DeleteImpersonation(ctx, token); err != nil {
+		log.Printf("[AUTH] impersonation: delete failed for actor %s: %v", imp.ActorUserID, err)
+	}
+	m.abandonSession(ctx, imp.ActorToken)
+	log.Printf("[AUTH] impersonation ended (logout): actor=%s target=%s", imp.ActorUserID, imp.TargetUserID)
+}
+
+// ImpersonationMiddleware enforces active impersonations: it ends them once
+// ImpersonationTimeout has passed, keeps the impersonated session inside its
+// workspace (/w/{slug}/...) and out of the target's /me/ pages, and injects
+// the "viewing as" banner into every full HTML page. Wrap the app handler
+// with it outside the session and workspace-path middleware, so it sees the
+// cookie before any URL-driven principal switch. A no-op unless
+// impersonation is configured.
+func (m *AuthModule) ImpersonationMiddleware(next http.Handler) http.Handler {
+	if !m.impersonationEnabled() {
+		return next
+	}
+	store := m.deps.ImpersonationStore
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie(m.deps.SessionCookieName)
+		if err != nil || cookie.Value == "" || strings.HasPrefix(r.URL.Path, "/auth/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		imp, err := store.GetImpersonation(r.Context(), cookie.Value)
+		if err != nil {
+			log.Printf("[AUTH] impersonation: lookup failed: %v", err)
+		}
+		if imp == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !time.Now().Before(imp.ExpiresAt) {
+			m.endImpersonation(w, r, imp, useCaseImpersonateTimeout)
+			return
+		}
+		ctx := r.Context()
+		// The target's own pages (/me/...: sessions, account data) are not
+		// the administrator's to use.
+		if r.URL.Path == "/me" || strings.HasPrefix(r.URL.Path, "/me/") {
+			redirectTopLevel(w, r, m.homeURLForWorkspaceID(ctx, imp.WorkspaceID))
+			return
+		}
+		if resolve := m.deps.WorkspaceSlugResolver; resolve != nil && strings.HasPrefix(r.URL.Path, "/w/") {
+			if slug := resolve(ctx, imp.WorkspaceID); slug != "" && !strings.HasPrefix(r.URL.Path+"/", "/w/"+slug+"/") {
+				redirectTopLevel(w, r, m.homeURLForWorkspaceID(ctx, imp.WorkspaceID))
+				return
+			}
+		}
+		r = r.WithContext(context.WithValue(ctx, ctxKeyImpersonation, imp))
+		if r.Method != http.MethodGet || r.Header.Get("HX-Request") == "true" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		bw := &bufferedResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(bw, r)
+		body := bw.buf.Bytes()
+		if bw.status == http.StatusOK && strings.HasPrefix(w.Header().Get("Content-Type"), "text/html") {
+			if banner, err := m.impersonationBanner(imp); err != nil {
+				log.Printf("[AUTH] impersonation: banner render failed: %v", err)
+			} else {
+				body = injectAfterBodyTag(body, banner)
+				w.Header().Del("Content-Length")
+			}
+		}
+		w.WriteHeader(bw.status)
+		if _, err := w.Write(body); err != nil {
+			log.Printf("[AUTH] impersonation: write failed: %v", err)
+		}
+	})
+}
+
+// impersonationBannerTemplate renders impersonationBannerHTML.
+var impersonationBannerTemplate = template.Must(template.New("impersonation-banner").Parse(impersonationBannerHTML))
+
+// impersonationBanner renders the banner for imp with the configured labels.
+func (m *AuthModule) impersonationBanner(imp *Impersonation) ([]byte, error) {
+	labels := m.deps.Labels.Impersonation
+	if labels.Title == "" {
+		labels = entydad.DefaultImpersonationLabels()
+	}
+	name := imp.TargetName
+	if name == "" {
+		name = imp.TargetUserID
+	}
+	var buf bytes.Buffer
+	err := impersonationBannerTemplate.Execute(&buf, map[string]string{
+		"Title":      strings.ReplaceAll(labels.Title, "{user}", name),
+		"Message":    strings.ReplaceAll(labels.Message, "{time}", imp.ExpiresAt.Format("15:04 MST")),
+		"StopButton": labels.StopButton,
+		"StopURL":    entydad.AuthImpersonateStopURL,
+	})
+	return buf.Bytes(), err
+}
+
+// Impersonating reports whether the request behind ctx runs in an
+// impersonated session. Views whose actions the impersonator must not take
+// (the target's session list, say) receive it as a closure.
+func (m *AuthModule) Impersonating(ctx context.Context) bool {
+	return m.impersonating(ctx, nil)
+}
+
+// impersonating reports whether the request behind ctx (or r, for handlers
+// under the session-excluded /auth/ prefix) runs in an impersonated
+// session. Used to refuse password and second-factor changes.
+func (m *AuthModule) impersonating(ctx context.Context, r *http.Request) bool {
+	if _, ok := ctx.Value(ctxKeyImpersonation).(*Impersonation); ok {
+		return true
+	}
+	store := m.deps.ImpersonationStore
+	if store == nil {
+		return false
+	}
+	token := ""
+	if id, ok := identity.FromContext(ctx); ok && id != nil {
+		token = id.SessionToken
+	}
+	if token == "" && r != nil {
+		if cookie, err := r.Cookie(m.deps.SessionCookieName); err == nil {
+			token = cookie.Value
+		}
+	}
+	if token == "" {
+		return false
+	}
+	imp, err := store.GetImpersonation(ctx, token)
+	if err != nil {
+		// Fail closed: these are the changes impersonation must never make.
+		log.Printf("[AUTH] impersonation: lookup failed: %v", err)
+		return true
+	}
+	return imp != nil
+}
+
+// workspacePrincipal picks the principal an impersonation switches into:
+// the first one in workspaceID that needs no acting-as choice.
+func workspacePrincipal(principals []Principal, workspaceID string) (Principal, bool) {
+	for _, p := range principals {
+		if p.WorkspaceID == workspaceID && DelegateActingAsResolved(p, "", "") {
+			return p, true
+		}
+	}
+	return Principal{}, false
+}
+
+// localPath returns the path and query of raw when it is a same-origin
+// path, "" otherwise — never an open redirect.
+func localPath(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Path == "" || !strings.HasPrefix(u.Path, "/") || strings.HasPrefix(u.Path, "//") {
+		return ""
+	}
+	if u.RawQuery != "" {
+		return u.Path + "?" + u.RawQuery
+	}
+	return u.Path
+}
+
+// redirectTopLevel redirects the browser, as a full navigation for HTMX
+// requests (see handleLogout).
+func redirectTopLevel(w http.ResponseWriter, r *http.Request, target string) {
+	if r.Header.Get("HX-Request") == "true" {
+		w.Header().Set("HX-Redirect", target)
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+// bufferedResponseWriter holds the response back so the banner can be
+// injected before it is sent.
+type bufferedResponseWriter struct {
+	http.ResponseWriter
+	status int
+	buf    bytes.Buffer
+}
+
+func (b *bufferedResponseWriter) WriteHeader(status int) {
+	if b.status == 0 {
+		b.status = status
+	}
+}
+
+func (b *bufferedResponseWriter) Write(p []byte) (int, error) {
+	if b.status == 0 {
+		b.status = http.StatusOK
+	}
+	return b.buf.Write(p)
+}
+
+// injectAfterBodyTag inserts snippet right after the opening <body> tag;
+// documents without one (fragments) are returned unchanged.
+func injectAfterBodyTag(doc, snippet []byte) []byte {
+	i := bytes.Index(bytes.ToLower(doc), []byte("<body"))
+	if i < 0 {
+		return doc
+	}
+	end := bytes.IndexByte(doc[i:], '>')
+	if end < 0 {
+		return doc
+	}
+	at := i + end + 1
+	out := make([]byte, 0, len(doc)+len(snippet))
+	out = append(out, doc[:at]...)
+	out = append(out, snippet...)
+	return append(out, doc[at:]...)
+}
+
+// MemoryImpersonationStore is an in-process ImpersonationStore for tests
+// and single-instance hosts.
+type MemoryImpersonationStore struct {
+	mu     sync.Mutex
+	active map[string]Impersonation
+	grants map[string]time.Time
+}
+
+// NewMemoryImpersonationStore returns an empty store.
+func NewMemoryImpersonationStore() *MemoryImpersonationStore {
+	return &MemoryImpersonationStore{active: make(map[string]Impersonation), grants: make(map[string]time.Time)}
+}
+
+func (s *MemoryImpersonationStore) SaveImpersonation(_ context.Context, imp Impersonation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.active[imp.Token] = imp
+	return nil
+}
+
+func (s *MemoryImpersonationStore) GetImpersonation(_ context.Context, token string) (*Impersonation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	imp, ok := s.active[token]
+	if !ok {
+		return nil, nil
+	}
+	return &imp, nil
+}
+
+func (s *MemoryImpersonationStore) DeleteImpersonation(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.active, token)
+	return nil
+}
+
+func (s *MemoryImpersonationStore) ConsumeImpersonationGrant(_ context.Context, id string, expires time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, exp := range s.grants {
+		if now.After(exp) {
+			delete(s.grants, k)
+		}
+	}
+	if _, ok := s.grants[id]; ok {
+		return false, nil
+	}
+	s.grants[id] = expires
+	return true, nil
+}
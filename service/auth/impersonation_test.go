@@ -0,0 +1,366 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+)
+
+// sessionAdapter is a passwordAdapter whose sessions validate to a user.
+type sessionAdapter struct {
+	*passwordAdapter
+	sessions map[string]string // token → user ID
+}
+
+func (a *sessionAdapter) ValidateSession(_ context.Context, token string) (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if userID, ok := a.sessions[token]; ok {
+		return userID, nil
+	}
+	return "", errors.New("invalid session")
+}
+
+type staticPrincipals map[string][]Principal
+
+func (s staticPrincipals) Resolve(_ context.Context, userID string) ([]Principal, error) {
+	return s[userID], nil
+}
+func (staticPrincipals) IsEnabled() bool { return true }
+
+// switchRecorder is a PrincipalSwitcher recording every audited switch.
+type switchRecorder struct {
+	mu    sync.Mutex
+	calls []PrincipalSwitchInput
+}
+
+func (s *switchRecorder) switchPrincipal(_ context.Context, in PrincipalSwitchInput) (*PrincipalSwitchResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, in)
+	return &PrincipalSwitchResult{}, nil
+}
+
+func (s *switchRecorder) last() PrincipalSwitchInput {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.calls) == 0 {
+		return PrincipalSwitchInput{}
+	}
+	return s.calls[len(s.calls)-1]
+}
+
+func newImpersonationTestModule(sessions *recordingSessionManager, switches *switchRecorder, timeout time.Duration) (*AuthModule, *sessionAdapter) {
+	adapter := &sessionAdapter{
+		passwordAdapter: &passwordAdapter{users: make(map[string]string)},
+		sessions:        map[string]string{"admin-tok": "admin", "other-tok": "other"},
+	}
+	staff := func(userID string) []Principal {
+		return []Principal{{Type: PrincipalTypeStaff, PrincipalID: "staff-" + userID, WorkspaceID: "ws-1"}}
+	}
+	grants := map[string][]effective.Grant{
+		"admin":   allowGrants("user:impersonate", "user:*", "invoice:read", "invoice:update"),
+		"user-2":  append(allowGrants("invoice:read", "user:update"), denyGrants("invoice:update")...),
+		"owner":   allowGrants("invoice:read"),
+		"peer":    allowGrants("user:*"),
+		"billing": allowGrants("invoice:*"),
+	}
+	return NewAuthModule(&Deps{
+		AuthAdapter:    adapter,
+		SessionManager: sessions,
+		Renderer:       nopRenderer{},
+		PrincipalResolver: staticPrincipals{
+			"admin":   staff("admin"),
+			"user-2":  staff("user-2"),
+			"owner":   {{Type: PrincipalTypeOperatorOwner, PrincipalID: "owner-1", WorkspaceID: "ws-1"}},
+			"peer":    staff("peer"),
+			"billing": staff("billing"),
+		},
+		PermissionGrants: func(_ context.Context, userID, workspaceID string) ([]effective.Grant, error) {
+			if workspaceID != "ws-1" {
+				return nil, nil
+			}
+			return grants[userID], nil
+		},
+		PermissionCatalog: func(context.Context, string) ([]string, error) {
+			return testPermissionCatalog, nil
+		},
+		PrincipalSwitcher: switches.switchPrincipal,
+		SessionMinter: func(_ context.Context, userID string) (string, error) {
+			adapter.mu.Lock()
+			defer adapter.mu.Unlock()
+			adapter.sessions["imp-"+userID] = userID
+			return "imp-" + userID, nil
+		},
+		WorkspaceSlugResolver: func(context.Context, string) string { return "acme" },
+		CSRFIssuer:            func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:            []byte("test-secret"),
+		AllowPasswordChange:   true,
+		MFAStore:              NewMemoryMFAStore(),
+		ImpersonationStore:    NewMemoryImpersonationStore(),
+		ImpersonationTimeout:  timeout,
+	}), adapter
+}
+
+var testPermissionCatalog = []string{
+	"user:impersonate", "user:read", "user:update",
+	"invoice:read", "invoice:update", "invoice:approve",
+}
+
+func allowGrants(codes ...string) []effective.Grant {
+	out := make([]effective.Grant, 0, len(codes))
+	for _, c := range codes {
+		out = append(out, effective.Grant{RoleID: "role", Code: c})
+	}
+	return out
+}
+
+func denyGrants(codes ...string) []effective.Grant {
+	out := allowGrants(codes...)
+	for i := range out {
+		out[i].Deny = true
+	}
+	return out
+}
+
+func sessionCookie(m *AuthModule, token string) []*http.Cookie {
+	return []*http.Cookie{{Name: m.deps.SessionCookieName, Value: token}}
+}
+
+func getWith(h http.Handler, target string, cookies []*http.Cookie, htmx bool) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, target, nil)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	if htmx {
+		req.Header.Set("HX-Request", "true")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// startImpersonation follows a start link in the administrator's browser.
+func startImpersonation(t *testing.T, m *AuthModule) string {
+	t.Helper()
+	link, err := m.ImpersonationURL(context.Background(), ImpersonationRequest{
+		ActorUserID:  "admin",
+		TargetUserID: "user-2",
+		TargetName:   "Rosa Diaz",
+		WorkspaceID:  "ws-1",
+		Reason:       "  Ticket #42: invoice totals look wrong ",
+		ReturnURL:    "https://app.example.com/w/acme/app/users/detail/user-2?tab=security",
+	})
+	if err != nil || !strings.HasPrefix(link, entydad.AuthImpersonateURL+"?grant=") {
+		t.Fatalf("ImpersonationURL = %q, %v", link, err)
+	}
+	return link
+}
+
+func TestImpersonation_StartBannerStop(t *testing.T) {
+	sessions := &recordingSessionManager{}
+	switches := &switchRecorder{}
+	m, adapter := newImpersonationTestModule(sessions, switches, 0)
+	ctx := context.Background()
+
+	if _, err := m.ImpersonationURL(ctx, ImpersonationRequest{ActorUserID: "admin", TargetUserID: "user-2", WorkspaceID: "ws-1", Reason: " "}); !errors.Is(err, ErrImpersonationReason) {
+		t.Fatalf("blank reason: err = %v", err)
+	}
+	if _, err := m.ImpersonationURL(ctx, ImpersonationRequest{ActorUserID: "admin", TargetUserID: "admin", WorkspaceID: "ws-1", Reason: "x"}); !errors.Is(err, ErrImpersonationTarget) {
+		t.Fatalf("self: err = %v", err)
+	}
+
+	link := startImpersonation(t, m)
+	start := m.handleImpersonateStart()
+
+	// The link is bound to the administrator's own session.
+	rec := getWith(start, link, sessionCookie(m, "other-tok"), false)
+	if loc := rec.Header().Get("Location"); loc != entydad.AuthLoginURL || len(switches.calls) != 0 {
+		t.Fatalf("foreign session: Location = %q, switches = %d", loc, len(switches.calls))
+	}
+
+	rec = getWith(start, link, sessionCookie(m, "admin-tok"), false)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != "/w/acme/home" || sessions.token != "imp-user-2" {
+		t.Fatalf("start: %d Location=%q cookie=%q", rec.Code, rec.Header().Get("Location"), sessions.token)
+	}
+	got := switches.last()
+	if got.UseCase != "impersonate_start" || !got.RequireAudit || got.UserID != "user-2" || got.ImpersonatorUserID != "admin" ||
+		got.Reason != "Ticket #42: invoice totals look wrong" || got.TargetPrincipal.PrincipalID != "staff-user-2" {
+		t.Fatalf("start switch = %+v", got)
+	}
+
+	// Single use.
+	getWith(start, link, sessionCookie(m, "admin-tok"), false)
+	if len(switches.calls) != 1 {
+		t.Fatalf("replayed link switched again: %d switches", len(switches.calls))
+	}
+
+	// Every full page carries the banner; fragments and other workspaces do not.
+	var mfaErr error
+	app := m.ImpersonationMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _, mfaErr = m.MFABeginSetup(r.Context(), "user-2", "rosa@example.com")
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(`<html><body class="app"><main>home</main></body></html>`))
+	}))
+	imp := sessionCookie(m, "imp-user-2")
+	rec = getWith(app, "/w/acme/home", imp, false)
+	body := rec.Body.String()
+	if !strings.Contains(body, `<body class="app"><div class="alert alert-warning`) || !strings.Contains(body, "Viewing as Rosa Diaz") ||
+		!strings.Contains(body, `action="`+entydad.AuthImpersonateStopURL+`"`) {
+		t.Fatalf("banner missing: %s", body)
+	}
+	if !errors.Is(mfaErr, ErrImpersonating) {
+		t.Fatalf("MFABeginSetup while impersonating: err = %v", mfaErr)
+	}
+	if rec = getWith(app, "/w/acme/home", imp, true); strings.Contains(rec.Body.String(), "impersonation-banner") {
+		t.Fatalf("banner injected into an HTMX fragment: %s", rec.Body.String())
+	}
+	if rec = getWith(app, "/w/globex/home", imp, false); rec.Header().Get("Location") != "/w/acme/home" {
+		t.Fatalf("other workspace: %d Location=%q", rec.Code, rec.Header().Get("Location"))
+	}
+	for _, path := range []string{"/me/sessions", "/me"} {
+		if rec = getWith(app, path, imp, false); rec.Header().Get("Location") != "/w/acme/home" {
+			t.Fatalf("%s: %d Location=%q", path, rec.Code, rec.Header().Get("Location"))
+		}
+	}
+	if rec = postForm(app.ServeHTTP, "/me/sessions/revoke-others", nil, imp); rec.Header().Get("Location") != "/w/acme/home" {
+		t.Fatalf("POST /me/sessions/revoke-others: %d Location=%q", rec.Code, rec.Header().Get("Location"))
+	}
+	if rec = getWith(app, "/w/acme/home", sessionCookie(m, "admin-tok"), false); strings.Contains(rec.Body.String(), "impersonation-banner") {
+		t.Fatal("banner shown on a normal session")
+	}
+
+	// No password changes while impersonating.
+	form := url.Values{"old_password": {"a"}, "new_password": {"b"}, "confirm_password": {"b"}}
+	rec = postForm(m.handleChangePassword(), entydad.AuthChangePasswordURL, form, imp)
+	if loc := rec.Header().Get("Location"); loc != entydad.AuthChangePasswordURL+"?error=impersonating" {
+		t.Fatalf("change password: Location = %q", loc)
+	}
+
+	// Stop returns the administrator to their own session and page.
+	rec = postForm(m.handleImpersonateStop(), entydad.AuthImpersonateStopURL, nil, imp)
+	if loc := rec.Header().Get("Location"); loc != "/w/acme/app/users/detail/user-2?tab=security" || sessions.token != "admin-tok" {
+		t.Fatalf("stop: Location=%q cookie=%q", loc, sessions.token)
+	}
+	if got := switches.last(); got.UseCase != "impersonate_stop" || !got.RequireAudit || got.UserID != "admin" {
+		t.Fatalf("stop switch = %+v", got)
+	}
+	if len(adapter.invalidated) != 1 || adapter.invalidated[0] != "imp-user-2" {
+		t.Fatalf("invalidated = %v", adapter.invalidated)
+	}
+	if m.impersonating(ctx, httptest.NewRequest(http.MethodGet, "/", nil)) {
+		t.Fatal("still impersonating after stop")
+	}
+}
+
+func TestImpersonation_Timeout(t *testing.T) {
+	sessions := &recordingSessionManager{}
+	switches := &switchRecorder{}
+	m, adapter := newImpersonationTestModule(sessions, switches, time.Millisecond)
+
+	getWith(m.handleImpersonateStart(), startImpersonation(t, m), sessionCookie(m, "admin-tok"), false)
+	if sessions.token != "imp-user-2" {
+		t.Fatalf("start: cookie = %q", sessions.token)
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	served := false
+	app := m.ImpersonationMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { served = true }))
+	rec := getWith(app, "/w/acme/home", sessionCookie(m, "imp-user-2"), true)
+	if served || rec.Header().Get("HX-Redirect") != "/w/acme/app/users/detail/user-2?tab=security" || sessions.token != "admin-tok" {
+		t.Fatalf("timeout: served=%v HX-Redirect=%q cookie=%q", served, rec.Header().Get("HX-Redirect"), sessions.token)
+	}
+	if got := switches.last(); got.UseCase != "impersonate_timeout" || !got.RequireAudit {
+		t.Fatalf("timeout switch = %+v", got)
+	}
+	if len(adapter.invalidated) != 1 || adapter.invalidated[0] != "imp-user-2" {
+		t.Fatalf("invalidated = %v", adapter.invalidated)
+	}
+}
+
+func TestImpersonation_TargetReach(t *testing.T) {
+	sessions := &recordingSessionManager{}
+	switches := &switchRecorder{}
+	m, _ := newImpersonationTestModule(sessions, switches, 0)
+	ctx := context.Background()
+
+	for _, target := range []string{
+		"owner",   // workspace owner
+		"peer",    // another administrator, through user:*
+		"billing", // invoice:* covers invoice:approve, which the actor lacks
+		"nobody",  // no principal in the workspace
+	} {
+		_, err := m.ImpersonationURL(ctx, ImpersonationRequest{ActorUserID: "admin", TargetUserID: target, WorkspaceID: "ws-1", Reason: "x"})
+		if !errors.Is(err, ErrImpersonationTarget) {
+			t.Errorf("%s: err = %v, want ErrImpersonationTarget", target, err)
+		}
+	}
+
+	// The check runs again when the link is followed: here the target
+	// gained a permission after the link was issued.
+	link := startImpersonation(t, m)
+	m.deps.PermissionGrants = func(_ context.Context, userID, _ string) ([]effective.Grant, error) {
+		if userID == "user-2" {
+			return allowGrants("invoice:read", "invoice:approve"), nil
+		}
+		return allowGrants("user:impersonate", "invoice:read"), nil
+	}
+	rec := getWith(m.handleImpersonateStart(), link, sessionCookie(m, "admin-tok"), false)
+	if rec.Header().Get("Location") != "/w/acme/home" || len(switches.calls) != 0 || sessions.token == "imp-user-2" {
+		t.Fatalf("start after escalation: Location=%q switches=%d cookie=%q", rec.Header().Get("Location"), len(switches.calls), sessions.token)
+	}
+}
+
+func TestPermissionsBeyond(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name          string
+		target, actor []effective.Grant
+		want          string
+	}{
+		{
+			name:   "actor wildcard covers concrete codes",
+			target: allowGrants("user:update", "invoice:read"),
+			actor:  allowGrants("user:*", "*:read"),
+			want:   "",
+		},
+		{
+			name:   "target wildcard expands over the catalog",
+			target: allowGrants("invoice:*"),
+			actor:  allowGrants("invoice:read", "invoice:update"),
+			want:   "invoice:approve",
+		},
+		{
+			name:   "actor DENY beats the actor's ALLOW",
+			target: allowGrants("invoice:update"),
+			actor:  append(allowGrants("invoice:*"), denyGrants("invoice:update")...),
+			want:   "invoice:update",
+		},
+		{
+			name:   "target DENY only narrows the target",
+			target: append(allowGrants("invoice:*"), denyGrants("invoice:approve")...),
+			actor:  allowGrants("invoice:read", "invoice:update"),
+			want:   "",
+		},
+		{
+			name:   "a target code outside the catalog still counts",
+			target: allowGrants("report:export"),
+			actor:  allowGrants("invoice:*"),
+			want:   "report:export",
+		},
+	} {
+		got := permissionsBeyond(effective.New(tc.target), effective.New(tc.actor), testPermissionCatalog)
+		if strings.Join(got, ",") != tc.want {
+			t.Errorf("%s: permissionsBeyond = %v, want %q", tc.name, got, tc.want)
+		}
+	}
+}
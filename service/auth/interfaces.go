@@ -68,6 +68,16 @@ type Renderer interface {
 // include a user ID (mock providers). Injected as a closure.
 type UserIDByEmail func(ctx context.Context, email string) (userID string)
 
+// EmailByUserID is the reverse lookup, for sign-ins that prove a user
+// without an address (passkeys). Injected as a closure.
+type EmailByUserID func(ctx context.Context, userID string) (email string)
+
+// DeleteUser removes a user that a signup registered moments earlier when
+// the rest of that signup failed (the unverified mark, the invitation join),
+// so no half set-up account is left able to sign in. Injected as a closure
+// over the host's user repository.
+type DeleteUser func(ctx context.Context, userID string) error
+
 // FirebaseVerifier verifies a Firebase ID token and returns the signed-in
 // user's email plus the firebase.sign_in_provider claim (e.g. "microsoft.com",
 // "google.com", "password"). Returns an error for an invalid/expired token.
@@ -87,6 +97,12 @@ type SessionMinter func(ctx context.Context, userID string) (token string, err e
 // constructing post-login redirect URLs (/w/{slug}/home).
 type WorkspaceSlugResolver func(ctx context.Context, workspaceID string) (slug string)
 
+// PasswordResetTokenUser resolves the user a password-reset token was
+// issued for, without consuming it. Optional: when set, the reset confirm
+// step also applies the PasswordPolicy no-reuse and must-not-contain-email
+// rules. Returns empty strings for an unknown or expired token.
+type PasswordResetTokenUser func(ctx context.Context, token string) (userID, email string)
+
 // RouteRegistrar extends pyeza's view.RouteRegistrar with HandleFunc for
 // raw http.HandlerFunc registration. The auth module needs both: GET
 // (for view-based routes like login/signup pages) and HandleFunc (for
@@ -0,0 +1,514 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	acceptinvitemod "github.com/erniealice/entydad-golang/service/auth/views/login02/accept-invite"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+var (
+	ErrInvitationsDisabled = errors.New("auth: invitations are not configured")
+	ErrInvitationNotFound  = errors.New("auth: invitation not found")
+	ErrInvitationPending   = errors.New("auth: this address already has a pending invitation")
+	ErrInvitationEmail     = errors.New("auth: invalid invitation email address")
+)
+
+// Invitation is a pending invitation to join a workspace. It lives until the
+// invitee accepts it or an administrator revokes it; once ExpiresAt passes
+// it can only be resent.
+type Invitation struct {
+	ID          string
+	WorkspaceID string
+	Workspace   string // workspace name, for the email and the accept page
+	Email       string
+	RoleIDs     []string
+	InvitedBy   string // inviter's user ID
+	InviterName string // inviter's display name
+	CreatedAt   time.Time
+	// SentAt is the last (re)send. Links carry it to the microsecond, so
+	// resending retires every earlier link; stores must keep that precision.
+	SentAt    time.Time
+	ExpiresAt time.Time
+}
+
+// InvitationStore persists pending invitations.
+type InvitationStore interface {
+	CreateInvitation(ctx context.Context, inv Invitation) error
+	// GetInvitation returns ErrInvitationNotFound for an unknown (accepted,
+	// revoked) invitation.
+	GetInvitation(ctx context.Context, id string) (Invitation, error)
+	// ListInvitations returns the workspace's pending invitations,
+	// expired ones included.
+	ListInvitations(ctx context.Context, workspaceID string) ([]Invitation, error)
+	UpdateInvitation(ctx context.Context, inv Invitation) error
+	DeleteInvitation(ctx context.Context, id string) error
+	// AcceptInvitation joins userID to the invitation's workspace — the
+	// workspace_user row plus one workspace_user_role row per RoleIDs —
+	// and removes the invitation, all in one transaction: either everything
+	// happens or nothing does. Returns ErrInvitationNotFound when the
+	// invitation is no longer pending, so a link works once.
+	AcceptInvitation(ctx context.Context, id, userID string) error
+}
+
+// InvitationInput is the administrator's side of a new invitation.
+type InvitationInput struct {
+	WorkspaceID string
+	Workspace   string
+	Email       string
+	RoleIDs     []string
+	InvitedBy   string
+	InviterName string
+}
+
+const (
+	// invitationTTL is how long an emailed invitation stays valid.
+	invitationTTL = 7 * 24 * time.Hour
+
+	linkPurposeInvitation = "invitation"
+)
+
+// invitationClaims is the payload of the emailed link. Everything else is
+// read from the store, so revoking or resending takes effect immediately.
+type invitationClaims struct {
+	ID   string `json:"i"`
+	Sent int64  `json:"s"`
+}
+
+// invitationsEnabled reports whether invitations can be mailed and accepted.
+func (m *AuthModule) invitationsEnabled() bool {
+	return m.deps.Invitations != nil && m.mailEnabled() &&
+		m.deps.AuthAdapter != nil && m.deps.SessionManager != nil
+}
+
+// InviteToWorkspace records a pending invitation and mails its accept link.
+// Wired into the workspace detail page's Invitations tab by the host.
+func (m *AuthModule) InviteToWorkspace(ctx context.Context, in InvitationInput) (Invitation, error) {
+	if !m.invitationsEnabled() {
+		return Invitation{}, ErrInvitationsDisabled
+	}
+	addr, err := mail.ParseAddress(strings.TrimSpace(in.Email))
+	if err != nil || addr.Name != "" {
+		return Invitation{}, ErrInvitationEmail
+	}
+	email := strings.ToLower(addr.Address)
+	pending, err := m.deps.Invitations.ListInvitations(ctx, in.WorkspaceID)
+	if err != nil {
+		return Invitation{}, err
+	}
+	for _, p := range pending {
+		if strings.EqualFold(p.Email, email) {
+			return Invitation{}, ErrInvitationPending
+		}
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Invitation{}, err
+	}
+	now := time.Now()
+	inv := Invitation{
+		ID:          hex.EncodeToString(id),
+		WorkspaceID: in.WorkspaceID,
+		Workspace:   in.Workspace,
+		Email:       email,
+		RoleIDs:     in.RoleIDs,
+		InvitedBy:   in.InvitedBy,
+		InviterName: in.InviterName,
+		CreatedAt:   now,
+		SentAt:      now,
+		ExpiresAt:   now.Add(invitationTTL),
+	}
+	if err := m.deps.Invitations.CreateInvitation(ctx, inv); err != nil {
+		return Invitation{}, err
+	}
+	log.Printf("[AUTH] invitation %s: %s invited to workspace %s by %s", inv.ID, email, inv.WorkspaceID, inv.InvitedBy)
+	return inv, m.sendInvitation(ctx, inv)
+}
+
+// ListInvitations returns the workspace's pending invitations, oldest first.
+func (m *AuthModule) ListInvitations(ctx context.Context, workspaceID string) ([]Invitation, error) {
+	if !m.invitationsEnabled() {
+		return nil, ErrInvitationsDisabled
+	}
+	return m.deps.Invitations.ListInvitations(ctx, workspaceID)
+}
+
+// ResendInvitation mails a fresh link and restarts the expiry; earlier links
+// stop working.
+func (m *AuthModule) ResendInvitation(ctx context.Context, workspaceID, id string) error {
+	inv, err := m.workspaceInvitation(ctx, workspaceID, id)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	inv.SentAt, inv.ExpiresAt = now, now.Add(invitationTTL)
+	if err := m.deps.Invitations.UpdateInvitation(ctx, inv); err != nil {
+		return err
+	}
+	return m.sendInvitation(ctx, inv)
+}
+
+// RevokeInvitation withdraws a pending invitation; its links stop working.
+func (m *AuthModule) RevokeInvitation(ctx context.Context, workspaceID, id string) error {
+	if _, err := m.workspaceInvitation(ctx, workspaceID, id); err != nil {
+		return err
+	}
+	log.Printf("[AUTH] invitation %s revoked (workspace %s)", id, workspaceID)
+	return m.deps.Invitations.DeleteInvitation(ctx, id)
+}
+
+// workspaceInvitation loads id, refusing invitations of another workspace.
+func (m *AuthModule) workspaceInvitation(ctx context.Context, workspaceID, id string) (Invitation, error) {
+	if !m.invitationsEnabled() {
+		return Invitation{}, ErrInvitationsDisabled
+	}
+	inv, err := m.deps.Invitations.GetInvitation(ctx, id)
+	if err != nil {
+		return Invitation{}, err
+	}
+	if inv.WorkspaceID != workspaceID {
+		return Invitation{}, ErrInvitationNotFound
+	}
+	return inv, nil
+}
+
+// sendInvitation mails inv's accept link.
+func (m *AuthModule) sendInvitation(ctx context.Context, inv Invitation) error {
+	claims := invitationClaims{ID: inv.ID, Sent: inv.SentAt.UnixMicro()}
+	token, err := m.signLinkToken(linkPurposeInvitation, claims, time.Until(inv.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	link := m.publicURL(entydad.AuthAcceptInviteURL + "?token=" + url.QueryEscape(token))
+	return m.sendMail(ctx, MailInvitation, MailData{
+		To:        inv.Email,
+		Link:      link,
+		Workspace: inv.Workspace,
+		InvitedBy: inv.InviterName,
+	})
+}
+
+// openInvitation resolves an emailed token to its still-pending, unexpired
+// invitation.
+func (m *AuthModule) openInvitation(ctx context.Context, token string) (Invitation, bool) {
+	var c invitationClaims
+	if !m.openLinkToken(linkPurposeInvitation, token, &c) || c.ID == "" {
+		return Invitation{}, false
+	}
+	inv, err := m.deps.Invitations.GetInvitation(ctx, c.ID)
+	if err != nil {
+		if !errors.Is(err, ErrInvitationNotFound) {
+			log.Printf("[AUTH] accept-invite: lookup failed for invitation %s: %v", c.ID, err)
+		}
+		return Invitation{}, false
+	}
+	if inv.SentAt.UnixMicro() != c.Sent || time.Now().After(inv.ExpiresAt) {
+		return Invitation{}, false
+	}
+	return inv, true
+}
+
+// handleAcceptInvitePage returns the GET /auth/accept-invite handler.
+func (m *AuthModule) handleAcceptInvitePage(page *acceptinvitemod.Deps) http.HandlerFunc {
+	v := acceptinvitemod.NewView(page)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		state := acceptinvitemod.State{ErrorCode: q.Get("error")}
+		if inv, ok := m.openInvitation(r.Context(), q.Get("token")); ok {
+			state.Token = q.Get("token")
+			state.Workspace = inv.Workspace
+			state.Inviter = inv.InviterName
+			state.Email = inv.Email
+			state.HasAccount = m.userIDForEmail(r.Context(), inv.Email) != ""
+			state.SSOURL = m.inviteSSOURL(r.Context(), inv.Email, state.Token)
+		}
+		// The token sits in the URL: keep it out of Referer headers.
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("Cache-Control", "no-store")
+		result := v.Handle(acceptinvitemod.WithState(r.Context(), state), &view.ViewContext{
+			Request:     r,
+			CurrentPath: r.URL.Path,
+		})
+		m.renderAuthView(w, r, result)
+	}
+}
+
+// handleAcceptInvite returns the POST /auth/accept-invite handler. An
+// invitee with an account proves it with their password; anyone else is
+// registered with the invited address. Either way the invitation is then
+// accepted (workspace_user + roles, atomically) and the sign-in continues
+// through two-step verification and routePrincipals — the new workspace is
+// among the principals. An invitee whose domain requires SSO is sent to
+// its identity provider, and the OIDC callback accepts the invitation.
+func (m *AuthModule) handleAcceptInvite() http.HandlerFunc {
+	authAdapter := m.deps.AuthAdapter
+	sessionMw := m.deps.SessionManager
+	principalLoader := m.deps.PrincipalResolver
+	limiter := m.deps.LoginAttemptLimiter
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+		token := r.FormValue("token")
+		inv, ok := m.openInvitation(r.Context(), token)
+		if !ok {
+			http.Redirect(w, r, entydad.AuthAcceptInviteURL, http.StatusSeeOther)
+			return
+		}
+		back := func(code string) {
+			http.Redirect(w, r, entydad.AuthAcceptInviteURL+"?token="+url.QueryEscape(token)+"&error="+code, http.StatusSeeOther)
+		}
+		email := inv.Email
+		password := r.FormValue("password")
+		// Home realm: accepting signs in with a password, which an
+		// SSO-required domain refuses before the account is touched.
+		if sso := m.inviteSSOURL(r.Context(), email, token); sso != "" {
+			http.Redirect(w, r, sso, http.StatusSeeOther)
+			return
+		}
+		if m.ssoRequired(r.Context(), email) {
+			m.loginFailed(r, "", email, AuthFailureSSORequired)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=sso_required", http.StatusSeeOther)
+			return
+		}
+
+		sessionToken := ""
+		registered := false
+		userID := m.userIDForEmail(r.Context(), email)
+		if userID != "" {
+			attempt := LoginAttempt{Scope: LoginScopePassword, Email: email, IP: m.clientIP(r)}
+			decision := limiter.Check(r.Context(), attempt)
+			if code := limitedErrorCode(decision); code != "" {
+				log.Printf("[AUTH] accept-invite %s for %s from %s", code, email, attempt.IP)
+				back(code)
+				return
+			}
+			if !waitLoginDelay(r.Context(), decision.Delay) {
+				return
+			}
+			tok, _, err := authAdapter.Login(r.Context(), email, password)
+			if err != nil {
+				log.Printf("[AUTH] accept-invite: login failed for %s: %v", email, err)
+				code := "invalid"
+				if limiter.RecordFailure(r.Context(), attempt).Locked {
+					code = "locked"
+				}
+				back(code)
+				return
+			}
+			limiter.RecordSuccess(r.Context(), attempt)
+			sessionToken = tok
+		} else {
+			if password != r.FormValue("confirm_password") {
+				back("mismatch")
+				return
+			}
+			if code := m.deps.PasswordPolicy.Check(r.Context(), "", email, password); code != "" {
+				back(code)
+				return
+			}
+			attempt := LoginAttempt{Scope: LoginScopeSignup, Email: email, IP: m.clientIP(r)}
+			if limitedErrorCode(limiter.Check(r.Context(), attempt)) != "" {
+				log.Printf("[AUTH] accept-invite: signup throttled for %s from %s", email, attempt.IP)
+				back("throttled")
+				return
+			}
+			newID, err := authAdapter.Register(r.Context(), email, password, r.FormValue("first_name"), r.FormValue("last_name"), "")
+			if err != nil {
+				log.Printf("[AUTH] accept-invite: register failed for %s: %v", email, err)
+				limiter.RecordFailure(r.Context(), attempt)
+				back(classifySignupError(err))
+				return
+			}
+			limiter.RecordSuccess(r.Context(), attempt)
+			m.deps.PasswordPolicy.Remember(r.Context(), newID, password)
+			userID, registered = newID, true
+		}
+
+		if err := m.joinInvitation(r.Context(), inv, userID, registered); err != nil {
+			if sessionToken != "" {
+				m.abandonSession(r.Context(), sessionToken)
+			}
+			if errors.Is(err, ErrInvitationNotFound) {
+				http.Redirect(w, r, entydad.AuthAcceptInviteURL, http.StatusSeeOther)
+				return
+			}
+			log.Printf("[AUTH] accept-invite: join failed for user %s (invitation %s): %v", userID, inv.ID, err)
+			back("generic")
+			return
+		}
+
+		if sessionToken == "" {
+			// Registered and joined — sign the new account in.
+			tok, _, err := authAdapter.Login(r.Context(), email, password)
+			if err != nil {
+				http.Redirect(w, r, entydad.AuthLoginURL+"?registered=true", http.StatusSeeOther)
+				return
+			}
+			sessionToken = tok
+		}
+		if target, pending := m.parkLogin(w, r, sessionToken, userID, email); pending {
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
+		sessionMw.SetSessionCookie(w, sessionToken)
+		m.deps.CSRFIssuer(w, m.deps.CSRFSecret, sessionToken, "")
+
+		if principalLoader == nil || !principalLoader.IsEnabled() {
+			m.signedIn(w, r, sessionToken, userID)
+			http.Redirect(w, r, entydad.DefaultAppRedirectURL, http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, m.routePrincipals(w, r, sessionToken, userID), http.StatusSeeOther)
+	}
+}
+
+// joinInvitation accepts inv for userID and marks the invited address
+// verified: the emailed link proves control of it. When userID was
+// registered for this invitation, a failed join discards the account, so
+// no user is left behind without the membership it was made for.
+func (m *AuthModule) joinInvitation(ctx context.Context, inv Invitation, userID string, registered bool) error {
+	if err := m.deps.Invitations.AcceptInvitation(ctx, inv.ID, userID); err != nil {
+		if registered {
+			m.discardRegistration(ctx, userID)
+		}
+		return err
+	}
+	log.Printf("[AUTH] invitation %s accepted: user=%s workspace=%s", inv.ID, userID, inv.WorkspaceID)
+	if m.emailVerificationEnabled() {
+		if err := m.deps.EmailVerification.SetEmailVerified(ctx, userID, true); err != nil {
+			log.Printf("[AUTH] accept-invite: could not mark user %s verified: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+// inviteSSOURL is where an invitee whose domain requires single sign-on
+// accepts: the realm's OIDC provider, carrying the invitation token through
+// the round trip. Empty when the domain allows passwords, or when its
+// provider is not a configured OIDC provider.
+func (m *AuthModule) inviteSSOURL(ctx context.Context, email, token string) string {
+	realm, ok := m.homeRealmFor(ctx, email)
+	if !ok || !realm.SSORequired || realm.Provider == "" || !m.oidcEnabled() {
+		return ""
+	}
+	if _, found := m.oidc[realm.Provider]; !found {
+		return ""
+	}
+	q := url.Values{"provider": {realm.Provider}, "login_hint": {email}, "invite": {token}}
+	return entydad.AuthOIDCStartURL + "?" + q.Encode()
+}
+
+// registerSSOInvitee creates the account of an invitee who signed in
+// through their identity provider. The password is random and never told:
+// the SSO-required domain refuses password sign-in anyway.
+func (m *AuthModule) registerSSOInvitee(ctx context.Context, email string, claims map[string]any) (string, error) {
+	password := oidcRandom()
+	if password == "" {
+		return "", errors.New("auth: no entropy for the invitee's password")
+	}
+	first, _ := claims["given_name"].(string)
+	last, _ := claims["family_name"].(string)
+	return m.deps.AuthAdapter.Register(ctx, email, password, first, last, "")
+}
+
+// JoinWorkspaceFunc creates the workspace_user row for userID in
+// workspaceID plus one workspace_user_role row per roleIDs, in one
+// transaction.
+type JoinWorkspaceFunc func(ctx context.Context, userID, workspaceID string, roleIDs []string) error
+
+// MemoryInvitationStore is an in-process InvitationStore for tests and
+// single-instance hosts. Accepting calls Join under the store lock, so an
+// invitation is used at most once; Join itself must be atomic.
+type MemoryInvitationStore struct {
+	mu          sync.Mutex
+	join        JoinWorkspaceFunc
+	invitations map[string]Invitation
+}
+
+// NewMemoryInvitationStore returns an empty store that joins accepted
+// invitees through join.
+func NewMemoryInvitationStore(join JoinWorkspaceFunc) *MemoryInvitationStore {
+	return &MemoryInvitationStore{join: join, invitations: make(map[string]Invitation)}
+}
+
+func (s *MemoryInvitationStore) CreateInvitation(_ context.Context, inv Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.invitations[inv.ID] = inv
+	return nil
+}
+
+func (s *MemoryInvitationStore) GetInvitation(_ context.Context, id string) (Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invitations[id]
+	if !ok {
+		return Invitation{}, ErrInvitationNotFound
+	}
+	return inv, nil
+}
+
+func (s *MemoryInvitationStore) ListInvitations(_ context.Context, workspaceID string) ([]Invitation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []Invitation
+	for _, inv := range s.invitations {
+		if inv.WorkspaceID == workspaceID {
+			out = append(out, inv)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.Before(out[j].CreatedAt) })
+	return out, nil
+}
+
+func (s *MemoryInvitationStore) UpdateInvitation(_ context.Context, inv Invitation) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.invitations[inv.ID]; !ok {
+		return ErrInvitationNotFound
+	}
+	s.invitations[inv.ID] = inv
+	return nil
+}
+
+func (s *MemoryInvitationStore) DeleteInvitation(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.invitations[id]; !ok {
+		return ErrInvitationNotFound
+	}
+	delete(s.invitations, id)
+	return nil
+}
+
+func (s *MemoryInvitationStore) AcceptInvitation(ctx context.Context, id, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	inv, ok := s.invitations[id]
+	if !ok {
+		return ErrInvitationNotFound
+	}
+	if s.join != nil {
+		if err := s.join(ctx, userID, inv.WorkspaceID, inv.RoleIDs); err != nil {
+			return err
+		}
+	}
+	delete(s.invitations, id)
+	return nil
+}
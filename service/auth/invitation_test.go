@@ -0,0 +1,270 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	entydad "github.com/erniealice/entydad-golang"
+	acceptinvitemod "github.com/erniealice/entydad-golang/service/auth/views/login02/accept-invite"
+)
+
+// joinRecorder is a JoinWorkspaceFunc recording the memberships it creates.
+type joinRecorder struct {
+	mu     sync.Mutex
+	joined []string // "user workspace role,role"
+}
+
+func (j *joinRecorder) join(_ context.Context, userID, workspaceID string, roleIDs []string) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.joined = append(j.joined, userID+" "+workspaceID+" "+strings.Join(roleIDs, ","))
+	return nil
+}
+
+func newInvitationTestModule(outbox Mailer, sessions *recordingSessionManager, join JoinWorkspaceFunc) (*AuthModule, *passwordAdapter) {
+	adapter := &passwordAdapter{users: map[string]string{"old@example.com": "old-pass"}}
+	return NewAuthModule(&Deps{
+		AuthAdapter:    adapter,
+		SessionManager: sessions,
+		Renderer:       nopRenderer{},
+		UserIDByEmail: func(_ context.Context, email string) string {
+			adapter.mu.Lock()
+			defer adapter.mu.Unlock()
+			if _, ok := adapter.users[email]; ok {
+				return "user-" + email
+			}
+			return ""
+		},
+		CSRFIssuer:    func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:    []byte("test-secret"),
+		Mailer:        outbox,
+		PublicBaseURL: "https://app.example.com",
+		Invitations:   NewMemoryInvitationStore(join),
+	}), adapter
+}
+
+func inviteToken(t *testing.T, outbox *MemoryOutbox, to string) string {
+	t.Helper()
+	msg, ok := outbox.Last(to)
+	link, err := url.Parse(msg.Link)
+	if !ok || err != nil || msg.Kind != MailInvitation || link.Path != entydad.AuthAcceptInviteURL {
+		t.Fatalf("invitation mail to %s: %+v", to, msg)
+	}
+	if !strings.Contains(msg.Subject, "Acme") || !strings.Contains(msg.Text, "Rosa") {
+		t.Fatalf("invitation mail copy: %q / %q", msg.Subject, msg.Text)
+	}
+	return link.Query().Get("token")
+}
+
+func TestInvitation_SignUpAndJoinOnce(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	sessions := &recordingSessionManager{}
+	joins := &joinRecorder{}
+	m, _ := newInvitationTestModule(outbox, sessions, joins.join)
+	ctx := context.Background()
+
+	in := InvitationInput{WorkspaceID: "ws-1", Workspace: "Acme", Email: "New@Example.com", RoleIDs: []string{"r-admin"}, InviterName: "Rosa"}
+	if _, err := m.InviteToWorkspace(ctx, in); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := m.InviteToWorkspace(ctx, in); !errors.Is(err, ErrInvitationPending) {
+		t.Fatalf("second invite: %v, want ErrInvitationPending", err)
+	}
+	token := inviteToken(t, outbox, "new@example.com")
+
+	// The page offers signup: the address has no account yet.
+	page := httptest.NewRecorder()
+	m.handleAcceptInvitePage(&acceptinvitemod.Deps{})(page, httptest.NewRequest(http.MethodGet, entydad.AuthAcceptInviteURL+"?token="+url.QueryEscape(token), nil))
+	if page.Code != http.StatusOK || page.Body.String() != "accept-invite" {
+		t.Fatalf("accept page: %d %q", page.Code, page.Body.String())
+	}
+
+	form := url.Values{"token": {token}, "password": {"s3cret-pass"}, "confirm_password": {"other"}}
+	rec := postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, form, nil)
+	if got := rec.Header().Get("Location"); !strings.HasSuffix(got, "&error=mismatch") || len(joins.joined) != 0 {
+		t.Fatalf("mismatch: → %q joined %v", got, joins.joined)
+	}
+
+	form.Set("confirm_password", "s3cret-pass")
+	rec = postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, form, nil)
+	if got := rec.Header().Get("Location"); got != entydad.DefaultAppRedirectURL || sessions.token != "session-new@example.com" {
+		t.Fatalf("accept: → %q session %q", got, sessions.token)
+	}
+	if len(joins.joined) != 1 || joins.joined[0] != "user-new@example.com ws-1 r-admin" {
+		t.Fatalf("joined %v", joins.joined)
+	}
+
+	// The link works once.
+	sessions.token = ""
+	rec = postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, form, nil)
+	if got := rec.Header().Get("Location"); got != entydad.AuthAcceptInviteURL || sessions.token != "" || len(joins.joined) != 1 {
+		t.Fatalf("replay: → %q session %q joined %v", got, sessions.token, joins.joined)
+	}
+}
+
+func TestInvitation_ExistingUserResendAndRevoke(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	sessions := &recordingSessionManager{}
+	joins := &joinRecorder{}
+	m, _ := newInvitationTestModule(outbox, sessions, joins.join)
+	ctx := context.Background()
+
+	inv, err := m.InviteToWorkspace(ctx, InvitationInput{WorkspaceID: "ws-1", Workspace: "Acme", Email: "old@example.com", InviterName: "Rosa"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	first := inviteToken(t, outbox, "old@example.com")
+
+	// Another workspace cannot touch it; a resend retires the first link.
+	if err := m.ResendInvitation(ctx, "ws-2", inv.ID); !errors.Is(err, ErrInvitationNotFound) {
+		t.Fatalf("cross-workspace resend: %v", err)
+	}
+	if err := m.ResendInvitation(ctx, "ws-1", inv.ID); err != nil {
+		t.Fatal(err)
+	}
+	second := inviteToken(t, outbox, "old@example.com")
+	if _, ok := m.openInvitation(ctx, first); ok {
+		t.Fatal("first link still valid after resend")
+	}
+
+	// Existing account: the password is checked, then the user joins.
+	rec := postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, url.Values{"token": {second}, "password": {"wrong"}}, nil)
+	if got := rec.Header().Get("Location"); !strings.HasSuffix(got, "&error=invalid") || len(joins.joined) != 0 {
+		t.Fatalf("wrong password: → %q joined %v", got, joins.joined)
+	}
+	rec = postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, url.Values{"token": {second}, "password": {"old-pass"}}, nil)
+	if got := rec.Header().Get("Location"); got != entydad.DefaultAppRedirectURL || len(joins.joined) != 1 {
+		t.Fatalf("accept: → %q joined %v", got, joins.joined)
+	}
+
+	// Revoked invitations are gone from the list and their links are dead.
+	inv2, _ := m.InviteToWorkspace(ctx, InvitationInput{WorkspaceID: "ws-1", Workspace: "Acme", Email: "third@example.com", InviterName: "Rosa"})
+	token := inviteToken(t, outbox, "third@example.com")
+	if err := m.RevokeInvitation(ctx, "ws-1", inv2.ID); err != nil {
+		t.Fatal(err)
+	}
+	if list, _ := m.ListInvitations(ctx, "ws-1"); len(list) != 0 {
+		t.Fatalf("pending after revoke: %+v", list)
+	}
+	if _, ok := m.openInvitation(ctx, token); ok {
+		t.Fatal("revoked link still valid")
+	}
+}
+
+func TestInvitation_FailedJoinDiscardsSignup(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	sessions := &recordingSessionManager{}
+	m, _ := newInvitationTestModule(outbox, sessions, func(context.Context, string, string, []string) error {
+		return errors.New("workspace_user insert failed")
+	})
+	var deleted []string
+	m.deps.DeleteUser = func(_ context.Context, userID string) error {
+		deleted = append(deleted, userID)
+		return nil
+	}
+	ctx := context.Background()
+
+	if _, err := m.InviteToWorkspace(ctx, InvitationInput{WorkspaceID: "ws-1", Workspace: "Acme", Email: "new@example.com", InviterName: "Rosa"}); err != nil {
+		t.Fatal(err)
+	}
+	token := inviteToken(t, outbox, "new@example.com")
+	form := url.Values{"token": {token}, "password": {"s3cret-pass"}, "confirm_password": {"s3cret-pass"}}
+	rec := postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, form, nil)
+	if got := rec.Header().Get("Location"); !strings.HasSuffix(got, "&error=generic") || sessions.token != "" {
+		t.Fatalf("failed join: → %q session %q", got, sessions.token)
+	}
+	if len(deleted) != 1 || deleted[0] != "user-new@example.com" {
+		t.Fatalf("deleted %v, want the account registered for the invitation", deleted)
+	}
+
+	// An existing account is never discarded.
+	if _, err := m.InviteToWorkspace(ctx, InvitationInput{WorkspaceID: "ws-1", Workspace: "Acme", Email: "old@example.com", InviterName: "Rosa"}); err != nil {
+		t.Fatal(err)
+	}
+	token = inviteToken(t, outbox, "old@example.com")
+	postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, url.Values{"token": {token}, "password": {"old-pass"}}, nil)
+	if len(deleted) != 1 {
+		t.Fatalf("deleted %v after an existing user's failed join", deleted)
+	}
+}
+
+func TestInvitation_SSORequiredAcceptsThroughCallback(t *testing.T) {
+	t.Parallel()
+	idp := newStubIdP(t)
+	outbox := NewMemoryOutbox()
+	sessions := &recordingSessionManager{}
+	joins := &joinRecorder{}
+	adapter := &passwordAdapter{users: map[string]string{}}
+	m := NewAuthModule(&Deps{
+		AuthAdapter:    adapter,
+		SessionManager: sessions,
+		SessionMinter: func(_ context.Context, userID string) (string, error) {
+			return "session-for-" + userID, nil
+		},
+		Renderer: nopRenderer{},
+		UserIDByEmail: func(_ context.Context, email string) string {
+			adapter.mu.Lock()
+			defer adapter.mu.Unlock()
+			if _, ok := adapter.users[email]; ok {
+				return "user-" + email
+			}
+			return ""
+		},
+		HomeRealm: func(_ context.Context, domain string) (HomeRealm, bool) {
+			return HomeRealm{WorkspaceID: "ws-1", Provider: "stub", SSORequired: true}, domain == "example.com"
+		},
+		CSRFIssuer:    func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:    []byte("test-secret"),
+		Mailer:        outbox,
+		PublicBaseURL: "https://app.example.com",
+		Invitations:   NewMemoryInvitationStore(joins.join),
+		OIDCProviders: []OIDCProvider{idp.provider()},
+	})
+	ctx := context.Background()
+
+	// Someone else's IdP sign-in cannot take Bo's invitation.
+	if _, err := m.InviteToWorkspace(ctx, InvitationInput{WorkspaceID: "ws-1", Workspace: "Acme", Email: "bo@example.com", InviterName: "Rosa"}); err != nil {
+		t.Fatal(err)
+	}
+	boToken := inviteToken(t, outbox, "bo@example.com")
+	rec := runOIDCFlowFrom(t, m, idp, m.inviteSSOURL(ctx, "bo@example.com", boToken), nil)
+	if got := rec.Header().Get("Location"); !strings.HasSuffix(got, "&error=sso_email") || len(joins.joined) != 0 || sessions.token != "" {
+		t.Fatalf("other address: → %q joined %v session %q", got, joins.joined, sessions.token)
+	}
+
+	// The password form hands the invitee to the IdP instead.
+	if _, err := m.InviteToWorkspace(ctx, InvitationInput{WorkspaceID: "ws-1", Workspace: "Acme", Email: "ana@example.com", RoleIDs: []string{"r-admin"}, InviterName: "Rosa"}); err != nil {
+		t.Fatal(err)
+	}
+	token := inviteToken(t, outbox, "ana@example.com")
+	form := url.Values{"token": {token}, "password": {"s3cret-pass"}, "confirm_password": {"s3cret-pass"}}
+	rec = postForm(m.handleAcceptInvite(), entydad.AuthAcceptInvitePostURL, form, nil)
+	sso := rec.Header().Get("Location")
+	if !strings.HasPrefix(sso, entydad.AuthOIDCStartURL+"?") || !strings.Contains(sso, "invite="+url.QueryEscape(token)) {
+		t.Fatalf("password accept: → %q, want the SSO start", sso)
+	}
+	if len(adapter.users) != 0 || len(joins.joined) != 0 {
+		t.Fatalf("password accept touched accounts: users %v joined %v", adapter.users, joins.joined)
+	}
+
+	// The callback registers the invitee, joins and signs in.
+	rec = runOIDCFlowFrom(t, m, idp, sso, nil)
+	if got := rec.Header().Get("Location"); got != entydad.DefaultAppRedirectURL || sessions.token != "session-for-user-ana@example.com" {
+		t.Fatalf("callback: → %q session %q", got, sessions.token)
+	}
+	if len(joins.joined) != 1 || joins.joined[0] != "user-ana@example.com ws-1 r-admin" {
+		t.Fatalf("joined %v", joins.joined)
+	}
+	if _, ok := m.openInvitation(ctx, token); ok {
+		t.Fatal("invitation still open after the SSO accept")
+	}
+}
@@ -0,0 +1,521 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"net/netip"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoginAttemptScope names the credential endpoint an attempt was made
+// against. Each scope carries its own sliding-window rule (see
+// LoginAttemptPolicy.Rules) so a burst of reset requests can't starve the
+// login budget and vice versa.
+type LoginAttemptScope string
+
+const (
+	LoginScopePassword      LoginAttemptScope = "login"
+	LoginScopeFirebase      LoginAttemptScope = "firebase"
+	LoginScopeResetPassword LoginAttemptScope = "reset_password"
+	LoginScopeSignup        LoginAttemptScope = "signup"
+	LoginScopeMFA           LoginAttemptScope = "mfa"
+	LoginScopePasskey       LoginAttemptScope = "passkey"
+	LoginScopeOIDC          LoginAttemptScope = "oidc"
+	LoginScopeVerifyEmail   LoginAttemptScope = "verify_email"
+	LoginScopeMagicLink     LoginAttemptScope = "magic_link"
+	LoginScopeStepUp        LoginAttemptScope = "step_up"
+	LoginScopeRelogin       LoginAttemptScope = "relogin"
+)
+
+// LoginAttempt identifies one credential attempt. Email is normalised
+// (trimmed, lower-cased) by the limiter; an empty Email limits by IP only
+// (e.g. a Firebase ID token that failed verification).
+type LoginAttempt struct {
+	Scope LoginAttemptScope
+	Email string
+	IP    string
+}
+
+// LoginAttemptDecision is the limiter's verdict for an attempt.
+//
+//   - Locked      — the account is temporarily locked (login02 ?error=locked)
+//   - !Allowed    — a per-email or per-IP window is exhausted (?error=throttled)
+//   - Delay       — progressive back-off the handler waits out before
+//     checking credentials (Allowed attempts only)
+//   - RetryAfter  — when the caller may try again (Retry-After header)
+type LoginAttemptDecision struct {
+	Allowed    bool
+	Locked     bool
+	Delay      time.Duration
+	RetryAfter time.Duration
+}
+
+// LoginAttemptLimiter throttles the pre-session credential endpoints
+// (/auth/login, /auth/firebase, /auth/reset-password, /auth/signup,
+// /auth/mfa, /auth/passkey, /auth/oidc/callback, /auth/verify-email/resend).
+// Handlers call Check before touching the AuthAdapter, then report the
+// outcome with RecordFailure / RecordSuccess. Check reserves the attempt's
+// place in its windows before comparing, so parallel attempts cannot all
+// slip under a limit; the report settles the reservation (a failure keeps
+// it, a success in a failures-only scope gives it back). RecordFailure
+// returns the post-failure decision so the handler can tell the user the
+// account has just been locked.
+//
+// The default (NewLoginAttemptLimiter over NewMemoryLoginAttemptStore) is
+// per-process; multi-instance deployments inject a limiter backed by a
+// shared LoginAttemptStore.
+type LoginAttemptLimiter interface {
+	Check(ctx context.Context, attempt LoginAttempt) LoginAttemptDecision
+	RecordFailure(ctx context.Context, attempt LoginAttempt) LoginAttemptDecision
+	RecordSuccess(ctx context.Context, attempt LoginAttempt)
+}
+
+// LoginAttemptStore is the persistence seam under the default limiter.
+// Keys are opaque strings built by the limiter ("login:email:a@b.c",
+// "signup:ip:10.0.0.1", "lock:a@b.c"). Implementations backed by a database
+// or cache need timestamped append-and-count, count-since, drop-newest and
+// reset, plus a lock-until value per key.
+//
+// ReserveAttempt must be atomic: it appends at and returns the number of
+// attempts after since, the new one included, in one step (an INCR, an
+// INSERT … RETURNING count), so concurrent reservations each see the
+// others. ReleaseAttempt drops the newest attempt under key.
+type LoginAttemptStore interface {
+	ReserveAttempt(ctx context.Context, key string, at, since time.Time) (int, error)
+	ReleaseAttempt(ctx context.Context, key string) error
+	CountAttempts(ctx context.Context, key string, since time.Time) (int, error)
+	ResetAttempts(ctx context.Context, key string) error
+	SetLock(ctx context.Context, key string, until time.Time) error
+	LockedUntil(ctx context.Context, key string) (time.Time, error)
+}
+
+// LoginAttemptRule is the sliding-window budget for one scope.
+type LoginAttemptRule struct {
+	EmailLimit int           // attempts per email within Window (0 = unlimited)
+	IPLimit    int           // attempts per client IP within Window (0 = unlimited)
+	Window     time.Duration // sliding-window length
+	// CountSuccess counts every attempt, not only failures. Used by
+	// reset-password and signup where each request sends an email or
+	// creates a row, so a "successful" flood is still abuse.
+	CountSuccess bool
+	// Lockout makes failures in this scope count towards the account lock.
+	Lockout bool
+	// CheckLock refuses a locked account in this scope. Off for
+	// reset-password: resetting is how a locked-out user gets back in.
+	CheckLock bool
+}
+
+// LoginAttemptPolicy configures the default limiter.
+type LoginAttemptPolicy struct {
+	Rules map[LoginAttemptScope]LoginAttemptRule
+
+	// Progressive delay: once an email has DelayAfter failures in the
+	// window, each further attempt waits BaseDelay doubled per extra
+	// failure, capped at MaxDelay.
+	DelayAfter int
+	BaseDelay  time.Duration
+	MaxDelay   time.Duration
+
+	// Account lock: LockoutThreshold failures (in Lockout scopes) within
+	// LockoutWindow lock the email for LockoutDuration. 0 disables.
+	LockoutThreshold int
+	LockoutWindow    time.Duration
+	LockoutDuration  time.Duration
+}
+
+// DefaultLoginAttemptPolicy returns the limits applied when the host does
+// not inject its own limiter.
+func DefaultLoginAttemptPolicy() LoginAttemptPolicy {
+	return LoginAttemptPolicy{
+		Rules: map[LoginAttemptScope]LoginAttemptRule{
+			LoginScopePassword:      {EmailLimit: 20, IPLimit: 100, Window: 15 * time.Minute, Lockout: true, CheckLock: true},
+			LoginScopeFirebase:      {EmailLimit: 20, IPLimit: 100, Window: 15 * time.Minute, CheckLock: true},
+			LoginScopeResetPassword: {EmailLimit: 5, IPLimit: 20, Window: time.Hour, CountSuccess: true},
+			LoginScopeSignup:        {EmailLimit: 5, IPLimit: 10, Window: time.Hour, CountSuccess: true},
+			LoginScopeMFA:           {EmailLimit: 10, IPLimit: 50, Window: 15 * time.Minute, Lockout: true, CheckLock: true},
+			LoginScopePasskey:       {IPLimit: 100, Window: 15 * time.Minute},
+			LoginScopeOIDC:          {EmailLimit: 20, IPLimit: 100, Window: 15 * time.Minute, CheckLock: true},
+			LoginScopeVerifyEmail:   {EmailLimit: 5, IPLimit: 20, Window: time.Hour, CountSuccess: true},
+			LoginScopeMagicLink:     {EmailLimit: 5, IPLimit: 20, Window: time.Hour, CountSuccess: true},
+			LoginScopeStepUp:        {EmailLimit: 10, IPLimit: 50, Window: 15 * time.Minute, Lockout: true, CheckLock: true},
+			LoginScopeRelogin:       {EmailLimit: 10, IPLimit: 50, Window: 15 * time.Minute, Lockout: true, CheckLock: true},
+		},
+		DelayAfter:       3,
+		BaseDelay:        500 * time.Millisecond,
+		MaxDelay:         8 * time.Second,
+		LockoutThreshold: 10,
+		LockoutWindow:    15 * time.Minute,
+		LockoutDuration:  15 * time.Minute,
+	}
+}
+
+// slidingWindowLimiter is the default LoginAttemptLimiter. Window store
+// errors fail OPEN (logged): an unavailable throttle store must not take
+// sign-in down with it — the adapter's own credential check still runs. A
+// failed lock lookup fails CLOSED instead, or a store outage would unlock
+// every locked account.
+type slidingWindowLimiter struct {
+	store  LoginAttemptStore
+	policy LoginAttemptPolicy
+	now    func() time.Time
+}
+
+// NewLoginAttemptLimiter returns the sliding-window limiter over store.
+func NewLoginAttemptLimiter(store LoginAttemptStore, policy LoginAttemptPolicy) LoginAttemptLimiter {
+	return &slidingWindowLimiter{store: store, policy: policy, now: time.Now}
+}
+
+func (l *slidingWindowLimiter) Check(ctx context.Context, attempt LoginAttempt) LoginAttemptDecision {
+	now := l.now()
+	email := normalizeAttemptEmail(attempt.Email)
+	rule, ok := l.policy.Rules[attempt.Scope]
+	if !ok {
+		return LoginAttemptDecision{Allowed: true}
+	}
+
+	if email != "" && rule.CheckLock {
+		until, err := l.store.LockedUntil(ctx, lockKey(email))
+		if err != nil {
+			log.Printf("[AUTH] login limiter: lock lookup failed, refusing %s: %v", email, err)
+			return LoginAttemptDecision{RetryAfter: time.Minute}
+		}
+		if until.After(now) {
+			return LoginAttemptDecision{Locked: true, RetryAfter: until.Sub(now)}
+		}
+	}
+
+	// Reserve first, compare after: the attempt holds its place in the
+	// windows before anyone else's count is read.
+	since := now.Add(-rule.Window)
+	var reserved []string
+	refuse := func() LoginAttemptDecision {
+		for _, key := range reserved {
+			l.release(ctx, key)
+		}
+		return LoginAttemptDecision{RetryAfter: rule.Window}
+	}
+	emailCount := 0
+	if email != "" && rule.EmailLimit > 0 {
+		key := attemptKey(attempt.Scope, "email", email)
+		n, ok := l.reserve(ctx, key, now, since)
+		if ok {
+			reserved = append(reserved, key)
+		}
+		if n > rule.EmailLimit {
+			return refuse()
+		}
+		emailCount = n - 1
+	}
+	if attempt.IP != "" && rule.IPLimit > 0 {
+		key := attemptKey(attempt.Scope, "ip", attempt.IP)
+		n, ok := l.reserve(ctx, key, now, since)
+		if ok {
+			reserved = append(reserved, key)
+		}
+		if n > rule.IPLimit {
+			return refuse()
+		}
+	}
+	if rule.CountSuccess {
+		return LoginAttemptDecision{Allowed: true}
+	}
+	return LoginAttemptDecision{Allowed: true, Delay: l.delayFor(emailCount)}
+}
+
+// RecordFailure settles a failed attempt: its reservation from Check stays
+// counted, and enough of them lock the account.
+func (l *slidingWindowLimiter) RecordFailure(ctx context.Context, attempt LoginAttempt) LoginAttemptDecision {
+	now := l.now()
+	email := normalizeAttemptEmail(attempt.Email)
+
+	rule := l.policy.Rules[attempt.Scope]
+	if !rule.Lockout || email == "" || l.policy.LockoutThreshold <= 0 {
+		return LoginAttemptDecision{Allowed: true}
+	}
+	key := attemptKey(attempt.Scope, "email", email)
+	failures := l.count(ctx, key, now.Add(-l.policy.LockoutWindow))
+	if failures < l.policy.LockoutThreshold {
+		return LoginAttemptDecision{Allowed: true, Delay: l.delayFor(failures)}
+	}
+	until := now.Add(l.policy.LockoutDuration)
+	if err := l.store.SetLock(ctx, lockKey(email), until); err != nil {
+		log.Printf("[AUTH] login limiter: set lock failed: %v", err)
+		return LoginAttemptDecision{Allowed: true}
+	}
+	// The lock now carries the back-off; start the next window clean.
+	if err := l.store.ResetAttempts(ctx, key); err != nil {
+		log.Printf("[AUTH] login limiter: reset after lock failed: %v", err)
+	}
+	log.Printf("[AUTH] account locked for %s until %s after %d failed attempts", email, until.Format(time.RFC3339), failures)
+	return LoginAttemptDecision{Locked: true, RetryAfter: l.policy.LockoutDuration}
+}
+
+// RecordSuccess settles a successful attempt. In a CountSuccess scope the
+// reservation stays; otherwise the IP's is given back and the email's
+// failure history cleared (not the IP's — one good credential must not
+// launder a spraying source).
+func (l *slidingWindowLimiter) RecordSuccess(ctx context.Context, attempt LoginAttempt) {
+	email := normalizeAttemptEmail(attempt.Email)
+	rule := l.policy.Rules[attempt.Scope]
+	if rule.CountSuccess {
+		return
+	}
+	if attempt.IP != "" && rule.IPLimit > 0 {
+		l.release(ctx, attemptKey(attempt.Scope, "ip", attempt.IP))
+	}
+	if email != "" {
+		if err := l.store.ResetAttempts(ctx, attemptKey(attempt.Scope, "email", email)); err != nil {
+			log.Printf("[AUTH] login limiter: reset failed: %v", err)
+		}
+	}
+}
+
+// reserve appends an attempt to key and returns the window's count with
+// it; ok=false when the store failed (the attempt then passes).
+func (l *slidingWindowLimiter) reserve(ctx context.Context, key string, at, since time.Time) (int, bool) {
+	n, err := l.store.ReserveAttempt(ctx, key, at, since)
+	if err != nil {
+		log.Printf("[AUTH] login limiter: reserve failed: %v", err)
+		return 0, false
+	}
+	return n, true
+}
+
+func (l *slidingWindowLimiter) release(ctx context.Context, key string) {
+	if err := l.store.ReleaseAttempt(ctx, key); err != nil {
+		log.Printf("[AUTH] login limiter: release failed: %v", err)
+	}
+}
+
+func (l *slidingWindowLimiter) count(ctx context.Context, key string, since time.Time) int {
+	n, err := l.store.CountAttempts(ctx, key, since)
+	if err != nil {
+		log.Printf("[AUTH] login limiter: count failed: %v", err)
+		return 0
+	}
+	return n
+}
+
+// delayFor returns the progressive back-off for an email with n recent
+// failures: zero up to DelayAfter, then BaseDelay doubling per failure.
+func (l *slidingWindowLimiter) delayFor(n int) time.Duration {
+	p := l.policy
+	if p.BaseDelay <= 0 || n < p.DelayAfter {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := p.DelayAfter; i < n; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	return d
+}
+
+func attemptKey(scope LoginAttemptScope, kind, value string) string {
+	return string(scope) + ":" + kind + ":" + value
+}
+
+func lockKey(email string) string {
+	return "lock:" + email
+}
+
+func normalizeAttemptEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// MemoryLoginAttemptStore is the in-process LoginAttemptStore. Timestamps
+// older than the requested window are pruned on every count and
+// reservation, so memory is bounded by the traffic inside the longest
+// window. Expired locks are overwritten by the next SetLock for the same
+// key.
+type MemoryLoginAttemptStore struct {
+	mu       sync.Mutex
+	attempts map[string][]time.Time
+	locks    map[string]time.Time
+}
+
+// NewMemoryLoginAttemptStore returns an empty in-memory store.
+func NewMemoryLoginAttemptStore() *MemoryLoginAttemptStore {
+	return &MemoryLoginAttemptStore{
+		attempts: make(map[string][]time.Time),
+		locks:    make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryLoginAttemptStore) ReserveAttempt(_ context.Context, key string, at, since time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[key] = append(s.prune(key, since), at)
+	return len(s.attempts[key]), nil
+}
+
+func (s *MemoryLoginAttemptStore) ReleaseAttempt(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	times := s.attempts[key]
+	switch {
+	case len(times) > 1:
+		s.attempts[key] = times[:len(times)-1]
+	case len(times) == 1:
+		delete(s.attempts, key)
+	}
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) CountAttempts(_ context.Context, key string, since time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.prune(key, since)), nil
+}
+
+// prune drops key's attempts at or before since and returns the rest.
+// Callers hold s.mu.
+func (s *MemoryLoginAttemptStore) prune(key string, since time.Time) []time.Time {
+	times := s.attempts[key]
+	kept := times[:0]
+	for _, t := range times {
+		if t.After(since) {
+			kept = append(kept, t)
+		}
+	}
+	if len(kept) == 0 {
+		delete(s.attempts, key)
+		return nil
+	}
+	s.attempts[key] = kept
+	return kept
+}
+
+func (s *MemoryLoginAttemptStore) ResetAttempts(_ context.Context, key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) SetLock(_ context.Context, key string, until time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.locks[key] = until
+	return nil
+}
+
+func (s *MemoryLoginAttemptStore) LockedUntil(_ context.Context, key string) (time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.locks[key], nil
+}
+
+// limitedErrorCode maps a refusing decision to the short ?error= code the
+// auth pages resolve to a label: "locked" or "throttled". Empty when the
+// attempt may proceed.
+func limitedErrorCode(d LoginAttemptDecision) string {
+	switch {
+	case d.Locked:
+		return "locked"
+	case !d.Allowed:
+		return "throttled"
+	default:
+		return ""
+	}
+}
+
+// waitLoginDelay sleeps out a progressive back-off, returning false if the
+// client went away first.
+func waitLoginDelay(ctx context.Context, d time.Duration) bool {
+	if d <= 0 {
+		return true
+	}
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// setRetryAfter writes a Retry-After header (whole seconds, at least 1).
+func setRetryAfter(w http.ResponseWriter, d time.Duration) {
+	secs := int(d.Round(time.Second) / time.Second)
+	if secs < 1 {
+		secs = 1
+	}
+	w.Header().Set("Retry-After", strconv.Itoa(secs))
+}
+
+// clientIP returns the caller's IP for throttling and the event log: the
+// RemoteAddr host, unless that is one of Deps.TrustedProxies. Behind a
+// trusted proxy it is the nearest X-Forwarded-For hop that is not itself
+// a trusted proxy (the client writes the leftmost hops, so they are never
+// taken on trust), then X-Real-Ip.
+func (m *AuthModule) clientIP(r *http.Request) string {
+	remote, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remote = r.RemoteAddr
+	}
+	if !m.trustedProxy(remote) {
+		return remote
+	}
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		if _, err := netip.ParseAddr(hop); err != nil {
+			break
+		}
+		if !m.trustedProxy(hop) {
+			return hop
+		}
+	}
+	if xr := strings.TrimSpace(r.Header.Get("X-Real-Ip")); xr != "" {
+		if _, err := netip.ParseAddr(xr); err == nil {
+			return xr
+		}
+	}
+	return remote
+}
+
+// trustedProxy reports whether ip is in Deps.TrustedProxies.
+func (m *AuthModule) trustedProxy(ip string) bool {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+	for _, p := range m.trustedProxies {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTrustedProxies reads Deps.TrustedProxies: IPs or CIDR prefixes.
+// Invalid entries are logged and skipped.
+func parseTrustedProxies(entries []string) []netip.Prefix {
+	var out []netip.Prefix
+	for _, e := range entries {
+		e = strings.TrimSpace(e)
+		if p, err := netip.ParsePrefix(e); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		if a, err := netip.ParseAddr(e); err == nil {
+			out = append(out, netip.PrefixFrom(a.Unmap(), a.Unmap().BitLen()))
+			continue
+		}
+		log.Printf("[AUTH] ignoring invalid trusted proxy %q", e)
+	}
+	return out
+}
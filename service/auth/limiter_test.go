@@ -0,0 +1,254 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestLimiter(policy LoginAttemptPolicy) (*slidingWindowLimiter, *time.Time) {
+	now := time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)
+	l := NewLoginAttemptLimiter(NewMemoryLoginAttemptStore(), policy).(*slidingWindowLimiter)
+	l.now = func() time.Time { return now }
+	return l, &now
+}
+
+func TestLoginAttemptLimiter_LocksAccountAfterThreshold(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	l, now := newTestLimiter(DefaultLoginAttemptPolicy())
+	attempt := LoginAttempt{Scope: LoginScopePassword, Email: "Ada@Example.com", IP: "10.0.0.1"}
+
+	for i := 1; i < 10; i++ {
+		if d := failAttempt(l, attempt); d.Locked {
+			t.Fatalf("failure %d: locked before threshold", i)
+		}
+	}
+	if d := failAttempt(l, attempt); !d.Locked {
+		t.Fatalf("10th failure: want Locked, got %+v", d)
+	}
+
+	// The lock is keyed by the normalised email, across IPs and both
+	// sign-in paths — but not the reset flow.
+	other := LoginAttempt{Scope: LoginScopeFirebase, Email: " ada@example.com", IP: "10.9.9.9"}
+	if d := l.Check(ctx, other); !d.Locked || limitedErrorCode(d) != "locked" {
+		t.Fatalf("firebase check while locked: want locked, got %+v", d)
+	}
+	reset := LoginAttempt{Scope: LoginScopeResetPassword, Email: "ada@example.com", IP: "10.0.0.1"}
+	if d := l.Check(ctx, reset); !d.Allowed {
+		t.Fatalf("reset check while locked: want allowed, got %+v", d)
+	}
+
+	*now = now.Add(16 * time.Minute)
+	if d := l.Check(ctx, attempt); !d.Allowed || d.Locked {
+		t.Fatalf("after lock expiry: want allowed, got %+v", d)
+	}
+}
+
+func TestLoginAttemptLimiter_SlidingWindows(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	tests := []struct {
+		name     string
+		rule     LoginAttemptRule
+		attempts []LoginAttempt // recorded before the check
+		success  bool           // record via RecordSuccess instead of RecordFailure
+		check    LoginAttempt
+		advance  time.Duration
+		want     string
+	}{
+		{
+			name:     "per-email limit",
+			rule:     LoginAttemptRule{EmailLimit: 3, Window: time.Minute},
+			attempts: repeatAttempt(LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c", IP: "1.1.1.1"}, 3),
+			check:    LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c", IP: "2.2.2.2"},
+			want:     "throttled",
+		},
+		{
+			name:     "per-ip limit across emails",
+			rule:     LoginAttemptRule{IPLimit: 2, Window: time.Minute},
+			attempts: []LoginAttempt{{Scope: LoginScopePassword, Email: "a@b.c", IP: "1.1.1.1"}, {Scope: LoginScopePassword, Email: "x@y.z", IP: "1.1.1.1"}},
+			check:    LoginAttempt{Scope: LoginScopePassword, Email: "new@b.c", IP: "1.1.1.1"},
+			want:     "throttled",
+		},
+		{
+			name:     "window slides",
+			rule:     LoginAttemptRule{EmailLimit: 3, Window: time.Minute},
+			attempts: repeatAttempt(LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c"}, 3),
+			check:    LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c"},
+			advance:  2 * time.Minute,
+			want:     "",
+		},
+		{
+			name:     "success clears email failures",
+			rule:     LoginAttemptRule{EmailLimit: 1, Window: time.Minute},
+			attempts: repeatAttempt(LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c"}, 1),
+			success:  true,
+			check:    LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c"},
+			want:     "",
+		},
+		{
+			name:     "count-success scope counts successes",
+			rule:     LoginAttemptRule{EmailLimit: 2, Window: time.Hour, CountSuccess: true},
+			attempts: repeatAttempt(LoginAttempt{Scope: LoginScopeResetPassword, Email: "a@b.c"}, 2),
+			success:  true,
+			check:    LoginAttempt{Scope: LoginScopeResetPassword, Email: "a@b.c"},
+			want:     "throttled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			policy := LoginAttemptPolicy{Rules: map[LoginAttemptScope]LoginAttemptRule{tt.check.Scope: tt.rule}}
+			l, now := newTestLimiter(policy)
+			for _, a := range tt.attempts {
+				failAttempt(l, a)
+			}
+			if tt.success {
+				l.RecordSuccess(ctx, tt.attempts[0])
+			}
+			*now = now.Add(tt.advance)
+			if got := limitedErrorCode(l.Check(ctx, tt.check)); got != tt.want {
+				t.Errorf("Check() code = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoginAttemptLimiter_ProgressiveDelay(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	policy := DefaultLoginAttemptPolicy()
+	policy.LockoutThreshold = 0
+	l, _ := newTestLimiter(policy)
+	attempt := LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c"}
+
+	want := []time.Duration{0, 0, 0, 500 * time.Millisecond, time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 8 * time.Second}
+	for i, w := range want {
+		if got := l.Check(ctx, attempt).Delay; got != w {
+			t.Errorf("after %d failures: delay = %v, want %v", i, got, w)
+		}
+		l.RecordFailure(ctx, attempt)
+	}
+}
+
+func TestLoginAttemptLimiter_ParallelAttemptsReserve(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	policy := DefaultLoginAttemptPolicy()
+	policy.BaseDelay = 0
+	l, _ := newTestLimiter(policy)
+	attempt := LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c", IP: "10.0.0.1"}
+
+	// Every attempt is checked before any failure is reported.
+	var (
+		wg      sync.WaitGroup
+		allowed atomic.Int32
+	)
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if l.Check(ctx, attempt).Allowed {
+				allowed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := allowed.Load(); got != 20 {
+		t.Fatalf("%d parallel attempts allowed, want the email limit of 20", got)
+	}
+	if d := l.RecordFailure(ctx, attempt); !d.Locked {
+		t.Fatalf("failure after 20 reserved attempts: want Locked, got %+v", d)
+	}
+}
+
+func TestLoginAttemptLimiter_SuccessReturnsIPReservation(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	policy := LoginAttemptPolicy{Rules: map[LoginAttemptScope]LoginAttemptRule{
+		LoginScopePassword: {IPLimit: 1, Window: time.Minute},
+	}}
+	l, _ := newTestLimiter(policy)
+	for i := 0; i < 3; i++ {
+		attempt := LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c", IP: "10.0.0.1"}
+		if d := l.Check(ctx, attempt); !d.Allowed {
+			t.Fatalf("sign-in %d: refused %+v", i, d)
+		}
+		l.RecordSuccess(ctx, attempt)
+	}
+}
+
+// lockOutageStore fails every lock lookup.
+type lockOutageStore struct{ *MemoryLoginAttemptStore }
+
+func (lockOutageStore) LockedUntil(context.Context, string) (time.Time, error) {
+	return time.Time{}, errors.New("store down")
+}
+
+func TestLoginAttemptLimiter_LockLookupFailsClosed(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	l := NewLoginAttemptLimiter(lockOutageStore{NewMemoryLoginAttemptStore()}, DefaultLoginAttemptPolicy())
+
+	if d := l.Check(ctx, LoginAttempt{Scope: LoginScopePassword, Email: "a@b.c", IP: "10.0.0.1"}); d.Allowed {
+		t.Fatalf("login with the lock store down: want refused, got %+v", d)
+	}
+	// Scopes that do not check the lock carry on.
+	if d := l.Check(ctx, LoginAttempt{Scope: LoginScopeResetPassword, Email: "a@b.c", IP: "10.0.0.1"}); !d.Allowed {
+		t.Fatalf("reset with the lock store down: want allowed, got %+v", d)
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	t.Parallel()
+	m := NewAuthModule(&Deps{TrustedProxies: []string{"10.0.0.0/8", "192.0.2.1", "not-an-ip"}})
+	tests := []struct {
+		name    string
+		headers map[string]string
+		remote  string
+		want    string
+	}{
+		{"remote addr host", nil, "192.0.2.9:5555", "192.0.2.9"},
+		{"forwarded header from an untrusted peer", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "192.0.2.9:5555", "192.0.2.9"},
+		{"real ip from an untrusted peer", map[string]string{"X-Real-Ip": "198.51.100.4"}, "192.0.2.9:5555", "192.0.2.9"},
+		{"forwarded by a trusted proxy", map[string]string{"X-Forwarded-For": "203.0.113.7"}, "10.0.0.2:1234", "203.0.113.7"},
+		{"spoofed leftmost hop", map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.7, 10.0.0.1"}, "192.0.2.1:1234", "203.0.113.7"},
+		{"only trusted hops", map[string]string{"X-Forwarded-For": "10.0.0.1"}, "10.0.0.2:1234", "10.0.0.2"},
+		{"garbage hop", map[string]string{"X-Forwarded-For": "203.0.113.7, junk"}, "10.0.0.2:1234", "10.0.0.2"},
+		{"real ip from a trusted proxy", map[string]string{"X-Real-Ip": "198.51.100.4"}, "10.0.0.2:1234", "198.51.100.4"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			r := httptest.NewRequest("POST", "/auth/login", nil)
+			r.RemoteAddr = tt.remote
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			if got := m.clientIP(r); got != tt.want {
+				t.Errorf("clientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// failAttempt checks attempt and reports it failed, as a handler does.
+func failAttempt(l LoginAttemptLimiter, attempt LoginAttempt) LoginAttemptDecision {
+	l.Check(context.Background(), attempt)
+	return l.RecordFailure(context.Background(), attempt)
+}
+
+func repeatAttempt(a LoginAttempt, n int) []LoginAttempt {
+	out := make([]LoginAttempt, n)
+	for i := range out {
+		out[i] = a
+	}
+	return out
+}
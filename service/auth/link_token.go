@@ -0,0 +1,65 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Link tokens are the signed, self-contained values carried in emailed
+// links (?token=...): base64url(JSON) "." base64url(HMAC-SHA256). The MAC
+// key is derived from the cookie key and the purpose, so a token minted for
+// one flow never opens in another. Like the sealed cookies, tokens signed
+// under a per-process random key (no CSRFSecret) die with the process.
+// Tokens are readable by their holder — never put secrets in the payload.
+
+type linkTokenEnvelope struct {
+	Expires int64           `json:"x"`
+	Data    json.RawMessage `json:"d"`
+}
+
+// signLinkToken returns a token carrying v for purpose, valid for ttl.
+func (m *AuthModule) signLinkToken(purpose string, v any, ttl time.Duration) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	body, err := json.Marshal(linkTokenEnvelope{Expires: time.Now().Add(ttl).Unix(), Data: data})
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(body)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(m.linkTokenMAC(purpose, payload)), nil
+}
+
+// openLinkToken verifies token for purpose and decodes its payload into v;
+// false when malformed, forged, signed for another purpose or expired.
+func (m *AuthModule) openLinkToken(purpose, token string, v any) bool {
+	payload, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	got, err := base64.RawURLEncoding.DecodeString(sig)
+	if err != nil || !hmac.Equal(got, m.linkTokenMAC(purpose, payload)) {
+		return false
+	}
+	body, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return false
+	}
+	var env linkTokenEnvelope
+	if json.Unmarshal(body, &env) != nil || time.Now().Unix() > env.Expires {
+		return false
+	}
+	return json.Unmarshal(env.Data, v) == nil
+}
+
+func (m *AuthModule) linkTokenMAC(purpose, payload string) []byte {
+	key := sha256.Sum256(append([]byte("entydad/link/"+purpose+":"), m.cookieKey...))
+	mac := hmac.New(sha256.New, key[:])
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
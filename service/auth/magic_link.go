@@ -0,0 +1,284 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	magiclinkmod "github.com/erniealice/entydad-golang/service/auth/views/login02/magic-link"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// MagicLinkStore is the replay guard for emailed sign-in links: every link
+// carries a random ID that can be consumed once.
+type MagicLinkStore interface {
+	// ConsumeMagicLink marks id used and reports whether this was its first
+	// use. The link is dead after expires anyway, so the record may be
+	// dropped then.
+	ConsumeMagicLink(ctx context.Context, id string, expires time.Time) (first bool, err error)
+}
+
+// MagicLinkAllowed reports whether a workspace lets its members sign in
+// with an emailed link. Injected as a closure over the workspace settings
+// lookup, like MFARequired.
+type MagicLinkAllowed func(ctx context.Context, workspaceID string) bool
+
+const (
+	// magicLinkTTL is the lifetime of an emailed sign-in link.
+	magicLinkTTL = 15 * time.Minute
+
+	linkPurposeMagicLink = "magic_link"
+)
+
+// magicLinkClaims is the payload of the emailed link. The email is re-checked
+// against the user on use, so a link for a since-changed address stops
+// working.
+type magicLinkClaims struct {
+	ID     string `json:"i"`
+	UserID string `json:"u"`
+	Email  string `json:"e"`
+}
+
+// magicLinkEnabled reports whether passwordless sign-in is switched on and
+// fully wired.
+func (m *AuthModule) magicLinkEnabled() bool {
+	return m.deps.AllowMagicLink && m.mailEnabled() &&
+		m.deps.SessionMinter != nil && m.deps.SessionManager != nil
+}
+
+// magicLinkAllowed applies the workspace-level toggle: with MagicLinkAllowed
+// set, the user needs a principal in at least one workspace that allows
+// magic links. Fails closed when the principals cannot be resolved.
+func (m *AuthModule) magicLinkAllowed(ctx context.Context, userID string) bool {
+	allowed := m.deps.MagicLinkAllowed
+	if allowed == nil {
+		return true
+	}
+	loader := m.deps.PrincipalResolver
+	if loader == nil || !loader.IsEnabled() {
+		return false
+	}
+	principals, err := loader.Resolve(ctx, userID)
+	if err != nil {
+		log.Printf("[AUTH] magic-link: principal lookup failed for user %s: %v", userID, err)
+		return false
+	}
+	for _, p := range principals {
+		if allowed(ctx, p.WorkspaceID) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleMagicLinkPage returns the GET /auth/magic-link handler: the request
+// form, or the "link sent" notice with ?sent=1.
+func (m *AuthModule) handleMagicLinkPage(page *magiclinkmod.Deps) http.HandlerFunc {
+	v := magiclinkmod.NewView(page)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		m.renderMagicLink(w, r, v, magiclinkmod.State{
+			Sent:      q.Get("sent") == "1",
+			ErrorCode: q.Get("error"),
+		})
+	}
+}
+
+// handleMagicLinkRequest returns the POST /auth/magic-link handler. Always
+// answers ?sent=1 (unless throttled) so the response does not reveal whether
+// the email has an account; the link is only mailed when it does and the
+// workspace toggle allows it.
+func (m *AuthModule) handleMagicLinkRequest() http.HandlerFunc {
+	limiter := m.deps.LoginAttemptLimiter
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+		email := strings.TrimSpace(r.FormValue("email"))
+		if email == "" {
+			http.Redirect(w, r, entydad.AuthMagicLinkURL+"?error=invalid", http.StatusSeeOther)
+			return
+		}
+		// Home realm: no link for an SSO-required domain. Like the
+		// password path this depends on the domain alone.
+		if m.ssoRequired(r.Context(), email) {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=sso_required", http.StatusSeeOther)
+			return
+		}
+		// Every request counts, like reset-password: each one sends mail.
+		attempt := LoginAttempt{Scope: LoginScopeMagicLink, Email: email, IP: m.clientIP(r)}
+		if limitedErrorCode(limiter.Check(r.Context(), attempt)) != "" {
+			log.Printf("[AUTH] magic-link throttled for %s from %s", email, attempt.IP)
+			http.Redirect(w, r, entydad.AuthMagicLinkURL+"?error=throttled", http.StatusSeeOther)
+			return
+		}
+		limiter.RecordSuccess(r.Context(), attempt)
+
+		sent := entydad.AuthMagicLinkURL + "?sent=1"
+		userID := m.userIDForEmail(r.Context(), email)
+		if userID == "" {
+			log.Printf("[AUTH] magic-link: no user for %s", email)
+			http.Redirect(w, r, sent, http.StatusSeeOther)
+			return
+		}
+		if !m.magicLinkAllowed(r.Context(), userID) {
+			log.Printf("[AUTH] magic-link: not allowed for user %s", userID)
+			http.Redirect(w, r, sent, http.StatusSeeOther)
+			return
+		}
+		if err := m.sendMagicLink(r.Context(), userID, email); err != nil {
+			log.Printf("[AUTH] magic-link: send failed for user %s: %v", userID, err)
+		}
+		http.Redirect(w, r, sent, http.StatusSeeOther)
+	}
+}
+
+// sendMagicLink mails userID a single-use link to the confirm page.
+func (m *AuthModule) sendMagicLink(ctx context.Context, userID, email string) error {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return err
+	}
+	claims := magicLinkClaims{ID: hex.EncodeToString(id), UserID: userID, Email: email}
+	token, err := m.signLinkToken(linkPurposeMagicLink, claims, magicLinkTTL)
+	if err != nil {
+		return err
+	}
+	link := m.publicURL(entydad.AuthMagicLinkVerifyURL + "?token=" + url.QueryEscape(token))
+	return m.sendMail(ctx, MailMagicLink, MailData{To: email, Link: link})
+}
+
+// handleMagicLinkConfirm returns the GET /auth/magic-link/verify handler:
+// checks the token without consuming it and renders the confirm step whose
+// form POSTs it back.
+func (m *AuthModule) handleMagicLinkConfirm(page *magiclinkmod.Deps) http.HandlerFunc {
+	v := magiclinkmod.NewView(page)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		var c magicLinkClaims
+		if !m.openLinkToken(linkPurposeMagicLink, token, &c) || c.ID == "" {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=magic_link", http.StatusSeeOther)
+			return
+		}
+		// The token sits in the URL: keep it out of Referer headers.
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		m.renderMagicLink(w, r, v, magiclinkmod.State{Token: token, Email: c.Email})
+	}
+}
+
+// handleMagicLinkVerify returns the POST /auth/magic-link/verify handler:
+// consumes the link, mints a session via SessionMinter and continues like
+// every other sign-in (two-step verification, then routePrincipals).
+func (m *AuthModule) handleMagicLinkVerify() http.HandlerFunc {
+	minter := m.deps.SessionMinter
+	sessionMw := m.deps.SessionManager
+	principalLoader := m.deps.PrincipalResolver
+	store := m.deps.MagicLinkStore
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		fail := func() {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=magic_link", http.StatusSeeOther)
+		}
+		if err := r.ParseForm(); err != nil {
+			fail()
+			return
+		}
+		var c magicLinkClaims
+		if !m.openLinkToken(linkPurposeMagicLink, r.FormValue("token"), &c) || c.ID == "" || c.UserID == "" {
+			fail()
+			return
+		}
+		if m.userIDForEmail(r.Context(), c.Email) != c.UserID {
+			log.Printf("[AUTH] magic-link: link for user %s no longer matches %s", c.UserID, c.Email)
+			fail()
+			return
+		}
+		if !m.magicLinkAllowed(r.Context(), c.UserID) {
+			log.Printf("[AUTH] magic-link: no longer allowed for user %s", c.UserID)
+			fail()
+			return
+		}
+		first, err := store.ConsumeMagicLink(r.Context(), c.ID, time.Now().Add(magicLinkTTL))
+		if err != nil || !first {
+			log.Printf("[AUTH] magic-link: replay or store failure for user %s: %v", c.UserID, err)
+			fail()
+			return
+		}
+		token, err := minter(r.Context(), c.UserID)
+		if err != nil || token == "" {
+			log.Printf("[AUTH] magic-link: mint session failed for user %s: %v", c.UserID, err)
+			fail()
+			return
+		}
+		log.Printf("[AUTH] magic-link login OK: user=%s", c.UserID)
+		// Following the link proves control of the address.
+		if m.emailVerificationEnabled() {
+			if err := m.deps.EmailVerification.SetEmailVerified(r.Context(), c.UserID, true); err != nil {
+				log.Printf("[AUTH] magic-link: could not mark user %s verified: %v", c.UserID, err)
+			}
+		}
+
+		if target, pending := m.parkLogin(w, r, token, c.UserID, c.Email); pending {
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
+		sessionMw.SetSessionCookie(w, token)
+		m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, "")
+
+		if principalLoader == nil || !principalLoader.IsEnabled() {
+			m.signedIn(w, r, token, c.UserID)
+			http.Redirect(w, r, entydad.DefaultAppRedirectURL, http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, m.routePrincipals(w, r, token, c.UserID), http.StatusSeeOther)
+	}
+}
+
+func (m *AuthModule) renderMagicLink(w http.ResponseWriter, r *http.Request, v view.View, state magiclinkmod.State) {
+	w.Header().Set("Cache-Control", "no-store")
+	result := v.Handle(magiclinkmod.WithState(r.Context(), state), &view.ViewContext{
+		Request:     r,
+		CurrentPath: r.URL.Path,
+	})
+	m.renderAuthView(w, r, result)
+}
+
+// MemoryMagicLinkStore is an in-process MagicLinkStore for tests and
+// single-instance hosts. Several instances need a shared store, or a link
+// could be used once per instance.
+type MemoryMagicLinkStore struct {
+	mu   sync.Mutex
+	used map[string]time.Time
+}
+
+// NewMemoryMagicLinkStore returns an empty store.
+func NewMemoryMagicLinkStore() *MemoryMagicLinkStore {
+	return &MemoryMagicLinkStore{used: make(map[string]time.Time)}
+}
+
+func (s *MemoryMagicLinkStore) ConsumeMagicLink(_ context.Context, id string, expires time.Time) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for k, exp := range s.used {
+		if now.After(exp) {
+			delete(s.used, k)
+		}
+	}
+	if _, ok := s.used[id]; ok {
+		return false, nil
+	}
+	s.used[id] = expires
+	return true, nil
+}
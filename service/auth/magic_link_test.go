@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	entydad "github.com/erniealice/entydad-golang"
+	magiclinkmod "github.com/erniealice/entydad-golang/service/auth/views/login02/magic-link"
+)
+
+func newMagicLinkTestModule(outbox Mailer, sessions *recordingSessionManager) *AuthModule {
+	return NewAuthModule(&Deps{
+		SessionManager: sessions,
+		SessionMinter: func(_ context.Context, userID string) (string, error) {
+			return "session-for-" + userID, nil
+		},
+		UserIDByEmail: func(_ context.Context, email string) string {
+			if email == "ana@example.com" {
+				return "user-ana"
+			}
+			return ""
+		},
+		Renderer:       nopRenderer{},
+		CSRFIssuer:     func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:     []byte("test-secret"),
+		Mailer:         outbox,
+		PublicBaseURL:  "https://app.example.com",
+		AllowMagicLink: true,
+	})
+}
+
+func TestMagicLink_SignInOnceAndNoEnumeration(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	sessions := &recordingSessionManager{}
+	m := newMagicLinkTestModule(outbox, sessions)
+	if !m.magicLinkEnabled() {
+		t.Fatal("magic link not enabled")
+	}
+
+	// Known and unknown addresses get the same answer; only one is mailed.
+	for _, email := range []string{"ana@example.com", "nobody@example.com"} {
+		rec := postForm(m.handleMagicLinkRequest(), entydad.AuthMagicLinkPostURL, url.Values{"email": {email}}, nil)
+		if got := rec.Header().Get("Location"); got != entydad.AuthMagicLinkURL+"?sent=1" {
+			t.Fatalf("request %s: → %q", email, got)
+		}
+	}
+	if n := len(outbox.Messages()); n != 1 {
+		t.Fatalf("%d mails, want 1", n)
+	}
+	msg, _ := outbox.Last("ana@example.com")
+	link, err := url.Parse(msg.Link)
+	if err != nil || msg.Kind != MailMagicLink || link.Path != entydad.AuthMagicLinkVerifyURL {
+		t.Fatalf("mail: %+v", msg)
+	}
+	token := link.Query().Get("token")
+
+	// GET (what a mail scanner would do) renders the confirm step only.
+	page := httptest.NewRecorder()
+	m.handleMagicLinkConfirm(&magiclinkmod.Deps{})(page, httptest.NewRequest(http.MethodGet, link.RequestURI(), nil))
+	if page.Code != http.StatusOK || page.Body.String() != "magic-link" || sessions.token != "" {
+		t.Fatalf("confirm page: %d %q session %q", page.Code, page.Body.String(), sessions.token)
+	}
+
+	for _, tc := range []struct{ token, want, session string }{
+		{token[:len(token)-2] + "xx", entydad.AuthLoginURL + "?error=magic_link", ""},
+		{token, entydad.DefaultAppRedirectURL, "session-for-user-ana"},
+		{token, entydad.AuthLoginURL + "?error=magic_link", ""}, // replay
+	} {
+		sessions.token = ""
+		rec := postForm(m.handleMagicLinkVerify(), entydad.AuthMagicLinkVerifyURL, url.Values{"token": {tc.token}}, nil)
+		if got := rec.Header().Get("Location"); got != tc.want || sessions.token != tc.session {
+			t.Fatalf("verify: → %q session %q, want → %q session %q", got, sessions.token, tc.want, tc.session)
+		}
+	}
+}
+
+func TestMagicLink_ThrottledPerEmail(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	m := newMagicLinkTestModule(outbox, &recordingSessionManager{})
+	rule := DefaultLoginAttemptPolicy().Rules[LoginScopeMagicLink]
+
+	var last string
+	for i := 0; i <= rule.EmailLimit; i++ {
+		rec := postForm(m.handleMagicLinkRequest(), entydad.AuthMagicLinkPostURL, url.Values{"email": {"ana@example.com"}}, nil)
+		last = rec.Header().Get("Location")
+	}
+	if last != entydad.AuthMagicLinkURL+"?error=throttled" {
+		t.Fatalf("request %d: → %q, want throttled", rule.EmailLimit+1, last)
+	}
+	if n := len(outbox.Messages()); n != rule.EmailLimit {
+		t.Fatalf("%d mails, want %d", n, rule.EmailLimit)
+	}
+}
+
+func TestMagicLink_WorkspaceToggle(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	m := newMagicLinkTestModule(outbox, &recordingSessionManager{})
+	// A toggle without a principal resolver cannot be evaluated: closed.
+	m.deps.MagicLinkAllowed = func(context.Context, string) bool { return true }
+
+	postForm(m.handleMagicLinkRequest(), entydad.AuthMagicLinkPostURL, url.Values{"email": {"ana@example.com"}}, nil)
+	if n := len(outbox.Messages()); n != 0 {
+		t.Fatalf("%d mails sent with the toggle unresolvable, want 0", n)
+	}
+}
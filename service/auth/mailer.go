@@ -0,0 +1,212 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"strings"
+	"text/template"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+)
+
+// Mailer delivers the auth module's transactional email (reset and
+// verification links, invitations, security alerts). Injected by the host —
+// SMTPMailer for real delivery, MemoryOutbox / FileOutbox for development
+// and E2E runs; nil disables every flow that needs to mail the user.
+type Mailer interface {
+	Send(ctx context.Context, msg MailMessage) error
+}
+
+// MailMessage is one outgoing email. Text is always set; HTML is optional.
+// Kind and Link are informational (the rendered template and its action
+// URL) so an outbox reader does not have to parse the body.
+type MailMessage struct {
+	To      string   `json:"to"`
+	Subject string   `json:"subject"`
+	Text    string   `json:"text"`
+	HTML    string   `json:"html,omitempty"`
+	Kind    MailKind `json:"kind,omitempty"`
+	Link    string   `json:"link,omitempty"`
+}
+
+// MailKind names one of the transactional templates.
+type MailKind string
+
+const (
+	MailPasswordReset MailKind = "password_reset"
+	MailVerifyEmail   MailKind = "verify_email"
+	MailMagicLink     MailKind = "magic_link"
+	MailInvitation    MailKind = "invitation"
+	MailSecurityAlert MailKind = "security_alert"
+)
+
+var mailKinds = []MailKind{MailPasswordReset, MailVerifyEmail, MailMagicLink, MailInvitation, MailSecurityAlert}
+
+// SecurityAlert names the account change a MailSecurityAlert reports.
+type SecurityAlert string
+
+const (
+	SecurityAlertPasswordChanged SecurityAlert = "password_changed"
+)
+
+// MailData is the per-message input of a template.
+type MailData struct {
+	To        string
+	Link      string        // action URL (reset, verify, magic link, invitation)
+	Workspace string        // invitation: workspace name
+	InvitedBy string        // invitation: inviter's display name
+	Alert     SecurityAlert // security_alert: what changed
+	IP        string        // security_alert: client IP of the change
+	Time      time.Time     // security_alert: when (zero = now)
+}
+
+//go:embed mail/*.html mail/*.txt
+var mailTemplatesFS embed.FS
+
+// MailTemplates renders MailMessages from a layout plus one template per
+// MailKind. Each kind ships "<kind>.txt" defining "subject" and "text", and
+// "<kind>.html" defining "content"; "layout.txt" / "layout.html" wrap them.
+// All copy comes from entydad.AuthEmailLabels (lyngua "auth.json" → "email"),
+// with "{workspace}", "{inviter}" and "{email}" filled from MailData.
+type MailTemplates struct {
+	text map[MailKind]*template.Template
+	html map[MailKind]*htmltemplate.Template
+}
+
+// DefaultMailTemplates returns the built-in templates.
+func DefaultMailTemplates() *MailTemplates {
+	t, err := ParseMailTemplates(mailTemplatesFS)
+	if err != nil {
+		panic("auth: built-in mail templates: " + err.Error())
+	}
+	return t
+}
+
+// ParseMailTemplates parses a host-supplied template set laid out like the
+// built-in one (a "mail" directory with layout.* and <kind>.* files), so a
+// host can rebrand the emails without forking the module.
+func ParseMailTemplates(fsys fs.FS) (*MailTemplates, error) {
+	t := &MailTemplates{
+		text: make(map[MailKind]*template.Template, len(mailKinds)),
+		html: make(map[MailKind]*htmltemplate.Template, len(mailKinds)),
+	}
+	for _, kind := range mailKinds {
+		txt, err := template.New("layout.txt").Funcs(template.FuncMap{"fill": fillMailLabel}).
+			ParseFS(fsys, "mail/layout.txt", "mail/"+string(kind)+".txt")
+		if err != nil {
+			return nil, err
+		}
+		html, err := htmltemplate.New("layout.html").Funcs(htmltemplate.FuncMap{"fill": fillMailLabel}).
+			ParseFS(fsys, "mail/layout.html", "mail/"+string(kind)+".html")
+		if err != nil {
+			return nil, err
+		}
+		t.text[kind], t.html[kind] = txt, html
+	}
+	return t, nil
+}
+
+// mailView is the template data: the labels, the message input and the
+// alert copy resolved for MailSecurityAlert.
+type mailView struct {
+	L entydad.AuthEmailLabels
+	MailData
+	AlertSubject string
+	AlertHeading string
+	AlertBody    string
+	When         string
+}
+
+// Render builds the MailMessage of kind for d with labels l.
+func (t *MailTemplates) Render(kind MailKind, l entydad.AuthEmailLabels, d MailData) (MailMessage, error) {
+	txt, html := t.text[kind], t.html[kind]
+	if txt == nil || html == nil {
+		return MailMessage{}, fmt.Errorf("auth: unknown mail kind %q", kind)
+	}
+	v := mailView{L: l, MailData: d}
+	if kind == MailSecurityAlert {
+		switch d.Alert {
+		case SecurityAlertPasswordChanged:
+			v.AlertSubject, v.AlertHeading, v.AlertBody = l.PasswordChangedSubject, l.PasswordChangedHeading, l.PasswordChangedBody
+		default:
+			return MailMessage{}, fmt.Errorf("auth: unknown security alert %q", d.Alert)
+		}
+		when := d.Time
+		if when.IsZero() {
+			when = time.Now()
+		}
+		v.When = when.UTC().Format("2006-01-02 15:04 MST")
+	}
+
+	var subject, text, body bytes.Buffer
+	if err := txt.ExecuteTemplate(&subject, "subject", v); err != nil {
+		return MailMessage{}, err
+	}
+	if err := txt.Execute(&text, v); err != nil {
+		return MailMessage{}, err
+	}
+	if err := html.Execute(&body, v); err != nil {
+		return MailMessage{}, err
+	}
+	return MailMessage{
+		To:      d.To,
+		Subject: strings.TrimSpace(subject.String()),
+		Text:    text.String(),
+		HTML:    body.String(),
+		Kind:    kind,
+		Link:    d.Link,
+	}, nil
+}
+
+// fillMailLabel substitutes the MailData placeholders in a label.
+func fillMailLabel(label string, v mailView) string {
+	return strings.NewReplacer(
+		"{workspace}", v.Workspace,
+		"{inviter}", v.InvitedBy,
+		"{email}", v.To,
+	).Replace(label)
+}
+
+// mailEnabled reports whether the module can send emailed links.
+func (m *AuthModule) mailEnabled() bool {
+	return m.deps.Mailer != nil && m.deps.PublicBaseURL != ""
+}
+
+// sendMail renders kind for d with Labels.Email (English defaults when the
+// host loaded none) and hands it to the Mailer.
+func (m *AuthModule) sendMail(ctx context.Context, kind MailKind, d MailData) error {
+	l := m.deps.Labels.Email
+	if l == (entydad.AuthEmailLabels{}) {
+		l = entydad.DefaultAuthEmailLabels()
+	}
+	msg, err := m.deps.MailTemplates.Render(kind, l, d)
+	if err != nil {
+		return err
+	}
+	return m.deps.Mailer.Send(ctx, msg)
+}
+
+// sendSecurityAlert mails email that alert just happened on their account.
+// Best effort: a delivery failure is logged, never surfaced to the request.
+func (m *AuthModule) sendSecurityAlert(r *http.Request, email string, alert SecurityAlert) {
+	if !m.mailEnabled() || email == "" {
+		return
+	}
+	d := MailData{To: email, Alert: alert, IP: m.clientIP(r), Time: time.Now()}
+	if err := m.sendMail(r.Context(), MailSecurityAlert, d); err != nil {
+		log.Printf("[AUTH] security alert %s to %s failed: %v", alert, email, err)
+	}
+}
+
+// publicURL joins path onto the configured PublicBaseURL. Emailed links
+// never derive their origin from the request Host header.
+func (m *AuthModule) publicURL(path string) string {
+	return strings.TrimRight(m.deps.PublicBaseURL, "/") + path
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MailOutbox is a Mailer that keeps what it was asked to send. Both
+// implementations below satisfy it; with Deps.TestMode the module exposes
+// the latest message per recipient on GET /test/outbox?to=… so E2E specs
+// can follow emailed links without a real mail pipeline.
+type MailOutbox interface {
+	Mailer
+	// Last returns the most recent message sent to the address (case-
+	// insensitive), false when there is none.
+	Last(to string) (MailMessage, bool)
+}
+
+// MemoryOutbox is an in-process MailOutbox for tests and single-instance
+// development hosts.
+type MemoryOutbox struct {
+	mu       sync.Mutex
+	messages []MailMessage
+}
+
+// NewMemoryOutbox returns an empty outbox.
+func NewMemoryOutbox() *MemoryOutbox {
+	return &MemoryOutbox{}
+}
+
+func (o *MemoryOutbox) Send(_ context.Context, msg MailMessage) error {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.messages = append(o.messages, msg)
+	return nil
+}
+
+// Messages returns a copy of everything sent, oldest first.
+func (o *MemoryOutbox) Messages() []MailMessage {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return append([]MailMessage(nil), o.messages...)
+}
+
+func (o *MemoryOutbox) Last(to string) (MailMessage, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for i := len(o.messages) - 1; i >= 0; i-- {
+		if strings.EqualFold(o.messages[i].To, to) {
+			return o.messages[i], true
+		}
+	}
+	return MailMessage{}, false
+}
+
+// FileOutbox writes every message as a JSON file (MailMessage fields) into a
+// directory, so a spec runner in another process — or several app
+// instances — can read the mail. File names sort in send order.
+type FileOutbox struct {
+	dir string
+	mu  sync.Mutex
+	seq uint64
+}
+
+// NewFileOutbox returns an outbox writing into dir, creating it if needed.
+func NewFileOutbox(dir string) (*FileOutbox, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileOutbox{dir: dir}, nil
+}
+
+func (o *FileOutbox) Send(_ context.Context, msg MailMessage) error {
+	body, err := json.MarshalIndent(msg, "", "  ")
+	if err != nil {
+		return err
+	}
+	o.mu.Lock()
+	o.seq++
+	name := fmt.Sprintf("%020d-%06d.json", time.Now().UnixNano(), o.seq)
+	o.mu.Unlock()
+	// Write then rename, so a reader never sees a half-written file.
+	tmp := filepath.Join(o.dir, "."+name+".tmp")
+	if err := os.WriteFile(tmp, body, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(o.dir, name))
+}
+
+func (o *FileOutbox) Last(to string) (MailMessage, bool) {
+	names, err := filepath.Glob(filepath.Join(o.dir, "*.json"))
+	if err != nil {
+		return MailMessage{}, false
+	}
+	sort.Strings(names)
+	for i := len(names) - 1; i >= 0; i-- {
+		body, err := os.ReadFile(names[i])
+		if err != nil {
+			continue
+		}
+		var msg MailMessage
+		if json.Unmarshal(body, &msg) == nil && strings.EqualFold(msg.To, to) {
+			return msg, true
+		}
+	}
+	return MailMessage{}, false
+}
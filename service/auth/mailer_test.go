@@ -0,0 +1,158 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/mail"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+)
+
+func TestMailTemplates_RenderEveryKind(t *testing.T) {
+	t.Parallel()
+	tpl := DefaultMailTemplates()
+	l := entydad.DefaultAuthEmailLabels()
+	d := MailData{
+		To:        "ann@example.com",
+		Link:      "https://app.example.com/x?token=a&b=<c>",
+		Workspace: "Acme & Co",
+		InvitedBy: "Bo",
+		Alert:     SecurityAlertPasswordChanged,
+		IP:        "203.0.113.9",
+		Time:      time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC),
+	}
+	for _, tc := range []struct {
+		kind    MailKind
+		subject string
+		want    []string
+	}{
+		{MailPasswordReset, l.ResetSubject, []string{l.ResetBody, d.Link, l.ResetExpiry}},
+		{MailVerifyEmail, l.VerifySubject, []string{l.VerifyBody, d.Link, l.VerifyExpiry}},
+		{MailMagicLink, l.MagicLinkSubject, []string{l.MagicLinkBody, d.Link, l.MagicLinkExpiry}},
+		{MailInvitation, "You're invited to join Acme & Co", []string{"Bo invited you to join the Acme & Co workspace", d.Link}},
+		{MailSecurityAlert, l.PasswordChangedSubject, []string{l.PasswordChangedBody, "2026-03-01 09:30 UTC", d.IP, l.AlertHelp}},
+	} {
+		msg, err := tpl.Render(tc.kind, l, d)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.kind, err)
+		}
+		if msg.Subject != tc.subject || msg.To != d.To || msg.Kind != tc.kind {
+			t.Errorf("%s: subject %q to %q kind %q", tc.kind, msg.Subject, msg.To, msg.Kind)
+		}
+		for _, w := range append(tc.want, l.SecurityNotice) {
+			if !strings.Contains(msg.Text, w) {
+				t.Errorf("%s: text lacks %q:\n%s", tc.kind, w, msg.Text)
+			}
+		}
+		if strings.Contains(msg.HTML, "<c>") || strings.Contains(msg.HTML, "Acme & Co") {
+			t.Errorf("%s: html not escaped:\n%s", tc.kind, msg.HTML)
+		}
+	}
+	if _, err := tpl.Render(MailSecurityAlert, l, MailData{Alert: "nope"}); err == nil {
+		t.Error("unknown alert rendered")
+	}
+}
+
+func TestOutboxes_Last(t *testing.T) {
+	t.Parallel()
+	file, err := NewFileOutbox(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, o := range map[string]MailOutbox{"memory": NewMemoryOutbox(), "file": file} {
+		for _, msg := range []MailMessage{
+			{To: "a@example.com", Subject: "1"},
+			{To: "b@example.com", Subject: "2"},
+			{To: "A@example.com", Subject: "3", Link: "https://x/y"},
+		} {
+			if err := o.Send(context.Background(), msg); err != nil {
+				t.Fatalf("%s: %v", name, err)
+			}
+		}
+		if got, ok := o.Last("a@example.com"); !ok || got.Subject != "3" || got.Link != "https://x/y" {
+			t.Errorf("%s: Last(a) = %+v, %v", name, got, ok)
+		}
+		if _, ok := o.Last("c@example.com"); ok {
+			t.Errorf("%s: Last(c) found a message", name)
+		}
+	}
+}
+
+func TestBuildMIMEMessage(t *testing.T) {
+	t.Parallel()
+	from, _ := mail.ParseAddress("Acme <no-reply@acme.example>")
+	to, _ := mail.ParseAddress("ann@example.com")
+	raw, err := buildMIMEMessage(from, to, MailMessage{Subject: "Grüße", Text: "hi\nthere", HTML: "<p>hi</p>"}, time.Now())
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := mail.ReadMessage(strings.NewReader(string(raw)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if subj, _ := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject")); subj != "Grüße" {
+		t.Errorf("subject = %q", subj)
+	}
+	_, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	var bodies []string
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, _ := io.ReadAll(p) // multipart decodes quoted-printable
+		bodies = append(bodies, string(b))
+	}
+	if len(bodies) != 2 || bodies[0] != "hi\r\nthere" || bodies[1] != "<p>hi</p>" {
+		t.Errorf("parts = %q", bodies)
+	}
+
+	if _, err := buildMIMEMessage(from, to, MailMessage{Subject: "x\r\nBcc: evil@example.com"}, time.Now()); err == nil {
+		t.Error("subject with CRLF accepted")
+	}
+}
+
+func TestPasswordReset_MailsLinkToOutbox(t *testing.T) {
+	t.Parallel()
+	outbox := NewMemoryOutbox()
+	m, adapter := newVerificationTestModule(outbox, &recordingSessionManager{})
+	m.deps.TestMode = true
+	adapter.users["ann@example.com"] = "pw"
+
+	for _, email := range []string{"ann@example.com", "ghost@example.com"} {
+		rec := postForm(m.handleResetPasswordRequest(), entydad.AuthResetPasswordURL, url.Values{"email": {email}}, nil)
+		if got := rec.Header().Get("Location"); got != entydad.AuthResetPasswordURL+"?sent=true" {
+			t.Fatalf("%s: → %q", email, got)
+		}
+	}
+	if n := len(outbox.Messages()); n != 1 {
+		t.Fatalf("%d mails, want 1 (none for unknown addresses)", n)
+	}
+
+	rec := httptest.NewRecorder()
+	m.handleTestOutbox(outbox)(rec, httptest.NewRequest(http.MethodGet, "/test/outbox?to=ann@example.com", nil))
+	var msg MailMessage
+	if err := json.NewDecoder(rec.Body).Decode(&msg); err != nil {
+		t.Fatal(err)
+	}
+	want := "https://app.example.com" + entydad.AuthResetConfirmURL + "?token=" + url.QueryEscape("reset/ann@example.com")
+	if msg.Kind != MailPasswordReset || msg.Link != want || !strings.Contains(msg.Text, want) {
+		t.Fatalf("outbox: %+v", msg)
+	}
+}
@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"sync"
+	"time"
+)
+
+// MFA errors returned by the account-page methods (MFAConfirmSetup,
+// MFADisable, MFARegenerateRecoveryCodes). Callers map them to labels, along
+// with ErrImpersonating: none of them run in an impersonated session.
+var (
+	ErrMFANotConfigured = errors.New("auth: two-step verification is not configured")
+	ErrMFANotEnrolled   = errors.New("auth: two-step verification is not enabled for this user")
+	ErrMFAInvalidCode   = errors.New("auth: invalid verification code")
+	// ErrMFAAlreadyEnrolled: MFAConfirmSetup never replaces an enrolment;
+	// MFADisable (which asks for a current code) has to remove it first.
+	ErrMFAAlreadyEnrolled = errors.New("auth: two-step verification is already enabled for this user")
+)
+
+// MFAEnrollment is a user's TOTP enrolment. Secret is the base32 shared key
+// (the store is responsible for encrypting it at rest); RecoveryCodeHashes
+// holds the SHA-256 of each unused recovery code; LastUsedStep is the most
+// recent accepted TOTP time step, so a code cannot be replayed.
+type MFAEnrollment struct {
+	Secret             string
+	RecoveryCodeHashes []string
+	LastUsedStep       int64
+	EnrolledAt         time.Time
+}
+
+// MFAStore persists TOTP enrolments. GetEnrollment returns (nil, nil) when
+// the user has not enrolled. Satisfied by the host's user-credential
+// repository; NewMemoryMFAStore is the per-process default for tests.
+type MFAStore interface {
+	GetEnrollment(ctx context.Context, userID string) (*MFAEnrollment, error)
+	SaveEnrollment(ctx context.Context, userID string, enrollment MFAEnrollment) error
+	DeleteEnrollment(ctx context.Context, userID string) error
+}
+
+// MFARequired reports whether a workspace requires two-step verification
+// for its operator principals (OPERATOR_OWNER / OPERATOR_STAFF). Injected as
+// a closure over the workspace settings lookup.
+type MFARequired func(ctx context.Context, workspaceID string) bool
+
+// consumeMFACode checks code against the enrolment — a 6-digit TOTP code or
+// one of the recovery codes — and records its use on e (LastUsedStep
+// advanced / recovery hash removed). The caller saves e when ok.
+func consumeMFACode(e *MFAEnrollment, code string, now time.Time) bool {
+	if step, ok := verifyTOTP(e.Secret, code, now, e.LastUsedStep); ok {
+		e.LastUsedStep = step
+		return true
+	}
+	hash := hashRecoveryCode(code)
+	for i, h := range e.RecoveryCodeHashes {
+		if subtle.ConstantTimeCompare([]byte(h), []byte(hash)) == 1 {
+			e.RecoveryCodeHashes = append(e.RecoveryCodeHashes[:i:i], e.RecoveryCodeHashes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ---------------------------------------------------------------------------
+// Account-page methods. The portal account module takes these as closures
+// (MFAStatus, MFABeginSetup, ...) so it never imports the store directly.
+// ---------------------------------------------------------------------------
+
+// MFAStatus reports whether userID has two-step verification enabled and how
+// many unused recovery codes remain.
+func (m *AuthModule) MFAStatus(ctx context.Context, userID string) (enabled bool, recoveryRemaining int, err error) {
+	if m.deps.MFAStore == nil {
+		return false, 0, ErrMFANotConfigured
+	}
+	e, err := m.deps.MFAStore.GetEnrollment(ctx, userID)
+	if err != nil || e == nil {
+		return false, 0, err
+	}
+	return true, len(e.RecoveryCodeHashes), nil
+}
+
+// MFABeginSetup generates a fresh secret for the account-page enrolment
+// form. Nothing is stored until MFAConfirmSetup proves the authenticator
+// app produces matching codes.
+func (m *AuthModule) MFABeginSetup(ctx context.Context, userID, email string) (secret, provisioningURI string, err error) {
+	if m.deps.MFAStore == nil {
+		return "", "", ErrMFANotConfigured
+	}
+	if m.impersonating(ctx, nil) {
+		return "", "", ErrImpersonating
+	}
+	secret, err = GenerateTOTPSecret()
+	if err != nil {
+		return "", "", err
+	}
+	return secret, TOTPProvisioningURI(m.mfaIssuer(), email, secret), nil
+}
+
+// MFAConfirmSetup verifies code against secret and, on success, saves the
+// enrolment. It refuses with ErrMFAAlreadyEnrolled when the user already
+// has one, so nobody can swap the secret without a current code. It
+// returns the plaintext recovery codes — the only time they are ever
+// available.
+func (m *AuthModule) MFAConfirmSetup(ctx context.Context, userID, secret, code string) ([]string, error) {
+	if m.deps.MFAStore == nil {
+		return nil, ErrMFANotConfigured
+	}
+	if m.impersonating(ctx, nil) {
+		return nil, ErrImpersonating
+	}
+	existing, err := m.deps.MFAStore.GetEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+	now := time.Now()
+	step, ok := verifyTOTP(secret, code, now, 0)
+	if !ok {
+		return nil, ErrMFAInvalidCode
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := m.deps.MFAStore.SaveEnrollment(ctx, userID, MFAEnrollment{
+		Secret:             secret,
+		RecoveryCodeHashes: hashes,
+		LastUsedStep:       step,
+		EnrolledAt:         now,
+	}); err != nil {
+		return nil, err
+	}
+	m.recordAuthEvent(ctx, nil, AuthEvent{Type: AuthEventMFAEnabled, UserID: userID})
+	return codes, nil
+}
+
+// MFADisable removes the enrolment after checking a current TOTP or
+// recovery code.
+func (m *AuthModule) MFADisable(ctx context.Context, userID, code string) error {
+	if m.impersonating(ctx, nil) {
+		return ErrImpersonating
+	}
+	if _, err := m.verifyEnrolledCode(ctx, userID, code); err != nil {
+		return err
+	}
+	if err := m.deps.MFAStore.DeleteEnrollment(ctx, userID); err != nil {
+		return err
+	}
+	m.recordAuthEvent(ctx, nil, AuthEvent{Type: AuthEventMFADisabled, UserID: userID})
+	return nil
+}
+
+// MFARegenerateRecoveryCodes replaces every recovery code after checking a
+// current TOTP or recovery code, returning the new plaintext codes.
+func (m *AuthModule) MFARegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if m.impersonating(ctx, nil) {
+		return nil, ErrImpersonating
+	}
+	e, err := m.verifyEnrolledCode(ctx, userID, code)
+	if err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	e.RecoveryCodeHashes = hashes
+	if err := m.deps.MFAStore.SaveEnrollment(ctx, userID, *e); err != nil {
+		return nil, err
+	}
+	m.recordAuthEvent(ctx, nil, AuthEvent{Type: AuthEventMFARecoveryCodesRegenerated, UserID: userID})
+	return codes, nil
+}
+
+// verifyEnrolledCode loads the enrolment and consumes code against it,
+// saving the updated replay/recovery state.
+func (m *AuthModule) verifyEnrolledCode(ctx context.Context, userID, code string) (*MFAEnrollment, error) {
+	if m.deps.MFAStore == nil {
+		return nil, ErrMFANotConfigured
+	}
+	e, err := m.deps.MFAStore.GetEnrollment(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if !consumeMFACode(e, code, time.Now()) {
+		return nil, ErrMFAInvalidCode
+	}
+	if err := m.deps.MFAStore.SaveEnrollment(ctx, userID, *e); err != nil {
+		return nil, err
+	}
+	return e, nil
+}
+
+func (m *AuthModule) mfaIssuer() string {
+	if m.deps.MFAIssuer != "" {
+		return m.deps.MFAIssuer
+	}
+	return m.deps.LogoText
+}
+
+// MemoryMFAStore is an in-process MFAStore for tests and single-instance
+// development hosts.
+type MemoryMFAStore struct {
+	mu          sync.Mutex
+	enrollments map[string]MFAEnrollment
+}
+
+// NewMemoryMFAStore returns an empty in-memory enrolment store.
+func NewMemoryMFAStore() *MemoryMFAStore {
+	return &MemoryMFAStore{enrollments: make(map[string]MFAEnrollment)}
+}
+
+func (s *MemoryMFAStore) GetEnrollment(_ context.Context, userID string) (*MFAEnrollment, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.enrollments[userID]
+	if !ok {
+		return nil, nil
+	}
+	e.RecoveryCodeHashes = append([]string(nil), e.RecoveryCodeHashes...)
+	return &e, nil
+}
+
+func (s *MemoryMFAStore) SaveEnrollment(_ context.Context, userID string, e MFAEnrollment) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e.RecoveryCodeHashes = append([]string(nil), e.RecoveryCodeHashes...)
+	s.enrollments[userID] = e
+	return nil
+}
+
+func (s *MemoryMFAStore) DeleteEnrollment(_ context.Context, userID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.enrollments, userID)
+	return nil
+}
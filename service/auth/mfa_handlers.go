@@ -0,0 +1,325 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	mfamod "github.com/erniealice/entydad-golang/service/auth/views/login02/mfa"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+const (
+	mfaPendingCookieName = "mfa_pending"
+	// mfaPendingTTL bounds how long a password-verified login may sit at the
+	// challenge before the user has to sign in again.
+	mfaPendingTTL = 5 * time.Minute
+)
+
+// mfaPending is the login parked between the first factor and the TOTP
+// challenge. It travels in an encrypted, HttpOnly cookie scoped to /auth/
+// rather than as the session cookie, so the session token is never usable by
+// the browser until the challenge passes — the session stays principal-less
+// AND cookie-less until then. Secret is set only in setup mode (workspace
+// requires MFA, user not yet enrolled) so the key survives a re-render.
+type mfaPending struct {
+	Token   string `json:"t"`
+	UserID  string `json:"u"`
+	Email   string `json:"e"`
+	Setup   bool   `json:"s,omitempty"`
+	Secret  string `json:"k,omitempty"`
+	Method  string `json:"m,omitempty"` // sign-in method, for the security event log
+	Expires int64  `json:"x"`
+}
+
+// beginMFA decides whether a freshly authenticated login must pass the TOTP
+// challenge before principal routing. When it must, the pending login is
+// parked in the mfa_pending cookie and the caller redirects to the returned
+// target instead of setting the session cookie. pending=false means carry on
+// with SetSessionCookie + routePrincipals exactly as before.
+//
+// A store failure fails CLOSED: the session is invalidated and the user is
+// sent back to login rather than let through unchallenged.
+func (m *AuthModule) beginMFA(w http.ResponseWriter, r *http.Request, token, userID, email string) (target string, pending bool) {
+	store := m.deps.MFAStore
+	if store == nil || userID == "" {
+		return "", false
+	}
+	enrollment, err := store.GetEnrollment(r.Context(), userID)
+	if err != nil {
+		log.Printf("[AUTH] mfa: enrolment lookup failed for user %s: %v", userID, err)
+		m.abandonSession(r.Context(), token)
+		return entydad.AuthLoginURL + "?error=mfa", true
+	}
+	p := mfaPending{Token: token, UserID: userID, Email: email, Method: m.signInMethod(r)}
+	if enrollment == nil {
+		if !m.mfaRequired(r.Context(), userID) {
+			return "", false
+		}
+		p.Setup = true
+	}
+	if err := m.setMFAPending(w, p); err != nil {
+		log.Printf("[AUTH] mfa: pending cookie failed for user %s: %v", userID, err)
+		m.abandonSession(r.Context(), token)
+		return entydad.AuthLoginURL + "?error=mfa", true
+	}
+	return entydad.AuthMFAURL, true
+}
+
+// mfaRequired reports whether any of the user's operator principals
+// (OPERATOR_OWNER / OPERATOR_STAFF) belongs to a workspace that requires
+// two-step verification. Client, supplier and delegate principals are never
+// forced to enrol.
+func (m *AuthModule) mfaRequired(ctx context.Context, userID string) bool {
+	required := m.deps.MFARequired
+	loader := m.deps.PrincipalResolver
+	if required == nil || loader == nil || !loader.IsEnabled() {
+		return false
+	}
+	principals, err := loader.Resolve(ctx, userID)
+	if err != nil {
+		// routePrincipals will hit the same error and land on no-access.
+		return false
+	}
+	for _, p := range principals {
+		switch p.Type {
+		case PrincipalTypeOperatorOwner, PrincipalTypeOperatorStaff:
+			if required(ctx, p.WorkspaceID) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleMFAPage returns the GET /auth/mfa handler: the TOTP challenge,
+// ?recovery=1 for the recovery-code form, or first-time setup when the
+// pending login was flagged by a workspace MFA requirement.
+func (m *AuthModule) handleMFAPage(page *mfamod.Deps) http.HandlerFunc {
+	v := mfamod.NewView(page)
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		p, ok := m.readMFAPending(r)
+		if !ok {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=mfa_expired", http.StatusSeeOther)
+			return
+		}
+		state := mfamod.State{Mode: mfamod.ModeChallenge}
+		switch {
+		case p.Setup:
+			if p.Secret == "" {
+				secret, err := GenerateTOTPSecret()
+				if err != nil {
+					log.Printf("[AUTH] mfa: secret generation failed: %v", err)
+					http.Error(w, "internal error", http.StatusInternalServerError)
+					return
+				}
+				p.Secret = secret
+				if err := m.setMFAPending(w, p); err != nil {
+					log.Printf("[AUTH] mfa: pending cookie failed for user %s: %v", p.UserID, err)
+					http.Error(w, "internal error", http.StatusInternalServerError)
+					return
+				}
+			}
+			state = m.mfaSetupState(p)
+		case r.URL.Query().Get("recovery") == "1":
+			state.Mode = mfamod.ModeRecovery
+		}
+		m.renderMFA(w, r, v, state)
+	}
+}
+
+// handleMFAVerify returns the POST /auth/mfa handler. On success it sets the
+// session cookie that was withheld at login and hands off to routePrincipals;
+// setup mode first shows the new recovery codes with a continue link.
+func (m *AuthModule) handleMFAVerify(page *mfamod.Deps) http.HandlerFunc {
+	v := mfamod.NewView(page)
+	limiter := m.deps.LoginAttemptLimiter
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-store")
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+		p, ok := m.readMFAPending(r)
+		if !ok {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=mfa_expired", http.StatusSeeOther)
+			return
+		}
+		if r.FormValue("action") == "cancel" {
+			m.abandonMFA(w, r, p)
+			http.Redirect(w, r, entydad.AuthLoginURL, http.StatusSeeOther)
+			return
+		}
+
+		mode := mfamod.ModeChallenge
+		code := r.FormValue("code")
+		if rc := strings.TrimSpace(r.FormValue("recovery_code")); rc != "" {
+			mode, code = mfamod.ModeRecovery, rc
+		}
+		retry := func(errorCode string) {
+			state := mfamod.State{Mode: mode, ErrorCode: errorCode}
+			if p.Setup {
+				state = m.mfaSetupState(p)
+				state.ErrorCode = errorCode
+			}
+			m.renderMFA(w, r, v, state)
+		}
+
+		attempt := LoginAttempt{Scope: LoginScopeMFA, Email: p.Email, IP: m.clientIP(r)}
+		decision := limiter.Check(r.Context(), attempt)
+		if decision.Locked {
+			log.Printf("[AUTH] mfa locked for %s from %s", p.Email, attempt.IP)
+			m.mfaChallengeFailed(r, p, AuthFailureLocked)
+			m.abandonMFA(w, r, p)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=locked", http.StatusSeeOther)
+			return
+		}
+		if !decision.Allowed {
+			setRetryAfter(w, decision.RetryAfter)
+			retry("throttled")
+			return
+		}
+		if !waitLoginDelay(r.Context(), decision.Delay) {
+			return
+		}
+
+		var recoveryCodes []string
+		var err error
+		if p.Setup {
+			recoveryCodes, err = m.MFAConfirmSetup(r.Context(), p.UserID, p.Secret, code)
+		} else {
+			_, err = m.verifyEnrolledCode(r.Context(), p.UserID, code)
+		}
+		verified := err == nil
+		if errors.Is(err, ErrMFAInvalidCode) {
+			err = nil
+		}
+		if err != nil {
+			// Store failure (or the enrolment vanished mid-challenge): fail
+			// closed back to login.
+			log.Printf("[AUTH] mfa verify failed for user %s: %v", p.UserID, err)
+			m.abandonMFA(w, r, p)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=mfa", http.StatusSeeOther)
+			return
+		}
+		if !verified {
+			if limiter.RecordFailure(r.Context(), attempt).Locked {
+				m.mfaChallengeFailed(r, p, AuthFailureLocked)
+				m.abandonMFA(w, r, p)
+				http.Redirect(w, r, entydad.AuthLoginURL+"?error=locked", http.StatusSeeOther)
+				return
+			}
+			m.mfaChallengeFailed(r, p, AuthFailureInvalidCode)
+			retry("invalid")
+			return
+		}
+		limiter.RecordSuccess(r.Context(), attempt)
+		log.Printf("[AUTH] mfa OK: user=%s setup=%t", p.UserID, p.Setup)
+		m.recordAuthEvent(r.Context(), r, AuthEvent{Type: AuthEventMFAChallengeSucceeded, UserID: p.UserID, Email: p.Email, Method: p.Method})
+
+		target := m.completeMFA(w, r, p)
+		if p.Setup {
+			m.renderMFA(w, r, v, mfamod.State{
+				Mode:          mfamod.ModeRecoveryCodes,
+				RecoveryCodes: recoveryCodes,
+				ContinueURL:   target,
+			})
+			return
+		}
+		http.Redirect(w, r, target, http.StatusSeeOther)
+	}
+}
+
+// completeMFA releases the parked login: the pending cookie is cleared and,
+// unless the consent step is due next, the held session is issued
+// (releaseLogin).
+func (m *AuthModule) completeMFA(w http.ResponseWriter, r *http.Request, p mfaPending) string {
+	m.clearMFAPending(w)
+	if _, err := m.deps.AuthAdapter.ValidateSession(r.Context(), p.Token); err != nil {
+		log.Printf("[AUTH] mfa: parked session no longer valid for user %s: %v", p.UserID, err)
+		return entydad.AuthLoginURL + "?error=mfa_expired"
+	}
+	if target, pending := m.beginConsent(w, r, p.Token, p.UserID, p.Email); pending {
+		return target
+	}
+	return m.releaseLogin(w, r, p.Token, p.UserID)
+}
+
+// releaseLogin issues the session of a login parked by parkLogin: the
+// session cookie + CSRF are set exactly as the password path would have,
+// and the shared routePrincipals tail picks the destination.
+func (m *AuthModule) releaseLogin(w http.ResponseWriter, r *http.Request, token, userID string) string {
+	m.deps.SessionManager.SetSessionCookie(w, token)
+	m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, "")
+	principalLoader := m.deps.PrincipalResolver
+	if principalLoader == nil || !principalLoader.IsEnabled() {
+		m.signedIn(w, r, token, userID)
+		return entydad.DefaultAppRedirectURL
+	}
+	return m.routePrincipals(w, r, token, userID)
+}
+
+// mfaChallengeFailed records a refused two-step code.
+func (m *AuthModule) mfaChallengeFailed(r *http.Request, p mfaPending, reason string) {
+	m.recordAuthEvent(r.Context(), r, AuthEvent{Type: AuthEventMFAChallengeFailed, UserID: p.UserID, Email: p.Email, Method: p.Method, Reason: reason})
+}
+
+// abandonMFA drops a parked login (cancel, lockout, store failure).
+func (m *AuthModule) abandonMFA(w http.ResponseWriter, r *http.Request, p mfaPending) {
+	m.clearMFAPending(w)
+	m.abandonSession(r.Context(), p.Token)
+}
+
+func (m *AuthModule) abandonSession(ctx context.Context, token string) {
+	if m.deps.AuthAdapter == nil {
+		return
+	}
+	if err := m.deps.AuthAdapter.InvalidateSession(ctx, token); err != nil {
+		log.Printf("[AUTH] mfa: failed to invalidate parked session: %v", err)
+	}
+}
+
+func (m *AuthModule) mfaSetupState(p mfaPending) mfamod.State {
+	return mfamod.State{
+		Mode:            mfamod.ModeSetup,
+		Secret:          p.Secret,
+		ProvisioningURI: TOTPProvisioningURI(m.mfaIssuer(), p.Email, p.Secret),
+	}
+}
+
+func (m *AuthModule) renderMFA(w http.ResponseWriter, r *http.Request, v view.View, state mfamod.State) {
+	result := v.Handle(mfamod.WithState(r.Context(), state), &view.ViewContext{
+		Request:     r,
+		CurrentPath: r.URL.Path,
+	})
+	m.renderAuthView(w, r, result)
+}
+
+func (m *AuthModule) setMFAPending(w http.ResponseWriter, p mfaPending) error {
+	if p.Expires == 0 {
+		p.Expires = time.Now().Add(mfaPendingTTL).Unix()
+	}
+	return m.setSealedCookie(w, mfaPendingCookieName, p, time.Until(time.Unix(p.Expires, 0)))
+}
+
+func (m *AuthModule) readMFAPending(r *http.Request) (mfaPending, bool) {
+	var p mfaPending
+	if !m.openSealedCookie(r, mfaPendingCookieName, &p) {
+		return mfaPending{}, false
+	}
+	if p.Token == "" || p.UserID == "" || time.Now().Unix() > p.Expires {
+		return mfaPending{}, false
+	}
+	return p, true
+}
+
+func (m *AuthModule) clearMFAPending(w http.ResponseWriter) {
+	m.clearSealedCookie(w, mfaPendingCookieName)
+}
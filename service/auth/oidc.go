@@ -0,0 +1,478 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Generic OpenID Connect sign-in (Keycloak, Okta, Entra ID, ...):
+// authorization code + PKCE (S256), ID token verified against the
+// provider's JWKS. Only the ID token is used — the access token is never
+// stored — and only RS256 / ES256 signatures are accepted.
+
+var (
+	errOIDCDiscovery = errors.New("auth: oidc discovery failed")
+	errOIDCExchange  = errors.New("auth: oidc code exchange failed")
+	errOIDCToken     = errors.New("auth: oidc id token invalid")
+	errOIDCEmail     = errors.New("auth: oidc id token has no usable email")
+)
+
+const (
+	oidcHTTPTimeout = 10 * time.Second
+	// oidcClockLeeway absorbs clock skew between this host and the IdP.
+	oidcClockLeeway = time.Minute
+	// oidcJWKSRefetch throttles JWKS refreshes triggered by an unknown kid
+	// (key rotation) so forged kids can't hammer the IdP.
+	oidcJWKSRefetch = time.Minute
+	oidcMaxBody     = 1 << 20
+)
+
+// OIDCProvider configures one OpenID Connect identity provider. Endpoints
+// come from Issuer + "/.well-known/openid-configuration" unless all three
+// of AuthorizationEndpoint, TokenEndpoint and JWKSURL are set.
+type OIDCProvider struct {
+	// ID keys the provider in /auth/oidc/start?provider=<ID> (e.g. "okta").
+	ID string
+	// Name is the login02 button label ("Continue with <Name>").
+	Name   string
+	Issuer string
+	// ClientSecret empty ⇒ public client (PKCE only).
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the absolute GET /auth/oidc/callback URL registered
+	// with the IdP.
+	RedirectURL string
+	// Scopes default to openid, email, profile.
+	Scopes []string
+
+	AuthorizationEndpoint string
+	TokenEndpoint         string
+	JWKSURL               string
+
+	// EmailClaim names the claim joined to user.email (default "email").
+	// When it is "email", the token must also carry email_verified as the
+	// JSON boolean true; a missing, false or string value is refused. Any
+	// other claim (Entra ID's "preferred_username" or "upn") carries no
+	// verification, so it is refused unless TrustUnverifiedEmailClaim is
+	// set. Only set that for an IdP whose admins alone control the claim:
+	// in a multi-tenant app (AllowedIssuers) any tenant can put a victim's
+	// address in a UPN and be signed in as them.
+	EmailClaim                string
+	TrustUnverifiedEmailClaim bool
+	// AllowedIssuers is the iss allow-list. Empty ⇒ exactly Issuer. A
+	// multi-tenant Entra ID app (".../common/v2.0") lists each tenant's
+	// issuer here.
+	AllowedIssuers []string
+
+	// HTTPClient for discovery, token and JWKS calls (default: 10s timeout).
+	HTTPClient *http.Client
+}
+
+// oidcMetadata is the subset of the discovery document the flow uses.
+type oidcMetadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcClient is the runtime side of one OIDCProvider: lazily discovered
+// metadata and the cached JWKS. Discovery is deferred to first use so an
+// unreachable IdP doesn't block startup.
+type oidcClient struct {
+	cfg  OIDCProvider
+	http *http.Client
+	now  func() time.Time
+
+	mu          sync.Mutex
+	meta        *oidcMetadata
+	keys        map[string]crypto.PublicKey
+	keysFetched time.Time
+}
+
+func newOIDCClient(cfg OIDCProvider) *oidcClient {
+	hc := cfg.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: oidcHTTPTimeout}
+	}
+	if cfg.EmailClaim == "" {
+		cfg.EmailClaim = "email"
+	}
+	if len(cfg.Scopes) == 0 {
+		cfg.Scopes = []string{"openid", "email", "profile"}
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &oidcClient{cfg: cfg, http: hc, now: time.Now}
+}
+
+func (c *oidcClient) metadata(ctx context.Context) (oidcMetadata, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.meta != nil {
+		return *c.meta, nil
+	}
+	meta := oidcMetadata{
+		Issuer:                c.cfg.Issuer,
+		AuthorizationEndpoint: c.cfg.AuthorizationEndpoint,
+		TokenEndpoint:         c.cfg.TokenEndpoint,
+		JWKSURI:               c.cfg.JWKSURL,
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		var doc oidcMetadata
+		if err := c.getJSON(ctx, c.cfg.Issuer+"/.well-known/openid-configuration", &doc); err != nil {
+			return oidcMetadata{}, fmt.Errorf("%w: %v", errOIDCDiscovery, err)
+		}
+		// OIDC Discovery §4.3: the document must name the issuer it was
+		// fetched for. Multi-tenant issuers are templated, so the check is
+		// left to the iss allow-list when one is configured.
+		if len(c.cfg.AllowedIssuers) == 0 && strings.TrimSuffix(doc.Issuer, "/") != c.cfg.Issuer {
+			return oidcMetadata{}, fmt.Errorf("%w: issuer %q", errOIDCDiscovery, doc.Issuer)
+		}
+		if meta.AuthorizationEndpoint == "" {
+			meta.AuthorizationEndpoint = doc.AuthorizationEndpoint
+		}
+		if meta.TokenEndpoint == "" {
+			meta.TokenEndpoint = doc.TokenEndpoint
+		}
+		if meta.JWKSURI == "" {
+			meta.JWKSURI = doc.JWKSURI
+		}
+	}
+	if meta.AuthorizationEndpoint == "" || meta.TokenEndpoint == "" || meta.JWKSURI == "" {
+		return oidcMetadata{}, fmt.Errorf("%w: incomplete metadata", errOIDCDiscovery)
+	}
+	c.meta = &meta
+	return meta, nil
+}
+
+// authCodeURL builds the authorization request (code flow, PKCE S256).
+// loginHint (optional) pre-fills the account at the IdP.
+func (c *oidcClient) authCodeURL(ctx context.Context, state, nonce, verifier, loginHint string) (string, error) {
+	meta, err := c.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	challenge := sha256.Sum256([]byte(verifier))
+	q := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {c.cfg.ClientID},
+		"redirect_uri":          {c.cfg.RedirectURL},
+		"scope":                 {strings.Join(c.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	if loginHint != "" {
+		q.Set("login_hint", loginHint)
+	}
+	sep := "?"
+	if strings.Contains(meta.AuthorizationEndpoint, "?") {
+		sep = "&"
+	}
+	return meta.AuthorizationEndpoint + sep + q.Encode(), nil
+}
+
+// exchange redeems the authorization code and returns the raw ID token.
+func (c *oidcClient) exchange(ctx context.Context, code, verifier string) (string, error) {
+	meta, err := c.metadata(ctx)
+	if err != nil {
+		return "", err
+	}
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {c.cfg.RedirectURL},
+		"code_verifier": {verifier},
+		"client_id":     {c.cfg.ClientID},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, meta.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	if c.cfg.ClientSecret != "" {
+		// client_secret_basic (RFC 6749 §2.3.1: form-encode, then Basic).
+		req.SetBasicAuth(url.QueryEscape(c.cfg.ClientID), url.QueryEscape(c.cfg.ClientSecret))
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", errOIDCExchange, err)
+	}
+	defer resp.Body.Close()
+	var body struct {
+		IDToken string `json:"id_token"`
+		Error   string `json:"error"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, oidcMaxBody)).Decode(&body); err != nil {
+		return "", fmt.Errorf("%w: status %d", errOIDCExchange, resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK || body.IDToken == "" {
+		return "", fmt.Errorf("%w: status %d %s", errOIDCExchange, resp.StatusCode, body.Error)
+	}
+	return body.IDToken, nil
+}
+
+// verifyIDToken checks the signature, issuer, audience, lifetime and nonce
+// (OIDC Core §3.1.3.7) and returns the claims.
+func (c *oidcClient) verifyIDToken(ctx context.Context, raw, nonce string) (map[string]any, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errOIDCToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeJWTPart(parts[0], &header); err != nil {
+		return nil, errOIDCToken
+	}
+	if header.Alg != "RS256" && header.Alg != "ES256" {
+		return nil, fmt.Errorf("%w: alg %q", errOIDCToken, header.Alg)
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errOIDCToken
+	}
+	key, err := c.signingKey(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if !verifyJWTSignature(header.Alg, key, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, fmt.Errorf("%w: signature", errOIDCToken)
+	}
+
+	var claims map[string]any
+	if err := decodeJWTPart(parts[1], &claims); err != nil {
+		return nil, errOIDCToken
+	}
+	iss, _ := claims["iss"].(string)
+	if !c.issuerAllowed(iss) {
+		return nil, fmt.Errorf("%w: issuer %q", errOIDCToken, iss)
+	}
+	if !audienceContains(claims["aud"], c.cfg.ClientID) {
+		return nil, fmt.Errorf("%w: audience", errOIDCToken)
+	}
+	if azp, ok := claims["azp"].(string); ok && azp != c.cfg.ClientID {
+		return nil, fmt.Errorf("%w: azp", errOIDCToken)
+	}
+	now := c.now()
+	exp, ok := numericDate(claims["exp"])
+	if !ok || now.After(exp.Add(oidcClockLeeway)) {
+		return nil, fmt.Errorf("%w: expired", errOIDCToken)
+	}
+	if iat, ok := numericDate(claims["iat"]); ok && iat.After(now.Add(oidcClockLeeway)) {
+		return nil, fmt.Errorf("%w: issued in the future", errOIDCToken)
+	}
+	if nbf, ok := numericDate(claims["nbf"]); ok && nbf.After(now.Add(oidcClockLeeway)) {
+		return nil, fmt.Errorf("%w: not yet valid", errOIDCToken)
+	}
+	got, _ := claims["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return nil, fmt.Errorf("%w: nonce", errOIDCToken)
+	}
+	return claims, nil
+}
+
+// email maps the verified claims to the sign-in email.
+func (c *oidcClient) email(claims map[string]any) (string, error) {
+	email, _ := claims[c.cfg.EmailClaim].(string)
+	email = strings.TrimSpace(email)
+	if email == "" || !strings.Contains(email, "@") {
+		return "", errOIDCEmail
+	}
+	if c.cfg.EmailClaim != "email" {
+		if !c.cfg.TrustUnverifiedEmailClaim {
+			return "", fmt.Errorf("%w: unverified %s claim", errOIDCEmail, c.cfg.EmailClaim)
+		}
+		return email, nil
+	}
+	if verified, _ := claims["email_verified"].(bool); !verified {
+		return "", fmt.Errorf("%w: email not verified", errOIDCEmail)
+	}
+	return email, nil
+}
+
+func (c *oidcClient) issuerAllowed(iss string) bool {
+	iss = strings.TrimSuffix(iss, "/")
+	if len(c.cfg.AllowedIssuers) == 0 {
+		return iss != "" && iss == c.cfg.Issuer
+	}
+	for _, a := range c.cfg.AllowedIssuers {
+		if iss != "" && iss == strings.TrimSuffix(a, "/") {
+			return true
+		}
+	}
+	return false
+}
+
+// signingKey returns the JWKS key for kid, refetching the set (at most once
+// per oidcJWKSRefetch) when the kid is unknown — the IdP rotated keys.
+func (c *oidcClient) signingKey(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	meta, err := c.metadata(ctx)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if key, ok := pickJWK(c.keys, kid); ok {
+		return key, nil
+	}
+	if !c.keysFetched.IsZero() && c.now().Sub(c.keysFetched) < oidcJWKSRefetch {
+		return nil, fmt.Errorf("%w: unknown kid %q", errOIDCToken, kid)
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := c.getJSON(ctx, meta.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("%w: jwks: %v", errOIDCToken, err)
+	}
+	c.keys, c.keysFetched = parseJWKS(set.Keys), c.now()
+	if key, ok := pickJWK(c.keys, kid); ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("%w: unknown kid %q", errOIDCToken, kid)
+}
+
+func (c *oidcClient) getJSON(ctx context.Context, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: status %d", u, resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, oidcMaxBody)).Decode(v)
+}
+
+// jsonWebKey is the subset of RFC 7517 needed for RSA and P-256 keys.
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// parseJWKS keeps the usable signing keys; unsupported or malformed
+// entries are skipped rather than failing the whole set.
+func parseJWKS(keys []jsonWebKey) map[string]crypto.PublicKey {
+	out := make(map[string]crypto.PublicKey, len(keys))
+	b64 := base64.RawURLEncoding
+	for _, k := range keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		switch k.Kty {
+		case "RSA":
+			n, err1 := b64.DecodeString(k.N)
+			e, err2 := b64.DecodeString(k.E)
+			if err1 != nil || err2 != nil || len(n) < 256 || len(e) == 0 || len(e) > 4 {
+				continue
+			}
+			exp := 0
+			for _, b := range e {
+				exp = exp<<8 | int(b)
+			}
+			out[k.Kid] = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: exp}
+		case "EC":
+			x, err1 := b64.DecodeString(k.X)
+			y, err2 := b64.DecodeString(k.Y)
+			if k.Crv != "P-256" || err1 != nil || err2 != nil || len(x) != 32 || len(y) != 32 {
+				continue
+			}
+			if _, err := ecdh.P256().NewPublicKey(append(append([]byte{4}, x...), y...)); err != nil {
+				continue
+			}
+			out[k.Kid] = &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		}
+	}
+	return out
+}
+
+// pickJWK finds the key for kid; a token without a kid is accepted only
+// when the set holds exactly one key.
+func pickJWK(keys map[string]crypto.PublicKey, kid string) (crypto.PublicKey, bool) {
+	if key, ok := keys[kid]; ok {
+		return key, true
+	}
+	if kid == "" && len(keys) == 1 {
+		for _, key := range keys {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, sig []byte) bool {
+	h := sha256.Sum256(signed)
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		return alg == "RS256" && rsa.VerifyPKCS1v15(pub, crypto.SHA256, h[:], sig) == nil
+	case *ecdsa.PublicKey:
+		// JWS ES256 signatures are the raw 64-byte R || S (RFC 7518 §3.4).
+		if alg != "ES256" || len(sig) != 64 {
+			return false
+		}
+		r, s := new(big.Int).SetBytes(sig[:32]), new(big.Int).SetBytes(sig[32:])
+		return ecdsa.Verify(pub, h[:], r, s)
+	}
+	return false
+}
+
+func decodeJWTPart(part string, v any) error {
+	b, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
+
+func audienceContains(aud any, clientID string) bool {
+	switch a := aud.(type) {
+	case string:
+		return clientID != "" && a == clientID
+	case []any:
+		for _, v := range a {
+			if s, ok := v.(string); ok && clientID != "" && s == clientID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func numericDate(v any) (time.Time, bool) {
+	f, ok := v.(float64)
+	if !ok {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
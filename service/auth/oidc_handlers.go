@@ -0,0 +1,286 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	login02mod "github.com/erniealice/entydad-golang/service/auth/views/login02"
+)
+
+const (
+	oidcStateCookieName = "oidc_state"
+	// oidcStateTTL bounds the round trip through the IdP's login page.
+	oidcStateTTL = 10 * time.Minute
+)
+
+// oidcState is the in-flight authorization request, kept in a sealed cookie
+// between /auth/oidc/start and the callback: the provider, the CSRF state,
+// the ID-token nonce and the PKCE verifier (never sent to the browser in
+// the clear). Invite is the invitation link token of an invitee accepting
+// through single sign-on.
+type oidcState struct {
+	Provider string `json:"p"`
+	State    string `json:"s"`
+	Nonce    string `json:"n"`
+	Verifier string `json:"v"`
+	Invite   string `json:"i,omitempty"`
+	Expires  int64  `json:"x"`
+}
+
+// handleOIDCStart returns the GET /auth/oidc/start?provider=<id> handler. It
+// parks a fresh state / nonce / PKCE verifier in the sealed oidc_state
+// cookie and redirects to the provider's authorization endpoint, passing on
+// ?login_hint= (set by home-realm discovery). ?invite= (set by the accept
+// page) is kept in the state for the callback.
+func (m *AuthModule) handleOIDCStart() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := r.URL.Query().Get("provider")
+		client, ok := m.oidc[id]
+		if !ok {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=oidc", http.StatusSeeOther)
+			return
+		}
+		st := oidcState{
+			Provider: id,
+			State:    oidcRandom(),
+			Nonce:    oidcRandom(),
+			Verifier: oidcRandom(),
+			Invite:   r.URL.Query().Get("invite"),
+			Expires:  time.Now().Add(oidcStateTTL).Unix(),
+		}
+		if st.State == "" || st.Nonce == "" || st.Verifier == "" {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=oidc", http.StatusSeeOther)
+			return
+		}
+		target, err := client.authCodeURL(r.Context(), st.State, st.Nonce, st.Verifier, r.URL.Query().Get("login_hint"))
+		if err != nil {
+			log.Printf("[AUTH] oidc %s: %v", id, err)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=oidc", http.StatusSeeOther)
+			return
+		}
+		if err := m.setSealedCookie(w, oidcStateCookieName, st, oidcStateTTL); err != nil {
+			log.Printf("[AUTH] oidc %s: state cookie: %v", id, err)
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error=oidc", http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, target, http.StatusFound)
+	}
+}
+
+// handleOIDCCallback returns the GET /auth/oidc/callback handler. It checks
+// the state against the sealed cookie, redeems the code (with the PKCE
+// verifier), verifies the ID token against the provider's JWKS, maps the
+// email claim to a DB user, mints a session (SessionMinter) and reuses
+// routePrincipals — the same tail as the password and Firebase paths, with
+// the /auth/mfa challenge in front for enrolled users. A sign-in started
+// from an invitation accepts it first, registering the invitee when the
+// address has no account yet.
+//
+// The callback is a top-level navigation from the IdP, so it answers with
+// 303 redirects; failures land on login02 with ?error=oidc / no_account /
+// throttled / locked.
+func (m *AuthModule) handleOIDCCallback() http.HandlerFunc {
+	minter := m.deps.SessionMinter
+	sessionMw := m.deps.SessionManager
+	principalLoader := m.deps.PrincipalResolver
+	limiter := m.deps.LoginAttemptLimiter
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		fail := func(code string) {
+			http.Redirect(w, r, entydad.AuthLoginURL+"?error="+code, http.StatusSeeOther)
+		}
+		var st oidcState
+		ok := m.openSealedCookie(r, oidcStateCookieName, &st)
+		m.clearSealedCookie(w, oidcStateCookieName)
+		q := r.URL.Query()
+		if !ok || time.Now().Unix() > st.Expires ||
+			subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(st.State)) != 1 {
+			log.Printf("[AUTH] oidc callback: missing, expired or mismatched state")
+			fail("oidc")
+			return
+		}
+		client, ok := m.oidc[st.Provider]
+		if !ok {
+			fail("oidc")
+			return
+		}
+		if e := q.Get("error"); e != "" {
+			// access_denied (user cancelled) and friends.
+			log.Printf("[AUTH] oidc %s: provider returned error %q", st.Provider, e)
+			fail("oidc")
+			return
+		}
+		code := q.Get("code")
+		if code == "" {
+			fail("oidc")
+			return
+		}
+
+		attempt := LoginAttempt{Scope: LoginScopeOIDC, IP: m.clientIP(r)}
+		if c := limitedErrorCode(limiter.Check(r.Context(), attempt)); c != "" {
+			fail(c)
+			return
+		}
+		rawIDToken, err := client.exchange(r.Context(), code, st.Verifier)
+		if err != nil {
+			log.Printf("[AUTH] oidc %s: %v", st.Provider, err)
+			limiter.RecordFailure(r.Context(), attempt)
+			fail("oidc")
+			return
+		}
+		claims, err := client.verifyIDToken(r.Context(), rawIDToken, st.Nonce)
+		if err != nil {
+			log.Printf("[AUTH] oidc %s: %v", st.Provider, err)
+			limiter.RecordFailure(r.Context(), attempt)
+			fail("oidc")
+			return
+		}
+		email, err := client.email(claims)
+		if err != nil {
+			log.Printf("[AUTH] oidc %s: %v", st.Provider, err)
+			limiter.RecordFailure(r.Context(), attempt)
+			fail("oidc")
+			return
+		}
+		// The IP already holds its reservation from the first check.
+		attempt.Email = email
+		if c := limitedErrorCode(limiter.Check(r.Context(), LoginAttempt{Scope: attempt.Scope, Email: email})); c != "" {
+			fail(c)
+			return
+		}
+		userID := m.userIDForEmail(r.Context(), email)
+		if st.Invite != "" {
+			if userID = m.oidcAcceptInvite(w, r, st.Invite, email, userID, claims); userID == "" {
+				return
+			}
+		}
+		if userID == "" {
+			log.Printf("[AUTH] oidc %s: no DB user maps to email %s", st.Provider, email)
+			limiter.RecordFailure(r.Context(), attempt)
+			fail("no_account")
+			return
+		}
+		token, err := minter(r.Context(), userID)
+		if err != nil || token == "" {
+			log.Printf("[AUTH] oidc %s: mint session failed for user %s: %v", st.Provider, userID, err)
+			fail("oidc")
+			return
+		}
+		limiter.RecordSuccess(r.Context(), attempt)
+		log.Printf("[AUTH] oidc login OK: user=%s provider=%s", userID, st.Provider)
+		r = withSSOProvider(r, st.Provider)
+
+		if target, pending := m.parkLogin(w, r, token, userID, email); pending {
+			http.Redirect(w, r, target, http.StatusSeeOther)
+			return
+		}
+		sessionMw.SetSessionCookie(w, token)
+		m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, "")
+
+		if principalLoader == nil || !principalLoader.IsEnabled() {
+			m.signedIn(w, r, token, userID)
+			http.Redirect(w, r, entydad.DefaultAppRedirectURL, http.StatusSeeOther)
+			return
+		}
+		http.Redirect(w, r, m.routePrincipals(w, r, token, userID), http.StatusSeeOther)
+	}
+}
+
+// oidcAcceptInvite accepts the invitation an SSO sign-in was started from,
+// for the signed-in address only, and returns the user it joined. It
+// answers the request itself and returns "" when the invitation cannot be
+// accepted.
+func (m *AuthModule) oidcAcceptInvite(w http.ResponseWriter, r *http.Request, token, email, userID string, claims map[string]any) string {
+	back := func(code string) string {
+		http.Redirect(w, r, entydad.AuthAcceptInviteURL+"?token="+url.QueryEscape(token)+"&error="+code, http.StatusSeeOther)
+		return ""
+	}
+	if !m.invitationsEnabled() {
+		http.Redirect(w, r, entydad.AuthAcceptInviteURL, http.StatusSeeOther)
+		return ""
+	}
+	inv, ok := m.openInvitation(r.Context(), token)
+	if !ok {
+		http.Redirect(w, r, entydad.AuthAcceptInviteURL, http.StatusSeeOther)
+		return ""
+	}
+	if !strings.EqualFold(inv.Email, email) {
+		log.Printf("[AUTH] oidc accept-invite: invitation %s is for %s, signed in as %s", inv.ID, inv.Email, email)
+		return back("sso_email")
+	}
+	registered := false
+	if userID == "" {
+		newID, err := m.registerSSOInvitee(r.Context(), inv.Email, claims)
+		if err != nil {
+			log.Printf("[AUTH] oidc accept-invite: register failed for %s: %v", inv.Email, err)
+			return back(classifySignupError(err))
+		}
+		userID, registered = newID, true
+	}
+	if err := m.joinInvitation(r.Context(), inv, userID, registered); err != nil {
+		if errors.Is(err, ErrInvitationNotFound) {
+			http.Redirect(w, r, entydad.AuthAcceptInviteURL, http.StatusSeeOther)
+			return ""
+		}
+		log.Printf("[AUTH] oidc accept-invite: join failed for user %s (invitation %s): %v", userID, inv.ID, err)
+		return back("generic")
+	}
+	return userID
+}
+
+// oidcLoginButtons maps the configured providers to login02 buttons, in
+// configuration order.
+func (m *AuthModule) oidcLoginButtons() []login02mod.OIDCProvider {
+	var out []login02mod.OIDCProvider
+	for _, p := range m.deps.OIDCProviders {
+		if _, ok := m.oidc[p.ID]; !ok {
+			continue
+		}
+		name := p.Name
+		if name == "" {
+			name = p.ID
+		}
+		out = append(out, login02mod.OIDCProvider{
+			ID:       p.ID,
+			Name:     name,
+			StartURL: entydad.AuthOIDCStartURL + "?provider=" + p.ID,
+		})
+	}
+	return out
+}
+
+// newOIDCClients validates the configured providers; incomplete entries
+// are skipped (logged) rather than failing startup.
+func newOIDCClients(providers []OIDCProvider) map[string]*oidcClient {
+	out := make(map[string]*oidcClient, len(providers))
+	for _, p := range providers {
+		if p.ID == "" || strings.ContainsAny(p.ID, "?&#/ ") || p.Issuer == "" || p.ClientID == "" || p.RedirectURL == "" {
+			log.Printf("[AUTH] oidc provider %q skipped: ID, Issuer, ClientID and RedirectURL are required", p.ID)
+			continue
+		}
+		if _, dup := out[p.ID]; dup {
+			log.Printf("[AUTH] oidc provider %q skipped: duplicate ID", p.ID)
+			continue
+		}
+		out[p.ID] = newOIDCClient(p)
+	}
+	return out
+}
+
+// oidcRandom returns 32 random bytes, base64url — state, nonce and PKCE
+// verifier (RFC 7636 §4.1: 43 chars). Empty on entropy failure.
+func oidcRandom() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,364 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+)
+
+// stubIdP is a local OpenID provider: discovery, JWKS, and a token endpoint
+// that checks the PKCE verifier and client credentials before issuing an
+// RS256 ID token for the nonce the authorization request carried.
+type stubIdP struct {
+	t   *testing.T
+	srv *httptest.Server
+	key *rsa.PrivateKey
+
+	mu        sync.Mutex
+	challenge string // code_challenge from the authorization request
+	nonce     string
+	claims    map[string]any // overrides merged into the issued token
+}
+
+func newStubIdP(t *testing.T) *stubIdP {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	idp := &stubIdP{t: t, key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.srv.URL,
+			"authorization_endpoint": idp.srv.URL + "/authorize",
+			"token_endpoint":         idp.srv.URL + "/token",
+			"jwks_uri":               idp.srv.URL + "/jwks",
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		b64 := base64.RawURLEncoding
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA", "kid": "k1", "use": "sig", "alg": "RS256",
+			"n": b64.EncodeToString(key.N.Bytes()),
+			"e": b64.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		id, secret, _ := r.BasicAuth()
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		switch {
+		case id != "entydad" || secret != "s3cret":
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error":"invalid_client"}`))
+			return
+		case r.PostFormValue("code") != "code-123" ||
+			base64.RawURLEncoding.EncodeToString(sum[:]) != idp.challenge:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		claims := idp.baseClaims()
+		for k, v := range idp.claims {
+			if v == nil {
+				delete(claims, k)
+				continue
+			}
+			claims[k] = v
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"access_token": "opaque",
+			"id_token":     signRS256(t, key, "k1", claims),
+		})
+	})
+	idp.srv = httptest.NewServer(mux)
+	t.Cleanup(idp.srv.Close)
+	return idp
+}
+
+func (idp *stubIdP) baseClaims() map[string]any {
+	now := time.Now()
+	return map[string]any{
+		"iss":            idp.srv.URL,
+		"aud":            "entydad",
+		"sub":            "idp-user-1",
+		"email":          "Ana@Example.com",
+		"email_verified": true,
+		"nonce":          idp.nonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+	}
+}
+
+func (idp *stubIdP) provider() OIDCProvider {
+	return OIDCProvider{
+		ID:           "stub",
+		Name:         "Stub IdP",
+		Issuer:       idp.srv.URL,
+		ClientID:     "entydad",
+		ClientSecret: "s3cret",
+		RedirectURL:  "https://app.example.com/auth/oidc/callback",
+		HTTPClient:   idp.srv.Client(),
+	}
+}
+
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]any) string {
+	t.Helper()
+	signingInput := jwtSegment(t, map[string]string{"alg": "RS256", "kid": kid, "typ": "JWT"}) + "." + jwtSegment(t, claims)
+	h := sha256.Sum256([]byte(signingInput))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func jwtSegment(t *testing.T, v any) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+type recordingSessionManager struct{ token string }
+
+func (s *recordingSessionManager) SetSessionCookie(_ http.ResponseWriter, token string) {
+	s.token = token
+}
+func (s *recordingSessionManager) ClearSessionCookie(http.ResponseWriter) { s.token = "" }
+
+func newOIDCTestModule(idp *stubIdP, sessions *recordingSessionManager) *AuthModule {
+	return NewAuthModule(&Deps{
+		SessionManager: sessions,
+		SessionMinter: func(_ context.Context, userID string) (string, error) {
+			return "session-for-" + userID, nil
+		},
+		UserIDByEmail: func(_ context.Context, email string) string {
+			if email == "ana@example.com" {
+				return "user-ana"
+			}
+			return ""
+		},
+		CSRFIssuer:    func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:    []byte("test-secret"),
+		OIDCProviders: []OIDCProvider{idp.provider()},
+	})
+}
+
+// runOIDCFlow drives start → (stub authorize) → callback and returns the
+// callback response. mutate may tamper with the callback query.
+func runOIDCFlow(t *testing.T, m *AuthModule, idp *stubIdP, mutate func(q url.Values)) *httptest.ResponseRecorder {
+	t.Helper()
+	return runOIDCFlowFrom(t, m, idp, entydad.AuthOIDCStartURL+"?provider=stub", mutate)
+}
+
+// runOIDCFlowFrom is runOIDCFlow starting at startURL.
+func runOIDCFlowFrom(t *testing.T, m *AuthModule, idp *stubIdP, startURL string, mutate func(q url.Values)) *httptest.ResponseRecorder {
+	t.Helper()
+	start := httptest.NewRecorder()
+	m.handleOIDCStart()(start, httptest.NewRequest(http.MethodGet, startURL, nil))
+	if start.Code != http.StatusFound {
+		t.Fatalf("start: status = %d, want 302", start.Code)
+	}
+	authz, err := url.Parse(start.Header().Get("Location"))
+	if err != nil || !strings.HasPrefix(authz.String(), idp.srv.URL+"/authorize?") {
+		t.Fatalf("start: unexpected redirect %q", start.Header().Get("Location"))
+	}
+	aq := authz.Query()
+	if aq.Get("code_challenge_method") != "S256" || aq.Get("response_type") != "code" || aq.Get("client_id") != "entydad" {
+		t.Fatalf("start: authorization request %v", aq)
+	}
+	idp.mu.Lock()
+	idp.challenge, idp.nonce = aq.Get("code_challenge"), aq.Get("nonce")
+	idp.mu.Unlock()
+
+	q := url.Values{"code": {"code-123"}, "state": {aq.Get("state")}}
+	if mutate != nil {
+		mutate(q)
+	}
+	req := httptest.NewRequest(http.MethodGet, entydad.AuthOIDCCallbackURL+"?"+q.Encode(), nil)
+	for _, c := range start.Result().Cookies() {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	m.handleOIDCCallback()(rec, req)
+	return rec
+}
+
+func TestOIDC_StubIdPSignIn(t *testing.T) {
+	t.Parallel()
+	idp := newStubIdP(t)
+	sessions := &recordingSessionManager{}
+	m := newOIDCTestModule(idp, sessions)
+
+	rec := runOIDCFlow(t, m, idp, nil)
+	if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != entydad.DefaultAppRedirectURL {
+		t.Fatalf("callback: %d → %q, want 303 → %q", rec.Code, rec.Header().Get("Location"), entydad.DefaultAppRedirectURL)
+	}
+	if sessions.token != "session-for-user-ana" {
+		t.Errorf("session cookie token = %q, want the minted session", sessions.token)
+	}
+}
+
+func TestOIDC_CallbackFailures(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name   string
+		claims map[string]any
+		mutate func(q url.Values)
+		want   string
+	}{
+		{"state mismatch", nil, func(q url.Values) { q.Set("state", "forged") }, "oidc"},
+		{"provider error", nil, func(q url.Values) { q.Del("code"); q.Set("error", "access_denied") }, "oidc"},
+		{"code rejected by IdP", nil, func(q url.Values) { q.Set("code", "stolen") }, "oidc"},
+		{"unverified email", map[string]any{"email_verified": false}, nil, "oidc"},
+		{"email_verified missing", map[string]any{"email_verified": nil}, nil, "oidc"},
+		{"email_verified as a string", map[string]any{"email_verified": "true"}, nil, "oidc"},
+		{"unknown email", map[string]any{"email": "stranger@example.com"}, nil, "no_account"},
+		{"wrong audience", map[string]any{"aud": "someone-else"}, nil, "oidc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			idp := newStubIdP(t)
+			idp.claims = tt.claims
+			sessions := &recordingSessionManager{}
+			rec := runOIDCFlow(t, newOIDCTestModule(idp, sessions), idp, tt.mutate)
+			want := entydad.AuthLoginURL + "?error=" + tt.want
+			if rec.Code != http.StatusSeeOther || rec.Header().Get("Location") != want {
+				t.Fatalf("callback: %d → %q, want 303 → %q", rec.Code, rec.Header().Get("Location"), want)
+			}
+			if sessions.token != "" {
+				t.Error("session cookie set on a failed sign-in")
+			}
+		})
+	}
+}
+
+func TestOIDCVerifyIDToken(t *testing.T) {
+	t.Parallel()
+	idp := newStubIdP(t)
+	c := newOIDCClient(idp.provider())
+	idp.nonce = "n-1"
+	other, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	with := func(overrides map[string]any) map[string]any {
+		claims := idp.baseClaims()
+		for k, v := range overrides {
+			claims[k] = v
+		}
+		return claims
+	}
+	unsigned := jwtSegment(t, map[string]string{"alg": "none", "kid": "k1"}) + "." + jwtSegment(t, idp.baseClaims()) + "."
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr bool
+	}{
+		{"ok", signRS256(t, idp.key, "k1", idp.baseClaims()), false},
+		{"audience array", signRS256(t, idp.key, "k1", with(map[string]any{"aud": []string{"other", "entydad"}, "azp": "entydad"})), false},
+		{"wrong issuer", signRS256(t, idp.key, "k1", with(map[string]any{"iss": "https://evil.example"})), true},
+		{"foreign azp", signRS256(t, idp.key, "k1", with(map[string]any{"aud": []string{"other", "entydad"}, "azp": "other"})), true},
+		{"expired", signRS256(t, idp.key, "k1", with(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})), true},
+		{"issued in the future", signRS256(t, idp.key, "k1", with(map[string]any{"iat": time.Now().Add(time.Hour).Unix()})), true},
+		{"nonce mismatch", signRS256(t, idp.key, "k1", with(map[string]any{"nonce": "replayed"})), true},
+		{"signed by another key", signRS256(t, other, "k1", idp.baseClaims()), true},
+		{"unknown kid", signRS256(t, idp.key, "k9", idp.baseClaims()), true},
+		{"alg none", unsigned, true},
+	}
+	for _, tt := range tests {
+		_, err := c.verifyIDToken(context.Background(), tt.token, "n-1")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: err = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, errOIDCToken) {
+			t.Errorf("%s: err = %v, want errOIDCToken", tt.name, err)
+		}
+	}
+}
+
+func TestOIDCVerifyIDToken_ES256(t *testing.T) {
+	t.Parallel()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b64 := base64.RawURLEncoding
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "EC", "crv": "P-256", "kid": "ec1",
+			"x": b64.EncodeToString(key.X.FillBytes(make([]byte, 32))),
+			"y": b64.EncodeToString(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	}))
+	t.Cleanup(jwks.Close)
+	c := newOIDCClient(OIDCProvider{
+		ID: "ec", Issuer: "https://idp.example", ClientID: "entydad",
+		RedirectURL:               "https://app.example.com/auth/oidc/callback",
+		AuthorizationEndpoint:     "https://idp.example/authorize",
+		TokenEndpoint:             "https://idp.example/token",
+		JWKSURL:                   jwks.URL,
+		EmailClaim:                "preferred_username",
+		TrustUnverifiedEmailClaim: true,
+		HTTPClient:                jwks.Client(),
+	})
+	claims := map[string]any{
+		"iss": "https://idp.example", "aud": "entydad", "nonce": "n-2",
+		"preferred_username": "ana@example.com",
+		"exp":                time.Now().Add(time.Minute).Unix(),
+	}
+	signingInput := jwtSegment(t, map[string]string{"alg": "ES256", "kid": "ec1"}) + "." + jwtSegment(t, claims)
+	h := sha256.Sum256([]byte(signingInput))
+	r, s, err := ecdsa.Sign(rand.Reader, key, h[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+
+	got, err := c.verifyIDToken(context.Background(), signingInput+"."+b64.EncodeToString(sig), "n-2")
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if email, err := c.email(got); err != nil || email != "ana@example.com" {
+		t.Fatalf("email = %q, %v; want the preferred_username claim", email, err)
+	}
+}
+
+// A claim other than "email" carries no email_verified, so it is joined to
+// user.email only when the provider opts in.
+func TestOIDCEmail_UnverifiedCustomClaim(t *testing.T) {
+	t.Parallel()
+	claims := map[string]any{"upn": "ana@example.com", "email_verified": true}
+	cfg := OIDCProvider{ID: "entra", Issuer: "https://login.example", EmailClaim: "upn"}
+	if email, err := newOIDCClient(cfg).email(claims); !errors.Is(err, errOIDCEmail) {
+		t.Fatalf("without opt-in: email = %q, err = %v; want errOIDCEmail", email, err)
+	}
+	cfg.TrustUnverifiedEmailClaim = true
+	if email, err := newOIDCClient(cfg).email(claims); err != nil || email != "ana@example.com" {
+		t.Fatalf("with opt-in: email = %q, %v", email, err)
+	}
+}
@@ -0,0 +1,149 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrPasskeyNotFound is returned by DeletePasskey when the credential does
+// not exist or belongs to another user.
+var ErrPasskeyNotFound = errors.New("auth: passkey not found")
+
+// PasskeyCredential is one registered WebAuthn credential. PublicKey is the
+// raw COSE_Key from registration; SignCount is the authenticator's last
+// reported signature counter (0 for authenticators that don't keep one).
+type PasskeyCredential struct {
+	ID         []byte
+	UserID     string
+	Name       string // user-chosen label, e.g. "MacBook Touch ID"
+	PublicKey  []byte
+	SignCount  uint32
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+// PasskeyStore persists passkey credentials. GetPasskey returns (nil, nil)
+// for an unknown credential id. Satisfied by the host's user-credential
+// repository; NewMemoryPasskeyStore is the per-process default for tests.
+type PasskeyStore interface {
+	ListPasskeys(ctx context.Context, userID string) ([]PasskeyCredential, error)
+	GetPasskey(ctx context.Context, credentialID []byte) (*PasskeyCredential, error)
+	SavePasskey(ctx context.Context, credential PasskeyCredential) error
+	UpdatePasskeyUsage(ctx context.Context, credentialID []byte, signCount uint32, usedAt time.Time) error
+	DeletePasskey(ctx context.Context, userID string, credentialID []byte) error
+}
+
+// PasskeySummary is the account-page view of a credential.
+type PasskeySummary struct {
+	ID         string // base64url credential id (the delete form's key)
+	Name       string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+}
+
+// ListPasskeys returns userID's registered passkeys, newest first.
+func (m *AuthModule) ListPasskeys(ctx context.Context, userID string) ([]PasskeySummary, error) {
+	if m.deps.PasskeyStore == nil {
+		return nil, nil
+	}
+	creds, err := m.deps.PasskeyStore.ListPasskeys(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]PasskeySummary, 0, len(creds))
+	for _, c := range creds {
+		out = append(out, PasskeySummary{
+			ID:         webauthnB64.EncodeToString(c.ID),
+			Name:       c.Name,
+			CreatedAt:  c.CreatedAt,
+			LastUsedAt: c.LastUsedAt,
+		})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt.After(out[j].CreatedAt) })
+	return out, nil
+}
+
+// DeletePasskey removes one of userID's passkeys by its base64url id.
+func (m *AuthModule) DeletePasskey(ctx context.Context, userID, credentialID string) error {
+	if m.deps.PasskeyStore == nil {
+		return ErrPasskeyNotFound
+	}
+	if m.impersonating(ctx, nil) {
+		return ErrImpersonating
+	}
+	id, err := webauthnB64.DecodeString(strings.TrimSpace(credentialID))
+	if err != nil || len(id) == 0 {
+		return ErrPasskeyNotFound
+	}
+	return m.deps.PasskeyStore.DeletePasskey(ctx, userID, id)
+}
+
+// MemoryPasskeyStore is an in-process PasskeyStore for tests and
+// single-instance development hosts.
+type MemoryPasskeyStore struct {
+	mu    sync.Mutex
+	creds map[string]PasskeyCredential // keyed by string(credential id)
+}
+
+// NewMemoryPasskeyStore returns an empty in-memory passkey store.
+func NewMemoryPasskeyStore() *MemoryPasskeyStore {
+	return &MemoryPasskeyStore{creds: make(map[string]PasskeyCredential)}
+}
+
+func (s *MemoryPasskeyStore) ListPasskeys(_ context.Context, userID string) ([]PasskeyCredential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []PasskeyCredential
+	for _, c := range s.creds {
+		if c.UserID == userID {
+			out = append(out, c)
+		}
+	}
+	return out, nil
+}
+
+func (s *MemoryPasskeyStore) GetPasskey(_ context.Context, credentialID []byte) (*PasskeyCredential, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.creds[string(credentialID)]
+	if !ok {
+		return nil, nil
+	}
+	return &c, nil
+}
+
+func (s *MemoryPasskeyStore) SavePasskey(_ context.Context, c PasskeyCredential) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c.ID = bytes.Clone(c.ID)
+	s.creds[string(c.ID)] = c
+	return nil
+}
+
+func (s *MemoryPasskeyStore) UpdatePasskeyUsage(_ context.Context, credentialID []byte, signCount uint32, usedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.creds[string(credentialID)]
+	if !ok {
+		return ErrPasskeyNotFound
+	}
+	c.SignCount, c.LastUsedAt = signCount, usedAt
+	s.creds[string(credentialID)] = c
+	return nil
+}
+
+func (s *MemoryPasskeyStore) DeletePasskey(_ context.Context, userID string, credentialID []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	c, ok := s.creds[string(credentialID)]
+	if !ok || c.UserID != userID {
+		return ErrPasskeyNotFound
+	}
+	delete(s.creds, string(credentialID))
+	return nil
+}
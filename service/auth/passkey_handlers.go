@@ -0,0 +1,360 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+)
+
+const (
+	passkeyChallengeCookieName = "passkey_challenge"
+	passkeyChallengeTTL        = 5 * time.Minute
+	// passkeyTimeoutMS is the ceremony timeout handed to the browser.
+	passkeyTimeoutMS = 60000
+	// passkeyNameMaxLen caps the user-chosen credential label (in runes).
+	passkeyNameMaxLen = 64
+)
+
+// passkeyChallenge is the single-use ceremony state, kept in a sealed cookie
+// between the options call and the verify call. UserID is set only for
+// registration, binding the challenge to the signed-in user.
+type passkeyChallenge struct {
+	Challenge []byte `json:"c"`
+	UserID    string `json:"u,omitempty"`
+	Expires   int64  `json:"x"`
+}
+
+// handlePasskeyOptions returns the POST /auth/passkey/options handler: a
+// fresh assertion challenge for a discoverable-credential sign-in (no email
+// needed — the authenticator offers the user's passkeys for this RP).
+func (m *AuthModule) handlePasskeyOptions() http.HandlerFunc {
+	limiter := m.deps.LoginAttemptLimiter
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		attempt := LoginAttempt{Scope: LoginScopePasskey, IP: m.clientIP(r)}
+		if !writeFirebaseLimited(w, limiter.Check(r.Context(), attempt)) {
+			return
+		}
+		// Asking for a challenge is not a sign-in attempt: give the
+		// reservation back.
+		limiter.RecordSuccess(r.Context(), attempt)
+		challenge, err := m.newPasskeyChallenge(w, "")
+		if err != nil {
+			log.Printf("[AUTH] passkey: challenge failed: %v", err)
+			writeFirebaseError(w, http.StatusInternalServerError, "passkey")
+			return
+		}
+		writePasskeyJSON(w, map[string]any{
+			"challenge":        webauthnB64.EncodeToString(challenge),
+			"rpId":             m.deps.PasskeyRPID,
+			"timeout":          passkeyTimeoutMS,
+			"userVerification": "required",
+		})
+	}
+}
+
+// handlePasskeyLogin returns the POST /auth/passkey handler. It verifies the
+// assertion against the stored credential, mints a session (SessionMinter)
+// and reuses routePrincipals, answering with the same JSON contract as
+// /auth/firebase ({"redirect"} / {"error"}) so login02 handles both alike.
+//
+// User verification is required on the ceremony, so the passkey already
+// proves possession plus PIN/biometric — the TOTP challenge is not applied
+// on top. The home realm and the consent step still are, against the
+// owner's address (EmailByUserID).
+func (m *AuthModule) handlePasskeyLogin() http.HandlerFunc {
+	store := m.deps.PasskeyStore
+	minter := m.deps.SessionMinter
+	sessionMw := m.deps.SessionManager
+	principalLoader := m.deps.PrincipalResolver
+	limiter := m.deps.LoginAttemptLimiter
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			writeFirebaseJSON(w, http.StatusBadRequest, "invalid_request")
+			return
+		}
+		attempt := LoginAttempt{Scope: LoginScopePasskey, IP: m.clientIP(r)}
+		if !writeFirebaseLimited(w, limiter.Check(r.Context(), attempt)) {
+			return
+		}
+		ch, ok := m.takePasskeyChallenge(w, r)
+		if !ok || ch.UserID != "" {
+			writeFirebaseError(w, http.StatusBadRequest, "expired")
+			return
+		}
+		fail := func(reason string, err error) {
+			log.Printf("[AUTH] passkey login failed (%s): %v", reason, err)
+			limiter.RecordFailure(r.Context(), attempt)
+			writeFirebaseError(w, http.StatusUnauthorized, "passkey")
+		}
+
+		credentialID, err1 := webauthnB64.DecodeString(r.FormValue("credential_id"))
+		clientData, err2 := webauthnB64.DecodeString(r.FormValue("client_data"))
+		authData, err3 := webauthnB64.DecodeString(r.FormValue("authenticator_data"))
+		signature, err4 := webauthnB64.DecodeString(r.FormValue("signature"))
+		userHandle, err5 := webauthnB64.DecodeString(r.FormValue("user_handle"))
+		if err1 != nil || err2 != nil || err3 != nil || err4 != nil || err5 != nil || len(credentialID) == 0 {
+			fail("decode", nil)
+			return
+		}
+		cred, err := store.GetPasskey(r.Context(), credentialID)
+		if err != nil || cred == nil {
+			fail("unknown credential", err)
+			return
+		}
+		// The user handle is the user id set at registration; when the
+		// authenticator returns one it must name the credential's owner.
+		if len(userHandle) > 0 && string(userHandle) != cred.UserID {
+			fail("user handle mismatch", nil)
+			return
+		}
+		signCount, err := verifyAssertion(cred.PublicKey, authData, clientData, signature,
+			m.deps.PasskeyRPID, ch.Challenge, m.passkeyOrigins())
+		if err != nil {
+			fail("verify", err)
+			return
+		}
+		// A counter that fails to advance signals a cloned authenticator
+		// (WebAuthn §6.1.1). Authenticators without a counter report 0.
+		if (signCount != 0 || cred.SignCount != 0) && signCount <= cred.SignCount {
+			fail("sign counter did not advance", nil)
+			return
+		}
+		if err := store.UpdatePasskeyUsage(r.Context(), credentialID, signCount, time.Now()); err != nil {
+			log.Printf("[AUTH] passkey: usage update failed for user %s: %v", cred.UserID, err)
+		}
+
+		token, err := minter(r.Context(), cred.UserID)
+		if err != nil || token == "" {
+			log.Printf("[AUTH] passkey: mint session failed for user %s: %v", cred.UserID, err)
+			writeFirebaseError(w, http.StatusInternalServerError, "session")
+			return
+		}
+		limiter.RecordSuccess(r.Context(), attempt)
+		log.Printf("[AUTH] passkey login OK: user=%s", cred.UserID)
+		email := m.emailForUserID(r.Context(), cred.UserID)
+		if target, refused := m.homeRealmRefused(r, token, cred.UserID, email); refused {
+			writeFirebaseRedirect(w, target)
+			return
+		}
+		if target, pending := m.beginConsent(w, r, token, cred.UserID, email); pending {
+			writeFirebaseRedirect(w, target)
+			return
+		}
+		sessionMw.SetSessionCookie(w, token)
+		m.deps.CSRFIssuer(w, m.deps.CSRFSecret, token, "")
+
+		if principalLoader == nil || !principalLoader.IsEnabled() {
+			m.signedIn(w, r, token, cred.UserID)
+			writeFirebaseRedirect(w, entydad.DefaultAppRedirectURL)
+			return
+		}
+		writeFirebaseRedirect(w, m.routePrincipals(w, r, token, cred.UserID))
+	}
+}
+
+// handlePasskeyRegisterOptions returns the POST /auth/passkey/register/options
+// handler: creation options for the signed-in user (account page). The
+// optional "email" form value only labels the credential inside the
+// authenticator.
+func (m *AuthModule) handlePasskeyRegisterOptions() http.HandlerFunc {
+	store := m.deps.PasskeyStore
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := m.passkeyAccountUser(w, r)
+		if !ok {
+			return
+		}
+		label := strings.TrimSpace(r.FormValue("email"))
+		if label == "" {
+			label = userID
+		}
+		existing, err := store.ListPasskeys(r.Context(), userID)
+		if err != nil {
+			log.Printf("[AUTH] passkey: list failed for user %s: %v", userID, err)
+			writeFirebaseError(w, http.StatusInternalServerError, "passkey")
+			return
+		}
+		exclude := make([]map[string]string, 0, len(existing))
+		for _, c := range existing {
+			exclude = append(exclude, map[string]string{"type": "public-key", "id": webauthnB64.EncodeToString(c.ID)})
+		}
+		challenge, err := m.newPasskeyChallenge(w, userID)
+		if err != nil {
+			log.Printf("[AUTH] passkey: challenge failed: %v", err)
+			writeFirebaseError(w, http.StatusInternalServerError, "passkey")
+			return
+		}
+		rpName := m.deps.PasskeyRPName
+		if rpName == "" {
+			rpName = m.deps.LogoText
+		}
+		writePasskeyJSON(w, map[string]any{
+			"challenge": webauthnB64.EncodeToString(challenge),
+			"rp":        map[string]string{"id": m.deps.PasskeyRPID, "name": rpName},
+			"user": map[string]string{
+				"id":          webauthnB64.EncodeToString([]byte(userID)),
+				"name":        label,
+				"displayName": label,
+			},
+			"pubKeyCredParams": []map[string]any{
+				{"type": "public-key", "alg": coseAlgES256},
+				{"type": "public-key", "alg": coseAlgEdDSA},
+				{"type": "public-key", "alg": coseAlgRS256},
+			},
+			"timeout":     passkeyTimeoutMS,
+			"attestation": "none",
+			"authenticatorSelection": map[string]string{
+				"residentKey":      "required",
+				"userVerification": "required",
+			},
+			"excludeCredentials": exclude,
+		})
+	}
+}
+
+// handlePasskeyRegister returns the POST /auth/passkey/register handler,
+// verifying the attestation and saving the credential for the signed-in user.
+func (m *AuthModule) handlePasskeyRegister() http.HandlerFunc {
+	store := m.deps.PasskeyStore
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		userID, ok := m.passkeyAccountUser(w, r)
+		if !ok {
+			return
+		}
+		ch, ok := m.takePasskeyChallenge(w, r)
+		if !ok || ch.UserID != userID {
+			writeFirebaseError(w, http.StatusBadRequest, "expired")
+			return
+		}
+		clientData, err1 := webauthnB64.DecodeString(r.FormValue("client_data"))
+		attestation, err2 := webauthnB64.DecodeString(r.FormValue("attestation_object"))
+		if err1 != nil || err2 != nil {
+			writeFirebaseError(w, http.StatusBadRequest, "passkey")
+			return
+		}
+		credentialID, publicKey, signCount, err := verifyRegistration(attestation, clientData,
+			m.deps.PasskeyRPID, ch.Challenge, m.passkeyOrigins())
+		if err != nil {
+			log.Printf("[AUTH] passkey registration rejected for user %s: %v", userID, err)
+			writeFirebaseError(w, http.StatusBadRequest, "passkey")
+			return
+		}
+		if existing, err := store.GetPasskey(r.Context(), credentialID); err != nil || existing != nil {
+			log.Printf("[AUTH] passkey: credential already registered or lookup failed for user %s: %v", userID, err)
+			writeFirebaseError(w, http.StatusConflict, "exists")
+			return
+		}
+		name := strings.TrimSpace(r.FormValue("name"))
+		if runes := []rune(name); len(runes) > passkeyNameMaxLen {
+			name = string(runes[:passkeyNameMaxLen])
+		}
+		if name == "" {
+			name = "Passkey"
+		}
+		now := time.Now()
+		if err := store.SavePasskey(r.Context(), PasskeyCredential{
+			ID:         credentialID,
+			UserID:     userID,
+			Name:       name,
+			PublicKey:  publicKey,
+			SignCount:  signCount,
+			CreatedAt:  now,
+			LastUsedAt: now,
+		}); err != nil {
+			log.Printf("[AUTH] passkey: save failed for user %s: %v", userID, err)
+			writeFirebaseError(w, http.StatusInternalServerError, "passkey")
+			return
+		}
+		log.Printf("[AUTH] passkey registered: user=%s", userID)
+		writePasskeyJSON(w, map[string]string{"status": "registered"})
+	}
+}
+
+// passkeyAccountUser authenticates a registration call: the session cookie
+// must be valid (these routes sit under the session-excluded /auth/ prefix)
+// and the Origin header must be one of the passkey origins, so a cross-site
+// page cannot drive the ceremony. Impersonated sessions cannot register.
+func (m *AuthModule) passkeyAccountUser(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if err := r.ParseForm(); err != nil {
+		writeFirebaseJSON(w, http.StatusBadRequest, "invalid_request")
+		return "", false
+	}
+	origin := r.Header.Get("Origin")
+	allowed := false
+	for _, o := range m.passkeyOrigins() {
+		if origin == o {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		writeFirebaseError(w, http.StatusForbidden, "origin")
+		return "", false
+	}
+	userID := m.userIDFromSessionCookie(r)
+	if userID == "" {
+		writeFirebaseError(w, http.StatusUnauthorized, "unauthenticated")
+		return "", false
+	}
+	if m.impersonating(r.Context(), r) {
+		writeFirebaseError(w, http.StatusForbidden, "impersonating")
+		return "", false
+	}
+	return userID, true
+}
+
+func (m *AuthModule) newPasskeyChallenge(w http.ResponseWriter, userID string) ([]byte, error) {
+	challenge := make([]byte, 32)
+	if _, err := rand.Read(challenge); err != nil {
+		return nil, err
+	}
+	err := m.setSealedCookie(w, passkeyChallengeCookieName, passkeyChallenge{
+		Challenge: challenge,
+		UserID:    userID,
+		Expires:   time.Now().Add(passkeyChallengeTTL).Unix(),
+	}, passkeyChallengeTTL)
+	return challenge, err
+}
+
+// takePasskeyChallenge reads and clears the ceremony challenge — each one
+// verifies at most one response.
+func (m *AuthModule) takePasskeyChallenge(w http.ResponseWriter, r *http.Request) (passkeyChallenge, bool) {
+	var ch passkeyChallenge
+	ok := m.openSealedCookie(r, passkeyChallengeCookieName, &ch)
+	m.clearSealedCookie(w, passkeyChallengeCookieName)
+	if !ok || len(ch.Challenge) == 0 || time.Now().Unix() > ch.Expires {
+		return passkeyChallenge{}, false
+	}
+	return ch, true
+}
+
+// passkeysEnabled reports whether the passkey routes are wired: a store to
+// hold credentials, a minter for the session and the RP ID the ceremonies
+// are scoped to.
+func (m *AuthModule) passkeysEnabled() bool {
+	return m.deps.PasskeyStore != nil && m.deps.SessionMinter != nil && m.deps.PasskeyRPID != ""
+}
+
+// passkeyOrigins is the allowed clientData origin list (default
+// https://<PasskeyRPID>).
+func (m *AuthModule) passkeyOrigins() []string {
+	if len(m.deps.PasskeyOrigins) > 0 {
+		return m.deps.PasskeyOrigins
+	}
+	return []string{"https://" + m.deps.PasskeyRPID}
+}
+
+func writePasskeyJSON(w http.ResponseWriter, body any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(http.StatusOK)
+	_ = json.NewEncoder(w).Encode(body)
+}
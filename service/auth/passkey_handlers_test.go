@@ -0,0 +1,84 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	entydad "github.com/erniealice/entydad-golang"
+)
+
+// newPasskeyTestModule signs user-ana in with a's passkey.
+func newPasskeyTestModule(t *testing.T, a *testAuthenticator, sessions *recordingSessionManager) *AuthModule {
+	t.Helper()
+	store := NewMemoryPasskeyStore()
+	if err := store.SavePasskey(context.Background(), PasskeyCredential{ID: a.credID, UserID: "user-ana", PublicKey: a.coseKey()}); err != nil {
+		t.Fatal(err)
+	}
+	return NewAuthModule(&Deps{
+		SessionManager: sessions,
+		SessionMinter: func(_ context.Context, userID string) (string, error) {
+			return "session-for-" + userID, nil
+		},
+		PasskeyStore: store,
+		PasskeyRPID:  testRPID,
+		CSRFIssuer:   func(http.ResponseWriter, []byte, string, string) string { return "" },
+		CSRFSecret:   []byte("test-secret"),
+	})
+}
+
+// passkeyLogin runs the options + assertion ceremony with a.
+func passkeyLogin(t *testing.T, m *AuthModule, a *testAuthenticator, signCount uint32) *httptest.ResponseRecorder {
+	t.Helper()
+	options := httptest.NewRecorder()
+	m.handlePasskeyOptions()(options, httptest.NewRequest(http.MethodPost, entydad.AuthPasskeyOptionsURL, nil))
+	var opts struct{ Challenge string }
+	if err := json.NewDecoder(options.Body).Decode(&opts); err != nil {
+		t.Fatal(err)
+	}
+	challenge, _ := webauthnB64.DecodeString(opts.Challenge)
+	authData := testAuthData(testRPID, authFlagUserPresent|authFlagUserVerified, signCount, nil)
+	clientData := testClientData("webauthn.get", challenge, testOrigin)
+	form := url.Values{
+		"credential_id":      {webauthnB64.EncodeToString(a.credID)},
+		"client_data":        {webauthnB64.EncodeToString(clientData)},
+		"authenticator_data": {webauthnB64.EncodeToString(authData)},
+		"signature":          {webauthnB64.EncodeToString(a.sign(t, authData, clientData))},
+	}
+	return postForm(m.handlePasskeyLogin(), entydad.AuthPasskeyLoginURL, form, options.Result().Cookies())
+}
+
+// A passkey already verified the user, so an enrolled TOTP is not asked
+// for; the consent step still is, under the owner's address.
+func TestPasskeyLogin_ConsentWithoutTOTP(t *testing.T) {
+	t.Parallel()
+	a := newTestAuthenticator(t)
+	sessions := &recordingSessionManager{}
+	m := newPasskeyTestModule(t, a, sessions)
+	m.deps.EmailByUserID = func(context.Context, string) string { return "ana@example.com" }
+	m.deps.MFAStore = NewMemoryMFAStore()
+	if err := m.deps.MFAStore.SaveEnrollment(context.Background(), "user-ana", MFAEnrollment{Secret: rfc6238Secret}); err != nil {
+		t.Fatal(err)
+	}
+	m.deps.ConsentDocuments = []ConsentDocument{{Kind: "terms", Version: "2026-01", Title: "Terms"}}
+	m.deps.RecordConsent = func(context.Context, ConsentAcceptance) error { return nil }
+	m.deps.ListConsents = func(context.Context, string) ([]ConsentAcceptance, error) { return nil, nil }
+
+	rec := passkeyLogin(t, m, a, 1)
+	var body struct{ Redirect string }
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil || body.Redirect != entydad.AuthConsentURL {
+		t.Fatalf("passkey login → %q (%v), want the consent step", body.Redirect, err)
+	}
+	if sessions.token != "" || cookieNamed(rec, mfaPendingCookieName) != nil {
+		t.Fatalf("session %q set or TOTP challenge parked", sessions.token)
+	}
+	req := httptest.NewRequest(http.MethodGet, entydad.AuthConsentURL, nil)
+	req.AddCookie(cookieNamed(rec, consentPendingCookieName)[0])
+	p, ok := m.readConsentPending(req)
+	if !ok || p.Email != "ana@example.com" || p.Method != "passkey" {
+		t.Fatalf("consent pending = %+v, %v", p, ok)
+	}
+}
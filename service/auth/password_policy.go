@@ -0,0 +1,234 @@
+package auth
+
+import (
+	"context"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Password policy violation codes. They double as the ?error= codes on
+// signup02 / reset-password02 / change-password and, camel-cased, as the
+// shared.errors.password* keys in the admin user actions.
+const (
+	PasswordTooShort      = "too_short"
+	PasswordClasses       = "password_classes"
+	PasswordBreached      = "password_breached"
+	PasswordReused        = "password_reused"
+	PasswordContainsEmail = "password_contains_email"
+)
+
+// PasswordHistoryStore keeps the last few password hashes per user for the
+// no-reuse rule. Hashes are opaque strings produced by the policy
+// (PBKDF2-SHA256, per-entry salt); the store only appends, trims to keep
+// entries and lists newest first.
+type PasswordHistoryStore interface {
+	RecentPasswordHashes(ctx context.Context, userID string, limit int) ([]string, error)
+	AddPasswordHash(ctx context.Context, userID, hash string, keep int) error
+}
+
+// PasswordPolicy is the rule set applied to every new password: signup,
+// reset, change-password and the admin user add / reset-password actions.
+// Zero fields disable their rule. Check returns the first violated rule's
+// code ("" when the password is acceptable).
+type PasswordPolicy struct {
+	MinLength int // in characters
+	// MinCharClasses is how many of lower / upper / digit / symbol the
+	// password must mix (0–4).
+	MinCharClasses int
+	// Breached refuses passwords found in an offline breach corpus
+	// (DefaultBreachedPasswords, or a host-built LoadBreachedBloom file).
+	Breached BreachedPasswordList
+	// DisallowEmail refuses passwords containing the account email or its
+	// local part (when at least 3 characters long).
+	DisallowEmail bool
+	// History + HistorySize refuse the user's last HistorySize passwords.
+	// Needs the user id, so it applies to change-password, the admin
+	// actions and — when the reset token can be resolved — reset.
+	History     PasswordHistoryStore
+	HistorySize int
+}
+
+// DefaultPasswordPolicy returns the recommended rules: 8+ characters from
+// at least two classes, not breached, not the email, none of the last 5
+// (once a History store is wired).
+func DefaultPasswordPolicy() *PasswordPolicy {
+	return &PasswordPolicy{
+		MinLength:      8,
+		MinCharClasses: 2,
+		Breached:       DefaultBreachedPasswords(),
+		DisallowEmail:  true,
+		HistorySize:    5,
+	}
+}
+
+// Check returns the violated rule's code, or "" when password is
+// acceptable. userID and email may be empty (signup / unresolved reset
+// token); the rules that need them are then skipped. History store errors
+// fail open (logged) like the attempt limiter: an unavailable store must
+// not block password changes.
+func (p *PasswordPolicy) Check(ctx context.Context, userID, email, password string) string {
+	if p == nil {
+		return ""
+	}
+	n := utf8.RuneCountInString(password)
+	if n < p.MinLength || n == 0 {
+		return PasswordTooShort
+	}
+	if p.MinCharClasses > 0 && charClasses(password) < p.MinCharClasses {
+		return PasswordClasses
+	}
+	if p.DisallowEmail && containsEmail(password, email) {
+		return PasswordContainsEmail
+	}
+	if p.Breached != nil && p.Breached.Contains(password) {
+		return PasswordBreached
+	}
+	if p.History != nil && p.HistorySize > 0 && userID != "" {
+		hashes, err := p.History.RecentPasswordHashes(ctx, userID, p.HistorySize)
+		if err != nil {
+			log.Printf("[AUTH] password history unavailable for user %s: %v", userID, err)
+			return ""
+		}
+		for _, h := range hashes {
+			if passwordHashMatches(h, password) {
+				return PasswordReused
+			}
+		}
+	}
+	return ""
+}
+
+// Remember records password in userID's history once it has been set. A
+// no-op without a History store.
+func (p *PasswordPolicy) Remember(ctx context.Context, userID, password string) {
+	if p == nil || p.History == nil || p.HistorySize <= 0 || userID == "" {
+		return
+	}
+	h, err := hashPasswordForHistory(password)
+	if err == nil {
+		err = p.History.AddPasswordHash(ctx, userID, h, p.HistorySize)
+	}
+	if err != nil {
+		log.Printf("[AUTH] password history not recorded for user %s: %v", userID, err)
+	}
+}
+
+// MemoryPasswordHistoryStore is an in-process PasswordHistoryStore for
+// tests and single-instance development hosts.
+type MemoryPasswordHistoryStore struct {
+	mu     sync.Mutex
+	hashes map[string][]string // newest first
+}
+
+// NewMemoryPasswordHistoryStore returns an empty in-memory history store.
+func NewMemoryPasswordHistoryStore() *MemoryPasswordHistoryStore {
+	return &MemoryPasswordHistoryStore{hashes: make(map[string][]string)}
+}
+
+func (s *MemoryPasswordHistoryStore) RecentPasswordHashes(_ context.Context, userID string, limit int) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := s.hashes[userID]
+	if limit > 0 && len(h) > limit {
+		h = h[:limit]
+	}
+	return append([]string(nil), h...), nil
+}
+
+func (s *MemoryPasswordHistoryStore) AddPasswordHash(_ context.Context, userID, hash string, keep int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	h := append([]string{hash}, s.hashes[userID]...)
+	if keep > 0 && len(h) > keep {
+		h = h[:keep]
+	}
+	s.hashes[userID] = h
+	return nil
+}
+
+func charClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+	n := 0
+	for _, b := range []bool{lower, upper, digit, symbol} {
+		if b {
+			n++
+		}
+	}
+	return n
+}
+
+func containsEmail(password, email string) bool {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return false
+	}
+	pw := strings.ToLower(password)
+	if strings.Contains(pw, email) {
+		return true
+	}
+	local, _, _ := strings.Cut(email, "@")
+	return utf8.RuneCountInString(local) >= 3 && strings.Contains(pw, local)
+}
+
+// History hashes: "pbkdf2-sha256$<iter>$<salt>$<key>" (raw base64url).
+// The iteration count travels with each entry so it can be raised later
+// without invalidating stored history.
+const (
+	passwordHistoryScheme     = "pbkdf2-sha256"
+	passwordHistoryIterations = 210000
+	passwordHistoryKeyLen     = 32
+)
+
+func hashPasswordForHistory(password string) (string, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, passwordHistoryIterations, passwordHistoryKeyLen)
+	if err != nil {
+		return "", err
+	}
+	b64 := base64.RawURLEncoding
+	return fmt.Sprintf("%s$%d$%s$%s", passwordHistoryScheme, passwordHistoryIterations, b64.EncodeToString(salt), b64.EncodeToString(key)), nil
+}
+
+func passwordHashMatches(stored, password string) bool {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 4 || parts[0] != passwordHistoryScheme {
+		return false
+	}
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter < 1 || iter > 10_000_000 {
+		return false
+	}
+	b64 := base64.RawURLEncoding
+	salt, err1 := b64.DecodeString(parts[2])
+	want, err2 := b64.DecodeString(parts[3])
+	if err1 != nil || err2 != nil || len(want) == 0 {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	return err == nil && subtle.ConstantTimeCompare(got, want) == 1
+}
@@ -0,0 +1,125 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	t.Parallel()
+	p := DefaultPasswordPolicy()
+
+	tests := []struct {
+		name     string
+		email    string
+		password string
+		want     string
+	}{
+		{"acceptable", "ada@example.com", "correct-Horse-7", ""},
+		{"too short", "", "aB3$", PasswordTooShort},
+		{"multibyte counts characters", "", "ñandú-Ñandú", ""},
+		{"single class", "", "alllowercaseletters", PasswordClasses},
+		{"bundled breached entry", "", "password123", PasswordBreached},
+		{"contains full email", "ada@example.com", "x-ADA@example.com-1", PasswordContainsEmail},
+		{"contains local part", "lovelace@example.com", "Lovelace-2026", PasswordContainsEmail},
+		{"short local part ignored", "al@example.com", "totally-Al-42x", ""},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			if got := p.Check(context.Background(), "", tt.email, tt.password); got != tt.want {
+				t.Fatalf("Check(%q) = %q, want %q", tt.password, got, tt.want)
+			}
+		})
+	}
+
+	var nilPolicy *PasswordPolicy
+	if got := nilPolicy.Check(context.Background(), "u-1", "", "x"); got != "" {
+		t.Fatalf("nil policy Check = %q, want no violation", got)
+	}
+}
+
+func TestPasswordPolicy_History(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	p := DefaultPasswordPolicy()
+	p.History = NewMemoryPasswordHistoryStore()
+	p.HistorySize = 2
+
+	p.Remember(ctx, "u-1", "first-Passw0rd")
+	p.Remember(ctx, "u-1", "second-Passw0rd")
+	if got := p.Check(ctx, "u-1", "", "second-Passw0rd"); got != PasswordReused {
+		t.Fatalf("recent password: got %q, want %q", got, PasswordReused)
+	}
+	if got := p.Check(ctx, "u-2", "", "second-Passw0rd"); got != "" {
+		t.Fatalf("other user: got %q, want no violation", got)
+	}
+
+	// A third password pushes the first out of a 2-entry history.
+	p.Remember(ctx, "u-1", "third-Passw0rd")
+	if got := p.Check(ctx, "u-1", "", "first-Passw0rd"); got != "" {
+		t.Fatalf("aged-out password: got %q, want no violation", got)
+	}
+
+	hashes, _ := p.History.RecentPasswordHashes(ctx, "u-1", 10)
+	for _, h := range hashes {
+		if strings.Contains(h, "Passw0rd") {
+			t.Fatalf("history entry stores plaintext: %q", h)
+		}
+	}
+}
+
+type failingHistoryStore struct{}
+
+func (failingHistoryStore) RecentPasswordHashes(context.Context, string, int) ([]string, error) {
+	return nil, errors.New("store down")
+}
+
+func (failingHistoryStore) AddPasswordHash(context.Context, string, string, int) error {
+	return errors.New("store down")
+}
+
+func TestPasswordPolicy_HistoryFailsOpen(t *testing.T) {
+	t.Parallel()
+	p := &PasswordPolicy{History: failingHistoryStore{}, HistorySize: 5}
+	if got := p.Check(context.Background(), "u-1", "", "anything"); got != "" {
+		t.Fatalf("store error: got %q, want no violation", got)
+	}
+}
+
+func TestBreachedBloom_RoundTrip(t *testing.T) {
+	t.Parallel()
+	b := NewBreachedBloom(100, 0.001)
+	if _, err := b.AddLines(strings.NewReader("# comment\nhunter2\n\nletmein\n"), false); err != nil {
+		t.Fatalf("AddLines: %v", err)
+	}
+	// SHA-1("trustno1") in HIBP "HASH:count" form.
+	if _, err := b.AddLines(strings.NewReader("E68E11BE8B70E435C65AEF8BA9798FF7775C361E:3\n"), true); err != nil {
+		t.Fatalf("AddLines(hashed): %v", err)
+	}
+
+	var buf bytes.Buffer
+	if _, err := b.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	loaded, err := LoadBreachedBloom(&buf)
+	if err != nil {
+		t.Fatalf("LoadBreachedBloom: %v", err)
+	}
+	for _, pw := range []string{"hunter2", "letmein", "trustno1"} {
+		if !loaded.Contains(pw) {
+			t.Errorf("Contains(%q) = false, want true", pw)
+		}
+	}
+	if loaded.Contains("a-long-and-unlikely-passphrase") {
+		t.Errorf("unexpected hit for an unlisted password")
+	}
+
+	if _, err := LoadBreachedBloom(strings.NewReader("not a bloom file")); err == nil {
+		t.Fatalf("LoadBreachedBloom accepted garbage")
+	}
+}
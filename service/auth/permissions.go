@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+)
+
+// Runtime permissions: with PermissionGrants and PermissionCatalog set, the
+// codes a request carries are decided by the effective resolver, under the
+// rules the permission pages and the explainer show — a DENY beats any
+// ALLOW, a wildcard grant reaches every catalog code it covers, inactive
+// rows take no part. PermissionsMiddleware sets them on session requests
+// and bearer tokens are narrowed to them. Without the two deps the host's
+// PermissionCodes is used as it is, and wildcard and DENY rows are only as
+// enforced as the host's own permission chain enforces them.
+
+func (m *AuthModule) effectivePermissionsEnabled() bool {
+	return m.deps.PermissionGrants != nil && m.deps.PermissionCatalog != nil
+}
+
+// EffectivePermissionCodes returns the codes userID is allowed in
+// workspaceID, sorted: effective.Set.Runtime over the user's grants and the
+// workspace's catalog when PermissionGrants and PermissionCatalog are set,
+// else the host's PermissionCodes. Empty when neither is wired.
+func (m *AuthModule) EffectivePermissionCodes(ctx context.Context, userID, workspaceID string) ([]string, error) {
+	if !m.effectivePermissionsEnabled() {
+		if m.deps.PermissionCodes == nil {
+			return nil, nil
+		}
+		return m.deps.PermissionCodes(ctx, userID, workspaceID)
+	}
+	grants, err := m.deps.PermissionGrants(ctx, userID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	catalog, err := m.deps.PermissionCatalog(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return effective.New(grants).Runtime(catalog), nil
+}
+
+// PermissionsMiddleware replaces the permissions view.GetUserPermissions
+// reads with EffectivePermissionCodes for the request identity's user and
+// workspace, so perms.Can enforces wildcard and DENY rows the way the
+// permission pages show them. Wrap the app handler with it inside the
+// session middleware. Requests without a workspace identity pass through,
+// and those BearerTokenMiddleware authenticated keep the token's narrower
+// set. A failed lookup is answered 503 rather than served with the host's
+// set. Returns next unchanged when PermissionGrants or PermissionCatalog is
+// not set.
+func (m *AuthModule) PermissionsMiddleware(next http.Handler) http.Handler {
+	if !m.effectivePermissionsEnabled() {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		id, ok := identity.FromContext(ctx)
+		if !ok || id == nil || id.UserID == "" || id.WorkspaceID == "" || ctx.Value(ctxKeyAccessToken) != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		codes, err := m.EffectivePermissionCodes(ctx, id.UserID, id.WorkspaceID)
+		if err != nil {
+			log.Printf("[AUTH] permissions: failed to resolve user %s in workspace %s: %v", id.UserID, id.WorkspaceID, err)
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(view.WithUserPermissions(ctx, types.NewUserPermissions(codes))))
+	})
+}
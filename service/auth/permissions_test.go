@@ -0,0 +1,79 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+
+	"github.com/erniealice/entydad-golang/domain/entity/identity/permission/effective"
+)
+
+// newRuntimePermissionsModule gives user-ana a wildcard ALLOW on client
+// and a DENY on client:delete from another role.
+func newRuntimePermissionsModule(store AccessTokenStore) *AuthModule {
+	return NewAuthModule(&Deps{
+		AccessTokenStore: store,
+		PermissionGrants: func(_ context.Context, userID, workspaceID string) ([]effective.Grant, error) {
+			if userID != "user-ana" || workspaceID != "ws-1" {
+				return nil, nil
+			}
+			return []effective.Grant{
+				{RoleID: "sales", Code: "client:*"},
+				{RoleID: "auditor", Code: "client:delete", Deny: true},
+			}, nil
+		},
+		PermissionCatalog: func(context.Context, string) ([]string, error) {
+			return []string{"client:list", "client:delete", "invoice:list"}, nil
+		},
+	})
+}
+
+func TestPermissionsMiddleware_DenyRemovesCode(t *testing.T) {
+	t.Parallel()
+	m := newRuntimePermissionsModule(nil)
+
+	var perms *types.UserPermissions
+	h := m.PermissionsMiddleware(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		perms = view.GetUserPermissions(r.Context())
+	}))
+	serve := func(ctx context.Context) {
+		perms = nil
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/w/acme/clients", nil).WithContext(ctx))
+	}
+	// The host's literal chain would have granted client:delete.
+	host := view.WithUserPermissions(context.Background(), types.NewUserPermissions([]string{"client:delete"}))
+	session := identity.WithRequestIdentity(host, &identity.RequestIdentity{UserID: "user-ana", WorkspaceID: "ws-1"})
+
+	serve(session)
+	if perms == nil || !perms.Can("client", "list") || perms.Can("client", "delete") || perms.Can("invoice", "list") {
+		t.Fatalf("session permissions = %+v, want client:list only", perms)
+	}
+
+	// A bearer token keeps its own, narrower set.
+	serve(context.WithValue(session, ctxKeyAccessToken, "tok-1"))
+	if perms == nil || !perms.Can("client", "delete") || perms.Can("client", "list") {
+		t.Fatalf("token permissions = %+v, want the token's set untouched", perms)
+	}
+}
+
+func TestAccessToken_ScopesFollowEffectivePermissions(t *testing.T) {
+	t.Parallel()
+	m := newRuntimePermissionsModule(NewMemoryAccessTokenStore())
+	ctx := context.Background()
+
+	scopes, err := m.AccessTokenScopes(ctx, "user-ana", "ws-1")
+	if err != nil || len(scopes) != 1 || scopes[0] != "client:list" {
+		t.Fatalf("scopes = %v, %v; want [client:list]", scopes, err)
+	}
+	if _, _, err := m.IssueAccessToken(ctx, AccessTokenRequest{
+		UserID: "user-ana", Name: "purge", WorkspaceID: "ws-1", Permissions: []string{"client:delete"},
+	}); !errors.Is(err, ErrAccessTokenScope) {
+		t.Fatalf("token for a denied code: err = %v, want ErrAccessTokenScope", err)
+	}
+}
@@ -0,0 +1,110 @@
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// Sealed cookies carry short-lived pre-session state (the MFA pending login,
+// WebAuthn challenges) between two /auth/ requests without a server-side
+// store: AES-GCM sealed JSON under a key derived from CSRFSecret and the
+// cookie name (or a per-process random key when no secret is configured,
+// which only costs in-flight ceremonies on restart). Payloads carry their
+// own expiry — the cookie Max-Age is a hint, not a check.
+
+var errSealedCookie = errors.New("auth: sealed cookie invalid")
+
+func (m *AuthModule) setSealedCookie(w http.ResponseWriter, name string, v any, ttl time.Duration) error {
+	value, err := m.sealValue(name, v)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, m.authCookie(name, value, int(ttl.Seconds())))
+	return nil
+}
+
+// openSealedCookie decodes the named cookie into v; false when absent,
+// tampered with, or sealed under another key.
+func (m *AuthModule) openSealedCookie(r *http.Request, name string, v any) bool {
+	c, err := r.Cookie(name)
+	if err != nil || c.Value == "" {
+		return false
+	}
+	return m.openSealedValue(name, c.Value, v)
+}
+
+// sealValue seals v under name's key. Besides cookies it carries state that
+// must not be readable by the browser through a form field (the step-up
+// pending request).
+func (m *AuthModule) sealValue(name string, v any) (string, error) {
+	plain, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	aead, err := m.cookieCipher(name)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, plain, []byte(name))), nil
+}
+
+// openSealedValue reverses sealValue; false when tampered with or sealed
+// under another key or name.
+func (m *AuthModule) openSealedValue(name, value string, v any) bool {
+	sealed, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return false
+	}
+	aead, err := m.cookieCipher(name)
+	if err != nil || len(sealed) < aead.NonceSize() {
+		return false
+	}
+	nonce, body := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plain, err := aead.Open(nil, nonce, body, []byte(name))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(plain, v) == nil
+}
+
+func (m *AuthModule) clearSealedCookie(w http.ResponseWriter, name string) {
+	http.SetCookie(w, m.authCookie(name, "", -1))
+}
+
+// authCookie is the shared shape: HttpOnly, SameSite=Lax, scoped to /auth/
+// so it never rides along on app requests.
+func (m *AuthModule) authCookie(name, value string, maxAge int) *http.Cookie {
+	secure := false
+	if m.deps.SecureCookies != nil {
+		secure = m.deps.SecureCookies()
+	}
+	return &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/auth/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   secure,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+func (m *AuthModule) cookieCipher(name string) (cipher.AEAD, error) {
+	key := sha256.Sum256(append([]byte("entydad/"+name+":"), m.cookieKey...))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
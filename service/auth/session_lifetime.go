@@ -0,0 +1,409 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"html/template"
+	"log"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+// Session lifetimes: sessions minted by SessionMinter or AuthAdapter.Login
+// live as long as the host's session store lets them. On top of that the
+// module enforces an idle and an absolute lifetime, chosen per request by
+// Deps.SessionLifetimes (client-portal sessions can be shorter than
+// operator ones). Every sign-in starts the session's clock in
+// Deps.SessionActivity (signedIn) and SessionLifetimeMiddleware records when
+// it was last seen. A session token without a clock — minted before
+// lifetimes were switched on, or its record lost — cannot show it is within
+// its absolute lifetime and is treated as expired.
+//
+// An expired session is not served. A full-page request signs it out and
+// lands on the login page with ?error=session_expired. An htmx request is
+// held back the way a step-up action is: its method, URL and form are
+// sealed into a pending token and the response opens the re-login dialog.
+// Signing in there restarts the session's clock and replays the held-back
+// request, so a half-filled drawer is not lost. Impersonated sessions are
+// signed out instead of being offered the dialog.
+
+const (
+	sessionReloginPendingName = "entydad_relogin_pending"
+	// sessionActivityResolution is how often a session's last-seen time is
+	// written back; idle expiry is accurate to about this much.
+	sessionActivityResolution = time.Minute
+)
+
+// SessionLifetime bounds a session. Idle is the longest gap between two
+// requests, Absolute the longest time since sign-in (or the last re-login).
+// Zero disables the bound.
+type SessionLifetime struct {
+	Idle     time.Duration
+	Absolute time.Duration
+}
+
+// SessionLifetimePolicy picks the lifetime for the session behind id.
+type SessionLifetimePolicy func(ctx context.Context, id *identity.RequestIdentity) SessionLifetime
+
+// SessionLifetimeRules is a SessionLifetimePolicy from static settings: a
+// workspace listed in Workspaces uses its own lifetime; otherwise sessions
+// acting for a client or supplier (the portal principals) use ActingAs,
+// when set, and everything else Default.
+type SessionLifetimeRules struct {
+	Default    SessionLifetime
+	ActingAs   SessionLifetime
+	Workspaces map[string]SessionLifetime
+}
+
+// Policy returns r as a SessionLifetimePolicy.
+func (r SessionLifetimeRules) Policy() SessionLifetimePolicy {
+	return func(_ context.Context, id *identity.RequestIdentity) SessionLifetime {
+		if l, ok := r.Workspaces[id.WorkspaceID]; ok {
+			return l
+		}
+		if (id.ActingAsClientID != "" || id.ActingAsSupplierID != "") && r.ActingAs != (SessionLifetime{}) {
+			return r.ActingAs
+		}
+		return r.Default
+	}
+}
+
+// SessionActivity is when a session token was first and last seen.
+type SessionActivity struct {
+	UserID     string
+	StartedAt  time.Time
+	LastSeenAt time.Time
+}
+
+// SessionActivityStore keeps SessionActivity by session token.
+// GetSessionActivity returns nil, nil for a token it has not seen.
+// Persistent implementations should key by a hash of the token.
+type SessionActivityStore interface {
+	GetSessionActivity(ctx context.Context, token string) (*SessionActivity, error)
+	PutSessionActivity(ctx context.Context, token string, a SessionActivity) error
+	DeleteSessionActivity(ctx context.Context, token string) error
+}
+
+func (m *AuthModule) sessionLifetimesEnabled() bool {
+	d := m.deps
+	return d.SessionActivity != nil && d.SessionLifetimes != nil && d.AuthAdapter != nil && d.SessionManager != nil
+}
+
+// SessionLifetimeMiddleware enforces Deps.SessionLifetimes and serves the
+// re-login dialog at entydad.AuthSessionReloginURL. It needs the request
+// identity, so wrap the app handler inside the session middleware (and
+// outside StepUpMiddleware, so an expired session never reaches the
+// step-up prompt). Requests without a session token — bearer tokens,
+// signed-out pages — pass through. Returns next unchanged when lifetimes
+// are not configured.
+func (m *AuthModule) SessionLifetimeMiddleware(next http.Handler) http.Handler {
+	if !m.sessionLifetimesEnabled() {
+		return next
+	}
+	store := m.deps.SessionActivity
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == entydad.AuthSessionReloginURL {
+			m.serveRelogin(w, r, next)
+			return
+		}
+		ctx := r.Context()
+		id, ok := identity.FromContext(ctx)
+		if !ok || id == nil || id.UserID == "" || id.SessionToken == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+		now := time.Now()
+		a, err := store.GetSessionActivity(ctx, id.SessionToken)
+		if err != nil {
+			// Fail closed, but do not sign anyone out over a store outage.
+			log.Printf("[AUTH] session lifetime: lookup failed: %v", err)
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		if a == nil {
+			log.Printf("[AUTH] session lifetime: no clock for user %s's session", id.UserID)
+			m.expireSession(w, r, id, SessionExpiredAbsolute)
+			return
+		}
+		if reason := sessionExpired(a, m.deps.SessionLifetimes(ctx, id), now); reason != "" {
+			m.expireSession(w, r, id, reason)
+			return
+		}
+		if now.Sub(a.LastSeenAt) >= sessionActivityResolution {
+			a.LastSeenAt = now
+			m.putSessionActivity(ctx, id.SessionToken, *a)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// sessionExpired returns the AuthEvent reason a is past l with, "" while
+// the session is live.
+func sessionExpired(a *SessionActivity, l SessionLifetime, now time.Time) string {
+	switch {
+	case l.Absolute > 0 && now.Sub(a.StartedAt) >= l.Absolute:
+		return SessionExpiredAbsolute
+	case l.Idle > 0 && now.Sub(a.LastSeenAt) >= l.Idle:
+		return SessionExpiredIdle
+	}
+	return ""
+}
+
+// expireSession answers a request on an expired session: htmx requests are
+// held back behind the re-login dialog, everything else signs out.
+func (m *AuthModule) expireSession(w http.ResponseWriter, r *http.Request, id *identity.RequestIdentity, reason string) {
+	if r.Header.Get("HX-Request") == "true" && !m.impersonating(r.Context(), r) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+		pending, err := m.sealValue(sessionReloginPendingName, stepUpPending{
+			UserID: id.UserID,
+			Method: r.Method,
+			URL:    r.URL.RequestURI(),
+			Form:   r.PostForm,
+			Exp:    time.Now().Add(stepUpPendingTTL).Unix(),
+		})
+		if err == nil {
+			log.Printf("[AUTH] session expired (%s): user=%s, holding back %s %s for re-login", reason, id.UserID, r.Method, r.URL.Path)
+			m.promptRelogin(w, r, pending, "")
+			return
+		}
+		log.Printf("[AUTH] session lifetime: failed to seal pending request: %v", err)
+	}
+	log.Printf("[AUTH] session expired (%s): user=%s, signing out", reason, id.UserID)
+	m.endExpiredSession(w, r, id, reason)
+	redirectTopLevel(w, r, entydad.AuthLoginURL+"?error=session_expired")
+}
+
+// endExpiredSession invalidates the session and clears its cookies.
+func (m *AuthModule) endExpiredSession(w http.ResponseWriter, r *http.Request, id *identity.RequestIdentity, reason string) {
+	ctx := r.Context()
+	if err := m.deps.AuthAdapter.InvalidateSession(ctx, id.SessionToken); err != nil {
+		log.Printf("[AUTH] session lifetime: failed to invalidate session: %v", err)
+	}
+	m.logoutImpersonation(ctx, id.SessionToken)
+	m.forgetSessionActivity(ctx, id.SessionToken)
+	m.deps.SessionManager.ClearSessionCookie(w)
+	m.clearCredentialCheck(w)
+	m.recordAuthEvent(ctx, r, AuthEvent{Type: AuthEventSessionExpired, UserID: id.UserID, Email: id.Email, Reason: reason})
+}
+
+// promptRelogin answers a held-back or failed request with the re-login
+// dialog, the way promptStepUp does.
+func (m *AuthModule) promptRelogin(w http.ResponseWriter, r *http.Request, pending, errorCode string) {
+	if r.Header.Get("HX-Request") != "true" {
+		m.renderRelogin(w, r, pending, errorCode, http.StatusUnauthorized)
+		return
+	}
+	target := entydad.AuthSessionReloginURL + "?p=" + url.QueryEscape(pending)
+	if errorCode != "" {
+		target += "&error=" + errorCode
+	}
+	location, _ := json.Marshal(map[string]string{
+		"path":   target,
+		"target": "[data-dialog-container]",
+		"swap":   "innerHTML",
+	})
+	w.Header().Set("HX-Location", string(location))
+	w.Header().Set("HX-Error-Message", m.sessionExpiryLabels().Title)
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// serveRelogin handles entydad.AuthSessionReloginURL. GET renders the
+// dialog for the pending token in ?p=; POST checks the password or code
+// and, when right, restarts the session's clock and replays the pending
+// request to next.
+func (m *AuthModule) serveRelogin(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	id, ok := identity.FromContext(r.Context())
+	if !ok || id == nil || id.UserID == "" || id.SessionToken == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		m.renderRelogin(w, r, r.URL.Query().Get("p"), r.URL.Query().Get("error"), http.StatusOK)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	token := r.PostFormValue("p")
+	pending, ok := m.openHeldRequest(sessionReloginPendingName, token, id.UserID)
+	if !ok {
+		m.promptRelogin(w, r, token, "")
+		return
+	}
+
+	attempt := LoginAttempt{Scope: LoginScopeRelogin, Email: id.Email, IP: m.clientIP(r)}
+	decision := m.deps.LoginAttemptLimiter.Check(ctx, attempt)
+	if !decision.Allowed {
+		log.Printf("[AUTH] re-login throttled: user=%s locked=%v", id.UserID, decision.Locked)
+		setRetryAfter(w, decision.RetryAfter)
+		m.promptRelogin(w, r, token, "throttled")
+		return
+	}
+	if !waitLoginDelay(ctx, decision.Delay) {
+		return
+	}
+	if !m.verifyStepUp(ctx, id.UserID, id.Email, r.PostFormValue("password"), r.PostFormValue("code")) {
+		log.Printf("[AUTH] re-login failed: user=%s", id.UserID)
+		if d := m.deps.LoginAttemptLimiter.RecordFailure(ctx, attempt); d.Locked {
+			setRetryAfter(w, d.RetryAfter)
+			m.promptRelogin(w, r, token, "throttled")
+			return
+		}
+		m.promptRelogin(w, r, token, "invalid")
+		return
+	}
+	m.deps.LoginAttemptLimiter.RecordSuccess(ctx, attempt)
+
+	m.signedIn(w, r, id.SessionToken, id.UserID)
+	log.Printf("[AUTH] re-login confirmed: user=%s, replaying %s %s", id.UserID, pending.Method, pending.URL)
+	next.ServeHTTP(w, replayStepUp(r, pending))
+}
+
+// sessionReloginTemplate renders sessionReloginHTML.
+var sessionReloginTemplate = template.Must(template.New("session-relogin").Parse(sessionReloginHTML))
+
+// renderRelogin writes the dialog for token. An unusable token shows the
+// expired message without a form.
+func (m *AuthModule) renderRelogin(w http.ResponseWriter, r *http.Request, token, errorCode string, status int) {
+	labels := m.sessionExpiryLabels()
+	data := map[string]any{
+		"Labels": labels,
+		"Action": entydad.AuthSessionReloginURL,
+		"Token":  token,
+	}
+	id, _ := identity.FromContext(r.Context())
+	if id != nil {
+		if _, ok := m.openHeldRequest(sessionReloginPendingName, token, id.UserID); ok {
+			data["Valid"] = true
+			if enabled, _, err := m.MFAStatus(r.Context(), id.UserID); err == nil && enabled {
+				data["MFA"] = true
+			}
+		}
+	}
+	switch errorCode {
+	case "invalid":
+		data["Error"] = labels.ErrorInvalid
+	case "throttled":
+		data["Error"] = labels.ErrorThrottled
+	}
+
+	var buf bytes.Buffer
+	if err := sessionReloginTemplate.Execute(&buf, data); err != nil {
+		log.Printf("[AUTH] session lifetime: failed to render re-login dialog: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (m *AuthModule) sessionExpiryLabels() entydad.SessionExpiryLabels {
+	if m.deps.Labels.SessionExpiry.Title == "" {
+		return entydad.DefaultSessionExpiryLabels()
+	}
+	return m.deps.Labels.SessionExpiry
+}
+
+// startSessionClock starts token's idle and absolute lifetimes at a
+// sign-in (or re-login).
+func (m *AuthModule) startSessionClock(ctx context.Context, token, userID string) {
+	if m.deps.SessionActivity == nil || token == "" {
+		return
+	}
+	now := time.Now()
+	m.putSessionActivity(ctx, token, SessionActivity{UserID: userID, StartedAt: now, LastSeenAt: now})
+}
+
+func (m *AuthModule) putSessionActivity(ctx context.Context, token string, a SessionActivity) {
+	if err := m.deps.SessionActivity.PutSessionActivity(ctx, token, a); err != nil {
+		log.Printf("[AUTH] session lifetime: failed to record activity: %v", err)
+	}
+}
+
+// carrySessionActivity moves the clock of a rotated session to its new
+// token, so switching principal does not restart the absolute lifetime.
+func (m *AuthModule) carrySessionActivity(ctx context.Context, oldToken, newToken string) {
+	store := m.deps.SessionActivity
+	if store == nil || oldToken == "" || newToken == "" || oldToken == newToken {
+		return
+	}
+	a, err := store.GetSessionActivity(ctx, oldToken)
+	if err != nil {
+		log.Printf("[AUTH] session lifetime: lookup failed: %v", err)
+		return
+	}
+	if a == nil {
+		return
+	}
+	if err := store.PutSessionActivity(ctx, newToken, *a); err != nil {
+		log.Printf("[AUTH] session lifetime: failed to record activity: %v", err)
+		return
+	}
+	m.forgetSessionActivity(ctx, oldToken)
+}
+
+// forgetSessionActivity drops a signed-out session's clock.
+func (m *AuthModule) forgetSessionActivity(ctx context.Context, token string) {
+	store := m.deps.SessionActivity
+	if store == nil || token == "" {
+		return
+	}
+	if err := store.DeleteSessionActivity(ctx, token); err != nil {
+		log.Printf("[AUTH] session lifetime: failed to forget session: %v", err)
+	}
+}
+
+// MemorySessionActivityStore is an in-process SessionActivityStore for
+// single-instance deployments and tests.
+type MemorySessionActivityStore struct {
+	mu       sync.Mutex
+	sessions map[string]SessionActivity
+}
+
+// NewMemorySessionActivityStore returns an empty in-memory store.
+func NewMemorySessionActivityStore() *MemorySessionActivityStore {
+	return &MemorySessionActivityStore{sessions: make(map[string]SessionActivity)}
+}
+
+// GetSessionActivity implements SessionActivityStore.
+func (s *MemorySessionActivityStore) GetSessionActivity(_ context.Context, token string) (*SessionActivity, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.sessions[token]
+	if !ok {
+		return nil, nil
+	}
+	return &a, nil
+}
+
+// PutSessionActivity implements SessionActivityStore.
+func (s *MemorySessionActivityStore) PutSessionActivity(_ context.Context, token string, a SessionActivity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sessions[token] = a
+	return nil
+}
+
+// DeleteSessionActivity implements SessionActivityStore.
+func (s *MemorySessionActivityStore) DeleteSessionActivity(_ context.Context, token string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.sessions, token)
+	return nil
+}
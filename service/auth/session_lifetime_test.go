@@ -0,0 +1,200 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+type sessionLifetimeHarness struct {
+	m        *AuthModule
+	adapter  *passwordAdapter
+	store    *MemorySessionActivityStore
+	sessions *recordingSessionManager
+	h        http.Handler
+	served   []url.Values
+}
+
+func newSessionLifetimeHarness(t *testing.T) *sessionLifetimeHarness {
+	t.Helper()
+	s := &sessionLifetimeHarness{
+		adapter:  &passwordAdapter{users: map[string]string{"rosa@example.com": "s3cret-pass"}},
+		store:    NewMemorySessionActivityStore(),
+		sessions: &recordingSessionManager{token: "tok"},
+	}
+	s.m = NewAuthModule(&Deps{
+		AuthAdapter:     s.adapter,
+		SessionManager:  s.sessions,
+		Renderer:        nopRenderer{},
+		CSRFSecret:      []byte("test-secret"),
+		SessionActivity: s.store,
+		SessionLifetimes: SessionLifetimeRules{
+			Default:  SessionLifetime{Idle: 30 * time.Minute, Absolute: 12 * time.Hour},
+			ActingAs: SessionLifetime{Idle: 10 * time.Minute, Absolute: 2 * time.Hour},
+		}.Policy(),
+	})
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		s.served = append(s.served, r.PostForm)
+		w.Header().Set("HX-Trigger", "client-saved")
+	})
+	mw := s.m.SessionLifetimeMiddleware(app)
+	// Stands in for the session middleware.
+	s.h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := identity.WithRequestIdentity(r.Context(), &identity.RequestIdentity{
+			UserID:           "user-1",
+			Email:            "rosa@example.com",
+			SessionToken:     "tok",
+			ActingAsClientID: r.Header.Get("X-Test-Acting-As"),
+		})
+		mw.ServeHTTP(w, r.WithContext(ctx))
+	})
+	return s
+}
+
+func (s *sessionLifetimeHarness) do(method, target string, htmx bool, actingAs string, form url.Values) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if htmx {
+		req.Header.Set("HX-Request", "true")
+	}
+	req.Header.Set("X-Test-Acting-As", actingAs)
+	rec := httptest.NewRecorder()
+	s.h.ServeHTTP(rec, req)
+	return rec
+}
+
+// age makes the session look started and last seen the given time ago.
+func (s *sessionLifetimeHarness) age(started, lastSeen time.Duration) {
+	now := time.Now()
+	_ = s.store.PutSessionActivity(context.Background(), "tok", SessionActivity{
+		UserID: "user-1", StartedAt: now.Add(-started), LastSeenAt: now.Add(-lastSeen),
+	})
+}
+
+func TestSessionLifetime_ReloginReplaysHeldBackRequest(t *testing.T) {
+	t.Parallel()
+	s := newSessionLifetimeHarness(t)
+	save := url.Values{"name": {"Acme"}}
+
+	// Signing in starts the clock.
+	s.m.signedIn(httptest.NewRecorder(), httptest.NewRequest(http.MethodPost, entydad.AuthLoginPostURL, nil), "tok", "user-1")
+	s.do(http.MethodGet, "/w/acme/clients", false, "", nil)
+	if a, _ := s.store.GetSessionActivity(context.Background(), "tok"); a == nil || len(s.served) != 1 {
+		t.Fatalf("first request: activity = %+v, served = %d", a, len(s.served))
+	}
+
+	// Idle too long: the drawer submit is held back behind the dialog.
+	s.age(time.Hour, 45*time.Minute)
+	rec := s.do(http.MethodPost, "/action/client/add", true, "", save)
+	var loc struct{ Path, Target string }
+	if err := json.Unmarshal([]byte(rec.Header().Get("HX-Location")), &loc); err != nil {
+		t.Fatalf("HX-Location %q: %v", rec.Header().Get("HX-Location"), err)
+	}
+	if rec.Code != http.StatusUnauthorized || loc.Target != "[data-dialog-container]" ||
+		!strings.HasPrefix(loc.Path, entydad.AuthSessionReloginURL+"?p=") || len(s.served) != 1 {
+		t.Fatalf("held back: %d HX-Location=%+v served=%d", rec.Code, loc, len(s.served))
+	}
+	if len(s.adapter.invalidated) != 0 {
+		t.Fatalf("session invalidated while the dialog is open: %v", s.adapter.invalidated)
+	}
+	u, _ := url.Parse(loc.Path)
+	token := u.Query().Get("p")
+
+	rec = s.do(http.MethodGet, loc.Path, true, "", nil)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `data-testid="session-relogin-form"`) {
+		t.Fatalf("dialog: %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Wrong password: asked again, nothing served.
+	rec = s.do(http.MethodPost, entydad.AuthSessionReloginURL, true, "", url.Values{"p": {token}, "password": {"nope"}})
+	if !strings.Contains(rec.Header().Get("HX-Location"), "error=invalid") || len(s.served) != 1 {
+		t.Fatalf("wrong password: HX-Location=%q served=%d", rec.Header().Get("HX-Location"), len(s.served))
+	}
+
+	// Right password: the clock restarts and the held-back submit runs.
+	rec = s.do(http.MethodPost, entydad.AuthSessionReloginURL, true, "", url.Values{"p": {token}, "password": {"s3cret-pass"}})
+	if len(s.served) != 2 || s.served[1].Get("name") != "Acme" || rec.Header().Get("HX-Trigger") != "client-saved" {
+		t.Fatalf("replay: served=%v HX-Trigger=%q", s.served, rec.Header().Get("HX-Trigger"))
+	}
+	a, _ := s.store.GetSessionActivity(context.Background(), "tok")
+	if a == nil || time.Since(a.StartedAt) > time.Minute {
+		t.Fatalf("clock not restarted: %+v", a)
+	}
+}
+
+func TestSessionLifetime_FullPageSignsOut(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name          string
+		actingAs      string
+		started, seen time.Duration
+		noClock       bool // no sign-in recorded for the token
+		wantExpired   bool
+	}{
+		{name: "operator within both lifetimes", started: 3 * time.Hour, seen: 20 * time.Minute},
+		{name: "portal session idle past its shorter timeout", actingAs: "client-A", started: time.Hour, seen: 20 * time.Minute, wantExpired: true},
+		{name: "operator past the absolute lifetime", started: 13 * time.Hour, seen: time.Minute, wantExpired: true},
+		{name: "session without a clock", noClock: true, wantExpired: true},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			s := newSessionLifetimeHarness(t)
+			if !tt.noClock {
+				s.age(tt.started, tt.seen)
+			}
+
+			rec := s.do(http.MethodGet, "/w/acme/clients", false, tt.actingAs, nil)
+			if !tt.wantExpired {
+				if len(s.served) != 1 {
+					t.Fatalf("served = %d, want 1", len(s.served))
+				}
+				return
+			}
+			if got := rec.Header().Get("Location"); got != entydad.AuthLoginURL+"?error=session_expired" || len(s.served) != 0 {
+				t.Fatalf("Location = %q, served = %d", got, len(s.served))
+			}
+			if len(s.adapter.invalidated) != 1 || s.adapter.invalidated[0] != "tok" || s.sessions.token != "" {
+				t.Fatalf("invalidated = %v, cookie = %q", s.adapter.invalidated, s.sessions.token)
+			}
+			if a, _ := s.store.GetSessionActivity(context.Background(), "tok"); a != nil {
+				t.Fatalf("activity kept after sign-out: %+v", a)
+			}
+		})
+	}
+}
+
+type failingActivityStore struct{ *MemorySessionActivityStore }
+
+func (failingActivityStore) GetSessionActivity(context.Context, string) (*SessionActivity, error) {
+	return nil, errors.New("store down")
+}
+
+// A store outage serves nothing but signs no one out.
+func TestSessionLifetime_StoreErrorFailsClosed(t *testing.T) {
+	t.Parallel()
+	s := newSessionLifetimeHarness(t)
+	s.m.deps.SessionActivity = failingActivityStore{s.store}
+	s.h = s.m.SessionLifetimeMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+		t.Error("request served on a store error")
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/w/acme/clients", nil)
+	req = req.WithContext(identity.WithRequestIdentity(req.Context(), &identity.RequestIdentity{UserID: "user-1", SessionToken: "tok"}))
+	rec := httptest.NewRecorder()
+	s.h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusServiceUnavailable || len(s.adapter.invalidated) != 0 {
+		t.Fatalf("status = %d, invalidated = %v", rec.Code, s.adapter.invalidated)
+	}
+}
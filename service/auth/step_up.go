@@ -0,0 +1,443 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+// Step-up re-authentication: the actions in Deps.StepUpActions (granting
+// permissions, deleting workspaces, resetting passwords, ...) need a
+// credential check younger than Deps.StepUpMaxAge, so a stolen session
+// cookie alone cannot perform them. A credential check is any sign-in or a
+// confirmed step-up prompt; it is recorded in a sealed cookie bound to the
+// user and the session, so an impersonated session never has one:
+// impersonators cannot perform sensitive actions. A session rotated by a
+// principal switch carries the check over.
+//
+// A stale request is not served. Instead its method, URL and form are
+// sealed into a pending token and the response asks htmx (HX-Location) to
+// load the prompt into pyeza's dialog. The prompt posts the password or an
+// MFA code together with the token; on success the original request is
+// replayed to the app and its response goes back to the dialog form, which
+// closes the dialog and refreshes the table exactly as the original
+// confirmation would have. Row and bulk actions that pyeza sends with
+// fetch() ignore HX-Location, so they fail quietly while stale — mark their
+// single-item drawer or dialog forms instead where possible.
+
+const (
+	stepUpCookieName  = "entydad_step_up"
+	stepUpPendingName = "entydad_step_up_pending"
+	stepUpPendingTTL  = 10 * time.Minute
+)
+
+// stepUpClaims is the credential-check cookie payload. Session is the
+// sessionTokenHash of the session the check was made in.
+type stepUpClaims struct {
+	UserID  string `json:"u"`
+	Session string `json:"s"`
+	At      int64  `json:"t"`
+}
+
+// stepUpPending is the request held back until the prompt is confirmed.
+type stepUpPending struct {
+	UserID string     `json:"u"`
+	Method string     `json:"m"`
+	URL    string     `json:"p"`
+	Form   url.Values `json:"f,omitempty"`
+	Exp    int64      `json:"e"`
+}
+
+func (m *AuthModule) stepUpEnabled() bool {
+	return len(m.deps.StepUpActions) > 0 && m.deps.AuthAdapter != nil
+}
+
+// recordCredentialCheck marks now as userID's last credential check in
+// the session token.
+func (m *AuthModule) recordCredentialCheck(w http.ResponseWriter, token, userID string) {
+	m.writeCredentialCheck(w, stepUpClaims{UserID: userID, Session: sessionTokenHash(token), At: time.Now().Unix()})
+}
+
+// carryCredentialCheck moves the request's credential check from a
+// rotated session to its new token, keeping the time it was made.
+func (m *AuthModule) carryCredentialCheck(w http.ResponseWriter, r *http.Request, oldToken, newToken string) {
+	var c stepUpClaims
+	if !m.stepUpEnabled() || !m.openSealedCookie(r, stepUpCookieName, &c) || c.Session != sessionTokenHash(oldToken) {
+		return
+	}
+	c.Session = sessionTokenHash(newToken)
+	m.writeCredentialCheck(w, c)
+}
+
+func (m *AuthModule) writeCredentialCheck(w http.ResponseWriter, claims stepUpClaims) {
+	if !m.stepUpEnabled() || claims.UserID == "" || claims.Session == "" {
+		return
+	}
+	value, err := m.sealValue(stepUpCookieName, claims)
+	if err != nil {
+		log.Printf("[AUTH] step-up: failed to record credential check: %v", err)
+		return
+	}
+	c := m.authCookie(stepUpCookieName, value, int(m.deps.StepUpMaxAge.Seconds()))
+	c.Path = "/" // read on app requests, unlike the /auth/ ceremony cookies
+	http.SetCookie(w, c)
+}
+
+func (m *AuthModule) clearCredentialCheck(w http.ResponseWriter) {
+	c := m.authCookie(stepUpCookieName, "", -1)
+	c.Path = "/"
+	http.SetCookie(w, c)
+}
+
+// credentialCheckFresh reports whether userID passed a credential check
+// within StepUpMaxAge in this browser's current session. A check made in
+// another session (one signed out of, or whose cookie was copied away)
+// does not count.
+func (m *AuthModule) credentialCheckFresh(r *http.Request, userID string) bool {
+	var c stepUpClaims
+	if !m.openSealedCookie(r, stepUpCookieName, &c) || c.UserID != userID {
+		return false
+	}
+	if c.Session == "" || c.Session != sessionTokenHash(m.requestSessionToken(r)) {
+		return false
+	}
+	return time.Since(time.Unix(c.At, 0)) < m.deps.StepUpMaxAge
+}
+
+// requestSessionToken is the session token the request carries: the
+// session cookie, else the token the session middleware resolved.
+func (m *AuthModule) requestSessionToken(r *http.Request) string {
+	if cookie, err := r.Cookie(m.deps.SessionCookieName); err == nil && cookie.Value != "" {
+		return cookie.Value
+	}
+	if id, ok := identity.FromContext(r.Context()); ok && id != nil {
+		return id.SessionToken
+	}
+	return ""
+}
+
+// sessionTokenHash identifies a session in the credential-check cookie
+// without carrying the token itself. Empty for no token.
+func sessionTokenHash(token string) string {
+	if token == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// StepUpMiddleware holds back the sensitive actions of a session whose last
+// credential check is older than StepUpMaxAge and serves the step-up prompt
+// at entydad.AuthStepUpURL. It needs the request identity, so wrap the app
+// handler inside the session middleware (and inside the workspace form
+// guard, which has then already checked the request being held back); the
+// /auth/ passkey registration endpoints fall back to the session cookie.
+// Requests accepting JSON get {"error":"step_up"} instead of the prompt.
+// Returns next unchanged when step-up is not configured.
+func (m *AuthModule) StepUpMiddleware(next http.Handler) http.Handler {
+	if !m.stepUpEnabled() {
+		return next
+	}
+	sensitive := m.stepUpMatchers()
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == entydad.AuthStepUpURL {
+			m.serveStepUp(w, r, next)
+			return
+		}
+		if !sensitive(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID := m.stepUpUser(r)
+		if userID == "" || m.credentialCheckFresh(r, userID) {
+			// Without a user the handler's own auth check answers.
+			next.ServeHTTP(w, r)
+			return
+		}
+		if acceptsJSON(r) {
+			// fetch() ceremonies (passkey registration) cannot follow the
+			// prompt; they show their own message instead.
+			log.Printf("[AUTH] step-up required: user=%s %s %s", userID, r.Method, r.URL.Path)
+			writeFirebaseError(w, http.StatusUnauthorized, "step_up")
+			return
+		}
+
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, "Invalid form data", http.StatusBadRequest)
+			return
+		}
+		pending, err := m.sealValue(stepUpPendingName, stepUpPending{
+			UserID: userID,
+			Method: r.Method,
+			URL:    r.URL.RequestURI(),
+			Form:   r.PostForm,
+			Exp:    time.Now().Add(stepUpPendingTTL).Unix(),
+		})
+		if err != nil {
+			log.Printf("[AUTH] step-up: failed to seal pending request: %v", err)
+			http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+			return
+		}
+		log.Printf("[AUTH] step-up required: user=%s %s %s", userID, r.Method, r.URL.Path)
+		m.promptStepUp(w, r, pending, "")
+	})
+}
+
+// stepUpUser is the user a request acts as: the request identity, else
+// the session cookie's user for the /auth/ endpoints, which are mounted
+// outside the session middleware.
+func (m *AuthModule) stepUpUser(r *http.Request) string {
+	if id, ok := identity.FromContext(r.Context()); ok && id != nil && id.UserID != "" {
+		return id.UserID
+	}
+	return m.userIDFromSessionCookie(r)
+}
+
+// acceptsJSON reports whether the request asked for a JSON answer.
+func acceptsJSON(r *http.Request) bool {
+	return strings.Contains(r.Header.Get("Accept"), "application/json")
+}
+
+// stepUpMatchers compiles StepUpActions into a request matcher. Each
+// pattern gets its own ServeMux: patterns from different modules may
+// overlap (/action/{entity}/... and /action/user/reset-password/{id}), which
+// a single mux rejects. Invalid patterns are logged and skipped.
+func (m *AuthModule) stepUpMatchers() func(r *http.Request) bool {
+	matched := http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+	seen := make(map[string]bool, len(m.deps.StepUpActions))
+	var muxes []*http.ServeMux
+	for _, pattern := range m.deps.StepUpActions {
+		if seen[pattern] {
+			continue
+		}
+		seen[pattern] = true
+		mux := http.NewServeMux()
+		if err := handleStepUpAction(mux, pattern, matched); err != nil {
+			log.Printf("[AUTH] step-up: ignoring action %q: %v", pattern, err)
+			continue
+		}
+		muxes = append(muxes, mux)
+	}
+	return func(r *http.Request) bool {
+		for _, mux := range muxes {
+			if _, pattern := mux.Handler(r); pattern != "" {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// handleStepUpAction registers pattern, turning ServeMux's panic on an
+// invalid pattern into an error.
+func handleStepUpAction(mux *http.ServeMux, pattern string, h http.Handler) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("%v", p)
+		}
+	}()
+	mux.Handle(pattern, h)
+	return nil
+}
+
+// promptStepUp answers a held-back or failed request with the prompt. htmx
+// follows HX-Location even on this 401, loading the prompt into the dialog
+// container (which opens it); the form that sent the request shows
+// HX-Error-Message. Plain requests get the prompt markup itself.
+func (m *AuthModule) promptStepUp(w http.ResponseWriter, r *http.Request, pending, errorCode string) {
+	if r.Header.Get("HX-Request") != "true" {
+		m.renderStepUp(w, r, pending, errorCode, http.StatusUnauthorized)
+		return
+	}
+	target := entydad.AuthStepUpURL + "?p=" + url.QueryEscape(pending)
+	if errorCode != "" {
+		target += "&error=" + errorCode
+	}
+	location, _ := json.Marshal(map[string]string{
+		"path":   target,
+		"target": "[data-dialog-container]",
+		"swap":   "innerHTML",
+	})
+	w.Header().Set("HX-Location", string(location))
+	w.Header().Set("HX-Error-Message", m.stepUpLabels().Message)
+	w.WriteHeader(http.StatusUnauthorized)
+}
+
+// serveStepUp handles entydad.AuthStepUpURL. GET renders the prompt for
+// the pending token in ?p=; POST checks the password or code and, when
+// right, replays the pending request to next.
+func (m *AuthModule) serveStepUp(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	id, ok := identity.FromContext(r.Context())
+	if !ok || id == nil || id.UserID == "" {
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		m.renderStepUp(w, r, r.URL.Query().Get("p"), r.URL.Query().Get("error"), http.StatusOK)
+		return
+	case http.MethodPost:
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	ctx := r.Context()
+	token := r.PostFormValue("p")
+	pending, ok := m.openStepUpPending(token, id.UserID)
+	if !ok {
+		m.promptStepUp(w, r, token, "")
+		return
+	}
+
+	attempt := LoginAttempt{Scope: LoginScopeStepUp, Email: id.Email, IP: m.clientIP(r)}
+	decision := m.deps.LoginAttemptLimiter.Check(ctx, attempt)
+	if !decision.Allowed {
+		log.Printf("[AUTH] step-up throttled: user=%s locked=%v", id.UserID, decision.Locked)
+		setRetryAfter(w, decision.RetryAfter)
+		m.promptStepUp(w, r, token, "throttled")
+		return
+	}
+	if !waitLoginDelay(ctx, decision.Delay) {
+		return
+	}
+	if !m.verifyStepUp(ctx, id.UserID, id.Email, r.PostFormValue("password"), r.PostFormValue("code")) {
+		log.Printf("[AUTH] step-up failed: user=%s", id.UserID)
+		if d := m.deps.LoginAttemptLimiter.RecordFailure(ctx, attempt); d.Locked {
+			setRetryAfter(w, d.RetryAfter)
+			m.promptStepUp(w, r, token, "throttled")
+			return
+		}
+		m.promptStepUp(w, r, token, "invalid")
+		return
+	}
+	m.deps.LoginAttemptLimiter.RecordSuccess(ctx, attempt)
+
+	m.recordCredentialCheck(w, m.requestSessionToken(r), id.UserID)
+	log.Printf("[AUTH] step-up confirmed: user=%s, replaying %s %s", id.UserID, pending.Method, pending.URL)
+	next.ServeHTTP(w, replayStepUp(r, pending))
+}
+
+// openStepUpPending opens a pending token; false when tampered with,
+// expired, or sealed for another user.
+func (m *AuthModule) openStepUpPending(token, userID string) (*stepUpPending, bool) {
+	return m.openHeldRequest(stepUpPendingName, token, userID)
+}
+
+// openHeldRequest opens a held-back request sealed under name (step-up or
+// re-login); false when tampered with, expired, or sealed for another user.
+func (m *AuthModule) openHeldRequest(name, token, userID string) (*stepUpPending, bool) {
+	var p stepUpPending
+	if token == "" || !m.openSealedValue(name, token, &p) {
+		return nil, false
+	}
+	if p.UserID != userID || time.Now().Unix() > p.Exp || !strings.HasPrefix(p.URL, "/") {
+		return nil, false
+	}
+	return &p, true
+}
+
+// verifyStepUp checks a current MFA code (TOTP or recovery) or, failing
+// that, the password.
+func (m *AuthModule) verifyStepUp(ctx context.Context, userID, email, password, code string) bool {
+	if code = strings.TrimSpace(code); code != "" {
+		_, err := m.verifyEnrolledCode(ctx, userID, code)
+		if err == nil {
+			return true
+		}
+		if !errors.Is(err, ErrMFAInvalidCode) && !errors.Is(err, ErrMFANotEnrolled) && !errors.Is(err, ErrMFANotConfigured) {
+			log.Printf("[AUTH] step-up: MFA check failed for user %s: %v", userID, err)
+		}
+	}
+	if password == "" || email == "" {
+		return false
+	}
+	token, authID, err := m.deps.AuthAdapter.Login(ctx, email, password)
+	if err != nil {
+		return false
+	}
+	// Login mints a session; step-up only needed the check.
+	m.abandonSession(ctx, token)
+	return authID == nil || authID.GetId() == "" || authID.GetId() == userID
+}
+
+// replayStepUp rebuilds the held-back request on top of the prompt's
+// request, keeping its identity, cookies and htmx headers.
+func replayStepUp(r *http.Request, p *stepUpPending) *http.Request {
+	req := r.Clone(r.Context())
+	u, err := url.ParseRequestURI(p.URL)
+	if err != nil {
+		u = &url.URL{Path: "/"}
+	}
+	body := p.Form.Encode()
+	req.Method = p.Method
+	req.URL = u
+	req.RequestURI = p.URL
+	req.Body = io.NopCloser(strings.NewReader(body))
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Form, req.PostForm, req.MultipartForm = nil, nil, nil
+	return req
+}
+
+// stepUpTemplate renders stepUpPromptHTML.
+var stepUpTemplate = template.Must(template.New("step-up").Parse(stepUpPromptHTML))
+
+// renderStepUp writes the prompt for token. An unusable token shows the
+// expired message without a form.
+func (m *AuthModule) renderStepUp(w http.ResponseWriter, r *http.Request, token, errorCode string, status int) {
+	labels := m.stepUpLabels()
+	data := map[string]any{
+		"Labels": labels,
+		"Action": entydad.AuthStepUpURL,
+		"Token":  token,
+	}
+	id, _ := identity.FromContext(r.Context())
+	if id != nil {
+		if _, ok := m.openStepUpPending(token, id.UserID); ok {
+			data["Valid"] = true
+			if enabled, _, err := m.MFAStatus(r.Context(), id.UserID); err == nil && enabled {
+				data["MFA"] = true
+			}
+		}
+	}
+	switch errorCode {
+	case "invalid":
+		data["Error"] = labels.ErrorInvalid
+	case "throttled":
+		data["Error"] = labels.ErrorThrottled
+	}
+
+	var buf bytes.Buffer
+	if err := stepUpTemplate.Execute(&buf, data); err != nil {
+		log.Printf("[AUTH] step-up: failed to render prompt: %v", err)
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	_, _ = w.Write(buf.Bytes())
+}
+
+func (m *AuthModule) stepUpLabels() entydad.StepUpLabels {
+	if m.deps.Labels.StepUp.Title == "" {
+		return entydad.DefaultStepUpLabels()
+	}
+	return m.deps.Labels.StepUp
+}
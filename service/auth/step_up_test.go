@@ -0,0 +1,290 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	entydad "github.com/erniealice/entydad-golang"
+	"github.com/erniealice/espyna-golang/shared/identity"
+)
+
+const stepUpAssignURL = "/action/role/detail/role-1/permissions/assign"
+
+type stepUpHarness struct {
+	m       *AuthModule
+	adapter *passwordAdapter
+	h       http.Handler
+	served  []url.Values
+}
+
+func newStepUpHarness(t *testing.T) *stepUpHarness {
+	t.Helper()
+	s := &stepUpHarness{adapter: &passwordAdapter{users: map[string]string{"rosa@example.com": "s3cret-pass"}}}
+	s.m = NewAuthModule(&Deps{
+		AuthAdapter:    s.adapter,
+		SessionManager: &recordingSessionManager{},
+		Renderer:       nopRenderer{},
+		CSRFSecret:     []byte("test-secret"),
+		MFAStore:       NewMemoryMFAStore(),
+		StepUpActions: []string{
+			"POST /action/role/detail/{id}/permissions/assign",
+			"POST /action/user/{id}/impersonate",
+			"POST /action/user/reset-password/{id}",
+			"POST /action/user/reset-password/{id}",
+			"not a pattern {",
+		},
+	})
+	app := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+		s.served = append(s.served, r.PostForm)
+		w.Header().Set("HX-Trigger", "permissions-assigned")
+	})
+	mw := s.m.StepUpMiddleware(app)
+	// Stands in for the session middleware. The session is the user's
+	// unless X-Test-Session names another.
+	s.h = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID := r.Header.Get("X-Test-User")
+		session := r.Header.Get("X-Test-Session")
+		if session == "" {
+			session = "session-" + userID
+		}
+		ctx := identity.WithRequestIdentity(r.Context(), &identity.RequestIdentity{UserID: userID, Email: "rosa@example.com", SessionToken: session})
+		mw.ServeHTTP(w, r.WithContext(ctx))
+	})
+	return s
+}
+
+func (s *stepUpHarness) do(method, target, userID string, form url.Values, cookies []*http.Cookie) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("HX-Request", "true")
+	req.Header.Set("X-Test-User", userID)
+	for _, c := range cookies {
+		req.AddCookie(c)
+	}
+	rec := httptest.NewRecorder()
+	s.h.ServeHTTP(rec, req)
+	return rec
+}
+
+// promptPath returns the prompt URL a held-back request points htmx at.
+func promptPath(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var loc struct{ Path, Target string }
+	if err := json.Unmarshal([]byte(rec.Header().Get("HX-Location")), &loc); err != nil {
+		t.Fatalf("HX-Location %q: %v", rec.Header().Get("HX-Location"), err)
+	}
+	if rec.Code != http.StatusUnauthorized || loc.Target != "[data-dialog-container]" || !strings.HasPrefix(loc.Path, entydad.AuthStepUpURL+"?p=") {
+		t.Fatalf("prompt: %d HX-Location=%+v", rec.Code, loc)
+	}
+	return loc.Path
+}
+
+func pendingToken(t *testing.T, path string) string {
+	t.Helper()
+	u, err := url.Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u.Query().Get("p")
+}
+
+func TestStepUp_PasswordReplaysHeldBackAction(t *testing.T) {
+	t.Parallel()
+	s := newStepUpHarness(t)
+	grant := url.Values{"permission_ids": {"perm-1", "perm-2"}}
+
+	// Not a sensitive action: served as is.
+	s.do(http.MethodGet, "/action/role/detail/role-1/permissions/assign", "user-1", nil, nil)
+	if len(s.served) != 1 {
+		t.Fatalf("GET drawer: served = %d", len(s.served))
+	}
+
+	// No credential check yet: held back and prompted.
+	rec := s.do(http.MethodPost, stepUpAssignURL, "user-1", grant, nil)
+	path := promptPath(t, rec)
+	if len(s.served) != 1 || rec.Header().Get("HX-Error-Message") == "" {
+		t.Fatalf("held back: served = %d, HX-Error-Message = %q", len(s.served), rec.Header().Get("HX-Error-Message"))
+	}
+	token := pendingToken(t, path)
+
+	rec = s.do(http.MethodGet, path, "user-1", nil, nil)
+	body := rec.Body.String()
+	if rec.Code != http.StatusOK || !strings.Contains(body, `data-testid="step-up-form"`) || strings.Contains(body, `name="code"`) {
+		t.Fatalf("prompt: %d %s", rec.Code, body)
+	}
+
+	// Wrong password: prompted again with the error, still not served.
+	rec = s.do(http.MethodPost, entydad.AuthStepUpURL, "user-1", url.Values{"p": {token}, "password": {"nope"}}, nil)
+	if got := promptPath(t, rec); !strings.HasSuffix(got, "&error=invalid") || len(s.served) != 1 {
+		t.Fatalf("wrong password: path = %q, served = %d", got, len(s.served))
+	}
+	rec = s.do(http.MethodGet, entydad.AuthStepUpURL+"?p="+url.QueryEscape(token)+"&error=invalid", "user-1", nil, nil)
+	if !strings.Contains(rec.Body.String(), `data-testid="step-up-error"`) {
+		t.Fatalf("error prompt: %s", rec.Body.String())
+	}
+
+	// The token is bound to the user it was issued for.
+	rec = s.do(http.MethodPost, entydad.AuthStepUpURL, "user-2", url.Values{"p": {token}, "password": {"s3cret-pass"}}, nil)
+	if got := promptPath(t, rec); strings.Contains(got, "error=") || len(s.served) != 1 {
+		t.Fatalf("other user: path = %q, served = %d", got, len(s.served))
+	}
+	rec = s.do(http.MethodGet, entydad.AuthStepUpURL+"?p="+url.QueryEscape(token), "user-2", nil, nil)
+	if !strings.Contains(rec.Body.String(), `data-testid="step-up-expired"`) {
+		t.Fatalf("other user's prompt: %s", rec.Body.String())
+	}
+
+	// Right password: the original request is replayed with its form.
+	rec = s.do(http.MethodPost, entydad.AuthStepUpURL, "user-1", url.Values{"p": {token}, "password": {"s3cret-pass"}}, nil)
+	if rec.Code != http.StatusOK || len(s.served) != 2 || rec.Header().Get("HX-Trigger") != "permissions-assigned" {
+		t.Fatalf("confirmed: %d served = %d HX-Trigger = %q", rec.Code, len(s.served), rec.Header().Get("HX-Trigger"))
+	}
+	if got := s.served[1]["permission_ids"]; len(got) != 2 || got[0] != "perm-1" || got[1] != "perm-2" {
+		t.Fatalf("replayed form = %v", s.served[1])
+	}
+	if len(s.adapter.invalidated) != 1 || s.adapter.invalidated[0] != "session-rosa@example.com" {
+		t.Fatalf("password check session not abandoned: %v", s.adapter.invalidated)
+	}
+	var check *http.Cookie
+	for _, c := range rec.Result().Cookies() {
+		if c.Name == stepUpCookieName {
+			check = c
+		}
+	}
+	if check == nil || check.Path != "/" || !check.HttpOnly {
+		t.Fatalf("credential check cookie = %+v", check)
+	}
+
+	// Fresh: served directly, for this user only.
+	if rec = s.do(http.MethodPost, stepUpAssignURL, "user-1", grant, []*http.Cookie{check}); rec.Code != http.StatusOK || len(s.served) != 3 {
+		t.Fatalf("fresh: %d served = %d", rec.Code, len(s.served))
+	}
+	promptPath(t, s.do(http.MethodPost, "/action/user/reset-password/user-9", "user-2", nil, []*http.Cookie{check}))
+}
+
+// A credential check belongs to the session it was made in: copied into
+// another of the user's sessions it does not count, and a session rotated
+// by a principal switch keeps it.
+func TestStepUp_CheckBoundToSession(t *testing.T) {
+	t.Parallel()
+	s := newStepUpHarness(t)
+	grant := url.Values{"permission_ids": {"perm-1"}}
+	rec := httptest.NewRecorder()
+	s.m.recordCredentialCheck(rec, "session-user-1", "user-1")
+	check := cookieNamed(rec, stepUpCookieName)
+	if check == nil {
+		t.Fatal("no credential check cookie")
+	}
+
+	if rec := s.do(http.MethodPost, stepUpAssignURL, "user-1", grant, check); rec.Code != http.StatusOK || len(s.served) != 1 {
+		t.Fatalf("same session: %d served = %d", rec.Code, len(s.served))
+	}
+	other := func(cookies []*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, stepUpAssignURL, strings.NewReader(grant.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("HX-Request", "true")
+		req.Header.Set("X-Test-User", "user-1")
+		req.Header.Set("X-Test-Session", "session-rotated")
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		s.h.ServeHTTP(rec, req)
+		return rec
+	}
+	promptPath(t, other(check))
+	if len(s.served) != 1 {
+		t.Fatalf("other session: served = %d", len(s.served))
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/action/auth/switch-principal", nil)
+	req.AddCookie(check[0])
+	rec = httptest.NewRecorder()
+	s.m.carryCredentialCheck(rec, req, "session-user-1", "session-rotated")
+	carried := cookieNamed(rec, stepUpCookieName)
+	if rec := other(carried); carried == nil || rec.Code != http.StatusOK || len(s.served) != 2 {
+		t.Fatalf("rotated session: %d served = %d", rec.Code, len(s.served))
+	}
+}
+
+func TestStepUp_MFACodeAndStaleCheck(t *testing.T) {
+	t.Parallel()
+	s := newStepUpHarness(t)
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.m.deps.MFAStore.SaveEnrollment(context.Background(), "user-1", MFAEnrollment{Secret: rfc6238Secret, RecoveryCodeHashes: hashes}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A credential check older than StepUpMaxAge does not count.
+	stale, err := s.m.sealValue(stepUpCookieName, stepUpClaims{UserID: "user-1", Session: sessionTokenHash("session-user-1"), At: time.Now().Add(-time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := promptPath(t, s.do(http.MethodPost, "/action/user/user-2/impersonate", "user-1", url.Values{"reason": {"ticket 42"}}, []*http.Cookie{{Name: stepUpCookieName, Value: stale}}))
+	if len(s.served) != 0 {
+		t.Fatalf("stale: served = %d", len(s.served))
+	}
+	if body := s.do(http.MethodGet, path, "user-1", nil, nil).Body.String(); !strings.Contains(body, `name="code"`) {
+		t.Fatalf("enrolled user's prompt lacks the code field: %s", body)
+	}
+
+	rec := s.do(http.MethodPost, entydad.AuthStepUpURL, "user-1", url.Values{"p": {pendingToken(t, path)}, "code": {codes[0]}}, nil)
+	if rec.Code != http.StatusOK || len(s.served) != 1 || s.served[0].Get("reason") != "ticket 42" {
+		t.Fatalf("recovery code: %d served = %v", rec.Code, s.served)
+	}
+}
+
+// The /auth/ passkey registration endpoints carry no request identity: the
+// session cookie names the user, and the fetch() ceremony gets a JSON
+// error instead of the prompt.
+func TestStepUp_PasskeyRegistrationBySessionCookie(t *testing.T) {
+	t.Parallel()
+	m := NewAuthModule(&Deps{
+		AuthAdapter: &sessionAdapter{
+			passwordAdapter: &passwordAdapter{users: map[string]string{}},
+			sessions:        map[string]string{"session-ana": "user-ana"},
+		},
+		SessionManager: &recordingSessionManager{},
+		Renderer:       nopRenderer{},
+		CSRFSecret:     []byte("test-secret"),
+		StepUpActions:  []string{"POST " + entydad.AuthPasskeyRegisterOptionsURL},
+	})
+	served := 0
+	h := m.StepUpMiddleware(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { served++ }))
+	do := func(cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, entydad.AuthPasskeyRegisterOptionsURL, nil)
+		req.Header.Set("Accept", "application/json")
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+	session := &http.Cookie{Name: m.deps.SessionCookieName, Value: "session-ana"}
+
+	rec := do(session)
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), `"step_up"`) || served != 0 {
+		t.Fatalf("stale: %d %s served = %d", rec.Code, rec.Body, served)
+	}
+
+	check := httptest.NewRecorder()
+	m.recordCredentialCheck(check, "session-ana", "user-ana")
+	if rec := do(append(cookieNamed(check, stepUpCookieName), session)...); rec.Code != http.StatusOK || served != 1 {
+		t.Fatalf("fresh: %d served = %d", rec.Code, served)
+	}
+
+	// Signed out: the handler's own check answers.
+	if do(); served != 2 {
+		t.Fatalf("signed out: served = %d", served)
+	}
+}
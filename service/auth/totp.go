@@ -0,0 +1,120 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// RFC 6238 parameters. Fixed to the values every mainstream authenticator
+// app assumes (SHA-1, 6 digits, 30 s); the otpauth URI states them anyway.
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	// totpSkew accepts one step either side of "now" for clock drift.
+	totpSkew = 1
+
+	recoveryCodeCount = 10
+)
+
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateTOTPSecret returns a fresh 160-bit shared secret, base32-encoded
+// (no padding) as authenticator apps expect.
+func GenerateTOTPSecret() (string, error) {
+	b := make([]byte, 20)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return totpEncoding.EncodeToString(b), nil
+}
+
+// TOTPProvisioningURI builds the otpauth:// URI an authenticator app
+// imports (QR code or tap-to-open link).
+func TOTPProvisioningURI(issuer, account, secret string) string {
+	label := url.PathEscape(account)
+	if issuer != "" {
+		label = url.PathEscape(issuer) + ":" + label
+	}
+	q := url.Values{}
+	q.Set("secret", secret)
+	if issuer != "" {
+		q.Set("issuer", issuer)
+	}
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+	return "otpauth://totp/" + label + "?" + q.Encode()
+}
+
+// totpCode computes the code for one time step (RFC 4226 §5.3 dynamic
+// truncation over HMAC-SHA1).
+func totpCode(key []byte, step int64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	off := sum[len(sum)-1] & 0x0f
+	bin := binary.BigEndian.Uint32(sum[off:off+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, bin%1_000_000)
+}
+
+// verifyTOTP checks code against secret at now (±totpSkew steps). It
+// returns the matched step so the caller can refuse a replay of the same
+// or an earlier step (lastStep); ok is false for any mismatch, malformed
+// input or replay.
+func verifyTOTP(secret, code string, now time.Time, lastStep int64) (step int64, ok bool) {
+	code = strings.ReplaceAll(strings.TrimSpace(code), " ", "")
+	if len(code) != totpDigits {
+		return 0, false
+	}
+	key, err := totpEncoding.DecodeString(strings.ToUpper(strings.TrimSpace(secret)))
+	if err != nil || len(key) == 0 {
+		return 0, false
+	}
+	current := now.Unix() / totpPeriod
+	for s := current - totpSkew; s <= current+totpSkew; s++ {
+		if s <= lastStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, s)), []byte(code)) == 1 {
+			return s, true
+		}
+	}
+	return 0, false
+}
+
+// newRecoveryCodes returns recoveryCodeCount single-use codes (shown once)
+// and their hashes (stored). Codes are 40 random bits rendered as
+// "xxxxx-xxxxx" hex, so an unsalted SHA-256 is sufficient.
+func newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < recoveryCodeCount; i++ {
+		b := make([]byte, 5)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := hex.EncodeToString(b)
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// hashRecoveryCode normalises (case, dashes, spaces) before hashing so the
+// user can type the code however it was copied.
+func hashRecoveryCode(code string) string {
+	norm := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+	sum := sha256.Sum256([]byte(norm))
+	return hex.EncodeToString(sum[:])
+}
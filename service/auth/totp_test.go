@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// rfc6238Secret is the RFC 6238 Appendix B SHA-1 key "12345678901234567890".
+const rfc6238Secret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+func TestVerifyTOTP_RFC6238Vectors(t *testing.T) {
+	t.Parallel()
+	// Appendix B codes truncated to the 6 digits authenticator apps show.
+	tests := []struct {
+		unix int64
+		code string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+	}
+	for _, tt := range tests {
+		now := time.Unix(tt.unix, 0)
+		step, ok := verifyTOTP(rfc6238Secret, tt.code, now, 0)
+		if !ok {
+			t.Errorf("T=%d: code %s rejected", tt.unix, tt.code)
+			continue
+		}
+		if want := tt.unix / totpPeriod; step != want {
+			t.Errorf("T=%d: step = %d, want %d", tt.unix, step, want)
+		}
+	}
+}
+
+func TestVerifyTOTP_SkewAndReplay(t *testing.T) {
+	t.Parallel()
+	now := time.Unix(1111111109, 0)
+
+	// One step late is tolerated, two is not.
+	if _, ok := verifyTOTP(rfc6238Secret, "081804", now.Add(totpPeriod*time.Second), 0); !ok {
+		t.Error("code one step old: want accepted")
+	}
+	if _, ok := verifyTOTP(rfc6238Secret, "081804", now.Add(2*totpPeriod*time.Second), 0); ok {
+		t.Error("code two steps old: want rejected")
+	}
+
+	step, ok := verifyTOTP(rfc6238Secret, "081 804", now, 0)
+	if !ok {
+		t.Fatal("spaced code: want accepted")
+	}
+	if _, ok := verifyTOTP(rfc6238Secret, "081804", now, step); ok {
+		t.Error("replayed step: want rejected")
+	}
+	for _, bad := range []string{"", "12345", "1234567", "abcdef"} {
+		if _, ok := verifyTOTP(rfc6238Secret, bad, now, 0); ok {
+			t.Errorf("malformed code %q: want rejected", bad)
+		}
+	}
+}
+
+func TestConsumeMFACode_RecoveryCodesAreSingleUse(t *testing.T) {
+	t.Parallel()
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(codes) != recoveryCodeCount || len(hashes) != recoveryCodeCount {
+		t.Fatalf("got %d codes / %d hashes, want %d", len(codes), len(hashes), recoveryCodeCount)
+	}
+	e := &MFAEnrollment{Secret: rfc6238Secret, RecoveryCodeHashes: hashes}
+	now := time.Unix(59, 0)
+
+	typed := strings.ToUpper(strings.ReplaceAll(codes[3], "-", " "))
+	if !consumeMFACode(e, typed, now) {
+		t.Fatal("recovery code typed with spaces/upper-case: want accepted")
+	}
+	if len(e.RecoveryCodeHashes) != recoveryCodeCount-1 {
+		t.Errorf("remaining hashes = %d, want %d", len(e.RecoveryCodeHashes), recoveryCodeCount-1)
+	}
+	if consumeMFACode(e, codes[3], now) {
+		t.Error("reused recovery code: want rejected")
+	}
+	if !consumeMFACode(e, "287082", now) || e.LastUsedStep != 1 {
+		t.Errorf("TOTP code: want accepted with LastUsedStep 1, got %d", e.LastUsedStep)
+	}
+}
+
+func TestMFAPendingCookie_RoundTripAndTamper(t *testing.T) {
+	t.Parallel()
+	m := NewAuthModule(&Deps{CSRFSecret: []byte("test-secret")})
+	want := mfaPending{Token: "tok", UserID: "u1", Email: "a@b.c", Setup: true, Secret: rfc6238Secret}
+
+	rec := httptest.NewRecorder()
+	if err := m.setMFAPending(rec, want); err != nil {
+		t.Fatal(err)
+	}
+	cookie := rec.Result().Cookies()[0]
+	if !cookie.HttpOnly || cookie.Path != "/auth/" {
+		t.Errorf("cookie flags: HttpOnly=%t Path=%q", cookie.HttpOnly, cookie.Path)
+	}
+	if strings.Contains(cookie.Value, "tok") {
+		t.Error("cookie value must not expose the session token")
+	}
+
+	r := httptest.NewRequest("GET", "/auth/mfa", nil)
+	r.AddCookie(cookie)
+	got, ok := m.readMFAPending(r)
+	if !ok || got.Token != want.Token || got.UserID != want.UserID || got.Secret != want.Secret || !got.Setup {
+		t.Fatalf("round trip: got %+v ok=%t", got, ok)
+	}
+
+	tampered := httptest.NewRequest("GET", "/auth/mfa", nil)
+	cookie.Value = cookie.Value[:len(cookie.Value)-2] + "AA"
+	tampered.AddCookie(cookie)
+	if _, ok := m.readMFAPending(tampered); ok {
+		t.Error("tampered cookie: want rejected")
+	}
+
+	other := NewAuthModule(&Deps{CSRFSecret: []byte("other-secret")})
+	r2 := httptest.NewRequest("GET", "/auth/mfa", nil)
+	r2.AddCookie(rec.Result().Cookies()[0])
+	if _, ok := other.readMFAPending(r2); ok {
+		t.Error("cookie sealed under another key: want rejected")
+	}
+}
+
+// An existing enrolment is never replaced by a new setup: without a current
+// code a stolen session cannot swap in its own authenticator.
+func TestMFAConfirmSetup_RefusesExistingEnrollment(t *testing.T) {
+	t.Parallel()
+	m := NewAuthModule(&Deps{MFAStore: NewMemoryMFAStore(), CSRFSecret: []byte("test-secret")})
+	ctx := context.Background()
+	key, _ := totpEncoding.DecodeString(rfc6238Secret)
+	code := totpCode(key, time.Now().Unix()/totpPeriod)
+	if _, err := m.MFAConfirmSetup(ctx, "user-1", rfc6238Secret, code); err != nil {
+		t.Fatalf("first setup: %v", err)
+	}
+
+	other, err := GenerateTOTPSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, _ := totpEncoding.DecodeString(other)
+	if _, err := m.MFAConfirmSetup(ctx, "user-1", other, totpCode(otherKey, time.Now().Unix()/totpPeriod)); !errors.Is(err, ErrMFAAlreadyEnrolled) {
+		t.Fatalf("second setup: err = %v, want ErrMFAAlreadyEnrolled", err)
+	}
+	if e, _ := m.deps.MFAStore.GetEnrollment(ctx, "user-1"); e == nil || e.Secret != rfc6238Secret {
+		t.Fatalf("enrolment replaced: %+v", e)
+	}
+}
@@ -178,6 +178,13 @@ type PrincipalSwitchInput struct {
 	SecFetchSite       string
 	UserAgent          string
 	RequireAudit       bool
+
+	// ImpersonatorUserID and Reason are set only for the impersonate_*
+	// use cases (start, stop, timeout): the administrator behind the switch
+	// and the reason they gave, for the composition layer to record on the
+	// audit row.
+	ImpersonatorUserID string
+	Reason             string
 }
 
 // PrincipalSwitchResult mirrors the composition-internal principalSwitchResult.
@@ -95,6 +95,26 @@ func resolveErrorLabel(code string, l entydad.ChangePasswordLabels) string {
 		if l.ErrorTooShort != "" {
 			return l.ErrorTooShort
 		}
+	case "password_classes":
+		if l.ErrorPasswordClasses != "" {
+			return l.ErrorPasswordClasses
+		}
+	case "password_breached":
+		if l.ErrorPasswordBreached != "" {
+			return l.ErrorPasswordBreached
+		}
+	case "password_reused":
+		if l.ErrorPasswordReused != "" {
+			return l.ErrorPasswordReused
+		}
+	case "password_contains_email":
+		if l.ErrorPasswordContainsEmail != "" {
+			return l.ErrorPasswordContainsEmail
+		}
+	case "impersonating":
+		if l.ErrorImpersonating != "" {
+			return l.ErrorImpersonating
+		}
 	}
 	return l.Error
 }
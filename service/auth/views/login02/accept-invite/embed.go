@@ -0,0 +1,22 @@
+// Package acceptinvite renders the workspace invitation page
+// (/auth/accept-invite) on the login02 auth shell. The emailed link opens it;
+// an invitee who already has an account signs in with their password, anyone
+// else creates an account — either way the POST joins them to the workspace
+// with the invited roles and continues like every other sign-in. An address
+// whose domain requires single sign-on gets a button into its identity
+// provider instead, which accepts the invitation on the way back.
+//
+// Route convention:
+//
+//	GET  /auth/accept-invite?token=…  — the page (invalid state without a
+//	                                    live invitation)
+//	POST /auth/accept-invite          — sign in or sign up, then join
+package acceptinvite
+
+import "embed"
+
+// TemplatesFS embeds the accept-invite templates. Register alongside
+// login02.TemplatesFS.
+//
+//go:embed templates/*.html
+var TemplatesFS embed.FS
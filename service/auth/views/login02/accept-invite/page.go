@@ -0,0 +1,159 @@
+package acceptinvite
+
+import (
+	"context"
+	"strings"
+
+	entydad "github.com/erniealice/entydad-golang"
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// State is the per-request data the auth handler installs via WithState.
+// An empty Token selects the invalid state.
+type State struct {
+	Token      string // the emailed token, re-posted by the form
+	Workspace  string // workspace name
+	Inviter    string // inviter's display name
+	Email      string // invited address
+	HasAccount bool   // sign in instead of sign up
+	SSOURL     string // the address signs in through SSO: start here instead of the password form
+	ErrorCode  string // short code mapped to a label (see AcceptInviteLabels)
+}
+
+type ctxKey int
+
+const ctxKeyState ctxKey = 0
+
+// WithState returns a derived context carrying the page state.
+func WithState(ctx context.Context, s State) context.Context {
+	return context.WithValue(ctx, ctxKeyState, s)
+}
+
+func getState(ctx context.Context) State {
+	s, _ := ctx.Value(ctxKeyState).(State)
+	return s
+}
+
+// Deps holds view dependencies for the accept-invite page.
+type Deps struct {
+	Labels       entydad.AcceptInviteLabels
+	CommonLabels pyeza.CommonLabels
+	LogoText     string
+	LogoIcon     string
+	PostURL      string // default: /auth/accept-invite
+	LoginURL     string // default: /auth/login
+}
+
+// PageData is the template-facing data shape.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Labels          entydad.AcceptInviteLabels
+	LogoText        string
+	LogoIcon        string
+	PostURL         string
+	LoginURL        string
+	State
+	Heading    string
+	Subheading string
+	SSOMessage string
+	Error      string
+}
+
+// NewView creates the accept-invite page view. The handler installs State
+// via WithState.
+func NewView(deps *Deps) view.View {
+	postURL := deps.PostURL
+	if postURL == "" {
+		postURL = entydad.AuthAcceptInvitePostURL
+	}
+	loginURL := deps.LoginURL
+	if loginURL == "" {
+		loginURL = entydad.AuthLoginURL
+	}
+	labels := deps.Labels
+	if labels.Title == "" {
+		labels = entydad.DefaultAcceptInviteLabels()
+	}
+
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		state := getState(ctx)
+		fill := strings.NewReplacer(
+			"{workspace}", state.Workspace,
+			"{inviter}", state.Inviter,
+			"{email}", state.Email,
+		).Replace
+		errorMsg := ""
+		if state.ErrorCode != "" {
+			errorMsg = fill(resolveErrorLabel(state.ErrorCode, labels))
+		}
+
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion: viewCtx.CacheVersion,
+				Title:        labels.Title,
+				CurrentPath:  viewCtx.CurrentPath,
+				CommonLabels: deps.CommonLabels,
+			},
+			ContentTemplate: "accept-invite-content",
+			Labels:          labels,
+			LogoText:        deps.LogoText,
+			LogoIcon:        deps.LogoIcon,
+			PostURL:         postURL,
+			LoginURL:        loginURL,
+			State:           state,
+			Heading:         fill(labels.Heading),
+			Subheading:      fill(labels.Subheading),
+			SSOMessage:      fill(labels.SSOMessage),
+			Error:           errorMsg,
+		}
+
+		return view.OK("accept-invite", pageData)
+	})
+}
+
+// resolveErrorLabel maps a short error code to the matching label; anything
+// unrecognized returns the generic Error label.
+func resolveErrorLabel(code string, l entydad.AcceptInviteLabels) string {
+	switch code {
+	case "invalid":
+		if l.ErrorInvalid != "" {
+			return l.ErrorInvalid
+		}
+	case "locked":
+		if l.ErrorLocked != "" {
+			return l.ErrorLocked
+		}
+	case "throttled":
+		if l.ErrorThrottled != "" {
+			return l.ErrorThrottled
+		}
+	case "mismatch":
+		if l.ErrorMismatch != "" {
+			return l.ErrorMismatch
+		}
+	case "weak_password", "too_short":
+		if l.ErrorWeakPassword != "" {
+			return l.ErrorWeakPassword
+		}
+	case "password_classes":
+		if l.ErrorPasswordClasses != "" {
+			return l.ErrorPasswordClasses
+		}
+	case "password_breached":
+		if l.ErrorPasswordBreached != "" {
+			return l.ErrorPasswordBreached
+		}
+	case "password_contains_email":
+		if l.ErrorPasswordContainsEmail != "" {
+			return l.ErrorPasswordContainsEmail
+		}
+	case "sso_email":
+		if l.ErrorSSOEmail != "" {
+			return l.ErrorSSOEmail
+		}
+	}
+	return l.Error
+}
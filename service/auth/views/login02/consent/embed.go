@@ -0,0 +1,25 @@
+// Package consent renders the terms-of-service / privacy consent step
+// (/auth/consent) that sits between the credential check and principal
+// routing, after two-step verification. It reuses the login02 auth shell,
+// like the MFA challenge.
+//
+// Route convention:
+//
+//	GET  /auth/consent  — the consent documents the user has not accepted
+//	                      at their current version
+//	POST /auth/consent  — accept (every document checked) or decline; on
+//	                      accept the held session is issued and routed
+//	                      through routePrincipals
+//
+// Security contract: as on /auth/mfa, the browser holds NO session cookie
+// until the documents are accepted — only a short-lived sealed
+// consent_pending cookie on /auth/.
+package consent
+
+import "embed"
+
+// TemplatesFS embeds the consent templates. Register alongside
+// login02.TemplatesFS.
+//
+//go:embed templates/*.html
+var TemplatesFS embed.FS
@@ -0,0 +1,142 @@
+package consent
+
+import (
+	"context"
+	"html/template"
+	"log"
+
+	entydad "github.com/erniealice/entydad-golang"
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// Document is one consent document awaiting acceptance. Body is markdown
+// (the host's lyngua-loaded copy); the page renders it to HTML.
+type Document struct {
+	Kind    string
+	Version string
+	Title   string
+	Body    string
+}
+
+// State is the per-request data the auth handler resolves from the pending
+// login and installs via WithState.
+type State struct {
+	Documents []Document
+	ErrorCode string // short code mapped to a label (see ConsentLabels)
+}
+
+type ctxKey int
+
+const ctxKeyState ctxKey = 0
+
+// WithState returns a derived context carrying the page state.
+func WithState(ctx context.Context, s State) context.Context {
+	return context.WithValue(ctx, ctxKeyState, s)
+}
+
+func getState(ctx context.Context) State {
+	if s, ok := ctx.Value(ctxKeyState).(State); ok {
+		return s
+	}
+	return State{}
+}
+
+// Deps holds view dependencies for the consent page.
+type Deps struct {
+	Labels       entydad.ConsentLabels
+	CommonLabels pyeza.CommonLabels
+	LogoText     string
+	LogoIcon     string
+	PostURL      string // default: /auth/consent
+}
+
+// DocumentView is a Document ready for the template. AcceptValue is the
+// checkbox value ("kind:version"), so accepting a document that changed
+// since the page was rendered does not count.
+type DocumentView struct {
+	Kind        string
+	Version     string
+	Title       string
+	Body        template.HTML
+	AcceptValue string
+}
+
+// PageData is the template-facing data shape.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Labels          entydad.ConsentLabels
+	LogoText        string
+	LogoIcon        string
+	PostURL         string
+	Documents       []DocumentView
+	Error           string
+}
+
+// NewView creates the consent page view. The handler installs State via
+// WithState.
+func NewView(deps *Deps) view.View {
+	postURL := deps.PostURL
+	if postURL == "" {
+		postURL = entydad.AuthConsentPostURL
+	}
+	labels := deps.Labels
+	if labels.Title == "" {
+		labels = entydad.DefaultConsentLabels()
+	}
+
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		state := getState(ctx)
+		errorMsg := ""
+		if state.ErrorCode != "" {
+			errorMsg = resolveErrorLabel(state.ErrorCode, labels)
+		}
+		docs := make([]DocumentView, 0, len(state.Documents))
+		for _, d := range state.Documents {
+			body, err := pyeza.RenderMarkdown([]byte(d.Body))
+			if err != nil {
+				log.Printf("consent: rendering %s %s: %v", d.Kind, d.Version, err)
+				body = template.HTML(template.HTMLEscapeString(d.Body))
+			}
+			docs = append(docs, DocumentView{
+				Kind:        d.Kind,
+				Version:     d.Version,
+				Title:       d.Title,
+				Body:        body,
+				AcceptValue: d.Kind + ":" + d.Version,
+			})
+		}
+
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion: viewCtx.CacheVersion,
+				Title:        labels.Title,
+				CurrentPath:  viewCtx.CurrentPath,
+				CommonLabels: deps.CommonLabels,
+			},
+			ContentTemplate: "consent-content",
+			Labels:          labels,
+			LogoText:        deps.LogoText,
+			LogoIcon:        deps.LogoIcon,
+			PostURL:         postURL,
+			Documents:       docs,
+			Error:           errorMsg,
+		}
+
+		return view.OK("consent", pageData)
+	})
+}
+
+// resolveErrorLabel maps a short error code to the matching label; anything
+// unrecognized returns the generic Error label.
+func resolveErrorLabel(code string, l entydad.ConsentLabels) string {
+	switch code {
+	case "required":
+		if l.ErrorRequired != "" {
+			return l.ErrorRequired
+		}
+	}
+	return l.Error
+}
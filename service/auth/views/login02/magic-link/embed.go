@@ -0,0 +1,22 @@
+// Package magiclink renders the passwordless sign-in pages (/auth/magic-link)
+// on the login02 auth shell: the request form, the "link sent" notice and the
+// confirm step the emailed link opens.
+//
+// Route convention:
+//
+//	GET  /auth/magic-link                 — request form (?sent=1 notice)
+//	POST /auth/magic-link                 — mail a sign-in link
+//	GET  /auth/magic-link/verify?token=…  — confirm page (does not consume)
+//	POST /auth/magic-link/verify          — consume the link, sign in
+//
+// The link is consumed only by the confirm POST, so a mail scanner that
+// prefetches it does not burn the single use.
+package magiclink
+
+import "embed"
+
+// TemplatesFS embeds the magic-link templates. Register alongside
+// login02.TemplatesFS.
+//
+//go:embed templates/*.html
+var TemplatesFS embed.FS
@@ -0,0 +1,119 @@
+package magiclink
+
+import (
+	"context"
+
+	entydad "github.com/erniealice/entydad-golang"
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// State is the per-request data the auth handler installs via WithState.
+// Token non-empty selects the confirm step.
+type State struct {
+	Sent      bool   // a link was just requested
+	Token     string // confirm step: the emailed token, re-posted by the form
+	Email     string // confirm step: the address the link was issued for
+	ErrorCode string // short code mapped to a label (see MagicLinkLabels)
+}
+
+type ctxKey int
+
+const ctxKeyState ctxKey = 0
+
+// WithState returns a derived context carrying the page state.
+func WithState(ctx context.Context, s State) context.Context {
+	return context.WithValue(ctx, ctxKeyState, s)
+}
+
+func getState(ctx context.Context) State {
+	s, _ := ctx.Value(ctxKeyState).(State)
+	return s
+}
+
+// Deps holds view dependencies for the magic-link pages.
+type Deps struct {
+	Labels       entydad.MagicLinkLabels
+	CommonLabels pyeza.CommonLabels
+	LogoText     string
+	LogoIcon     string
+	PostURL      string // default: /auth/magic-link
+	VerifyURL    string // default: /auth/magic-link/verify
+	LoginURL     string // default: /auth/login
+}
+
+// PageData is the template-facing data shape.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Labels          entydad.MagicLinkLabels
+	LogoText        string
+	LogoIcon        string
+	PostURL         string
+	VerifyURL       string
+	LoginURL        string
+	State
+	Error string
+}
+
+// NewView creates the magic-link page view. The handler installs State via
+// WithState.
+func NewView(deps *Deps) view.View {
+	postURL := deps.PostURL
+	if postURL == "" {
+		postURL = entydad.AuthMagicLinkPostURL
+	}
+	verifyURL := deps.VerifyURL
+	if verifyURL == "" {
+		verifyURL = entydad.AuthMagicLinkVerifyURL
+	}
+	loginURL := deps.LoginURL
+	if loginURL == "" {
+		loginURL = entydad.AuthLoginURL
+	}
+	labels := deps.Labels
+	if labels.Title == "" {
+		labels = entydad.DefaultMagicLinkLabels()
+	}
+
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		state := getState(ctx)
+		errorMsg := ""
+		if state.ErrorCode != "" {
+			errorMsg = resolveErrorLabel(state.ErrorCode, labels)
+		}
+
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion: viewCtx.CacheVersion,
+				Title:        labels.Title,
+				CurrentPath:  viewCtx.CurrentPath,
+				CommonLabels: deps.CommonLabels,
+			},
+			ContentTemplate: "magic-link-content",
+			Labels:          labels,
+			LogoText:        deps.LogoText,
+			LogoIcon:        deps.LogoIcon,
+			PostURL:         postURL,
+			VerifyURL:       verifyURL,
+			LoginURL:        loginURL,
+			State:           state,
+			Error:           errorMsg,
+		}
+
+		return view.OK("magic-link", pageData)
+	})
+}
+
+// resolveErrorLabel maps a short error code to the matching label; anything
+// unrecognized returns the generic Error label.
+func resolveErrorLabel(code string, l entydad.MagicLinkLabels) string {
+	switch code {
+	case "throttled":
+		if l.ErrorThrottled != "" {
+			return l.ErrorThrottled
+		}
+	}
+	return l.Error
+}
@@ -0,0 +1,24 @@
+// Package mfa renders the second-factor step (/auth/mfa) that sits between
+// the credential check and principal routing. It reuses the login02 auth
+// shell, like the select-workspace-role chooser.
+//
+// Route convention:
+//
+//	GET  /auth/mfa  — TOTP challenge, recovery-code challenge, or first-time
+//	                  setup (when a workspace requires MFA and the user has
+//	                  not enrolled)
+//	POST /auth/mfa  — verify; on success the held session is issued and
+//	                  routed through routePrincipals
+//
+// Security contract: until this step passes the browser holds NO session
+// cookie — only a short-lived signed mfa_pending cookie on /auth/. Nothing
+// principal-scoped is reachable.
+package mfa
+
+import "embed"
+
+// TemplatesFS embeds the MFA templates. Register alongside
+// login02.TemplatesFS.
+//
+//go:embed templates/*.html
+var TemplatesFS embed.FS
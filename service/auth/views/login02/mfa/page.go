@@ -0,0 +1,130 @@
+package mfa
+
+import (
+	"context"
+
+	entydad "github.com/erniealice/entydad-golang"
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// Mode selects which variant of the page renders.
+type Mode string
+
+const (
+	ModeChallenge     Mode = "challenge"      // enter a TOTP code
+	ModeRecovery      Mode = "recovery"       // enter a recovery code
+	ModeSetup         Mode = "setup"          // first-time enrolment (workspace requires MFA)
+	ModeRecoveryCodes Mode = "recovery_codes" // one-time listing after setup
+)
+
+// State is the per-request data the auth handler resolves (the pending
+// login, a freshly generated secret, …) and installs via WithState.
+type State struct {
+	Mode            Mode
+	Secret          string   // ModeSetup: base32 key for manual entry
+	ProvisioningURI string   // ModeSetup: otpauth:// link
+	RecoveryCodes   []string // ModeRecoveryCodes: shown exactly once
+	ContinueURL     string   // ModeRecoveryCodes: post-login destination
+	ErrorCode       string   // short code mapped to a label (see MFALabels)
+}
+
+type ctxKey int
+
+const ctxKeyState ctxKey = 0
+
+// WithState returns a derived context carrying the page state.
+func WithState(ctx context.Context, s State) context.Context {
+	return context.WithValue(ctx, ctxKeyState, s)
+}
+
+func getState(ctx context.Context) State {
+	if s, ok := ctx.Value(ctxKeyState).(State); ok {
+		return s
+	}
+	return State{Mode: ModeChallenge}
+}
+
+// Deps holds view dependencies for the MFA page.
+type Deps struct {
+	Labels       entydad.MFALabels
+	CommonLabels pyeza.CommonLabels
+	LogoText     string
+	LogoIcon     string
+	PostURL      string // default: /auth/mfa
+	PageURL      string // default: /auth/mfa (mode links)
+}
+
+// PageData is the template-facing data shape.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Labels          entydad.MFALabels
+	LogoText        string
+	LogoIcon        string
+	PostURL         string
+	PageURL         string
+	State
+	Error string
+}
+
+// NewView creates the MFA page view. The handler installs State via
+// WithState; without it the page renders the TOTP challenge.
+func NewView(deps *Deps) view.View {
+	postURL := deps.PostURL
+	if postURL == "" {
+		postURL = entydad.AuthMFAPostURL
+	}
+	pageURL := deps.PageURL
+	if pageURL == "" {
+		pageURL = entydad.AuthMFAURL
+	}
+	labels := deps.Labels
+	if labels.Title == "" {
+		labels = entydad.DefaultMFALabels()
+	}
+
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		state := getState(ctx)
+		errorMsg := ""
+		if state.ErrorCode != "" {
+			errorMsg = resolveErrorLabel(state.ErrorCode, labels)
+		}
+
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion: viewCtx.CacheVersion,
+				Title:        labels.Title,
+				CurrentPath:  viewCtx.CurrentPath,
+				CommonLabels: deps.CommonLabels,
+			},
+			ContentTemplate: "mfa-content",
+			Labels:          labels,
+			LogoText:        deps.LogoText,
+			LogoIcon:        deps.LogoIcon,
+			PostURL:         postURL,
+			PageURL:         pageURL,
+			State:           state,
+			Error:           errorMsg,
+		}
+
+		return view.OK("mfa", pageData)
+	})
+}
+
+// resolveErrorLabel maps a short error code to the matching label; anything
+// unrecognized returns the generic Error label.
+func resolveErrorLabel(code string, l entydad.MFALabels) string {
+	switch code {
+	case "invalid":
+		if l.ErrorInvalidCode != "" {
+			return l.ErrorInvalidCode
+		}
+	case "throttled":
+		if l.ErrorThrottled != "" {
+			return l.ErrorThrottled
+		}
+	}
+	return l.Error
+}
@@ -37,6 +37,23 @@ type FirebaseConfig struct {
 	FirebasePostURL string // where the client POSTs the verified ID token
 }
 
+// PasskeyConfig enables the "Sign in with a passkey" button. The page script
+// fetches assertion options from OptionsURL, runs navigator.credentials.get
+// and POSTs the assertion to PostURL, which answers with the same
+// {"redirect"} / {"error"} JSON as the Firebase endpoint. Nil = no button.
+type PasskeyConfig struct {
+	OptionsURL string
+	PostURL    string
+}
+
+// OIDCProvider is one generic OpenID Connect sign-in button: a plain link to
+// StartURL (/auth/oidc/start?provider=<ID>), which redirects to the IdP.
+type OIDCProvider struct {
+	ID       string
+	Name     string
+	StartURL string
+}
+
 // Deps holds view dependencies for the login02 page.
 type Deps struct {
 	Labels          entydad.Login02Labels
@@ -56,6 +73,19 @@ type Deps struct {
 	ShowPasswordForm bool
 	// AllowSignups renders the "no account? sign up" footer link when true.
 	AllowSignups bool
+	// PasskeyConfig non-nil ⇒ passkey sign-in button (see type doc).
+	PasskeyConfig *PasskeyConfig
+	// OIDCProviders renders one "Continue with <Name>" link per provider.
+	OIDCProviders []OIDCProvider
+	// MagicLinkURL non-empty renders the "Email me a sign-in link" button
+	// (passwordless sign-in page).
+	MagicLinkURL string
+	// DiscoverURL non-empty makes the page identifier-first: without
+	// ?email= it asks for the email alone and POSTs it here (home-realm
+	// discovery); with ?email= it shows the password step for that address.
+	// ?provider=<method> (firebase mode) narrows the page to that sign-in
+	// button.
+	DiscoverURL string
 }
 
 // PageData holds the data for the login02 page.
@@ -74,7 +104,14 @@ type PageData struct {
 	FirebaseConfig   *FirebaseConfig
 	ShowPasswordForm bool
 	AllowSignups     bool
-	Error            string // non-empty when login failed (e.g. ?error=invalid)
+	PasskeyConfig    *PasskeyConfig
+	OIDCProviders    []OIDCProvider
+	MagicLinkURL     string
+	DiscoverURL      string
+	IdentifierStep   bool   // identifier-first: the email-only step is showing
+	Email            string // identifier-first: the discovered address, prefilled
+	Error            string // non-empty when login failed (e.g. ?error=invalid, ?error=locked)
+	Notice           string // success banner (e.g. ?verified=1 after email verification)
 }
 
 // NewView creates the login02 page view (GET /login).
@@ -105,10 +142,34 @@ func NewView(deps *Deps) view.View {
 
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
 		errorMsg := ""
+		notice := ""
+		email := ""
+		provider := ""
 		if viewCtx.Request != nil {
-			if viewCtx.Request.URL.Query().Get("error") != "" {
-				errorMsg = deps.Labels.Error
+			q := viewCtx.Request.URL.Query()
+			if code := q.Get("error"); code != "" {
+				errorMsg = resolveErrorLabel(code, deps.Labels)
+			}
+			if q.Get("verified") == "1" {
+				notice = deps.Labels.EmailVerified
 			}
+			email = q.Get("email")
+			provider = q.Get("provider")
+		}
+		// Identifier-first: the email step until discovery hands back an
+		// address; a discovered Firebase provider replaces the password step
+		// with its own button.
+		identifierStep := deps.DiscoverURL != "" && email == ""
+		pagePasswordForm := showPasswordForm && !identifierStep
+		socialProviders := deps.SocialProviders
+		if deps.DiscoverURL != "" && email != "" && provider != "" && deps.FirebaseConfig != nil {
+			socialProviders = nil
+			for _, p := range deps.SocialProviders {
+				if p.Method == provider {
+					socialProviders = append(socialProviders, p)
+				}
+			}
+			pagePasswordForm = false
 		}
 
 		pageData := &PageData{
@@ -127,13 +188,69 @@ func NewView(deps *Deps) view.View {
 			RegisterURL:     registerURL,
 			ForgotURL:       forgotURL,
 			Slides:           deps.Slides,
-			SocialProviders:  deps.SocialProviders,
+			SocialProviders:  socialProviders,
 			FirebaseConfig:   deps.FirebaseConfig,
-			ShowPasswordForm: showPasswordForm,
+			ShowPasswordForm: pagePasswordForm,
 			AllowSignups:     deps.AllowSignups,
+			PasskeyConfig:    deps.PasskeyConfig,
+			OIDCProviders:    deps.OIDCProviders,
+			MagicLinkURL:     deps.MagicLinkURL,
+			DiscoverURL:      deps.DiscoverURL,
+			IdentifierStep:   identifierStep,
+			Email:            email,
 			Error:            errorMsg,
+			Notice:           notice,
 		}
 
 		return view.OK("login02", pageData)
 	})
 }
+
+// resolveErrorLabel maps the short ?error= code from the login handlers to
+// the matching label. Anything unrecognized (including "invalid") returns the
+// generic Error label.
+func resolveErrorLabel(code string, l entydad.Login02Labels) string {
+	switch code {
+	case "oidc":
+		if l.ErrorOIDC != "" {
+			return l.ErrorOIDC
+		}
+	case "no_account":
+		if l.ErrorNoAccount != "" {
+			return l.ErrorNoAccount
+		}
+	case "verify_link":
+		if l.ErrorVerifyLink != "" {
+			return l.ErrorVerifyLink
+		}
+	case "magic_link":
+		if l.ErrorMagicLink != "" {
+			return l.ErrorMagicLink
+		}
+	case "locked":
+		if l.ErrorLocked != "" {
+			return l.ErrorLocked
+		}
+	case "throttled":
+		if l.ErrorThrottled != "" {
+			return l.ErrorThrottled
+		}
+	case "mfa_expired":
+		if l.ErrorMFAExpired != "" {
+			return l.ErrorMFAExpired
+		}
+	case "sso_required":
+		if l.ErrorSSORequired != "" {
+			return l.ErrorSSORequired
+		}
+	case "consent_declined":
+		if l.ErrorConsentDeclined != "" {
+			return l.ErrorConsentDeclined
+		}
+	case "session_expired":
+		if l.ErrorSessionExpired != "" {
+			return l.ErrorSessionExpired
+		}
+	}
+	return l.Error
+}
@@ -98,6 +98,9 @@ type PrincipalCard struct {
 	// pick a specific card when several share the same kind (multi-target
 	// delegate, multi-client-grant user).
 	HasMultipleOfKind bool
+	// IsDefault marks the user's remembered default principal; its "make
+	// this my default" checkbox renders pre-ticked.
+	IsDefault bool
 }
 
 // CardsResolver returns the cards to render for the current request.
@@ -121,6 +124,9 @@ type Deps struct {
 	SwitchPostURL string        // default: /action/auth/switch-principal
 	LogoutURL     string        // default: /auth/logout
 	ResolveCards  CardsResolver // required for production; nil falls back to ctx cards (test-only)
+	// RememberDefault shows the "make this my default" checkbox on each
+	// card. The host sets it when a default principal store is wired.
+	RememberDefault bool
 }
 
 // Labels holds i18n strings for the select-workspace-role page.
@@ -139,6 +145,7 @@ type Labels struct {
 	SubmitLabel          string `json:"submitLabel"`
 	EmptyState           string `json:"emptyState"`
 	ErrorSwitchPrincipal string `json:"errorSwitchPrincipal"`
+	MakeDefault          string `json:"makeDefault"`
 
 	// KindLabels maps principal Kind tokens to localized role labels.
 	// Loaded from the "kindLabels" sub-object within the
@@ -162,6 +169,7 @@ func DefaultLabels() Labels {
 		SubmitLabel:         "Continue as",
 		EmptyState:          "You don't have any active workspace roles for this account.",
 		ErrorSwitchPrincipal: "Could not switch principal. Please try again.",
+		MakeDefault:          "Make this my default",
 		// Generic English role labels for all canonical principal kinds.
 		// Business-type overlays (e.g. education/auth.json) can override
 		// individual keys; the lyngua deep-merge preserves the rest.
@@ -198,6 +206,7 @@ type PageData struct {
 	LogoutURL       string
 	Cards           []PrincipalCard
 	Error           string // surfaces ?error= query param messages
+	RememberDefault bool
 }
 
 // ctxKeyCards is a typed context key used by host code to inject the
@@ -257,6 +266,9 @@ func NewView(deps *Deps) view.View {
 	if labels.Subheading == "" {
 		labels.Subheading = labels.Page.Subheading
 	}
+	if labels.MakeDefault == "" {
+		labels.MakeDefault = DefaultLabels().MakeDefault
+	}
 
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
 		errorMsg := ""
@@ -313,6 +325,7 @@ func NewView(deps *Deps) view.View {
 			LogoutURL:       logoutURL,
 			Cards:           annotated,
 			Error:           errorMsg,
+			RememberDefault: deps.RememberDefault,
 		}
 
 		return view.OK("select-workspace-role", pageData)
@@ -0,0 +1,23 @@
+// Package verifyemail renders the "check your email" step (/auth/verify-email)
+// shown after self-signup and whenever an account whose address is not yet
+// confirmed tries to sign in. It reuses the login02 auth shell, like the MFA
+// challenge.
+//
+// Route convention:
+//
+//	GET  /auth/verify-email          — the page (pending address from the
+//	                                   sealed verify_pending cookie)
+//	GET  /auth/verify-email?token=…  — confirm the emailed link
+//	POST /auth/verify-email/resend   — send a fresh link
+//
+// No session exists at this step: the pending cookie only names the address
+// a new link may be sent to.
+package verifyemail
+
+import "embed"
+
+// TemplatesFS embeds the verify-email templates. Register alongside
+// login02.TemplatesFS.
+//
+//go:embed templates/*.html
+var TemplatesFS embed.FS
@@ -0,0 +1,115 @@
+package verifyemail
+
+import (
+	"context"
+
+	entydad "github.com/erniealice/entydad-golang"
+	pyeza "github.com/erniealice/pyeza-golang"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// State is the per-request data the auth handler resolves from the pending
+// cookie and installs via WithState.
+type State struct {
+	Email     string // address the link went to
+	Resent    bool   // a fresh link was just sent
+	ErrorCode string // short code mapped to a label (see VerifyEmailLabels)
+}
+
+type ctxKey int
+
+const ctxKeyState ctxKey = 0
+
+// WithState returns a derived context carrying the page state.
+func WithState(ctx context.Context, s State) context.Context {
+	return context.WithValue(ctx, ctxKeyState, s)
+}
+
+func getState(ctx context.Context) State {
+	s, _ := ctx.Value(ctxKeyState).(State)
+	return s
+}
+
+// Deps holds view dependencies for the verify-email page.
+type Deps struct {
+	Labels       entydad.VerifyEmailLabels
+	CommonLabels pyeza.CommonLabels
+	LogoText     string
+	LogoIcon     string
+	ResendURL    string // default: /auth/verify-email/resend
+	LoginURL     string // default: /auth/login
+}
+
+// PageData is the template-facing data shape.
+type PageData struct {
+	types.PageData
+	ContentTemplate string
+	Labels          entydad.VerifyEmailLabels
+	LogoText        string
+	LogoIcon        string
+	ResendURL       string
+	LoginURL        string
+	State
+	Error string
+}
+
+// NewView creates the verify-email page view. The handler installs State
+// via WithState.
+func NewView(deps *Deps) view.View {
+	resendURL := deps.ResendURL
+	if resendURL == "" {
+		resendURL = entydad.AuthVerifyEmailResendURL
+	}
+	loginURL := deps.LoginURL
+	if loginURL == "" {
+		loginURL = entydad.AuthLoginURL
+	}
+	labels := deps.Labels
+	if labels.Title == "" {
+		labels = entydad.DefaultVerifyEmailLabels()
+	}
+
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		state := getState(ctx)
+		errorMsg := ""
+		if state.ErrorCode != "" {
+			errorMsg = resolveErrorLabel(state.ErrorCode, labels)
+		}
+
+		pageData := &PageData{
+			PageData: types.PageData{
+				CacheVersion: viewCtx.CacheVersion,
+				Title:        labels.Title,
+				CurrentPath:  viewCtx.CurrentPath,
+				CommonLabels: deps.CommonLabels,
+			},
+			ContentTemplate: "verify-email-content",
+			Labels:          labels,
+			LogoText:        deps.LogoText,
+			LogoIcon:        deps.LogoIcon,
+			ResendURL:       resendURL,
+			LoginURL:        loginURL,
+			State:           state,
+			Error:           errorMsg,
+		}
+
+		return view.OK("verify-email", pageData)
+	})
+}
+
+// resolveErrorLabel maps a short error code to the matching label; anything
+// unrecognized returns the generic Error label.
+func resolveErrorLabel(code string, l entydad.VerifyEmailLabels) string {
+	switch code {
+	case "throttled":
+		if l.ErrorThrottled != "" {
+			return l.ErrorThrottled
+		}
+	case "mail":
+		if l.ErrorSendFailed != "" {
+			return l.ErrorSendFailed
+		}
+	}
+	return l.Error
+}
@@ -129,10 +129,34 @@ func resolveErrorLabel(code string, l entydad.ResetPassword02Labels) string {
 		if l.ErrorExpiredToken != "" {
 			return l.ErrorExpiredToken
 		}
-	case "weak_password":
+	case "weak_password", "too_short":
 		if l.ErrorWeakPassword != "" {
 			return l.ErrorWeakPassword
 		}
+	case "throttled":
+		if l.ErrorThrottled != "" {
+			return l.ErrorThrottled
+		}
+	case "password_classes":
+		if l.ErrorPasswordClasses != "" {
+			return l.ErrorPasswordClasses
+		}
+	case "password_breached":
+		if l.ErrorPasswordBreached != "" {
+			return l.ErrorPasswordBreached
+		}
+	case "password_reused":
+		if l.ErrorPasswordReused != "" {
+			return l.ErrorPasswordReused
+		}
+	case "password_contains_email":
+		if l.ErrorPasswordContainsEmail != "" {
+			return l.ErrorPasswordContainsEmail
+		}
+	case "sso_required":
+		if l.ErrorSSORequired != "" {
+			return l.ErrorSSORequired
+		}
 	}
 	return l.Error
 }
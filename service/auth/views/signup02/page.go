@@ -46,6 +46,7 @@ type PageData struct {
 	TermsURL        string
 	Slides          []CarouselSlide
 	SocialProviders []SocialProvider
+	Error           string // non-empty when signup failed (?error=<code>)
 }
 
 // NewView creates the signup02 page view (GET /auth/signup).
@@ -64,6 +65,13 @@ func NewView(deps *Deps) view.View {
 	}
 
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		errorMsg := ""
+		if viewCtx.Request != nil {
+			if code := viewCtx.Request.URL.Query().Get("error"); code != "" {
+				errorMsg = resolveErrorLabel(code, deps.Labels)
+			}
+		}
+
 		pageData := &PageData{
 			PageData: types.PageData{
 				CacheVersion: viewCtx.CacheVersion,
@@ -80,8 +88,50 @@ func NewView(deps *Deps) view.View {
 			TermsURL:        termsURL,
 			Slides:          deps.Slides,
 			SocialProviders: deps.SocialProviders,
+			Error:           errorMsg,
 		}
 
 		return view.OK("signup02", pageData)
 	})
 }
+
+// resolveErrorLabel maps a short error code from the signup handler to the
+// matching localised label. Anything unrecognized returns the generic Error
+// label — raw err.Error() strings are never displayed.
+func resolveErrorLabel(code string, l entydad.Signup02Labels) string {
+	switch code {
+	case "mismatch":
+		if l.ErrorMismatch != "" {
+			return l.ErrorMismatch
+		}
+	case "email_taken":
+		if l.ErrorEmailTaken != "" {
+			return l.ErrorEmailTaken
+		}
+	case "weak_password", "too_short":
+		if l.ErrorWeakPassword != "" {
+			return l.ErrorWeakPassword
+		}
+	case "invalid_email":
+		if l.ErrorInvalidEmail != "" {
+			return l.ErrorInvalidEmail
+		}
+	case "throttled":
+		if l.ErrorThrottled != "" {
+			return l.ErrorThrottled
+		}
+	case "password_classes":
+		if l.ErrorPasswordClasses != "" {
+			return l.ErrorPasswordClasses
+		}
+	case "password_breached":
+		if l.ErrorPasswordBreached != "" {
+			return l.ErrorPasswordBreached
+		}
+	case "password_contains_email":
+		if l.ErrorPasswordContainsEmail != "" {
+			return l.ErrorPasswordContainsEmail
+		}
+	}
+	return l.Error
+}
@@ -0,0 +1,238 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+const (
+	testRPID   = "app.example.com"
+	testOrigin = "https://app.example.com"
+)
+
+// cborHead / cborEnc are a tiny CBOR encoder, just enough to build the
+// authenticator structures the verifier decodes.
+func cborHead(major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return []byte{major<<5 | byte(n)}
+	case n <= 0xff:
+		return []byte{major<<5 | 24, byte(n)}
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16([]byte{major<<5 | 25}, uint16(n))
+	}
+	return binary.BigEndian.AppendUint32([]byte{major<<5 | 26}, uint32(n))
+}
+
+func cborEnc(v any) []byte {
+	switch x := v.(type) {
+	case int:
+		if x >= 0 {
+			return cborHead(0, uint64(x))
+		}
+		return cborHead(1, uint64(-1-x))
+	case []byte:
+		return append(cborHead(2, uint64(len(x))), x...)
+	case string:
+		return append(cborHead(3, uint64(len(x))), x...)
+	case [][2]any: // ordered map
+		out := cborHead(5, uint64(len(x)))
+		for _, kv := range x {
+			out = append(out, cborEnc(kv[0])...)
+			out = append(out, cborEnc(kv[1])...)
+		}
+		return out
+	}
+	panic("cborEnc: unsupported type")
+}
+
+type testAuthenticator struct {
+	key    *ecdsa.PrivateKey
+	credID []byte
+}
+
+func newTestAuthenticator(t *testing.T) *testAuthenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testAuthenticator{key: key, credID: []byte("credential-0001")}
+}
+
+func (a *testAuthenticator) coseKey() []byte {
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+	return cborEnc([][2]any{{1, 2}, {3, coseAlgES256}, {-1, 1}, {-2, x}, {-3, y}})
+}
+
+func testAuthData(rpID string, flags byte, signCount uint32, attested []byte) []byte {
+	h := sha256.Sum256([]byte(rpID))
+	out := append(h[:], flags)
+	out = binary.BigEndian.AppendUint32(out, signCount)
+	return append(out, attested...)
+}
+
+func (a *testAuthenticator) attestation(rpID string, flags byte) []byte {
+	attested := make([]byte, 16) // zero AAGUID
+	attested = binary.BigEndian.AppendUint16(attested, uint16(len(a.credID)))
+	attested = append(attested, a.credID...)
+	attested = append(attested, a.coseKey()...)
+	authData := testAuthData(rpID, flags|authFlagAttested, 0, attested)
+	return cborEnc([][2]any{{"fmt", "none"}, {"attStmt", [][2]any{}}, {"authData", authData}})
+}
+
+func (a *testAuthenticator) sign(t *testing.T, authData, clientData []byte) []byte {
+	t.Helper()
+	cdHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte(nil), authData...), cdHash[:]...))
+	sig, err := ecdsa.SignASN1(rand.Reader, a.key, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return sig
+}
+
+func testClientData(ceremony string, challenge []byte, origin string) []byte {
+	b, _ := json.Marshal(map[string]any{
+		"type":      ceremony,
+		"challenge": webauthnB64.EncodeToString(challenge),
+		"origin":    origin,
+	})
+	return b
+}
+
+func TestVerifyRegistration(t *testing.T) {
+	t.Parallel()
+	a := newTestAuthenticator(t)
+	challenge := []byte("registration-challenge-32-bytes!")
+	origins := []string{testOrigin}
+	uv := byte(authFlagUserPresent | authFlagUserVerified)
+
+	tests := []struct {
+		name       string
+		attObj     []byte
+		clientData []byte
+		wantErr    error
+	}{
+		{"ok", a.attestation(testRPID, uv), testClientData("webauthn.create", challenge, testOrigin), nil},
+		{"wrong origin", a.attestation(testRPID, uv), testClientData("webauthn.create", challenge, "https://evil.example"), errWebAuthnClientData},
+		{"wrong challenge", a.attestation(testRPID, uv), testClientData("webauthn.create", []byte("other"), testOrigin), errWebAuthnClientData},
+		{"assertion ceremony", a.attestation(testRPID, uv), testClientData("webauthn.get", challenge, testOrigin), errWebAuthnClientData},
+		{"rp id mismatch", a.attestation("evil.example", uv), testClientData("webauthn.create", challenge, testOrigin), errWebAuthnAuthData},
+		{"no user verification", a.attestation(testRPID, authFlagUserPresent), testClientData("webauthn.create", challenge, testOrigin), errWebAuthnAuthData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, key, _, err := verifyRegistration(tt.attObj, tt.clientData, testRPID, challenge, origins)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(id) != string(a.credID) {
+				t.Errorf("credential id = %q, want %q", id, a.credID)
+			}
+			if string(key) != string(a.coseKey()) {
+				t.Error("public key does not round-trip the COSE key")
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	t.Parallel()
+	a := newTestAuthenticator(t)
+	other := newTestAuthenticator(t)
+	challenge := []byte("assertion-challenge-32-bytes!!!!")
+	origins := []string{testOrigin}
+	uv := byte(authFlagUserPresent | authFlagUserVerified)
+
+	okAuthData := testAuthData(testRPID, uv, 7, nil)
+	okClientData := testClientData("webauthn.get", challenge, testOrigin)
+
+	tests := []struct {
+		name       string
+		authData   []byte
+		clientData []byte
+		signer     *testAuthenticator
+		wantErr    error
+	}{
+		{"ok", okAuthData, okClientData, a, nil},
+		{"wrong origin", okAuthData, testClientData("webauthn.get", challenge, "https://evil.example"), a, errWebAuthnClientData},
+		{"wrong challenge", okAuthData, testClientData("webauthn.get", []byte("replayed"), testOrigin), a, errWebAuthnClientData},
+		{"registration ceremony", okAuthData, testClientData("webauthn.create", challenge, testOrigin), a, errWebAuthnClientData},
+		{"rp id mismatch", testAuthData("evil.example", uv, 7, nil), okClientData, a, errWebAuthnAuthData},
+		{"no user verification", testAuthData(testRPID, authFlagUserPresent, 7, nil), okClientData, a, errWebAuthnAuthData},
+		{"signed by another key", okAuthData, okClientData, other, errWebAuthnSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sig := tt.signer.sign(t, tt.authData, tt.clientData)
+			count, err := verifyAssertion(a.coseKey(), tt.authData, tt.clientData, sig, testRPID, challenge, origins)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("err = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if count != 7 {
+				t.Errorf("sign count = %d, want 7", count)
+			}
+		})
+	}
+}
+
+func TestVerifyAssertion_TamperedClientData(t *testing.T) {
+	t.Parallel()
+	a := newTestAuthenticator(t)
+	challenge := []byte("assertion-challenge-32-bytes!!!!")
+	authData := testAuthData(testRPID, authFlagUserPresent|authFlagUserVerified, 1, nil)
+	signed := testClientData("webauthn.get", challenge, testOrigin)
+	sig := a.sign(t, authData, signed)
+
+	// Same fields, different bytes: the signature covers the exact JSON.
+	tampered := append(append([]byte(nil), signed[:len(signed)-1]...), []byte(`,"x":1}`)...)
+	if _, err := verifyAssertion(a.coseKey(), authData, tampered, sig, testRPID, challenge, []string{testOrigin}); !errors.Is(err, errWebAuthnSignature) {
+		t.Fatalf("err = %v, want %v", err, errWebAuthnSignature)
+	}
+}
+
+func TestParseCOSEKey_RejectsOffCurvePoint(t *testing.T) {
+	t.Parallel()
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	y[31] = 1
+	raw := cborEnc([][2]any{{1, 2}, {3, coseAlgES256}, {-1, 1}, {-2, x}, {-3, y}})
+	if _, err := parseCOSEKey(raw); !errors.Is(err, errWebAuthnKey) {
+		t.Fatalf("err = %v, want %v", err, errWebAuthnKey)
+	}
+}
+
+func TestDecodeCBOR_RejectsTruncatedAndDeep(t *testing.T) {
+	t.Parallel()
+	if _, _, err := decodeCBOR([]byte{0x58, 0x10, 0x01}); !errors.Is(err, errCBOR) {
+		t.Errorf("truncated byte string: err = %v", err)
+	}
+	deep := make([]byte, 0, 64)
+	for i := 0; i < 40; i++ {
+		deep = append(deep, 0x81) // array of one
+	}
+	deep = append(deep, 0x00)
+	if _, _, err := decodeCBOR(deep); !errors.Is(err, errCBOR) {
+		t.Errorf("deep nesting: err = %v", err)
+	}
+}
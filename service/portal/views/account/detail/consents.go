@@ -0,0 +1,50 @@
+package detail
+
+import (
+	"context"
+	"log"
+
+	"github.com/erniealice/entydad-golang/service/auth"
+)
+
+// ConsentHistory lists the signed-in user's terms / privacy acceptances,
+// newest first. Satisfied by auth.AuthModule.ConsentHistory.
+type ConsentHistory func(ctx context.Context, userID string) ([]auth.ConsentAcceptance, error)
+
+// ConsentsData is the consents tab state: a read-only history.
+type ConsentsData struct {
+	Items    []ConsentItem
+	ErrorKey string // translation key, rendered via .T
+}
+
+// ConsentItem is one row of the consent history.
+type ConsentItem struct {
+	Document   string
+	Version    string
+	AcceptedAt string
+	IP         string
+}
+
+func loadConsents(ctx context.Context, deps *ModuleDeps) *ConsentsData {
+	cd := &ConsentsData{}
+	userID, _ := currentUser(ctx)
+	items, err := deps.ConsentHistory(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load consent history for user %s: %v", userID, err)
+		cd.ErrorKey = "memberPages.account.consents.errorUnavailable"
+		return cd
+	}
+	for _, it := range items {
+		document := it.Title
+		if document == "" {
+			document = it.Kind
+		}
+		cd.Items = append(cd.Items, ConsentItem{
+			Document:   document,
+			Version:    it.Version,
+			AcceptedAt: formatAccountTime(it.AcceptedAt),
+			IP:         it.IP,
+		})
+	}
+	return cd
+}
@@ -0,0 +1,185 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/erniealice/entydad-golang/service/auth"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// Data tab closures, satisfied by auth.AuthModule's RequestDataExport,
+// ListDataExports, AccountDeletionStatus, ScheduleAccountDeletion and
+// CancelAccountDeletion. Either set may be left nil: the tab shows the
+// export section, the deletion section, or both.
+type (
+	RequestDataExport       func(ctx context.Context, userID string) (auth.DataExportSummary, error)
+	ListDataExports         func(ctx context.Context, userID string) ([]auth.DataExportSummary, error)
+	AccountDeletionStatus   func(ctx context.Context, userID string) (*auth.AccountDeletion, error)
+	ScheduleAccountDeletion func(ctx context.Context, userID string) (auth.AccountDeletion, error)
+	CancelAccountDeletion   func(ctx context.Context, userID string) error
+)
+
+// POST paths for the data tab, relative to the account page URL.
+// AccountDeletePath is a step-up action: see the account module's
+// SensitiveActions.
+const (
+	DataExportRequestPath   = "/data-export"
+	AccountDeletePath       = "/delete-account"
+	AccountDeleteCancelPath = "/delete-account/cancel"
+)
+
+// DataData is the data tab state.
+type DataData struct {
+	ExportEnabled   bool
+	Exports         []DataExportItem
+	ExportPending   bool // an export is being prepared; the form is hidden
+	DeletionEnabled bool
+	DeletionAt      string // scheduled deletion date, "" when none
+	ErrorKey        string // translation key, rendered via .T
+	SuccessKey      string // translation key, rendered via .T
+
+	ExportURL       string
+	DeleteURL       string
+	DeleteCancelURL string
+}
+
+// DataExportItem is one row of the exports list. StatusKey is a translation
+// key; DownloadURL is set while the archive can be downloaded.
+type DataExportItem struct {
+	RequestedAt string
+	ExpiresAt   string
+	StatusKey   string
+	DownloadURL string
+}
+
+func dataTabEnabled(deps *ModuleDeps) bool {
+	return dataExportEnabled(deps) || accountDeletionEnabled(deps)
+}
+
+func dataExportEnabled(deps *ModuleDeps) bool {
+	return deps.ListDataExports != nil && deps.RequestDataExport != nil
+}
+
+func accountDeletionEnabled(deps *ModuleDeps) bool {
+	return deps.AccountDeletionStatus != nil && deps.ScheduleAccountDeletion != nil && deps.CancelAccountDeletion != nil
+}
+
+func loadData(ctx context.Context, deps *ModuleDeps) *DataData {
+	pageURL := deps.PageURL
+	if pageURL == "" {
+		pageURL = "/app/account"
+	}
+	dd := &DataData{
+		ExportURL:       pageURL + DataExportRequestPath,
+		DeleteURL:       pageURL + AccountDeletePath,
+		DeleteCancelURL: pageURL + AccountDeleteCancelPath,
+	}
+	userID, _ := currentUser(ctx)
+	if dataExportEnabled(deps) {
+		dd.ExportEnabled = true
+		exports, err := deps.ListDataExports(ctx, userID)
+		if err != nil {
+			log.Printf("Failed to load data exports for user %s: %v", userID, err)
+			dd.ErrorKey = "memberPages.account.data.errorUnavailable"
+		}
+		for _, e := range exports {
+			dd.Exports = append(dd.Exports, DataExportItem{
+				RequestedAt: formatAccountTime(e.RequestedAt),
+				ExpiresAt:   formatAccountTime(e.ExpiresAt),
+				StatusKey:   "memberPages.account.data.export.status." + e.Status,
+				DownloadURL: e.DownloadURL,
+			})
+			if e.Status == auth.DataExportPending {
+				dd.ExportPending = true
+			}
+		}
+	}
+	if accountDeletionEnabled(deps) {
+		dd.DeletionEnabled = true
+		scheduled, err := deps.AccountDeletionStatus(ctx, userID)
+		if err != nil {
+			log.Printf("Failed to load account deletion for user %s: %v", userID, err)
+			dd.ErrorKey = "memberPages.account.data.errorUnavailable"
+		} else if scheduled != nil {
+			dd.DeletionAt = scheduled.ScheduledFor.Format("2006-01-02")
+		}
+	}
+	return dd
+}
+
+// NewDataExportRequestAction starts a data export. The tab lists it as
+// being prepared; reloading shows the download link once it is ready.
+func NewDataExportRequestAction(deps *ModuleDeps) view.View {
+	return dataAction(deps, func(ctx context.Context, viewCtx *view.ViewContext, userID string) (successKey, errorKey string) {
+		if _, err := deps.RequestDataExport(ctx, userID); err != nil {
+			return "", dataErrorKey(err)
+		}
+		return "memberPages.account.data.export.requested", ""
+	})
+}
+
+// NewAccountDeleteAction schedules the signed-in user's account for
+// deletion after the grace period. The form's confirm box must be ticked.
+func NewAccountDeleteAction(deps *ModuleDeps) view.View {
+	return dataAction(deps, func(ctx context.Context, viewCtx *view.ViewContext, userID string) (successKey, errorKey string) {
+		if viewCtx.Request.FormValue("confirm") != "1" {
+			return "", "memberPages.account.data.delete.errorConfirm"
+		}
+		if _, err := deps.ScheduleAccountDeletion(ctx, userID); err != nil {
+			return "", dataErrorKey(err)
+		}
+		return "memberPages.account.data.delete.scheduled", ""
+	})
+}
+
+// NewAccountDeleteCancelAction withdraws the scheduled deletion.
+func NewAccountDeleteCancelAction(deps *ModuleDeps) view.View {
+	return dataAction(deps, func(ctx context.Context, viewCtx *view.ViewContext, userID string) (successKey, errorKey string) {
+		if err := deps.CancelAccountDeletion(ctx, userID); err != nil {
+			return "", dataErrorKey(err)
+		}
+		return "memberPages.account.data.delete.cancelled", ""
+	})
+}
+
+// dataAction is the shared POST shell: permission gate, form parse, the
+// action, then the data tab re-rendered with its result.
+func dataAction(deps *ModuleDeps, run func(ctx context.Context, viewCtx *view.ViewContext, userID string) (successKey, errorKey string)) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("user", "update") {
+			return view.Forbidden("user:update")
+		}
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		userID, _ := currentUser(ctx)
+		if userID == "" {
+			return view.Forbidden("user:update")
+		}
+		successKey, errorKey := run(ctx, viewCtx, userID)
+		dd := loadData(ctx, deps)
+		if errorKey != "" {
+			dd.ErrorKey = errorKey
+		} else if dd.ErrorKey == "" {
+			dd.SuccessKey = successKey
+		}
+		return renderPage(viewCtx, deps, "data", &PageData{Data: dd})
+	})
+}
+
+// dataErrorKey maps a closure error to a translation key.
+func dataErrorKey(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrDataExportPending):
+		return "memberPages.account.data.export.errorPending"
+	case errors.Is(err, auth.ErrAccountDeletionNotFound):
+		return "memberPages.account.data.delete.errorNotScheduled"
+	case errors.Is(err, auth.ErrImpersonating):
+		return "memberPages.account.data.errorImpersonating"
+	}
+	log.Printf("Account data action failed: %v", err)
+	return "memberPages.account.data.errorUnavailable"
+}
@@ -15,6 +15,40 @@ type ModuleDeps struct {
 	// PageURL is the base URL for the account page used to build tab hrefs.
 	// Defaults to "/app/account" when empty for backward compatibility.
 	PageURL string
+
+	// Two-step verification closures (see two_factor.go). MFAStatus nil ⇒
+	// the two_factor tab is hidden.
+	MFAStatus                  MFAStatus
+	MFABeginSetup              MFABeginSetup
+	MFAConfirmSetup            MFAConfirmSetup
+	MFADisable                 MFADisable
+	MFARegenerateRecoveryCodes MFARegenerateRecoveryCodes
+
+	// Passkey closures (see passkeys.go). ListPasskeys nil ⇒ the passkeys
+	// tab is hidden. The register URLs are the auth module's passkey
+	// registration endpoints the tab's script calls.
+	ListPasskeys              ListPasskeys
+	DeletePasskey             DeletePasskey
+	PasskeyRegisterOptionsURL string
+	PasskeyRegisterURL        string
+
+	// ConsentHistory (see consents.go) nil ⇒ the consents tab is hidden.
+	ConsentHistory ConsentHistory
+
+	// Personal access token closures (see tokens.go). ListAccessTokens nil
+	// ⇒ the tokens tab is hidden.
+	ListAccessTokens  ListAccessTokens
+	IssueAccessToken  IssueAccessToken
+	RevokeAccessToken RevokeAccessToken
+	AccessTokenScopes AccessTokenScopes
+
+	// Data tab closures (see data.go): the data export and account
+	// deletion. Neither set complete ⇒ the data tab is hidden.
+	RequestDataExport       RequestDataExport
+	ListDataExports         ListDataExports
+	AccountDeletionStatus   AccountDeletionStatus
+	ScheduleAccountDeletion ScheduleAccountDeletion
+	CancelAccountDeletion   CancelAccountDeletion
 }
 
 // PageData carries the rendering context for the account page.
@@ -23,9 +57,14 @@ type PageData struct {
 	TabItems          []pyeza.TabItem
 	ActiveTab         string
 	ChangePasswordURL string
+	TwoFactor         *TwoFactorData // nil unless the two_factor tab is active
+	Passkeys          *PasskeysData  // nil unless the passkeys tab is active
+	Consents          *ConsentsData  // nil unless the consents tab is active
+	Tokens            *TokensData    // nil unless the tokens tab is active
+	Data              *DataData      // nil unless the data tab is active
 }
 
-// NewView creates the account detail view (full page — tabs: email | password | sessions).
+// NewView creates the account detail view (full page — tabs: email | password | two_factor | passkeys | consents | tokens | data | sessions).
 func NewView(deps *ModuleDeps) view.View {
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
 		perms := view.GetUserPermissions(ctx)
@@ -37,42 +76,85 @@ func NewView(deps *ModuleDeps) view.View {
 		if viewCtx.Request != nil {
 			activeTab = viewCtx.Request.URL.Query().Get("tab")
 		}
-		tabs := buildTabs(deps.Messages, deps.PageURL)
-		if activeTab == "" || !validTab(tabs, activeTab) {
-			activeTab = "email"
-		}
 
-		titleKey := "memberPages.section.account.title"
-		iconKey := "memberPages.section.account.icon"
-
-		pageData := &PageData{
-			PageData: types.PageData{
-				CacheVersion:    viewCtx.CacheVersion,
-				Title:           lookup(deps.Messages, titleKey, "Account"),
-				CurrentPath:     viewCtx.CurrentPath,
-				ActiveNav:       "home",
-				ContentTemplate: "account-page-content",
-				HeaderTitle:     lookup(deps.Messages, titleKey, "Account"),
-				HeaderIcon:      lookup(deps.Messages, iconKey, "icon-user"),
-				Messages:        deps.Messages,
-			},
-			TabItems:          tabs,
-			ActiveTab:         activeTab,
-			ChangePasswordURL: deps.ChangePasswordURL,
+		tab := &PageData{}
+		switch {
+		case activeTab == "two_factor" && deps.MFAStatus != nil:
+			setup := viewCtx.Request != nil && viewCtx.Request.URL.Query().Get("setup") == "1"
+			tab.TwoFactor = loadTwoFactor(ctx, deps, setup)
+		case activeTab == "passkeys" && deps.ListPasskeys != nil:
+			tab.Passkeys = loadPasskeys(ctx, deps)
+		case activeTab == "consents" && deps.ConsentHistory != nil:
+			tab.Consents = loadConsents(ctx, deps)
+		case activeTab == "tokens" && deps.ListAccessTokens != nil:
+			tab.Tokens = loadTokens(ctx, deps)
+		case activeTab == "data" && dataTabEnabled(deps):
+			tab.Data = loadData(ctx, deps)
 		}
-		return view.OK("account-page", pageData)
+		return renderPage(viewCtx, deps, activeTab, tab)
 	})
 }
 
-func buildTabs(messages map[string]string, pageURL string) []pyeza.TabItem {
+// renderPage assembles the account page for the GET view and for the tab
+// actions, which re-render it in place (new recovery codes and token secrets
+// are only ever shown in that response). tab carries the active tab's state.
+func renderPage(viewCtx *view.ViewContext, deps *ModuleDeps, activeTab string, tab *PageData) view.ViewResult {
+	tabs := buildTabs(deps)
+	if activeTab == "" || !validTab(tabs, activeTab) {
+		activeTab = "email"
+	}
+
+	titleKey := "memberPages.section.account.title"
+	iconKey := "memberPages.section.account.icon"
+
+	pageData := &PageData{
+		PageData: types.PageData{
+			CacheVersion:    viewCtx.CacheVersion,
+			Title:           lookup(deps.Messages, titleKey, "Account"),
+			CurrentPath:     viewCtx.CurrentPath,
+			ActiveNav:       "home",
+			ContentTemplate: "account-page-content",
+			HeaderTitle:     lookup(deps.Messages, titleKey, "Account"),
+			HeaderIcon:      lookup(deps.Messages, iconKey, "icon-user"),
+			Messages:        deps.Messages,
+		},
+		TabItems:          tabs,
+		ActiveTab:         activeTab,
+		ChangePasswordURL: deps.ChangePasswordURL,
+		TwoFactor:         tab.TwoFactor,
+		Passkeys:          tab.Passkeys,
+		Consents:          tab.Consents,
+		Tokens:            tab.Tokens,
+		Data:              tab.Data,
+	}
+	return view.OK("account-page", pageData)
+}
+
+func buildTabs(deps *ModuleDeps) []pyeza.TabItem {
+	messages, pageURL := deps.Messages, deps.PageURL
 	if pageURL == "" {
 		pageURL = "/app/account"
 	}
-	return []pyeza.TabItem{
+	tabs := []pyeza.TabItem{
 		{Key: "email", Label: lookup(messages, "memberPages.account.tab.email", "Sign-in email"), Href: pageURL + "?tab=email"},
 		{Key: "password", Label: lookup(messages, "memberPages.account.tab.password", "Password"), Href: pageURL + "?tab=password"},
-		{Key: "sessions", Label: lookup(messages, "memberPages.account.tab.sessions", "Sessions"), Href: pageURL + "?tab=sessions"},
 	}
+	if deps.MFAStatus != nil {
+		tabs = append(tabs, pyeza.TabItem{Key: "two_factor", Label: lookup(messages, "memberPages.account.tab.twoFactor", "Two-step verification"), Href: pageURL + "?tab=two_factor"})
+	}
+	if deps.ListPasskeys != nil {
+		tabs = append(tabs, pyeza.TabItem{Key: "passkeys", Label: lookup(messages, "memberPages.account.tab.passkeys", "Passkeys"), Href: pageURL + "?tab=passkeys"})
+	}
+	if deps.ConsentHistory != nil {
+		tabs = append(tabs, pyeza.TabItem{Key: "consents", Label: lookup(messages, "memberPages.account.tab.consents", "Terms & privacy"), Href: pageURL + "?tab=consents"})
+	}
+	if deps.ListAccessTokens != nil {
+		tabs = append(tabs, pyeza.TabItem{Key: "tokens", Label: lookup(messages, "memberPages.account.tab.tokens", "Access tokens"), Href: pageURL + "?tab=tokens"})
+	}
+	if dataTabEnabled(deps) {
+		tabs = append(tabs, pyeza.TabItem{Key: "data", Label: lookup(messages, "memberPages.account.tab.data", "Your data"), Href: pageURL + "?tab=data"})
+	}
+	return append(tabs, pyeza.TabItem{Key: "sessions", Label: lookup(messages, "memberPages.account.tab.sessions", "Sessions"), Href: pageURL + "?tab=sessions"})
 }
 
 func validTab(tabs []pyeza.TabItem, key string) bool {
@@ -0,0 +1,111 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/erniealice/entydad-golang/service/auth"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// Passkey closures, satisfied by auth.AuthModule.ListPasskeys and
+// auth.AuthModule.DeletePasskey.
+type (
+	ListPasskeys  func(ctx context.Context, userID string) ([]auth.PasskeySummary, error)
+	DeletePasskey func(ctx context.Context, userID, credentialID string) error
+)
+
+// PasskeyDeletePath is the passkeys tab's POST path, relative to the account
+// page URL.
+const PasskeyDeletePath = "/passkeys/delete"
+
+// PasskeysData is the passkeys tab state. Registration itself runs in the
+// browser against the auth module's /auth/passkey/register endpoints; the
+// page only lists and removes credentials.
+type PasskeysData struct {
+	Items    []PasskeyItem
+	ErrorKey string // translation key, rendered via .T
+
+	DeleteURL          string
+	RegisterOptionsURL string
+	RegisterURL        string
+}
+
+// PasskeyItem is one row of the passkeys list.
+type PasskeyItem struct {
+	ID         string
+	Name       string
+	CreatedAt  string
+	LastUsedAt string
+}
+
+func loadPasskeys(ctx context.Context, deps *ModuleDeps) *PasskeysData {
+	pageURL := deps.PageURL
+	if pageURL == "" {
+		pageURL = "/app/account"
+	}
+	pk := &PasskeysData{
+		DeleteURL:          pageURL + PasskeyDeletePath,
+		RegisterOptionsURL: deps.PasskeyRegisterOptionsURL,
+		RegisterURL:        deps.PasskeyRegisterURL,
+	}
+	userID, _ := currentUser(ctx)
+	items, err := deps.ListPasskeys(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load passkeys for user %s: %v", userID, err)
+		pk.ErrorKey = "memberPages.account.passkeys.errorUnavailable"
+		return pk
+	}
+	for _, it := range items {
+		pk.Items = append(pk.Items, PasskeyItem{
+			ID:         it.ID,
+			Name:       it.Name,
+			CreatedAt:  formatAccountTime(it.CreatedAt),
+			LastUsedAt: formatAccountTime(it.LastUsedAt),
+		})
+	}
+	return pk
+}
+
+// NewPasskeyDeleteAction removes one of the signed-in user's passkeys and
+// re-renders the passkeys tab.
+func NewPasskeyDeleteAction(deps *ModuleDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("user", "update") {
+			return view.Forbidden("user:update")
+		}
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		userID, _ := currentUser(ctx)
+		if userID == "" {
+			return view.Forbidden("user:update")
+		}
+		errorKey := ""
+		if err := deps.DeletePasskey(ctx, userID, viewCtx.Request.FormValue("credential_id")); err != nil {
+			if errors.Is(err, auth.ErrPasskeyNotFound) {
+				errorKey = "memberPages.account.passkeys.errorNotFound"
+			} else if errors.Is(err, auth.ErrImpersonating) {
+				errorKey = "memberPages.account.passkeys.errorImpersonating"
+			} else {
+				log.Printf("Failed to delete passkey for user %s: %v", userID, err)
+				errorKey = "memberPages.account.passkeys.errorUnavailable"
+			}
+		}
+		pk := loadPasskeys(ctx, deps)
+		if pk.ErrorKey == "" {
+			pk.ErrorKey = errorKey
+		}
+		return renderPage(viewCtx, deps, "passkeys", &PageData{Passkeys: pk})
+	})
+}
+
+func formatAccountTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
@@ -0,0 +1,203 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/erniealice/entydad-golang/service/auth"
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// Personal access token closures, satisfied by auth.AuthModule's
+// ListAccessTokens, IssueAccessToken, RevokeAccessToken and
+// AccessTokenScopes.
+type (
+	ListAccessTokens  func(ctx context.Context, userID string) ([]auth.AccessTokenSummary, error)
+	IssueAccessToken  func(ctx context.Context, req auth.AccessTokenRequest) (secret string, token auth.AccessTokenSummary, err error)
+	RevokeAccessToken func(ctx context.Context, userID, tokenID string) error
+	AccessTokenScopes func(ctx context.Context, userID, workspaceID string) ([]string, error)
+)
+
+// POST paths for the tokens tab, relative to the account page URL.
+const (
+	AccessTokenIssuePath  = "/tokens/issue"
+	AccessTokenRevokePath = "/tokens/revoke"
+)
+
+// accessTokenExpiryDays are the lifetimes the issue form offers.
+var accessTokenExpiryDays = []int{30, 90, 365}
+
+// TokensData is the tokens tab state. Secret is only set in the response to
+// the issue action — only a hash is stored, so this is the one chance to
+// copy it.
+type TokensData struct {
+	Items      []TokenItem
+	Scopes     []string // permission codes the issue form offers
+	ExpiryDays []int
+	Secret     string
+	ErrorKey   string // translation key, rendered via .T
+
+	IssueURL  string
+	RevokeURL string
+}
+
+// TokenItem is one row of the tokens list. StatusKey is a translation key.
+type TokenItem struct {
+	ID          string
+	Name        string
+	Hint        string
+	Permissions string
+	CreatedAt   string
+	ExpiresAt   string
+	LastUsedAt  string
+	StatusKey   string
+	Active      bool
+}
+
+func loadTokens(ctx context.Context, deps *ModuleDeps) *TokensData {
+	pageURL := deps.PageURL
+	if pageURL == "" {
+		pageURL = "/app/account"
+	}
+	td := &TokensData{
+		ExpiryDays: accessTokenExpiryDays,
+		IssueURL:   pageURL + AccessTokenIssuePath,
+		RevokeURL:  pageURL + AccessTokenRevokePath,
+	}
+	userID, _ := currentUser(ctx)
+	items, err := deps.ListAccessTokens(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load access tokens for user %s: %v", userID, err)
+		td.ErrorKey = "memberPages.account.tokens.errorUnavailable"
+		return td
+	}
+	for _, it := range items {
+		item := TokenItem{
+			ID:          it.ID,
+			Name:        it.Name,
+			Hint:        it.Hint,
+			Permissions: strings.Join(it.Permissions, ", "),
+			CreatedAt:   formatAccountTime(it.CreatedAt),
+			ExpiresAt:   formatAccountTime(it.ExpiresAt),
+			LastUsedAt:  formatAccountTime(it.LastUsedAt),
+			StatusKey:   "memberPages.account.tokens.statusActive",
+		}
+		switch {
+		case it.Revoked:
+			item.StatusKey = "memberPages.account.tokens.statusRevoked"
+		case it.Expired():
+			item.StatusKey = "memberPages.account.tokens.statusExpired"
+		default:
+			item.Active = true
+		}
+		td.Items = append(td.Items, item)
+	}
+	if deps.AccessTokenScopes != nil {
+		if workspaceID := currentWorkspace(ctx); workspaceID != "" {
+			scopes, err := deps.AccessTokenScopes(ctx, userID, workspaceID)
+			if err != nil {
+				log.Printf("Failed to load access token scopes for user %s: %v", userID, err)
+			}
+			td.Scopes = scopes
+		}
+	}
+	return td
+}
+
+// NewAccessTokenIssueAction issues a token for the current workspace with
+// the ticked permissions and shows its secret once.
+func NewAccessTokenIssueAction(deps *ModuleDeps) view.View {
+	return tokensAction(deps, func(ctx context.Context, viewCtx *view.ViewContext, userID string) (secret, errorKey string) {
+		workspaceID := currentWorkspace(ctx)
+		if workspaceID == "" {
+			return "", "memberPages.account.tokens.errorNoWorkspace"
+		}
+		days, _ := strconv.Atoi(viewCtx.Request.FormValue("expires_days"))
+		if !validExpiryDays(days) {
+			days = accessTokenExpiryDays[0]
+		}
+		secret, _, err := deps.IssueAccessToken(ctx, auth.AccessTokenRequest{
+			UserID:      userID,
+			Name:        viewCtx.Request.FormValue("name"),
+			WorkspaceID: workspaceID,
+			Permissions: viewCtx.Request.Form["permissions"],
+			ExpiresAt:   time.Now().AddDate(0, 0, days),
+		})
+		if err != nil {
+			return "", tokensErrorKey(err)
+		}
+		return secret, ""
+	})
+}
+
+// NewAccessTokenRevokeAction revokes one of the signed-in user's tokens.
+func NewAccessTokenRevokeAction(deps *ModuleDeps) view.View {
+	return tokensAction(deps, func(ctx context.Context, viewCtx *view.ViewContext, userID string) (secret, errorKey string) {
+		if err := deps.RevokeAccessToken(ctx, userID, viewCtx.Request.FormValue("token_id")); err != nil {
+			return "", tokensErrorKey(err)
+		}
+		return "", ""
+	})
+}
+
+// tokensAction is the shared POST shell: permission gate, form parse, the
+// action, then the tokens tab re-rendered with its result.
+func tokensAction(deps *ModuleDeps, run func(ctx context.Context, viewCtx *view.ViewContext, userID string) (secret, errorKey string)) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("user", "update") {
+			return view.Forbidden("user:update")
+		}
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		userID, _ := currentUser(ctx)
+		if userID == "" {
+			return view.Forbidden("user:update")
+		}
+		secret, errorKey := run(ctx, viewCtx, userID)
+		td := loadTokens(ctx, deps)
+		td.Secret = secret
+		if td.ErrorKey == "" {
+			td.ErrorKey = errorKey
+		}
+		return renderPage(viewCtx, deps, "tokens", &PageData{Tokens: td})
+	})
+}
+
+func currentWorkspace(ctx context.Context) string {
+	if id, ok := identity.FromContext(ctx); ok && id != nil {
+		return id.WorkspaceID
+	}
+	return ""
+}
+
+func validExpiryDays(days int) bool {
+	for _, d := range accessTokenExpiryDays {
+		if d == days {
+			return true
+		}
+	}
+	return false
+}
+
+// tokensErrorKey maps a closure error to a translation key.
+func tokensErrorKey(err error) string {
+	switch {
+	case errors.Is(err, auth.ErrAccessTokenRequest):
+		return "memberPages.account.tokens.errorRequest"
+	case errors.Is(err, auth.ErrAccessTokenScope):
+		return "memberPages.account.tokens.errorScope"
+	case errors.Is(err, auth.ErrAccessTokenNotFound):
+		return "memberPages.account.tokens.errorNotFound"
+	case errors.Is(err, auth.ErrImpersonating):
+		return "memberPages.account.tokens.errorImpersonating"
+	}
+	log.Printf("Access token action failed: %v", err)
+	return "memberPages.account.tokens.errorUnavailable"
+}
@@ -0,0 +1,178 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+
+	"github.com/erniealice/entydad-golang/service/auth"
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// Two-step verification closures, satisfied by the auth module's account
+// methods (auth.AuthModule.MFAStatus, ...). auth.ErrMFAInvalidCode renders as
+// an invalid-code message, auth.ErrImpersonating as a refusal; any other
+// error as "unavailable".
+type (
+	MFAStatus                  func(ctx context.Context, userID string) (enabled bool, recoveryRemaining int, err error)
+	MFABeginSetup              func(ctx context.Context, userID, email string) (secret, provisioningURI string, err error)
+	MFAConfirmSetup            func(ctx context.Context, userID, secret, code string) (recoveryCodes []string, err error)
+	MFADisable                 func(ctx context.Context, userID, code string) error
+	MFARegenerateRecoveryCodes func(ctx context.Context, userID, code string) (recoveryCodes []string, err error)
+)
+
+// POST paths for the two_factor tab, relative to the account page URL.
+const (
+	TwoFactorConfirmPath       = "/two-factor/confirm"
+	TwoFactorDisablePath       = "/two-factor/disable"
+	TwoFactorRecoveryCodesPath = "/two-factor/recovery-codes"
+)
+
+// TwoFactorData is the two_factor tab state. RecoveryCodes is only set in the
+// response to a confirm / regenerate action — the codes are never stored in
+// the clear, so this is the one chance to copy them.
+type TwoFactorData struct {
+	Enabled           bool
+	RecoveryRemaining int
+	// Setup is the enrolment form: Secret for manual entry plus the
+	// otpauth:// link, round-tripped as a hidden field until confirmed.
+	Setup           bool
+	Secret          string
+	ProvisioningURI string
+	RecoveryCodes   []string
+	ErrorKey        string // translation key, rendered via .T
+
+	SetupURL         string
+	ConfirmURL       string
+	DisableURL       string
+	RecoveryCodesURL string
+}
+
+func loadTwoFactor(ctx context.Context, deps *ModuleDeps, setup bool) *TwoFactorData {
+	tf := newTwoFactorData(deps)
+	userID, email := currentUser(ctx)
+	enabled, remaining, err := deps.MFAStatus(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load two-step verification status for user %s: %v", userID, err)
+		tf.ErrorKey = "memberPages.account.twoFactor.errorUnavailable"
+		return tf
+	}
+	tf.Enabled, tf.RecoveryRemaining = enabled, remaining
+	if setup && !enabled && deps.MFABeginSetup != nil {
+		secret, uri, err := deps.MFABeginSetup(ctx, userID, email)
+		if err != nil {
+			tf.ErrorKey = twoFactorErrorKey(err)
+			return tf
+		}
+		tf.Setup, tf.Secret, tf.ProvisioningURI = true, secret, uri
+	}
+	return tf
+}
+
+func newTwoFactorData(deps *ModuleDeps) *TwoFactorData {
+	pageURL := deps.PageURL
+	if pageURL == "" {
+		pageURL = "/app/account"
+	}
+	return &TwoFactorData{
+		SetupURL:         pageURL + "?tab=two_factor&setup=1",
+		ConfirmURL:       pageURL + TwoFactorConfirmPath,
+		DisableURL:       pageURL + TwoFactorDisablePath,
+		RecoveryCodesURL: pageURL + TwoFactorRecoveryCodesPath,
+	}
+}
+
+// NewTwoFactorConfirmAction verifies the first code from the authenticator
+// app and enables two-step verification, showing the recovery codes.
+func NewTwoFactorConfirmAction(deps *ModuleDeps) view.View {
+	return twoFactorAction(deps, func(ctx context.Context, viewCtx *view.ViewContext, userID string, tf *TwoFactorData) {
+		if tf.Enabled {
+			// Replacing an existing enrolment must go through disable
+			// (which asks for a current code) first.
+			return
+		}
+		secret := strings.TrimSpace(viewCtx.Request.FormValue("secret"))
+		codes, err := deps.MFAConfirmSetup(ctx, userID, secret, viewCtx.Request.FormValue("code"))
+		if err != nil {
+			// Keep the same secret so the entry already added to the
+			// authenticator app stays valid for the retry.
+			tf.Setup, tf.Secret = true, secret
+			tf.ErrorKey = twoFactorErrorKey(err)
+			return
+		}
+		tf.Enabled, tf.RecoveryRemaining, tf.RecoveryCodes = true, len(codes), codes
+	})
+}
+
+// NewTwoFactorDisableAction turns two-step verification off after a current
+// code (TOTP or recovery) confirms it is the account holder.
+func NewTwoFactorDisableAction(deps *ModuleDeps) view.View {
+	return twoFactorAction(deps, func(ctx context.Context, viewCtx *view.ViewContext, userID string, tf *TwoFactorData) {
+		if err := deps.MFADisable(ctx, userID, viewCtx.Request.FormValue("code")); err != nil {
+			tf.ErrorKey = twoFactorErrorKey(err)
+			return
+		}
+		tf.Enabled, tf.RecoveryRemaining = false, 0
+	})
+}
+
+// NewTwoFactorRecoveryCodesAction replaces every recovery code after a
+// current code confirms it is the account holder.
+func NewTwoFactorRecoveryCodesAction(deps *ModuleDeps) view.View {
+	return twoFactorAction(deps, func(ctx context.Context, viewCtx *view.ViewContext, userID string, tf *TwoFactorData) {
+		codes, err := deps.MFARegenerateRecoveryCodes(ctx, userID, viewCtx.Request.FormValue("code"))
+		if err != nil {
+			tf.ErrorKey = twoFactorErrorKey(err)
+			return
+		}
+		tf.RecoveryRemaining, tf.RecoveryCodes = len(codes), codes
+	})
+}
+
+// twoFactorAction is the shared POST shell: permission gate, form parse,
+// current status, then the action-specific step, re-rendering the page on
+// the two_factor tab.
+func twoFactorAction(deps *ModuleDeps, run func(ctx context.Context, viewCtx *view.ViewContext, userID string, tf *TwoFactorData)) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("user", "update") {
+			return view.Forbidden("user:update")
+		}
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		userID, _ := currentUser(ctx)
+		if userID == "" {
+			return view.Forbidden("user:update")
+		}
+		tf := loadTwoFactor(ctx, deps, false)
+		if tf.ErrorKey == "" {
+			run(ctx, viewCtx, userID, tf)
+		}
+		return renderPage(viewCtx, deps, "two_factor", &PageData{TwoFactor: tf})
+	})
+}
+
+func currentUser(ctx context.Context) (userID, email string) {
+	if id, ok := identity.FromContext(ctx); ok && id != nil {
+		return id.UserID, id.Email
+	}
+	return "", ""
+}
+
+// twoFactorErrorKey maps a closure error to a translation key.
+func twoFactorErrorKey(err error) string {
+	if errors.Is(err, auth.ErrMFAInvalidCode) {
+		return "memberPages.account.twoFactor.errorInvalidCode"
+	}
+	if errors.Is(err, auth.ErrImpersonating) {
+		return "memberPages.account.twoFactor.errorImpersonating"
+	}
+	if errors.Is(err, auth.ErrMFAAlreadyEnrolled) {
+		return "memberPages.account.twoFactor.errorAlreadyEnabled"
+	}
+	log.Printf("Two-step verification action failed: %v", err)
+	return "memberPages.account.twoFactor.errorUnavailable"
+}
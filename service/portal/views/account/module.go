@@ -1,11 +1,14 @@
 // Package account provides the /app/account page — account & security
-// (tabs: email | password | sessions). Part of the four "personal scope"
+// (tabs: email | password | two_factor | passkeys | consents | tokens | data | sessions). Part of the four "personal scope"
 // pages accessible from the sidebar bottom profile popover.
 //
 // Permission gating (Layer 3): user:update
 package account
 
 import (
+	"net/http"
+
+	entydad "github.com/erniealice/entydad-golang"
 	accountdetail "github.com/erniealice/entydad-golang/service/portal/views/account/detail"
 	"github.com/erniealice/pyeza-golang/view"
 )
@@ -17,6 +20,48 @@ type ModuleDeps struct {
 	// PageURL is the route path for the account page (e.g. "/app/account").
 	// Defaults to "/app/account" when empty for backward compatibility.
 	PageURL string
+
+	// Two-step verification (TOTP). Wired from the auth module's account
+	// methods (authModule.MFAStatus, ...). MFAStatus nil ⇒ the two_factor
+	// tab is hidden and its POST routes are not mounted.
+	MFAStatus                  accountdetail.MFAStatus
+	MFABeginSetup              accountdetail.MFABeginSetup
+	MFAConfirmSetup            accountdetail.MFAConfirmSetup
+	MFADisable                 accountdetail.MFADisable
+	MFARegenerateRecoveryCodes accountdetail.MFARegenerateRecoveryCodes
+
+	// Passkeys. Wired from authModule.ListPasskeys / DeletePasskey.
+	// ListPasskeys nil ⇒ the passkeys tab is hidden and its POST route is
+	// not mounted. The register URLs default to entydad's
+	// /auth/passkey/register[/options] endpoints.
+	ListPasskeys              accountdetail.ListPasskeys
+	DeletePasskey             accountdetail.DeletePasskey
+	PasskeyRegisterOptionsURL string
+	PasskeyRegisterURL        string
+
+	// Terms / privacy consent history. Wired from
+	// authModule.ConsentHistory; nil ⇒ the consents tab is hidden.
+	ConsentHistory accountdetail.ConsentHistory
+
+	// Personal access tokens. Wired from authModule.ListAccessTokens,
+	// IssueAccessToken, RevokeAccessToken and AccessTokenScopes.
+	// ListAccessTokens nil ⇒ the tokens tab is hidden and its POST routes
+	// are not mounted.
+	ListAccessTokens  accountdetail.ListAccessTokens
+	IssueAccessToken  accountdetail.IssueAccessToken
+	RevokeAccessToken accountdetail.RevokeAccessToken
+	AccessTokenScopes accountdetail.AccessTokenScopes
+
+	// Data export and account deletion. Wired from
+	// authModule.RequestDataExport / ListDataExports and
+	// AccountDeletionStatus / ScheduleAccountDeletion /
+	// CancelAccountDeletion. Either set complete ⇒ the data tab is shown
+	// and its POST routes are mounted.
+	RequestDataExport       accountdetail.RequestDataExport
+	ListDataExports         accountdetail.ListDataExports
+	AccountDeletionStatus   accountdetail.AccountDeletionStatus
+	ScheduleAccountDeletion accountdetail.ScheduleAccountDeletion
+	CancelAccountDeletion   accountdetail.CancelAccountDeletion
 }
 
 // Module wires the account route.
@@ -29,15 +74,104 @@ func NewModule(deps *ModuleDeps) *Module {
 	return &Module{deps: deps}
 }
 
-// RegisterRoutes registers the GET handler for the account page.
+// RegisterRoutes registers the GET handler for the account page and, when
+// two-step verification, passkeys, access tokens or the data tab are wired,
+// their POST actions.
 func (m *Module) RegisterRoutes(r view.RouteRegistrar) {
-	pageURL := m.deps.PageURL
-	if pageURL == "" {
-		pageURL = "/app/account"
-	}
-	r.GET(pageURL, accountdetail.NewView(&accountdetail.ModuleDeps{
-		Messages:          m.deps.Messages,
-		ChangePasswordURL: m.deps.ChangePasswordURL,
-		PageURL:           pageURL,
-	}))
+	pageURL := m.pageURL()
+	registerOptionsURL, registerURL := m.passkeyRegisterURLs()
+	detailDeps := &accountdetail.ModuleDeps{
+		Messages:                   m.deps.Messages,
+		ChangePasswordURL:          m.deps.ChangePasswordURL,
+		PageURL:                    pageURL,
+		MFAStatus:                  m.deps.MFAStatus,
+		MFABeginSetup:              m.deps.MFABeginSetup,
+		MFAConfirmSetup:            m.deps.MFAConfirmSetup,
+		MFADisable:                 m.deps.MFADisable,
+		MFARegenerateRecoveryCodes: m.deps.MFARegenerateRecoveryCodes,
+		ListPasskeys:               m.deps.ListPasskeys,
+		DeletePasskey:              m.deps.DeletePasskey,
+		PasskeyRegisterOptionsURL:  registerOptionsURL,
+		PasskeyRegisterURL:         registerURL,
+		ConsentHistory:             m.deps.ConsentHistory,
+		ListAccessTokens:           m.deps.ListAccessTokens,
+		IssueAccessToken:           m.deps.IssueAccessToken,
+		RevokeAccessToken:          m.deps.RevokeAccessToken,
+		AccessTokenScopes:          m.deps.AccessTokenScopes,
+		RequestDataExport:          m.deps.RequestDataExport,
+		ListDataExports:            m.deps.ListDataExports,
+		AccountDeletionStatus:      m.deps.AccountDeletionStatus,
+		ScheduleAccountDeletion:    m.deps.ScheduleAccountDeletion,
+		CancelAccountDeletion:      m.deps.CancelAccountDeletion,
+	}
+	r.GET(pageURL, accountdetail.NewView(detailDeps))
+	if m.deps.MFAStatus != nil {
+		r.POST(pageURL+accountdetail.TwoFactorConfirmPath, accountdetail.NewTwoFactorConfirmAction(detailDeps))
+		r.POST(pageURL+accountdetail.TwoFactorDisablePath, accountdetail.NewTwoFactorDisableAction(detailDeps))
+		r.POST(pageURL+accountdetail.TwoFactorRecoveryCodesPath, accountdetail.NewTwoFactorRecoveryCodesAction(detailDeps))
+	}
+	if m.deps.ListPasskeys != nil && m.deps.DeletePasskey != nil {
+		r.POST(pageURL+accountdetail.PasskeyDeletePath, accountdetail.NewPasskeyDeleteAction(detailDeps))
+	}
+	if m.deps.ListAccessTokens != nil && m.deps.IssueAccessToken != nil && m.deps.RevokeAccessToken != nil {
+		r.POST(pageURL+accountdetail.AccessTokenIssuePath, accountdetail.NewAccessTokenIssueAction(detailDeps))
+		r.POST(pageURL+accountdetail.AccessTokenRevokePath, accountdetail.NewAccessTokenRevokeAction(detailDeps))
+	}
+	if m.deps.RequestDataExport != nil && m.deps.ListDataExports != nil {
+		r.POST(pageURL+accountdetail.DataExportRequestPath, accountdetail.NewDataExportRequestAction(detailDeps))
+	}
+	if m.accountDeletionEnabled() {
+		r.POST(pageURL+accountdetail.AccountDeletePath, accountdetail.NewAccountDeleteAction(detailDeps))
+		r.POST(pageURL+accountdetail.AccountDeleteCancelPath, accountdetail.NewAccountDeleteCancelAction(detailDeps))
+	}
+}
+
+// SensitiveActions returns the ServeMux patterns of the actions that let a
+// stolen session take over or outlive the account, when wired: enrolling
+// or disabling two-step verification, issuing an access token, deleting
+// the account and the passkey registration endpoints. Pass them to the
+// auth module's Deps.StepUpActions so these need a recent sign-in.
+func (m *Module) SensitiveActions() []string {
+	var actions []string
+	if m.deps.MFAStatus != nil {
+		actions = append(actions,
+			http.MethodPost+" "+m.pageURL()+accountdetail.TwoFactorConfirmPath,
+			http.MethodPost+" "+m.pageURL()+accountdetail.TwoFactorDisablePath)
+	}
+	if m.deps.ListAccessTokens != nil && m.deps.IssueAccessToken != nil && m.deps.RevokeAccessToken != nil {
+		actions = append(actions, http.MethodPost+" "+m.pageURL()+accountdetail.AccessTokenIssuePath)
+	}
+	if m.accountDeletionEnabled() {
+		actions = append(actions, http.MethodPost+" "+m.pageURL()+accountdetail.AccountDeletePath)
+	}
+	if m.deps.ListPasskeys != nil {
+		registerOptionsURL, registerURL := m.passkeyRegisterURLs()
+		actions = append(actions, http.MethodPost+" "+registerOptionsURL, http.MethodPost+" "+registerURL)
+	}
+	return actions
+}
+
+// passkeyRegisterURLs returns the passkey registration endpoints, defaulting
+// to entydad's.
+func (m *Module) passkeyRegisterURLs() (optionsURL, registerURL string) {
+	optionsURL, registerURL = m.deps.PasskeyRegisterOptionsURL, m.deps.PasskeyRegisterURL
+	if optionsURL == "" {
+		optionsURL = entydad.AuthPasskeyRegisterOptionsURL
+	}
+	if registerURL == "" {
+		registerURL = entydad.AuthPasskeyRegisterURL
+	}
+	return optionsURL, registerURL
+}
+
+func (m *Module) accountDeletionEnabled() bool {
+	d := m.deps
+	return d.AccountDeletionStatus != nil && d.ScheduleAccountDeletion != nil && d.CancelAccountDeletion != nil
+}
+
+func (m *Module) pageURL() string {
+	if m.deps.PageURL == "" {
+		return "/app/account"
+	}
+	return m.deps.PageURL
 }
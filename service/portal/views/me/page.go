@@ -8,7 +8,9 @@
 // implicit-workspace prefix.
 //
 // v1 lock per phases.md 9b: /me/* is read-only. Cross-workspace actions
-// return the user to the originating /w/{ws}/* page for execution.
+// return the user to the originating /w/{ws}/* page for execution. The one
+// exception is /me/sessions, whose sign-out actions act on the user's own
+// sessions rather than on workspace data.
 //
 // Created 2026-05-22 in Phase P9b.
 package me
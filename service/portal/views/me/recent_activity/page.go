@@ -1,18 +1,27 @@
 // Package recent_activity is the /me/recent-activity view (Phase P9b).
 //
-// Lists the user's recent workspace switches — forensic surface required by
-// the security red-team (A-2). Each switch is one row of audit_entry where
-// `actor_user_id = current_user_id AND use_case LIKE 'switch_%'`, ordered by
-// most recent. Per phases.md 9b lock (a), this view is READ-ONLY; there are
-// no actions on /me/* in v1.
+// Lists the user's recent security events — forensic surface required by
+// the security red-team (A-2): sign-ins and refused sign-ins, sign-outs,
+// password resets and changes, two-step verification events and workspace
+// switches, each with the IP address and device it came from. Every event
+// is one row of audit_entry where `actor_user_id = current_user_id`: the
+// auth module's AuthEventSink writes the sign-in, password and two-step
+// rows (use_case = auth.AuthEventType), the principal switcher the
+// `switch_%` rows. Ordered by most recent, filterable by category and to
+// refused attempts only. Per phases.md 9b lock (a), this view is
+// READ-ONLY; there are no actions on /me/* in v1.
 //
-// The view consumes a ListRecentSwitchesFunc closure injected by the
+// The view consumes a ListRecentActivityFunc closure injected by the
 // composition layer. The closure encapsulates the audit-entry query so
 // entydad does not take a direct dependency on the postgres adapter.
 package recent_activity
 
 import (
 	"context"
+	"log"
+	"net/url"
+	"strings"
+	"time"
 
 	me "github.com/erniealice/entydad-golang/service/portal/views/me"
 	"github.com/erniealice/espyna-golang/shared/identity"
@@ -20,7 +29,66 @@ import (
 	"github.com/erniealice/pyeza-golang/view"
 )
 
-// SwitchEntry is one row in the recent-activity table.
+// ActivityEntry is one security event.
+type ActivityEntry struct {
+	OccurredAt time.Time
+	UseCase    string // audit use case, e.g. login_failure, switch_explicit_rotate
+	Method     string // sign-in method of login events, e.g. password, passkey
+	Reason     string // reason class of refused attempts, e.g. invalid_credentials
+	IP         string
+	UserAgent  string
+	RequestURL string // switches only
+}
+
+// Filter categories, the ?category= values.
+const (
+	CategoryAll      = ""
+	CategorySignIn   = "sign_in"
+	CategoryPassword = "password"
+	CategoryMFA      = "mfa"
+	CategorySwitch   = "switch"
+)
+
+// categoryPrefixes lists the use-case prefixes each category covers.
+var categoryPrefixes = map[string][]string{
+	CategorySignIn:   {"login_", "logout", "mfa_challenge_"},
+	CategoryPassword: {"password_"},
+	CategoryMFA:      {"mfa_"},
+	CategorySwitch:   {"switch_", "impersonate_"},
+}
+
+// ActivityFilter narrows the list. A closure can translate it into its
+// query or apply Matches to each row.
+type ActivityFilter struct {
+	Category     string
+	FailuresOnly bool // refused attempts (use cases ending in _failure)
+}
+
+// Matches reports whether an event with useCase passes the filter.
+func (f ActivityFilter) Matches(useCase string) bool {
+	if f.FailuresOnly && !strings.HasSuffix(useCase, "_failure") {
+		return false
+	}
+	prefixes, ok := categoryPrefixes[f.Category]
+	if !ok {
+		return true
+	}
+	for _, p := range prefixes {
+		if strings.HasPrefix(useCase, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListRecentActivityFunc looks up the most-recent security events of the
+// given user that match filter, newest first. Limit is the cap on rows
+// returned.
+type ListRecentActivityFunc func(ctx context.Context, userID string, filter ActivityFilter, limit int) ([]ActivityEntry, error)
+
+// SwitchEntry is one workspace switch row.
+//
+// Deprecated: switches are ActivityEntry rows of ListRecentActivity.
 type SwitchEntry struct {
 	OccurredAt string // ISO 8601 timestamp
 	UseCase    string // e.g. switch_url_rotate, switch_explicit
@@ -30,12 +98,45 @@ type SwitchEntry struct {
 
 // ListRecentSwitchesFunc looks up the most-recent N workspace switch rows
 // for the given user. Limit is the cap on rows returned.
+//
+// Deprecated: use ListRecentActivityFunc.
 type ListRecentSwitchesFunc func(ctx context.Context, userID string, limit int) ([]SwitchEntry, error)
 
 // ModuleDeps bundles per-request configuration for the view.
 type ModuleDeps struct {
 	Messages           map[string]string
+	ListRecentActivity ListRecentActivityFunc
+	// ListRecentSwitches is only consulted when ListRecentActivity is nil.
+	//
+	// Deprecated: wire ListRecentActivity.
 	ListRecentSwitches ListRecentSwitchesFunc
+	// PageURL is the route path of the page, for the filter links.
+	// Defaults to "/me/recent-activity".
+	PageURL string
+}
+
+func (d *ModuleDeps) pageURL() string {
+	if d.PageURL == "" {
+		return "/me/recent-activity"
+	}
+	return d.PageURL
+}
+
+// ActivityRow is an ActivityEntry formatted for the table.
+type ActivityRow struct {
+	OccurredAt string
+	Event      string
+	Detail     string
+	IP         string
+	UserAgent  string
+	Failure    bool
+}
+
+// FilterLink is one filter chip.
+type FilterLink struct {
+	Label    string
+	URL      string
+	Selected bool
 }
 
 // PageData carries rendering context for the /me/recent-activity page.
@@ -43,22 +144,43 @@ type PageData struct {
 	me.PageData
 	Subtitle     string
 	EmptyMessage string
-	Switches     []SwitchEntry
+	Rows         []ActivityRow
+	Filters      []FilterLink
+	FailuresOnly FilterLink
+
+	ColWhen   string
+	ColEvent  string
+	ColDetail string
+	ColIP     string
+	ColDevice string
 }
 
+const listLimit = 50
+
 // NewView constructs the /me/recent-activity view.
 func NewView(deps *ModuleDeps) view.View {
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
-		var switches []SwitchEntry
-		if deps != nil && deps.ListRecentSwitches != nil {
-			if id, ok := identity.FromContext(ctx); ok {
-				if rows, err := deps.ListRecentSwitches(ctx, id.UserID, 50); err == nil {
-					switches = rows
-				}
+		msg := func(key, fallback string) string {
+			return me.Msg(deps.Messages, key, fallback)
+		}
+
+		var filter ActivityFilter
+		if viewCtx.Request != nil {
+			q := viewCtx.Request.URL.Query()
+			if _, ok := categoryPrefixes[q.Get("category")]; ok {
+				filter.Category = q.Get("category")
+			}
+			filter.FailuresOnly = q.Get("failures") == "1"
+		}
+
+		var rows []ActivityRow
+		if id, ok := identity.FromContext(ctx); ok && id != nil {
+			for _, e := range listActivity(ctx, deps, id.UserID, filter) {
+				rows = append(rows, activityRow(msg, e))
 			}
 		}
 
-		title := me.Msg(deps.Messages, "me.recentActivity.title", "Recent Activity")
+		title := msg("me.recentActivity.title", "Recent Activity")
 		pd := &PageData{
 			PageData: me.PageData{
 				PageData: types.PageData{
@@ -72,10 +194,173 @@ func NewView(deps *ModuleDeps) view.View {
 					Messages:        deps.Messages,
 				},
 			},
-			Subtitle:     me.Msg(deps.Messages, "me.recentActivity.subtitle", "Your recent workspace switches across all sessions."),
-			EmptyMessage: me.Msg(deps.Messages, "me.recentActivity.empty", "No recent workspace switches."),
-			Switches:     switches,
+			Subtitle:     msg("me.recentActivity.subtitle", "Sign-ins, password and two-step verification changes and workspace switches on your account."),
+			EmptyMessage: msg("me.recentActivity.empty", "No recent activity."),
+			Rows:         rows,
+
+			ColWhen:   msg("me.recentActivity.colWhen", "When"),
+			ColEvent:  msg("me.recentActivity.colEvent", "Event"),
+			ColDetail: msg("me.recentActivity.colDetail", "Details"),
+			ColIP:     msg("me.recentActivity.colIp", "IP address"),
+			ColDevice: msg("me.recentActivity.colDevice", "Device"),
 		}
+		pd.Filters, pd.FailuresOnly = filterLinks(deps, msg, filter)
 		return view.OK("me-page", pd)
 	})
 }
+
+// listActivity runs the wired closure; lookup failures render as an empty
+// list.
+func listActivity(ctx context.Context, deps *ModuleDeps, userID string, filter ActivityFilter) []ActivityEntry {
+	if deps.ListRecentActivity != nil {
+		entries, err := deps.ListRecentActivity(ctx, userID, filter, listLimit)
+		if err != nil {
+			log.Printf("Failed to list recent activity for user %s: %v", userID, err)
+			return nil
+		}
+		return entries
+	}
+	if deps.ListRecentSwitches == nil || !filter.Matches("switch_") {
+		return nil
+	}
+	switches, err := deps.ListRecentSwitches(ctx, userID, listLimit)
+	if err != nil {
+		log.Printf("Failed to list recent switches for user %s: %v", userID, err)
+		return nil
+	}
+	entries := make([]ActivityEntry, 0, len(switches))
+	for _, s := range switches {
+		at, _ := time.Parse(time.RFC3339, s.OccurredAt)
+		entries = append(entries, ActivityEntry{OccurredAt: at, UseCase: s.UseCase, RequestURL: s.RequestURL})
+	}
+	return entries
+}
+
+// eventFallbacks are the English event names, keyed by use case.
+var eventFallbacks = map[string]string{
+	"login_success":                  "Signed in",
+	"login_failure":                  "Sign-in refused",
+	"logout":                         "Signed out",
+	"password_reset_requested":       "Password reset requested",
+	"password_reset_completed":       "Password reset",
+	"password_changed":               "Password changed",
+	"mfa_challenge_success":          "Two-step code accepted",
+	"mfa_challenge_failure":          "Two-step code refused",
+	"mfa_enabled":                    "Two-step verification turned on",
+	"mfa_disabled":                   "Two-step verification turned off",
+	"mfa_recovery_codes_regenerated": "Recovery codes replaced",
+	"impersonate_start":              "Administrator started viewing as you",
+	"impersonate_stop":               "Administrator stopped viewing as you",
+	"impersonate_timeout":            "Administrator view as you timed out",
+	"session_expired":                "Session expired",
+	"data_export_requested":          "Data export requested",
+	"account_deletion_scheduled":     "Account deletion scheduled",
+	"account_deletion_cancelled":     "Account deletion cancelled",
+}
+
+// methodFallbacks and reasonFallbacks are the English detail texts.
+var (
+	methodFallbacks = map[string]string{
+		"password":   "Password",
+		"firebase":   "Single sign-on",
+		"magic_link": "Email link",
+		"passkey":    "Passkey",
+		"oidc":       "Single sign-on",
+		"invitation": "Invitation",
+		"relogin":    "Re-login",
+	}
+	reasonFallbacks = map[string]string{
+		"invalid_credentials": "Wrong email or password",
+		"invalid_code":        "Wrong code",
+		"throttled":           "Too many attempts",
+		"locked":              "Account temporarily locked",
+		"unverified_email":    "Email address not verified",
+		"no_account":          "No account for this email",
+		"method_not_allowed":  "Sign-in method not allowed",
+		"sso_required":        "Organization requires single sign-on",
+		"idle_timeout":        "Inactive too long",
+		"absolute_timeout":    "Maximum session length reached",
+	}
+)
+
+func activityRow(msg func(key, fallback string) string, e ActivityEntry) ActivityRow {
+	event := eventFallbacks[e.UseCase]
+	switch {
+	case event != "":
+		event = msg("me.recentActivity.event."+e.UseCase, event)
+	case strings.HasPrefix(e.UseCase, "switch_"):
+		event = msg("me.recentActivity.event.switch", "Switched workspace")
+	default:
+		event = e.UseCase
+	}
+
+	var details []string
+	if e.Method != "" {
+		details = append(details, msg("me.recentActivity.method."+e.Method, or(methodFallbacks[e.Method], e.Method)))
+	}
+	if e.Reason != "" {
+		details = append(details, msg("me.recentActivity.reason."+e.Reason, or(reasonFallbacks[e.Reason], e.Reason)))
+	}
+	if e.RequestURL != "" {
+		details = append(details, e.RequestURL)
+	}
+
+	at := ""
+	if !e.OccurredAt.IsZero() {
+		at = e.OccurredAt.Format("2006-01-02 15:04")
+	}
+	return ActivityRow{
+		OccurredAt: at,
+		Event:      event,
+		Detail:     strings.Join(details, " · "),
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		Failure:    strings.HasSuffix(e.UseCase, "_failure"),
+	}
+}
+
+// filterLinks builds the category chips (keeping the failures toggle) and
+// the failures toggle (keeping the category).
+func filterLinks(deps *ModuleDeps, msg func(key, fallback string) string, f ActivityFilter) ([]FilterLink, FilterLink) {
+	link := func(category string, failures bool) string {
+		q := url.Values{}
+		if category != "" {
+			q.Set("category", category)
+		}
+		if failures {
+			q.Set("failures", "1")
+		}
+		if len(q) == 0 {
+			return deps.pageURL()
+		}
+		return deps.pageURL() + "?" + q.Encode()
+	}
+	categories := []struct{ value, key, fallback string }{
+		{CategoryAll, "me.recentActivity.filterAll", "All"},
+		{CategorySignIn, "me.recentActivity.filterSignIn", "Sign-ins"},
+		{CategoryPassword, "me.recentActivity.filterPassword", "Password"},
+		{CategoryMFA, "me.recentActivity.filterMfa", "Two-step verification"},
+		{CategorySwitch, "me.recentActivity.filterSwitch", "Workspace switches"},
+	}
+	links := make([]FilterLink, 0, len(categories))
+	for _, c := range categories {
+		links = append(links, FilterLink{
+			Label:    msg(c.key, c.fallback),
+			URL:      link(c.value, f.FailuresOnly),
+			Selected: f.Category == c.value,
+		})
+	}
+	failures := FilterLink{
+		Label:    msg("me.recentActivity.filterFailures", "Refused attempts only"),
+		URL:      link(f.Category, !f.FailuresOnly),
+		Selected: f.FailuresOnly,
+	}
+	return links, failures
+}
+
+func or(v, fallback string) string {
+	if v == "" {
+		return fallback
+	}
+	return v
+}
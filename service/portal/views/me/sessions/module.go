@@ -0,0 +1,24 @@
+package sessions
+
+import "github.com/erniealice/pyeza-golang/view"
+
+// Module wires the /me/sessions routes.
+type Module struct {
+	deps *ModuleDeps
+}
+
+// NewModule creates a new sessions module.
+func NewModule(deps *ModuleDeps) *Module {
+	return &Module{deps: deps}
+}
+
+// RegisterRoutes registers the GET handler for the page and, when both
+// closures are wired, the two sign-out actions.
+func (m *Module) RegisterRoutes(r view.RouteRegistrar) {
+	pageURL := m.deps.pageURL()
+	r.GET(pageURL, NewView(m.deps))
+	if m.deps.ListSessions != nil && m.deps.RevokeSession != nil {
+		r.POST(pageURL+RevokePath, NewRevokeAction(m.deps))
+		r.POST(pageURL+RevokeOthersPath, NewRevokeOthersAction(m.deps))
+	}
+}
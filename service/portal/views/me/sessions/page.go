@@ -0,0 +1,336 @@
+// Package sessions is the /me/sessions view.
+//
+// Lists the user's active sessions across every device — user agent, IP,
+// when each was created and last seen, and the principal it is currently
+// acting as — and lets the user sign any other session out, one at a time or
+// all at once. The session serving the request is marked and cannot be
+// revoked here; the regular sign-out covers it. Nothing can be revoked
+// from an impersonated session: the sessions are the target's, not the
+// administrator's.
+//
+// The view consumes a ListSessionsFunc / RevokeSessionFunc closure pair
+// injected by the composition layer, so entydad does not take a direct
+// dependency on the session store or the audit adapter.
+package sessions
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"time"
+
+	me "github.com/erniealice/entydad-golang/service/portal/views/me"
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/types"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// SessionEntry is one row in the sessions table.
+type SessionEntry struct {
+	// ID is an opaque handle for RevokeSession — never the session token.
+	ID         string
+	UserAgent  string
+	IP         string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// Principal describes who the session is acting as (e.g. "Acme · Owner");
+	// empty before a principal has been picked.
+	Principal string
+	// Current marks the session serving this request.
+	Current bool
+}
+
+// ListSessionsFunc returns the user's active (not expired, not invalidated)
+// sessions. currentToken is the session cookie of the request, so the
+// closure can mark the matching entry Current.
+type ListSessionsFunc func(ctx context.Context, userID, currentToken string) ([]SessionEntry, error)
+
+// RevokeSessionFunc ends sessions with AuthAdapter.InvalidateSession
+// semantics: the session is dead for every subsequent request, wherever it
+// is presented. It must only touch sessions owned by in.UserID, and must
+// write one audit row per call with in.UseCase (actor in.UserID).
+type RevokeSessionFunc func(ctx context.Context, in RevokeSessionInput) error
+
+// Audit use cases passed in RevokeSessionInput.UseCase.
+const (
+	UseCaseRevoke       = "session_revoke"
+	UseCaseRevokeOthers = "session_revoke_others"
+)
+
+// RevokeSessionInput names the sessions to end.
+type RevokeSessionInput struct {
+	UserID string
+	// SessionID is the SessionEntry.ID to end with UseCaseRevoke; empty with
+	// UseCaseRevokeOthers, which ends every session but CurrentToken's.
+	SessionID    string
+	CurrentToken string
+	UseCase      string
+	// Request context for the audit row.
+	IP        string
+	UserAgent string
+}
+
+// ErrSessionNotFound is returned by RevokeSessionFunc when the session does
+// not exist, has already ended or belongs to another user.
+var ErrSessionNotFound = errors.New("sessions: session not found")
+
+// POST paths, relative to the page URL.
+const (
+	RevokePath       = "/revoke"
+	RevokeOthersPath = "/revoke-others"
+)
+
+// ModuleDeps bundles per-request configuration for the view.
+type ModuleDeps struct {
+	Messages      map[string]string
+	ListSessions  ListSessionsFunc
+	RevokeSession RevokeSessionFunc
+	// Impersonating reports whether the request runs in an impersonated
+	// session (auth.AuthModule.Impersonating); both actions refuse then and
+	// the page hides them. Nil when impersonation is not configured.
+	Impersonating func(ctx context.Context) bool
+	// PageURL is the route path of the page. Defaults to "/me/sessions".
+	PageURL string
+}
+
+func (d *ModuleDeps) impersonating(ctx context.Context) bool {
+	return d.Impersonating != nil && d.Impersonating(ctx)
+}
+
+func (d *ModuleDeps) pageURL() string {
+	if d.PageURL == "" {
+		return "/me/sessions"
+	}
+	return d.PageURL
+}
+
+// SessionRow is a SessionEntry formatted for the table.
+type SessionRow struct {
+	ID         string
+	UserAgent  string
+	IP         string
+	CreatedAt  string
+	LastSeenAt string
+	Principal  string
+	Current    bool
+}
+
+// PageData carries rendering context for the /me/sessions page.
+type PageData struct {
+	me.PageData
+	Subtitle     string
+	EmptyMessage string
+	Notice       string
+	ErrorMessage string
+	Sessions     []SessionRow
+	HasOthers    bool
+
+	RevokeURL       string
+	RevokeOthersURL string
+
+	ColDevice       string
+	ColIP           string
+	ColCreated      string
+	ColLastSeen     string
+	ColPrincipal    string
+	CurrentBadge    string
+	NoPrincipal     string
+	RevokeLabel     string
+	RevokeOthersBtn string
+}
+
+// NewView constructs the /me/sessions view. ?revoked=1 / ?revoked=others
+// and ?error=… carry the outcome of the POST actions back to the page.
+func NewView(deps *ModuleDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		msg := func(key, fallback string) string { return me.Msg(deps.Messages, key, fallback) }
+
+		var rows []SessionRow
+		hasOthers := false
+		errorMessage := ""
+		if id, ok := identity.FromContext(ctx); ok && id != nil && deps.ListSessions != nil {
+			entries, err := deps.ListSessions(ctx, id.UserID, id.SessionToken)
+			if err != nil {
+				log.Printf("Failed to list sessions for user %s: %v", id.UserID, err)
+				errorMessage = msg("me.sessions.errorUnavailable", "Your sessions could not be loaded. Try again later.")
+			}
+			for _, e := range entries {
+				rows = append(rows, SessionRow{
+					ID:         e.ID,
+					UserAgent:  e.UserAgent,
+					IP:         e.IP,
+					CreatedAt:  formatTime(e.CreatedAt),
+					LastSeenAt: formatTime(e.LastSeenAt),
+					Principal:  e.Principal,
+					Current:    e.Current,
+				})
+				if !e.Current {
+					hasOthers = true
+				}
+			}
+		}
+
+		notice := ""
+		if viewCtx.Request != nil {
+			q := viewCtx.Request.URL.Query()
+			switch q.Get("revoked") {
+			case "1":
+				notice = msg("me.sessions.revoked", "The session was signed out.")
+			case "others":
+				notice = msg("me.sessions.revokedOthers", "All other sessions were signed out.")
+			}
+			switch q.Get("error") {
+			case "not_found":
+				errorMessage = msg("me.sessions.errorNotFound", "That session has already ended.")
+			case "current":
+				errorMessage = msg("me.sessions.errorCurrent", "Use Sign out to end the session you are using.")
+			case "unavailable":
+				errorMessage = msg("me.sessions.errorRevoke", "The session could not be signed out. Try again later.")
+			case "impersonating":
+				errorMessage = msg("me.sessions.errorImpersonating", "Sessions cannot be signed out while viewing as another user.")
+			}
+		}
+
+		pageURL := deps.pageURL()
+		canRevoke := deps.RevokeSession != nil && !deps.impersonating(ctx)
+		title := msg("me.sessions.title", "Sessions")
+		pd := &PageData{
+			PageData: me.PageData{
+				PageData: types.PageData{
+					CacheVersion:    viewCtx.CacheVersion,
+					Title:           title,
+					CurrentPath:     viewCtx.CurrentPath,
+					ActiveNav:       "sessions",
+					ContentTemplate: "me-sessions-content",
+					HeaderTitle:     title,
+					HeaderIcon:      "icon-shield",
+					Messages:        deps.Messages,
+				},
+			},
+			Subtitle:     msg("me.sessions.subtitle", "Devices signed in to your account. Sign out any you do not recognise."),
+			EmptyMessage: msg("me.sessions.empty", "No active sessions."),
+			Notice:       notice,
+			ErrorMessage: errorMessage,
+			Sessions:     rows,
+			HasOthers:    hasOthers && canRevoke,
+
+			RevokeURL:       pageURL + RevokePath,
+			RevokeOthersURL: pageURL + RevokeOthersPath,
+
+			ColDevice:       msg("me.sessions.colDevice", "Device"),
+			ColIP:           msg("me.sessions.colIp", "IP address"),
+			ColCreated:      msg("me.sessions.colCreated", "Signed in"),
+			ColLastSeen:     msg("me.sessions.colLastSeen", "Last active"),
+			ColPrincipal:    msg("me.sessions.colPrincipal", "Acting as"),
+			CurrentBadge:    msg("me.sessions.current", "This device"),
+			NoPrincipal:     msg("me.sessions.noPrincipal", "—"),
+			RevokeLabel:     msg("me.sessions.revoke", "Sign out"),
+			RevokeOthersBtn: msg("me.sessions.revokeOthers", "Sign out everywhere else"),
+		}
+		if !canRevoke {
+			pd.RevokeURL, pd.RevokeOthersURL = "", ""
+		}
+		return view.OK("me-page", pd)
+	})
+}
+
+// NewRevokeAction signs out one of the user's other sessions, then
+// redirects back to the page.
+func NewRevokeAction(deps *ModuleDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		id, ok := identity.FromContext(ctx)
+		if !ok || id == nil || id.UserID == "" {
+			return view.Forbidden("session:revoke")
+		}
+		pageURL := deps.pageURL()
+		if deps.impersonating(ctx) {
+			return view.Redirect(pageURL + "?error=impersonating")
+		}
+		if deps.ListSessions == nil || deps.RevokeSession == nil {
+			return view.Redirect(pageURL + "?error=unavailable")
+		}
+		if err := viewCtx.Request.ParseForm(); err != nil {
+			return view.HTMXError(viewCtx.T("shared.errors.invalidFormData"))
+		}
+		sessionID := viewCtx.Request.FormValue("session_id")
+
+		// Only a listed, non-current session of this user can be revoked;
+		// the closure re-checks ownership.
+		entries, err := deps.ListSessions(ctx, id.UserID, id.SessionToken)
+		if err != nil {
+			log.Printf("Failed to list sessions for user %s: %v", id.UserID, err)
+			return view.Redirect(pageURL + "?error=unavailable")
+		}
+		found := false
+		for _, e := range entries {
+			if e.ID != sessionID || sessionID == "" {
+				continue
+			}
+			if e.Current {
+				return view.Redirect(pageURL + "?error=current")
+			}
+			found = true
+		}
+		if !found {
+			return view.Redirect(pageURL + "?error=not_found")
+		}
+
+		in := revokeInput(viewCtx, id, UseCaseRevoke)
+		in.SessionID = sessionID
+		if err := deps.RevokeSession(ctx, in); err != nil {
+			if errors.Is(err, ErrSessionNotFound) {
+				return view.Redirect(pageURL + "?error=not_found")
+			}
+			log.Printf("Failed to revoke session for user %s: %v", id.UserID, err)
+			return view.Redirect(pageURL + "?error=unavailable")
+		}
+		return view.Redirect(pageURL + "?revoked=1")
+	})
+}
+
+// NewRevokeOthersAction signs out every session of the user except the one
+// making the request, then redirects back to the page.
+func NewRevokeOthersAction(deps *ModuleDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		id, ok := identity.FromContext(ctx)
+		if !ok || id == nil || id.UserID == "" {
+			return view.Forbidden("session:revoke")
+		}
+		pageURL := deps.pageURL()
+		if deps.impersonating(ctx) {
+			return view.Redirect(pageURL + "?error=impersonating")
+		}
+		// Without the current token "others" would include this session.
+		if id.SessionToken == "" || deps.RevokeSession == nil {
+			return view.Redirect(pageURL + "?error=unavailable")
+		}
+		if err := deps.RevokeSession(ctx, revokeInput(viewCtx, id, UseCaseRevokeOthers)); err != nil {
+			log.Printf("Failed to revoke other sessions for user %s: %v", id.UserID, err)
+			return view.Redirect(pageURL + "?error=unavailable")
+		}
+		return view.Redirect(pageURL + "?revoked=others")
+	})
+}
+
+func revokeInput(viewCtx *view.ViewContext, id *identity.RequestIdentity, useCase string) RevokeSessionInput {
+	r := viewCtx.Request
+	ip := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		ip = host
+	}
+	return RevokeSessionInput{
+		UserID:       id.UserID,
+		CurrentToken: id.SessionToken,
+		UseCase:      useCase,
+		IP:           ip,
+		UserAgent:    r.UserAgent(),
+	}
+}
+
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04")
+}
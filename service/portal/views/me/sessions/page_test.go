@@ -0,0 +1,160 @@
+package sessions
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// fakeSession is one session in fakeStore.
+type fakeSession struct {
+	user, token string
+}
+
+// fakeStore keeps sessions by ID and honours the RevokeSessionFunc
+// contract: only the caller's own sessions are touched.
+type fakeStore struct {
+	sessions map[string]fakeSession
+	revokes  []RevokeSessionInput
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{sessions: map[string]fakeSession{
+		"s-ana-laptop": {user: "ana", token: "tok-ana-laptop"},
+		"s-ana-phone":  {user: "ana", token: "tok-ana-phone"},
+		"s-ana-tablet": {user: "ana", token: "tok-ana-tablet"},
+		"s-bo-laptop":  {user: "bo", token: "tok-bo-laptop"},
+	}}
+}
+
+func (s *fakeStore) list(_ context.Context, userID, currentToken string) ([]SessionEntry, error) {
+	var out []SessionEntry
+	for id, sess := range s.sessions {
+		if sess.user == userID {
+			out = append(out, SessionEntry{ID: id, Current: sess.token == currentToken})
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) revoke(_ context.Context, in RevokeSessionInput) error {
+	s.revokes = append(s.revokes, in)
+	if in.UseCase == UseCaseRevoke {
+		if sess, ok := s.sessions[in.SessionID]; !ok || sess.user != in.UserID {
+			return ErrSessionNotFound
+		}
+		delete(s.sessions, in.SessionID)
+		return nil
+	}
+	for id, sess := range s.sessions {
+		if sess.user == in.UserID && sess.token != in.CurrentToken {
+			delete(s.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) ids() []string {
+	var out []string
+	for id := range s.sessions {
+		out = append(out, id)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func (s *fakeStore) deps() *ModuleDeps {
+	return &ModuleDeps{ListSessions: s.list, RevokeSession: s.revoke}
+}
+
+func handle(v view.View, form url.Values) view.ViewResult {
+	req := httptest.NewRequest(http.MethodPost, "/me/sessions/revoke", strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	ctx := identity.WithRequestIdentity(context.Background(), &identity.RequestIdentity{UserID: "ana", SessionToken: "tok-ana-laptop"})
+	return v.Handle(ctx, &view.ViewContext{Request: req})
+}
+
+func TestRevokeAction(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name         string
+		sessionID    string
+		wantRedirect string
+		wantIDs      string
+	}{
+		{"own other session", "s-ana-phone", "/me/sessions?revoked=1", "s-ana-laptop s-ana-tablet s-bo-laptop"},
+		{"another user's session", "s-bo-laptop", "/me/sessions?error=not_found", "s-ana-laptop s-ana-phone s-ana-tablet s-bo-laptop"},
+		{"current session", "s-ana-laptop", "/me/sessions?error=current", "s-ana-laptop s-ana-phone s-ana-tablet s-bo-laptop"},
+		{"unknown session", "s-nope", "/me/sessions?error=not_found", "s-ana-laptop s-ana-phone s-ana-tablet s-bo-laptop"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			store := newFakeStore()
+			res := handle(NewRevokeAction(store.deps()), url.Values{"session_id": {tt.sessionID}})
+			if res.Redirect != tt.wantRedirect {
+				t.Fatalf("Redirect = %q, want %q", res.Redirect, tt.wantRedirect)
+			}
+			if got := strings.Join(store.ids(), " "); got != tt.wantIDs {
+				t.Fatalf("sessions left = %q, want %q", got, tt.wantIDs)
+			}
+		})
+	}
+}
+
+func TestRevokeOthersAction_KeepsCurrentSession(t *testing.T) {
+	t.Parallel()
+	store := newFakeStore()
+	res := handle(NewRevokeOthersAction(store.deps()), nil)
+	if res.Redirect != "/me/sessions?revoked=others" {
+		t.Fatalf("Redirect = %q", res.Redirect)
+	}
+	if got := strings.Join(store.ids(), " "); got != "s-ana-laptop s-bo-laptop" {
+		t.Fatalf("sessions left = %q, want the current one and the other user's", got)
+	}
+	if len(store.revokes) != 1 || store.revokes[0].CurrentToken != "tok-ana-laptop" || store.revokes[0].SessionID != "" {
+		t.Fatalf("revoke input = %+v", store.revokes)
+	}
+}
+
+func TestRevokeActions_Refused(t *testing.T) {
+	t.Parallel()
+	form := url.Values{"session_id": {"s-ana-phone"}}
+
+	// A missing store answers "unavailable" instead of panicking.
+	for name, deps := range map[string]*ModuleDeps{
+		"no store":      {},
+		"no list":       {RevokeSession: newFakeStore().revoke},
+		"no revocation": {ListSessions: newFakeStore().list},
+	} {
+		if res := handle(NewRevokeAction(deps), form); res.Redirect != "/me/sessions?error=unavailable" {
+			t.Errorf("%s: revoke Redirect = %q", name, res.Redirect)
+		}
+		if deps.RevokeSession == nil {
+			if res := handle(NewRevokeOthersAction(deps), nil); res.Redirect != "/me/sessions?error=unavailable" {
+				t.Errorf("%s: revoke-others Redirect = %q", name, res.Redirect)
+			}
+		}
+	}
+
+	// An impersonator cannot sign the target's sessions out.
+	store := newFakeStore()
+	deps := store.deps()
+	deps.Impersonating = func(context.Context) bool { return true }
+	if res := handle(NewRevokeAction(deps), form); res.Redirect != "/me/sessions?error=impersonating" {
+		t.Errorf("impersonating: revoke Redirect = %q", res.Redirect)
+	}
+	if res := handle(NewRevokeOthersAction(deps), nil); res.Redirect != "/me/sessions?error=impersonating" {
+		t.Errorf("impersonating: revoke-others Redirect = %q", res.Redirect)
+	}
+	if len(store.revokes) != 0 {
+		t.Fatalf("revocations while impersonating: %+v", store.revokes)
+	}
+}
@@ -14,6 +14,11 @@ type ModuleDeps struct {
 	// PageURL is the base URL for the preferences page used to build tab hrefs.
 	// Defaults to "/app/preferences" when empty for backward compatibility.
 	PageURL string
+
+	// Default principal closures (see sign_in.go). DefaultPrincipal nil ⇒
+	// the sign_in tab is hidden.
+	DefaultPrincipal      DefaultPrincipal
+	ClearDefaultPrincipal ClearDefaultPrincipal
 }
 
 // PageData carries the rendering context for the preference page.
@@ -21,9 +26,10 @@ type PageData struct {
 	types.PageData
 	TabItems  []pyeza.TabItem
 	ActiveTab string
+	SignIn    *SignInData // nil unless the sign_in tab is active
 }
 
-// NewView creates the preference detail view (full page — tabs: appearance | notifications | language-region).
+// NewView creates the preference detail view (full page — tabs: appearance | notifications | language-region | sign_in).
 func NewView(deps *ModuleDeps) view.View {
 	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
 		perms := view.GetUserPermissions(ctx)
@@ -35,41 +41,59 @@ func NewView(deps *ModuleDeps) view.View {
 		if viewCtx.Request != nil {
 			activeTab = viewCtx.Request.URL.Query().Get("tab")
 		}
-		tabs := buildTabs(deps.Messages, deps.PageURL)
-		if activeTab == "" || !validTab(tabs, activeTab) {
-			activeTab = "appearance"
-		}
-
-		titleKey := "memberPages.section.preferences.title"
-		iconKey := "memberPages.section.preferences.icon"
 
-		pageData := &PageData{
-			PageData: types.PageData{
-				CacheVersion:    viewCtx.CacheVersion,
-				Title:           lookup(deps.Messages, titleKey, "Preferences"),
-				CurrentPath:     viewCtx.CurrentPath,
-				ActiveNav:       "home",
-				ContentTemplate: "preferences-page-content",
-				HeaderTitle:     lookup(deps.Messages, titleKey, "Preferences"),
-				HeaderIcon:      lookup(deps.Messages, iconKey, "icon-settings"),
-				Messages:        deps.Messages,
-			},
-			TabItems:  tabs,
-			ActiveTab: activeTab,
+		tab := &PageData{}
+		if activeTab == "sign_in" && deps.DefaultPrincipal != nil {
+			tab.SignIn = loadSignIn(ctx, deps)
 		}
-		return view.OK("preferences-page", pageData)
+		return renderPage(viewCtx, deps, activeTab, tab)
 	})
 }
 
-func buildTabs(messages map[string]string, pageURL string) []pyeza.TabItem {
+// renderPage assembles the preferences page for the GET view and for the
+// tab actions, which re-render it in place. tab carries the active tab's
+// state.
+func renderPage(viewCtx *view.ViewContext, deps *ModuleDeps, activeTab string, tab *PageData) view.ViewResult {
+	tabs := buildTabs(deps)
+	if activeTab == "" || !validTab(tabs, activeTab) {
+		activeTab = "appearance"
+	}
+
+	titleKey := "memberPages.section.preferences.title"
+	iconKey := "memberPages.section.preferences.icon"
+
+	pageData := &PageData{
+		PageData: types.PageData{
+			CacheVersion:    viewCtx.CacheVersion,
+			Title:           lookup(deps.Messages, titleKey, "Preferences"),
+			CurrentPath:     viewCtx.CurrentPath,
+			ActiveNav:       "home",
+			ContentTemplate: "preferences-page-content",
+			HeaderTitle:     lookup(deps.Messages, titleKey, "Preferences"),
+			HeaderIcon:      lookup(deps.Messages, iconKey, "icon-settings"),
+			Messages:        deps.Messages,
+		},
+		TabItems:  tabs,
+		ActiveTab: activeTab,
+		SignIn:    tab.SignIn,
+	}
+	return view.OK("preferences-page", pageData)
+}
+
+func buildTabs(deps *ModuleDeps) []pyeza.TabItem {
+	messages, pageURL := deps.Messages, deps.PageURL
 	if pageURL == "" {
 		pageURL = "/app/preferences"
 	}
-	return []pyeza.TabItem{
+	tabs := []pyeza.TabItem{
 		{Key: "appearance", Label: lookup(messages, "memberPages.preferences.tab.appearance", "Appearance"), Href: pageURL + "?tab=appearance"},
 		{Key: "notifications", Label: lookup(messages, "memberPages.preferences.tab.notifications", "Notifications"), Href: pageURL + "?tab=notifications"},
 		{Key: "language-region", Label: lookup(messages, "memberPages.preferences.tab.languageRegion", "Language & region"), Href: pageURL + "?tab=language-region"},
 	}
+	if deps.DefaultPrincipal != nil {
+		tabs = append(tabs, pyeza.TabItem{Key: "sign_in", Label: lookup(messages, "memberPages.preferences.tab.signIn", "Sign-in"), Href: pageURL + "?tab=sign_in"})
+	}
+	return tabs
 }
 
 func validTab(tabs []pyeza.TabItem, key string) bool {
@@ -0,0 +1,94 @@
+package detail
+
+import (
+	"context"
+	"errors"
+	"log"
+
+	"github.com/erniealice/entydad-golang/service/auth"
+	"github.com/erniealice/espyna-golang/shared/identity"
+	"github.com/erniealice/pyeza-golang/view"
+)
+
+// Default principal closures, satisfied by auth.AuthModule.DefaultPrincipal
+// and auth.AuthModule.ClearDefaultPrincipal.
+type (
+	DefaultPrincipal      func(ctx context.Context, userID string) (*auth.DefaultPrincipalSummary, error)
+	ClearDefaultPrincipal func(ctx context.Context, userID string) error
+)
+
+// DefaultPrincipalClearPath is the sign_in tab's POST path, relative to the
+// preferences page URL.
+const DefaultPrincipalClearPath = "/default-principal/clear"
+
+// SignInData is the sign_in tab state: the principal sign-in lands in
+// without the workspace role chooser, if the user picked one there.
+type SignInData struct {
+	Default  *auth.DefaultPrincipalSummary // nil when none is remembered
+	SetAt    string
+	ErrorKey string // translation key, rendered via .T
+	Cleared  bool
+
+	ClearURL string
+}
+
+func loadSignIn(ctx context.Context, deps *ModuleDeps) *SignInData {
+	pageURL := deps.PageURL
+	if pageURL == "" {
+		pageURL = "/app/preferences"
+	}
+	data := &SignInData{}
+	if deps.ClearDefaultPrincipal != nil {
+		data.ClearURL = pageURL + DefaultPrincipalClearPath
+	}
+	userID := currentUser(ctx)
+	d, err := deps.DefaultPrincipal(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to load default principal for user %s: %v", userID, err)
+		data.ErrorKey = "memberPages.preferences.signIn.errorUnavailable"
+		return data
+	}
+	data.Default = d
+	if d != nil && !d.SetAt.IsZero() {
+		data.SetAt = d.SetAt.Format("2006-01-02 15:04")
+	}
+	return data
+}
+
+// NewDefaultPrincipalClearAction forgets the signed-in user's default
+// principal, so the next sign-in shows the chooser again, and re-renders the
+// sign_in tab.
+func NewDefaultPrincipalClearAction(deps *ModuleDeps) view.View {
+	return view.ViewFunc(func(ctx context.Context, viewCtx *view.ViewContext) view.ViewResult {
+		perms := view.GetUserPermissions(ctx)
+		if !perms.Can("user", "update") {
+			return view.Forbidden("user:update")
+		}
+		userID := currentUser(ctx)
+		if userID == "" {
+			return view.Forbidden("user:update")
+		}
+		errorKey := ""
+		if err := deps.ClearDefaultPrincipal(ctx, userID); err != nil {
+			if errors.Is(err, auth.ErrImpersonating) {
+				errorKey = "memberPages.preferences.signIn.errorImpersonating"
+			} else {
+				log.Printf("Failed to clear default principal for user %s: %v", userID, err)
+				errorKey = "memberPages.preferences.signIn.errorUnavailable"
+			}
+		}
+		data := loadSignIn(ctx, deps)
+		if data.ErrorKey == "" {
+			data.ErrorKey = errorKey
+		}
+		data.Cleared = errorKey == ""
+		return renderPage(viewCtx, deps, "sign_in", &PageData{SignIn: data})
+	})
+}
+
+func currentUser(ctx context.Context) string {
+	if id, ok := identity.FromContext(ctx); ok && id != nil {
+		return id.UserID
+	}
+	return ""
+}
@@ -1,5 +1,5 @@
 // Package preference provides the /app/preferences page — UI preferences
-// (tabs: appearance | notifications | language-region). Part of the four
+// (tabs: appearance | notifications | language-region | sign_in). Part of the four
 // "personal scope" pages accessible from the sidebar bottom profile popover.
 //
 // Note: package name is singular (preference) per entydad noun convention;
@@ -19,6 +19,12 @@ type ModuleDeps struct {
 	// PageURL is the route path for the preferences page (e.g. "/app/preferences").
 	// Defaults to "/app/preferences" when empty for backward compatibility.
 	PageURL string
+
+	// Remembered default principal. Wired from authModule.DefaultPrincipal
+	// and ClearDefaultPrincipal. DefaultPrincipal nil ⇒ the sign_in tab is
+	// hidden and its POST route is not mounted.
+	DefaultPrincipal      preferencedetail.DefaultPrincipal
+	ClearDefaultPrincipal preferencedetail.ClearDefaultPrincipal
 }
 
 // Module wires the preference route.
@@ -31,14 +37,21 @@ func NewModule(deps *ModuleDeps) *Module {
 	return &Module{deps: deps}
 }
 
-// RegisterRoutes registers the GET handler for the preferences page.
+// RegisterRoutes registers the GET handler for the preferences page and,
+// when the default principal is wired, its clear action.
 func (m *Module) RegisterRoutes(r view.RouteRegistrar) {
 	pageURL := m.deps.PageURL
 	if pageURL == "" {
 		pageURL = "/app/preferences"
 	}
-	r.GET(pageURL, preferencedetail.NewView(&preferencedetail.ModuleDeps{
-		Messages: m.deps.Messages,
-		PageURL:  pageURL,
-	}))
+	detailDeps := &preferencedetail.ModuleDeps{
+		Messages:              m.deps.Messages,
+		PageURL:               pageURL,
+		DefaultPrincipal:      m.deps.DefaultPrincipal,
+		ClearDefaultPrincipal: m.deps.ClearDefaultPrincipal,
+	}
+	r.GET(pageURL, preferencedetail.NewView(detailDeps))
+	if m.deps.DefaultPrincipal != nil && m.deps.ClearDefaultPrincipal != nil {
+		r.POST(pageURL+preferencedetail.DefaultPrincipalClearPath, preferencedetail.NewDefaultPrincipalClearAction(detailDeps))
+	}
 }